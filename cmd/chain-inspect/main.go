@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"secop-blockchain/pkg/blockchain"
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// chain-inspect permite revisar la cadena sin pasar por la API HTTP, útil para
+// respuesta a incidentes cuando el nodo está caído. Lee directamente la base de
+// datos del nodo (solo lectura, con el nodo detenido) o un volcado JSON.
+//
+// Uso:
+//   chain-inspect -db data/DNP-NODE.db list
+//   chain-inspect -db data/DNP-NODE.db block <altura|hash>
+//   chain-inspect -db data/DNP-NODE.db tx <altura|hash>
+//   chain-inspect -file chain.json contract <id>
+//   chain-inspect -db data/DNP-NODE.db hashing
+
+var (
+	dbPath    = flag.String("db", "", "base de datos BoltDB del nodo (se abre en solo lectura)")
+	chainFile = flag.String("file", "chain.json", "archivo con la cadena (respuesta de /api/p2p/get-chain), si no se usa -db")
+	format    = flag.String("format", "pretty", "formato de salida: pretty o json")
+)
+
+// contractStates guarda el estado de los contratos leído de la base de datos, si existe
+var contractStates = map[string]json.RawMessage{}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	var chain []blockchain.Block
+	var err error
+	if *dbPath != "" {
+		chain, err = loadChainFromDB(*dbPath)
+	} else {
+		chain, err = loadChain(*chainFile)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error abriendo la cadena: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch flag.Arg(0) {
+	case "list":
+		err = listBlocks(chain)
+	case "block":
+		err = withBlock(chain, flag.Arg(1), printBlock)
+	case "tx":
+		err = withBlock(chain, flag.Arg(1), printTransaction)
+	case "contract":
+		err = printContract(chain, flag.Arg(1))
+	case "hashing":
+		err = printHashing(chain)
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Uso: chain-inspect [opciones] <list|block|tx|contract|hashing> [altura|hash|id]\n\n")
+	flag.PrintDefaults()
+}
+
+// loadChain lee la cadena desde un archivo JSON ({"chain": [...]} o un arreglo de bloques)
+func loadChain(path string) ([]blockchain.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapped struct {
+		Chain []blockchain.Block `json:"chain"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err == nil && wrapped.Chain != nil {
+		return wrapped.Chain, nil
+	}
+
+	var blocks []blockchain.Block
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return nil, fmt.Errorf("formato de cadena no reconocido: %v", err)
+	}
+	return blocks, nil
+}
+
+// loadChainFromDB lee los bloques y el estado de contratos desde la base de datos del nodo
+func loadChainFromDB(path string) ([]blockchain.Block, error) {
+	store, err := storage.OpenBoltReadOnly(path)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	var chain []blockchain.Block
+	err = store.Blocks(func(height int, data []byte) error {
+		var block blockchain.Block
+		if err := json.Unmarshal(data, &block); err != nil {
+			return fmt.Errorf("bloque %d corrupto: %v", height, err)
+		}
+		chain = append(chain, block)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.ForEach(storage.BucketContracts, func(key string, value []byte) error {
+		contractStates[key] = json.RawMessage(value)
+		return nil
+	})
+	return chain, err
+}
+
+// findBlock busca un bloque por altura o por hash
+func findBlock(chain []blockchain.Block, ref string) (*blockchain.Block, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("se requiere altura o hash del bloque")
+	}
+	if height, err := strconv.Atoi(ref); err == nil {
+		if height < 0 || height >= len(chain) {
+			return nil, fmt.Errorf("altura %d fuera de rango (0-%d)", height, len(chain)-1)
+		}
+		return &chain[height], nil
+	}
+	for i := range chain {
+		if chain[i].Hash == ref {
+			return &chain[i], nil
+		}
+	}
+	return nil, fmt.Errorf("bloque %s no encontrado", ref)
+}
+
+func withBlock(chain []blockchain.Block, ref string, print func(*blockchain.Block) error) error {
+	block, err := findBlock(chain, ref)
+	if err != nil {
+		return err
+	}
+	return print(block)
+}
+
+func listBlocks(chain []blockchain.Block) error {
+	if *format == "json" {
+		type summary struct {
+			Index int    `json:"index"`
+			Type  string `json:"type"`
+			Hash  string `json:"hash"`
+		}
+		summaries := make([]summary, len(chain))
+		for i, block := range chain {
+			summaries[i] = summary{Index: block.Index, Type: block.Type, Hash: block.Hash}
+		}
+		return printJSON(summaries)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ALTURA\tTIPO\tFECHA\tHASH")
+	for _, block := range chain {
+		blockType := block.Type
+		if blockType == "" {
+			blockType = "GENESIS"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", block.Index, blockType, block.Timestamp.Format("2006-01-02 15:04:05"), block.Hash)
+	}
+	return w.Flush()
+}
+
+func printBlock(block *blockchain.Block) error {
+	if *format == "json" {
+		return printJSON(block)
+	}
+
+	fmt.Printf("Bloque #%d\n", block.Index)
+	fmt.Printf("  Tipo:          %s\n", block.Type)
+	fmt.Printf("  Fecha:         %s\n", block.Timestamp.Format("2006-01-02 15:04:05 MST"))
+	fmt.Printf("  Hash:          %s\n", block.Hash)
+	fmt.Printf("  Hash previo:   %s\n", block.PreviousHash)
+	fmt.Printf("  Nonce:         %d\n", block.Nonce)
+	fmt.Printf("  Hash válido:   %v\n", block.IsValid())
+	return printTransaction(block)
+}
+
+func printTransaction(block *blockchain.Block) error {
+	if *format == "json" {
+		return printJSON(block.Data)
+	}
+
+	keys := make([]string, 0, len(block.Data))
+	for key := range block.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Printf("  Transacción:\n")
+	for _, key := range keys {
+		fmt.Printf("    %-16s %v\n", key+":", block.Data[key])
+	}
+	return nil
+}
+
+// printContract reconstruye el historial de un contrato a partir de los bloques que lo referencian
+func printContract(chain []blockchain.Block, contractID string) error {
+	if contractID == "" {
+		return fmt.Errorf("se requiere el ID del contrato")
+	}
+
+	var history []blockchain.Block
+	for _, block := range chain {
+		for _, event := range block.Events() {
+			if event.ContractID == contractID {
+				history = append(history, block)
+				break
+			}
+		}
+	}
+	if len(history) == 0 {
+		return fmt.Errorf("contrato %s no encontrado en la cadena", contractID)
+	}
+
+	state := contractStates[contractID]
+
+	if *format == "json" {
+		return printJSON(map[string]interface{}{
+			"contract_id": contractID,
+			"state":       state,
+			"events":      history,
+		})
+	}
+
+	fmt.Printf("Contrato %s (%d eventos)\n", contractID, len(history))
+	if state != nil {
+		var contract blockchain.Contract
+		if err := json.Unmarshal(state, &contract); err == nil {
+			fmt.Printf("  Entidad:       %s (%s)\n", contract.EntityName, contract.EntityCode)
+			fmt.Printf("  Estado:        %s (paso %d de %d)\n", contract.Status, contract.CurrentStep, len(contract.ValidationSteps))
+			fmt.Printf("  Monto:         %.2f\n", contract.Amount)
+			fmt.Printf("  Descripción:   %s\n", contract.Description)
+		}
+	}
+	for _, block := range history {
+		fmt.Printf("\n#%d %s %s\n", block.Index, block.Type, block.Timestamp.Format("2006-01-02 15:04:05"))
+		if err := printTransaction(&block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printHashing resume la migración al hash canónico: cuántos bloques usan cada versión,
+// desde qué altura la cadena es canónica y qué bloques no verifican su hash
+func printHashing(chain []blockchain.Block) error {
+	versions := map[int]int{}
+	firstCanonical := -1
+	legacyAfterCanonical := []int{}
+	invalid := []int{}
+	for _, block := range chain {
+		versions[block.HashVersion]++
+		if block.HashVersion == blockchain.HashVersionCanonical && firstCanonical < 0 {
+			firstCanonical = block.Index
+		}
+		if block.HashVersion == blockchain.HashVersionLegacy && firstCanonical >= 0 {
+			legacyAfterCanonical = append(legacyAfterCanonical, block.Index)
+		}
+		if !block.Pruned && !block.IsValid() {
+			invalid = append(invalid, block.Index)
+		}
+	}
+
+	if *format == "json" {
+		return printJSON(map[string]interface{}{
+			"blocks":                 len(chain),
+			"versions":               versions,
+			"first_canonical":        firstCanonical,
+			"legacy_after_canonical": legacyAfterCanonical,
+			"invalid":                invalid,
+		})
+	}
+
+	fmt.Printf("Bloques:              %d\n", len(chain))
+	fmt.Printf("Hash legado (JSON):   %d\n", versions[blockchain.HashVersionLegacy])
+	fmt.Printf("Hash canónico (CBOR): %d\n", versions[blockchain.HashVersionCanonical])
+	if firstCanonical >= 0 {
+		fmt.Printf("Canónica desde:       altura %d\n", firstCanonical)
+	} else {
+		fmt.Printf("Canónica desde:       (sin bloques canónicos)\n")
+	}
+	if len(legacyAfterCanonical) > 0 {
+		fmt.Printf("⚠️ Bloques legados después del primero canónico: %v\n", legacyAfterCanonical)
+	}
+	if len(invalid) > 0 {
+		fmt.Printf("❌ Bloques cuyo hash no verifica: %v\n", invalid)
+	}
+	return nil
+}
+
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Zona horaria por defecto del mapa de calor: la hora legal de Colombia, sin horario de verano
+var colombiaTime = time.FixedZone("America/Bogota", -5*60*60)
+
+// getValidationActivity retorna el mapa de calor de las decisiones de validación con las
+// señales de actividad sospechosa. Acepta bucket=hour|day, group_by=role|entity, from y to
+// en formato 2006-01-02, entity_code, role y tz (nombre IANA, por defecto America/Bogota).
+func getValidationActivity(c *gin.Context) {
+	query := blockchain.ActivityQuery{
+		Bucket:     c.DefaultQuery("bucket", blockchain.ActivityBucketHour),
+		GroupBy:    c.DefaultQuery("group_by", blockchain.ActivityGroupRole),
+		EntityCode: c.Query("entity_code"),
+		Role:       blockchain.AdminRole(c.Query("role")),
+		Location:   colombiaTime,
+	}
+
+	if tz := c.Query("tz"); tz != "" {
+		location, err := time.LoadLocation(tz)
+		if err != nil {
+			respondErrorMessage(c, http.StatusBadRequest, fmt.Sprintf("zona horaria inválida: %s", tz))
+			return
+		}
+		query.Location = location
+	}
+
+	for param, target := range map[string]**time.Time{"from": &query.From, "to": &query.To} {
+		if value := c.Query(param); value != "" {
+			date, err := time.ParseInLocation("2006-01-02", value, query.Location)
+			if err != nil {
+				respondErrorMessage(c, http.StatusBadRequest, fmt.Sprintf("fecha inválida en %s: %s", param, value))
+				return
+			}
+			if param == "to" {
+				date = date.Add(24*time.Hour - time.Nanosecond)
+			}
+			*target = &date
+		}
+	}
+
+	report, err := bc.ActivityHeatmap(query)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de alertas de los entes de control
+
+// getAlertRules lista las reglas del ente de control de la sesión
+func getAlertRules(c *gin.Context) {
+	rules := alertManager.GetRules(currentUser(c).Role)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(rules),
+		"data":    rules,
+	})
+}
+
+// createAlertRuleRequest es el cuerpo de POST /api/alerts/rules
+type createAlertRuleRequest struct {
+	Name         string  `json:"name" binding:"required"`
+	Kind         string  `json:"kind" binding:"required"`
+	Threshold    float64 `json:"threshold" binding:"required"`
+	ContractType string  `json:"contract_type"`
+	EntityCode   string  `json:"entity_code"`
+	CallbackURL  string  `json:"callback_url"`
+}
+
+// createAlertRule crea la regla a nombre del ente de control de la sesión
+func createAlertRule(c *gin.Context) {
+	var req createAlertRuleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user := currentUser(c)
+	rule, err := alertManager.AddRule(blockchain.AlertRule{
+		Name:         req.Name,
+		Kind:         req.Kind,
+		Threshold:    req.Threshold,
+		ContractType: req.ContractType,
+		EntityCode:   req.EntityCode,
+		CallbackURL:  req.CallbackURL,
+		CreatedBy:    user.Subject,
+		Role:         user.Role,
+	})
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"rule":    rule,
+	})
+}
+
+// deleteAlertRule elimina la regla si la creó el ente de control de la sesión
+func deleteAlertRule(c *gin.Context) {
+	if err := alertManager.RemoveRule(c.Param("id"), currentUser(c).Role); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// getAlerts lista las alertas generadas para el ente de control de la sesión
+func getAlerts(c *gin.Context) {
+	alerts := alertManager.GetAlerts(currentUser(c).Role)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(alerts),
+		"data":    alerts,
+	})
+}
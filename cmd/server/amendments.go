@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de las adiciones y prórrogas de los contratos
+
+// requestAmendmentRequest es el cuerpo de POST /api/contracts/:id/amendments
+type requestAmendmentRequest struct {
+	AdditionalAmount float64    `json:"additional_amount" binding:"gte=0,lte=1000000000000000"`
+	NewEndDate       *time.Time `json:"new_end_date"`
+	Justification    string     `json:"justification" binding:"required"`
+}
+
+// requestAmendment radica una adición, una prórroga o ambas; queda pendiente del flujo
+// abreviado de aprobación
+func requestAmendment(c *gin.Context) {
+	contractID := c.Param("id")
+
+	var req requestAmendmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, ok := checkContractEntity(c, contractID); !ok {
+		return
+	}
+	user := currentUser(c)
+
+	amendment, err := amendmentManager.Request(contractID, req.AdditionalAmount, req.NewEndDate, req.Justification, user.Subject, user.Role)
+	if err != nil {
+		respondAmendmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":   true,
+		"amendment": amendment,
+	})
+}
+
+// getContractAmendments es público: lista las modificaciones del contrato y su valor
+// acumulado
+func getContractAmendments(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	amendments := append([]blockchain.Amendment{}, contract.Amendments...)
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"contract_id":   contract.ID,
+		"amount":        contract.Amount,
+		"amended_value": contract.AmendedValue,
+		"total_value":   contract.TotalValue(),
+		"amendable":     contract.Amount*blockchain.MaxAmendedFraction - contract.AmendedValue,
+		"end_date":      contract.EndDate,
+		"amendments":    amendments,
+	})
+}
+
+// decideAmendmentRequest es el cuerpo de POST /api/contracts/:id/amendments/:aid/decision
+type decideAmendmentRequest struct {
+	Approved *bool  `json:"approved" binding:"required"`
+	Comments string `json:"comments"`
+}
+
+// decideAmendment registra la decisión del rol sobre el paso pendiente de la modificación
+func decideAmendment(c *gin.Context) {
+	contractID := c.Param("id")
+
+	var req decideAmendmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	contract, ok := checkContractEntity(c, contractID)
+	if !ok {
+		return
+	}
+	if contract.Amendment(c.Param("aid")) == nil {
+		respondErrorMessage(c, http.StatusNotFound, "modificación no encontrada")
+		return
+	}
+	user := currentUser(c)
+
+	amendment, err := amendmentManager.Decide(contractID, c.Param("aid"), *req.Approved, req.Comments, user.Subject, user.Role)
+	if err != nil {
+		respondAmendmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"amendment": amendment,
+	})
+}
+
+// respondAmendmentError responde 409 con las operaciones en curso si el contrato está
+// bloqueado por otra; los demás errores son de la petición
+func respondAmendmentError(c *gin.Context, err error) {
+	var conflict *blockchain.OperationConflictError
+	if errors.As(err, &conflict) {
+		body := errorBody(c, http.StatusConflict, conflict.Error())
+		body["in_flight"] = conflict.InFlight
+		c.AbortWithStatusJSON(http.StatusConflict, body)
+		return
+	}
+	respondError(c, http.StatusBadRequest, err)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de administración de llaves de API para integradores externos
+
+func getAPIKeys(c *gin.Context) {
+	keys := apiKeys.List()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(keys),
+		"data":    keys,
+	})
+}
+
+// createAPIKeyRequest es el cuerpo de POST /api/admin/apikeys
+type createAPIKeyRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Scope         string `json:"scope" binding:"required"`
+	EntityCode    string `json:"entity_code"`
+	ExpiresInDays int    `json:"expires_in_days"`
+}
+
+func createAPIKey(c *gin.Context) {
+	var req createAPIKeyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	key, secret, err := apiKeys.Create(req.Name, req.Scope, req.EntityCode, currentUser(c).Subject, ttl)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// El secreto solo se entrega en esta respuesta
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"key":     key,
+		"secret":  secret,
+	})
+}
+
+func getAPIKey(c *gin.Context) {
+	key, err := apiKeys.Get(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"key":     key,
+	})
+}
+
+// updateAPIKeyRequest es el cuerpo de PUT /api/admin/apikeys/:id
+type updateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func updateAPIKey(c *gin.Context) {
+	var req updateAPIKeyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	key, err := apiKeys.Update(c.Param("id"), req.Name)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"key":     key,
+	})
+}
+
+func revokeAPIKey(c *gin.Context) {
+	if err := apiKeys.Revoke(c.Param("id")); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers del archivo de bloques antiguos
+
+// archivePolicyFromEnv lee la política de archivo; con ARCHIVE_KEEP_RECENT vacío o en 0 queda desactivado
+func archivePolicyFromEnv(nodeID string) (blockchain.ArchivePolicy, bool) {
+	keepRecent, err := strconv.Atoi(getEnv("ARCHIVE_KEEP_RECENT", "0"))
+	if err != nil || keepRecent <= 0 {
+		return blockchain.ArchivePolicy{}, false
+	}
+	segmentSize, err := strconv.Atoi(getEnv("ARCHIVE_SEGMENT_SIZE", "1000"))
+	if err != nil || segmentSize <= 0 {
+		segmentSize = 1000
+	}
+
+	return blockchain.ArchivePolicy{
+		Dir:         getEnv("ARCHIVE_DIR", "./data/"+nodeID+"-archive"),
+		KeepRecent:  keepRecent,
+		SegmentSize: segmentSize,
+	}, true
+}
+
+func getArchiveStatus(c *gin.Context) {
+	status := bc.ArchiveStatus()
+	if status == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": true,
+		"archive": status,
+	})
+}
+
+func runArchive(c *gin.Context) {
+	archived, err := bc.ArchiveOldBlocks()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"archived": archived,
+		"archive":  bc.ArchiveStatus(),
+	})
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de documentos adjuntos con ventanas de visibilidad
+
+func uploadAttachment(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		respondErrorMessage(c, http.StatusBadRequest, "archivo requerido")
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, blockchain.MaxAttachmentSize+1))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	mediaType := header.Header.Get("Content-Type")
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType = http.DetectContentType(content)
+	}
+
+	visibility := blockchain.AttachmentVisibility{
+		VisibleFromStatus: blockchain.ContractStatus(c.PostForm("visible_from_status")),
+	}
+	if value := c.PostForm("visible_after"); value != "" {
+		visibleAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondErrorMessage(c, http.StatusBadRequest, "visible_after debe estar en formato RFC3339")
+			return
+		}
+		visibility.VisibleAfter = &visibleAfter
+	}
+
+	user := currentUser(c)
+	attachment, err := attachmentStore.Upload(
+		c.Param("id"),
+		user.Subject,
+		user.Role,
+		c.PostForm("category"),
+		header.Filename,
+		mediaType,
+		c.PostForm("description"),
+		visibility,
+		c.PostForm("no_index") == "true",
+		content,
+	)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":    true,
+		"attachment": attachment,
+	})
+}
+
+// getAttachments es público. Los adjuntos aún no publicables se listan solo con su hash
+// anclado y sus condiciones, para que cualquiera pueda verificar después que no cambiaron.
+func getAttachments(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	earlyAccess := hasEarlyAttachmentAccess(c)
+	now := time.Now()
+	attachments := attachmentStore.List(contract.ID)
+	data := make([]gin.H, 0, len(attachments))
+	for _, attachment := range attachments {
+		if earlyAccess || attachment.Visibility.IsDue(contract, now) {
+			data = append(data, gin.H{
+				"attachment": attachment,
+				"public":     attachment.Visibility.IsDue(contract, now),
+				"url":        "/api/contracts/" + contract.ID + "/attachments/" + attachment.ID + "/file",
+			})
+			continue
+		}
+		data = append(data, gin.H{
+			"attachment": gin.H{
+				"id":          attachment.ID,
+				"contract_id": attachment.ContractID,
+				"category":    attachment.Category,
+				"sha256":      attachment.SHA256,
+				"visibility":  attachment.Visibility,
+				"uploaded_at": attachment.UploadedAt,
+				"block_hash":  attachment.BlockHash,
+			},
+			"public": false,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"contract_id": contract.ID,
+		"status":      contract.Status,
+		"count":       len(data),
+		"data":        data,
+	})
+}
+
+func getAttachmentFile(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	attachment, content, err := attachmentStore.Open(contract.ID, c.Param("aid"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+	if !hasEarlyAttachmentAccess(c) && !attachment.Visibility.IsDue(contract, time.Now()) {
+		body := errorBody(c, http.StatusForbidden, "el documento aún no es público")
+		body["visibility"] = attachment.Visibility
+		c.JSON(http.StatusForbidden, body)
+		return
+	}
+
+	c.Header("X-Content-SHA256", attachment.SHA256)
+	c.Header("X-Block-Hash", attachment.BlockHash)
+	c.Data(http.StatusOK, attachment.MediaType, content)
+}
+
+// setAttachmentIndexingRequest es el cuerpo de PUT /api/contracts/:id/attachments/:aid/indexing
+type setAttachmentIndexingRequest struct {
+	NoIndex *bool `json:"no_index" binding:"required"`
+}
+
+// setAttachmentIndexing incluye o excluye un adjunto de la búsqueda por contenido, p. ej.
+// cuando el proceso se declara reservado después de cargarlo
+func setAttachmentIndexing(c *gin.Context) {
+	var req setAttachmentIndexingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	attachment, err := attachmentStore.SetIndexing(c.Param("id"), c.Param("aid"), *req.NoIndex)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"attachment": attachment,
+		"indexed":    attachmentStore.Index.IsIndexed(attachment.ID),
+	})
+}
+
+// hasEarlyAttachmentAccess indica si la sesión puede ver adjuntos antes de que sean públicos
+func hasEarlyAttachmentAccess(c *gin.Context) bool {
+	user := sessionUser(c)
+	if user == nil {
+		return false
+	}
+	for _, role := range attachmentEarlyAccessRoles {
+		if user.Role == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"secop-blockchain/internal/auth"
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Handlers y middleware de autenticación de funcionarios
+
+// Llave del contexto de Gin donde queda la identidad autenticada
+const authClaimsKey = "auth_claims"
+
+// Políticas de roles por ruta
+var (
+	// Quienes pueden radicar contratos nuevos
+	contractCreatorRoles = []blockchain.AdminRole{blockchain.RoleProjectDeveloper}
+	// Roles internos que participan en el flujo de validación
+	workflowRoles = []blockchain.AdminRole{
+		blockchain.RoleProjectDeveloper,
+		blockchain.RoleTechnicalCommission,
+		blockchain.RoleLegalCommission,
+		blockchain.RoleContractsChief,
+		blockchain.RoleAdminChief,
+		blockchain.RoleBudgetAuthority,
+	}
+	// Quienes pueden registrar observaciones de auditoría
+	auditorRoles = []blockchain.AdminRole{blockchain.RoleComptroller}
+	// Quienes configuran las plantillas de aprobación automática del flujo
+	workflowTemplateRoles = []blockchain.AdminRole{blockchain.RoleAdminChief}
+	// Quienes administran las llaves de API de los integradores
+	apiKeyAdminRoles = []blockchain.AdminRole{blockchain.RoleAdminChief}
+	// Quienes proponen cambios al conjunto de validadores del consenso
+	consensusAdminRoles = []blockchain.AdminRole{blockchain.RoleAdminChief}
+	// Quienes fuerzan un checkpoint en el nodo autoridad
+	checkpointAdminRoles = []blockchain.AdminRole{blockchain.RoleAdminChief}
+	// Quienes agregan peers y emiten tokens de ingreso a la red
+	peerAdminRoles = []blockchain.AdminRole{blockchain.RoleAdminChief}
+	// Quienes cargan documentos adjuntos a los procesos
+	attachmentUploaderRoles = workflowRoles
+	// Quienes ven los adjuntos antes de que se cumplan sus condiciones de publicación
+	attachmentEarlyAccessRoles = append(append([]blockchain.AdminRole{}, workflowRoles...), blockchain.RoleComptroller, blockchain.RoleProsecutor)
+	// Entes de control que consultan las declaraciones de conflicto de interés
+	conflictReviewerRoles = []blockchain.AdminRole{blockchain.RoleComptroller, blockchain.RoleProsecutor}
+	// Equipos de analítica de los entes de control que descargan las exportaciones Parquet
+	exportRoles = []blockchain.AdminRole{blockchain.RoleComptroller, blockchain.RoleProsecutor}
+	// Analistas de los entes de control que consultan los grupos de contratos similares
+	clusterAnalystRoles = []blockchain.AdminRole{blockchain.RoleComptroller, blockchain.RoleProsecutor}
+	// Entes de control que consultan el mapa de calor de la actividad de validación
+	activityAnalystRoles = []blockchain.AdminRole{blockchain.RoleComptroller, blockchain.RoleProsecutor}
+	// Quienes consultan los informes de consecutivos de números de proceso
+	processNumberAuditRoles = []blockchain.AdminRole{blockchain.RoleAdminChief, blockchain.RoleComptroller, blockchain.RoleProsecutor}
+	// Quienes fijan las metas de gestión contractual de las entidades (el DNP)
+	kpiTargetRoles = []blockchain.AdminRole{blockchain.RoleAdminChief}
+	// Quienes consultan el cumplimiento de las metas por entidad
+	complianceAnalystRoles = []blockchain.AdminRole{blockchain.RoleAdminChief, blockchain.RoleComptroller, blockchain.RoleProsecutor}
+	// Quienes operan el nodo: modo de mantenimiento, llave de firma, archivo y respaldos.
+	// Se exigen con authorizeNode, que además deja por fuera a las sesiones de una entidad
+	nodeAdminRoles = []blockchain.AdminRole{blockchain.RoleAdminChief}
+	// Quienes consultan el tráfico rechazado por cliente y levantan frenos
+	trafficAdminRoles = []blockchain.AdminRole{blockchain.RoleAdminChief}
+	// Quienes registran los webhooks de los sistemas externos
+	webhookAdminRoles = []blockchain.AdminRole{blockchain.RoleAdminChief}
+	// Quienes consultan el contenido de los contratos reservados y su registro de accesos
+	reservedReaderRoles = []blockchain.AdminRole{blockchain.RoleAdminChief, blockchain.RoleComptroller, blockchain.RoleProsecutor}
+	// Quienes registran pagos y constituyen los saldos que pasan de vigencia (el ordenador del gasto)
+	treasuryRoles = []blockchain.AdminRole{blockchain.RoleBudgetAuthority}
+	// Quienes solicitan adiciones y prórrogas de los contratos
+	amendmentRequesterRoles = []blockchain.AdminRole{blockchain.RoleProjectDeveloper, blockchain.RoleContractsChief, blockchain.RoleSupervisor}
+	// Quienes deciden los pasos del flujo abreviado de las modificaciones
+	amendmentReviewerRoles = blockchain.AmendmentApprovalRoles
+	// Quienes planean los hitos de ejecución de los contratos
+	milestonePlannerRoles = []blockchain.AdminRole{blockchain.RoleProjectDeveloper, blockchain.RoleContractsChief}
+	// Quienes certifican el cumplimiento de los hitos (la supervisión del contrato)
+	milestoneSupervisorRoles = []blockchain.AdminRole{blockchain.RoleSupervisor}
+	// Administradores de la entidad: registran sus sistemas externos y operan sus borradores
+	entityAdminRoles = []blockchain.AdminRole{blockchain.RoleAdminChief}
+	// Oficina de contratación de la entidad: publica el proceso, responde las observaciones y adjudica
+	contractOfficeRoles = []blockchain.AdminRole{blockchain.RoleContractsChief, blockchain.RoleAdminChief}
+	// Entes de control que sancionan a los proveedores
+	sanctionRoles = []blockchain.AdminRole{blockchain.RoleComptroller, blockchain.RoleProsecutor}
+	// Quienes cargan las evidencias de ejecución de los contratos (la supervisión)
+	evidenceUploaderRoles = []blockchain.AdminRole{blockchain.RoleSupervisor}
+	// Entes de control que configuran las reglas de alerta
+	alertRuleRoles = []blockchain.AdminRole{blockchain.RoleComptroller, blockchain.RoleProsecutor}
+	// Quienes cierran la vigencia fiscal
+	fiscalClosingRoles = []blockchain.AdminRole{blockchain.RoleAdminChief, blockchain.RoleBudgetAuthority}
+	// Quienes consultan los informes de cierre de vigencia
+	fiscalClosingReaderRoles = []blockchain.AdminRole{blockchain.RoleAdminChief, blockchain.RoleBudgetAuthority, blockchain.RoleComptroller, blockchain.RoleProsecutor}
+)
+
+// Encabezado con el que los integradores externos presentan su llave de API
+const apiKeyHeader = "X-API-Key"
+
+// sessionTTLFromEnv lee la duración de las sesiones emitidas por el nodo
+func sessionTTLFromEnv() time.Duration {
+	minutes, err := strconv.Atoi(getEnv("AUTH_TOKEN_TTL_MINUTES", "480"))
+	if err != nil || minutes <= 0 {
+		minutes = 480
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// authRequired rechaza las peticiones sin un token de sesión válido en
+// Authorization: Bearer y deja la identidad en el contexto para el handler.
+// Las llaves de API (X-API-Key) solo se aceptan si su alcance está entre scopes.
+func authRequired(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, status, err := authenticate(c.Request.Header, scopes...)
+		if err != nil {
+			respondError(c, status, err)
+			return
+		}
+
+		c.Set(authClaimsKey, claims)
+		c.Next()
+	}
+}
+
+// authenticate valida las credenciales de los encabezados (los de REST o los metadatos de
+// gRPC) y retorna la identidad, o el status HTTP con el que se rechazan
+func authenticate(header http.Header, scopes ...string) (*auth.Claims, int, error) {
+	if secret := header.Get(apiKeyHeader); secret != "" {
+		claims, err := apiKeys.Authenticate(secret)
+		if err != nil {
+			return nil, http.StatusUnauthorized, err
+		}
+
+		for _, scope := range scopes {
+			if claims.Scope == scope {
+				return claims, http.StatusOK, nil
+			}
+		}
+		return nil, http.StatusForbidden, errors.New("la llave de API con alcance " + claims.Scope + " no está autorizada para esta operación")
+	}
+
+	authorization := header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return nil, http.StatusUnauthorized, errors.New("autenticación requerida")
+	}
+
+	claims, err := authIssuer.Verify(strings.TrimPrefix(authorization, "Bearer "))
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+	return claims, http.StatusOK, nil
+}
+
+// authorize permite la ruta solo a los roles indicados, tomando el rol de la sesión.
+// Si el cuerpo de la petición trae un campo "role" distinto al de la sesión se rechaza,
+// para que nadie actúe con un rol que no tiene. Debe ir después de authRequired.
+func authorize(roles ...blockchain.AdminRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := currentUser(c)
+
+		if !hasRole(user, roles...) {
+			respondErrorMessage(c, http.StatusForbidden, "el rol "+string(user.Role)+" no está autorizado para esta operación")
+			return
+		}
+
+		if c.Request.Body != nil {
+			body, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				respondError(c, http.StatusBadRequest, err)
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+			var claimed struct {
+				Role string `json:"role"`
+			}
+			if json.Unmarshal(body, &claimed) == nil && claimed.Role != "" && blockchain.AdminRole(claimed.Role) != user.Role {
+				respondErrorMessage(c, http.StatusForbidden, "el rol enviado no corresponde a la sesión")
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// authorizeNode restringe la ruta a quienes operan el nodo: un rol de nodeAdminRoles sin
+// entidad. El jefe administrativo de una entidad administra su entidad, no el nodo que
+// comparten todas.
+func authorizeNode() gin.HandlerFunc {
+	byRole := authorize(nodeAdminRoles...)
+	return func(c *gin.Context) {
+		if user := currentUser(c); user.EntityCode != "" {
+			respondErrorMessage(c, http.StatusForbidden, "solo los operadores del nodo pueden hacer esta operación; la sesión pertenece a la entidad "+user.EntityCode)
+			return
+		}
+		byRole(c)
+	}
+}
+
+// hasRole indica si el rol de la sesión está entre los permitidos
+func hasRole(user *auth.Claims, roles ...blockchain.AdminRole) bool {
+	for _, role := range roles {
+		if user.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// optionalAuth autentica la sesión si la petición trae credenciales y deja pasar como
+// anónimas las que no, para rutas públicas que muestran más a los funcionarios
+func optionalAuth(scopes ...string) gin.HandlerFunc {
+	required := authRequired(scopes...)
+	return func(c *gin.Context) {
+		if c.GetHeader(apiKeyHeader) == "" && c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		required(c)
+	}
+}
+
+// currentUser retorna la identidad autenticada por authRequired
+func currentUser(c *gin.Context) *auth.Claims {
+	return c.MustGet(authClaimsKey).(*auth.Claims)
+}
+
+// requireContractEntity responde 404 si el contrato no existe y 403 si la sesión no
+// pertenece a la entidad dueña del contrato; los funcionarios sin entidad no operan
+// sobre procesos ajenos
+func requireContractEntity(c *gin.Context, contractID string) (*blockchain.Contract, bool) {
+	contract, exists := bc.Contract(contractID)
+	if !exists {
+		respondErrorMessage(c, http.StatusNotFound, "contrato no encontrado")
+		return nil, false
+	}
+	if user := currentUser(c); user.EntityCode != contract.EntityCode {
+		respondErrorMessage(c, http.StatusForbidden, "la sesión no pertenece a la entidad "+contract.EntityCode)
+		return nil, false
+	}
+	return contract, true
+}
+
+// entityAllowed indica si la sesión puede operar sobre la entidad: las llaves de API de una
+// entidad quedan limitadas a ella y las sesiones sin entidad no tienen ese límite
+func entityAllowed(user *auth.Claims, entityCode string) bool {
+	return user.EntityCode == "" || user.EntityCode == entityCode
+}
+
+// entityScopeMessage explica el rechazo de una sesión limitada a otra entidad
+func entityScopeMessage(user *auth.Claims) string {
+	return "la llave de API solo puede operar sobre la entidad " + user.EntityCode
+}
+
+// checkContractEntity responde 404 si el contrato no existe y 403 si la sesión está
+// limitada a otra entidad; a diferencia de requireContractEntity, deja operar a los
+// funcionarios sin entidad
+func checkContractEntity(c *gin.Context, contractID string) (*blockchain.Contract, bool) {
+	contract, exists := bc.Contract(contractID)
+	if !exists {
+		respondErrorMessage(c, http.StatusNotFound, "contrato no encontrado")
+		return nil, false
+	}
+	if user := currentUser(c); !entityAllowed(user, contract.EntityCode) {
+		respondErrorMessage(c, http.StatusForbidden, entityScopeMessage(user))
+		return nil, false
+	}
+	return contract, true
+}
+
+// validCreator indica si la identidad de la sesión puede quedar como creadora de un
+// contrato, que created_by exige en forma de correo: los funcionarios inician sesión con
+// su correo y las llaves de API se identifican como "apikey:<id>"
+func validCreator(subject string) bool {
+	if strings.HasPrefix(subject, apiKeyClientPrefix) {
+		return len(subject) > len(apiKeyClientPrefix)
+	}
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	return ok && engine.Var(subject, "required,email") == nil
+}
+
+// sessionUser retorna la identidad autenticada por optionalAuth, o nil si la petición es anónima
+func sessionUser(c *gin.Context) *auth.Claims {
+	claims, exists := c.Get(authClaimsKey)
+	if !exists {
+		return nil
+	}
+	return claims.(*auth.Claims)
+}
+
+// loginRequest es el cuerpo de POST /api/auth/login
+type loginRequest struct {
+	UserID   string `json:"user_id" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+func login(c *gin.Context) {
+	var req loginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := authDirectory.Authenticate(req.UserID, req.Password)
+	if err != nil {
+		respondError(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	token, claims, err := authIssuer.Issue(user)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"token":      token,
+		"token_type": "Bearer",
+		"expires_at": time.Unix(claims.ExpiresAt, 0),
+		"user": gin.H{
+			"id":   user.ID,
+			"name": user.Name,
+			"role": user.Role,
+		},
+	})
+}
+
+func getSession(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"user":    currentUser(c),
+	})
+}
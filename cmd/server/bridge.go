@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers del puente de sincronización con SECOP II
+
+func getBridgeStatus(c *gin.Context) {
+	if secopBridge == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": true,
+		"bridge":  secopBridge.Status(),
+	})
+}
+
+func getBridgeMappings(c *gin.Context) {
+	if secopBridge == nil {
+		respondErrorMessage(c, http.StatusServiceUnavailable, "puente SECOP II no configurado")
+		return
+	}
+
+	c.JSON(http.StatusOK, secopBridge.Mappings())
+}
+
+func reconcileBridge(c *gin.Context) {
+	if secopBridge == nil {
+		respondErrorMessage(c, http.StatusServiceUnavailable, "puente SECOP II no configurado")
+		return
+	}
+
+	report := secopBridge.Reconcile(c.Query("remote") == "true")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"report":  report,
+	})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers y configuración de los checkpoints de la cadena
+
+// enableCheckpointsFromEnv designa la autoridad de checkpoints con CHECKPOINT_AUTHORITY
+// ("NODO:llave_publica_base64", normalmente el nodo raíz del DNP). El nodo autoridad crea
+// un checkpoint cada CHECKPOINT_INTERVAL_BLOCKS bloques (100 por defecto).
+func enableCheckpointsFromEnv() (bool, error) {
+	value := getEnv("CHECKPOINT_AUTHORITY", "")
+	if value == "" {
+		return false, nil
+	}
+	authorities, err := blockchain.ParseAuthorities(value)
+	if err != nil {
+		return false, fmt.Errorf("CHECKPOINT_AUTHORITY inválido: %v", err)
+	}
+	if len(authorities) != 1 {
+		return false, fmt.Errorf("CHECKPOINT_AUTHORITY debe designar un solo nodo")
+	}
+	interval, err := strconv.Atoi(getEnv("CHECKPOINT_INTERVAL_BLOCKS", "100"))
+	if err != nil {
+		return false, fmt.Errorf("CHECKPOINT_INTERVAL_BLOCKS inválido: %v", err)
+	}
+	return true, bc.EnableCheckpoints(authorities[0], interval)
+}
+
+func getCheckpoints(c *gin.Context) {
+	if bc.Checkpoints == nil {
+		c.JSON(http.StatusOK, gin.H{"success": true, "enabled": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"enabled":         true,
+		"authority":       bc.Checkpoints.Authority(),
+		"interval_blocks": bc.Checkpoints.Interval(),
+		"is_authority":    bc.Checkpoints.IsAuthority(),
+		"latest":          bc.Checkpoints.Latest(),
+	})
+}
+
+// createCheckpoint firma un checkpoint sobre la punta sin esperar el intervalo; solo
+// funciona en el nodo autoridad
+func createCheckpoint(c *gin.Context) {
+	if bc.Checkpoints == nil {
+		respondErrorMessage(c, http.StatusBadRequest, "checkpoints no configurados en este nodo")
+		return
+	}
+
+	checkpoint, err := bc.Checkpoints.Create()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"checkpoint": checkpoint,
+	})
+}
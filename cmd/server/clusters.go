@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers del agrupamiento de contratos por similitud de su objeto
+
+// clusterOptionsFromEnv lee la configuración del agrupamiento. CLUSTER_MODALITY_THRESHOLDS
+// tiene la forma "MODALIDAD:monto,MODALIDAD:monto".
+func clusterOptionsFromEnv() (blockchain.ClusterOptions, time.Duration, error) {
+	options := blockchain.ClusterOptions{Thresholds: make(map[string]float64)}
+
+	if value := getEnv("CLUSTER_SIMILARITY", ""); value != "" {
+		similarity, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return options, 0, fmt.Errorf("CLUSTER_SIMILARITY inválido: %v", err)
+		}
+		options.Similarity = similarity
+	}
+	if value := getEnv("CLUSTER_WINDOW_DAYS", ""); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			return options, 0, fmt.Errorf("CLUSTER_WINDOW_DAYS inválido: %v", err)
+		}
+		options.WindowDays = days
+	}
+	for _, entry := range strings.Split(getEnv("CLUSTER_MODALITY_THRESHOLDS", ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return options, 0, fmt.Errorf("umbral de modalidad inválido: %s", entry)
+		}
+		amount, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || amount <= 0 {
+			return options, 0, fmt.Errorf("umbral de modalidad inválido: %s", entry)
+		}
+		options.Thresholds[strings.TrimSpace(parts[0])] = amount
+	}
+
+	hours, err := strconv.Atoi(getEnv("CLUSTER_INTERVAL_HOURS", "24"))
+	if err != nil || hours <= 0 {
+		hours = 24
+	}
+	return options, time.Duration(hours) * time.Hour, nil
+}
+
+// getContractClusters retorna los grupos del último agrupamiento, opcionalmente solo los
+// posibles fraccionamientos o los que incluyen una entidad
+func getContractClusters(c *gin.Context) {
+	report := contractClusterer.Latest()
+	if report == nil {
+		report = contractClusterer.Cluster()
+	}
+
+	onlySplits := c.Query("possible_split") == "true"
+	entityCode := c.Query("entity_code")
+	clusters := make([]blockchain.ContractCluster, 0, len(report.Clusters))
+	for _, cluster := range report.Clusters {
+		if onlySplits && !cluster.PossibleSplit {
+			continue
+		}
+		if entityCode != "" && !includesEntity(cluster, entityCode) {
+			continue
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"generated_at": report.GeneratedAt,
+		"height":       report.Height,
+		"options":      report.Options,
+		"count":        len(clusters),
+		"data":         clusters,
+	})
+}
+
+// runContractClustering agrupa los contratos sin esperar la ejecución periódica
+func runContractClustering(c *gin.Context) {
+	report := contractClusterer.Cluster()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"report":  report,
+	})
+}
+
+// includesEntity indica si el grupo tiene contratos de la entidad
+func includesEntity(cluster blockchain.ContractCluster, entityCode string) bool {
+	for _, entity := range cluster.Entities {
+		if entity == entityCode {
+			return true
+		}
+	}
+	return false
+}
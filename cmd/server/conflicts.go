@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de declaraciones de conflicto de interés
+
+// declareConflictRequest es el cuerpo de POST /api/contracts/:id/conflict-declarations
+type declareConflictRequest struct {
+	HasConflict   *bool  `json:"has_conflict" binding:"required"`
+	Statement     string `json:"statement"`
+	AlternateID   string `json:"alternate_id"`
+	AlternateName string `json:"alternate_name"`
+}
+
+// declareConflict registra la declaración del validador en sesión para el paso actual.
+// Si declara conflicto y propone un validador alterno, el paso se le asigna a este.
+func declareConflict(c *gin.Context) {
+	var req declareConflictRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user := currentUser(c)
+	declaration, err := bc.Conflicts.Declare(c.Param("id"), user.Subject, user.Name, user.Role, *req.HasConflict, req.Statement, req.AlternateID)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response := gin.H{
+		"success":     true,
+		"declaration": declaration,
+	}
+	if declaration.HasConflict && req.AlternateID != "" {
+		alternateName := req.AlternateName
+		if alternateName == "" {
+			alternateName = req.AlternateID
+		}
+		claim, err := workQueue.Assign(declaration.ContractID, req.AlternateID, alternateName, declaration.Role, user.Subject)
+		if err != nil {
+			// La declaración ya quedó anclada; el paso vuelve a la cola del comité
+			response["routing_error"] = err.Error()
+		} else {
+			response["claim"] = claim
+		}
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// getConflictDeclarations lista las declaraciones de un contrato para los entes de control
+func getConflictDeclarations(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	declarations := bc.Conflicts.List(contract.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(declarations),
+		"data":    declarations,
+	})
+}
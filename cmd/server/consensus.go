@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers y configuración del consenso por prueba de autoridad
+
+// enableConsensusFromEnv activa la prueba de autoridad si CONSENSUS_MODE=poa (por defecto,
+// el modo del perfil), con los validadores de génesis de POA_VALIDATORS
+// ("NODO:llave_publica_base64,..."). Si el nodo ingresó con un token y no los configura,
+// usa el modo y los validadores que informó el miembro de la red.
+func enableConsensusFromEnv(joined *blockchain.JoinResponse) error {
+	defaultMode := activeProfile.Consensus.Mode
+	if joined != nil {
+		defaultMode = joined.ConsensusMode
+	}
+	mode := getEnv("CONSENSUS_MODE", defaultMode)
+	switch mode {
+	case blockchain.ConsensusLongestChain:
+		return nil
+	case blockchain.ConsensusPoA:
+	default:
+		return fmt.Errorf("modo de consenso desconocido: %s", mode)
+	}
+
+	if validators := getEnv("POA_VALIDATORS", ""); validators != "" || joined == nil || len(joined.GenesisValidators) == 0 {
+		genesis, err := blockchain.ParseAuthorities(validators)
+		if err != nil {
+			return fmt.Errorf("POA_VALIDATORS inválido: %v", err)
+		}
+		activationHeight, err := strconv.Atoi(getEnv("POA_ACTIVATION_HEIGHT", "1"))
+		if err != nil {
+			return fmt.Errorf("POA_ACTIVATION_HEIGHT inválido: %v", err)
+		}
+		return bc.EnableProofOfAuthority(genesis, activationHeight)
+	}
+
+	fmt.Printf("🏛️ Validadores de génesis recibidos de %s\n", joined.NodeID)
+	return bc.EnableProofOfAuthority(joined.GenesisValidators, joined.ActivationHeight)
+}
+
+func getConsensus(c *gin.Context) {
+	authorities := bc.Authorities()
+	c.JSON(http.StatusOK, gin.H{
+		"success":           true,
+		"mode":              bc.ConsensusMode(),
+		"activation_height": bc.AuthorityActivationHeight(),
+		"is_authority":      bc.IsAuthority(p2pNetwork.NodeID),
+		"count":             len(authorities),
+		"authorities":       authorities,
+	})
+}
+
+// addAuthorityRequest es el cuerpo de POST /api/consensus/validators
+type addAuthorityRequest struct {
+	NodeID    string `json:"node_id" binding:"required"`
+	PublicKey string `json:"public_key"`
+}
+
+// addAuthority propone agregar un validador. Si no se indica la llave pública se usa la
+// que el nodo anunció en el handshake.
+func addAuthority(c *gin.Context) {
+	var req addAuthorityRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var keys []blockchain.PublicKeyInfo
+	if req.PublicKey != "" {
+		publicKey, err := base64.StdEncoding.DecodeString(req.PublicKey)
+		if err != nil || len(publicKey) != ed25519.PublicKeySize {
+			respondErrorMessage(c, http.StatusBadRequest, "llave pública inválida")
+			return
+		}
+		keys = []blockchain.PublicKeyInfo{{
+			KeyID:     blockchain.KeyIDFor(publicKey),
+			PublicKey: req.PublicKey,
+		}}
+	} else {
+		known, exists := p2pNetwork.ValidatorKeys()[req.NodeID]
+		if !exists {
+			respondErrorMessage(c, http.StatusBadRequest, "nodo desconocido; indique su llave pública")
+			return
+		}
+		keys = known
+	}
+
+	if err := bc.ProposeValidatorChange(blockchain.GovernanceAddValidator, req.NodeID, keys); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":     true,
+		"authorities": bc.Authorities(),
+	})
+}
+
+func removeAuthority(c *gin.Context) {
+	if err := bc.ProposeValidatorChange(blockchain.GovernanceRemoveValidator, c.Param("id"), nil); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"authorities": bc.Authorities(),
+	})
+}
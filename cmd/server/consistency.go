@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// consistencyGuard aplica el nivel de consistencia pedido en ?consistency=local|quorum.
+// Con "local" (por defecto) se responde de inmediato con la vista del nodo; con
+// "quorum" primero se contrasta la punta de la cadena con la mayoría de los peers.
+func consistencyGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		level := c.DefaultQuery("consistency", blockchain.ConsistencyLocal)
+
+		switch level {
+		case blockchain.ConsistencyLocal:
+			c.Header("X-Consistency", level)
+			c.Next()
+		case blockchain.ConsistencyQuorum:
+			result, err := p2pNetwork.EnsureQuorum()
+			if err != nil {
+				body := errorBody(c, http.StatusServiceUnavailable, err.Error())
+				body["quorum"] = result
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, body)
+				return
+			}
+			c.Header("X-Consistency", level)
+			c.Header("X-Quorum", strconv.Itoa(result.Agreeing)+"/"+strconv.Itoa(result.Total))
+			c.Header("X-Chain-Tip", result.Local.Hash)
+			c.Next()
+		default:
+			respondErrorMessage(c, http.StatusBadRequest, "nivel de consistencia inválido (local o quorum)")
+		}
+	}
+}
+
+func getTip(c *gin.Context) {
+	c.JSON(http.StatusOK, p2pNetwork.LocalTip())
+}
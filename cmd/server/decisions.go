@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// decisionMatrixHeader son las columnas del CSV de la matriz de decisiones
+var decisionMatrixHeader = []string{
+	"No.", "Paso", "Rol", "Usuario", "Nombre", "Decisión", "Automática", "Fecha (hora Colombia)",
+	"Comentarios", "Llave de firma", "Acta", "Hash del acta", "Bloque", "Hash del bloque",
+}
+
+// getContractDecisions retorna la matriz de decisiones del contrato en JSON o, con
+// ?format=csv, como CSV listo para los papeles de trabajo: separado por punto y coma y con
+// BOM para que Excel en español lo abra con tildes y columnas correctas
+func getContractDecisions(c *gin.Context) {
+	contractID := c.Param("id")
+	decisions, err := bc.ContractDecisions(contractID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, gin.H{
+			"success":     true,
+			"contract_id": contractID,
+			"count":       len(decisions),
+			"data":        decisions,
+		})
+	case "csv":
+		file, err := decisionMatrixCSV(decisions)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+		fileName := fmt.Sprintf("decisiones-%s-%s.csv", contractID, time.Now().Format("20060102"))
+		c.Header("Content-Disposition", "attachment; filename="+fileName)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", file)
+	default:
+		respondErrorMessage(c, http.StatusBadRequest, "format inválido (se admite json o csv)")
+	}
+}
+
+// decisionMatrixCSV escribe las decisiones como CSV
+func decisionMatrixCSV(decisions []blockchain.ContractDecision) ([]byte, error) {
+	var file bytes.Buffer
+	file.WriteString("\ufeff")
+	writer := csv.NewWriter(&file)
+	writer.Comma = ';'
+
+	writer.Write(decisionMatrixHeader)
+	for _, decision := range decisions {
+		automatic := "No"
+		if decision.AutoApproved {
+			automatic = "Sí"
+		}
+		writer.Write([]string{
+			strconv.Itoa(decision.Number),
+			strconv.Itoa(decision.StepNumber),
+			string(decision.Role),
+			decision.ValidatorID,
+			decision.ValidatorName,
+			decision.Decision,
+			automatic,
+			decision.DecidedAt.In(colombiaTime).Format("2006-01-02 15:04:05"),
+			strings.Join(strings.Fields(decision.Comments), " "),
+			decision.SignatureKeyID,
+			decision.ActReference,
+			decision.ActHash,
+			strconv.Itoa(decision.BlockIndex),
+			decision.BlockHash,
+		})
+	}
+	writer.Flush()
+	return file.Bytes(), writer.Error()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de la política de limpieza de borradores
+
+// draftPolicyFromEnv lee la política de borradores desde variables de entorno
+func draftPolicyFromEnv() blockchain.DraftPolicy {
+	staleDays, err := strconv.Atoi(getEnv("DRAFT_STALE_DAYS", "30"))
+	if err != nil || staleDays <= 0 {
+		staleDays = 30
+	}
+	graceDays, err := strconv.Atoi(getEnv("DRAFT_GRACE_DAYS", "15"))
+	if err != nil || graceDays < 0 {
+		graceDays = 15
+	}
+
+	return blockchain.DraftPolicy{
+		StaleAfter:  time.Duration(staleDays) * 24 * time.Hour,
+		GracePeriod: time.Duration(graceDays) * 24 * time.Hour,
+	}
+}
+
+// getDrafts lista los borradores marcados y los archivados; el administrador de una
+// entidad solo ve los de su entidad y quien opera el nodo, sin entidad, los de todas
+func getDrafts(c *gin.Context) {
+	entityCode := currentUser(c).EntityCode
+	visible := func(contract *blockchain.Contract) bool {
+		return entityCode == "" || contract.EntityCode == entityCode
+	}
+
+	var flagged []*blockchain.Contract
+	for _, contract := range bc.GetContractsByStatus(blockchain.StatusDraft) {
+		if contract.StaleFlaggedAt != nil && visible(contract) {
+			flagged = append(flagged, contract)
+		}
+	}
+	archived := []*blockchain.Contract{}
+	for _, contract := range draftJanitor.Archived() {
+		if visible(contract) {
+			archived = append(archived, contract)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"flagged":  flagged,
+		"archived": archived,
+	})
+}
+
+// sweepDrafts revisa ahora los borradores de la entidad del administrador
+func sweepDrafts(c *gin.Context) {
+	user := currentUser(c)
+	if user.EntityCode == "" {
+		respondErrorMessage(c, http.StatusForbidden, "la sesión no pertenece a ninguna entidad")
+		return
+	}
+	report := draftJanitor.SweepEntity(time.Now(), user.EntityCode)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"report":  report,
+	})
+}
+
+// restoreDraft restaura un borrador archivado de la entidad del administrador
+func restoreDraft(c *gin.Context) {
+	contract, exists := draftJanitor.ArchivedDraft(c.Param("id"))
+	if !exists {
+		respondErrorMessage(c, http.StatusNotFound, "borrador archivado no encontrado")
+		return
+	}
+	if user := currentUser(c); user.EntityCode != contract.EntityCode {
+		respondErrorMessage(c, http.StatusForbidden, "la sesión no pertenece a la entidad "+contract.EntityCode)
+		return
+	}
+
+	if err := draftJanitor.Restore(contract.ID); err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Borrador restaurado",
+	})
+}
+
+// setDraftHoldRequest es el cuerpo de POST /api/contracts/:id/draft-hold
+type setDraftHoldRequest struct {
+	Hold bool `json:"hold"`
+}
+
+// setDraftHold retiene o libera un borrador de la entidad del administrador
+func setDraftHold(c *gin.Context) {
+	var req setDraftHoldRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, ok := requireContractEntity(c, c.Param("id")); !ok {
+		return
+	}
+
+	user := currentUser(c)
+	if err := draftJanitor.SetHold(c.Param("id"), user.Subject, user.Role, req.Hold); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"hold":    req.Hold,
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de sistemas externos de las entidades (integración máquina a máquina)
+
+// registerSystemKeyRequest es el cuerpo de POST /api/entities/:code/systems
+type registerSystemKeyRequest struct {
+	SystemID  string `json:"system_id"`
+	PublicKey string `json:"public_key"`
+}
+
+// registerSystemKey registra el sistema de la entidad del administrador que inicia sesión
+func registerSystemKey(c *gin.Context) {
+	entityCode := c.Param("code")
+	if user := currentUser(c); user.EntityCode != entityCode {
+		respondErrorMessage(c, http.StatusForbidden, "solo un administrador de la entidad "+entityCode+" puede registrar sus sistemas")
+		return
+	}
+
+	var req registerSystemKeyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	key, err := bc.RegisterSystemKey(entityCode, req.SystemID, req.PublicKey)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"system":  key,
+	})
+}
+
+func getSystemKeys(c *gin.Context) {
+	keys := bc.GetSystemKeys(c.Param("code"))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(keys),
+		"data":    keys,
+	})
+}
+
+// createSignedContractRequest es el cuerpo de POST /api/contracts/signed
+type createSignedContractRequest struct {
+	EntityCode string `json:"entity_code"`
+	SystemID   string `json:"system_id"`
+	Payload    string `json:"payload"`   // JSON del contrato en base64
+	Signature  string `json:"signature"` // Firma Ed25519 del payload en base64
+}
+
+func createSignedContract(c *gin.Context) {
+	var req createSignedContractRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(req.Payload)
+	if err != nil {
+		respondErrorMessage(c, http.StatusBadRequest, "payload mal codificado")
+		return
+	}
+
+	contract, err := bc.AddSignedContract(req.EntityCode, req.SystemID, payload, req.Signature)
+	if err != nil {
+		respondError(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":       true,
+		"message":       "Contrato creado exitosamente",
+		"contract_id":   contract.ID,
+		"origin_system": contract.OriginSystem,
+	})
+}
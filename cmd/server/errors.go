@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// Respuestas de error de la API. Todas comparten el mismo sobre:
+//
+//	{"success": false, "error": "mensaje", "code": "VALIDATION_FAILED",
+//	 "fields": [{"field": "amount", "rule": "gt", "message": "..."}], "trace_id": "..."}
+//
+// "error" sigue siendo el mensaje legible que ya leían los clientes y los demás nodos;
+// "code" permite reaccionar al error sin interpretar el texto y "trace_id" (también en el
+// encabezado X-Request-ID) ubica la petición en los registros del nodo.
+
+// Encabezado con el identificador de la petición
+const traceIDHeader = "X-Request-ID"
+
+// Llave del identificador de la petición en el contexto de Gin
+const traceIDKey = "trace_id"
+
+// Códigos de error
+const (
+	errorCodeInvalidRequest  = "INVALID_REQUEST"
+	errorCodeMalformedJSON   = "MALFORMED_JSON"
+	errorCodeValidation      = "VALIDATION_FAILED"
+	errorCodeUnauthorized    = "UNAUTHORIZED"
+	errorCodeForbidden       = "FORBIDDEN"
+	errorCodeNotFound        = "NOT_FOUND"
+	errorCodeConflict        = "CONFLICT"
+	errorCodePayloadTooLarge = "PAYLOAD_TOO_LARGE"
+	errorCodeUnprocessable   = "UNPROCESSABLE"
+	errorCodeRateLimited     = "RATE_LIMITED"
+	errorCodeInternal        = "INTERNAL_ERROR"
+	errorCodeUnavailable     = "UNAVAILABLE"
+)
+
+// errorCodeByStatus es el código por defecto de cada estado HTTP
+var errorCodeByStatus = map[int]string{
+	http.StatusBadRequest:            errorCodeInvalidRequest,
+	http.StatusUnauthorized:          errorCodeUnauthorized,
+	http.StatusForbidden:             errorCodeForbidden,
+	http.StatusNotFound:              errorCodeNotFound,
+	http.StatusConflict:              errorCodeConflict,
+	http.StatusRequestEntityTooLarge: errorCodePayloadTooLarge,
+	http.StatusUnprocessableEntity:   errorCodeUnprocessable,
+	http.StatusTooManyRequests:       errorCodeRateLimited,
+	http.StatusServiceUnavailable:    errorCodeUnavailable,
+}
+
+// fieldError es el error de validación de un campo de la petición
+type fieldError struct {
+	Field   string `json:"field"` // Ruta del campo en el JSON, p. ej. "amount" o "consortium.members"
+	Rule    string `json:"rule"`  // Regla incumplida: required, email, gt, entity_code, type...
+	Message string `json:"message"`
+}
+
+// apiError es el sobre de las respuestas de error (ver el comentario del archivo)
+type apiError struct {
+	Success bool         `json:"success"`
+	Error   string       `json:"error"`
+	Code    string       `json:"code"`
+	Fields  []fieldError `json:"fields,omitempty"`
+	TraceID string       `json:"trace_id"`
+}
+
+// traceRequests asigna a cada petición un identificador, el que envía el cliente en
+// X-Request-ID si es razonable o uno nuevo, y lo devuelve en la respuesta
+func traceRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		traceID := c.GetHeader(traceIDHeader)
+		if !validTraceID.MatchString(traceID) {
+			traceID = uuid.New().String()
+		}
+		c.Set(traceIDKey, traceID)
+		c.Header(traceIDHeader, traceID)
+		c.Next()
+	}
+}
+
+// validTraceID acepta los identificadores que envían los balanceadores y los clientes
+var validTraceID = regexp.MustCompile(`^[A-Za-z0-9._:-]{8,128}$`)
+
+// traceID retorna el identificador de la petición
+func traceID(c *gin.Context) string {
+	return c.GetString(traceIDKey)
+}
+
+// errorBody arma el sobre de error; los handlers que agregan datos al error lo completan
+// antes de responder
+func errorBody(c *gin.Context, status int, message string) gin.H {
+	code, known := errorCodeByStatus[status]
+	if !known {
+		code = errorCodeInternal
+	}
+	return gin.H{
+		"success":  false,
+		"error":    message,
+		"code":     code,
+		"trace_id": traceID(c),
+	}
+}
+
+// respondErrorMessage responde el error con el código que corresponde al estado
+func respondErrorMessage(c *gin.Context, status int, message string) {
+	logServerError(c, status, message)
+	c.AbortWithStatusJSON(status, errorBody(c, status, message))
+}
+
+// logServerError registra los errores del nodo con el identificador de la petición, que
+// es lo que el cliente reporta
+func logServerError(c *gin.Context, status int, message string) {
+	if status >= http.StatusInternalServerError {
+		fmt.Printf("❌ [%s] %s %s: %s\n", traceID(c), c.Request.Method, c.Request.URL.Path, message)
+	}
+}
+
+// respondError responde el error; los errores de lectura y validación del cuerpo JSON se
+// detallan campo por campo
+func respondError(c *gin.Context, status int, err error) {
+	body := apiError{
+		Error:   err.Error(),
+		Code:    errorCodeByStatus[status],
+		TraceID: traceID(c),
+	}
+	if body.Code == "" {
+		body.Code = errorCodeInternal
+	}
+
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	var syntaxError *json.SyntaxError
+	switch {
+	case errors.As(err, &validationErrors):
+		body.Code = errorCodeValidation
+		for _, failure := range validationErrors {
+			body.Fields = append(body.Fields, fieldError{
+				Field:   validationFieldPath(failure),
+				Rule:    failure.Tag(),
+				Message: validationMessage(failure),
+			})
+		}
+		body.Error = fmt.Sprintf("la petición tiene %d campos inválidos", len(body.Fields))
+		if len(body.Fields) == 1 {
+			body.Error = body.Fields[0].Field + ": " + body.Fields[0].Message
+		}
+	case errors.As(err, &typeError):
+		body.Code = errorCodeValidation
+		body.Fields = []fieldError{{
+			Field:   typeError.Field,
+			Rule:    "type",
+			Message: "debe ser de tipo " + jsonTypeName(typeError.Type),
+		}}
+	case errors.As(err, &syntaxError), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		body.Code = errorCodeMalformedJSON
+	}
+	logServerError(c, status, err.Error())
+	c.AbortWithStatusJSON(status, body)
+}
+
+// validationFieldPath retorna la ruta JSON del campo sin el nombre del tipo raíz
+func validationFieldPath(failure validator.FieldError) string {
+	namespace := failure.Namespace()
+	if dot := strings.Index(namespace, "."); dot >= 0 {
+		return namespace[dot+1:]
+	}
+	return failure.Field()
+}
+
+// validationMessage describe en español la regla incumplida
+func validationMessage(failure validator.FieldError) string {
+	switch failure.Tag() {
+	case "required":
+		return "es obligatorio"
+	case "email":
+		return "debe ser un correo electrónico válido"
+	case "entity_code":
+		return "debe ser un código de entidad de 5 a 12 dígitos (p. ej. el código DANE)"
+	case "gt":
+		return "debe ser mayor que " + failure.Param()
+	case "gte":
+		return "debe ser mayor o igual que " + failure.Param()
+	case "lt":
+		return "debe ser menor que " + failure.Param()
+	case "lte":
+		return "debe ser como máximo " + failure.Param()
+	case "min":
+		return "debe tener al menos " + failure.Param()
+	case "max":
+		return "debe tener como máximo " + failure.Param()
+	case "oneof":
+		return "debe ser uno de: " + failure.Param()
+	}
+	return "no cumple la regla " + failure.Tag()
+}
+
+// jsonTypeName nombra el tipo de Go como lo ve un cliente JSON
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "texto"
+	case reflect.Bool:
+		return "booleano"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "entero"
+	case reflect.Float32, reflect.Float64:
+		return "número"
+	case reflect.Slice, reflect.Array:
+		return "lista"
+	}
+	return "objeto"
+}
+
+// registerValidators nombra los campos de los errores de validación como en el JSON y
+// agrega las reglas propias de la API
+func registerValidators() error {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("el validador de Gin no es go-playground/validator")
+	}
+	engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+	return engine.RegisterValidation("entity_code", func(field validator.FieldLevel) bool {
+		return blockchain.ValidEntityCode(field.Field().String())
+	})
+}
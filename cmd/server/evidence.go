@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io"
+	"net/http"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de la galería de evidencias de ejecución
+
+func uploadEvidence(c *gin.Context) {
+	contractID := c.Param("id")
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		respondErrorMessage(c, http.StatusBadRequest, "archivo requerido")
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, blockchain.MaxEvidenceSize+1))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	mediaType := header.Header.Get("Content-Type")
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType = http.DetectContentType(content)
+	}
+
+	user := currentUser(c)
+	evidence, err := evidenceStore.Upload(
+		contractID,
+		user.Subject,
+		user.Role,
+		header.Filename,
+		mediaType,
+		c.PostForm("description"),
+		content,
+	)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"evidence": evidence,
+	})
+}
+
+// getEvidenceGallery es público: permite a la ciudadanía contrastar el avance reportado
+func getEvidenceGallery(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	gallery := make([]gin.H, 0, len(contract.Evidence))
+	for _, evidence := range contract.Evidence {
+		gallery = append(gallery, gin.H{
+			"evidence": evidence,
+			"url":      "/api/contracts/" + contract.ID + "/evidence/" + evidence.ID + "/file",
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"contract_id": contract.ID,
+		"status":      contract.Status,
+		"count":       len(gallery),
+		"data":        gallery,
+	})
+}
+
+func getEvidenceFile(c *gin.Context) {
+	evidence, content, err := evidenceStore.Open(c.Param("id"), c.Param("eid"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.Header("X-Content-SHA256", evidence.SHA256)
+	c.Header("X-Block-Hash", evidence.BlockHash)
+	c.Data(http.StatusOK, evidence.MediaType, content)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de las exportaciones Parquet para analítica
+
+// Tipo MIME de los archivos Parquet
+const parquetMediaType = "application/vnd.apache.parquet"
+
+func exportContractsParquet(c *gin.Context) {
+	sendParquet(c, "contracts", bc.ExportContracts())
+}
+
+func exportBlocksParquet(c *gin.Context) {
+	columns, err := bc.ExportBlocks()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	sendParquet(c, "blocks", columns)
+}
+
+// sendParquet responde con las columnas como archivo Parquet, o con su esquema si se pide
+// ?schema=true para que los equipos de datos detecten columnas nuevas antes de cargar
+func sendParquet(c *gin.Context, name string, columns []*blockchain.ParquetColumn) {
+	if c.Query("schema") == "true" {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"count":   len(columns),
+			"columns": columns,
+		})
+		return
+	}
+
+	var file bytes.Buffer
+	if err := blockchain.WriteParquet(&file, columns); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	fileName := fmt.Sprintf("%s-%s-%s.parquet", p2pNetwork.NodeID, name, time.Now().Format("20060102"))
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.Data(http.StatusOK, parquetMediaType, file.Bytes())
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de finalización de bloques por quórum de validadores
+
+// receiveFinality recibe de un peer los votos con que finalizó un bloque
+func receiveFinality(c *gin.Context) {
+	var certificate blockchain.FinalityCertificate
+	if err := c.ShouldBindJSON(&certificate); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	finality, err := p2pNetwork.ReceiveCertificate(certificate)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"finality": finality,
+	})
+}
+
+// getBlockFinality retorna el estado de finalización de un bloque de la cadena local
+func getBlockFinality(c *gin.Context) {
+	finality, exists := bc.Finality.Status(c.Param("hash"))
+	if !exists {
+		respondErrorMessage(c, http.StatusNotFound, "sin registro de finalización para el bloque")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"finality": finality,
+	})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de los pagos, los compromisos que pasan de vigencia y los cierres fiscales
+
+// registerContractPaymentRequest es el cuerpo de POST /api/contracts/:id/payments
+type registerContractPaymentRequest struct {
+	Amount    float64   `json:"amount" binding:"required"`
+	Reference string    `json:"reference"`
+	PaidAt    time.Time `json:"paid_at"`
+}
+
+// registerContractPayment registra un pago del contrato
+func registerContractPayment(c *gin.Context) {
+	contractID := c.Param("id")
+
+	var req registerContractPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, ok := checkContractEntity(c, contractID); !ok {
+		return
+	}
+	user := currentUser(c)
+
+	payment, err := fiscalLedger.RegisterPayment(contractID, req.Amount, req.Reference, req.PaidAt, user.Subject, user.Role)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"payment": payment,
+	})
+}
+
+// getContractPayments es público: lista los pagos del contrato y su saldo por pagar
+func getContractPayments(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	payments := append([]blockchain.ContractPayment{}, contract.Payments...)
+	carryovers := append([]blockchain.Carryover{}, contract.Carryovers...)
+	paid := contract.PaidThrough(time.Now())
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"contract_id":   contract.ID,
+		"amount":        contract.Amount,
+		"amended_value": contract.AmendedValue,
+		"paid":          paid,
+		"pending":       contract.TotalValue() - paid,
+		"payments":      payments,
+		"carryovers":    carryovers,
+	})
+}
+
+// markCarryoverRequest es el cuerpo de POST /api/contracts/:id/carryovers
+type markCarryoverRequest struct {
+	FiscalYear    int    `json:"fiscal_year" binding:"required"`
+	Kind          string `json:"kind" binding:"required"`
+	Justification string `json:"justification" binding:"required"`
+}
+
+// markCarryover constituye el saldo sin pagar del contrato al cierre de una vigencia
+func markCarryover(c *gin.Context) {
+	contractID := c.Param("id")
+
+	var req markCarryoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, ok := checkContractEntity(c, contractID); !ok {
+		return
+	}
+	user := currentUser(c)
+
+	carryover, err := fiscalLedger.MarkCarryover(contractID, req.FiscalYear, blockchain.CarryoverKind(req.Kind), req.Justification, user.Subject, user.Role)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":   true,
+		"carryover": carryover,
+	})
+}
+
+// closeFiscalYearRequest es el cuerpo de POST /api/fiscal-closings
+type closeFiscalYearRequest struct {
+	FiscalYear int `json:"fiscal_year" binding:"required"`
+}
+
+// closeFiscalYear genera el informe de cierre de la vigencia y ancla su huella en la cadena
+func closeFiscalYear(c *gin.Context) {
+	var req closeFiscalYearRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := fiscalLedger.Close(req.FiscalYear, currentUser(c).Subject)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"report":  report,
+	})
+}
+
+// getFiscalClosings lista las vigencias cerradas
+func getFiscalClosings(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"years":   fiscalLedger.Years(),
+	})
+}
+
+// getFiscalClosing retorna el informe de cierre de la vigencia, opcionalmente solo de una
+// entidad, y si su huella coincide con la anclada en la cadena. Las llaves de API de una
+// entidad solo ven su propio cierre.
+func getFiscalClosing(c *gin.Context) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		respondErrorMessage(c, http.StatusBadRequest, "vigencia inválida")
+		return
+	}
+	report, exists := fiscalLedger.Report(year)
+	if !exists {
+		respondErrorMessage(c, http.StatusNotFound, "la vigencia no tiene informe de cierre")
+		return
+	}
+
+	entityCode := c.Query("entity_code")
+	if user := currentUser(c); user.EntityCode != "" {
+		entityCode = user.EntityCode
+	}
+	if entityCode != "" {
+		report = report.ForEntity(entityCode)
+	}
+
+	verification := gin.H{"valid": true}
+	if err := fiscalLedger.Verify(year); err != nil {
+		verification = gin.H{"valid": false, "error": err.Error()}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"report":       report,
+		"verification": verification,
+	})
+}
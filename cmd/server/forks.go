@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de detección y resolución de bifurcaciones
+
+// getAncestors entrega a un peer los bloques que terminan en el hash indicado, para
+// que encuentre el último bloque que ambas cadenas comparten
+func getAncestors(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(blockchain.MaxForkDepth)))
+
+	blocks, err := bc.Ancestors(c.Param("hash"), limit)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"blocks":  p2pNetwork.BlocksFor(c.GetHeader("X-Node-ID"), blocks),
+		"node_id": p2pNetwork.NodeID,
+	})
+}
+
+// getForks lista los bloques huérfanos en espera y las bifurcaciones resueltas
+func getForks(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"orphans":     p2pNetwork.Forks.Orphans(),
+		"resolutions": p2pNetwork.Forks.Resolutions(),
+	})
+}
+
+// blockVote vota la finalización de un bloque recibido solo si quedó en la cadena local;
+// los huérfanos en espera y los de ramas descartadas no se votan
+func blockVote(hash string) *blockchain.FinalityVote {
+	if !bc.HasBlock(hash) {
+		return nil
+	}
+	return p2pNetwork.Vote(hash)
+}
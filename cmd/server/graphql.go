@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"secop-blockchain/internal/graphql"
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// API GraphQL de solo lectura sobre contratos, pasos de validación, auditoría, bloques y
+// peers, para que los portales traigan en una sola petición exactamente los datos anidados
+// que muestran. Se publica en /api/graphql sin versión: el esquema evoluciona agregando
+// campos, no cambiando de ruta.
+
+var (
+	graphqlSchemaOnce sync.Once
+	graphqlSchemaInst *graphql.Schema
+)
+
+// graphqlSchema construye el esquema la primera vez que se usa
+func graphqlSchema() *graphql.Schema {
+	graphqlSchemaOnce.Do(func() {
+		graphqlSchemaInst = buildGraphQLSchema()
+	})
+	return graphqlSchemaInst
+}
+
+// buildGraphQLSchema declara los tipos y cómo se resuelve cada campo sobre el ledger
+func buildGraphQLSchema() *graphql.Schema {
+	nonNull := func(t graphql.Type) graphql.Type { return &graphql.NonNull{Of: t} }
+	listOf := func(t graphql.Type) graphql.Type { return &graphql.List{Of: t} }
+	scalars := func(object *graphql.Object, t graphql.Type, names ...string) {
+		for _, name := range names {
+			object.Fields[name] = &graphql.Field{Type: t}
+		}
+	}
+
+	contractType := graphql.NewObject("Contract", "Contrato con su flujo de validación")
+	stepType := graphql.NewObject("ValidationStep", "Paso del flujo de validación del contrato")
+	auditType := graphql.NewObject("AuditEntry", "Entrada de la línea de auditoría del contrato")
+	blockType := graphql.NewObject("Block", "Bloque de la cadena con sus transacciones decodificadas")
+	transactionType := graphql.NewObject("Transaction", "Transacción decodificada de un bloque")
+	blockPageType := graphql.NewObject("BlockPage", "Página de bloques del explorador")
+	peerType := graphql.NewObject("Peer", "Nodo activo de la red")
+
+	contractType.Fields["id"] = &graphql.Field{Type: nonNull(graphql.ID)}
+	scalars(contractType, graphql.String, "entityCode", "entityName", "contractType", "description", "status",
+		"createdBy", "createdAt", "updatedAt", "awardedTo", "originSystem", "processNumber", "endDate")
+	scalars(contractType, graphql.Float, "amount", "amendedValue")
+	scalars(contractType, graphql.Int, "currentStep", "templateVersion", "sequence")
+	scalars(contractType, graphql.Boolean, "reserved", "retentionHold")
+	contractType.Fields["requiredRoles"] = &graphql.Field{Type: listOf(graphql.String)}
+	contractType.Fields["validationSteps"] = &graphql.Field{Type: listOf(stepType)}
+	contractType.Fields["pendingStep"] = &graphql.Field{
+		Type:        stepType,
+		Description: "Primer paso pendiente o en revisión; null si el flujo terminó",
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			contract := params.Source.(*blockchain.Contract)
+			for i := range contract.ValidationSteps {
+				status := contract.ValidationSteps[i].Status
+				if status == blockchain.ValidationPending || status == blockchain.ValidationInReview {
+					return &contract.ValidationSteps[i], nil
+				}
+			}
+			return nil, nil
+		},
+	}
+	contractType.Fields["auditTrail"] = &graphql.Field{
+		Type:        listOf(auditType),
+		Description: "Línea de auditoría, de la más antigua a la más reciente",
+		Args:        []graphql.Argument{{Name: "last", Type: graphql.Int, Description: "Solo las últimas N entradas"}},
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			trail := params.Source.(*blockchain.Contract).AuditTrail
+			if last, ok := params.Args["last"].(int); ok && last >= 0 && last < len(trail) {
+				trail = trail[len(trail)-last:]
+			}
+			return trail, nil
+		},
+	}
+
+	scalars(stepType, graphql.Int, "stepNumber")
+	scalars(stepType, graphql.String, "role", "validatorId", "validatorName", "status", "timestamp", "comments",
+		"digitalSign", "signatureKeyId", "signedAt", "ruleReference")
+	scalars(stepType, graphql.Boolean, "required", "autoApproved", "actRequired")
+	stepType.Fields["documents"] = &graphql.Field{Type: listOf(graphql.String)}
+
+	auditType.Fields["id"] = &graphql.Field{Type: nonNull(graphql.ID)}
+	scalars(auditType, graphql.String, "action", "userId", "userRole", "timestamp", "description", "blockHash")
+	auditType.Fields["block"] = &graphql.Field{
+		Type:        blockType,
+		Description: "Bloque donde quedó registrada la entrada",
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			return graphqlBlock(params.Source.(blockchain.AuditEntry).BlockHash), nil
+		},
+	}
+
+	scalars(blockType, graphql.Int, "height", "transactionCount")
+	scalars(blockType, graphql.String, "hash", "originHash", "previousHash", "timestamp", "type", "signerNodeId", "merkleRoot")
+	blockType.Fields["withheld"] = &graphql.Field{Type: graphql.Boolean, Description: "Toca un contrato reservado: sin transacciones"}
+	blockType.Fields["transactions"] = &graphql.Field{Type: listOf(transactionType)}
+
+	scalars(transactionType, graphql.Int, "position", "step")
+	scalars(transactionType, graphql.String, "id", "type", "contractId", "entityCode", "entityName", "actor", "role",
+		"description", "comments", "summary", "timestamp", "dataHash")
+	scalars(transactionType, graphql.Boolean, "approved")
+	scalars(transactionType, graphql.Float, "amount")
+	transactionType.Fields["contract"] = &graphql.Field{
+		Type:        contractType,
+		Description: "Estado actual del contrato que toca la transacción",
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			return graphqlContract(params.Source.(blockchain.ExplorerTransaction).ContractID), nil
+		},
+	}
+
+	scalars(blockPageType, graphql.Int, "page", "pageSize", "total", "totalPages")
+	blockPageType.Fields["blocks"] = &graphql.Field{Type: listOf(blockType)}
+
+	scalars(peerType, graphql.String, "id", "address", "port", "lastSeen")
+	scalars(peerType, graphql.Boolean, "active", "maintenance")
+
+	query := graphql.NewObject("Query", "Consultas de solo lectura sobre el ledger")
+	query.Fields["contracts"] = &graphql.Field{
+		Type:        listOf(contractType),
+		Description: "Contratos filtrados, de los más recientes a los más antiguos",
+		Args: []graphql.Argument{
+			{Name: "status", Type: graphql.String},
+			{Name: "entityCode", Type: graphql.String},
+			{Name: "contractType", Type: graphql.String},
+			{Name: "limit", Type: graphql.Int, Description: "Hasta 500; 50 por defecto"},
+			{Name: "offset", Type: graphql.Int},
+		},
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			query := blockchain.ContractQuery{Limit: defaultContractPageSize}
+			status, _ := params.Args["status"].(string)
+			query.Status = blockchain.ContractStatus(status)
+			query.EntityCode, _ = params.Args["entityCode"].(string)
+			query.ContractType, _ = params.Args["contractType"].(string)
+			if limit, ok := params.Args["limit"].(int); ok && limit > 0 {
+				query.Limit = limit
+			}
+			if query.Limit > maxContractPageSize {
+				query.Limit = maxContractPageSize
+			}
+			if offset, ok := params.Args["offset"].(int); ok && offset > 0 {
+				query.Offset = offset
+			}
+			return bc.QueryContracts(query)
+		},
+	}
+	query.Fields["contract"] = &graphql.Field{
+		Type: contractType,
+		Args: []graphql.Argument{{Name: "id", Type: nonNull(graphql.ID)}},
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			return graphqlContract(params.Args["id"].(string)), nil
+		},
+	}
+	query.Fields["blocks"] = &graphql.Field{
+		Type:        blockPageType,
+		Description: "Página de bloques, de los más recientes a los más antiguos salvo ascending",
+		Args: []graphql.Argument{
+			{Name: "page", Type: graphql.Int},
+			{Name: "pageSize", Type: graphql.Int, Description: "Hasta 100; 20 por defecto"},
+			{Name: "type", Type: graphql.String},
+			{Name: "ascending", Type: graphql.Boolean},
+		},
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			var query blockchain.ExplorerQuery
+			query.Page, _ = params.Args["page"].(int)
+			query.PageSize, _ = params.Args["pageSize"].(int)
+			query.Type, _ = params.Args["type"].(string)
+			query.Ascending, _ = params.Args["ascending"].(bool)
+			return bc.ExplorerBlocks(query)
+		},
+	}
+	query.Fields["block"] = &graphql.Field{
+		Type: blockType,
+		Args: []graphql.Argument{{Name: "hash", Type: nonNull(graphql.String), Description: "Hash local o el del nodo que lo creó"}},
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			return graphqlBlock(params.Args["hash"].(string)), nil
+		},
+	}
+	query.Fields["peers"] = &graphql.Field{
+		Type: listOf(peerType),
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			return p2pNetwork.GetActivePeers(), nil
+		},
+	}
+
+	return &graphql.Schema{Query: query}
+}
+
+// graphqlContract retorna el contrato, o nil si no existe
+func graphqlContract(contractID string) *blockchain.Contract {
+	if contractID == "" {
+		return nil
+	}
+	contract, exists := bc.Contract(contractID)
+	if !exists {
+		return nil
+	}
+	return contract
+}
+
+// graphqlBlock retorna el bloque decodificado, o nil si no existe
+func graphqlBlock(hash string) *blockchain.ExplorerBlock {
+	if hash == "" {
+		return nil
+	}
+	block, err := bc.ExplorerBlock(hash)
+	if err != nil {
+		return nil
+	}
+	return block
+}
+
+// postGraphQL resuelve la consulta del cuerpo {"query", "variables", "operationName"}
+func postGraphQL(c *gin.Context) {
+	var request graphql.Request
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, graphql.Result{Errors: []graphql.Error{{Message: err.Error()}}})
+		return
+	}
+	respondGraphQL(c, request)
+}
+
+// getGraphQL resuelve la consulta de ?query=, con las variables en JSON en ?variables=
+func getGraphQL(c *gin.Context) {
+	request := graphql.Request{Query: c.Query("query"), OperationName: c.Query("operationName")}
+	if variables := c.Query("variables"); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+			c.JSON(http.StatusBadRequest, graphql.Result{Errors: []graphql.Error{{Message: "variables inválidas: " + err.Error()}}})
+			return
+		}
+	}
+	respondGraphQL(c, request)
+}
+
+// respondGraphQL ejecuta la petición; una consulta inválida, sin datos, responde 400
+func respondGraphQL(c *gin.Context, request graphql.Request) {
+	if request.Query == "" {
+		c.JSON(http.StatusBadRequest, graphql.Result{Errors: []graphql.Error{{Message: "falta la consulta (query)"}}})
+		return
+	}
+	result := graphqlSchema().Execute(request)
+	status := http.StatusOK
+	if result.Data == nil {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, result)
+}
+
+// getGraphQLSchema publica el esquema en SDL
+func getGraphQLSchema(c *gin.Context) {
+	c.String(http.StatusOK, graphqlSchema().SDL())
+}
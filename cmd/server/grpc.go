@@ -0,0 +1,561 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"secop-blockchain/internal/auth"
+	"secop-blockchain/internal/grpcapi"
+	"secop-blockchain/pkg/blockchain"
+)
+
+// API gRPC equivalente a las rutas REST (definiciones en api/proto/secop.proto). Cada RPC
+// aplica las reglas de su ruta: credenciales en los metadatos authorization o x-api-key,
+// roles, modo de mantenimiento, consistencia pedida en x-consistency y, en el PeerService,
+// el certificado del nodo.
+
+// Prefijos de las rutas de los servicios del proto
+const (
+	secopService = "/secop.v1.SecopService/"
+	peerService  = "/secop.v1.PeerService/"
+)
+
+// grpcFromEnv crea el servidor gRPC si GRPC_PORT está configurado
+func grpcFromEnv() (*grpcapi.Server, string) {
+	port := getEnv("GRPC_PORT", "")
+	if port == "" {
+		return nil, ""
+	}
+	return newGRPCServer(), port
+}
+
+// newGRPCServer registra las RPC de SecopService y PeerService
+func newGRPCServer() *grpcapi.Server {
+	server := grpcapi.NewServer()
+
+	grpcapi.Unary(server, secopService+"Login", grpcLogin)
+	grpcapi.Unary(server, secopService+"Health", grpcHealth)
+	grpcapi.Unary(server, secopService+"ListBlocks", grpcListBlocks)
+	grpcapi.Unary(server, secopService+"GetBlock", grpcGetBlock)
+	grpcapi.ServerStream(server, secopService+"WatchBlocks", func(ctx context.Context, req *grpcapi.WatchBlocksRequest, send func(*grpcapi.Block) error) error {
+		return grpcWatchBlocks(ctx, req, nil, send)
+	})
+	grpcapi.Unary(server, secopService+"ListContracts", grpcListContracts)
+	grpcapi.Unary(server, secopService+"GetContract", grpcGetContract)
+	grpcapi.Unary(server, secopService+"CreateContract", grpcCreateContract)
+	grpcapi.Unary(server, secopService+"GetWorkflowSteps", grpcGetWorkflowSteps)
+	grpcapi.Unary(server, secopService+"GetContractWorkflow", grpcGetContractWorkflow)
+	grpcapi.Unary(server, secopService+"ValidateStep", grpcValidateStep)
+	grpcapi.Unary(server, secopService+"AddObservation", grpcAddObservation)
+	grpcapi.Unary(server, secopService+"RespondObservation", grpcRespondObservation)
+	grpcapi.Unary(server, secopService+"RegisterPayment", grpcRegisterPayment)
+
+	grpcapi.Unary(server, peerService+"ReceiveBlock", grpcReceiveBlock)
+	grpcapi.Unary(server, peerService+"GetBlocksPage", grpcGetBlocksPage)
+	grpcapi.ServerStream(server, peerService+"WatchBlocks", func(ctx context.Context, req *grpcapi.WatchBlocksRequest, send func(*grpcapi.Block) error) error {
+		if _, err := grpcPeer(ctx); err != nil {
+			return err
+		}
+		// Los bloques reservados llegan retenidos según la política del nodo, como en /api/p2p/blocks
+		nodeID := grpcapi.Request(ctx).Header.Get("X-Node-ID")
+		return grpcWatchBlocks(ctx, req, func(blocks []blockchain.Block) []blockchain.Block {
+			return p2pNetwork.BlocksFor(nodeID, blocks)
+		}, send)
+	})
+
+	return server
+}
+
+// grpcStatus convierte el status HTTP con el que REST rechaza una petición en el código gRPC
+func grpcStatus(status int, err error) error {
+	codes := map[int]grpcapi.Code{
+		http.StatusBadRequest:          grpcapi.InvalidArgument,
+		http.StatusUnauthorized:        grpcapi.Unauthenticated,
+		http.StatusForbidden:           grpcapi.PermissionDenied,
+		http.StatusNotFound:            grpcapi.NotFound,
+		http.StatusConflict:            grpcapi.Aborted,
+		http.StatusUnprocessableEntity: grpcapi.FailedPrecondition,
+		http.StatusServiceUnavailable:  grpcapi.Unavailable,
+	}
+	code, exists := codes[status]
+	if !exists {
+		code = grpcapi.Internal
+	}
+	return grpcapi.Errorf(code, "%s", err.Error())
+}
+
+// grpcContractError convierte los errores del ledger al radicar un contrato como REST (ver
+// contractErrorStatus); un dato inválido es un argumento inválido y nombra el campo
+func grpcContractError(err error) error {
+	var field *blockchain.ContractFieldError
+	if errors.As(err, &field) {
+		return grpcapi.Errorf(grpcapi.InvalidArgument, "%s: %s", field.Field, field.Message)
+	}
+	return grpcStatus(contractErrorStatus(err), err)
+}
+
+// grpcUser autentica la RPC con los metadatos, como authRequired y authorize
+func grpcUser(ctx context.Context, roles []blockchain.AdminRole, scopes ...string) (*auth.Claims, error) {
+	user, status, err := authenticate(grpcapi.Request(ctx).Header, scopes...)
+	if err != nil {
+		return nil, grpcStatus(status, err)
+	}
+	if !hasRole(user, roles...) {
+		return nil, grpcapi.Errorf(grpcapi.PermissionDenied, "el rol %s no está autorizado para esta operación", user.Role)
+	}
+	return user, nil
+}
+
+// grpcWritable rechaza las escrituras mientras el nodo está en mantenimiento, como maintenanceGuard
+func grpcWritable() error {
+	if maintenance.IsEnabled() {
+		return grpcapi.Errorf(grpcapi.Unavailable, "nodo en mantenimiento, escrituras congeladas: %s", maintenance.Status().Reason)
+	}
+	return nil
+}
+
+// grpcConsistency aplica el nivel de consistencia del metadato x-consistency, como consistencyGuard
+func grpcConsistency(ctx context.Context) error {
+	switch level := grpcapi.Request(ctx).Header.Get("X-Consistency"); level {
+	case "", blockchain.ConsistencyLocal:
+		return nil
+	case blockchain.ConsistencyQuorum:
+		if _, err := p2pNetwork.EnsureQuorum(); err != nil {
+			return grpcapi.Errorf(grpcapi.Unavailable, "%v", err)
+		}
+		return nil
+	default:
+		return grpcapi.Errorf(grpcapi.InvalidArgument, "nivel de consistencia inválido (local o quorum)")
+	}
+}
+
+// grpcPeer exige el certificado del nodo como peerCertRequired y retorna su ID, o "" sin TLS
+func grpcPeer(ctx context.Context) (string, error) {
+	if nodeTLS == nil {
+		return "", nil
+	}
+	req := grpcapi.Request(ctx)
+	if _, err := blockchain.PeerCertificate(req); err != nil {
+		return "", grpcapi.Errorf(grpcapi.PermissionDenied, "%v", err)
+	}
+	nodeID, err := blockchain.PeerNodeID(req)
+	if header := req.Header.Get("X-Node-ID"); header != "" && (err != nil || nodeID != header) {
+		return "", grpcapi.Errorf(grpcapi.PermissionDenied, "x-node-id no corresponde al certificado del nodo")
+	}
+	if err != nil {
+		return "", nil
+	}
+	return nodeID, nil
+}
+
+// grpcContractEntity es checkContractEntity para gRPC
+func grpcContractEntity(user *auth.Claims, contractID string) error {
+	contract, exists := bc.Contract(contractID)
+	if !exists {
+		return grpcapi.Errorf(grpcapi.NotFound, "contrato no encontrado")
+	}
+	if !entityAllowed(user, contract.EntityCode) {
+		return grpcapi.Errorf(grpcapi.PermissionDenied, "%s", entityScopeMessage(user))
+	}
+	return nil
+}
+
+func grpcLogin(ctx context.Context, req *grpcapi.LoginRequest) (*grpcapi.LoginResponse, error) {
+	if req.UserID == "" || req.Password == "" {
+		return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "user_id y password son requeridos")
+	}
+	user, err := authDirectory.Authenticate(req.UserID, req.Password)
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.Unauthenticated, "%v", err)
+	}
+	token, claims, err := authIssuer.Issue(user)
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.Internal, "%v", err)
+	}
+	return &grpcapi.LoginResponse{
+		Token:     token,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+		Role:      string(user.Role),
+	}, nil
+}
+
+func grpcHealth(ctx context.Context, _ *grpcapi.Empty) (*grpcapi.HealthResponse, error) {
+	return &grpcapi.HealthResponse{
+		Status:    "healthy",
+		NodeID:    p2pNetwork.NodeID,
+		Blocks:    int64(blockchain.ByzantineReportedHeight(bc.Len())),
+		Contracts: int64(bc.ContractCount()),
+	}, nil
+}
+
+func grpcListBlocks(ctx context.Context, req *grpcapi.ListBlocksRequest) (*grpcapi.ListBlocksResponse, error) {
+	if err := grpcConsistency(ctx); err != nil {
+		return nil, err
+	}
+	page, err := bc.BlocksFrom(int(req.Offset), int(req.Limit))
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "%v", err)
+	}
+	return &grpcapi.ListBlocksResponse{
+		Blocks: grpcapi.FromBlocks(page.Blocks),
+		Height: int64(page.Height),
+	}, nil
+}
+
+func grpcGetBlock(ctx context.Context, req *grpcapi.GetBlockRequest) (*grpcapi.Block, error) {
+	if err := grpcConsistency(ctx); err != nil {
+		return nil, err
+	}
+	var block *blockchain.Block
+	var err error
+	switch {
+	case req.Hash != nil:
+		block, err = bc.BlockByHash(*req.Hash)
+	case req.Height != nil:
+		block, err = bc.BlockAt(int(*req.Height))
+	default:
+		return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "se requiere hash o height")
+	}
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.NotFound, "%v", err)
+	}
+	return grpcapi.FromBlock(block), nil
+}
+
+// grpcWatchBlocks envía cada bloque nuevo en orden de altura, esperándolos como
+// GET /api/blocks/wait. Si la cadena se reemplaza por una más corta que la altura ya
+// entregada, el flujo termina con FAILED_PRECONDITION para que el cliente se resincronice.
+func grpcWatchBlocks(ctx context.Context, req *grpcapi.WatchBlocksRequest, filter func([]blockchain.Block) []blockchain.Block, send func(*grpcapi.Block) error) error {
+	afterHeight := bc.Len() - 1
+	if req.AfterHeight != nil {
+		if *req.AfterHeight < 0 {
+			return grpcapi.Errorf(grpcapi.InvalidArgument, "after_height inválido")
+		}
+		afterHeight = int(*req.AfterHeight)
+	}
+
+	for {
+		waited, err := bc.WaitForBlocks(ctx, afterHeight, blockchain.MaxBlockWait)
+		if err != nil {
+			return grpcapi.Errorf(grpcapi.Internal, "%v", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if len(waited) == 0 {
+			if tip := bc.Len() - 1; tip < afterHeight {
+				return grpcapi.Errorf(grpcapi.FailedPrecondition, "la cadena fue reemplazada: la punta quedó en la altura %d", tip)
+			}
+			continue
+		}
+
+		blocks := make([]blockchain.Block, len(waited))
+		for i, block := range waited {
+			blocks[i] = *block
+		}
+		if filter != nil {
+			blocks = filter(blocks)
+		}
+		for i := range blocks {
+			if err := send(grpcapi.FromBlock(&blocks[i])); err != nil {
+				return err
+			}
+		}
+		afterHeight = waited[len(waited)-1].Index
+	}
+}
+
+func grpcListContracts(ctx context.Context, req *grpcapi.ListContractsRequest) (*grpcapi.ListContractsResponse, error) {
+	if err := grpcConsistency(ctx); err != nil {
+		return nil, err
+	}
+	query := blockchain.ContractQuery{
+		EntityCode:   req.EntityCode,
+		ContractType: req.ContractType,
+		Status:       blockchain.ContractStatus(req.Status),
+		MinAmount:    req.MinAmount,
+		MaxAmount:    req.MaxAmount,
+		Sort:         req.Sort,
+		Ascending:    req.Ascending,
+		Limit:        int(req.Limit),
+		Offset:       int(req.Offset),
+	}
+	if err := query.Validate(); err != nil {
+		return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "%v", err)
+	}
+	if query.Limit < 0 || query.Offset < 0 {
+		return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "limit y offset no pueden ser negativos")
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultContractPageSize
+	}
+	if query.Limit > maxContractPageSize {
+		query.Limit = maxContractPageSize
+	}
+	if !req.From.IsZero() {
+		query.From = &req.From
+	}
+	if !req.To.IsZero() {
+		query.To = &req.To
+	}
+
+	contracts, err := bc.QueryContracts(query)
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.Internal, "%v", err)
+	}
+	total, err := bc.CountContracts(query)
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.Internal, "%v", err)
+	}
+
+	response := &grpcapi.ListContractsResponse{Total: int64(total)}
+	for _, contract := range contracts {
+		message, err := grpcapi.FromContract(contract)
+		if err != nil {
+			return nil, grpcapi.Errorf(grpcapi.Internal, "%v", err)
+		}
+		response.Contracts = append(response.Contracts, message)
+	}
+	return response, nil
+}
+
+func grpcGetContract(ctx context.Context, req *grpcapi.GetContractRequest) (*grpcapi.Contract, error) {
+	if err := grpcConsistency(ctx); err != nil {
+		return nil, err
+	}
+	contract, err := bc.GetContract(req.ContractID)
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.NotFound, "%v", err)
+	}
+	message, err := grpcapi.FromContract(contract)
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.Internal, "%v", err)
+	}
+	return message, nil
+}
+
+// grpcCreateContract radica un contrato como POST /api/contracts, con la llave de
+// idempotencia en el metadato idempotency-key
+func grpcCreateContract(ctx context.Context, req *grpcapi.CreateContractRequest) (*grpcapi.CreateContractResponse, error) {
+	user, err := grpcUser(ctx, contractCreatorRoles, auth.ScopeContractCreate)
+	if err != nil {
+		return nil, err
+	}
+	if err := grpcWritable(); err != nil {
+		return nil, err
+	}
+
+	contract := blockchain.Contract{
+		EntityCode:    req.EntityCode,
+		EntityName:    req.EntityName,
+		ContractType:  req.ContractType,
+		Description:   req.Description,
+		Amount:        req.Amount,
+		ProcessNumber: req.ProcessNumber,
+		Reserved:      req.Reserved,
+	}
+	if !entityAllowed(user, contract.EntityCode) {
+		return nil, grpcapi.Errorf(grpcapi.PermissionDenied, "%s", entityScopeMessage(user))
+	}
+	if !validCreator(user.Subject) {
+		return nil, grpcapi.Errorf(grpcapi.PermissionDenied, "la sesión %s no se identifica con un correo y no puede radicar contratos", user.Subject)
+	}
+
+	idempotencyKey := grpcapi.Request(ctx).Header.Get(idempotencyKeyHeader)
+	if idempotencyKey != "" {
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "%s admite hasta %d caracteres", idempotencyKeyHeader, maxIdempotencyKeyLength)
+		}
+		requestHash, err := blockchain.IdempotencyRequestHash(contract)
+		if err != nil {
+			return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "%v", err)
+		}
+		record, err := bc.IdempotencyKeys.Begin(user.Subject, idempotencyKey, requestHash)
+		switch {
+		case errors.Is(err, blockchain.ErrIdempotencyKeyReused):
+			return nil, grpcapi.Errorf(grpcapi.FailedPrecondition, "%v", err)
+		case errors.Is(err, blockchain.ErrIdempotencyKeyInFlight):
+			return nil, grpcapi.Errorf(grpcapi.Aborted, "%v", err)
+		case record != nil:
+			return &grpcapi.CreateContractResponse{ContractID: record.ContractID, Replayed: true}, nil
+		}
+	}
+
+	contract.CreatedBy = user.Subject
+	if err := bc.AddContract(&contract); err != nil {
+		if idempotencyKey != "" {
+			bc.IdempotencyKeys.Release(user.Subject, idempotencyKey)
+		}
+		return nil, grpcContractError(err)
+	}
+	if idempotencyKey != "" {
+		bc.IdempotencyKeys.Complete(user.Subject, idempotencyKey, contract.ID)
+	}
+	return &grpcapi.CreateContractResponse{ContractID: contract.ID}, nil
+}
+
+func grpcGetWorkflowSteps(ctx context.Context, _ *grpcapi.Empty) (*grpcapi.WorkflowStepsResponse, error) {
+	response := &grpcapi.WorkflowStepsResponse{}
+	for _, step := range workflowManager.GetWorkflowSteps() {
+		response.Steps = append(response.Steps, grpcapi.FromWorkflowStep(step))
+	}
+	return response, nil
+}
+
+func grpcGetContractWorkflow(ctx context.Context, req *grpcapi.GetContractRequest) (*grpcapi.WorkflowStatus, error) {
+	if err := grpcConsistency(ctx); err != nil {
+		return nil, err
+	}
+	return grpcWorkflowStatus(req.ContractID)
+}
+
+// grpcWorkflowStatus arma el avance del flujo del contrato de GET /api/contracts/:id/workflow
+func grpcWorkflowStatus(contractID string) (*grpcapi.WorkflowStatus, error) {
+	status, err := workflowManager.GetWorkflowStatus(contractID)
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.NotFound, "%v", err)
+	}
+	message := &grpcapi.WorkflowStatus{ContractID: contractID}
+	message.Status, _ = status["status"].(string)
+	if value, ok := status["current_step"].(int); ok {
+		message.CurrentStep = int32(value)
+	}
+	if value, ok := status["total_steps"].(int); ok {
+		message.TotalSteps = int32(value)
+	}
+	if value, ok := status["completed_steps"].(int); ok {
+		message.CompletedSteps = int32(value)
+	}
+	message.Progress, _ = status["progress"].(float64)
+	if steps, ok := status["validation_steps"].([]blockchain.ValidationStep); ok {
+		message.ValidationSteps = grpcapi.FromValidationSteps(steps)
+	}
+	if trail, ok := status["audit_trail"].([]blockchain.AuditEntry); ok {
+		message.AuditTrail = grpcapi.FromAuditTrail(trail)
+	}
+	message.CreatedAt, _ = status["created_at"].(time.Time)
+	message.UpdatedAt, _ = status["updated_at"].(time.Time)
+	return message, nil
+}
+
+func grpcValidateStep(ctx context.Context, req *grpcapi.ValidateStepRequest) (*grpcapi.WorkflowStatus, error) {
+	user, err := grpcUser(ctx, workflowRoles)
+	if err != nil {
+		return nil, err
+	}
+	if err := grpcWritable(); err != nil {
+		return nil, err
+	}
+
+	signature := blockchain.StepSignature{KeyID: req.KeyID, Signature: req.Signature, SignedAt: req.SignedAt}
+	var act *blockchain.StepAct
+	if req.Act != nil && req.Act.Hash != "" {
+		act = &blockchain.StepAct{Hash: req.Act.Hash, Reference: req.Act.Reference}
+	}
+	err = workflowManager.ValidateStep(req.ContractID, int(req.StepNumber), user.Subject, user.Name, user.Role, req.Approved, req.Comments, signature, act)
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "%v", err)
+	}
+	return grpcWorkflowStatus(req.ContractID)
+}
+
+func grpcAddObservation(ctx context.Context, req *grpcapi.AddObservationRequest) (*grpcapi.AuditObservation, error) {
+	user, err := grpcUser(ctx, auditorRoles, auth.ScopeAuditOnly)
+	if err != nil {
+		return nil, err
+	}
+	if err := grpcWritable(); err != nil {
+		return nil, err
+	}
+	if err := grpcContractEntity(user, req.ContractID); err != nil {
+		return nil, err
+	}
+
+	observation, err := workflowManager.AddAuditObservation(req.ContractID, user.Subject, user.Role, req.Observation)
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "%v", err)
+	}
+	return grpcapi.FromAuditObservation(observation), nil
+}
+
+func grpcRespondObservation(ctx context.Context, req *grpcapi.RespondObservationRequest) (*grpcapi.AuditObservation, error) {
+	user, err := grpcUser(ctx, workflowRoles)
+	if err != nil {
+		return nil, err
+	}
+	if err := grpcWritable(); err != nil {
+		return nil, err
+	}
+	if err := grpcContractEntity(user, req.ContractID); err != nil {
+		return nil, err
+	}
+
+	observation, err := workflowManager.RespondObservation(req.ContractID, req.ObservationID, user.Subject, user.Role, req.Response)
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "%v", err)
+	}
+	return grpcapi.FromAuditObservation(observation), nil
+}
+
+func grpcRegisterPayment(ctx context.Context, req *grpcapi.RegisterPaymentRequest) (*grpcapi.ContractPayment, error) {
+	user, err := grpcUser(ctx, treasuryRoles)
+	if err != nil {
+		return nil, err
+	}
+	if err := grpcWritable(); err != nil {
+		return nil, err
+	}
+	if err := grpcContractEntity(user, req.ContractID); err != nil {
+		return nil, err
+	}
+	if req.Amount == 0 {
+		return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "amount es requerido")
+	}
+
+	payment, err := fiscalLedger.RegisterPayment(req.ContractID, req.Amount, req.Reference, req.PaidAt, user.Subject, user.Role)
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "%v", err)
+	}
+	return grpcapi.FromPayment(payment), nil
+}
+
+// grpcReceiveBlock procesa un bloque enviado por un peer, como POST /api/p2p/receive-block
+func grpcReceiveBlock(ctx context.Context, req *grpcapi.Block) (*grpcapi.ReceiveBlockResponse, error) {
+	sender, err := grpcPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := grpcWritable(); err != nil {
+		return nil, err
+	}
+	if err := p2pNetwork.ReceiveBlock(req.ToBlock(), sender); err != nil {
+		return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "%v", err)
+	}
+	return &grpcapi.ReceiveBlockResponse{Accepted: true}, nil
+}
+
+// grpcGetBlocksPage entrega una página de bloques para la sincronización incremental,
+// como GET /api/p2p/blocks
+func grpcGetBlocksPage(ctx context.Context, req *grpcapi.BlocksPageRequest) (*grpcapi.BlocksPage, error) {
+	if _, err := grpcPeer(ctx); err != nil {
+		return nil, err
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = blockchain.SyncPageSize
+	}
+	page, err := bc.BlocksFrom(int(req.From), limit)
+	if err != nil {
+		return nil, grpcapi.Errorf(grpcapi.InvalidArgument, "%v", err)
+	}
+	blocks := p2pNetwork.BlocksFor(grpcapi.Request(ctx).Header.Get("X-Node-ID"), page.Blocks)
+	return &grpcapi.BlocksPage{
+		Blocks:     grpcapi.FromBlocks(blocks),
+		FromHeight: int64(page.FromHeight),
+		Height:     int64(blockchain.ByzantineReportedHeight(page.Height)),
+		HasMore:    page.HasMore,
+		NodeID:     p2pNetwork.NodeID,
+	}, nil
+}
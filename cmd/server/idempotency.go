@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+)
+
+// Encabezado con el que el cliente identifica una petición que puede reintentar
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// Longitud máxima de la llave de idempotencia
+const maxIdempotencyKeyLength = 255
+
+// idempotencyTTLFromEnv lee cuántas horas se recuerda una llave de idempotencia
+func idempotencyTTLFromEnv() time.Duration {
+	hours, err := strconv.Atoi(getEnv("IDEMPOTENCY_KEY_TTL_HOURS", ""))
+	if err != nil || hours <= 0 {
+		return blockchain.DefaultIdempotencyTTL
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// startIdempotencySweep descarta cada hora las llaves de idempotencia vencidas
+func startIdempotencySweep() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if removed := bc.IdempotencyKeys.Sweep(time.Now()); removed > 0 {
+			fmt.Printf("🧹 %d llaves de idempotencia vencidas descartadas\n", removed)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de ingreso de nodos a la red con tokens de un solo uso
+
+// joinFromEnv incorpora el nodo a la red al arrancar si se configuró JOIN_PEER
+// ("direccion:puerto" de un miembro) junto con JOIN_TOKEN
+func joinFromEnv() (*blockchain.JoinResponse, error) {
+	member := getEnv("JOIN_PEER", "")
+	if member == "" {
+		return nil, nil
+	}
+	token := getEnv("JOIN_TOKEN", "")
+	if token == "" {
+		return nil, fmt.Errorf("JOIN_PEER requiere JOIN_TOKEN")
+	}
+	separator := strings.LastIndex(member, ":")
+	if separator <= 0 {
+		return nil, fmt.Errorf("JOIN_PEER inválido %q, se esperaba direccion:puerto", member)
+	}
+	return p2pNetwork.Join(member[:separator], member[separator+1:], token)
+}
+
+func joinNetwork(c *gin.Context) {
+	var req blockchain.JoinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := p2pNetwork.AcceptJoin(joinTokens, req)
+	if err == blockchain.ErrInvalidJoinToken {
+		respondError(c, http.StatusUnauthorized, err)
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func getJoinTokens(c *gin.Context) {
+	tokens := joinTokens.List()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(tokens),
+		"data":    tokens,
+	})
+}
+
+// issueJoinTokenRequest es el cuerpo de POST /api/admin/join-tokens
+type issueJoinTokenRequest struct {
+	Note       string `json:"note"`
+	TTLMinutes int    `json:"ttl_minutes"`
+}
+
+// issueJoinToken emite un token de ingreso; el secreto solo se muestra en esta respuesta
+func issueJoinToken(c *gin.Context) {
+	var req issueJoinTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if req.TTLMinutes < 0 {
+		respondErrorMessage(c, http.StatusBadRequest, "ttl_minutes no puede ser negativo")
+		return
+	}
+
+	token, secret, err := joinTokens.Issue(currentUser(c).Subject, req.Note, time.Duration(req.TTLMinutes)*time.Minute)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"token":   token,
+		"secret":  secret,
+	})
+}
+
+func revokeJoinToken(c *gin.Context) {
+	if err := joinTokens.Revoke(c.Param("id")); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
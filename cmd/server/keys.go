@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de descubrimiento y rotación de llaves públicas
+
+// getJWKS publica las llaves del nodo, de los sistemas de entidades y de los funcionarios
+// validadores en formato JWKS
+func getJWKS(c *gin.Context) {
+	keys := []blockchain.JWK{}
+
+	for _, info := range p2pNetwork.Keys.PublicKeys() {
+		if jwk, err := info.ToJWK("node:" + p2pNetwork.NodeID); err == nil {
+			keys = append(keys, jwk)
+		}
+	}
+
+	for _, systemKey := range bc.AllSystemKeys() {
+		info := blockchain.PublicKeyInfo{
+			PublicKey: systemKey.PublicKey,
+			CreatedAt: systemKey.RegisteredAt,
+		}
+		jwk, err := info.ToJWK("system:" + systemKey.EntityCode + "/" + systemKey.SystemID)
+		if err != nil {
+			continue
+		}
+		jwk.Kid = systemKey.EntityCode + "/" + systemKey.SystemID
+		keys = append(keys, jwk)
+	}
+
+	for _, validatorKey := range bc.AllValidatorKeys() {
+		info := blockchain.PublicKeyInfo{
+			KeyID:     validatorKey.KeyID,
+			PublicKey: validatorKey.PublicKey,
+			CreatedAt: validatorKey.RegisteredAt,
+		}
+		jwk, err := info.ToJWK("official:" + validatorKey.ValidatorID)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// getValidatorKeys lista las llaves de los nodos validadores conocidos
+func getValidatorKeys(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=60")
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"validators":   p2pNetwork.ValidatorKeys(),
+		"endorsements": p2pNetwork.ValidatorKeyEndorsements(),
+	})
+}
+
+func rotateNodeKey(c *gin.Context) {
+	previousKeyID := p2pNetwork.Keys.ActiveKeyID()
+	info, err := p2pNetwork.Keys.Rotate()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// En prueba de autoridad las llaves nuevas solo valen una vez registradas en la cadena
+	if err := bc.PublishSignerKeys(previousKeyID); err != nil {
+		respondErrorMessage(c, http.StatusInternalServerError, fmt.Sprintf("llave rotada pero no registrada en la cadena: %v", err))
+		return
+	}
+	fmt.Printf("🔑 Llave de firma del nodo rotada por %s: %s\n", currentUser(c).Subject, info.KeyID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"key":     info,
+	})
+}
+
+// registerOfficialKeyRequest es el cuerpo de POST /api/keys/register
+type registerOfficialKeyRequest struct {
+	PublicKey string `json:"public_key" binding:"required"`
+}
+
+// registerOfficialKey inscribe la llave pública con la que el funcionario autenticado
+// firmará sus decisiones en el flujo de validación
+func registerOfficialKey(c *gin.Context) {
+	var req registerOfficialKeyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user := currentUser(c)
+	key, err := bc.RegisterValidatorKey(user.Subject, user.Role, req.PublicKey)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"key":     key,
+	})
+}
+
+// getOfficialKeys lista las llaves de firma registradas por un funcionario
+func getOfficialKeys(c *gin.Context) {
+	keys := bc.GetValidatorKeys(c.Param("id"))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(keys),
+		"data":    keys,
+	})
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de las metas de gestión contractual y el cumplimiento por entidad
+
+// complianceCheckIntervalFromEnv lee cada cuánto se revisa si ya cerró un mes sin informe
+func complianceCheckIntervalFromEnv() time.Duration {
+	hours, err := strconv.Atoi(getEnv("KPI_COMPLIANCE_CHECK_HOURS", "6"))
+	if err != nil || hours <= 0 {
+		hours = 6
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// getKPITargets retorna las metas vigentes
+func getKPITargets(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"targets": complianceScorer.Targets(),
+	})
+}
+
+// setKPITargetsRequest es el cuerpo de PUT /api/analytics/kpi-targets
+type setKPITargetsRequest struct {
+	MaxDaysToAward        float64 `json:"max_days_to_award" binding:"required"`
+	MinCompetitivePercent float64 `json:"min_competitive_percent"`
+	MaxDirectShare        float64 `json:"max_direct_contracting_share"`
+}
+
+// setKPITargets reemplaza las metas; los informes ya calculados conservan las suyas
+func setKPITargets(c *gin.Context) {
+	var req setKPITargetsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	targets, err := complianceScorer.SetTargets(blockchain.KPITargets{
+		MaxDaysToAward:        req.MaxDaysToAward,
+		MinCompetitivePercent: req.MinCompetitivePercent,
+		MaxDirectShare:        req.MaxDirectShare,
+	}, currentUser(c).Subject)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"targets": targets,
+	})
+}
+
+// getComplianceScores retorna el informe de cumplimiento del periodo (?period=AAAA-MM, el
+// más reciente por defecto), opcionalmente solo de una entidad
+func getComplianceScores(c *gin.Context) {
+	var report *blockchain.ComplianceReport
+	if period := c.Query("period"); period != "" {
+		stored, exists := complianceScorer.Report(period)
+		if !exists {
+			respondErrorMessage(c, http.StatusNotFound, "no hay informe de cumplimiento para el periodo")
+			return
+		}
+		report = stored
+	} else if report = complianceScorer.Latest(); report == nil {
+		respondErrorMessage(c, http.StatusNotFound, "aún no se ha calculado ningún informe de cumplimiento")
+		return
+	}
+
+	if entityCode := c.Query("entity_code"); entityCode != "" {
+		report = report.ForEntity(entityCode)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"periods": complianceScorer.Periods(),
+		"report":  report,
+	})
+}
+
+// scoreComplianceRequest es el cuerpo de POST /api/analytics/compliance/run
+type scoreComplianceRequest struct {
+	Period string `json:"period"`
+}
+
+// scoreCompliance calcula el cumplimiento de un periodo sin esperar el cierre del mes;
+// sin periodo calcula el mes en curso
+func scoreCompliance(c *gin.Context) {
+	var req scoreComplianceRequest
+	c.ShouldBindJSON(&req)
+	if req.Period == "" {
+		req.Period = time.Now().Format("2006-01")
+	}
+
+	report, err := complianceScorer.Score(req.Period)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"report":  report,
+	})
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+
+	"secop-blockchain/pkg/blockchain"
+)
+
+// libp2pConfigFromEnv lee la configuración del transporte P2P_BACKEND=libp2p:
+// LIBP2P_LISTEN, LIBP2P_KEY_FILE y las listas separadas por comas LIBP2P_ANNOUNCE y
+// LIBP2P_BOOTSTRAP
+func libp2pConfigFromEnv(nodeID string) blockchain.Libp2pConfig {
+	return blockchain.Libp2pConfig{
+		ListenAddr: getEnv("LIBP2P_LISTEN", "/ip4/0.0.0.0/tcp/4001"),
+		KeyFile:    getEnv("LIBP2P_KEY_FILE", "./data/"+nodeID+".libp2p.key"),
+		Announce:   splitEnvList(getEnv("LIBP2P_ANNOUNCE", "")),
+		Bootstrap:  splitEnvList(getEnv("LIBP2P_BOOTSTRAP", "")),
+	}
+}
+
+// splitEnvList separa una lista de valores por comas, sin los vacíos
+func splitEnvList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
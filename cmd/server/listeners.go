@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Listeners separados para la API pública y el tráfico entre nodos. Con P2P_PORT distinto
+// de NODE_PORT las rutas entre nodos solo se atienden en ese puerto, de modo que el
+// firewall puede limitarlo a la red de gobierno mientras la API pública sale a internet.
+
+// p2pListener es la configuración del listener de las rutas entre nodos
+type p2pListener struct {
+	port       string
+	separate   bool // Atiende en un puerto distinto al de la API pública
+	requireTLS bool // Rechaza en el handshake a quien no presente certificado de nodo
+}
+
+// p2pListenerFromEnv lee P2P_PORT y P2P_REQUIRE_MTLS; sin P2P_PORT las rutas entre nodos
+// comparten el puerto de la API pública
+func p2pListenerFromEnv(nodePort string) (p2pListener, error) {
+	listener := p2pListener{port: getEnv("P2P_PORT", nodePort)}
+	listener.separate = listener.port != nodePort
+	listener.requireTLS = getEnv("P2P_REQUIRE_MTLS", "false") == "true"
+
+	if listener.requireTLS {
+		if nodeTLS == nil {
+			return listener, errors.New("P2P_REQUIRE_MTLS requiere TLS_CERT_FILE, TLS_KEY_FILE y TLS_CA_FILE")
+		}
+		// Exigir certificado en el handshake del puerto compartido dejaría fuera a los ciudadanos
+		if !listener.separate {
+			return listener, errors.New("P2P_REQUIRE_MTLS requiere un P2P_PORT distinto de NODE_PORT")
+		}
+	}
+	return listener, nil
+}
+
+// router retorna el router de las rutas entre nodos: el de la API pública si comparten
+// puerto o uno propio si no
+func (l p2pListener) router(public *gin.Engine) *gin.Engine {
+	if !l.separate {
+		return public
+	}
+	router := gin.Default()
+	router.Use(traceRequests())
+	router.Use(trafficMonitor())
+	return router
+}
+
+// tlsConfig retorna la configuración TLS del listener entre nodos, o nil sin TLS
+func (l p2pListener) tlsConfig() *tls.Config {
+	if nodeTLS == nil {
+		return nil
+	}
+	config := nodeTLS.Server.Clone()
+	if l.requireTLS {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config
+}
+
+// publicTLSConfig retorna la configuración TLS de la API pública, o nil sin TLS
+func publicTLSConfig() *tls.Config {
+	if nodeTLS == nil {
+		return nil
+	}
+	return nodeTLS.Server
+}
+
+// serve atiende el handler en el puerto, por HTTPS si hay configuración TLS
+func serve(name, address, port string, handler http.Handler, config *tls.Config) error {
+	if config == nil {
+		fmt.Printf("🔗 %s disponible en http://%s:%s/api/\n", name, address, port)
+		return http.ListenAndServe(":"+port, handler)
+	}
+
+	fmt.Printf("🔗 %s disponible en https://%s:%s/api/\n", name, address, port)
+	server := &http.Server{
+		Addr:      ":" + port,
+		Handler:   handler,
+		TLSConfig: config,
+	}
+	return server.ListenAndServeTLS("", "")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// Stream de eventos en vivo (Server-Sent Events) para los tableros
+
+// Intervalo de los comentarios que mantienen viva la conexión ante proxies
+const liveKeepAlive = 15 * time.Second
+
+// streamEvents mantiene abierta la conexión y emite cada evento como SSE con su id, de
+// modo que el navegador retoma con Last-Event-ID los que se perdió al reconectarse
+func streamEvents(c *gin.Context) {
+	var lastID int64
+	if value := c.GetHeader("Last-Event-ID"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			respondErrorMessage(c, http.StatusBadRequest, "Last-Event-ID inválido")
+			return
+		}
+		lastID = parsed
+	}
+
+	id, missed, events := liveFeed.Subscribe(lastID)
+	defer func() {
+		if dropped := liveFeed.Unsubscribe(id); dropped > 0 {
+			fmt.Printf("⚠️ Cliente de eventos en vivo desconectado con %d eventos descartados\n", dropped)
+		}
+	}()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, event := range missed {
+		if err := writeLiveEvent(c, event); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(liveKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event := <-events:
+			if err := writeLiveEvent(c, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeLiveEvent escribe un evento en formato SSE
+func writeLiveEvent(c *gin.Context, event blockchain.LiveEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Name, payload)
+	return err
+}
+
+// liveSubscriptions atiende /ws: el cliente elige con mensajes de suscripción los temas,
+// entidades o contratos de los que recibe eventos
+func liveSubscriptions(c *gin.Context) {
+	// Sin Handshake no se valida el Origin: los frontends ciudadanos vienen de cualquier origen
+	server := websocket.Server{Handler: liveFeed.ServeSubscriber}
+	server.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Feed de bloques por long-polling para sistemas que no pueden usar WebSockets ni SSE
+
+// waitForBlocks responde con los bloques posteriores a ?after_height= en cuanto existan,
+// o con una lista vacía si pasan ?timeout= segundos (30 por defecto, máximo 60). Sin
+// after_height espera el siguiente bloque a partir de la punta actual.
+func waitForBlocks(c *gin.Context) {
+	afterHeight := bc.Len() - 1
+	if value := c.Query("after_height"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			respondErrorMessage(c, http.StatusBadRequest, "after_height inválido")
+			return
+		}
+		afterHeight = parsed
+	}
+
+	timeoutSeconds, err := strconv.Atoi(c.DefaultQuery("timeout", "30"))
+	if err != nil || timeoutSeconds < 0 {
+		respondErrorMessage(c, http.StatusBadRequest, "timeout inválido")
+		return
+	}
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	if timeout > blockchain.MaxBlockWait {
+		timeout = blockchain.MaxBlockWait
+	}
+
+	blocks, err := bc.WaitForBlocks(c.Request.Context(), afterHeight, timeout)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// El cliente continúa desde next_height; si es menor que su after_height la cadena
+	// fue reemplazada y debe resincronizarse
+	nextHeight := afterHeight
+	if len(blocks) > 0 {
+		nextHeight = blocks[len(blocks)-1].Index
+	} else if tip := bc.Len() - 1; tip < afterHeight {
+		nextHeight = tip
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"count":       len(blocks),
+		"blocks":      blocks,
+		"next_height": nextHeight,
+		"tip_height":  bc.Len() - 1,
+		"tip_hash":    bc.TipHash(),
+	})
+}
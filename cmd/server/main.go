@@ -1,13 +1,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
-	"secop-blockchain/internal/blockchain"
+	"secop-blockchain/internal/auth"
+	"secop-blockchain/pkg/blockchain"
+	"secop-blockchain/pkg/blockchain/storage"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -16,65 +20,682 @@ import (
 var bc *blockchain.Blockchain
 var p2pNetwork *blockchain.P2PNetwork
 var workflowManager *blockchain.WorkflowManager
+var maintenance *blockchain.MaintenanceMode
+var subscriptions *blockchain.SubscriptionManager
+var liveFeed *blockchain.LiveFeed
+var webhooks *blockchain.WebhookManager
+var sandbox *blockchain.Sandbox
+var draftJanitor *blockchain.DraftJanitor
+var evidenceStore *blockchain.EvidenceStore
+var attachmentStore *blockchain.AttachmentStore
+var workQueue *blockchain.WorkQueue
+var alertManager *blockchain.AlertManager
+var observationTracker *blockchain.ObservationTracker
+var processNumberAuditor *blockchain.ProcessNumberAuditor
+var contractClusterer *blockchain.ContractClusterer
+var complianceScorer *blockchain.ComplianceScorer
+var fiscalLedger *blockchain.FiscalLedger
+var amendmentManager *blockchain.AmendmentManager
+var milestoneTracker *blockchain.MilestoneTracker
+var secopBridge *blockchain.SecopBridge
+var metricsPusher *blockchain.MetricsPusher
+var queryEngine *blockchain.QueryEngine
+var backupKMS blockchain.KMS
+var nodeTLS *blockchain.NodeTLS
+var joinTokens *blockchain.JoinTokenStore
+var apiKeys *auth.APIKeyStore
+var trafficMetrics *auth.TrafficMonitor
+var authIssuer *auth.Issuer
+var authDirectory *auth.Directory
 
 func main() {
+	// Cargar el perfil del ambiente (dev, staging o production) antes que cualquier otra
+	// variable, porque fija sus valores por defecto
+	profile, err := loadProfileFromEnv()
+	if err != nil {
+		fmt.Printf("❌ Error cargando el perfil de configuración: %v\n", err)
+		os.Exit(1)
+	}
+	activeProfile = profile
+	if err := activeProfile.validate(sandboxModeFromEnv()); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	// Los mensajes del ledger se muestran en la consola del nodo según el nivel de registro
+	activeProfile.applyLogging()
+
 	// Obtener configuración del nodo desde variables de entorno
 	nodeID := getEnv("NODE_ID", "DNP-NODE")
 	nodeAddress := getEnv("NODE_ADDRESS", "localhost")
 	nodePort := getEnv("NODE_PORT", "8080")
 	
-	fmt.Printf("🚀 Iniciando nodo %s en %s:%s\n", nodeID, nodeAddress, nodePort)
+	fmt.Printf("🚀 Iniciando nodo %s en %s:%s (versión %s, esquema %d)\n", nodeID, nodeAddress, nodePort, blockchain.Version, blockchain.SchemaVersion)
+	fmt.Printf("⚙️ Perfil %s\n", activeProfile.describe())
+
+	// En modo sandbox la cadena es sintética y vive solo en memoria
+	sandboxMode := sandboxModeFromEnv()
+	if sandboxMode {
+		fmt.Printf("🧪 Modo sandbox: cadena sintética aislada de la red\n")
+	}
+
+	// Abrir almacenamiento persistente de la cadena
+	storageBackend := getEnv("STORAGE_BACKEND", "bolt")
+	if sandboxMode {
+		storageBackend = "memory"
+	}
+	store, err := storage.Open(storageBackend, getEnv("STORAGE_PATH", "./data/"+nodeID+".db"))
+	if err != nil {
+		fmt.Printf("❌ Error abriendo almacenamiento: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Inicializar blockchain (restaurando desde el almacenamiento si existe)
+	bc, err = blockchain.NewBlockchain(store)
+	if err != nil {
+		fmt.Printf("❌ Error inicializando blockchain: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Inicializar blockchain
-	bc = blockchain.NewBlockchain()
+	// Abrir el WAL de bloques y recuperar appends interrumpidos (solo con almacenamiento en disco)
+	defaultWAL := ""
+	if storageBackend != "memory" {
+		defaultWAL = "./data/" + nodeID + ".wal"
+	}
+	if walPath := getEnv("WAL_PATH", defaultWAL); walPath != "" && !sandboxMode {
+		if err := bc.OpenWAL(walPath); err != nil {
+			fmt.Printf("❌ Error recuperando WAL: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	
-	// Inicializar red P2P
-	p2pNetwork = blockchain.NewP2PNetwork(nodeID, nodeAddress, nodePort, bc)
+	// Archivar bloques antiguos fuera de la memoria si está configurado
+	if policy, enabled := archivePolicyFromEnv(nodeID); enabled && !sandboxMode {
+		if err := bc.EnableArchive(policy); err != nil {
+			fmt.Printf("❌ Error activando archivo de bloques: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// El estado de los contratos se deriva de la cadena restaurada, con los bloques archivados
+	if err := bc.ReplayState(); err != nil {
+		fmt.Printf("❌ Error reconstruyendo el estado desde la cadena: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Agrupar las transacciones de contrato en lotes si el mempool está configurado
+	if maxTransactions, interval, enabled := mempoolFromEnv(); enabled {
+		bc.Mempool, err = blockchain.NewMempool(bc, maxTransactions, interval)
+		if err != nil {
+			fmt.Printf("❌ Error activando mempool: %v\n", err)
+			os.Exit(1)
+		}
+		bc.Mempool.TTL = mempoolTTLFromEnv()
+		fmt.Printf("📦 Mempool activo: hasta %d transacciones por bloque cada %v\n", maxTransactions, interval)
+	}
+
+	// Cuánto se recuerdan las llaves de idempotencia de POST /api/contracts
+	bc.IdempotencyKeys.TTL = idempotencyTTLFromEnv()
+
+	// Usar PostgreSQL para las consultas de contratos si está configurado
+	if dsn := getEnv("CONTRACTS_DSN", ""); dsn != "" && !sandboxMode {
+		contractStore, err := blockchain.NewPostgresContractStore(dsn)
+		if err != nil {
+			fmt.Printf("❌ Error abriendo store de contratos: %v\n", err)
+			os.Exit(1)
+		}
+		if err := bc.SetContractStore(contractStore); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("🐘 Consultas de contratos servidas desde PostgreSQL\n")
+	}
+
+	// Configurar activaciones de features del protocolo
+	activations, err := blockchain.ParseActivations(getEnv("PROTOCOL_ACTIVATIONS", ""))
+	if err != nil {
+		fmt.Printf("❌ PROTOCOL_ACTIVATIONS inválido: %v\n", err)
+		os.Exit(1)
+	}
+	for feature, height := range activations {
+		bc.Protocol.SetActivation(feature, height)
+		fmt.Printf("🧩 Feature %s se activa en la altura %d\n", feature, height)
+	}
+	
+	// Cargar llaves de firma del nodo
+	nodeKeys, err := blockchain.LoadOrCreateNodeKeyring(getEnv("NODE_KEY_FILE", ""))
+	if err != nil {
+		fmt.Printf("❌ Error cargando llaves del nodo: %v\n", err)
+		os.Exit(1)
+	}
+	
+	// Los bloques que cree este nodo se firman con su llave activa
+	bc.SetSigner(nodeID, nodeKeys)
+
+	// Configurar TLS mutuo entre nodos si hay certificados
+	nodeTLS, err = nodeTLSFromEnv()
+	if err != nil {
+		fmt.Printf("❌ Error cargando certificados TLS: %v\n", err)
+		os.Exit(1)
+	}
+	if err := activeProfile.requireTLS(); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	// Las rutas entre nodos pueden atenderse en un puerto propio
+	peerListener, err := p2pListenerFromEnv(nodePort)
+	if err != nil {
+		fmt.Printf("❌ Error configurando el listener P2P: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Inicializar red P2P; los peers nos contactan en el puerto del listener P2P
+	p2pNetwork = blockchain.NewP2PNetwork(nodeID, nodeAddress, peerListener.port, bc)
+	p2pNetwork.Keys = nodeKeys
+	fanout, err := strconv.Atoi(getEnv("GOSSIP_FANOUT", strconv.Itoa(blockchain.DefaultGossipFanout)))
+	if err != nil {
+		fmt.Printf("❌ GOSSIP_FANOUT inválido: %v\n", err)
+		os.Exit(1)
+	}
+	p2pNetwork.GossipFanout = fanout
+	p2pNetwork.Reserved.SetAuthorizedNodes(reservedNodesFromEnv())
+	if err := configureReputationFromEnv(p2pNetwork.Reputation); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	p2pNetwork.PeerRetention, err = peerRetentionFromEnv()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	p2pNetwork.Libp2p = libp2pConfigFromEnv(nodeID)
+	if err := p2pNetwork.SetBackend(getEnv("P2P_BACKEND", blockchain.P2PBackendHTTP)); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if peerID, addrs := p2pNetwork.Libp2pID(); peerID != "" {
+		fmt.Printf("🛰️ Transporte libp2p activo como %s en %v\n", peerID, addrs)
+	}
+	if getEnv("P2P_WEBSOCKET", "true") == "true" {
+		p2pNetwork.EnableWebSocket()
+	}
+	if nodeTLS != nil {
+		p2pNetwork.SetTLS(nodeTLS.Client)
+		fmt.Printf("🔒 TLS mutuo habilitado para el tráfico entre nodos\n")
+	}
+
+	// El sandbox no acepta peers, así sus bloques nunca llegan a la red real
+	if sandboxMode {
+		sandbox = blockchain.NewSandbox(bc, p2pNetwork)
+		if err := configureSandboxFromEnv(sandbox); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Incorporarse a la red con un token de ingreso si está configurado
+	joinTokens = blockchain.NewJoinTokenStore(bc)
+	var joined *blockchain.JoinResponse
+	if !sandboxMode {
+		joined, err = joinFromEnv()
+	}
+	if err != nil {
+		fmt.Printf("❌ Error ingresando a la red: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Activar el consenso por prueba de autoridad si está configurado
+	if err := enableConsensusFromEnv(joined); err != nil {
+		fmt.Printf("❌ Error configurando consenso: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Aceptar los checkpoints de la autoridad designada si está configurada
+	checkpoints, err := enableCheckpointsFromEnv()
+	if err != nil {
+		fmt.Printf("❌ Error configurando checkpoints: %v\n", err)
+		os.Exit(1)
+	}
+	if joined != nil {
+		go p2pNetwork.SyncWithPeers()
+	}
 	
 	// Inicializar workflow manager
-	workflowManager = blockchain.NewWorkflowManager(bc)
+	workflowManager = bc.WorkflowManager
+	workflowManager.ObservationResponseDays = observationResponseDaysFromEnv()
+
+	// Inicializar modo mantenimiento (desactivado)
+	maintenance = blockchain.NewMaintenanceMode()
+	bc.Maintenance = maintenance
+	p2pNetwork.Maintenance = maintenance
+
+	// Inicializar suscripciones por contrato
+	subscriptions = blockchain.NewSubscriptionManager(bc)
+
+	// Inicializar los webhooks salientes de los sistemas externos
+	webhooks = blockchain.NewWebhookManager(bc)
+
+	// Inicializar los eventos en vivo para los tableros
+	liveFeed = blockchain.NewLiveFeed(bc)
+	p2pNetwork.SetPeerListener(liveFeed.PeerJoined)
+
+	// Inicializar la política de limpieza de borradores
+	draftJanitor = blockchain.NewDraftJanitor(bc, draftPolicyFromEnv())
+
+	// Inicializar la cola de trabajo de los comités
+	workQueue = blockchain.NewWorkQueue(bc, claimTimeoutFromEnv())
+
+	// Inicializar alertas de los entes de control
+	alertManager = blockchain.NewAlertManager(bc)
+
+	// Inicializar el seguimiento de plazos de respuesta a observaciones de control
+	observationTracker = blockchain.NewObservationTracker(bc, alertManager)
+
+	// Inicializar la revisión de consecutivos de procesos, que alerta al DNP
+	projectionMaxLag = projectionMaxLagFromEnv()
+
+	processNumberEvery, processNumberGrace := processNumberAuditFromEnv()
+	processNumberAuditor = blockchain.NewProcessNumberAuditor(bc, alertManager, processNumberGrace)
+
+	// Inicializar consultas guardadas de los analistas
+	queryEngine = blockchain.NewQueryEngine(bc)
+
+	// Inicializar el cálculo mensual del cumplimiento de metas de las entidades
+	complianceScorer = blockchain.NewComplianceScorer(bc)
+	queryEngine.SetComplianceScorer(complianceScorer)
+	fiscalLedger = blockchain.NewFiscalLedger(bc)
+	amendmentManager = blockchain.NewAmendmentManager(bc)
+	milestoneTracker = blockchain.NewMilestoneTracker(bc, fiscalLedger)
+
+	// Inicializar el agrupamiento de contratos similares (detección de fraccionamiento)
+	clusterOptions, clusterEvery, err := clusterOptionsFromEnv()
+	if err == nil {
+		contractClusterer, err = blockchain.NewContractClusterer(bc, clusterOptions)
+	}
+	if err != nil {
+		fmt.Printf("❌ Error configurando el agrupamiento de contratos: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Inicializar el puente con SECOP II si está configurado
+	if bridgeURL := getEnv("SECOP_BRIDGE_URL", ""); bridgeURL != "" && !sandboxMode {
+		mappings, err := blockchain.LoadBridgeMappings(getEnv("SECOP_BRIDGE_MAPPINGS", ""))
+		if err != nil {
+			fmt.Printf("❌ Error cargando equivalencias del puente SECOP II: %v\n", err)
+			os.Exit(1)
+		}
+		secopBridge = blockchain.NewSecopBridge(bc, bridgeURL, getEnv("SECOP_BRIDGE_TOKEN", ""), mappings)
+		fmt.Printf("🌉 Puente SECOP II activo hacia %s\n", bridgeURL)
+	}
+
+	// Los archivos del sandbox van aparte para no mezclarlos con los de producción
+	filesDir := "./data"
+	if sandboxMode {
+		filesDir = "./data/sandbox"
+	}
+
+	// Inicializar almacén de evidencias de ejecución (fuera de la cadena)
+	evidenceStore, err = blockchain.NewEvidenceStore(getEnv("EVIDENCE_DIR", filesDir+"/evidence"), bc)
+	if err != nil {
+		fmt.Printf("❌ Error inicializando almacén de evidencias: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Inicializar almacén de documentos adjuntos de los procesos (fuera de la cadena)
+	attachmentStore, err = blockchain.NewAttachmentStore(getEnv("ATTACHMENTS_DIR", filesDir+"/attachments"), bc)
+	if err != nil {
+		fmt.Printf("❌ Error inicializando almacén de adjuntos: %v\n", err)
+		os.Exit(1)
+	}
+	if extractor, enabled := pdfExtractorFromEnv(); enabled {
+		attachmentStore.Index.RegisterExtractor(extractor)
+		fmt.Printf("🔎 Texto de los PDF extraído con %s\n", getEnv("PDF_TEXT_COMMAND", ""))
+	}
 	
-	// Configurar peers iniciales desde variables de entorno (OPCIONAL)
-	setupInitialPeers()
+	// Inicializar el KMS de los respaldos cifrados
+	backupKMS, err = backupKMSFromEnv()
+	if err != nil {
+		fmt.Printf("❌ Error configurando el KMS de los respaldos cifrados: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("🔒 Respaldos cifrados con KMS %s (llave %s)\n", backupKMS.Provider(), backupKMS.KeyID())
 
-	// Configurar Gin
+	// Inicializar autenticación de funcionarios
+	authDirectory, err = auth.LoadDirectory(getEnv("AUTH_USERS_FILE", "./data/users.json"))
+	if err != nil {
+		fmt.Printf("❌ Error cargando usuarios: %v\n", err)
+		os.Exit(1)
+	}
+	authSecret := getEnv("AUTH_SECRET", "")
+	if authSecret == "" {
+		fmt.Printf("⚠️ AUTH_SECRET no configurado, las sesiones se invalidan al reiniciar\n")
+	}
+	authIssuer, err = auth.NewIssuer(authSecret, sessionTTLFromEnv())
+	if err != nil {
+		fmt.Printf("❌ Error inicializando autenticación: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("🔐 %d usuarios habilitados para iniciar sesión\n", authDirectory.Count())
+
+	// Cargar llaves de API de los integradores externos
+	apiKeys, err = auth.NewAPIKeyStore(store)
+	if err != nil {
+		fmt.Printf("❌ Error cargando llaves de API: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Cargar métricas del tráfico rechazado por cliente
+	trafficMetrics, err = auth.NewTrafficMonitor(store, trafficThrottleFromEnv())
+	if err != nil {
+		fmt.Printf("❌ Error cargando métricas de tráfico: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Cuotas de peticiones por cliente en la API pública
+	rateLimits, err = rateLimitsFromEnv()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("🚦 Límite de peticiones: %s\n", rateLimits.describe())
+
+	// Configurar peers iniciales desde variables de entorno (OPCIONAL); el resto de la red
+	// se descubre a partir de ellos
+	if !sandboxMode {
+		setupInitialPeers()
+		go p2pNetwork.DiscoverPeers()
+	}
+
+	// Configurar Gin, con los errores de validación nombrados como en el JSON
+	if err := registerValidators(); err != nil {
+		fmt.Printf("❌ Error configurando validaciones: %v\n", err)
+		os.Exit(1)
+	}
 	r := gin.Default()
 
-	// Configurar CORS
+	// Identificar cada petición para ubicarla en los registros desde su respuesta de error
+	r.Use(traceRequests())
+	r.NoRoute(func(c *gin.Context) {
+		respondErrorMessage(c, http.StatusNotFound, "ruta no encontrada: "+c.Request.URL.Path)
+	})
+
+	// Configurar CORS con los orígenes del perfil
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
+		AllowOrigins:     activeProfile.CORS.AllowOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"*"},
 		ExposeHeaders:    []string{"*"},
-		AllowCredentials: true,
+		AllowCredentials: activeProfile.CORS.AllowCredentials,
 	}))
 
+	// Contabilizar peticiones rechazadas por cliente y frenar a los abusivos
+	r.Use(trafficMonitor())
+	if sandbox != nil {
+		r.Use(sandboxHeader())
+	}
+
 	// *** BACKEND SOLO - Sin frontend ***
 	// r.Static("/static", "./web/public")
 	// r.StaticFile("/", "./web/public/index.html")
 
+	// Las rutas de la API viven bajo /api/v1; las anteriores sin versión siguen respondiendo
+	// hasta API_LEGACY_SUNSET con los encabezados de obsolescencia
+	legacySunset, err := legacySunsetFromEnv()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	api := newAPIRoutes(r, legacySunset, rateLimit())
+
+	// Rutas de autenticación de funcionarios
+	api.POST("/auth/login", login)
+	api.GET("/auth/session", authRequired(auth.ScopeReadOnly, auth.ScopeContractCreate, auth.ScopeAuditOnly), getSession)
+
 	// API Routes existentes
-	r.GET("/api/blocks", getBlocks)
-	r.GET("/api/contracts", getContracts)
-	r.POST("/api/contracts", createContract)
-	r.POST("/api/contracts/validate", validateContract)
-	r.GET("/api/stats", getStats)
+	api.GET("/blocks", consistencyGuard(), getBlocks)
+	api.GET("/blocks/wait", waitForBlocks)
+	api.GET("/blocks/height/:n", consistencyGuard(), getBlockByHeight)
+	api.GET("/blocks/:hash", consistencyGuard(), getBlockByHash)
+	api.GET("/blocks/:hash/finality", getBlockFinality)
+	api.GET("/blocks/:hash/transactions", consistencyGuard(), getBlockTransactions)
+	api.GET("/proofs/:txid", consistencyGuard(), getTransactionProof)
+	api.GET("/contracts", consistencyGuard(), getContracts)
+	api.GET("/contracts/search", consistencyGuard(), optionalAuth(auth.ScopeReadOnly), searchContracts)
+	api.GET("/contracts/:id", consistencyGuard(), getContract)
+	api.POST("/contracts", authRequired(auth.ScopeContractCreate), authorize(contractCreatorRoles...), maintenanceGuard(), createContract)
+	api.POST("/contracts/validate", authRequired(), authorize(workflowRoles...), maintenanceGuard(), validateContract)
+	api.POST("/contracts/signed", maintenanceGuard(), createSignedContract)
+	api.GET("/stats", consistencyGuard(), getStats)
+	api.GET("/events/stream", streamEvents)
+	r.GET("/ws", liveSubscriptions)
 
 	// Nuevas rutas de flujo de trabajo SECOP
-	r.GET("/api/workflow/steps", getWorkflowSteps)
-	r.GET("/api/contracts/:id/workflow", getContractWorkflowStatus)
-	r.POST("/api/contracts/:id/validate-step", validateContractStep)
-	r.POST("/api/contracts/:id/audit", addAuditObservation)
-	r.GET("/api/contracts/by-status/:status", getContractsByStatus)
-	r.GET("/api/contracts/by-role/:role", getContractsByRole)
-
-	// Nuevas rutas P2P
+	api.GET("/workflow/steps", getWorkflowSteps)
+	api.GET("/workflow/templates", getWorkflowTemplates)
+	api.PUT("/workflow/templates/:type", authRequired(), authorize(workflowTemplateRoles...), maintenanceGuard(), setWorkflowTemplate)
+	api.DELETE("/workflow/templates/:type", authRequired(), authorize(workflowTemplateRoles...), maintenanceGuard(), removeWorkflowTemplate)
+	api.GET("/workflow/templates/:type/versions", getWorkflowTemplateVersions)
+	api.GET("/workflow/templates/:type/versions/:version", getWorkflowTemplateVersion)
+	api.POST("/contracts/:id/workflow/template-migration", authRequired(), authorize(workflowTemplateRoles...), maintenanceGuard(), migrateContractTemplate)
+	api.GET("/contracts/:id/workflow", consistencyGuard(), getContractWorkflowStatus)
+	api.POST("/contracts/:id/validate-step", authRequired(), authorize(workflowRoles...), maintenanceGuard(), validateContractStep)
+	api.POST("/contracts/:id/conflict-declarations", authRequired(), authorize(workflowRoles...), maintenanceGuard(), declareConflict)
+	api.GET("/contracts/:id/conflict-declarations", authRequired(auth.ScopeAuditOnly), authorize(conflictReviewerRoles...), getConflictDeclarations)
+	api.POST("/contracts/:id/audit", authRequired(auth.ScopeAuditOnly), authorize(auditorRoles...), maintenanceGuard(), addAuditObservation)
+	api.GET("/contracts/:id/observations", consistencyGuard(), getContractObservations)
+	api.GET("/contracts/:id/operations", getContractOperations)
+	api.GET("/contracts/:id/decisions", consistencyGuard(), getContractDecisions)
+	api.POST("/contracts/:id/audit/:oid/response", authRequired(), authorize(workflowRoles...), maintenanceGuard(), respondAuditObservation)
+	api.GET("/observations/overdue", authRequired(auth.ScopeAuditOnly), authorize(auditorRoles...), getOverdueObservations)
+	api.GET("/reserved/blocks/:hash", authRequired(auth.ScopeAuditOnly), authorize(reservedReaderRoles...), getReservedBlock)
+	api.GET("/reserved/access-log", authRequired(auth.ScopeAuditOnly), authorize(reservedReaderRoles...), getReservedAccessLog)
+	api.GET("/contracts/by-status/:status", consistencyGuard(), getContractsByStatus)
+	api.GET("/contracts/by-role/:role", consistencyGuard(), getContractsByRole)
+
+	// Rutas de la cola de trabajo de los comités
+	api.GET("/work-queue/:role", getWorkQueue)
+	api.POST("/work-queue/:role/claim", maintenanceGuard(), authRequired(), authorize(workflowRoles...), claimNextFromQueue)
+	api.POST("/contracts/:id/claim", maintenanceGuard(), authRequired(), authorize(workflowRoles...), claimContract)
+	api.POST("/contracts/:id/assign", maintenanceGuard(), authRequired(), authorize(workflowRoles...), assignContract)
+	api.POST("/contracts/:id/release", maintenanceGuard(), authRequired(), releaseContract)
+
+	// Rutas de suscripciones por contrato
+	api.POST("/contracts/:id/subscribe", authRequired(), subscribeToContract)
+	api.GET("/contracts/:id/subscriptions", authRequired(), getContractSubscriptions)
+	api.DELETE("/contracts/:id/subscriptions/:sid", authRequired(), unsubscribeFromContract)
+
+	// Rutas de webhooks salientes
+	api.GET("/webhooks", authRequired(), authorize(webhookAdminRoles...), getWebhooks)
+	api.POST("/webhooks", authRequired(), authorize(webhookAdminRoles...), createWebhook)
+	api.GET("/webhooks/:id", authRequired(), authorize(webhookAdminRoles...), getWebhook)
+	api.PUT("/webhooks/:id", authRequired(), authorize(webhookAdminRoles...), updateWebhook)
+	api.DELETE("/webhooks/:id", authRequired(), authorize(webhookAdminRoles...), deleteWebhook)
+
+	// Rutas de evidencias de ejecución
+	api.GET("/contracts/:id/evidence", consistencyGuard(), getEvidenceGallery)
+	api.POST("/contracts/:id/evidence", maintenanceGuard(), authRequired(), authorize(evidenceUploaderRoles...), uploadEvidence)
+	api.GET("/contracts/:id/evidence/:eid/file", getEvidenceFile)
+	api.GET("/contracts/:id/attachments", consistencyGuard(), optionalAuth(auth.ScopeReadOnly), getAttachments)
+	api.POST("/contracts/:id/attachments", authRequired(), authorize(attachmentUploaderRoles...), maintenanceGuard(), uploadAttachment)
+	api.GET("/contracts/:id/attachments/:aid/file", optionalAuth(auth.ScopeReadOnly), getAttachmentFile)
+	api.PUT("/contracts/:id/attachments/:aid/indexing", authRequired(), authorize(attachmentUploaderRoles...), maintenanceGuard(), setAttachmentIndexing)
+
+	// Rutas compactas para la app móvil de veeduría ciudadana
+	mobile := api.Group("/mobile")
+	mobile.GET("/contracts", consistencyGuard(), getMobileContracts)
+	mobile.GET("/contracts/:id", consistencyGuard(), getMobileContract)
+	mobile.GET("/contracts/:id/timeline", consistencyGuard(), getMobileTimeline)
+	mobile.GET("/contracts/:id/evidence", consistencyGuard(), getMobileEvidence)
+	mobile.GET("/contracts/:id/evidence/:eid/thumbnail", getEvidenceThumbnail)
+
+	// Rutas de sistemas externos de las entidades
+	api.GET("/entities/:code/systems", getSystemKeys)
+	api.POST("/entities/:code/systems", maintenanceGuard(), authRequired(), authorize(entityAdminRoles...), registerSystemKey)
+
+	// Rutas de publicación, observaciones al pliego y adjudicación
+	api.GET("/suppliers", consistencyGuard(), getSuppliers)
+	api.POST("/suppliers", maintenanceGuard(), authRequired(), authorize(contractOfficeRoles...), registerSupplier)
+	api.POST("/suppliers/:nit/sanctions", maintenanceGuard(), authRequired(), authorize(sanctionRoles...), addSupplierSanction)
+	api.GET("/suppliers/:nit/history", consistencyGuard(), getSupplierHistory)
+	api.POST("/contracts/:id/publish", maintenanceGuard(), authRequired(), authorize(contractOfficeRoles...), publishContract)
+	api.GET("/contracts/:id/questions", consistencyGuard(), getContractQuestions)
+	api.POST("/contracts/:id/questions", maintenanceGuard(), authRequired(), submitContractQuestion)
+	api.POST("/contracts/:id/questions/:qid/response", maintenanceGuard(), authRequired(), authorize(contractOfficeRoles...), respondContractQuestion)
+	api.POST("/contracts/:id/award", maintenanceGuard(), authRequired(), authorize(contractOfficeRoles...), awardContract)
+
+	// Rutas de pagos, adiciones y prórrogas, hitos de ejecución y cierre de vigencia fiscal
+	api.GET("/contracts/:id/payments", consistencyGuard(), getContractPayments)
+	api.POST("/contracts/:id/payments", authRequired(), authorize(treasuryRoles...), maintenanceGuard(), registerContractPayment)
+	api.POST("/contracts/:id/carryovers", authRequired(), authorize(treasuryRoles...), maintenanceGuard(), markCarryover)
+	api.GET("/contracts/:id/amendments", consistencyGuard(), getContractAmendments)
+	api.POST("/contracts/:id/amendments", authRequired(), authorize(amendmentRequesterRoles...), maintenanceGuard(), requestAmendment)
+	api.POST("/contracts/:id/amendments/:aid/decision", authRequired(), authorize(amendmentReviewerRoles...), maintenanceGuard(), decideAmendment)
+	api.GET("/contracts/:id/milestones", consistencyGuard(), getContractMilestones)
+	api.POST("/contracts/:id/milestones", authRequired(), authorize(milestonePlannerRoles...), maintenanceGuard(), addMilestone)
+	api.POST("/contracts/:id/milestones/:mid/completion", authRequired(), authorize(milestoneSupervisorRoles...), maintenanceGuard(), completeMilestone)
+	api.POST("/contracts/:id/milestones/:mid/payment-act", authRequired(), authorize(treasuryRoles...), maintenanceGuard(), registerPaymentAct)
+	api.GET("/fiscal-closings", authRequired(auth.ScopeAuditOnly), authorize(fiscalClosingReaderRoles...), getFiscalClosings)
+	api.GET("/fiscal-closings/:year", authRequired(auth.ScopeAuditOnly), authorize(fiscalClosingReaderRoles...), getFiscalClosing)
+	api.POST("/fiscal-closings", authRequired(), authorize(fiscalClosingRoles...), maintenanceGuard(), closeFiscalYear)
+
+	// Nuevas rutas P2P. Las de salud y métricas no se versionan: las consultan los
+	// balanceadores, Prometheus y los demás nodos
 	r.GET("/api/health", healthCheck)
-	r.GET("/api/p2p/peers", getPeers)
-	r.POST("/api/p2p/add-peer", addPeer)
-	r.GET("/api/p2p/get-chain", getChain)
-	r.POST("/api/p2p/receive-block", receiveBlock)
-	r.POST("/api/p2p/sync", syncWithPeers)
+	r.GET("/api/health/ready", readinessCheck)
+	r.GET("/api/metrics", getMetrics)
+
+	// GraphQL de solo lectura: contratos, flujo, auditoría, bloques y peers en una consulta
+	r.POST("/api/graphql", rateLimit(), consistencyGuard(), postGraphQL)
+	r.GET("/api/graphql", rateLimit(), consistencyGuard(), getGraphQL)
+	r.GET("/api/graphql/schema", rateLimit(), getGraphQLSchema)
+	api.GET("/p2p/topology", getTopology)
+	api.POST("/p2p/add-peer", authRequired(), authorize(peerAdminRoles...), addPeer)
+	api.DELETE("/p2p/peers/:id", authRequired(), authorize(peerAdminRoles...), removePeer)
+	api.POST("/p2p/peers/:id/reactivate", authRequired(), authorize(peerAdminRoles...), reactivatePeer)
+	api.GET("/p2p/peer-removals", authRequired(), authorize(peerAdminRoles...), getPeerRemovals)
+	api.GET("/p2p/peers/:id/stats", getPeerStats)
+	api.DELETE("/p2p/peers/:id/ban", authRequired(), authorize(peerAdminRoles...), unbanPeer)
+	api.POST("/p2p/sync", maintenanceGuard(), syncWithPeers)
+
+	// Rutas entre nodos, en el listener P2P (el mismo router si comparten puerto)
+	p := peerListener.router(r)
+	if peerListener.separate {
+		p.GET("/api/health", healthCheck)
+	}
+	p.GET("/api/p2p/peers", peerCertRequired(), getPeers)
+	p.GET("/api/p2p/known-peers", peerCertRequired(), getKnownPeers)
+	p.GET("/api/p2p/ws", peerCertRequired(), peerWebSocket)
+	p.POST("/api/p2p/join", peerCertRequired(), maintenanceGuard(), joinNetwork)
+	p.POST("/api/p2p/finality", peerCertRequired(), receiveFinality)
+	p.GET("/api/p2p/get-chain", peerCertRequired(), getChain)
+	p.GET("/api/p2p/blocks", peerCertRequired(), getBlocksFrom)
+	p.GET("/api/p2p/ancestors/:hash", peerCertRequired(), getAncestors)
+	p.GET("/api/p2p/handshake", peerCertRequired(), getHandshake)
+	p.POST("/api/p2p/handshake", peerCertRequired(), registerPeerHandshake)
+	p.GET("/api/p2p/tip", peerCertRequired(), getTip)
+	p.GET("/api/p2p/version", peerCertRequired(), getPeerVersion)
+	p.GET("/api/p2p/validator-keys", peerCertRequired(), getValidatorKeys)
+	p.POST("/api/p2p/receive-block", peerCertRequired(), maintenanceGuard(), receiveBlock)
+	p.POST("/api/p2p/mempool", peerCertRequired(), maintenanceGuard(), receiveTransaction)
+	p.GET("/api/p2p/reserved/:hash", peerCertRequired(), getReservedBlockForPeer)
+	p.POST("/api/p2p/maintenance", peerCertRequired(), receivePeerMaintenance)
+
+	// Rutas de administración
+	api.GET("/admin/maintenance", getMaintenance)
+	api.GET("/admin/quarantine", authRequired(), authorizeNode(), getQuarantine)
+	api.GET("/admin/forks", authRequired(), authorizeNode(), getForks)
+	api.GET("/admin/traffic", authRequired(), authorize(trafficAdminRoles...), getTrafficMetrics)
+	api.DELETE("/admin/traffic/:client/throttle", authRequired(), authorize(trafficAdminRoles...), liftTrafficThrottle)
+	api.POST("/admin/keys/rotate", authRequired(), authorizeNode(), rotateNodeKey)
+
+	// Tokens de ingreso de nodos a la red
+	api.GET("/admin/join-tokens", authRequired(), authorize(peerAdminRoles...), getJoinTokens)
+	api.POST("/admin/join-tokens", authRequired(), authorize(peerAdminRoles...), issueJoinToken)
+	api.DELETE("/admin/join-tokens/:id", authRequired(), authorize(peerAdminRoles...), revokeJoinToken)
+
+	// Consenso por prueba de autoridad
+	api.GET("/consensus", getConsensus)
+	api.POST("/consensus/validators", authRequired(), authorize(consensusAdminRoles...), maintenanceGuard(), addAuthority)
+	api.DELETE("/consensus/validators/:id", authRequired(), authorize(consensusAdminRoles...), maintenanceGuard(), removeAuthority)
+	api.GET("/checkpoints", getCheckpoints)
+	api.POST("/checkpoints", authRequired(), authorize(checkpointAdminRoles...), maintenanceGuard(), createCheckpoint)
+
+	// Llaves de API de integradores externos
+	api.GET("/admin/apikeys", authRequired(), authorize(apiKeyAdminRoles...), getAPIKeys)
+	api.POST("/admin/apikeys", authRequired(), authorize(apiKeyAdminRoles...), createAPIKey)
+	api.GET("/admin/apikeys/:id", authRequired(), authorize(apiKeyAdminRoles...), getAPIKey)
+	api.PUT("/admin/apikeys/:id", authRequired(), authorize(apiKeyAdminRoles...), updateAPIKey)
+	api.DELETE("/admin/apikeys/:id", authRequired(), authorize(apiKeyAdminRoles...), revokeAPIKey)
+
+	// Rutas de alertas de los entes de control
+	api.GET("/alerts", authRequired(), authorize(alertRuleRoles...), getAlerts)
+	api.GET("/alerts/rules", authRequired(), authorize(alertRuleRoles...), getAlertRules)
+	api.POST("/alerts/rules", authRequired(), authorize(alertRuleRoles...), createAlertRule)
+	api.DELETE("/alerts/rules/:id", authRequired(), authorize(alertRuleRoles...), deleteAlertRule)
+
+	// Rutas de la revisión de consecutivos de números de proceso
+	api.GET("/process-numbers/reports", authRequired(auth.ScopeAuditOnly), authorize(processNumberAuditRoles...), getProcessNumberReports)
+	api.POST("/process-numbers/audit", authRequired(auth.ScopeAuditOnly), authorize(processNumberAuditRoles...), consistencyGuard(), auditProcessNumbers)
+
+	// Archivo de bloques antiguos
+	api.GET("/admin/archive", authRequired(), authorizeNode(), getArchiveStatus)
+	api.GET("/admin/mempool", authRequired(), authorizeNode(), getMempool)
+	api.POST("/admin/archive/run", authRequired(), authorizeNode(), runArchive)
+	api.GET("/admin/outbox", authRequired(), authorizeNode(), getOutboxStatus)
+	api.GET("/admin/metrics/push", authRequired(), authorizeNode(), getMetricsPush)
+	api.GET("/admin/projections", authRequired(), authorizeNode(), getProjections)
+	api.GET("/admin/p2p/connections", authRequired(), authorizeNode(), getPeerConnections)
+	api.GET("/admin/version", authRequired(), authorizeNode(), getVersion)
+	api.GET("/admin/version/network", authRequired(), authorizeNode(), getNetworkVersions)
+
+	// Rutas de consultas guardadas (la visibilidad depende del rol de la sesión)
+	api.GET("/queries", authRequired(auth.ScopeReadOnly), getSavedQueries)
+	api.POST("/queries", authRequired(), createSavedQuery)
+	api.GET("/queries/:id", authRequired(auth.ScopeReadOnly), getSavedQuery)
+	api.DELETE("/queries/:id", authRequired(), deleteSavedQuery)
+	api.POST("/queries/:id/run", authRequired(auth.ScopeReadOnly), runSavedQuery)
+	api.GET("/queries/:id/results", authRequired(auth.ScopeReadOnly), getSavedQueryResult)
+
+	// Rutas del agrupamiento de contratos por similitud de su objeto
+	api.GET("/analytics/clusters", authRequired(auth.ScopeAuditOnly), authorize(clusterAnalystRoles...), consistencyGuard(), getContractClusters)
+	api.POST("/analytics/clusters/run", authRequired(auth.ScopeAuditOnly), authorize(clusterAnalystRoles...), consistencyGuard(), runContractClustering)
+
+	// Mapa de calor de la actividad de validación para los entes de control
+	api.GET("/analytics/activity", authRequired(auth.ScopeAuditOnly), authorize(activityAnalystRoles...), consistencyGuard(), getValidationActivity)
+
+	// Rutas de las metas de gestión contractual y el cumplimiento de las entidades
+	api.GET("/analytics/kpi-targets", authRequired(auth.ScopeAuditOnly), authorize(complianceAnalystRoles...), getKPITargets)
+	api.PUT("/analytics/kpi-targets", authRequired(), authorize(kpiTargetRoles...), setKPITargets)
+	api.GET("/analytics/compliance", authRequired(auth.ScopeAuditOnly), authorize(complianceAnalystRoles...), getComplianceScores)
+	api.POST("/analytics/compliance/run", authRequired(auth.ScopeAuditOnly), authorize(complianceAnalystRoles...), consistencyGuard(), scoreCompliance)
+
+	// Puente de sincronización con SECOP II
+	api.GET("/bridge/status", getBridgeStatus)
+	api.GET("/bridge/mappings", getBridgeMappings)
+	api.POST("/bridge/reconcile", authRequired(), authorizeNode(), reconcileBridge)
+
+	// Respaldo y restauración de la cadena
+	api.GET("/chain/snapshot", authRequired(), authorizeNode(), getSnapshot)
+	api.GET("/export/contracts.parquet", authRequired(auth.ScopeAuditOnly), authorize(exportRoles...), consistencyGuard(), exportContractsParquet)
+	api.GET("/export/blocks.parquet", authRequired(auth.ScopeAuditOnly), authorize(exportRoles...), consistencyGuard(), exportBlocksParquet)
+	api.POST("/chain/restore", authRequired(), authorizeNode(), restoreSnapshot)
+	api.GET("/chain/backup", authRequired(), authorizeNode(), getBackup)
+	api.POST("/chain/backup/restore", authRequired(), authorizeNode(), restoreBackup)
+
+	// Descubrimiento de llaves públicas
+	r.GET("/.well-known/jwks.json", getJWKS)
+	api.GET("/keys/validators", getValidatorKeys)
+	api.POST("/keys/register", authRequired(), authorize(workflowRoles...), maintenanceGuard(), registerOfficialKey)
+	api.GET("/keys/officials/:id", getOfficialKeys)
+	api.GET("/admin/drafts", authRequired(), authorize(entityAdminRoles...), getDrafts)
+	api.POST("/admin/drafts/sweep", authRequired(), authorize(entityAdminRoles...), sweepDrafts)
+	api.POST("/admin/drafts/:id/restore", authRequired(), authorize(entityAdminRoles...), restoreDraft)
+	api.POST("/contracts/:id/draft-hold", authRequired(), authorize(entityAdminRoles...), setDraftHold)
+	api.POST("/admin/maintenance", authRequired(), authorizeNode(), setMaintenance)
+
+	// Especificación OpenAPI y explorador Swagger UI de todas las rutas anteriores
+	api.GET("/openapi.json", getOpenAPISpec(r))
+	api.GET("/docs", getSwaggerUI)
 
 	// Iniciar sincronización periódica
 	go startPeriodicSync()
@@ -82,15 +703,113 @@ func main() {
 	// Iniciar health check periódico
 	go startPeriodicHealthCheck()
 
-	// Crear contratos de ejemplo solo en el nodo DNP
-	if nodeID == "DNP-NODE" {
+	// Descartar las llaves de idempotencia vencidas
+	go startIdempotencySweep()
+
+	// Reintentar con espera exponencial los peers inactivos
+	go p2pNetwork.RunPeerRetry(5 * time.Second)
+
+	// Mantener abiertos los canales WebSocket con los peers
+	go p2pNetwork.RunWebSocket(5 * time.Second)
+
+	// Mantener la DHT y las conexiones libp2p, si es el transporte elegido
+	go p2pNetwork.RunLibp2p(30 * time.Second)
+
+	// Iniciar entrega de eventos a suscriptores
+	go subscriptions.Run(time.Second)
+	go webhooks.Run(time.Second)
+
+	// Iniciar revisión diaria de borradores inactivos
+	go draftJanitor.Run(24 * time.Hour)
+
+	// Iniciar escalamiento de observaciones de control sin respuesta
+	go observationTracker.Run(time.Hour)
+
+	// Iniciar liberación de contratos tomados sin actividad
+	go workQueue.Run(time.Minute)
+
+	// Iniciar archivo periódico de bloques antiguos
+	go bc.RunArchive(time.Hour)
+
+	// Iniciar despacho del outbox (difusión de bloques a peers)
+	go bc.Outbox.Run(time.Second)
+
+	// Iniciar revisión periódica de consecutivos de procesos
+	go processNumberAuditor.Run(processNumberEvery)
+
+	// Iniciar ejecución de consultas programadas
+	go queryEngine.Run(time.Minute)
+
+	// Iniciar agrupamiento periódico de contratos similares
+	go contractClusterer.Run(clusterEvery)
+
+	// Iniciar el cálculo del cumplimiento de metas al cierre de cada mes
+	go complianceScorer.Run(complianceCheckIntervalFromEnv())
+
+	// Indexar los adjuntos que aún no están en el índice de contenido
+	go attachmentStore.IndexPending()
+
+	// Iniciar sellado de los lotes del mempool
+	if bc.Mempool != nil {
+		go bc.Mempool.Run()
+	}
+
+	// Iniciar creación periódica de checkpoints (solo actúa en el nodo autoridad)
+	if checkpoints {
+		go bc.Checkpoints.Run(time.Minute)
+	}
+
+	// Iniciar guardado periódico de las métricas de tráfico rechazado
+	go trafficMetrics.Run(30 * time.Second)
+
+	// Iniciar envío de eventos al puente SECOP II
+	if secopBridge != nil {
+		go secopBridge.Run(2 * time.Second)
+	}
+
+	// Iniciar envío de métricas al colector remoto, para nodos que el monitoreo no alcanza
+	if metricsURL, metricsEvery := metricsPushFromEnv(); metricsURL != "" {
+		metricsPusher = blockchain.NewMetricsPusher(p2pNetwork, metricsURL, getEnv("METRICS_PUSH_TOKEN", ""))
+		go metricsPusher.Run(metricsEvery)
+		fmt.Printf("📈 Enviando métricas a %s cada %s\n", metricsURL, metricsEvery)
+	}
+
+	// El sandbox arranca con sus contratos sintéticos y se reinicia periódicamente; fuera
+	// de él, crear contratos de ejemplo solo si el perfil lo pide, en el nodo DNP y con la
+	// cadena vacía
+	if sandbox != nil {
+		sandbox.Reset()
+		go sandbox.Run()
+	} else if activeProfile.Seed.ExampleContracts && nodeID == "DNP-NODE" && bc.ContractCount() == 0 {
 		createExampleContracts()
 	}
 
+	// La API gRPC atiende en su propio puerto, con el mismo TLS de la API pública
+	if grpcServer, grpcPort := grpcFromEnv(); grpcServer != nil {
+		fmt.Printf("🔗 API gRPC disponible en %s:%s\n", nodeAddress, grpcPort)
+		go func() {
+			if err := grpcServer.ListenAndServe(":"+grpcPort, publicTLSConfig()); err != nil {
+				fmt.Printf("❌ Error en la API gRPC: %v\n", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	fmt.Printf("🌐 Servidor backend iniciado en puerto %s\n", nodePort)
-	fmt.Printf("🔗 API disponible en http://%s:%s/api/\n", nodeAddress, nodePort)
-	
-	r.Run(":" + nodePort)
+	if peerListener.separate {
+		fmt.Printf("🌐 Listener P2P iniciado en puerto %s\n", peerListener.port)
+		go func() {
+			if err := serve("API P2P", nodeAddress, peerListener.port, p, peerListener.tlsConfig()); err != nil {
+				fmt.Printf("❌ Error en el listener P2P: %v\n", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	if err := serve("API", nodeAddress, nodePort, r, publicTLSConfig()); err != nil {
+		fmt.Printf("❌ Error en el servidor: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 // setupInitialPeers configura los peers iniciales desde variables de entorno (OPCIONAL)
@@ -123,13 +842,21 @@ func setupInitialPeers() {
 // Nuevos handlers P2P
 
 func healthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":    "healthy",
-		"node_id":   p2pNetwork.NodeID,
-		"timestamp": time.Now(),
-		"blocks":    len(bc.Chain),
-		"contracts": len(bc.Contracts),
-	})
+	health := gin.H{
+		"status":      "healthy",
+		"node_id":     p2pNetwork.NodeID,
+		"timestamp":   time.Now(),
+		"blocks":      blockchain.ByzantineReportedHeight(bc.Len()),
+		"contracts":   bc.ContractCount(),
+		"maintenance": maintenance.Status(),
+		"byzantine":   blockchain.ByzantineBehaviors(),
+		"mode":        "production",
+	}
+	if sandbox != nil {
+		health["mode"] = "sandbox"
+		health["sandbox"] = sandbox.Status()
+	}
+	c.JSON(http.StatusOK, health)
 }
 
 func getPeers(c *gin.Context) {
@@ -140,19 +867,52 @@ func getPeers(c *gin.Context) {
 	})
 }
 
-func addPeer(c *gin.Context) {
-	var req struct {
-		PeerID  string `json:"peer_id"`
-		Address string `json:"address"`
-		Port    string `json:"port"`
+// getKnownPeers comparte los nodos que este nodo conoce; si quien pregunta se anuncia,
+// se agrega como peer una vez verificado
+func getKnownPeers(c *gin.Context) {
+	if nodeID := c.Query("node_id"); nodeID != "" {
+		go p2pNetwork.DiscoverPeer(nodeID, c.Query("address"), c.Query("port"))
 	}
 
+	peers := p2pNetwork.KnownPeers()
+	c.JSON(http.StatusOK, gin.H{
+		"peers":   peers,
+		"count":   len(peers),
+		"node_id": p2pNetwork.NodeID,
+	})
+}
+
+func getTopology(c *gin.Context) {
+	c.JSON(http.StatusOK, p2pNetwork.Topology())
+}
+
+// addPeerRequest es el cuerpo de POST /api/p2p/add-peer
+type addPeerRequest struct {
+	PeerID  string `json:"peer_id"`
+	Address string `json:"address"`
+	Port    string `json:"port"`
+}
+
+func addPeer(c *gin.Context) {
+	var req addPeerRequest
+
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	p2pNetwork.AddPeer(req.PeerID, req.Address, req.Port)
+	// El handshake es inmediato para informar si el peer rechazó el emparejamiento
+	if err := p2pNetwork.ConnectPeer(req.PeerID, req.Address, req.Port); err != nil {
+		if err == blockchain.ErrGenesisMismatch {
+			respondError(c, http.StatusConflict, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": fmt.Sprintf("Peer %s agregado; el handshake se reintentará: %v", req.PeerID, err),
+		})
+		return
+	}
 	
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -160,50 +920,146 @@ func addPeer(c *gin.Context) {
 	})
 }
 
+// removePeer retira un peer de la red de este nodo
+func removePeer(c *gin.Context) {
+	peerID := c.Param("id")
+	if !p2pNetwork.RemovePeer(peerID) {
+		respondErrorMessage(c, http.StatusNotFound, "peer no encontrado")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Peer %s retirado exitosamente", peerID),
+	})
+}
+
+// reactivatePeer reintenta de inmediato un peer inactivo
+func reactivatePeer(c *gin.Context) {
+	peerID := c.Param("id")
+	err := p2pNetwork.ReactivatePeer(peerID)
+	switch {
+	case err == blockchain.ErrPeerNotFound:
+		respondError(c, http.StatusNotFound, err)
+		return
+	case err == blockchain.ErrPeerAlreadyActive:
+		respondError(c, http.StatusConflict, err)
+		return
+	case err != nil:
+		respondError(c, http.StatusBadGateway, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Peer %s reactivado exitosamente", peerID),
+	})
+}
+
+func getHandshake(c *gin.Context) {
+	c.JSON(http.StatusOK, p2pNetwork.LocalHandshake())
+}
+
+// registerPeerHandshake registra al nodo que se agregó como peer de este, con su handshake
+func registerPeerHandshake(c *gin.Context) {
+	var info blockchain.HandshakeInfo
+	if err := c.ShouldBindJSON(&info); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	local, err := p2pNetwork.RegisterPeer(info)
+	if err == blockchain.ErrGenesisMismatch {
+		respondError(c, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusForbidden, err)
+		return
+	}
+	c.JSON(http.StatusOK, local)
+}
+
 func getChain(c *gin.Context) {
-	// Convertir Chain de []*Block a []Block para JSON
+	// Convertir Chain de []*Block a []Block para JSON, incluyendo los bloques archivados
+	chain, err := bc.FullChain()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
 	var blocks []blockchain.Block
-	for _, block := range bc.Chain {
+	for _, block := range chain {
 		blocks = append(blocks, *block)
 	}
+	blocks = p2pNetwork.BlocksFor(c.GetHeader("X-Node-ID"), blocks)
 	
 	c.JSON(http.StatusOK, gin.H{
 		"chain":  blocks,
-		"length": len(blocks),
+		"length": blockchain.ByzantineReportedHeight(len(blocks)),
 		"node_id": p2pNetwork.NodeID,
 	})
 }
 
+// getBlocksFrom entrega una página de bloques desde una altura para la sincronización incremental
+func getBlocksFrom(c *gin.Context) {
+	from, err := strconv.Atoi(c.Query("from_height"))
+	if err != nil {
+		respondErrorMessage(c, http.StatusBadRequest, "from_height inválido")
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(blockchain.SyncPageSize)))
+
+	page, err := bc.BlocksFrom(from, limit)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	page.Height = blockchain.ByzantineReportedHeight(page.Height)
+	page.NodeID = p2pNetwork.NodeID
+	page.Blocks = p2pNetwork.BlocksFor(c.GetHeader("X-Node-ID"), page.Blocks)
+	c.JSON(http.StatusOK, page)
+}
+
 func receiveBlock(c *gin.Context) {
 	var block blockchain.Block
 	if err := c.ShouldBindJSON(&block); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	err := p2pNetwork.ReceiveBlock(block)
+	// El remitente es el del certificado de nodo; sin TLS mutuo el bloque se procesa como
+	// anónimo, sin reputación ni sincronización con quien lo envió
+	err := p2pNetwork.ReceiveBlock(block, peerIdentity(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Bloque recibido y procesado exitosamente",
+		"vote":    blockVote(block.Hash),
+	})
+}
+
+func getQuarantine(c *gin.Context) {
+	blocks := p2pNetwork.Quarantine.List(c.Query("sender"))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(blocks),
+		"data":    blocks,
 	})
 }
 
 func syncWithPeers(c *gin.Context) {
 	err := p2pNetwork.SyncWithPeers()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Sincronización completada",
-		"blocks":  len(bc.Chain),
+		"blocks":  bc.Len(),
 	})
 }
 
@@ -214,6 +1070,9 @@ func startPeriodicSync() {
 	defer ticker.Stop()
 
 	for range ticker.C {
+		if maintenance.IsEnabled() {
+			continue
+		}
 		fmt.Printf("🔄 Sincronización periódica iniciada\n")
 		p2pNetwork.SyncWithPeers()
 	}
@@ -231,45 +1090,259 @@ func startPeriodicHealthCheck() {
 
 // Handlers existentes modificados para P2P
 
+// getBlocks retorna el resumen de la cadena o, si se pide una página (?page, ?page_size o
+// ?type), los bloques con sus transacciones decodificadas para el explorador
 func getBlocks(c *gin.Context) {
+	_, paged := c.GetQuery("page")
+	_, sized := c.GetQuery("page_size")
+	_, typed := c.GetQuery("type")
+	if !paged && !sized && !typed {
+		c.JSON(http.StatusOK, gin.H{
+			"success":     true,
+			"data":        chainSummary(),
+			"finality":    bc.Finality.Summary(),
+			"type_counts": blockTypeCounts(),
+		})
+		return
+	}
+
+	query := blockchain.ExplorerQuery{Type: c.Query("type")}
+	var err error
+	if value := c.Query("page"); value != "" {
+		if query.Page, err = strconv.Atoi(value); err != nil || query.Page < 1 {
+			respondErrorMessage(c, http.StatusBadRequest, "page inválido")
+			return
+		}
+	}
+	if value := c.Query("page_size"); value != "" {
+		if query.PageSize, err = strconv.Atoi(value); err != nil || query.PageSize < 1 {
+			respondErrorMessage(c, http.StatusBadRequest, "page_size inválido")
+			return
+		}
+	}
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		query.Ascending = true
+	case "desc":
+	default:
+		respondErrorMessage(c, http.StatusBadRequest, "order inválido (se admite asc o desc)")
+		return
+	}
+
+	page, err := bc.ExplorerBlocks(query)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"page":        page.Page,
+		"page_size":   page.PageSize,
+		"total":       page.Total,
+		"total_pages": page.TotalPages,
+		"data":        page.Blocks,
+		"type_counts": blockTypeCounts(),
+	})
+}
+
+// getBlockTransactions retorna las transacciones decodificadas del bloque
+func getBlockTransactions(c *gin.Context) {
+	block, err := bc.ExplorerBlock(c.Param("hash"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"block":    block.Hash,
+		"height":   block.Height,
+		"withheld": block.Withheld,
+		"count":    block.TransactionCount,
+		"data":     block.Transactions,
+	})
+}
+
+// blockTypeCounts cuenta los bloques por tipo, reutilizando el cache mientras no lleguen bloques
+func blockTypeCounts() interface{} {
+	counts, _ := bc.Views.GetOrCompute("block_type_counts", bc.TipHash(), func() (interface{}, error) {
+		return bc.BlockTypeCounts(), nil
+	})
+	return counts
+}
+
+// getBlockByHash retorna un bloque por su hash local o el de su autor
+func getBlockByHash(c *gin.Context) {
+	block, err := bc.BlockByHash(c.Param("hash"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    block,
+	})
+}
+
+// getBlockByHeight retorna el bloque de la altura dada
+func getBlockByHeight(c *gin.Context) {
+	height, err := strconv.Atoi(c.Param("n"))
+	if err != nil {
+		respondErrorMessage(c, http.StatusBadRequest, "altura inválida")
+		return
+	}
+
+	block, err := bc.BlockAt(height)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data": gin.H{
-			"blocks_count":    len(bc.Chain),
-			"contracts_count": len(bc.Contracts),
+		"data":    block,
+	})
+}
+
+// chainSummary calcula el resumen de la cadena, reutilizando el cache mientras no lleguen bloques
+func chainSummary() interface{} {
+	summary, _ := bc.Views.GetOrCompute("chain_summary", bc.TipHash(), func() (interface{}, error) {
+		chain := bc.Blocks()
+		return gin.H{
+			"blocks_count":    len(chain),
+			"contracts_count": bc.ContractCount(),
 			"is_valid":        bc.IsChainValid(),
-			"latest_block":    bc.Chain[len(bc.Chain)-1],
-		},
+			"latest_block":    chain[len(chain)-1],
+		}, nil
 	})
+	return summary
 }
 
 func getContracts(c *gin.Context) {
-	contracts := bc.GetAllContracts()
-	c.JSON(http.StatusOK, gin.H{
+	query, err := parseContractQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	page, pageSize, err := parseContractPage(c, &query)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	contracts, err := bc.QueryContracts(query)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	total, err := bc.CountContracts(query)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := gin.H{
 		"success": true,
 		"count":   len(contracts),
+		"total":   total,
 		"data":    contracts,
+	}
+	if page > 0 {
+		response["page"] = page
+		response["page_size"] = pageSize
+		response["total_pages"] = (total + pageSize - 1) / pageSize
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getContract retorna el contrato con sus pasos de validación, su línea de auditoría y cada
+// evento que lo afectó con la altura y el hash del bloque donde quedó registrado
+func getContract(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+	history, err := bc.ContractHistory(contract.ID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"data":             contract,
+		"validation_steps": contract.ValidationSteps,
+		"audit_trail":      contract.AuditTrail,
+		"progress":         contract.Progress(time.Now()),
+		"history":          history,
 	})
 }
 
 func createContract(c *gin.Context) {
 	var contract blockchain.Contract
 	if err := c.ShouldBindJSON(&contract); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Las llaves de API de una entidad solo radican contratos de esa entidad
+	user := currentUser(c)
+	if !entityAllowed(user, contract.EntityCode) {
+		respondErrorMessage(c, http.StatusForbidden, entityScopeMessage(user))
+		return
+	}
+	// created_by sale de la sesión, así que se le exige el mismo formato que al cuerpo
+	if !validCreator(user.Subject) {
+		respondErrorMessage(c, http.StatusForbidden, "la sesión "+user.Subject+" no se identifica con un correo y no puede radicar contratos")
 		return
 	}
 
+	// Con Idempotency-Key, el reintento de una petición ya procesada recibe el mismo
+	// contrato en lugar de radicar uno duplicado
+	idempotencyKey := c.GetHeader(idempotencyKeyHeader)
+	if idempotencyKey != "" {
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			respondErrorMessage(c, http.StatusBadRequest, fmt.Sprintf("%s admite hasta %d caracteres", idempotencyKeyHeader, maxIdempotencyKeyLength))
+			return
+		}
+		requestHash, err := blockchain.IdempotencyRequestHash(contract)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		record, err := bc.IdempotencyKeys.Begin(user.Subject, idempotencyKey, requestHash)
+		switch {
+		case errors.Is(err, blockchain.ErrIdempotencyKeyReused):
+			respondError(c, http.StatusUnprocessableEntity, err)
+			return
+		case errors.Is(err, blockchain.ErrIdempotencyKeyInFlight):
+			respondError(c, http.StatusConflict, err)
+			return
+		case record != nil:
+			c.Header("Idempotent-Replayed", "true")
+			c.JSON(http.StatusCreated, gin.H{
+				"success": true,
+				"message": "Contrato creado exitosamente",
+				"contract_id": record.ContractID,
+			})
+			return
+		}
+	}
+
+	contract.CreatedBy = user.Subject
+
 	err := bc.AddContract(&contract)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if idempotencyKey != "" {
+			bc.IdempotencyKeys.Release(user.Subject, idempotencyKey)
+		}
+		respondContractError(c, err)
 		return
 	}
-
-	// Broadcast del nuevo bloque a peers
-	if len(bc.Chain) > 0 {
-		lastBlock := *bc.Chain[len(bc.Chain)-1]
-		fmt.Printf("📡 Broadcasting nuevo contrato a peers\n")
-		go p2pNetwork.BroadcastBlock(lastBlock)
+	if idempotencyKey != "" {
+		bc.IdempotencyKeys.Complete(user.Subject, idempotencyKey, contract.ID)
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -279,30 +1352,60 @@ func createContract(c *gin.Context) {
 	})
 }
 
-func validateContract(c *gin.Context) {
-	var req struct {
-		ContractID string `json:"contractId"`
-		NodeID     string `json:"nodeId"`
-		Approved   bool   `json:"approved"`
-		Reason     string `json:"reason"`
+// contractErrorStatus retorna el estado HTTP de un error del ledger al radicar o validar
+// un contrato
+func contractErrorStatus(err error) int {
+	var field *blockchain.ContractFieldError
+	switch {
+	case errors.As(err, &field):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, blockchain.ErrContractNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, blockchain.ErrContractExists), errors.Is(err, blockchain.ErrContractRejected):
+		return http.StatusConflict
+	case errors.Is(err, blockchain.ErrMaintenance):
+		return http.StatusServiceUnavailable
 	}
+	return http.StatusInternalServerError
+}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// respondContractError responde el error con contractErrorStatus; un dato que el ledger
+// rechaza se detalla en fields como los de la validación del cuerpo
+func respondContractError(c *gin.Context, err error) {
+	var field *blockchain.ContractFieldError
+	if !errors.As(err, &field) {
+		respondError(c, contractErrorStatus(err), err)
 		return
 	}
+	body := errorBody(c, http.StatusUnprocessableEntity, field.Field+": "+field.Message)
+	body["code"] = errorCodeValidation
+	body["fields"] = []fieldError{{Field: field.Field, Rule: field.Rule, Message: field.Message}}
+	c.AbortWithStatusJSON(http.StatusUnprocessableEntity, body)
+}
 
-	err := bc.ValidateContract(req.ContractID, req.NodeID, req.Approved, req.Reason)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+// validateContractRequest es el cuerpo de POST /api/contracts/validate
+type validateContractRequest struct {
+	ContractID string `json:"contractId"`
+	Approved   bool   `json:"approved"`
+	Reason     string `json:"reason"`
+}
+
+func validateContract(c *gin.Context) {
+	var req validateContractRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	// Broadcast del bloque de validación a peers
-	if len(bc.Chain) > 0 {
-		lastBlock := *bc.Chain[len(bc.Chain)-1]
-		fmt.Printf("📡 Broadcasting validación a peers\n")
-		go p2pNetwork.BroadcastBlock(lastBlock)
+	// Quien valida sale de la sesión, no del cuerpo, y solo sobre contratos de su entidad
+	if _, ok := requireContractEntity(c, req.ContractID); !ok {
+		return
+	}
+	err := bc.ValidateContract(req.ContractID, currentUser(c).Subject, req.Approved, req.Reason)
+	if err != nil {
+		respondContractError(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -314,12 +1417,8 @@ func validateContract(c *gin.Context) {
 func getStats(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data": gin.H{
-			"blocks_count":    len(bc.Chain),
-			"contracts_count": len(bc.Contracts),
-			"is_valid":        bc.IsChainValid(),
-			"latest_block":    bc.Chain[len(bc.Chain)-1],
-		},
+		"data":    chainSummary(),
+		"cache":   bc.Views.Stats(),
 	})
 }
 
@@ -331,68 +1430,94 @@ func getWorkflowSteps(c *gin.Context) {
 
 func getContractWorkflowStatus(c *gin.Context) {
 	contractID := c.Param("id")
-	status, err := workflowManager.GetWorkflowStatus(contractID)
+	status, err := bc.Views.GetOrCompute("workflow:"+contractID, bc.TipHash(), func() (interface{}, error) {
+		return workflowManager.GetWorkflowStatus(contractID)
+	})
 	if err != nil {
-		c.JSON(404, gin.H{"error": err.Error()})
+		respondError(c, 404, err)
 		return
 	}
 	c.JSON(200, status)
 }
 
+// validateContractStepRequest es el cuerpo de POST /api/contracts/:id/validate-step
+type validateContractStepRequest struct {
+	StepNumber int       `json:"step_number"`
+	Approved   bool      `json:"approved"`
+	Comments   string    `json:"comments"`
+	Signature  string    `json:"signature"`
+	KeyID      string    `json:"key_id"`
+	SignedAt   time.Time `json:"signed_at"`
+	// Acta firmada del comité: hash SHA-256 del documento y su referencia
+	ActHash      string `json:"act_hash"`
+	ActReference string `json:"act_reference"`
+}
+
 func validateContractStep(c *gin.Context) {
 	contractID := c.Param("id")
 	
-	var req struct {
-		StepNumber    int    `json:"step_number"`
-		ValidatorID   string `json:"validator_id"`
-		ValidatorName string `json:"validator_name"`
-		Role          string `json:"role"`
-		Approved      bool   `json:"approved"`
-		Comments      string `json:"comments"`
-	}
+	var req validateContractStepRequest
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	
-	role := blockchain.AdminRole(req.Role)
-	err := workflowManager.ValidateStep(contractID, req.StepNumber, req.ValidatorID, req.ValidatorName, role, req.Approved, req.Comments)
+	// El validador y su rol salen de la sesión, no del cuerpo de la petición
+	user := currentUser(c)
+	signature := blockchain.StepSignature{KeyID: req.KeyID, Signature: req.Signature, SignedAt: req.SignedAt}
+	var act *blockchain.StepAct
+	if req.ActHash != "" {
+		act = &blockchain.StepAct{Hash: req.ActHash, Reference: req.ActReference}
+	}
+	err := workflowManager.ValidateStep(contractID, req.StepNumber, user.Subject, user.Name, user.Role, req.Approved, req.Comments, signature, act)
 	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	
 	c.JSON(200, gin.H{"message": "Paso validado exitosamente"})
 }
 
+// addAuditObservationRequest es el cuerpo de POST /api/contracts/:id/audit
+type addAuditObservationRequest struct {
+	Observation string `json:"observation"`
+}
+
 func addAuditObservation(c *gin.Context) {
 	contractID := c.Param("id")
 	
-	var req struct {
-		AuditorID   string `json:"auditor_id"`
-		Role        string `json:"role"`
-		Observation string `json:"observation"`
-	}
+	var req addAuditObservationRequest
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	
-	role := blockchain.AdminRole(req.Role)
-	err := workflowManager.AddAuditObservation(contractID, req.AuditorID, role, req.Observation)
+	if _, ok := checkContractEntity(c, contractID); !ok {
+		return
+	}
+	user := currentUser(c)
+	observation, err := workflowManager.AddAuditObservation(contractID, user.Subject, user.Role, req.Observation)
 	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	
-	c.JSON(200, gin.H{"message": "Observación de auditoría agregada"})
+	c.JSON(200, gin.H{
+		"message":        "Observación de auditoría agregada",
+		"observation_id": observation.ID,
+		"due_at":         observation.DueAt,
+	})
 }
 
 func getContractsByStatus(c *gin.Context) {
 	status := c.Param("status")
-	contracts := bc.GetContractsByStatus(blockchain.ContractStatus(status))
+	contracts, err := bc.QueryContracts(blockchain.ContractQuery{Status: blockchain.ContractStatus(status)})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
 	c.JSON(200, gin.H{"contracts": contracts})
 }
 
@@ -402,11 +1527,109 @@ func getContractsByRole(c *gin.Context) {
 	c.JSON(200, gin.H{"contracts": contracts})
 }
 
-// Función auxiliar para obtener variables de entorno
+// parseContractQuery lee los filtros y el orden de contratos de la URL (entity o
+// entity_code, contract_type, status, from o created_after y to en formato 2006-01-02,
+// min_amount, max_amount, sort=amount|created_at, order=asc|desc, limit, offset)
+func parseContractQuery(c *gin.Context) (blockchain.ContractQuery, error) {
+	query := blockchain.ContractQuery{
+		EntityCode:   c.DefaultQuery("entity_code", c.Query("entity")),
+		ContractType: c.Query("contract_type"),
+		Status:       blockchain.ContractStatus(c.Query("status")),
+		Sort:         c.Query("sort"),
+	}
+	if err := query.Validate(); err != nil {
+		return query, err
+	}
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		query.Ascending = true
+	case "desc":
+	default:
+		return query, fmt.Errorf("order inválido: %s (se admite asc o desc)", c.Query("order"))
+	}
+
+	from := c.DefaultQuery("created_after", c.Query("from"))
+	for param, value := range map[string]string{"from": from, "to": c.Query("to")} {
+		target := &query.From
+		if param == "to" {
+			target = &query.To
+		}
+		if value != "" {
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				return query, fmt.Errorf("fecha inválida en %s: %s", param, value)
+			}
+			if param == "to" {
+				date = date.Add(24*time.Hour - time.Nanosecond)
+			}
+			*target = &date
+		}
+	}
+
+	for param, target := range map[string]**float64{"min_amount": &query.MinAmount, "max_amount": &query.MaxAmount} {
+		if value := c.Query(param); value != "" {
+			amount, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return query, fmt.Errorf("monto inválido en %s: %s", param, value)
+			}
+			*target = &amount
+		}
+	}
+
+	for param, target := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		if value := c.Query(param); value != "" {
+			number, err := strconv.Atoi(value)
+			if err != nil || number < 0 {
+				return query, fmt.Errorf("valor inválido en %s: %s", param, value)
+			}
+			*target = number
+		}
+	}
+
+	return query, nil
+}
+
+// Tamaño de página de GET /api/contracts
+const (
+	defaultContractPageSize = 50
+	maxContractPageSize     = 500
+)
+
+// parseContractPage aplica ?page y ?page_size a la consulta y retorna la página pedida.
+// Si el cliente usa limit y offset se respetan y la página retornada es 0.
+func parseContractPage(c *gin.Context, query *blockchain.ContractQuery) (int, int, error) {
+	if c.Query("page") == "" && c.Query("page_size") == "" && (query.Limit > 0 || query.Offset > 0) {
+		return 0, 0, nil
+	}
+
+	page, pageSize := 1, defaultContractPageSize
+	for param, target := range map[string]*int{"page": &page, "page_size": &pageSize} {
+		if value := c.Query(param); value != "" {
+			number, err := strconv.Atoi(value)
+			if err != nil || number <= 0 {
+				return 0, 0, fmt.Errorf("valor inválido en %s: %s", param, value)
+			}
+			*target = number
+		}
+	}
+	if pageSize > maxContractPageSize {
+		pageSize = maxContractPageSize
+	}
+
+	query.Limit = pageSize
+	query.Offset = (page - 1) * pageSize
+	return page, pageSize, nil
+}
+
+// getEnv lee la variable de entorno; si no está definida usa el valor del perfil activo
+// y, en último caso, defaultValue
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
+	if value := activeProfile.Env[key]; value != "" {
+		return value
+	}
 	return defaultValue
 }
 
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maintenanceGuard rechaza las operaciones que modifican la cadena mientras el nodo está en mantenimiento
+func maintenanceGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !maintenance.IsEnabled() {
+			c.Next()
+			return
+		}
+
+		status := maintenance.Status()
+		if status.ETA != nil {
+			retryAfter := int(time.Until(*status.ETA).Seconds())
+			if retryAfter > 0 {
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+			}
+		}
+
+		body := errorBody(c, http.StatusServiceUnavailable, "nodo en mantenimiento, escrituras congeladas")
+		body["reason"] = status.Reason
+		body["eta"] = status.ETA
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, body)
+	}
+}
+
+func getMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, maintenance.Status())
+}
+
+// setMaintenanceRequest es el cuerpo de POST /api/admin/maintenance
+type setMaintenanceRequest struct {
+	Enabled     bool   `json:"enabled"`
+	Reason      string `json:"reason"`
+	ETAMinutes  int    `json:"eta_minutes"`
+	NotifyPeers bool   `json:"notify_peers"`
+}
+
+func setMaintenance(c *gin.Context) {
+	var req setMaintenanceRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Enabled {
+		if req.Reason == "" {
+			respondErrorMessage(c, http.StatusBadRequest, "razón de mantenimiento requerida")
+			return
+		}
+		var eta time.Time
+		if req.ETAMinutes > 0 {
+			eta = time.Now().Add(time.Duration(req.ETAMinutes) * time.Minute)
+		}
+		maintenance.Enable(req.Reason, eta)
+		fmt.Printf("🛠️ Modo mantenimiento activado por %s: %s\n", currentUser(c).Subject, req.Reason)
+	} else {
+		maintenance.Disable()
+		fmt.Printf("✅ Modo mantenimiento desactivado por %s\n", currentUser(c).Subject)
+	}
+
+	if req.NotifyPeers {
+		p2pNetwork.NotifyMaintenance(req.Enabled)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"maintenance": maintenance.Status(),
+	})
+}
+
+// receivePeerMaintenanceRequest es el cuerpo de POST /api/p2p/maintenance
+type receivePeerMaintenanceRequest struct {
+	NodeID  string `json:"node_id"`
+	Enabled bool   `json:"enabled"`
+}
+
+// receivePeerMaintenance procesa el aviso de mantenimiento de otro nodo
+func receivePeerMaintenance(c *gin.Context) {
+	var req receivePeerMaintenanceRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if !p2pNetwork.SetPeerMaintenance(req.NodeID, req.Enabled) {
+		respondErrorMessage(c, http.StatusNotFound, "peer no encontrado")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers del mempool de transacciones
+
+// mempoolFromEnv lee la configuración del mempool. Con MEMPOOL_MAX_TRANSACTIONS en 0
+// (por defecto) cada transacción se agrega en su propio bloque.
+func mempoolFromEnv() (int, time.Duration, bool) {
+	maxTransactions, err := strconv.Atoi(getEnv("MEMPOOL_MAX_TRANSACTIONS", "0"))
+	if err != nil || maxTransactions <= 0 {
+		return 0, 0, false
+	}
+	seconds, err := strconv.Atoi(getEnv("MEMPOOL_INTERVAL_SECONDS", "2"))
+	if err != nil || seconds <= 0 {
+		seconds = 2
+	}
+	return maxTransactions, time.Duration(seconds) * time.Second, true
+}
+
+// mempoolTTLFromEnv lee cuánto se conservan las transacciones recibidas de otros nodos
+func mempoolTTLFromEnv() time.Duration {
+	seconds, err := strconv.Atoi(getEnv("MEMPOOL_TX_TTL_SECONDS", ""))
+	if err != nil || seconds <= 0 {
+		return blockchain.DefaultMempoolTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// getMempool reporta las transacciones en espera y los bloques sellados por el mempool
+func getMempool(c *gin.Context) {
+	if bc.Mempool == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": true,
+		"stats":   bc.Mempool.Stats(),
+		"pending": bc.Mempool.Pending(),
+	})
+}
+
+// receiveTransaction recibe una transacción pendiente difundida por un peer
+func receiveTransaction(c *gin.Context) {
+	var tx blockchain.MempoolTransaction
+	if err := c.ShouldBindJSON(&tx); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	sender := c.GetHeader("X-Node-ID")
+	if sender == "" {
+		sender = c.ClientIP()
+	}
+
+	if err := p2pNetwork.ReceiveTransaction(tx, sender); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de las métricas del nodo y de su envío a un colector remoto
+
+// metricsPushFromEnv lee el colector al que se envían las métricas (METRICS_PUSH_URL; sin él
+// no se envían) y cada cuántos segundos
+func metricsPushFromEnv() (string, time.Duration) {
+	seconds, err := strconv.Atoi(getEnv("METRICS_PUSH_INTERVAL_SECONDS", "60"))
+	if err != nil || seconds <= 0 {
+		seconds = 60
+	}
+	return getEnv("METRICS_PUSH_URL", ""), time.Duration(seconds) * time.Second
+}
+
+// getMetrics expone las métricas del nodo en el formato de texto de Prometheus
+func getMetrics(c *gin.Context) {
+	text := blockchain.PrometheusText(p2pNetwork.NodeID, p2pNetwork.Metrics())
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(text))
+}
+
+// getMetricsPush informa el estado del envío de métricas al colector
+func getMetricsPush(c *gin.Context) {
+	if metricsPusher == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": true,
+		"status":  metricsPusher.Status(),
+	})
+}
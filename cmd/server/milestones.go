@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de los hitos de ejecución y sus actas de pago
+
+// addMilestoneRequest es el cuerpo de POST /api/contracts/:id/milestones
+type addMilestoneRequest struct {
+	Description string    `json:"description" binding:"required"`
+	DueDate     time.Time `json:"due_date" binding:"required"`
+	Amount      float64   `json:"amount" binding:"gt=0,lte=1000000000000000"`
+}
+
+// addMilestone agrega un hito al plan de ejecución del contrato
+func addMilestone(c *gin.Context) {
+	contractID := c.Param("id")
+
+	var req addMilestoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if _, ok := checkContractEntity(c, contractID); !ok {
+		return
+	}
+
+	user := currentUser(c)
+	milestone, err := milestoneTracker.AddMilestone(contractID, req.Description, req.DueDate, req.Amount, user.Subject, user.Role)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":   true,
+		"milestone": milestone,
+	})
+}
+
+// getContractMilestones es público: lista el plan de hitos del contrato con su avance
+func getContractMilestones(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	milestones := append([]blockchain.Milestone{}, contract.Milestones...)
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"contract_id": contract.ID,
+		"progress":    contract.Progress(time.Now()),
+		"milestones":  milestones,
+	})
+}
+
+// completeMilestoneRequest es el cuerpo de POST /api/contracts/:id/milestones/:mid/completion
+type completeMilestoneRequest struct {
+	EvidenceHash string `json:"evidence_hash" binding:"required"`
+}
+
+// completeMilestone certifica el cumplimiento del hito con el hash de su evidencia
+func completeMilestone(c *gin.Context) {
+	contractID := c.Param("id")
+
+	var req completeMilestoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if _, ok := checkContractEntity(c, contractID); !ok {
+		return
+	}
+
+	user := currentUser(c)
+	milestone, err := milestoneTracker.CompleteMilestone(contractID, c.Param("mid"), req.EvidenceHash, user.Subject, user.Role)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"milestone": milestone,
+	})
+}
+
+// registerPaymentActRequest es el cuerpo de POST /api/contracts/:id/milestones/:mid/payment-act
+type registerPaymentActRequest struct {
+	Reference string    `json:"reference" binding:"required"` // Número del acta de pago
+	PaidAt    time.Time `json:"paid_at"`
+}
+
+// registerPaymentAct paga el hito cumplido con un acta de pago
+func registerPaymentAct(c *gin.Context) {
+	contractID := c.Param("id")
+
+	var req registerPaymentActRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if _, ok := checkContractEntity(c, contractID); !ok {
+		return
+	}
+
+	user := currentUser(c)
+	payment, err := milestoneTracker.RegisterPaymentAct(contractID, c.Param("mid"), req.Reference, req.PaidAt, user.Subject, user.Role)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"payment": payment,
+	})
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Endpoints de la app móvil de veeduría ciudadana: respuestas compactas, paginadas en el
+// servidor y con miniaturas de las evidencias para no descargar las fotos originales
+
+// Paginación de los listados móviles
+const (
+	mobileDefaultPageSize = 20
+	mobileMaxPageSize     = 50
+)
+
+// Longitud máxima de la descripción en los resúmenes de contrato
+const mobileDescriptionLength = 140
+
+// shapeResponse deja en cada elemento solo los campos pedidos en ?fields=a,b,c; sin el
+// parámetro los elementos se retornan completos. El id se conserva siempre para que el
+// cliente pueda pedir el detalle.
+func shapeResponse(c *gin.Context, items []gin.H) []gin.H {
+	fields := c.Query("fields")
+	if fields == "" {
+		return items
+	}
+
+	keep := map[string]bool{"id": true}
+	for _, field := range strings.Split(fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			keep[field] = true
+		}
+	}
+
+	shaped := make([]gin.H, 0, len(items))
+	for _, item := range items {
+		compact := gin.H{}
+		for field, value := range item {
+			if keep[field] {
+				compact[field] = value
+			}
+		}
+		shaped = append(shaped, compact)
+	}
+	return shaped
+}
+
+// mobilePage lee limit y offset aplicando los valores por defecto de la app
+func mobilePage(c *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = mobileDefaultPageSize
+	}
+	if limit > mobileMaxPageSize {
+		limit = mobileMaxPageSize
+	}
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// mobileContractSummary es la versión compacta de un contrato
+func mobileContractSummary(contract *blockchain.Contract) gin.H {
+	description := contract.Description
+	if utf8.RuneCountInString(description) > mobileDescriptionLength {
+		description = string([]rune(description)[:mobileDescriptionLength-1]) + "…"
+	}
+	return gin.H{
+		"id":             contract.ID,
+		"process_number": contract.ProcessNumber,
+		"entity_name":    contract.EntityName,
+		"contract_type":  contract.ContractType,
+		"description":    description,
+		"amount":         contract.Amount,
+		"status":         contract.Status,
+		"awarded_to":     contract.AwardedTo,
+		"evidence_count": len(contract.Evidence),
+		"updated_at":     contract.UpdatedAt,
+	}
+}
+
+// mobileTimeline retorna la línea de auditoría sin datos de quien la registró
+func mobileTimeline(contract *blockchain.Contract) []gin.H {
+	timeline := make([]gin.H, 0, len(contract.AuditTrail))
+	for _, entry := range contract.AuditTrail {
+		timeline = append(timeline, gin.H{
+			"id":          entry.ID,
+			"action":      entry.Action,
+			"role":        entry.UserRole,
+			"description": entry.Description,
+			"timestamp":   entry.Timestamp,
+		})
+	}
+	return timeline
+}
+
+// mobileEvidence retorna las evidencias del contrato con la URL de su miniatura
+func mobileEvidence(contract *blockchain.Contract) []gin.H {
+	gallery := make([]gin.H, 0, len(contract.Evidence))
+	for _, evidence := range contract.Evidence {
+		item := gin.H{
+			"id":          evidence.ID,
+			"media_type":  evidence.MediaType,
+			"description": evidence.Description,
+			"uploaded_at": evidence.UploadedAt,
+			"url":         "/api/contracts/" + contract.ID + "/evidence/" + evidence.ID + "/file",
+		}
+		if strings.HasPrefix(evidence.MediaType, "image/") {
+			item["thumbnail_url"] = "/api/mobile/contracts/" + contract.ID + "/evidence/" + evidence.ID + "/thumbnail"
+		}
+		gallery = append(gallery, item)
+	}
+	return gallery
+}
+
+// getMobileContracts lista resúmenes de contratos con los mismos filtros de /api/contracts
+func getMobileContracts(c *gin.Context) {
+	query, err := parseContractQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	limit, offset := mobilePage(c)
+	// Se pide un contrato de más para saber si hay otra página sin contar todos
+	query.Limit, query.Offset = limit+1, offset
+
+	contracts, err := bc.QueryContracts(query)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	hasMore := len(contracts) > limit
+	if hasMore {
+		contracts = contracts[:limit]
+	}
+
+	summaries := make([]gin.H, 0, len(contracts))
+	for _, contract := range contracts {
+		summaries = append(summaries, mobileContractSummary(contract))
+	}
+
+	response := gin.H{
+		"success":  true,
+		"count":    len(summaries),
+		"limit":    limit,
+		"offset":   offset,
+		"has_more": hasMore,
+		"data":     shapeResponse(c, summaries),
+	}
+	if hasMore {
+		response["next_offset"] = offset + limit
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getMobileContract retorna el resumen del contrato con sus últimos eventos y evidencias
+func getMobileContract(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	timeline := mobileTimeline(contract)
+	if len(timeline) > mobileDefaultPageSize {
+		timeline = timeline[len(timeline)-mobileDefaultPageSize:]
+	}
+
+	summary := mobileContractSummary(contract)
+	summary["timeline"] = timeline
+	summary["evidence"] = mobileEvidence(contract)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    shapeResponse(c, []gin.H{summary})[0],
+	})
+}
+
+// getMobileTimeline pagina la línea de auditoría, lo más reciente primero
+func getMobileTimeline(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+	limit, offset := mobilePage(c)
+
+	timeline := mobileTimeline(contract)
+	for i, j := 0, len(timeline)-1; i < j; i, j = i+1, j-1 {
+		timeline[i], timeline[j] = timeline[j], timeline[i]
+	}
+	total := len(timeline)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"contract_id": contract.ID,
+		"total":       total,
+		"count":       end - offset,
+		"limit":       limit,
+		"offset":      offset,
+		"has_more":    end < total,
+		"data":        shapeResponse(c, timeline[offset:end]),
+	})
+}
+
+// getMobileEvidence lista las evidencias del contrato con sus miniaturas
+func getMobileEvidence(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	gallery := mobileEvidence(contract)
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"contract_id": contract.ID,
+		"count":       len(gallery),
+		"data":        shapeResponse(c, gallery),
+	})
+}
+
+// getEvidenceThumbnail retorna la miniatura JPEG de una foto de evidencia (?size= en píxeles)
+func getEvidenceThumbnail(c *gin.Context) {
+	size, err := strconv.Atoi(c.DefaultQuery("size", strconv.Itoa(blockchain.DefaultThumbnailSize)))
+	if err != nil || size < blockchain.MinThumbnailSize || size > blockchain.MaxThumbnailSize {
+		respondErrorMessage(c, http.StatusBadRequest, "size inválido")
+		return
+	}
+
+	thumbnail, err := evidenceStore.Thumbnail(c.Param("id"), c.Param("eid"), size)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	// La evidencia es inmutable: la miniatura puede quedar en caché del teléfono
+	c.Header("Cache-Control", "public, max-age=86400, immutable")
+	c.Data(http.StatusOK, "image/jpeg", thumbnail)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de las observaciones de los entes de control y su respuesta
+
+// observationResponseDaysFromEnv lee el plazo de respuesta de OBSERVATION_RESPONSE_DAYS
+func observationResponseDaysFromEnv() int {
+	days, err := strconv.Atoi(getEnv("OBSERVATION_RESPONSE_DAYS", strconv.Itoa(blockchain.DefaultObservationResponseDays)))
+	if err != nil || days <= 0 {
+		return blockchain.DefaultObservationResponseDays
+	}
+	return days
+}
+
+// getContractObservations lista las observaciones del contrato con su respuesta y estado
+func getContractObservations(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	now := time.Now()
+	observations := make([]gin.H, 0, len(contract.Observations))
+	for _, observation := range contract.Observations {
+		observations = append(observations, gin.H{
+			"observation": observation,
+			"status":      observation.Status(now),
+			"late":        observation.RespondedLate(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"observations": observations,
+	})
+}
+
+// respondAuditObservationRequest es el cuerpo de POST /api/contracts/:id/audit/:oid/response
+type respondAuditObservationRequest struct {
+	Response string `json:"response"`
+}
+
+// respondAuditObservation registra la respuesta de la entidad a una observación de control
+func respondAuditObservation(c *gin.Context) {
+	contractID := c.Param("id")
+
+	var req respondAuditObservationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, ok := checkContractEntity(c, contractID); !ok {
+		return
+	}
+	user := currentUser(c)
+
+	observation, err := workflowManager.RespondObservation(contractID, c.Param("oid"), user.Subject, user.Role, req.Response)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"message":     "Respuesta a la observación registrada",
+		"observation": observation,
+		"late":        observation.RespondedLate(),
+	})
+}
+
+// getOverdueObservations lista las observaciones vencidas sin respuesta
+func getOverdueObservations(c *gin.Context) {
+	overdue := observationTracker.Overdue(time.Now())
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"count":        len(overdue),
+		"observations": overdue,
+	})
+}
@@ -0,0 +1,539 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"secop-blockchain/internal/auth"
+	"secop-blockchain/internal/graphql"
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Especificación OpenAPI 3 de la API y su explorador Swagger UI, para que los equipos de
+// front-end y las entidades integren sin leer el código. Las rutas salen del router, así
+// que ninguna queda por fuera; apiDocs agrega el resumen, los parámetros de consulta y los
+// tipos del cuerpo y de la respuesta, y de esos tipos se derivan los esquemas.
+
+// apiDoc describe una ruta de la API
+type apiDoc struct {
+	Summary  string
+	Query    []string    // Parámetros de consulta que admite
+	Request  interface{} // Cuerpo JSON (un valor del tipo, p. ej. loginRequest{})
+	Response interface{} // Contenido de "data" en la respuesta {"success": true, "data": ...}
+	Auth     bool        // Exige sesión (Authorization: Bearer) o llave de API
+	Roles    []blockchain.AdminRole
+}
+
+// Parámetros de consulta compartidos por varias rutas
+var (
+	contractQueryParams = []string{"entity_code", "entity", "contract_type", "status", "from", "to", "created_after", "min_amount", "max_amount", "sort", "order", "page", "page_size", "limit", "offset"}
+	mobileQueryParams   = []string{"fields", "limit", "offset", "size"}
+)
+
+// apiDocs documenta las rutas por "MÉTODO ruta", con la ruta sin versión
+var apiDocs = map[string]apiDoc{
+	// Autenticación
+	"POST /api/auth/login":  {Summary: "Inicia sesión de un funcionario y emite el token de acceso", Request: loginRequest{}},
+	"GET /api/auth/session": {Summary: "Identidad de la sesión actual", Auth: true},
+
+	// Bloques
+	"GET /api/blocks":                      {Summary: "Resumen de la cadena o, con page, page_size o type, página de bloques decodificados", Query: []string{"page", "page_size", "type", "order"}, Response: []blockchain.ExplorerBlock{}},
+	"GET /api/blocks/wait":                 {Summary: "Espera (long polling) hasta que haya bloques después de una altura", Query: []string{"after_height", "timeout"}},
+	"GET /api/blocks/height/:n":            {Summary: "Bloque en la altura dada", Response: blockchain.Block{}},
+	"GET /api/blocks/:hash":                {Summary: "Bloque por su hash local o el de su autor", Response: blockchain.Block{}},
+	"GET /api/blocks/:hash/finality":       {Summary: "Estado de finalidad del bloque"},
+	"GET /api/blocks/:hash/transactions":   {Summary: "Transacciones decodificadas del bloque", Response: []blockchain.ExplorerTransaction{}},
+	"GET /api/proofs/:txid":                {Summary: "Prueba de Merkle de inclusión de una transacción"},
+	"GET /api/stats":                       {Summary: "Estadísticas de la cadena"},
+	"GET /api/events/stream":               {Summary: "Flujo de eventos de la cadena (Server-Sent Events)"},
+	"GET /ws":                              {Summary: "Suscripción en vivo a eventos por WebSocket"},
+	"GET /api/chain/snapshot":              {Summary: "Instantánea de la cadena para respaldo", Query: []string{"from"}, Auth: true, Roles: nodeAdminRoles},
+	"POST /api/chain/restore":              {Summary: "Restaura la cadena desde una instantánea", Query: []string{"dry_run"}, Request: blockchain.Snapshot{}, Auth: true, Roles: nodeAdminRoles},
+	"GET /api/chain/backup":                {Summary: "Respaldo cifrado de la cadena", Auth: true, Roles: nodeAdminRoles},
+	"POST /api/chain/backup/restore":       {Summary: "Restaura la cadena desde un respaldo cifrado", Request: blockchain.EncryptedBackup{}, Auth: true, Roles: nodeAdminRoles},
+	"GET /api/export/contracts.parquet":    {Summary: "Exportación Parquet de los contratos", Query: []string{"schema"}, Auth: true, Roles: exportRoles},
+	"GET /api/export/blocks.parquet":       {Summary: "Exportación Parquet de los bloques", Query: []string{"schema"}, Auth: true, Roles: exportRoles},
+	"GET /api/admin/quarantine":            {Summary: "Bloques en cuarentena recibidos de otros nodos", Query: []string{"sender"}, Response: []blockchain.QuarantinedBlock{}, Auth: true, Roles: nodeAdminRoles},
+	"GET /api/admin/forks":                 {Summary: "Bifurcaciones detectadas y resueltas", Query: []string{"limit"}, Auth: true, Roles: nodeAdminRoles},
+	"GET /api/admin/archive":               {Summary: "Estado del archivo de bloques antiguos", Auth: true, Roles: nodeAdminRoles},
+	"POST /api/admin/archive/run":          {Summary: "Archiva los bloques antiguos ahora", Auth: true, Roles: nodeAdminRoles},
+	"GET /api/admin/mempool":               {Summary: "Transacciones pendientes en el mempool", Auth: true, Roles: nodeAdminRoles},
+	"GET /api/checkpoints":                 {Summary: "Checkpoints de la cadena"},
+	"POST /api/checkpoints":                {Summary: "Fuerza un checkpoint en el nodo autoridad", Auth: true, Roles: checkpointAdminRoles},
+	"GET /api/consensus":                   {Summary: "Validadores del consenso por prueba de autoridad"},
+	"POST /api/consensus/validators":       {Summary: "Propone agregar un validador", Request: addAuthorityRequest{}, Auth: true, Roles: consensusAdminRoles},
+	"DELETE /api/consensus/validators/:id": {Summary: "Propone retirar un validador", Auth: true, Roles: consensusAdminRoles},
+	"GET /api/keys/validators":             {Summary: "Llaves públicas de los validadores"},
+	"GET /.well-known/jwks.json":           {Summary: "Llaves públicas de los validadores en formato JWKS"},
+	"POST /api/keys/register":              {Summary: "Registra la llave pública de firma del funcionario", Request: registerOfficialKeyRequest{}, Auth: true, Roles: workflowRoles},
+	"GET /api/keys/officials/:id":          {Summary: "Llaves públicas de un funcionario", Response: []blockchain.JWK{}},
+
+	// Contratos
+	"GET /api/contracts":                                    {Summary: "Contratos paginados con filtros y orden", Query: contractQueryParams, Response: []blockchain.Contract{}},
+	"GET /api/contracts/search":                             {Summary: "Búsqueda de texto completo en los contratos", Query: []string{"q", "limit"}},
+	"GET /api/contracts/:id":                                {Summary: "Contrato con sus pasos de validación, auditoría e historial en la cadena", Response: blockchain.Contract{}},
+	"POST /api/contracts":                                   {Summary: "Radica un contrato nuevo; con el encabezado Idempotency-Key los reintentos retornan el mismo contrato", Request: blockchain.Contract{}, Auth: true, Roles: contractCreatorRoles},
+	"POST /api/contracts/validate":                          {Summary: "Registra la validación de un contrato por un funcionario de la entidad", Request: validateContractRequest{}, Auth: true, Roles: workflowRoles},
+	"POST /api/contracts/signed":                            {Summary: "Radica un contrato firmado por el sistema de una entidad", Request: createSignedContractRequest{}},
+	"GET /api/contracts/by-status/:status":                  {Summary: "Contratos en un estado", Response: []blockchain.Contract{}},
+	"GET /api/contracts/by-role/:role":                      {Summary: "Contratos pendientes de un rol", Response: []blockchain.Contract{}},
+	"GET /api/contracts/:id/decisions":                      {Summary: "Matriz de decisiones de los validadores en JSON o CSV", Query: []string{"format"}, Response: []blockchain.ContractDecision{}},
+	"GET /api/contracts/:id/operations":                     {Summary: "Operaciones en curso sobre el contrato", Response: []blockchain.InFlightOperation{}},
+	"POST /api/contracts/:id/draft-hold":                    {Summary: "Retiene o libera un borrador de la depuración automática", Request: setDraftHoldRequest{}, Auth: true, Roles: entityAdminRoles},
+	"GET /api/admin/drafts":                                 {Summary: "Borradores depurados y por depurar de la entidad de la sesión", Auth: true, Roles: entityAdminRoles},
+	"POST /api/admin/drafts/sweep":                          {Summary: "Depura ahora los borradores vencidos de la entidad", Auth: true, Roles: entityAdminRoles},
+	"POST /api/admin/drafts/:id/restore":                    {Summary: "Restaura un borrador depurado de la entidad", Auth: true, Roles: entityAdminRoles},
+	"GET /api/mobile/contracts":                             {Summary: "Contratos en formato compacto para la app móvil", Query: mobileQueryParams},
+	"GET /api/mobile/contracts/:id":                         {Summary: "Contrato en formato compacto para la app móvil", Query: mobileQueryParams},
+	"GET /api/mobile/contracts/:id/timeline":                {Summary: "Línea de tiempo del contrato para la app móvil", Query: mobileQueryParams},
+	"GET /api/mobile/contracts/:id/evidence":                {Summary: "Evidencias del contrato para la app móvil", Query: mobileQueryParams},
+	"GET /api/mobile/contracts/:id/evidence/:eid/thumbnail": {Summary: "Miniatura de una evidencia"},
+
+	// Flujo de validación
+	"GET /api/workflow/steps":                             {Summary: "Pasos del flujo de validación"},
+	"GET /api/workflow/templates":                         {Summary: "Plantillas de aprobación automática vigentes", Response: []blockchain.WorkflowTemplate{}},
+	"PUT /api/workflow/templates/:type":                   {Summary: "Publica una versión de la plantilla de una modalidad", Request: setWorkflowTemplateRequest{}, Auth: true, Roles: workflowTemplateRoles},
+	"DELETE /api/workflow/templates/:type":                {Summary: "Retira la plantilla de una modalidad", Auth: true, Roles: workflowTemplateRoles},
+	"GET /api/workflow/templates/:type/versions":          {Summary: "Versiones de la plantilla de una modalidad", Response: []blockchain.WorkflowTemplate{}},
+	"GET /api/workflow/templates/:type/versions/:version": {Summary: "Una versión de la plantilla de una modalidad", Response: blockchain.WorkflowTemplate{}},
+	"POST /api/contracts/:id/workflow/template-migration": {Summary: "Migra el contrato a otra versión de su plantilla", Request: migrateContractTemplateRequest{}, Auth: true, Roles: workflowTemplateRoles},
+	"GET /api/contracts/:id/workflow":                     {Summary: "Estado del flujo de validación del contrato"},
+	"POST /api/contracts/:id/validate-step":               {Summary: "Aprueba o rechaza un paso del flujo", Request: validateContractStepRequest{}, Auth: true, Roles: workflowRoles},
+	"POST /api/contracts/:id/conflict-declarations":       {Summary: "Declara si hay conflicto de interés antes de decidir", Request: declareConflictRequest{}, Auth: true, Roles: workflowRoles},
+	"GET /api/contracts/:id/conflict-declarations":        {Summary: "Declaraciones de conflicto de interés del contrato", Response: []blockchain.ConflictDeclaration{}, Auth: true, Roles: conflictReviewerRoles},
+	"GET /api/work-queue/:role":                           {Summary: "Cola de trabajo del comité", Response: []blockchain.WorkItem{}},
+	"POST /api/work-queue/:role/claim":                    {Summary: "Toma los siguientes contratos de la cola del rol de la sesión", Request: claimNextFromQueueRequest{}, Auth: true, Roles: workflowRoles},
+	"POST /api/contracts/:id/claim":                       {Summary: "Toma un contrato para revisión", Auth: true, Roles: workflowRoles},
+	"POST /api/contracts/:id/assign":                      {Summary: "Asigna un contrato a un revisor", Request: assignContractRequest{}, Auth: true, Roles: workflowRoles},
+	"POST /api/contracts/:id/release":                     {Summary: "Libera un contrato tomado por la sesión", Auth: true},
+
+	// Control
+	"POST /api/contracts/:id/audit":               {Summary: "Registra una observación de un ente de control", Request: addAuditObservationRequest{}, Auth: true, Roles: auditorRoles},
+	"GET /api/contracts/:id/observations":         {Summary: "Observaciones de control del contrato"},
+	"POST /api/contracts/:id/audit/:oid/response": {Summary: "Responde una observación de control", Request: respondAuditObservationRequest{}, Auth: true, Roles: workflowRoles},
+	"GET /api/observations/overdue":               {Summary: "Observaciones de control sin respuesta a tiempo", Auth: true, Roles: auditorRoles},
+	"GET /api/reserved/blocks/:hash":              {Summary: "Contenido de un bloque de un contrato reservado", Auth: true, Roles: reservedReaderRoles},
+	"GET /api/reserved/access-log":                {Summary: "Registro de accesos a los contratos reservados", Query: []string{"block_hash"}, Auth: true, Roles: reservedReaderRoles},
+	"GET /api/alerts":                             {Summary: "Alertas generadas para el ente de control de la sesión", Response: []blockchain.Alert{}, Auth: true, Roles: alertRuleRoles},
+	"GET /api/alerts/rules":                       {Summary: "Reglas de alerta del ente de control de la sesión", Response: []blockchain.AlertRule{}, Auth: true, Roles: alertRuleRoles},
+	"POST /api/alerts/rules":                      {Summary: "Crea una regla de alerta", Request: createAlertRuleRequest{}, Auth: true, Roles: alertRuleRoles},
+	"DELETE /api/alerts/rules/:id":                {Summary: "Elimina una regla de alerta", Auth: true, Roles: alertRuleRoles},
+	"GET /api/process-numbers/reports":            {Summary: "Informes de la revisión de consecutivos de números de proceso", Response: []blockchain.ProcessNumberReport{}, Auth: true, Roles: processNumberAuditRoles},
+	"POST /api/process-numbers/audit":             {Summary: "Revisa los consecutivos de números de proceso ahora", Auth: true, Roles: processNumberAuditRoles},
+	"GET /api/analytics/clusters":                 {Summary: "Grupos de contratos con objeto similar", Query: []string{"entity_code", "possible_split"}, Response: []blockchain.ContractCluster{}, Auth: true, Roles: clusterAnalystRoles},
+	"POST /api/analytics/clusters/run":            {Summary: "Recalcula los grupos de contratos similares", Auth: true, Roles: clusterAnalystRoles},
+	"GET /api/analytics/activity":                 {Summary: "Mapa de calor de las decisiones de validación con señales sospechosas", Query: []string{"bucket", "group_by", "from", "to", "entity_code", "role", "tz"}, Response: blockchain.ActivityReport{}, Auth: true, Roles: activityAnalystRoles},
+	"GET /api/analytics/kpi-targets":              {Summary: "Metas de gestión contractual", Auth: true, Roles: complianceAnalystRoles},
+	"PUT /api/analytics/kpi-targets":              {Summary: "Fija las metas de gestión contractual", Request: setKPITargetsRequest{}, Auth: true, Roles: kpiTargetRoles},
+	"GET /api/analytics/compliance":               {Summary: "Cumplimiento de las metas por entidad", Query: []string{"entity_code", "period"}, Auth: true, Roles: complianceAnalystRoles},
+	"POST /api/analytics/compliance/run":          {Summary: "Calcula el cumplimiento de las metas de un periodo", Request: scoreComplianceRequest{}, Auth: true, Roles: complianceAnalystRoles},
+	"GET /api/queries":                            {Summary: "Consultas guardadas visibles para la sesión", Response: []blockchain.SavedQuery{}, Auth: true},
+	"POST /api/queries":                           {Summary: "Guarda una consulta", Request: createSavedQueryRequest{}, Auth: true},
+	"GET /api/queries/:id":                        {Summary: "Una consulta guardada", Auth: true},
+	"DELETE /api/queries/:id":                     {Summary: "Elimina una consulta guardada", Auth: true},
+	"POST /api/queries/:id/run":                   {Summary: "Ejecuta una consulta guardada", Auth: true},
+	"GET /api/queries/:id/results":                {Summary: "Último resultado de una consulta guardada", Auth: true},
+
+	// Suscripciones y webhooks
+	"POST /api/contracts/:id/subscribe":            {Summary: "Suscribe un sistema externo a los cambios del contrato", Request: subscribeToContractRequest{}, Auth: true},
+	"GET /api/contracts/:id/subscriptions":         {Summary: "Suscripciones al contrato registradas por la sesión", Response: []blockchain.ContractSubscription{}, Auth: true},
+	"DELETE /api/contracts/:id/subscriptions/:sid": {Summary: "Cancela una suscripción; solo quien la registró", Auth: true},
+	"GET /api/webhooks":                            {Summary: "Webhooks registrados con su estado de entrega", Response: []blockchain.WebhookStatus{}, Auth: true, Roles: webhookAdminRoles},
+	"POST /api/webhooks":                           {Summary: "Registra un webhook", Request: createWebhookRequest{}, Auth: true, Roles: webhookAdminRoles},
+	"GET /api/webhooks/:id":                        {Summary: "Un webhook con su estado de entrega", Auth: true, Roles: webhookAdminRoles},
+	"PUT /api/webhooks/:id":                        {Summary: "Actualiza un webhook", Request: blockchain.WebhookUpdate{}, Auth: true, Roles: webhookAdminRoles},
+	"DELETE /api/webhooks/:id":                     {Summary: "Elimina un webhook", Auth: true, Roles: webhookAdminRoles},
+
+	// Evidencias y adjuntos
+	"GET /api/contracts/:id/evidence":                  {Summary: "Galería de evidencias de ejecución"},
+	"POST /api/contracts/:id/evidence":                 {Summary: "Carga una evidencia de ejecución (multipart)", Auth: true, Roles: evidenceUploaderRoles},
+	"GET /api/contracts/:id/evidence/:eid/file":        {Summary: "Archivo de una evidencia"},
+	"GET /api/contracts/:id/attachments":               {Summary: "Documentos adjuntos del contrato"},
+	"POST /api/contracts/:id/attachments":              {Summary: "Carga un documento adjunto (multipart)", Auth: true, Roles: attachmentUploaderRoles},
+	"GET /api/contracts/:id/attachments/:aid/file":     {Summary: "Archivo de un documento adjunto"},
+	"PUT /api/contracts/:id/attachments/:aid/indexing": {Summary: "Permite o impide que los buscadores indexen el adjunto", Request: setAttachmentIndexingRequest{}, Auth: true, Roles: attachmentUploaderRoles},
+
+	// Entidades y proveedores
+	"GET /api/entities/:code/systems":                 {Summary: "Sistemas externos de la entidad con sus llaves", Response: []blockchain.EntitySystemKey{}},
+	"POST /api/entities/:code/systems":                {Summary: "Registra la llave pública de un sistema de la entidad", Request: registerSystemKeyRequest{}, Auth: true, Roles: entityAdminRoles},
+	"GET /api/suppliers":                              {Summary: "Proveedores registrados", Response: []blockchain.Supplier{}},
+	"POST /api/suppliers":                             {Summary: "Registra un proveedor", Request: blockchain.Supplier{}, Auth: true, Roles: contractOfficeRoles},
+	"POST /api/suppliers/:nit/sanctions":              {Summary: "Registra una sanción a un proveedor", Request: addSupplierSanctionRequest{}, Auth: true, Roles: sanctionRoles},
+	"GET /api/suppliers/:nit/history":                 {Summary: "Historial contractual del proveedor", Response: []blockchain.SupplierHistoryEntry{}},
+	"POST /api/contracts/:id/publish":                 {Summary: "Publica el proceso y abre las observaciones al pliego", Request: publishContractRequest{}, Auth: true, Roles: contractOfficeRoles},
+	"GET /api/contracts/:id/questions":                {Summary: "Observaciones al pliego con sus respuestas"},
+	"POST /api/contracts/:id/questions":               {Summary: "Presenta una observación al pliego", Request: submitContractQuestionRequest{}, Auth: true},
+	"POST /api/contracts/:id/questions/:qid/response": {Summary: "Responde una observación al pliego", Request: respondContractQuestionRequest{}, Auth: true, Roles: contractOfficeRoles},
+	"POST /api/contracts/:id/award":                   {Summary: "Adjudica el contrato", Request: awardContractRequest{}, Auth: true, Roles: contractOfficeRoles},
+
+	// Pagos y vigencias
+	"GET /api/contracts/:id/payments":                     {Summary: "Pagos del contrato"},
+	"POST /api/contracts/:id/payments":                    {Summary: "Registra un pago", Request: registerContractPaymentRequest{}, Auth: true, Roles: treasuryRoles},
+	"POST /api/contracts/:id/carryovers":                  {Summary: "Constituye un saldo para la siguiente vigencia", Request: markCarryoverRequest{}, Auth: true, Roles: treasuryRoles},
+	"GET /api/contracts/:id/amendments":                   {Summary: "Adiciones y prórrogas del contrato con su valor acumulado"},
+	"POST /api/contracts/:id/amendments":                  {Summary: "Solicita una adición o prórroga", Request: requestAmendmentRequest{}, Response: blockchain.Amendment{}, Auth: true, Roles: amendmentRequesterRoles},
+	"POST /api/contracts/:id/amendments/:aid/decision":    {Summary: "Decide el paso pendiente de una adición o prórroga", Request: decideAmendmentRequest{}, Response: blockchain.Amendment{}, Auth: true, Roles: amendmentReviewerRoles},
+	"GET /api/contracts/:id/milestones":                   {Summary: "Hitos de ejecución del contrato con su avance"},
+	"POST /api/contracts/:id/milestones":                  {Summary: "Agrega un hito al plan de ejecución", Request: addMilestoneRequest{}, Response: blockchain.Milestone{}, Auth: true, Roles: milestonePlannerRoles},
+	"POST /api/contracts/:id/milestones/:mid/completion":  {Summary: "Certifica el cumplimiento de un hito", Request: completeMilestoneRequest{}, Response: blockchain.Milestone{}, Auth: true, Roles: milestoneSupervisorRoles},
+	"POST /api/contracts/:id/milestones/:mid/payment-act": {Summary: "Registra el acta de pago de un hito cumplido", Request: registerPaymentActRequest{}, Response: blockchain.ContractPayment{}, Auth: true, Roles: treasuryRoles},
+	"GET /api/fiscal-closings":                            {Summary: "Informes de cierre de vigencia", Query: []string{"entity_code"}, Auth: true, Roles: fiscalClosingReaderRoles},
+	"GET /api/fiscal-closings/:year":                      {Summary: "Informe de cierre de una vigencia", Query: []string{"entity_code"}, Auth: true, Roles: fiscalClosingReaderRoles},
+	"POST /api/fiscal-closings":                           {Summary: "Cierra la vigencia fiscal", Request: closeFiscalYearRequest{}, Auth: true, Roles: fiscalClosingRoles},
+
+	// Red P2P
+	"GET /api/health":                    {Summary: "Estado del nodo"},
+	"GET /api/health/ready":              {Summary: "Indica si el nodo está listo para recibir tráfico"},
+	"GET /api/metrics":                   {Summary: "Métricas en formato Prometheus"},
+	"GET /api/p2p/topology":              {Summary: "Topología de la red vista por este nodo"},
+	"POST /api/p2p/add-peer":             {Summary: "Agrega un peer", Request: addPeerRequest{}, Auth: true, Roles: peerAdminRoles},
+	"DELETE /api/p2p/peers/:id":          {Summary: "Retira un peer", Auth: true, Roles: peerAdminRoles},
+	"POST /api/p2p/peers/:id/reactivate": {Summary: "Reactiva un peer retirado", Auth: true, Roles: peerAdminRoles},
+	"GET /api/p2p/peer-removals":         {Summary: "Peers retirados y el motivo", Response: []blockchain.PeerRemoval{}, Auth: true, Roles: peerAdminRoles},
+	"GET /api/p2p/peers/:id/stats":       {Summary: "Reputación y estadísticas de un peer"},
+	"DELETE /api/p2p/peers/:id/ban":      {Summary: "Levanta el bloqueo de un peer", Auth: true, Roles: peerAdminRoles},
+	"POST /api/p2p/sync":                 {Summary: "Sincroniza con los peers ahora"},
+	"GET /api/admin/p2p/connections":     {Summary: "Conexiones WebSocket con los peers", Response: []blockchain.PeerConnStatus{}, Auth: true, Roles: nodeAdminRoles},
+	"GET /api/admin/join-tokens":         {Summary: "Tokens de ingreso de nodos a la red", Response: []blockchain.JoinToken{}, Auth: true, Roles: peerAdminRoles},
+	"POST /api/admin/join-tokens":        {Summary: "Emite un token de ingreso a la red", Request: issueJoinTokenRequest{}, Auth: true, Roles: peerAdminRoles},
+	"DELETE /api/admin/join-tokens/:id":  {Summary: "Revoca un token de ingreso", Auth: true, Roles: peerAdminRoles},
+	"GET /api/p2p/peers":                 {Summary: "Peers conocidos (entre nodos)"},
+	"GET /api/p2p/known-peers":           {Summary: "Directorio de peers para el descubrimiento (entre nodos)"},
+	"GET /api/p2p/ws":                    {Summary: "Transporte WebSocket entre nodos"},
+	"POST /api/p2p/join":                 {Summary: "Ingreso de un nodo con un token (entre nodos)", Request: blockchain.JoinRequest{}},
+	"POST /api/p2p/finality":             {Summary: "Recibe un certificado de finalidad (entre nodos)", Request: blockchain.FinalityCertificate{}},
+	"GET /api/p2p/get-chain":             {Summary: "Cadena completa (entre nodos)"},
+	"GET /api/p2p/blocks":                {Summary: "Bloques desde una altura (entre nodos)", Query: []string{"from_height", "limit"}},
+	"GET /api/p2p/ancestors/:hash":       {Summary: "Ancestros de un bloque (entre nodos)"},
+	"GET /api/p2p/handshake":             {Summary: "Información del nodo para el saludo (entre nodos)"},
+	"POST /api/p2p/handshake":            {Summary: "Registra el saludo de un peer (entre nodos)", Request: blockchain.HandshakeInfo{}},
+	"GET /api/p2p/tip":                   {Summary: "Punta de la cadena (entre nodos)"},
+	"GET /api/p2p/version":               {Summary: "Versión del nodo (entre nodos)"},
+	"GET /api/p2p/validator-keys":        {Summary: "Llaves de los validadores (entre nodos)"},
+	"POST /api/p2p/receive-block":        {Summary: "Recibe un bloque (entre nodos)", Request: blockchain.Block{}},
+	"POST /api/p2p/mempool":              {Summary: "Recibe una transacción del mempool (entre nodos)", Request: blockchain.MempoolTransaction{}},
+	"GET /api/p2p/reserved/:hash":        {Summary: "Contenido de un bloque reservado (entre nodos)"},
+	"POST /api/p2p/maintenance":          {Summary: "Aviso de mantenimiento de un peer (entre nodos)", Request: receivePeerMaintenanceRequest{}},
+
+	// Administración del nodo
+	"GET /api/admin/maintenance":                 {Summary: "Estado del modo de mantenimiento"},
+	"POST /api/admin/maintenance":                {Summary: "Activa o desactiva el modo de mantenimiento", Request: setMaintenanceRequest{}, Auth: true, Roles: nodeAdminRoles},
+	"GET /api/admin/traffic":                     {Summary: "Tráfico rechazado por cliente", Response: []auth.ClientTraffic{}, Auth: true, Roles: trafficAdminRoles},
+	"DELETE /api/admin/traffic/:client/throttle": {Summary: "Levanta el freno de un cliente", Auth: true, Roles: trafficAdminRoles},
+	"POST /api/admin/keys/rotate":                {Summary: "Rota la llave de firma del nodo", Auth: true, Roles: nodeAdminRoles},
+	"GET /api/admin/apikeys":                     {Summary: "Llaves de API de los integradores", Auth: true, Roles: apiKeyAdminRoles},
+	"POST /api/admin/apikeys":                    {Summary: "Emite una llave de API", Request: createAPIKeyRequest{}, Auth: true, Roles: apiKeyAdminRoles},
+	"GET /api/admin/apikeys/:id":                 {Summary: "Una llave de API", Auth: true, Roles: apiKeyAdminRoles},
+	"PUT /api/admin/apikeys/:id":                 {Summary: "Renombra una llave de API", Request: updateAPIKeyRequest{}, Auth: true, Roles: apiKeyAdminRoles},
+	"DELETE /api/admin/apikeys/:id":              {Summary: "Revoca una llave de API", Auth: true, Roles: apiKeyAdminRoles},
+	"GET /api/admin/outbox":                      {Summary: "Estado de la bandeja de salida de eventos", Auth: true, Roles: nodeAdminRoles},
+	"GET /api/admin/metrics/push":                {Summary: "Estado del envío de métricas", Auth: true, Roles: nodeAdminRoles},
+	"GET /api/admin/projections":                 {Summary: "Estado de las proyecciones de lectura", Auth: true, Roles: nodeAdminRoles},
+	"GET /api/admin/version":                     {Summary: "Versión y esquema del nodo", Auth: true, Roles: nodeAdminRoles},
+	"GET /api/admin/version/network":             {Summary: "Versiones de los nodos de la red", Auth: true, Roles: nodeAdminRoles},
+	"GET /api/bridge/status":                     {Summary: "Estado del puente con SECOP II"},
+	"GET /api/bridge/mappings":                   {Summary: "Correspondencia de procesos con SECOP II"},
+	"POST /api/bridge/reconcile":                 {Summary: "Concilia los procesos con SECOP II", Query: []string{"remote"}, Auth: true, Roles: nodeAdminRoles},
+	"GET /api/openapi.json":                      {Summary: "Esta especificación OpenAPI"},
+	"GET /api/docs":                              {Summary: "Explorador Swagger UI de la API"},
+	"POST /api/graphql":                          {Summary: "Consulta GraphQL de solo lectura (contratos, pasos de validación, auditoría, bloques y peers)", Request: graphql.Request{}},
+	"GET /api/graphql":                           {Summary: "Consulta GraphQL por query string", Query: []string{"query", "variables", "operationName"}},
+	"GET /api/graphql/schema":                    {Summary: "Esquema GraphQL en SDL"},
+}
+
+// ginParam encuentra los parámetros de ruta de Gin (:id o *path)
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z_]+)`)
+
+// openAPISchemas arma los esquemas de los tipos de Go y los registra en components
+type openAPISchemas struct {
+	components map[string]interface{}
+}
+
+// schemaOf retorna el esquema del tipo; los structs con nombre quedan como referencia
+func (s *openAPISchemas) schemaOf(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case reflect.TypeOf(json.RawMessage{}):
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		if _, exists := s.components[t.Name()]; !exists {
+			s.components[t.Name()] = map[string]interface{}{} // Reservado por si el tipo se contiene a sí mismo
+			s.components[t.Name()] = s.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema arma el esquema de los campos del struct según sus etiquetas json y binding
+func (s *openAPISchemas) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	s.addFields(t, properties, &required)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields agrega los campos del struct, incluidos los de los structs embebidos
+func (s *openAPISchemas) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(embedded, properties, required)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := s.schemaOf(field.Type)
+		applyBindingRules(property, field.Tag.Get("binding"))
+		properties[name] = property
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// applyBindingRules traduce las reglas de validación del campo a restricciones del esquema
+func applyBindingRules(property map[string]interface{}, rules string) {
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "email":
+			property["format"] = "email"
+		case "entity_code":
+			property["pattern"] = blockchain.EntityCodePattern
+		case "gt", "gte":
+			if limit, err := strconv.ParseFloat(param, 64); err == nil {
+				property["minimum"] = limit
+				property["exclusiveMinimum"] = name == "gt"
+			}
+		case "lt", "lte":
+			if limit, err := strconv.ParseFloat(param, 64); err == nil {
+				property["maximum"] = limit
+				property["exclusiveMaximum"] = name == "lt"
+			}
+		}
+	}
+}
+
+// buildOpenAPISpec arma la especificación de las rutas registradas en el router
+func buildOpenAPISpec(routes gin.RoutesInfo) map[string]interface{} {
+	schemas := &openAPISchemas{components: map[string]interface{}{
+		"Error": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"success":  map[string]interface{}{"type": "boolean"},
+				"error":    map[string]interface{}{"type": "string"},
+				"code":     map[string]interface{}{"type": "string"},
+				"trace_id": map[string]interface{}{"type": "string"},
+				"fields": map[string]interface{}{"type": "array", "items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"field":   map[string]interface{}{"type": "string"},
+						"rule":    map[string]interface{}{"type": "string"},
+						"message": map[string]interface{}{"type": "string"},
+					},
+				}},
+			},
+		},
+	}}
+	paths := make(map[string]interface{})
+
+	// Los alias sin versión responden igual que /api/v1; solo se documenta la versión actual
+	versioned := make(map[string]bool)
+	for _, route := range routes {
+		versioned[route.Path] = true
+	}
+
+	for _, route := range routes {
+		if isLegacyRoute(route.Path, versioned) {
+			continue
+		}
+		doc := apiDocs[route.Method+" "+strings.Replace(route.Path, "/api/"+currentAPIVersion+"/", "/api/", 1)]
+		handler := strings.TrimPrefix(route.Handler, "main.")
+		if doc.Summary == "" {
+			doc.Summary = handler
+		}
+
+		operation := map[string]interface{}{
+			"operationId": handler,
+			"summary":     doc.Summary,
+			"tags":        []string{routeTag(route.Path)},
+		}
+		var parameters []interface{}
+		for _, match := range ginParam.FindAllStringSubmatch(route.Path, -1) {
+			parameters = append(parameters, map[string]interface{}{
+				"name": match[1], "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, name := range doc.Query {
+			parameters = append(parameters, map[string]interface{}{
+				"name": name, "in": "query", "schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if doc.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemas.schemaOf(reflect.TypeOf(doc.Request))},
+				},
+			}
+		}
+
+		success := map[string]interface{}{"type": "object"}
+		if doc.Response != nil {
+			success["properties"] = map[string]interface{}{
+				"success": map[string]interface{}{"type": "boolean"},
+				"data":    schemas.schemaOf(reflect.TypeOf(doc.Response)),
+			}
+		}
+		failure := map[string]interface{}{
+			"description": "Error",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"}},
+			},
+		}
+		operation["responses"] = map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "Respuesta exitosa",
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": success}},
+			},
+			"default": failure,
+		}
+
+		if doc.Auth {
+			operation["security"] = []interface{}{
+				map[string]interface{}{"bearerAuth": []string{}},
+				map[string]interface{}{"apiKey": []string{}},
+			}
+			if len(doc.Roles) > 0 {
+				roles := make([]string, len(doc.Roles))
+				for i, role := range doc.Roles {
+					roles[i] = string(role)
+				}
+				operation["description"] = "Roles autorizados: " + strings.Join(roles, ", ")
+			}
+		}
+
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		if paths[path] == nil {
+			paths[path] = make(map[string]interface{})
+		}
+		paths[path].(map[string]interface{})[strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "SECOP Blockchain API",
+			"version":     blockchain.Version,
+			"description": "API del nodo de la red de contratación pública. Las respuestas exitosas traen success y, en la mayoría de las rutas, data; los errores traen error. Las rutas sin versión (/api/...) son alias obsoletos de /api/v1 y anuncian su retiro con los encabezados Deprecation y Sunset.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKey":     map[string]interface{}{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+			},
+		},
+	}
+}
+
+// routeTag agrupa las rutas por el primer segmento después de /api y la versión
+func routeTag(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if segments[0] != "api" || len(segments) == 1 {
+		return segments[0]
+	}
+	if segments[1] == currentAPIVersion && len(segments) > 2 {
+		return segments[2]
+	}
+	return segments[1]
+}
+
+// getOpenAPISpec sirve la especificación, armada la primera vez que se pide para que
+// incluya todas las rutas registradas
+func getOpenAPISpec(r *gin.Engine) gin.HandlerFunc {
+	var once sync.Once
+	var spec map[string]interface{}
+	return func(c *gin.Context) {
+		once.Do(func() { spec = buildOpenAPISpec(r.Routes()) })
+		c.JSON(http.StatusOK, spec)
+	}
+}
+
+// swaggerUIPage carga Swagger UI desde su CDN apuntando a la especificación del nodo
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="es">
+<head>
+  <meta charset="utf-8">
+  <title>SECOP Blockchain API</title>
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/api/v1/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>`
+
+// getSwaggerUI sirve el explorador Swagger UI de la API
+func getSwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de las operaciones de varios pasos en curso sobre un contrato
+
+// getContractOperations lista las operaciones en curso (adiciones, liquidación) que
+// bloquean otras modificaciones del contrato
+func getContractOperations(c *gin.Context) {
+	contractID := c.Param("id")
+	if _, exists := bc.Contract(contractID); !exists {
+		respondErrorMessage(c, http.StatusNotFound, "contrato no encontrado")
+		return
+	}
+
+	operations := bc.Operations.InFlight(contractID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(operations),
+		"data":    operations,
+	})
+}
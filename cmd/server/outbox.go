@@ -0,0 +1,16 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers del outbox de efectos secundarios de los bloques
+
+func getOutboxStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"outbox":  bc.Outbox.Status(),
+	})
+}
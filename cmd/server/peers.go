@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers del retiro automático de peers inactivos
+
+// peerRetentionFromEnv lee de PEER_RETENTION_HOURS cuánto puede seguir inactivo un peer
+// antes de retirarlo; 0 desactiva el retiro automático
+func peerRetentionFromEnv() (time.Duration, error) {
+	value := getEnv("PEER_RETENTION_HOURS", strconv.Itoa(int(blockchain.DefaultPeerRetention/time.Hour)))
+	hours, err := strconv.Atoi(value)
+	if err != nil || hours < 0 {
+		return 0, fmt.Errorf("PEER_RETENTION_HOURS inválido: %s", value)
+	}
+	return time.Duration(hours) * time.Hour, nil
+}
+
+// getPeerRemovals lista los peers retirados por llevar demasiado tiempo inactivos
+func getPeerRemovals(c *gin.Context) {
+	removals := p2pNetwork.PeerRemovals()
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"count":           len(removals),
+		"retention_hours": int(p2pNetwork.PeerRetention / time.Hour),
+		"data":            removals,
+	})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de la revisión de consecutivos de los números de proceso
+
+// processNumberAuditFromEnv lee cada cuánto se revisan los consecutivos y cuánto se espera
+// antes de alertar un hueco
+func processNumberAuditFromEnv() (time.Duration, time.Duration) {
+	every, err := strconv.Atoi(getEnv("PROCESS_NUMBER_AUDIT_HOURS", "24"))
+	if err != nil || every <= 0 {
+		every = 24
+	}
+	grace, err := strconv.Atoi(getEnv("PROCESS_NUMBER_GAP_GRACE_HOURS", "24"))
+	if err != nil || grace < 0 {
+		grace = 24
+	}
+	return time.Duration(every) * time.Hour, time.Duration(grace) * time.Hour
+}
+
+// getProcessNumberReports retorna los informes de consecutivos, el más reciente primero
+func getProcessNumberReports(c *gin.Context) {
+	reports := processNumberAuditor.Reports()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(reports),
+		"data":    reports,
+	})
+}
+
+// auditProcessNumbers revisa los consecutivos sin esperar la revisión periódica
+func auditProcessNumbers(c *gin.Context) {
+	report, err := processNumberAuditor.Audit()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"report":  report,
+	})
+}
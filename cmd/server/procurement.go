@@ -0,0 +1,220 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de proveedores y del proceso de publicación
+
+// registerSupplier inscribe un proveedor; lo hace la oficina de contratación de una entidad
+func registerSupplier(c *gin.Context) {
+	var supplier blockchain.Supplier
+	if err := c.ShouldBindJSON(&supplier); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := bc.RegisterSupplier(&supplier); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Proveedor registrado exitosamente",
+		"nit":     supplier.NIT,
+	})
+}
+
+func getSuppliers(c *gin.Context) {
+	suppliers := bc.GetAllSuppliers()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(suppliers),
+		"data":    suppliers,
+	})
+}
+
+// publishContractRequest es el cuerpo de POST /api/contracts/:id/publish
+type publishContractRequest struct {
+	QuestionsDays int `json:"questions_days"`
+	ResponsesDays int `json:"responses_days"`
+}
+
+func publishContract(c *gin.Context) {
+	contractID := c.Param("id")
+
+	var req publishContractRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, ok := requireContractEntity(c, contractID); !ok {
+		return
+	}
+
+	if err := workflowManager.PublishContract(contractID, currentUser(c).Subject, req.QuestionsDays, req.ResponsesDays); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	contract, _ := bc.GetContract(contractID)
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  "Contrato publicado exitosamente",
+		"calendar": contract.Calendar,
+	})
+}
+
+func getContractQuestions(c *gin.Context) {
+	contract, err := bc.GetContract(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"calendar":  contract.Calendar,
+		"questions": contract.Questions,
+	})
+}
+
+// submitContractQuestionRequest es el cuerpo de POST /api/contracts/:id/questions
+type submitContractQuestionRequest struct {
+	SupplierNIT string `json:"supplier_nit"`
+	Question    string `json:"question"`
+}
+
+func submitContractQuestion(c *gin.Context) {
+	contractID := c.Param("id")
+
+	var req submitContractQuestionRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	question, err := workflowManager.SubmitQuestion(contractID, req.SupplierNIT, req.Question)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"question": question,
+	})
+}
+
+// respondContractQuestionRequest es el cuerpo de POST /api/contracts/:id/questions/:qid/response
+type respondContractQuestionRequest struct {
+	Response string `json:"response"`
+}
+
+func respondContractQuestion(c *gin.Context) {
+	contractID := c.Param("id")
+	questionID := c.Param("qid")
+
+	var req respondContractQuestionRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, ok := requireContractEntity(c, contractID); !ok {
+		return
+	}
+
+	if err := workflowManager.RespondQuestion(contractID, questionID, currentUser(c).Subject, req.Response); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Respuesta publicada exitosamente",
+	})
+}
+
+// awardContractRequest es el cuerpo de POST /api/contracts/:id/award
+type awardContractRequest struct {
+	SupplierNIT string                 `json:"supplier_nit"`
+	Consortium  *blockchain.Consortium `json:"consortium"`
+}
+
+func awardContract(c *gin.Context) {
+	contractID := c.Param("id")
+
+	var req awardContractRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, ok := requireContractEntity(c, contractID); !ok {
+		return
+	}
+
+	if err := workflowManager.AwardContract(contractID, currentUser(c).Subject, req.SupplierNIT, req.Consortium); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Contrato adjudicado exitosamente",
+	})
+}
+
+// addSupplierSanctionRequest es el cuerpo de POST /api/suppliers/:nit/sanctions
+type addSupplierSanctionRequest struct {
+	Reason     string     `json:"reason"`
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+func addSupplierSanction(c *gin.Context) {
+	nit := c.Param("nit")
+
+	var req addSupplierSanctionRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user := currentUser(c)
+	if err := bc.AddSanction(nit, user.Subject, user.Role, req.Reason, req.ValidUntil); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Sanción registrada",
+	})
+}
+
+func getSupplierHistory(c *gin.Context) {
+	history, err := bc.GetSupplierHistory(c.Param("nit"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(history),
+		"data":    history,
+	})
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// Perfiles de configuración por ambiente. El perfil (SECOP_PROFILE o ENV: dev, staging o
+// production) fija los valores por defecto de CORS, TLS, consenso, registro y datos de
+// ejemplo, y puede ajustarse con secop.<perfil>.yaml. Las variables de entorno siguen
+// mandando sobre el perfil; la combinación final se valida al arrancar.
+
+// Perfiles conocidos
+const (
+	ProfileDev        = "dev"
+	ProfileStaging    = "staging"
+	ProfileProduction = "production"
+)
+
+// profileAliases acepta los nombres habituales de cada ambiente
+var profileAliases = map[string]string{
+	"dev":         ProfileDev,
+	"development": ProfileDev,
+	"local":       ProfileDev,
+	"staging":     ProfileStaging,
+	"pruebas":     ProfileStaging,
+	"prod":        ProfileProduction,
+	"production":  ProfileProduction,
+	"produccion":  ProfileProduction,
+}
+
+// profileFiles son los archivos que se buscan para cada perfil, en orden
+var profileFiles = map[string][]string{
+	ProfileDev:        {"secop.dev.yaml"},
+	ProfileStaging:    {"secop.staging.yaml"},
+	ProfileProduction: {"secop.prod.yaml", "secop.production.yaml"},
+}
+
+// Niveles de registro: debug muestra además las rutas y el detalle de Gin; warn calla los
+// mensajes operativos del ledger y deja solo los del arranque y los errores
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+)
+
+// configProfile es la configuración de un ambiente
+type configProfile struct {
+	Name string `yaml:"-"`
+	File string `yaml:"-"` // Archivo del que se cargó, si hubo alguno
+
+	CORS struct {
+		AllowOrigins     []string `yaml:"allow_origins"`
+		AllowCredentials bool     `yaml:"allow_credentials"`
+	} `yaml:"cors"`
+	TLS struct {
+		Required bool `yaml:"required"` // Exige TLS_CERT_FILE: sin él el nodo no arranca
+	} `yaml:"tls"`
+	Consensus struct {
+		Mode string `yaml:"mode"` // Por defecto de CONSENSUS_MODE
+	} `yaml:"consensus"`
+	Logging struct {
+		Level string `yaml:"level"`
+	} `yaml:"logging"`
+	Seed struct {
+		ExampleContracts bool `yaml:"example_contracts"` // Contratos de ejemplo en una cadena vacía del nodo DNP
+	} `yaml:"seed"`
+	// Valores por defecto de cualquier otra variable de entorno (p. ej. STORAGE_BACKEND)
+	Env map[string]string `yaml:"env"`
+}
+
+// activeProfile es el perfil con el que arrancó el nodo
+var activeProfile = defaultProfile(ProfileDev)
+
+// defaultProfile retorna los valores por defecto del perfil, sin archivo
+func defaultProfile(name string) *configProfile {
+	profile := &configProfile{Name: name, Env: map[string]string{}}
+	profile.Consensus.Mode = blockchain.ConsensusLongestChain
+	switch name {
+	case ProfileDev:
+		profile.CORS.AllowOrigins = []string{"*"}
+		profile.CORS.AllowCredentials = true
+		profile.Logging.Level = LogLevelDebug
+		profile.Seed.ExampleContracts = true
+	case ProfileStaging:
+		profile.CORS.AllowOrigins = []string{"*"}
+		profile.Logging.Level = LogLevelInfo
+		profile.Seed.ExampleContracts = true
+	case ProfileProduction:
+		profile.TLS.Required = true
+		profile.Consensus.Mode = blockchain.ConsensusPoA
+		profile.Logging.Level = LogLevelInfo
+	}
+	return profile
+}
+
+// loadProfileFromEnv elige el perfil con SECOP_PROFILE o ENV (dev por defecto) y le aplica
+// el archivo SECOP_CONFIG o, si existe, secop.<perfil>.yaml en SECOP_CONFIG_DIR
+func loadProfileFromEnv() (*configProfile, error) {
+	requested := os.Getenv("SECOP_PROFILE")
+	if requested == "" {
+		requested = os.Getenv("ENV")
+	}
+	if requested == "" {
+		requested = ProfileDev
+	}
+	name, known := profileAliases[strings.ToLower(requested)]
+	if !known {
+		return nil, fmt.Errorf("perfil desconocido: %s (se admite dev, staging o production)", requested)
+	}
+	profile := defaultProfile(name)
+
+	file := os.Getenv("SECOP_CONFIG")
+	if file == "" {
+		dir := os.Getenv("SECOP_CONFIG_DIR")
+		if dir == "" {
+			dir = "."
+		}
+		for _, candidate := range profileFiles[name] {
+			if _, err := os.Stat(filepath.Join(dir, candidate)); err == nil {
+				file = filepath.Join(dir, candidate)
+				break
+			}
+		}
+	}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo %s: %v", file, err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(profile); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("error en %s: %v", file, err)
+		}
+		if profile.Env == nil {
+			profile.Env = map[string]string{}
+		}
+		profile.File = file
+	}
+	return profile, nil
+}
+
+// validate revisa la combinación de valores y retorna todos los problemas juntos. En
+// producción rechaza las combinaciones inseguras: CORS abierto con credenciales, consenso
+// sin autoridades, datos de ejemplo y el sandbox.
+func (p *configProfile) validate(sandboxMode bool) error {
+	var problems []string
+	if len(p.CORS.AllowOrigins) == 0 {
+		problems = append(problems, "cors.allow_origins vacío: indique los orígenes del front-end o *")
+	}
+	wildcard := false
+	for _, origin := range p.CORS.AllowOrigins {
+		if origin == "*" {
+			wildcard = true
+		}
+	}
+	switch p.Logging.Level {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarn:
+	default:
+		problems = append(problems, fmt.Sprintf("logging.level inválido: %s (se admite debug, info o warn)", p.Logging.Level))
+	}
+	consensusMode := getEnv("CONSENSUS_MODE", p.Consensus.Mode)
+	switch consensusMode {
+	case blockchain.ConsensusLongestChain, blockchain.ConsensusPoA:
+	default:
+		problems = append(problems, fmt.Sprintf("modo de consenso desconocido: %s", consensusMode))
+	}
+
+	if p.Name == ProfileProduction {
+		if wildcard && p.CORS.AllowCredentials {
+			problems = append(problems, "cors: el origen * con allow_credentials expone las sesiones a cualquier sitio; liste los orígenes permitidos")
+		}
+		if !p.TLS.Required {
+			problems = append(problems, "tls.required debe ser true en producción")
+		}
+		if consensusMode == blockchain.ConsensusLongestChain {
+			problems = append(problems, "el consenso de cadena más larga no es seguro en producción; use CONSENSUS_MODE=poa")
+		}
+		if p.Seed.ExampleContracts {
+			problems = append(problems, "seed.example_contracts crearía contratos ficticios en la cadena de producción")
+		}
+		if sandboxMode {
+			problems = append(problems, "SANDBOX_MODE no se permite en producción")
+		}
+		if p.Logging.Level == LogLevelDebug {
+			problems = append(problems, "logging.level debug expone el detalle de las peticiones en producción")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("perfil %s inválido:\n  - %s", p.Name, strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// requireTLS exige los certificados del nodo si el perfil lo pide
+func (p *configProfile) requireTLS() error {
+	if p.TLS.Required && nodeTLS == nil {
+		return fmt.Errorf("el perfil %s exige TLS: configure TLS_CERT_FILE, TLS_KEY_FILE y TLS_CA_FILE", p.Name)
+	}
+	return nil
+}
+
+// applyLogging ajusta el modo de Gin y los mensajes del ledger según el nivel de registro
+func (p *configProfile) applyLogging() {
+	switch p.Logging.Level {
+	case LogLevelDebug:
+		gin.SetMode(gin.DebugMode)
+		blockchain.SetLogger(log.New(os.Stdout, "", 0))
+	case LogLevelInfo:
+		gin.SetMode(gin.ReleaseMode)
+		blockchain.SetLogger(log.New(os.Stdout, "", 0))
+	case LogLevelWarn:
+		gin.SetMode(gin.ReleaseMode)
+		blockchain.SetLogger(nil)
+	}
+}
+
+// describe resume el perfil para el arranque
+func (p *configProfile) describe() string {
+	source := "valores por defecto"
+	if p.File != "" {
+		source = p.File
+	}
+	return fmt.Sprintf("%s (%s): CORS %s, TLS requerido %t, registro %s", p.Name, source, strings.Join(p.CORS.AllowOrigins, " "), p.TLS.Required, p.Logging.Level)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers del estado de las proyecciones (modelos de lectura) y de la disponibilidad
+
+// Atraso máximo en bloques que se tolera en una proyección antes de dejar de estar listo
+var projectionMaxLag = blockchain.DefaultProjectionMaxLag
+
+// projectionMaxLagFromEnv lee PROJECTION_MAX_LAG
+func projectionMaxLagFromEnv() int {
+	maxLag, err := strconv.Atoi(getEnv("PROJECTION_MAX_LAG", strconv.Itoa(blockchain.DefaultProjectionMaxLag)))
+	if err != nil || maxLag < 0 {
+		return blockchain.DefaultProjectionMaxLag
+	}
+	return maxLag
+}
+
+// getProjections retorna la altura aplicada, el atraso y los errores de cada proyección
+func getProjections(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"max_lag": projectionMaxLag,
+		"data":    bc.Projections.Status(projectionMaxLag),
+	})
+}
+
+// readinessCheck indica si el nodo puede atender tráfico: responde 503 mientras alguna
+// proyección esté atrasada más de PROJECTION_MAX_LAG bloques frente a la cadena
+func readinessCheck(c *gin.Context) {
+	lagging := bc.Projections.Lagging(projectionMaxLag)
+	if len(lagging) > 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"ready":   false,
+			"node_id": p2pNetwork.NodeID,
+			"max_lag": projectionMaxLag,
+			"lagging": lagging,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ready":   true,
+		"node_id": p2pNetwork.NodeID,
+		"max_lag": projectionMaxLag,
+	})
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de pruebas de inclusión de transacciones
+
+// getTransactionProof entrega la prueba de que una transacción está en la cadena, para que
+// un auditor la verifique contra el encabezado del bloque sin descargar el bloque completo
+func getTransactionProof(c *gin.Context) {
+	proof, err := bc.GetMerkleProof(c.Param("txid"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"proof":    proof,
+		"verified": blockchain.VerifyMerkleProof(*proof) == nil,
+	})
+}
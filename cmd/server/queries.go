@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de consultas guardadas de los analistas
+
+func getSavedQueries(c *gin.Context) {
+	queries := queryEngine.List(currentUser(c).Role)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(queries),
+		"data":    queries,
+	})
+}
+
+// createSavedQueryRequest es el cuerpo de POST /api/queries
+type createSavedQueryRequest struct {
+	Name              string                 `json:"name" binding:"required"`
+	Description       string                 `json:"description"`
+	Source            string                 `json:"source" binding:"required"`
+	Filter            blockchain.QueryFilter `json:"filter"`
+	GroupBy           string                 `json:"group_by"`
+	Aggregations      []string               `json:"aggregations"`
+	ScheduleMinutes   int                    `json:"schedule_minutes"`
+	IncludeCompliance bool                   `json:"include_compliance"`
+	SharedWith        []string               `json:"shared_with"`
+}
+
+func createSavedQuery(c *gin.Context) {
+	var req createSavedQueryRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user := currentUser(c)
+	query := blockchain.SavedQuery{
+		Name:              req.Name,
+		Description:       req.Description,
+		Source:            req.Source,
+		Filter:            req.Filter,
+		GroupBy:           req.GroupBy,
+		Aggregations:      req.Aggregations,
+		ScheduleMinutes:   req.ScheduleMinutes,
+		IncludeCompliance: req.IncludeCompliance,
+		CreatedBy:         user.Subject,
+		Role:              user.Role,
+	}
+	for _, role := range req.SharedWith {
+		query.SharedWith = append(query.SharedWith, blockchain.AdminRole(role))
+	}
+
+	saved, err := queryEngine.Save(query)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"query":   saved,
+	})
+}
+
+func getSavedQuery(c *gin.Context) {
+	query, err := queryEngine.Get(c.Param("id"), currentUser(c).Role)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"query":   query,
+	})
+}
+
+func deleteSavedQuery(c *gin.Context) {
+	if err := queryEngine.Delete(c.Param("id"), currentUser(c).Role); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func runSavedQuery(c *gin.Context) {
+	user := currentUser(c)
+	result, err := queryEngine.Execute(c.Param("id"), user.Role, user.Subject)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"result":  result,
+	})
+}
+
+func getSavedQueryResult(c *gin.Context) {
+	result, err := queryEngine.LastResult(c.Param("id"), currentUser(c).Role)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"result":  result,
+	})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"secop-blockchain/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Límite de peticiones de la API pública para los nodos expuestos al tráfico ciudadano.
+// Cada cliente (llave de API o IP, ver trafficClient) tiene una cuota por minuto para
+// todas sus peticiones y otra más estricta para las que escriben. Las llaves de API
+// reciben un múltiplo de la cuota de una IP. Las rutas entre nodos, la salud y las
+// métricas no se limitan.
+
+// rateLimitPolicy son las cuotas de la API; una cuota sin Burst no limita
+type rateLimitPolicy struct {
+	Reads            auth.RateLimit
+	Writes           auth.RateLimit
+	APIKeyMultiplier int
+}
+
+var (
+	rateLimits   rateLimitPolicy
+	readLimiter  = auth.NewRateLimiter()
+	writeLimiter = auth.NewRateLimiter()
+)
+
+// rateLimitsFromEnv lee las cuotas: RATE_LIMIT_READS_PER_MINUTE (600) y
+// RATE_LIMIT_WRITES_PER_MINUTE (30) por IP, y RATE_LIMIT_API_KEY_MULTIPLIER (5). Una cuota
+// en 0 desactiva ese límite.
+func rateLimitsFromEnv() (rateLimitPolicy, error) {
+	values := map[string]int{}
+	for key, fallback := range map[string]string{
+		"RATE_LIMIT_READS_PER_MINUTE":   "600",
+		"RATE_LIMIT_WRITES_PER_MINUTE":  "30",
+		"RATE_LIMIT_API_KEY_MULTIPLIER": "5",
+	} {
+		value, err := strconv.Atoi(getEnv(key, fallback))
+		if err != nil || value < 0 {
+			return rateLimitPolicy{}, fmt.Errorf("%s inválido: %s", key, getEnv(key, fallback))
+		}
+		values[key] = value
+	}
+	if values["RATE_LIMIT_API_KEY_MULTIPLIER"] == 0 {
+		values["RATE_LIMIT_API_KEY_MULTIPLIER"] = 1
+	}
+	return rateLimitPolicy{
+		Reads:            auth.PerMinute(values["RATE_LIMIT_READS_PER_MINUTE"]),
+		Writes:           auth.PerMinute(values["RATE_LIMIT_WRITES_PER_MINUTE"]),
+		APIKeyMultiplier: values["RATE_LIMIT_API_KEY_MULTIPLIER"],
+	}, nil
+}
+
+// forClient escala la cuota si el cliente se identificó con su llave de API
+func (p rateLimitPolicy) forClient(limit auth.RateLimit, client string) auth.RateLimit {
+	if strings.HasPrefix(client, apiKeyClientPrefix) {
+		limit.PerSecond *= float64(p.APIKeyMultiplier)
+		limit.Burst *= p.APIKeyMultiplier
+	}
+	return limit
+}
+
+// describe resume las cuotas para el arranque
+func (p rateLimitPolicy) describe() string {
+	quota := func(limit auth.RateLimit) string {
+		if !limit.Enabled() {
+			return "sin límite"
+		}
+		return fmt.Sprintf("%d/min", limit.Burst)
+	}
+	return fmt.Sprintf("lecturas %s, escrituras %s por IP (x%d con llave de API)", quota(p.Reads), quota(p.Writes), p.APIKeyMultiplier)
+}
+
+// rateLimit descuenta la petición de las cuotas del cliente y responde 429 con
+// Retry-After si se agotaron. Las consultas GraphQL cuentan como lecturas aunque usen POST.
+func rateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := trafficClient(c)
+		now := time.Now()
+
+		if limit := rateLimits.forClient(rateLimits.Reads, client); limit.Enabled() {
+			decision := readLimiter.Allow(client, limit, now)
+			c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			if !decision.Allowed {
+				rejectRateLimited(c, decision, "demasiadas peticiones, intente más tarde")
+				return
+			}
+		}
+
+		if isWriteRequest(c) {
+			if limit := rateLimits.forClient(rateLimits.Writes, client); limit.Enabled() {
+				decision := writeLimiter.Allow(client, limit, now)
+				c.Header("X-RateLimit-Write-Limit", strconv.Itoa(decision.Limit))
+				c.Header("X-RateLimit-Write-Remaining", strconv.Itoa(decision.Remaining))
+				if !decision.Allowed {
+					rejectRateLimited(c, decision, "cuota de escrituras agotada, intente más tarde")
+					return
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// isWriteRequest indica si la petición modifica el estado del nodo
+func isWriteRequest(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return !strings.HasSuffix(c.Request.URL.Path, "/graphql")
+}
+
+// rejectRateLimited responde 429 con el tiempo de espera en Retry-After
+func rejectRateLimited(c *gin.Context, decision auth.RateDecision, message string) {
+	retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	body := errorBody(c, http.StatusTooManyRequests, message)
+	body["retry_after"] = retryAfter
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, body)
+}
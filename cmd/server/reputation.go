@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de la reputación de los peers
+
+// configureReputationFromEnv aplica la política de veto de PEER_BAN_THRESHOLD y
+// PEER_BAN_MINUTES; un umbral en 0 desactiva los vetos
+func configureReputationFromEnv(reputation *blockchain.PeerReputation) error {
+	threshold, err := strconv.Atoi(getEnv("PEER_BAN_THRESHOLD", strconv.Itoa(blockchain.DefaultBanThreshold)))
+	if err != nil || threshold < 0 {
+		return fmt.Errorf("PEER_BAN_THRESHOLD inválido: %s", getEnv("PEER_BAN_THRESHOLD", ""))
+	}
+	minutes, err := strconv.Atoi(getEnv("PEER_BAN_MINUTES", strconv.Itoa(int(blockchain.DefaultBanDuration/time.Minute))))
+	if err != nil || minutes <= 0 {
+		return fmt.Errorf("PEER_BAN_MINUTES inválido: %s", getEnv("PEER_BAN_MINUTES", ""))
+	}
+	reputation.BanThreshold = threshold
+	reputation.BanDuration = time.Duration(minutes) * time.Minute
+	return nil
+}
+
+// getPeerStats retorna las estadísticas y la reputación de un peer
+func getPeerStats(c *gin.Context) {
+	peerID := c.Param("id")
+	stats, recorded := p2pNetwork.Reputation.Stats(peerID)
+
+	known := false
+	for _, peer := range p2pNetwork.KnownPeers() {
+		if peer.ID == peerID {
+			known = true
+			break
+		}
+	}
+	if !recorded && !known {
+		respondErrorMessage(c, http.StatusNotFound, "peer sin estadísticas en este nodo")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       stats,
+		"quarantine": len(p2pNetwork.Quarantine.List(peerID)),
+	})
+}
+
+// unbanPeer levanta el veto de un peer antes de que expire, p. ej. tras corregir su nodo
+func unbanPeer(c *gin.Context) {
+	if !p2pNetwork.Reputation.Unban(c.Param("id")) {
+		respondErrorMessage(c, http.StatusNotFound, "el peer no está vetado")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Veto levantado",
+	})
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de la entrega de bloques de contratos reservados
+
+// reservedNodesFromEnv lee de RESERVED_AUTHORIZED_NODES los nodos que reciben completos los
+// bloques de contratos reservados, separados por comas
+func reservedNodesFromEnv() []string {
+	var nodes []string
+	for _, nodeID := range strings.Split(getEnv("RESERVED_AUTHORIZED_NODES", ""), ",") {
+		if nodeID = strings.TrimSpace(nodeID); nodeID != "" {
+			nodes = append(nodes, nodeID)
+		}
+	}
+	return nodes
+}
+
+// getReservedBlockForPeer entrega a un nodo autorizado el bloque reservado completo
+func getReservedBlockForPeer(c *gin.Context) {
+	block, err := p2pNetwork.ReservedBlock(c.Param("hash"), c.GetHeader("X-Node-ID"))
+	if err == blockchain.ErrReservedNotAuthorized {
+		respondError(c, http.StatusForbidden, err)
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+	c.JSON(http.StatusOK, block)
+}
+
+// getReservedBlock entrega a un ente de control el bloque reservado completo; si este nodo
+// solo tiene el encabezado lo pide a un nodo autorizado. Toda consulta queda registrada.
+func getReservedBlock(c *gin.Context) {
+	hash := c.Param("hash")
+	user := currentUser(c)
+
+	block, err := p2pNetwork.RetrieveReservedBlock(hash)
+	if err != nil {
+		p2pNetwork.Reserved.LogAccess(hash, user.Subject, "user", false, err.Error())
+		status := http.StatusNotFound
+		if err == blockchain.ErrReservedNotAuthorized {
+			status = http.StatusForbidden
+		}
+		respondError(c, status, err)
+		return
+	}
+	p2pNetwork.Reserved.LogAccess(hash, user.Subject, "user", true, "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"block":   block,
+	})
+}
+
+// getReservedAccessLog lista las entregas y rechazos de bloques reservados, opcionalmente de un bloque
+func getReservedAccessLog(c *gin.Context) {
+	accesses := p2pNetwork.Reserved.AccessLog(c.Query("block_hash"))
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"authorized_nodes": p2pNetwork.Reserved.AuthorizedNodes(),
+		"count":            len(accesses),
+		"accesses":         accesses,
+	})
+}
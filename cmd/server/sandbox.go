@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sandboxModeFromEnv indica si el nodo corre como sandbox para integradores (SANDBOX_MODE=true)
+func sandboxModeFromEnv() bool {
+	return getEnv("SANDBOX_MODE", "false") == "true"
+}
+
+// configureSandboxFromEnv lee de SANDBOX_CONTRACTS cuántos contratos sintéticos crear y de
+// SANDBOX_RESET_HOURS cada cuánto reiniciar la cadena; 0 horas no la reinicia
+func configureSandboxFromEnv(sandbox *blockchain.Sandbox) error {
+	value := getEnv("SANDBOX_CONTRACTS", strconv.Itoa(blockchain.DefaultSandboxContracts))
+	contracts, err := strconv.Atoi(value)
+	if err != nil || contracts < 0 {
+		return fmt.Errorf("SANDBOX_CONTRACTS inválido: %s", value)
+	}
+	sandbox.Contracts = contracts
+
+	value = getEnv("SANDBOX_RESET_HOURS", strconv.Itoa(int(blockchain.DefaultSandboxResetPeriod/time.Hour)))
+	hours, err := strconv.Atoi(value)
+	if err != nil || hours < 0 {
+		return fmt.Errorf("SANDBOX_RESET_HOURS inválido: %s", value)
+	}
+	sandbox.ResetEvery = time.Duration(hours) * time.Hour
+	return nil
+}
+
+// sandboxHeader marca las respuestas del sandbox para que los integradores no las
+// confundan con datos de producción
+func sandboxHeader() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("X-Sandbox", "true")
+		c.Next()
+	}
+}
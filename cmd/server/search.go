@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Búsqueda de contratos por sus datos y por el contenido de sus adjuntos
+
+// Peso de la relevancia de los datos del contrato frente a las ocurrencias en sus adjuntos
+const metadataMatchScore = 10
+
+// pdfExtractorFromEnv configura un extractor externo para los PDF, p. ej.
+// PDF_TEXT_COMMAND="pdftotext -layout - -"
+func pdfExtractorFromEnv() (blockchain.TextExtractor, bool) {
+	command := strings.Fields(getEnv("PDF_TEXT_COMMAND", ""))
+	if len(command) == 0 {
+		return nil, false
+	}
+	return blockchain.CommandExtractor{MediaType: "application/pdf", Command: command}, true
+}
+
+// searchContracts busca en la descripción, la entidad, el creador y la modalidad de los
+// contratos y en el texto de sus adjuntos indexados, con los términos resaltados en cada
+// campo. Los adjuntos que aún no son públicos solo aparecen para los roles con acceso
+// anticipado.
+func searchContracts(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		respondErrorMessage(c, http.StatusBadRequest, "se requiere el parámetro q")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		respondErrorMessage(c, http.StatusBadRequest, "limit inválido")
+		return
+	}
+
+	type result struct {
+		Contract      *blockchain.Contract `json:"contract"`
+		Score         float64              `json:"score"`
+		MetadataMatch bool                 `json:"metadata_match"`
+		Highlights    map[string]string    `json:"highlights"`
+		Documents     []gin.H              `json:"documents"`
+	}
+	results := make(map[string]*result)
+	resultFor := func(contract *blockchain.Contract) *result {
+		if existing, ok := results[contract.ID]; ok {
+			return existing
+		}
+		created := &result{Contract: contract, Highlights: map[string]string{}, Documents: []gin.H{}}
+		results[contract.ID] = created
+		return created
+	}
+
+	// Buscar un ID de contrato lo trae primero
+	if contract, exists := bc.Contract(query); exists {
+		entry := resultFor(contract)
+		entry.MetadataMatch = true
+		entry.Score += 100 * metadataMatchScore
+	}
+	for _, hit := range bc.SearchContracts(query) {
+		contract, exists := bc.Contract(hit.ContractID)
+		if !exists {
+			continue
+		}
+		entry := resultFor(contract)
+		entry.MetadataMatch = true
+		entry.Score += hit.Score * metadataMatchScore
+		entry.Highlights = hit.Highlights
+	}
+
+	earlyAccess := hasEarlyAttachmentAccess(c)
+	now := time.Now()
+	for _, match := range attachmentStore.SearchContent(query) {
+		contract, exists := bc.Contract(match.ContractID)
+		if !exists {
+			continue
+		}
+		attachment, err := attachmentStore.Get(match.AttachmentID)
+		if err != nil || (!earlyAccess && !attachment.Visibility.IsDue(contract, now)) {
+			continue
+		}
+		entry := resultFor(contract)
+		entry.Score += float64(match.Score)
+		entry.Documents = append(entry.Documents, gin.H{
+			"attachment_id": attachment.ID,
+			"file_name":     attachment.FileName,
+			"category":      attachment.Category,
+			"snippet":       match.Snippet,
+			"score":         match.Score,
+			"url":           "/api/contracts/" + contract.ID + "/attachments/" + attachment.ID + "/file",
+		})
+	}
+
+	data := make([]*result, 0, len(results))
+	for _, entry := range results {
+		entry.Score = math.Round(entry.Score*100) / 100
+		data = append(data, entry)
+	}
+	sort.Slice(data, func(i, j int) bool {
+		if data[i].Score != data[j].Score {
+			return data[i].Score > data[j].Score
+		}
+		return data[i].Contract.CreatedAt.After(data[j].Contract.CreatedAt)
+	})
+	total := len(data)
+	if len(data) > limit {
+		data = data[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"query":   query,
+		"total":   total,
+		"count":   len(data),
+		"data":    data,
+	})
+}
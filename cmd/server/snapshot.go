@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de respaldo y restauración de la cadena
+
+// backupKMSFromEnv configura el KMS que protege los respaldos cifrados
+func backupKMSFromEnv() (blockchain.KMS, error) {
+	switch provider := getEnv("BACKUP_KMS_PROVIDER", blockchain.KMSLocal); provider {
+	case blockchain.KMSLocal:
+		return blockchain.LoadOrCreateLocalKMS(getEnv("BACKUP_KMS_KEY", "./data/backup.key"))
+	case blockchain.KMSAWS:
+		return blockchain.NewAWSKMS(blockchain.AWSKMSConfig{
+			Region:          getEnv("AWS_REGION", ""),
+			KeyID:           getEnv("BACKUP_KMS_KEY", ""),
+			AccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnv("AWS_SECRET_ACCESS_KEY", ""),
+			SessionToken:    getEnv("AWS_SESSION_TOKEN", ""),
+		})
+	case blockchain.KMSGCP:
+		return blockchain.NewGCPKMS(getEnv("BACKUP_KMS_KEY", ""), getEnv("GCP_ACCESS_TOKEN", ""))
+	default:
+		return nil, fmt.Errorf("proveedor de KMS desconocido: %s", provider)
+	}
+}
+
+func getSnapshot(c *gin.Context) {
+	from, err := strconv.Atoi(c.DefaultQuery("from", "0"))
+	if err != nil {
+		respondErrorMessage(c, http.StatusBadRequest, "altura inicial inválida")
+		return
+	}
+
+	snapshot, err := bc.Snapshot(from)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	fileName := fmt.Sprintf("%s-snapshot-%d-%d.json", p2pNetwork.NodeID, snapshot.From, snapshot.Height)
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.JSON(http.StatusOK, snapshot)
+}
+
+func restoreSnapshot(c *gin.Context) {
+	var snapshot blockchain.Snapshot
+	if err := c.ShouldBindJSON(&snapshot); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := bc.Restore(&snapshot, p2pNetwork.ValidatorKeys()); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"height":    bc.Len(),
+		"tip_hash":  bc.TipHash(),
+		"contracts": bc.ContractCount(),
+	})
+}
+
+func getBackup(c *gin.Context) {
+	if backupKMS == nil {
+		respondErrorMessage(c, http.StatusServiceUnavailable, "respaldos cifrados no configurados")
+		return
+	}
+
+	from, err := strconv.Atoi(c.DefaultQuery("from", "0"))
+	if err != nil {
+		respondErrorMessage(c, http.StatusBadRequest, "altura inicial inválida")
+		return
+	}
+
+	snapshot, err := bc.Snapshot(from)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	backup, err := blockchain.EncryptBackup(snapshot, p2pNetwork.NodeID, backupKMS)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	fileName := fmt.Sprintf("%s-backup-%d-%d.json", p2pNetwork.NodeID, snapshot.From, snapshot.Height)
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.JSON(http.StatusOK, backup)
+}
+
+// restoreBackup descifra y restaura un respaldo. Con ?dry_run=true solo verifica que
+// se pueda descifrar y aplicar, sin modificar la cadena.
+func restoreBackup(c *gin.Context) {
+	if backupKMS == nil {
+		respondErrorMessage(c, http.StatusServiceUnavailable, "respaldos cifrados no configurados")
+		return
+	}
+
+	var backup blockchain.EncryptedBackup
+	if err := c.ShouldBindJSON(&backup); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	snapshot, err := blockchain.DecryptBackup(&backup, backupKMS)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if c.Query("dry_run") == "true" {
+		if err := bc.VerifySnapshot(snapshot, p2pNetwork.ValidatorKeys()); err != nil {
+			body := errorBody(c, http.StatusBadRequest, err.Error())
+			body["manifest"] = backup.Manifest
+			c.JSON(http.StatusBadRequest, body)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success":  true,
+			"dry_run":  true,
+			"manifest": backup.Manifest,
+		})
+		return
+	}
+
+	if err := bc.Restore(snapshot, p2pNetwork.ValidatorKeys()); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"height":    bc.Len(),
+		"tip_hash":  bc.TipHash(),
+		"contracts": bc.ContractCount(),
+	})
+}
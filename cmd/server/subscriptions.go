@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de suscripciones de sistemas externos a eventos de un contrato
+
+// subscribeToContractRequest es el cuerpo de POST /api/contracts/:id/subscribe
+type subscribeToContractRequest struct {
+	CallbackURL string `json:"callback_url"`
+	SystemName  string `json:"system_name"`
+}
+
+func subscribeToContract(c *gin.Context) {
+	contractID := c.Param("id")
+
+	var req subscribeToContractRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	subscription, err := subscriptions.Subscribe(contractID, req.CallbackURL, req.SystemName, currentUser(c).Subject)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":      true,
+		"subscription": subscription,
+	})
+}
+
+// getContractSubscriptions lista las suscripciones al contrato que registró la sesión: las
+// URLs de callback de los demás sistemas no son públicas
+func getContractSubscriptions(c *gin.Context) {
+	subject := currentUser(c).Subject
+	list := []blockchain.ContractSubscription{}
+	for _, subscription := range subscriptions.GetSubscriptions(c.Param("id")) {
+		if subscription.CreatedBy == subject {
+			list = append(list, subscription)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(list),
+		"data":    list,
+	})
+}
+
+func unsubscribeFromContract(c *gin.Context) {
+	if err := subscriptions.Unsubscribe(c.Param("id"), c.Param("sid"), currentUser(c).Subject); err != nil {
+		if errors.Is(err, blockchain.ErrNotSubscriber) {
+			respondError(c, http.StatusForbidden, err)
+			return
+		}
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Suscripción eliminada",
+	})
+}
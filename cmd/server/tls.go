@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Configuración de TLS mutuo entre nodos
+
+// nodeTLSFromEnv carga el certificado del nodo y la CA de la red; sin TLS_CERT_FILE
+// el nodo sigue atendiendo por HTTP plano
+func nodeTLSFromEnv() (*blockchain.NodeTLS, error) {
+	certFile := getEnv("TLS_CERT_FILE", "")
+	if certFile == "" {
+		return nil, nil
+	}
+	return blockchain.LoadNodeTLS(certFile, getEnv("TLS_KEY_FILE", ""), getEnv("TLS_CA_FILE", ""))
+}
+
+// peerCertRequired exige en las rutas entre nodos un certificado de cliente emitido
+// por la CA de la red, para que nadie fuera de ella pueda enviar bloques ni leer la cadena
+func peerCertRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if nodeTLS == nil {
+			c.Next()
+			return
+		}
+
+		if _, err := blockchain.PeerCertificate(c.Request); err != nil {
+			respondError(c, http.StatusForbidden, err)
+			return
+		}
+		// Un nodo no puede presentarse con el certificado propio y el ID de otro
+		if header := c.GetHeader("X-Node-ID"); header != "" {
+			if nodeID, err := blockchain.PeerNodeID(c.Request); err != nil || nodeID != header {
+				respondErrorMessage(c, http.StatusForbidden, "X-Node-ID no corresponde al certificado del nodo")
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// peerIdentity retorna el ID del nodo autenticado por su certificado, o "" si la petición
+// no llegó por TLS mutuo. Solo a los nodos autenticados se les lleva reputación.
+func peerIdentity(c *gin.Context) string {
+	if nodeTLS == nil {
+		return ""
+	}
+	nodeID, err := blockchain.PeerNodeID(c.Request)
+	if err != nil {
+		return ""
+	}
+	return nodeID
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"secop-blockchain/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Métricas del tráfico rechazado por cliente y freno de clientes abusivos
+
+// Máximo de bytes de la respuesta que se leen para clasificar un rechazo
+const trafficBodyLimit = 1024
+
+// trafficThrottleFromEnv lee la política de freno. Con TRAFFIC_THROTTLE_THRESHOLD en 0
+// (por defecto) solo se registran las métricas.
+func trafficThrottleFromEnv() auth.ThrottlePolicy {
+	threshold, err := strconv.Atoi(getEnv("TRAFFIC_THROTTLE_THRESHOLD", "0"))
+	if err != nil || threshold < 0 {
+		threshold = 0
+	}
+	window, err := strconv.Atoi(getEnv("TRAFFIC_THROTTLE_WINDOW_MINUTES", "10"))
+	if err != nil || window <= 0 {
+		window = 10
+	}
+	block, err := strconv.Atoi(getEnv("TRAFFIC_THROTTLE_BLOCK_MINUTES", "15"))
+	if err != nil || block <= 0 {
+		block = 15
+	}
+	return auth.ThrottlePolicy{
+		Threshold: threshold,
+		Window:    time.Duration(window) * time.Minute,
+		Block:     time.Duration(block) * time.Minute,
+	}
+}
+
+// trafficRecorder copia el inicio del cuerpo de las respuestas de error para clasificarlas
+type trafficRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *trafficRecorder) Write(data []byte) (int, error) {
+	if w.Status() >= http.StatusBadRequest && w.body.Len() < trafficBodyLimit {
+		remaining := trafficBodyLimit - w.body.Len()
+		if len(data) < remaining {
+			remaining = len(data)
+		}
+		w.body.Write(data[:remaining])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// trafficMonitor contabiliza las peticiones rechazadas de cada cliente y responde 429
+// a los clientes frenados antes de llegar al handler
+func trafficMonitor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := trafficClient(c)
+		// La administración del tráfico no se frena, para poder levantar un freno propio
+		exempt := strings.HasPrefix(c.Request.URL.Path, "/api/admin/traffic") ||
+			strings.HasPrefix(c.Request.URL.Path, "/api/"+currentAPIVersion+"/admin/traffic")
+		if until := trafficMetrics.ThrottledUntil(client); until != nil && !exempt {
+			retryAfter := int(time.Until(*until).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			body := errorBody(c, http.StatusTooManyRequests, "demasiadas peticiones rechazadas, intente más tarde")
+			body["throttled_until"] = until
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, body)
+			return
+		}
+
+		recorder := &trafficRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status < http.StatusBadRequest || status >= http.StatusInternalServerError || status == http.StatusNotFound || status == http.StatusTooManyRequests {
+			return
+		}
+		message := trafficError(recorder.body.Bytes())
+		kind := classifyRejection(status, message)
+		if kind == "" {
+			return
+		}
+		trafficMetrics.Record(client, kind, status, c.FullPath(), message)
+	}
+}
+
+// Prefijos del identificador de cliente de trafficClient
+const (
+	apiKeyClientPrefix = "apikey:"
+	ipClientPrefix     = "ip:"
+)
+
+// trafficClient identifica al cliente por su llave de API o, si no presenta una válida, por su IP
+func trafficClient(c *gin.Context) string {
+	if secret := c.GetHeader(apiKeyHeader); secret != "" {
+		if id := apiKeys.Identify(secret); id != "" {
+			return apiKeyClientPrefix + id
+		}
+	}
+	return ipClientPrefix + c.ClientIP()
+}
+
+// trafficError extrae el mensaje de error de una respuesta JSON
+func trafficError(body []byte) string {
+	var response struct {
+		Error string `json:"error"`
+	}
+	json.Unmarshal(body, &response)
+	return response.Error
+}
+
+// classifyRejection asigna el tipo de rechazo según el estado y el mensaje de error, o ""
+// si el rechazo no se contabiliza (conflictos de estado del negocio, recursos inexistentes)
+func classifyRejection(status int, message string) string {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		if strings.Contains(message, "firma") {
+			return auth.FailureSignature
+		}
+		return auth.FailureAuth
+	case http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType:
+		return auth.FailurePayload
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		lower := strings.ToLower(message)
+		switch {
+		case strings.Contains(lower, "firma"):
+			return auth.FailureSignature
+		case strings.Contains(lower, "invalid character"), strings.Contains(lower, "json:"),
+			strings.Contains(lower, "eof"), strings.Contains(lower, "error:field validation"),
+			strings.Contains(lower, "multipart"):
+			return auth.FailurePayload
+		}
+		return auth.FailureValidation
+	}
+	return ""
+}
+
+// getTrafficMetrics lista el tráfico rechazado por cliente, los de más rechazos primero
+func getTrafficMetrics(c *gin.Context) {
+	clients := trafficMetrics.Clients()
+	throttled := 0
+	now := time.Now()
+	for _, client := range clients {
+		if client.ThrottledUntil != nil && now.Before(*client.ThrottledUntil) {
+			throttled++
+		}
+	}
+
+	policy := trafficMetrics.Policy()
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"count":     len(clients),
+		"throttled": throttled,
+		"policy": gin.H{
+			"threshold":      policy.Threshold,
+			"window_minutes": int(policy.Window.Minutes()),
+			"block_minutes":  int(policy.Block.Minutes()),
+		},
+		"data": clients,
+	})
+}
+
+// liftTrafficThrottle levanta el freno de un cliente, por ejemplo tras corregir su integración
+func liftTrafficThrottle(c *gin.Context) {
+	client := c.Param("client")
+	if err := trafficMetrics.Unthrottle(client); err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	fmt.Printf("🚦 %s levantó el freno del cliente %s\n", currentUser(c).Subject, client)
+	c.JSON(http.StatusOK, gin.H{"success": true, "client": client})
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de versión del nodo y coordinación de actualizaciones
+
+func getVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"version": p2pNetwork.LocalVersion(),
+	})
+}
+
+func getNetworkVersions(c *gin.Context) {
+	network := p2pNetwork.NetworkVersions()
+
+	compatible := true
+	for _, peer := range network.Peers {
+		if peer.Reachable && !peer.Compatible {
+			compatible = false
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"compatible": compatible,
+		"network":    network,
+	})
+}
+
+// getPeerVersion responde a la consulta de versión de otros nodos
+func getPeerVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, p2pNetwork.LocalVersion())
+}
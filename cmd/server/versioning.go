@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Versionamiento de la API. Las rutas viven bajo /api/v1; las rutas anteriores sin versión
+// (/api/...) siguen respondiendo igual mientras los integradores migran, pero anuncian su
+// retiro con los encabezados Deprecation, Sunset y Link. Un cambio incompatible se publica
+// en un grupo /api/v2 nuevo sin tocar los de v1.
+
+// Versión actual de la API
+const currentAPIVersion = "v1"
+
+// Fecha por defecto en que se retiran las rutas sin versión (API_LEGACY_SUNSET la cambia)
+const defaultLegacySunset = "2027-06-30"
+
+// apiRoutes registra cada ruta en la versión actual y en su alias anterior sin versión
+type apiRoutes struct {
+	current *gin.RouterGroup
+	legacy  *gin.RouterGroup
+}
+
+// newAPIRoutes crea los grupos /api/v1 y /api con los middleware comunes; el segundo marca
+// además sus respuestas como obsoletas
+func newAPIRoutes(r *gin.Engine, sunset time.Time, middleware ...gin.HandlerFunc) *apiRoutes {
+	return &apiRoutes{
+		current: r.Group("/api/"+currentAPIVersion, middleware...),
+		legacy:  r.Group("/api", append([]gin.HandlerFunc{deprecatedRoute(sunset)}, middleware...)...),
+	}
+}
+
+// Group crea un subgrupo en ambas versiones
+func (a *apiRoutes) Group(path string) *apiRoutes {
+	return &apiRoutes{current: a.current.Group(path), legacy: a.legacy.Group(path)}
+}
+
+// handle registra la ruta en ambas versiones
+func (a *apiRoutes) handle(method, path string, handlers ...gin.HandlerFunc) {
+	a.current.Handle(method, path, handlers...)
+	a.legacy.Handle(method, path, handlers...)
+}
+
+// GET registra una ruta GET
+func (a *apiRoutes) GET(path string, handlers ...gin.HandlerFunc) {
+	a.handle(http.MethodGet, path, handlers...)
+}
+
+// POST registra una ruta POST
+func (a *apiRoutes) POST(path string, handlers ...gin.HandlerFunc) {
+	a.handle(http.MethodPost, path, handlers...)
+}
+
+// PUT registra una ruta PUT
+func (a *apiRoutes) PUT(path string, handlers ...gin.HandlerFunc) {
+	a.handle(http.MethodPut, path, handlers...)
+}
+
+// DELETE registra una ruta DELETE
+func (a *apiRoutes) DELETE(path string, handlers ...gin.HandlerFunc) {
+	a.handle(http.MethodDelete, path, handlers...)
+}
+
+// deprecatedRoute anuncia en las rutas sin versión que están obsoletas, cuándo se retiran y
+// cuál es la ruta que las reemplaza
+func deprecatedRoute(sunset time.Time) gin.HandlerFunc {
+	sunsetHeader := sunset.UTC().Format(http.TimeFormat)
+	return func(c *gin.Context) {
+		successor := "/api/" + currentAPIVersion + strings.TrimPrefix(c.Request.URL.Path, "/api")
+		c.Header("Deprecation", "true")
+		c.Header("Sunset", sunsetHeader)
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		c.Next()
+	}
+}
+
+// legacySunsetFromEnv lee de API_LEGACY_SUNSET (2006-01-02) la fecha de retiro de las rutas
+// sin versión
+func legacySunsetFromEnv() (time.Time, error) {
+	value := getEnv("API_LEGACY_SUNSET", defaultLegacySunset)
+	sunset, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("API_LEGACY_SUNSET inválido: %s", value)
+	}
+	return sunset, nil
+}
+
+// isLegacyRoute indica si la ruta es el alias sin versión de una ruta de /api/v1
+func isLegacyRoute(path string, versioned map[string]bool) bool {
+	if !strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/api/"+currentAPIVersion+"/") {
+		return false
+	}
+	return versioned["/api/"+currentAPIVersion+strings.TrimPrefix(path, "/api")]
+}
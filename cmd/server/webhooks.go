@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de los webhooks salientes de los sistemas externos
+
+func getWebhooks(c *gin.Context) {
+	list := webhooks.List()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(list),
+		"events":  blockchain.WebhookEvents,
+		"data":    list,
+	})
+}
+
+// createWebhookRequest es el cuerpo de POST /api/webhooks
+type createWebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events" binding:"required"`
+	Description string   `json:"description"`
+}
+
+func createWebhook(c *gin.Context) {
+	var req createWebhookRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	webhook, err := webhooks.Create(req.URL, req.Events, req.Description, currentUser(c).Subject)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// El secreto de firma solo se entrega en esta respuesta
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"webhook": webhook,
+	})
+}
+
+func getWebhook(c *gin.Context) {
+	webhook, err := webhooks.Get(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"webhook": webhook,
+	})
+}
+
+func updateWebhook(c *gin.Context) {
+	var req blockchain.WebhookUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	webhook, err := webhooks.Update(c.Param("id"), req)
+	if errors.Is(err, blockchain.ErrWebhookNotFound) {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"webhook": webhook,
+	})
+}
+
+func deleteWebhook(c *gin.Context) {
+	if err := webhooks.Delete(c.Param("id")); err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
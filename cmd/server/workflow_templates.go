@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de plantillas de flujo por modalidad de contratación
+
+func getWorkflowTemplates(c *gin.Context) {
+	templates := workflowManager.Templates()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(templates),
+		"data":    templates,
+	})
+}
+
+// setWorkflowTemplateRequest es el cuerpo de PUT /api/workflow/templates/:type
+type setWorkflowTemplateRequest struct {
+	MaxAmount         float64 `json:"max_amount" binding:"required"`
+	AutoApprovedSteps []int   `json:"auto_approved_steps"`
+	ActRequiredSteps  []int   `json:"act_required_steps"`
+	RuleReference     string  `json:"rule_reference"`
+}
+
+func setWorkflowTemplate(c *gin.Context) {
+	var req setWorkflowTemplateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	template, err := workflowManager.SetTemplate(blockchain.WorkflowTemplate{
+		ContractType:      c.Param("type"),
+		MaxAmount:         req.MaxAmount,
+		AutoApprovedSteps: req.AutoApprovedSteps,
+		ActRequiredSteps:  req.ActRequiredSteps,
+		RuleReference:     req.RuleReference,
+		UpdatedBy:         currentUser(c).Subject,
+	})
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"template": template,
+	})
+}
+
+func removeWorkflowTemplate(c *gin.Context) {
+	if err := workflowManager.RemoveTemplate(c.Param("type")); err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// getWorkflowTemplateVersions lista el historial de versiones de la plantilla de una modalidad
+func getWorkflowTemplateVersions(c *gin.Context) {
+	versions := workflowManager.TemplateVersions(c.Param("type"))
+	if len(versions) == 0 {
+		respondErrorMessage(c, http.StatusNotFound, "plantilla no encontrada")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(versions),
+		"data":    versions,
+	})
+}
+
+// getWorkflowTemplateVersion retorna una versión específica de la plantilla de una modalidad
+func getWorkflowTemplateVersion(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		respondErrorMessage(c, http.StatusBadRequest, "versión inválida")
+		return
+	}
+
+	template, err := workflowManager.TemplateVersion(c.Param("type"), version)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"template": template,
+	})
+}
+
+// migrateContractTemplateRequest es el cuerpo de POST /api/contracts/:id/workflow/template-migration
+type migrateContractTemplateRequest struct {
+	Version *int   `json:"version" binding:"required"`
+	Reason  string `json:"reason" binding:"required"`
+}
+
+// migrateContractTemplate pasa un contrato en curso a otra versión de la plantilla de su
+// modalidad (0 para el flujo completo)
+func migrateContractTemplate(c *gin.Context) {
+	var req migrateContractTemplateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user := currentUser(c)
+	contract, err := workflowManager.MigrateContractTemplate(c.Param("id"), *req.Version, user.Subject, user.Role, req.Reason)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"contract": contract,
+	})
+}
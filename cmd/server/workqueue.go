@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de la cola de trabajo de los comités
+
+// claimTimeoutFromEnv lee el tiempo de inactividad tras el cual se libera una toma
+func claimTimeoutFromEnv() time.Duration {
+	minutes, err := strconv.Atoi(getEnv("CLAIM_TIMEOUT_MINUTES", "30"))
+	if err != nil || minutes <= 0 {
+		minutes = 30
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func getWorkQueue(c *gin.Context) {
+	items := workQueue.Items(blockchain.AdminRole(c.Param("role")))
+
+	claimed := 0
+	for _, item := range items {
+		if item.Claim != nil {
+			claimed++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"role":      c.Param("role"),
+		"count":     len(items),
+		"claimed":   claimed,
+		"available": len(items) - claimed,
+		"data":      items,
+	})
+}
+
+// claimNextFromQueueRequest es el cuerpo de POST /api/work-queue/:role/claim
+type claimNextFromQueueRequest struct {
+	Count int `json:"count"`
+}
+
+// claimNextFromQueue toma contratos de la cola del rol de la sesión a nombre de quien la inició
+func claimNextFromQueue(c *gin.Context) {
+	var req claimNextFromQueueRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+
+	user := currentUser(c)
+	if blockchain.AdminRole(c.Param("role")) != user.Role {
+		respondErrorMessage(c, http.StatusForbidden, "solo se toman contratos de la cola del rol "+string(user.Role))
+		return
+	}
+
+	claims, err := workQueue.ClaimNext(user.Role, user.Subject, user.Name, req.Count)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(claims),
+		"data":    claims,
+	})
+}
+
+// claimContract toma el contrato para revisión a nombre de la sesión, con su rol
+func claimContract(c *gin.Context) {
+	user := currentUser(c)
+	claim, err := workQueue.Claim(c.Param("id"), user.Subject, user.Name, user.Role)
+	if err != nil {
+		respondError(c, http.StatusConflict, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"claim":   claim,
+	})
+}
+
+// assignContractRequest es el cuerpo de POST /api/contracts/:id/assign
+type assignContractRequest struct {
+	AssigneeID   string `json:"assignee_id" binding:"required"`
+	AssigneeName string `json:"assignee_name" binding:"required"`
+}
+
+// assignContract asigna el contrato a un revisor del comité; quien asigna debe tener el rol
+// del paso pendiente
+func assignContract(c *gin.Context) {
+	var req assignContractRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user := currentUser(c)
+	claim, err := workQueue.Assign(c.Param("id"), req.AssigneeID, req.AssigneeName, user.Role, user.Subject)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"claim":   claim,
+	})
+}
+
+// releaseContract libera el contrato que tomó la sesión
+func releaseContract(c *gin.Context) {
+	if err := workQueue.Release(c.Param("id"), currentUser(c).Subject); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
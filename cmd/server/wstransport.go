@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// Handlers del canal WebSocket entre peers
+
+// peerWebSocket atiende el canal persistente que abre un peer; el peer se identifica con
+// X-Node-ID como en las demás rutas entre nodos y anuncia su dirección en la consulta
+func peerWebSocket(c *gin.Context) {
+	if !p2pNetwork.WebSocketEnabled() {
+		respondErrorMessage(c, http.StatusNotFound, "canal WebSocket desactivado en este nodo")
+		return
+	}
+	peerID := c.GetHeader("X-Node-ID")
+	if peerID == "" {
+		respondErrorMessage(c, http.StatusBadRequest, "X-Node-ID requerido")
+		return
+	}
+	// peerCertRequired ya comprobó que X-Node-ID coincida con el certificado, si lo hay
+	verified := peerIdentity(c) != ""
+
+	// Sin Handshake no se valida el Origin: quien conecta es un nodo, no un navegador
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		p2pNetwork.ServePeerConn(ws, peerID, verified, c.Query("address"), c.Query("port"))
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// getPeerConnections retorna el estado de los canales WebSocket abiertos con los peers
+func getPeerConnections(c *gin.Context) {
+	connections := p2pNetwork.PeerConnections()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"enabled": p2pNetwork.WebSocketEnabled(),
+		"backend": p2pNetwork.Backend(),
+		"count":   len(connections),
+		"data":    connections,
+	})
+}
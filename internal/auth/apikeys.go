@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+	"secop-blockchain/pkg/blockchain/storage"
+
+	"github.com/google/uuid"
+)
+
+// Alcances de las llaves de API para integradores externos
+const (
+	ScopeReadOnly       = "read-only"
+	ScopeContractCreate = "contract-create"
+	ScopeAuditOnly      = "audit-only"
+)
+
+// Rol con el que actúa cada alcance en las políticas de las rutas
+var scopeRoles = map[string]blockchain.AdminRole{
+	ScopeReadOnly:       blockchain.RoleCitizen,
+	ScopeContractCreate: blockchain.RoleProjectDeveloper,
+	ScopeAuditOnly:      blockchain.RoleComptroller,
+}
+
+// Prefijo de las llaves emitidas, para reconocerlas en configuraciones y logs
+const apiKeyPrefix = "secop_"
+
+// Error genérico de llave de API; no revela si la llave existe o fue revocada
+var ErrInvalidAPIKey = errors.New("llave de API inválida")
+
+// APIKey representa una llave de API emitida a un sistema externo. Solo se guarda la
+// huella SHA-256 del secreto; el secreto se muestra una única vez al crearla.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scope      string     `json:"scope"`
+	EntityCode string     `json:"entity_code,omitempty"` // Si se indica, solo opera sobre esa entidad
+	Hint       string     `json:"hint"`                  // Primeros caracteres del secreto
+	Hash       string     `json:"-"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// storedAPIKey es la forma persistida de la llave, incluida la huella del secreto
+type storedAPIKey struct {
+	APIKey
+	Hash string `json:"hash"`
+}
+
+// APIKeyStore administra las llaves de API y las persiste en el almacenamiento del nodo
+type APIKeyStore struct {
+	store  storage.Store
+	keys   map[string]*APIKey
+	byHash map[string]*APIKey
+	mutex  sync.RWMutex
+}
+
+// NewAPIKeyStore carga las llaves guardadas
+func NewAPIKeyStore(store storage.Store) (*APIKeyStore, error) {
+	ks := &APIKeyStore{
+		store:  store,
+		keys:   make(map[string]*APIKey),
+		byHash: make(map[string]*APIKey),
+	}
+
+	err := store.ForEach(storage.BucketAPIKeys, func(id string, value []byte) error {
+		var stored storedAPIKey
+		if err := json.Unmarshal(value, &stored); err != nil {
+			return fmt.Errorf("llave de API %s corrupta: %v", id, err)
+		}
+		key := stored.APIKey
+		key.Hash = stored.Hash
+		ks.keys[id] = &key
+		ks.byHash[key.Hash] = &key
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Create emite una llave nueva y retorna su secreto, que no vuelve a estar disponible
+func (ks *APIKeyStore) Create(name string, scope string, entityCode string, createdBy string, ttl time.Duration) (*APIKey, string, error) {
+	if name == "" {
+		return nil, "", errors.New("la llave requiere un nombre")
+	}
+	if _, valid := scopeRoles[scope]; !valid {
+		return nil, "", fmt.Errorf("alcance desconocido: %s", scope)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	secret := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	key := &APIKey{
+		ID:         uuid.New().String(),
+		Name:       name,
+		Scope:      scope,
+		EntityCode: entityCode,
+		Hint:       secret[:len(apiKeyPrefix)+4],
+		Hash:       hashAPIKey(secret),
+		CreatedBy:  createdBy,
+		CreatedAt:  time.Now(),
+	}
+	if ttl > 0 {
+		expiresAt := key.CreatedAt.Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	if err := ks.save(key); err != nil {
+		return nil, "", err
+	}
+	ks.keys[key.ID] = key
+	ks.byHash[key.Hash] = key
+
+	fmt.Printf("🔑 Llave de API %s (%s) emitida para %s\n", key.ID, scope, name)
+	copied := *key
+	return &copied, secret, nil
+}
+
+// Authenticate valida el secreto de una llave y retorna la identidad con la que actúa
+func (ks *APIKeyStore) Authenticate(secret string) (*Claims, error) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	key, exists := ks.byHash[hashAPIKey(secret)]
+	if !exists || key.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Registrar el uso como máximo una vez por minuto para no escribir en cada petición
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > time.Minute {
+		key.LastUsedAt = &now
+		if err := ks.save(key); err != nil {
+			fmt.Printf("⚠️ No se pudo registrar el uso de la llave %s: %v\n", key.ID, err)
+		}
+	}
+
+	return &Claims{
+		Subject:    "apikey:" + key.ID,
+		Name:       key.Name,
+		Role:       scopeRoles[key.Scope],
+		IssuedAt:   key.CreatedAt.Unix(),
+		TokenID:    key.ID,
+		Scope:      key.Scope,
+		EntityCode: key.EntityCode,
+	}, nil
+}
+
+// Identify retorna el ID de la llave a la que corresponde un secreto, sin validar su
+// vigencia ni registrar el uso, o "" si no corresponde a ninguna
+func (ks *APIKeyStore) Identify(secret string) string {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+
+	key, exists := ks.byHash[hashAPIKey(secret)]
+	if !exists {
+		return ""
+	}
+	return key.ID
+}
+
+// List retorna las llaves emitidas, sin sus secretos
+func (ks *APIKeyStore) List() []APIKey {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+
+	keys := make([]APIKey, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		keys = append(keys, *key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
+
+// Get retorna una llave por su ID
+func (ks *APIKeyStore) Get(id string) (*APIKey, error) {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+
+	key, exists := ks.keys[id]
+	if !exists {
+		return nil, errors.New("llave de API no encontrada")
+	}
+	copied := *key
+	return &copied, nil
+}
+
+// Update cambia el nombre de una llave; el alcance y la entidad no se modifican para
+// que un cambio de permisos siempre implique emitir una llave nueva
+func (ks *APIKeyStore) Update(id string, name string) (*APIKey, error) {
+	if name == "" {
+		return nil, errors.New("la llave requiere un nombre")
+	}
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	key, exists := ks.keys[id]
+	if !exists {
+		return nil, errors.New("llave de API no encontrada")
+	}
+	key.Name = name
+	if err := ks.save(key); err != nil {
+		return nil, err
+	}
+	copied := *key
+	return &copied, nil
+}
+
+// Revoke revoca una llave; se conserva para dejar rastro de quién la usó
+func (ks *APIKeyStore) Revoke(id string) error {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	key, exists := ks.keys[id]
+	if !exists {
+		return errors.New("llave de API no encontrada")
+	}
+	if key.RevokedAt != nil {
+		return errors.New("la llave ya está revocada")
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	if err := ks.save(key); err != nil {
+		key.RevokedAt = nil
+		return err
+	}
+
+	fmt.Printf("🚫 Llave de API %s revocada\n", id)
+	return nil
+}
+
+// save persiste una llave con la huella de su secreto
+func (ks *APIKeyStore) save(key *APIKey) error {
+	data, err := json.Marshal(storedAPIKey{APIKey: *key, Hash: key.Hash})
+	if err != nil {
+		return err
+	}
+	return ks.store.Put(storage.BucketAPIKeys, key.ID, data)
+}
+
+// hashAPIKey calcula la huella con la que se busca una llave
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"github.com/google/uuid"
+)
+
+// Claims representa la identidad contenida en un token de sesión. Las peticiones
+// autenticadas con una llave de API traen además su alcance y entidad.
+type Claims struct {
+	Subject    string               `json:"sub"`
+	Name       string               `json:"name"`
+	Role       blockchain.AdminRole `json:"role"`
+	IssuedAt   int64                `json:"iat"`
+	ExpiresAt  int64                `json:"exp"`
+	TokenID    string               `json:"jti"`
+	Scope      string               `json:"scope,omitempty"`
+	EntityCode string               `json:"entity_code,omitempty"`
+}
+
+// Issuer emite y verifica tokens JWT firmados con HMAC-SHA256
+type Issuer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// Encabezado fijo de los tokens emitidos por el nodo
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// NewIssuer crea un emisor de tokens; si el secreto está vacío se genera uno
+// aleatorio y los tokens dejan de ser válidos al reiniciar el nodo
+func NewIssuer(secret string, ttl time.Duration) (*Issuer, error) {
+	if ttl <= 0 {
+		return nil, errors.New("la duración de la sesión debe ser mayor que cero")
+	}
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	return &Issuer{secret: key, ttl: ttl}, nil
+}
+
+// Issue emite un token de sesión para el usuario
+func (is *Issuer) Issue(user User) (string, *Claims, error) {
+	now := time.Now()
+	claims := &Claims{
+		Subject:    user.ID,
+		Name:       user.Name,
+		Role:       user.Role,
+		IssuedAt:   now.Unix(),
+		ExpiresAt:  now.Add(is.ttl).Unix(),
+		TokenID:    uuid.New().String(),
+		EntityCode: user.EntityCode,
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + is.sign(unsigned), claims, nil
+}
+
+// Verify valida la firma y la vigencia de un token y retorna su identidad
+func (is *Issuer) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token mal formado")
+	}
+	if parts[0] != tokenHeader {
+		return nil, errors.New("algoritmo de token no soportado")
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(is.sign(parts[0]+"."+parts[1]))) {
+		return nil, errors.New("firma del token inválida")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("token mal formado")
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.New("token mal formado")
+	}
+	if claims.Subject == "" || claims.Role == "" {
+		return nil, errors.New("token sin identidad")
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, errors.New("sesión expirada")
+	}
+	return &claims, nil
+}
+
+// sign calcula la firma HS256 de la parte sin firmar del token
+func (is *Issuer) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, is.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
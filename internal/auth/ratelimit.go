@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit es la cuota de un cliente: Burst peticiones seguidas como máximo, que se
+// recuperan a razón de PerSecond por segundo
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+// PerMinute es la cuota de n peticiones por minuto, todas disponibles de una vez
+func PerMinute(n int) RateLimit {
+	return RateLimit{PerSecond: float64(n) / 60, Burst: n}
+}
+
+// Enabled indica si la cuota limita algo; con Burst 0 no hay límite
+func (l RateLimit) Enabled() bool {
+	return l.Burst > 0 && l.PerSecond > 0
+}
+
+// RateDecision es el resultado de consumir una petición de la cuota
+type RateDecision struct {
+	Allowed    bool
+	Limit      int           // Capacidad del cubo
+	Remaining  int           // Peticiones disponibles después de esta
+	RetryAfter time.Duration // Cuánto esperar para la siguiente si se rechazó
+}
+
+// tokenBucket son las fichas disponibles de un cliente
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	limit   RateLimit
+}
+
+// RateLimiter aplica un cubo de fichas por cliente (llave de API o IP). Los cubos llenos
+// que llevan un rato sin usarse se descartan para no crecer con cada IP que pasa.
+type RateLimiter struct {
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+// NewRateLimiter crea un limitador sin clientes
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// Allow consume una ficha del cubo del cliente con la cuota dada
+func (rl *RateLimiter) Allow(client string, limit RateLimit, now time.Time) RateDecision {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	if now.Sub(rl.lastSweep) >= time.Minute {
+		rl.sweep(now)
+	}
+
+	bucket, exists := rl.buckets[client]
+	if !exists {
+		bucket = &tokenBucket{tokens: float64(limit.Burst), updated: now}
+		rl.buckets[client] = bucket
+	}
+	bucket.limit = limit
+	bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+now.Sub(bucket.updated).Seconds()*limit.PerSecond)
+	bucket.updated = now
+
+	decision := RateDecision{Limit: limit.Burst}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		decision.Allowed = true
+		decision.Remaining = int(bucket.tokens)
+		return decision
+	}
+	decision.RetryAfter = time.Duration((1 - bucket.tokens) / limit.PerSecond * float64(time.Second))
+	return decision
+}
+
+// sweep descarta los cubos que ya se habrían llenado de nuevo
+func (rl *RateLimiter) sweep(now time.Time) {
+	rl.lastSweep = now
+	for client, bucket := range rl.buckets {
+		refill := time.Duration(float64(bucket.limit.Burst) / bucket.limit.PerSecond * float64(time.Second))
+		if now.Sub(bucket.updated) >= refill {
+			delete(rl.buckets, client)
+		}
+	}
+}
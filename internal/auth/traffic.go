@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// Tipos de petición rechazada que se contabilizan por cliente
+const (
+	FailureValidation = "validation" // Datos que no pasan las reglas del negocio
+	FailureSignature  = "signature"  // Firmas ausentes, inválidas o con llaves desconocidas
+	FailurePayload    = "payload"    // Cuerpos mal formados, incompletos o demasiado grandes
+	FailureAuth       = "auth"       // Credenciales inválidas o permisos insuficientes
+)
+
+// ThrottlePolicy define cuándo se frena a un cliente abusivo. Con Threshold 0 solo se
+// registran las métricas y nunca se frena a nadie.
+type ThrottlePolicy struct {
+	Threshold int           // Rechazos dentro de la ventana que activan el freno
+	Window    time.Duration // Ventana en la que se cuentan los rechazos
+	Block     time.Duration // Tiempo que el cliente queda frenado
+}
+
+// ClientTraffic resume el tráfico rechazado de un cliente (llave de API o IP)
+type ClientTraffic struct {
+	Client         string         `json:"client"`
+	Failures       map[string]int `json:"failures"`
+	Total          int            `json:"total"`
+	LastStatus     int            `json:"last_status"`
+	LastPath       string         `json:"last_path"`
+	LastError      string         `json:"last_error,omitempty"`
+	FirstSeen      time.Time      `json:"first_seen"`
+	LastSeen       time.Time      `json:"last_seen"`
+	WindowStart    time.Time      `json:"window_start"`
+	WindowFailures int            `json:"window_failures"`
+	ThrottledUntil *time.Time     `json:"throttled_until,omitempty"`
+	Throttles      int            `json:"throttles"`
+}
+
+// TrafficMonitor cuenta las peticiones rechazadas por cliente para detectar
+// integraciones rotas e intentos de sondeo. Los contadores se guardan en lote.
+type TrafficMonitor struct {
+	store   storage.Store
+	policy  ThrottlePolicy
+	clients map[string]*ClientTraffic
+	dirty   map[string]bool
+	mutex   sync.Mutex
+}
+
+// NewTrafficMonitor carga las métricas guardadas
+func NewTrafficMonitor(store storage.Store, policy ThrottlePolicy) (*TrafficMonitor, error) {
+	tm := &TrafficMonitor{
+		store:   store,
+		policy:  policy,
+		clients: make(map[string]*ClientTraffic),
+		dirty:   make(map[string]bool),
+	}
+
+	err := store.ForEach(storage.BucketTrafficMetrics, func(client string, value []byte) error {
+		var traffic ClientTraffic
+		if err := json.Unmarshal(value, &traffic); err != nil {
+			return fmt.Errorf("métricas de tráfico de %s corruptas: %v", client, err)
+		}
+		tm.clients[client] = &traffic
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tm, nil
+}
+
+// Record contabiliza una petición rechazada y, si el cliente supera el umbral de la
+// política, lo frena. Retorna true si el cliente quedó frenado con este rechazo.
+func (tm *TrafficMonitor) Record(client string, kind string, status int, path string, message string) bool {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	now := time.Now()
+	traffic, exists := tm.clients[client]
+	if !exists {
+		traffic = &ClientTraffic{Client: client, Failures: make(map[string]int), FirstSeen: now}
+		tm.clients[client] = traffic
+	}
+	traffic.Failures[kind]++
+	traffic.Total++
+	traffic.LastStatus = status
+	traffic.LastPath = path
+	traffic.LastError = message
+	traffic.LastSeen = now
+	tm.dirty[client] = true
+
+	if tm.policy.Threshold <= 0 {
+		return false
+	}
+	if now.Sub(traffic.WindowStart) > tm.policy.Window {
+		traffic.WindowStart = now
+		traffic.WindowFailures = 0
+	}
+	traffic.WindowFailures++
+	if traffic.WindowFailures < tm.policy.Threshold || traffic.throttled(now) {
+		return false
+	}
+
+	until := now.Add(tm.policy.Block)
+	traffic.ThrottledUntil = &until
+	traffic.Throttles++
+	traffic.WindowStart = now
+	traffic.WindowFailures = 0
+	fmt.Printf("🚦 Cliente %s frenado hasta %s por %d rechazos\n", client, until.Format(time.RFC3339), tm.policy.Threshold)
+	return true
+}
+
+// ThrottledUntil indica hasta cuándo está frenado un cliente, o nil si no lo está
+func (tm *TrafficMonitor) ThrottledUntil(client string) *time.Time {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	traffic, exists := tm.clients[client]
+	if !exists || !traffic.throttled(time.Now()) {
+		return nil
+	}
+	until := *traffic.ThrottledUntil
+	return &until
+}
+
+// Unthrottle levanta el freno de un cliente antes de tiempo
+func (tm *TrafficMonitor) Unthrottle(client string) error {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	traffic, exists := tm.clients[client]
+	if !exists || !traffic.throttled(time.Now()) {
+		return fmt.Errorf("el cliente %s no está frenado", client)
+	}
+	traffic.ThrottledUntil = nil
+	traffic.WindowFailures = 0
+	tm.dirty[client] = true
+	return nil
+}
+
+// Clients lista las métricas por cliente, los de más rechazos primero
+func (tm *TrafficMonitor) Clients() []ClientTraffic {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	clients := make([]ClientTraffic, 0, len(tm.clients))
+	for _, traffic := range tm.clients {
+		copied := *traffic
+		copied.Failures = make(map[string]int, len(traffic.Failures))
+		for kind, count := range traffic.Failures {
+			copied.Failures[kind] = count
+		}
+		clients = append(clients, copied)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].Total != clients[j].Total {
+			return clients[i].Total > clients[j].Total
+		}
+		return clients[i].Client < clients[j].Client
+	})
+	return clients
+}
+
+// Policy retorna la política de freno configurada
+func (tm *TrafficMonitor) Policy() ThrottlePolicy {
+	return tm.policy
+}
+
+// Flush guarda las métricas que cambiaron desde el último guardado
+func (tm *TrafficMonitor) Flush() error {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	for client := range tm.dirty {
+		data, err := json.Marshal(tm.clients[client])
+		if err != nil {
+			return err
+		}
+		if err := tm.store.Put(storage.BucketTrafficMetrics, client, data); err != nil {
+			return err
+		}
+		delete(tm.dirty, client)
+	}
+	return nil
+}
+
+// Run guarda periódicamente las métricas para no escribir en cada rechazo
+func (tm *TrafficMonitor) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := tm.Flush(); err != nil {
+			fmt.Printf("❌ Error guardando métricas de tráfico: %v\n", err)
+		}
+	}
+}
+
+// throttled indica si el cliente sigue frenado
+func (traffic *ClientTraffic) throttled(now time.Time) bool {
+	return traffic.ThrottledUntil != nil && now.Before(*traffic.ThrottledUntil)
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"secop-blockchain/pkg/blockchain"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User representa un funcionario que puede iniciar sesión en el nodo
+type User struct {
+	ID           string               `json:"id"`
+	Name         string               `json:"name"`
+	Role         blockchain.AdminRole `json:"role"`
+	EntityCode   string               `json:"entity_code,omitempty"` // Entidad a la que pertenece; vacía en los entes de control
+	PasswordHash string               `json:"password_hash"`
+}
+
+// Directory guarda los usuarios habilitados para iniciar sesión
+type Directory struct {
+	users map[string]User
+}
+
+// Error genérico de credenciales; no revela si el usuario existe
+var ErrInvalidCredentials = errors.New("credenciales inválidas")
+
+// LoadDirectory carga los usuarios desde un archivo JSON con contraseñas en bcrypt
+// (por ejemplo generadas con `htpasswd -bnBC 10 "" clave`). Si el archivo no existe
+// el directorio queda vacío y nadie puede iniciar sesión.
+func LoadDirectory(path string) (*Directory, error) {
+	dir := &Directory{users: make(map[string]User)}
+	if path == "" {
+		return dir, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return dir, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var users []User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("archivo de usuarios inválido: %v", err)
+	}
+	for _, user := range users {
+		if user.ID == "" || user.Role == "" || user.PasswordHash == "" {
+			return nil, fmt.Errorf("usuario incompleto en el archivo: %s", user.ID)
+		}
+		if _, exists := dir.users[user.ID]; exists {
+			return nil, fmt.Errorf("usuario duplicado en el archivo: %s", user.ID)
+		}
+		dir.users[user.ID] = user
+	}
+	return dir, nil
+}
+
+// Authenticate verifica las credenciales de un usuario
+func (d *Directory) Authenticate(userID string, password string) (User, error) {
+	user, exists := d.users[userID]
+	if !exists {
+		return User{}, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// Count retorna cuántos usuarios están habilitados
+func (d *Directory) Count() int {
+	return len(d.users)
+}
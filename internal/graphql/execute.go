@@ -0,0 +1,491 @@
+package graphql
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Request es una petición GraphQL tal como llega por HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// Error es un error de la consulta; Path indica el campo que falló al resolverse
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Result es la respuesta GraphQL: los datos resueltos y los errores, si hubo
+type Result struct {
+	Data   interface{} `json:"data"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// executor resuelve una operación
+type executor struct {
+	schema    *Schema
+	fragments map[string]*fragment
+	variables map[string]interface{}
+	errors    []Error
+}
+
+// Execute analiza, valida y resuelve la petición. Los errores del documento (sintaxis,
+// campos o argumentos desconocidos) se retornan sin datos; los de un campo al resolverse
+// lo dejan en null y siguen con el resto.
+func (s *Schema) Execute(request Request) *Result {
+	doc, err := parse(request.Query)
+	if err != nil {
+		return &Result{Errors: []Error{{Message: "error de sintaxis: " + err.Error()}}}
+	}
+
+	op, err := selectOperation(doc, request.OperationName)
+	if err != nil {
+		return &Result{Errors: []Error{{Message: err.Error()}}}
+	}
+	if op.kind != "query" {
+		return &Result{Errors: []Error{{Message: fmt.Sprintf("solo se admiten consultas (query), no %s", op.kind)}}}
+	}
+
+	variables := make(map[string]interface{})
+	for name, defaultValue := range op.variables {
+		variables[name] = defaultValue
+	}
+	for name, provided := range request.Variables {
+		variables[name] = provided
+	}
+
+	e := &executor{schema: s, fragments: doc.fragments, variables: variables}
+	maxDepth := s.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	if err := e.validate(s.Query, op.selections, 1, maxDepth, map[string]bool{}); err != nil {
+		return &Result{Errors: []Error{{Message: err.Error()}}}
+	}
+
+	data := e.resolveObject(s.Query, nil, op.selections, nil)
+	return &Result{Data: data, Errors: e.errors}
+}
+
+// selectOperation elige la operación por nombre, o la única del documento
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, fmt.Errorf("el documento tiene varias operaciones: indique operationName")
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("operación %s no encontrada", name)
+}
+
+// validate revisa que los campos y argumentos existan y que la consulta no sea demasiado
+// profunda, antes de resolver nada
+func (e *executor) validate(object *Object, selections []selection, depth, maxDepth int, visiting map[string]bool) error {
+	if depth > maxDepth {
+		return fmt.Errorf("la consulta supera la profundidad máxima de %d niveles", maxDepth)
+	}
+	for _, sel := range selections {
+		if sel.spread != "" || sel.inline {
+			frag, err := e.fragmentOf(object, sel)
+			if err != nil {
+				return err
+			}
+			if sel.spread != "" {
+				if visiting[sel.spread] {
+					return fmt.Errorf("el fragmento %s se incluye a sí mismo", sel.spread)
+				}
+				visiting[sel.spread] = true
+			}
+			if err := e.validate(object, frag, depth, maxDepth, visiting); err != nil {
+				return err
+			}
+			delete(visiting, sel.spread)
+			continue
+		}
+
+		if sel.name == "__typename" {
+			continue
+		}
+		field, exists := object.Fields[sel.name]
+		if !exists {
+			return fmt.Errorf("el campo %s no existe en el tipo %s", sel.name, object.Name)
+		}
+		for name := range sel.arguments {
+			if argumentOf(field, name) == nil {
+				return fmt.Errorf("el campo %s.%s no admite el argumento %s", object.Name, sel.name, name)
+			}
+		}
+		for _, arg := range field.Args {
+			if _, required := arg.Type.(*NonNull); required {
+				if _, present := sel.arguments[arg.Name]; !present {
+					return fmt.Errorf("el campo %s.%s requiere el argumento %s", object.Name, sel.name, arg.Name)
+				}
+			}
+		}
+
+		child := namedObject(field.Type)
+		switch {
+		case child != nil && len(sel.selections) == 0:
+			return fmt.Errorf("el campo %s.%s es de tipo %s: indique los subcampos", object.Name, sel.name, field.Type)
+		case child == nil && len(sel.selections) > 0:
+			return fmt.Errorf("el campo %s.%s es escalar y no admite subcampos", object.Name, sel.name)
+		case child != nil:
+			if err := e.validate(child, sel.selections, depth+1, maxDepth, visiting); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fragmentOf retorna las selecciones de un fragmento aplicado al objeto
+func (e *executor) fragmentOf(object *Object, sel selection) ([]selection, error) {
+	typeName, selections := sel.typeName, sel.selections
+	if sel.spread != "" {
+		frag, exists := e.fragments[sel.spread]
+		if !exists {
+			return nil, fmt.Errorf("fragmento %s no definido", sel.spread)
+		}
+		typeName, selections = frag.typeName, frag.selections
+	}
+	if typeName != "" && typeName != object.Name {
+		return nil, fmt.Errorf("un fragmento sobre %s no aplica al tipo %s", typeName, object.Name)
+	}
+	return selections, nil
+}
+
+// resolveObject resuelve las selecciones sobre el valor de un objeto
+func (e *executor) resolveObject(object *Object, source interface{}, selections []selection, path []interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	e.collect(object, source, selections, path, result)
+	return result
+}
+
+// collect resuelve cada selección y la agrega al resultado, expandiendo los fragmentos
+func (e *executor) collect(object *Object, source interface{}, selections []selection, path []interface{}, result map[string]interface{}) {
+	for _, sel := range selections {
+		include, err := e.included(sel.directives)
+		if err != nil {
+			e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+			continue
+		}
+		if !include {
+			continue
+		}
+		if sel.spread != "" || sel.inline {
+			frag, _ := e.fragmentOf(object, sel) // Ya validado
+			e.collect(object, source, frag, path, result)
+			continue
+		}
+
+		key := sel.name
+		if sel.alias != "" {
+			key = sel.alias
+		}
+		if _, done := result[key]; done {
+			continue
+		}
+		fieldPath := append(append([]interface{}{}, path...), key)
+		if sel.name == "__typename" {
+			result[key] = object.Name
+			continue
+		}
+		result[key] = e.resolveField(object.Fields[sel.name], source, sel, fieldPath)
+	}
+}
+
+// resolveField obtiene el valor del campo y lo completa según su tipo
+func (e *executor) resolveField(field *Field, source interface{}, sel selection, path []interface{}) interface{} {
+	args, err := e.arguments(field, sel.arguments)
+	if err != nil {
+		e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+		return nil
+	}
+
+	var resolved interface{}
+	if field.Resolve != nil {
+		resolved, err = field.Resolve(ResolveParams{Source: source, Args: args})
+	} else {
+		resolved = defaultResolve(source, sel.name)
+	}
+	if err != nil {
+		e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+		return nil
+	}
+	return e.complete(field.Type, resolved, sel, path)
+}
+
+// complete convierte el valor resuelto al tipo del campo
+func (e *executor) complete(fieldType Type, resolved interface{}, sel selection, path []interface{}) interface{} {
+	if nonNull, ok := fieldType.(*NonNull); ok {
+		fieldType = nonNull.Of
+	}
+	if isNil(resolved) {
+		return nil
+	}
+
+	switch typed := fieldType.(type) {
+	case *List:
+		items := reflect.ValueOf(resolved)
+		for items.Kind() == reflect.Ptr {
+			items = items.Elem()
+		}
+		if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+			e.errors = append(e.errors, Error{Message: "se esperaba una lista", Path: path})
+			return nil
+		}
+		list := make([]interface{}, items.Len())
+		for i := range list {
+			list[i] = e.complete(typed.Of, items.Index(i).Interface(), sel, append(append([]interface{}{}, path...), i))
+		}
+		return list
+	case *Object:
+		return e.resolveObject(typed, resolved, sel.selections, path)
+	case *Scalar:
+		value, err := serializeScalar(typed, resolved)
+		if err != nil {
+			e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+			return nil
+		}
+		return value
+	}
+	return nil
+}
+
+// included evalúa @include(if:) y @skip(if:)
+func (e *executor) included(directives []directive) (bool, error) {
+	for _, d := range directives {
+		condition, exists := d.arguments["if"]
+		if d.name != "include" && d.name != "skip" {
+			return false, fmt.Errorf("directiva @%s no soportada", d.name)
+		}
+		if !exists {
+			return false, fmt.Errorf("la directiva @%s requiere el argumento if", d.name)
+		}
+		value, err := coerce(Boolean, e.valueOf(condition))
+		if err != nil {
+			return false, fmt.Errorf("@%s: %v", d.name, err)
+		}
+		if (d.name == "include") != value.(bool) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// arguments convierte los argumentos de la selección a los tipos declarados en el campo
+func (e *executor) arguments(field *Field, provided map[string]value) (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+	for name, raw := range provided {
+		arg := argumentOf(field, name)
+		converted, err := coerce(arg.Type, e.valueOf(raw))
+		if err != nil {
+			return nil, fmt.Errorf("argumento %s: %v", name, err)
+		}
+		if converted != nil {
+			args[name] = converted
+		}
+	}
+	for _, arg := range field.Args {
+		if _, required := arg.Type.(*NonNull); required && args[arg.Name] == nil {
+			return nil, fmt.Errorf("el argumento %s no puede ser null", arg.Name)
+		}
+	}
+	return args, nil
+}
+
+// valueOf reemplaza las variables por su valor
+func (e *executor) valueOf(v value) interface{} {
+	switch v.kind {
+	case valueVariable:
+		return e.variables[v.variable]
+	case valueList:
+		items := make([]interface{}, len(v.list))
+		for i, item := range v.list {
+			items[i] = e.valueOf(item)
+		}
+		return items
+	case valueObject:
+		fields := make(map[string]interface{}, len(v.object))
+		for name, field := range v.object {
+			fields[name] = e.valueOf(field)
+		}
+		return fields
+	}
+	return v.literal
+}
+
+// coerce convierte un valor de entrada al tipo del argumento
+func coerce(t Type, input interface{}) (interface{}, error) {
+	if nonNull, ok := t.(*NonNull); ok {
+		t = nonNull.Of
+	}
+	if input == nil {
+		return nil, nil
+	}
+	switch typed := t.(type) {
+	case *List:
+		items, ok := input.([]interface{})
+		if !ok {
+			items = []interface{}{input}
+		}
+		converted := make([]interface{}, len(items))
+		for i, item := range items {
+			value, err := coerce(typed.Of, item)
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = value
+		}
+		return converted, nil
+	case *Scalar:
+		switch typed {
+		case Int:
+			switch number := input.(type) {
+			case int:
+				return number, nil
+			case float64: // Las variables llegan del JSON como float64
+				if number == math.Trunc(number) && math.Abs(number) <= math.MaxInt32 {
+					return int(number), nil
+				}
+			}
+		case Float:
+			switch number := input.(type) {
+			case int:
+				return float64(number), nil
+			case float64:
+				return number, nil
+			}
+		case String, ID:
+			switch text := input.(type) {
+			case string:
+				return text, nil
+			case int:
+				if typed == ID {
+					return fmt.Sprint(text), nil
+				}
+			}
+		case Boolean:
+			if flag, ok := input.(bool); ok {
+				return flag, nil
+			}
+		}
+		return nil, fmt.Errorf("se esperaba %s y llegó %v", typed.Name, input)
+	}
+	return nil, fmt.Errorf("tipo de argumento no soportado: %s", t)
+}
+
+// serializeScalar convierte el valor de Go al escalar de la respuesta
+func serializeScalar(scalar *Scalar, resolved interface{}) (interface{}, error) {
+	value := reflect.ValueOf(resolved)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	switch scalar {
+	case String, ID:
+		if value.Kind() == reflect.String {
+			return value.String(), nil
+		}
+		// Las fechas van en RFC 3339; una fecha sin asignar es null
+		if moment, ok := value.Interface().(time.Time); ok {
+			if moment.IsZero() {
+				return nil, nil
+			}
+			return moment.Format(time.RFC3339Nano), nil
+		}
+		return fmt.Sprint(value.Interface()), nil
+	case Int:
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return value.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return value.Uint(), nil
+		}
+	case Float:
+		switch value.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return value.Float(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(value.Int()), nil
+		}
+	case Boolean:
+		if value.Kind() == reflect.Bool {
+			return value.Bool(), nil
+		}
+	}
+	return nil, fmt.Errorf("el valor %v no es de tipo %s", resolved, scalar.Name)
+}
+
+// defaultResolve lee el campo del struct o la llave del mapa con el nombre dado
+func defaultResolve(source interface{}, name string) interface{} {
+	value := reflect.ValueOf(source)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		field := value.FieldByNameFunc(func(fieldName string) bool { return strings.EqualFold(fieldName, name) })
+		if field.IsValid() && field.CanInterface() {
+			return field.Interface()
+		}
+	case reflect.Map:
+		if value.Type().Key().Kind() == reflect.String {
+			entry := value.MapIndex(reflect.ValueOf(name))
+			if entry.IsValid() {
+				return entry.Interface()
+			}
+		}
+	}
+	return nil
+}
+
+// namedObject retorna el objeto dentro del tipo (quitando listas y no nulos), si hay
+func namedObject(t Type) *Object {
+	for {
+		switch typed := t.(type) {
+		case *List:
+			t = typed.Of
+		case *NonNull:
+			t = typed.Of
+		case *Object:
+			return typed
+		default:
+			return nil
+		}
+	}
+}
+
+func argumentOf(field *Field, name string) *Argument {
+	for i := range field.Args {
+		if field.Args[i].Name == name {
+			return &field.Args[i]
+		}
+	}
+	return nil
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,624 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Analizador de documentos GraphQL: operaciones de consulta con variables, alias,
+// argumentos, fragmentos y las directivas @include y @skip.
+
+// document es un documento GraphQL ya analizado
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+// operation es una consulta (query) del documento
+type operation struct {
+	kind       string // query, mutation o subscription
+	name       string
+	variables  map[string]interface{} // Valores por defecto de las variables declaradas
+	selections []selection
+}
+
+// fragment es un fragmento con nombre
+type fragment struct {
+	name       string
+	typeName   string
+	selections []selection
+}
+
+// selection es un campo, la expansión de un fragmento o un fragmento en línea
+type selection struct {
+	alias      string
+	name       string
+	arguments  map[string]value
+	directives []directive
+	selections []selection
+
+	spread   string // Nombre del fragmento en ...Nombre
+	inline   bool   // ... on Tipo { }
+	typeName string // Tipo del fragmento en línea, si lo indica
+}
+
+// directive es una directiva aplicada a una selección
+type directive struct {
+	name      string
+	arguments map[string]value
+}
+
+// value es un valor literal o una referencia a una variable ($nombre)
+type value struct {
+	variable string
+	literal  interface{}
+	list     []value
+	object   map[string]value
+	kind     valueKind
+}
+
+type valueKind int
+
+const (
+	valueLiteral valueKind = iota
+	valueVariable
+	valueList
+	valueObject
+)
+
+// Tipos de token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lexer separa el documento en tokens, ignorando espacios, comas y comentarios
+type lexer struct {
+	source string
+	pos    int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.source) {
+		c := l.source[l.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			l.pos++
+			continue
+		}
+		if c == '#' {
+			for l.pos < len(l.source) && l.source[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+		}
+		break
+	}
+	if l.pos >= len(l.source) {
+		return token{kind: tokenEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.source[l.pos]
+	switch {
+	case strings.HasPrefix(l.source[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunct, text: "...", pos: start}, nil
+	case strings.ContainsRune("!$():=@[]{}|", rune(c)):
+		l.pos++
+		return token{kind: tokenPunct, text: string(c), pos: start}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.source) && (l.source[l.pos] == '_' || isLetter(l.source[l.pos]) || isDigit(l.source[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, text: l.source[start:l.pos], pos: start}, nil
+	case c == '-' || isDigit(c):
+		l.pos++
+		kind := tokenInt
+		for l.pos < len(l.source) {
+			d := l.source[l.pos]
+			if isDigit(d) {
+				l.pos++
+			} else if d == '.' || d == 'e' || d == 'E' || ((d == '+' || d == '-') && kind == tokenFloat) {
+				kind = tokenFloat
+				l.pos++
+			} else {
+				break
+			}
+		}
+		return token{kind: kind, text: l.source[start:l.pos], pos: start}, nil
+	case c == '"':
+		return l.readString()
+	}
+	return token{}, fmt.Errorf("carácter inesperado %q en la posición %d", c, start)
+}
+
+// readString lee una cadena entre comillas, o una cadena de bloque entre triples comillas
+func (l *lexer) readString() (token, error) {
+	start := l.pos
+	if strings.HasPrefix(l.source[l.pos:], `"""`) {
+		end := strings.Index(l.source[l.pos+3:], `"""`)
+		if end < 0 {
+			return token{}, fmt.Errorf("cadena sin cerrar en la posición %d", start)
+		}
+		text := l.source[l.pos+3 : l.pos+3+end]
+		l.pos += end + 6
+		return token{kind: tokenString, text: strings.TrimSpace(text), pos: start}, nil
+	}
+
+	l.pos++
+	var builder strings.Builder
+	for l.pos < len(l.source) {
+		c := l.source[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return token{kind: tokenString, text: builder.String(), pos: start}, nil
+		case c == '\n':
+			return token{}, fmt.Errorf("cadena sin cerrar en la posición %d", start)
+		case c == '\\' && l.pos+1 < len(l.source):
+			escaped := l.source[l.pos+1]
+			l.pos += 2
+			switch escaped {
+			case 'n':
+				builder.WriteByte('\n')
+			case 't':
+				builder.WriteByte('\t')
+			case 'r':
+				builder.WriteByte('\r')
+			case 'b':
+				builder.WriteByte('\b')
+			case 'f':
+				builder.WriteByte('\f')
+			case 'u':
+				if l.pos+4 > len(l.source) {
+					return token{}, fmt.Errorf("escape unicode incompleto en la posición %d", l.pos)
+				}
+				code, err := strconv.ParseUint(l.source[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return token{}, fmt.Errorf("escape unicode inválido en la posición %d", l.pos)
+				}
+				builder.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				builder.WriteByte(escaped)
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(l.source[l.pos:])
+			builder.WriteRune(r)
+			l.pos += size
+		}
+	}
+	return token{}, fmt.Errorf("cadena sin cerrar en la posición %d", start)
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// parser construye el documento a partir de los tokens
+type parser struct {
+	lexer   *lexer
+	current token
+}
+
+// parse analiza el documento completo
+func parse(source string) (*document, error) {
+	p := &parser{lexer: &lexer{source: strings.TrimPrefix(source, "\ufeff")}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.current.kind != tokenEOF {
+		switch {
+		case p.isPunct("{"):
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: selections})
+		case p.isName("query"), p.isName("mutation"), p.isName("subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.isName("fragment"):
+			frag, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := doc.fragments[frag.name]; exists {
+				return nil, fmt.Errorf("el fragmento %s está definido más de una vez", frag.name)
+			}
+			doc.fragments[frag.name] = frag
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("el documento no tiene ninguna operación")
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.current = tok
+	return nil
+}
+
+func (p *parser) isPunct(text string) bool {
+	return p.current.kind == tokenPunct && p.current.text == text
+}
+
+func (p *parser) isName(text string) bool {
+	return p.current.kind == tokenName && p.current.text == text
+}
+
+func (p *parser) unexpected() error {
+	if p.current.kind == tokenEOF {
+		return fmt.Errorf("fin inesperado del documento")
+	}
+	return fmt.Errorf("token inesperado %q en la posición %d", p.current.text, p.current.pos)
+}
+
+// expect consume el signo de puntuación indicado
+func (p *parser) expect(text string) error {
+	if !p.isPunct(text) {
+		return fmt.Errorf("se esperaba %q en la posición %d", text, p.current.pos)
+	}
+	return p.advance()
+}
+
+// expectName consume un nombre y lo retorna
+func (p *parser) expectName() (string, error) {
+	if p.current.kind != tokenName {
+		return "", fmt.Errorf("se esperaba un nombre en la posición %d", p.current.pos)
+	}
+	name := p.current.text
+	return name, p.advance()
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	op := &operation{kind: p.current.text, variables: make(map[string]interface{})}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.current.kind == tokenName {
+		op.name = p.current.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isPunct("(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.isPunct(")") {
+			if err := p.expect("$"); err != nil {
+				return nil, err
+			}
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if err := p.skipType(); err != nil {
+				return nil, err
+			}
+			op.variables[name] = nil
+			if p.isPunct("=") {
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+				defaultValue, err := p.parseValue(true)
+				if err != nil {
+					return nil, err
+				}
+				op.variables[name] = defaultValue.literal
+			}
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+	return op, nil
+}
+
+// skipType consume el tipo de una variable (Int, [String!]!); los tipos se revisan al
+// usar la variable como argumento
+func (p *parser) skipType() error {
+	if p.isPunct("[") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.expectName(); err != nil {
+		return err
+	}
+	if p.isPunct("!") {
+		return p.advance()
+	}
+	return nil
+}
+
+func (p *parser) parseFragment() (*fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isName("on") {
+		return nil, fmt.Errorf("se esperaba \"on\" en el fragmento %s", name)
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	typeName, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, typeName: typeName, selections: selections}, nil
+}
+
+func (p *parser) parseSelectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for !p.isPunct("}") {
+		sel, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("selección vacía en la posición %d", p.current.pos)
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) parseSelection() (selection, error) {
+	var sel selection
+	var err error
+
+	if p.isPunct("...") {
+		if err := p.advance(); err != nil {
+			return sel, err
+		}
+		if p.current.kind == tokenName && !p.isName("on") {
+			sel.spread = p.current.text
+			if err := p.advance(); err != nil {
+				return sel, err
+			}
+			sel.directives, err = p.parseDirectives()
+			return sel, err
+		}
+		sel.inline = true
+		if p.isName("on") {
+			if err := p.advance(); err != nil {
+				return sel, err
+			}
+			if sel.typeName, err = p.expectName(); err != nil {
+				return sel, err
+			}
+		}
+		if sel.directives, err = p.parseDirectives(); err != nil {
+			return sel, err
+		}
+		sel.selections, err = p.parseSelectionSet()
+		return sel, err
+	}
+
+	if sel.name, err = p.expectName(); err != nil {
+		return sel, err
+	}
+	if p.isPunct(":") {
+		if err := p.advance(); err != nil {
+			return sel, err
+		}
+		sel.alias = sel.name
+		if sel.name, err = p.expectName(); err != nil {
+			return sel, err
+		}
+	}
+	if sel.arguments, err = p.parseArguments(); err != nil {
+		return sel, err
+	}
+	if sel.directives, err = p.parseDirectives(); err != nil {
+		return sel, err
+	}
+	if p.isPunct("{") {
+		sel.selections, err = p.parseSelectionSet()
+	}
+	return sel, err
+}
+
+func (p *parser) parseArguments() (map[string]value, error) {
+	arguments := make(map[string]value)
+	if !p.isPunct("(") {
+		return arguments, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	for !p.isPunct(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		arg, err := p.parseValue(false)
+		if err != nil {
+			return nil, err
+		}
+		arguments[name] = arg
+	}
+	return arguments, p.advance()
+}
+
+func (p *parser) parseDirectives() ([]directive, error) {
+	var directives []directive
+	for p.isPunct("@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, directive{name: name, arguments: arguments})
+	}
+	return directives, nil
+}
+
+// parseValue lee un valor; en los valores por defecto de las variables (constant) no se
+// admiten otras variables
+func (p *parser) parseValue(constant bool) (value, error) {
+	tok := p.current
+	switch {
+	case p.isPunct("$"):
+		if constant {
+			return value{}, fmt.Errorf("no se admiten variables en la posición %d", tok.pos)
+		}
+		if err := p.advance(); err != nil {
+			return value{}, err
+		}
+		name, err := p.expectName()
+		return value{kind: valueVariable, variable: name}, err
+	case p.isPunct("["):
+		if err := p.advance(); err != nil {
+			return value{}, err
+		}
+		list := value{kind: valueList, list: []value{}}
+		for !p.isPunct("]") {
+			item, err := p.parseValue(constant)
+			if err != nil {
+				return value{}, err
+			}
+			list.list = append(list.list, item)
+		}
+		if constant {
+			list = literalOf(list)
+		}
+		return list, p.advance()
+	case p.isPunct("{"):
+		if err := p.advance(); err != nil {
+			return value{}, err
+		}
+		object := value{kind: valueObject, object: make(map[string]value)}
+		for !p.isPunct("}") {
+			name, err := p.expectName()
+			if err != nil {
+				return value{}, err
+			}
+			if err := p.expect(":"); err != nil {
+				return value{}, err
+			}
+			field, err := p.parseValue(constant)
+			if err != nil {
+				return value{}, err
+			}
+			object.object[name] = field
+		}
+		if constant {
+			object = literalOf(object)
+		}
+		return object, p.advance()
+	case tok.kind == tokenInt:
+		number, err := strconv.Atoi(tok.text)
+		if err != nil {
+			return value{}, fmt.Errorf("entero inválido %s en la posición %d", tok.text, tok.pos)
+		}
+		return value{literal: number}, p.advance()
+	case tok.kind == tokenFloat:
+		number, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return value{}, fmt.Errorf("número inválido %s en la posición %d", tok.text, tok.pos)
+		}
+		return value{literal: number}, p.advance()
+	case tok.kind == tokenString:
+		return value{literal: tok.text}, p.advance()
+	case tok.kind == tokenName:
+		switch tok.text {
+		case "true":
+			return value{literal: true}, p.advance()
+		case "false":
+			return value{literal: false}, p.advance()
+		case "null":
+			return value{literal: nil}, p.advance()
+		}
+		// Valor de enumeración: se trata como su nombre
+		return value{literal: tok.text}, p.advance()
+	}
+	return value{}, p.unexpected()
+}
+
+// literalOf convierte una lista u objeto sin variables en su valor de Go
+func literalOf(v value) value {
+	switch v.kind {
+	case valueList:
+		items := make([]interface{}, len(v.list))
+		for i, item := range v.list {
+			items[i] = literalOf(item).literal
+		}
+		return value{literal: items}
+	case valueObject:
+		fields := make(map[string]interface{}, len(v.object))
+		for name, field := range v.object {
+			fields[name] = literalOf(field).literal
+		}
+		return value{literal: fields}
+	}
+	return v
+}
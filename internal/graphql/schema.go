@@ -0,0 +1,163 @@
+// Package graphql es un motor GraphQL de solo lectura para exponer los datos del ledger:
+// analiza las consultas, las valida contra un esquema declarado en Go y las resuelve campo
+// por campo. No admite mutaciones, suscripciones ni introspección (salvo __typename); el
+// esquema se publica en SDL con Schema.SDL.
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Type es un tipo del esquema: un escalar, un objeto, una lista o un tipo no nulo
+type Type interface {
+	String() string
+}
+
+// Scalar es un tipo escalar
+type Scalar struct {
+	Name string
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+// Escalares de GraphQL
+var (
+	String  = &Scalar{Name: "String"}
+	Int     = &Scalar{Name: "Int"}
+	Float   = &Scalar{Name: "Float"}
+	Boolean = &Scalar{Name: "Boolean"}
+	ID      = &Scalar{Name: "ID"}
+)
+
+// List es una lista de elementos de otro tipo
+type List struct {
+	Of Type
+}
+
+func (l *List) String() string { return "[" + l.Of.String() + "]" }
+
+// NonNull marca un tipo que nunca es null
+type NonNull struct {
+	Of Type
+}
+
+func (n *NonNull) String() string { return n.Of.String() + "!" }
+
+// Object es un tipo con campos
+type Object struct {
+	Name        string
+	Description string
+	Fields      map[string]*Field
+}
+
+func (o *Object) String() string { return o.Name }
+
+// NewObject crea un objeto sin campos; se agregan después para admitir tipos que se
+// referencian entre sí
+func NewObject(name, description string) *Object {
+	return &Object{Name: name, Description: description, Fields: make(map[string]*Field)}
+}
+
+// Field es un campo de un objeto. Sin Resolve se lee el campo del struct de Go (o la
+// llave del mapa) con el mismo nombre, sin distinguir mayúsculas
+type Field struct {
+	Type        Type
+	Description string
+	Args        []Argument
+	Resolve     ResolveFunc
+}
+
+// Argument es un argumento de un campo
+type Argument struct {
+	Name        string
+	Type        Type
+	Description string
+}
+
+// ResolveParams es lo que recibe quien resuelve un campo
+type ResolveParams struct {
+	Source interface{}            // Valor del objeto padre
+	Args   map[string]interface{} // Solo los argumentos presentes, ya convertidos a su tipo
+}
+
+// ResolveFunc retorna el valor de un campo
+type ResolveFunc func(params ResolveParams) (interface{}, error)
+
+// Schema es el esquema que se consulta
+type Schema struct {
+	Query    *Object
+	MaxDepth int // Profundidad máxima de las selecciones (DefaultMaxDepth si es 0)
+}
+
+// DefaultMaxDepth limita el anidamiento para que una consulta no recorra la cadena entera
+const DefaultMaxDepth = 10
+
+// SDL retorna el esquema en el lenguaje de definición de GraphQL
+func (s *Schema) SDL() string {
+	objects := make(map[string]*Object)
+	var collect func(t Type)
+	collect = func(t Type) {
+		switch typed := t.(type) {
+		case *List:
+			collect(typed.Of)
+		case *NonNull:
+			collect(typed.Of)
+		case *Object:
+			if _, seen := objects[typed.Name]; seen {
+				return
+			}
+			objects[typed.Name] = typed
+			for _, field := range typed.Fields {
+				collect(field.Type)
+			}
+		}
+	}
+	collect(s.Query)
+
+	names := make([]string, 0, len(objects))
+	for name := range objects {
+		if name != s.Query.Name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{s.Query.Name}, names...)
+
+	var builder strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		object := objects[name]
+		writeDescription(&builder, "", object.Description)
+		fmt.Fprintf(&builder, "type %s {\n", object.Name)
+		fields := make([]string, 0, len(object.Fields))
+		for fieldName := range object.Fields {
+			fields = append(fields, fieldName)
+		}
+		sort.Strings(fields)
+		for _, fieldName := range fields {
+			field := object.Fields[fieldName]
+			writeDescription(&builder, "  ", field.Description)
+			builder.WriteString("  " + fieldName)
+			if len(field.Args) > 0 {
+				args := make([]string, len(field.Args))
+				for j, arg := range field.Args {
+					args[j] = arg.Name + ": " + arg.Type.String()
+				}
+				builder.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			builder.WriteString(": " + field.Type.String() + "\n")
+		}
+		builder.WriteString("}\n")
+	}
+	return builder.String()
+}
+
+func writeDescription(builder *strings.Builder, indent, description string) {
+	if description != "" {
+		fmt.Fprintf(builder, "%s\"%s\"\n", indent, strings.ReplaceAll(description, `"`, `\"`))
+	}
+}
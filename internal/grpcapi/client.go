@@ -0,0 +1,133 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// Client invoca las RPC de un servidor gRPC, p. ej. el PeerService de otro nodo
+type Client struct {
+	baseURL  string
+	http     *http.Client
+	Metadata http.Header // Metadatos enviados en cada RPC (authorization, x-node-id...)
+}
+
+// NewClient crea un cliente para host:puerto. Con configuración TLS se conecta por HTTPS
+// (con el certificado del nodo si la configuración lo trae); sin ella, por h2c.
+func NewClient(address string, config *tls.Config) *Client {
+	transport := &http2.Transport{TLSClientConfig: config}
+	scheme := "https"
+	if config == nil {
+		scheme = "http"
+		transport.AllowHTTP = true
+		transport.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+	return &Client{
+		baseURL:  scheme + "://" + address,
+		http:     &http.Client{Transport: transport},
+		Metadata: http.Header{},
+	}
+}
+
+// Call invoca una RPC unaria
+func Call[Resp any](ctx context.Context, c *Client, path string, request interface{}) (*Resp, error) {
+	var response *Resp
+	err := c.invoke(ctx, path, request, func(message []byte) error {
+		if response != nil {
+			return Errorf(Internal, "la RPC unaria respondió más de un mensaje")
+		}
+		response = new(Resp)
+		return Unmarshal(message, response)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, Errorf(Internal, "la RPC unaria no respondió mensaje")
+	}
+	return response, nil
+}
+
+// Watch invoca una RPC con flujo de respuestas y entrega cada mensaje a receive hasta que
+// el flujo termina; si receive falla, el flujo se cancela con ese error
+func Watch[Resp any](ctx context.Context, c *Client, path string, request interface{}, receive func(*Resp) error) error {
+	return c.invoke(ctx, path, request, func(message []byte) error {
+		response := new(Resp)
+		if err := Unmarshal(message, response); err != nil {
+			return err
+		}
+		return receive(response)
+	})
+}
+
+func (c *Client) invoke(ctx context.Context, path string, request interface{}, receive func([]byte) error) error {
+	data, err := Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(appendFrame(nil, data)))
+	if err != nil {
+		return err
+	}
+	for key, values := range c.Metadata {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Te", "trailers")
+	if deadline, ok := ctx.Deadline(); ok {
+		if timeout := time.Until(deadline); timeout > 0 {
+			req.Header.Set("Grpc-Timeout", strconv.FormatInt(timeout.Milliseconds()+1, 10)+"m")
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Errorf(Unavailable, "%v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Errorf(Unknown, "el servidor respondió HTTP %d", resp.StatusCode)
+	}
+
+	for {
+		message, err := readFrame(resp.Body, 0)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			// Un corte por el contexto del cliente se reporta como tal, no como mensaje inválido
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return err
+		}
+		if err := receive(message); err != nil {
+			return err
+		}
+	}
+	// Sin mensajes la respuesta puede traer el estado en los encabezados (trailers-only)
+	header := resp.Trailer
+	if header.Get("Grpc-Status") == "" {
+		header = resp.Header
+	}
+	code, err := strconv.Atoi(header.Get("Grpc-Status"))
+	if err != nil {
+		return Errorf(Internal, "respuesta sin grpc-status")
+	}
+	if code == int(OK) {
+		return nil
+	}
+	return &Error{Code: Code(code), Message: decodeStatusMessage(header.Get("Grpc-Message"))}
+}
@@ -0,0 +1,635 @@
+package grpcapi
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Codificación protobuf (proto3) de los mensajes de api/proto/secop.proto. Los mensajes
+// son structs de Go con el número de cada campo en la etiqueta proto; se cubre lo que usa
+// el proto: string, bool, int32, int64, double, mensajes anidados, campos repetidos,
+// google.protobuf.Timestamp (time.Time) y google.protobuf.Struct (map[string]interface{}).
+// Los punteros a escalares representan los campos optional y oneof: se codifican aunque
+// tengan el valor por defecto.
+
+// Tipos de cable de protobuf
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	structType = reflect.TypeOf(map[string]interface{}{})
+)
+
+// messageField es un campo del struct con su número en el proto
+type messageField struct {
+	index  int
+	number int
+}
+
+// messageFields guarda por tipo los campos leídos de las etiquetas
+var messageFields sync.Map
+
+// fieldsOf retorna los campos con etiqueta proto del tipo de mensaje
+func fieldsOf(t reflect.Type) ([]messageField, error) {
+	if cached, ok := messageFields.Load(t); ok {
+		return cached.([]messageField), nil
+	}
+	var fields []messageField
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("proto")
+		if tag == "" {
+			continue
+		}
+		number, err := strconv.Atoi(tag)
+		if err != nil || number <= 0 {
+			return nil, fmt.Errorf("%s.%s: número de campo inválido %q", t.Name(), t.Field(i).Name, tag)
+		}
+		fields = append(fields, messageField{index: i, number: number})
+	}
+	messageFields.Store(t, fields)
+	return fields, nil
+}
+
+// Marshal codifica un mensaje (puntero a struct)
+func Marshal(message interface{}) ([]byte, error) {
+	value := reflect.ValueOf(message)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("se esperaba un puntero a mensaje, no %T", message)
+	}
+	return appendMessage(nil, value.Elem())
+}
+
+// Unmarshal decodifica data en el mensaje (puntero a struct). Los campos desconocidos se
+// descartan, como en cualquier implementación de proto3.
+func Unmarshal(data []byte, message interface{}) error {
+	value := reflect.ValueOf(message)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("se esperaba un puntero a mensaje, no %T", message)
+	}
+	return decodeMessage(data, value.Elem())
+}
+
+func appendMessage(buf []byte, message reflect.Value) ([]byte, error) {
+	fields, err := fieldsOf(message.Type())
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		if buf, err = appendField(buf, field.number, message.Field(field.index), false); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// appendField codifica un campo; con present se escribe aunque tenga el valor por defecto
+func appendField(buf []byte, number int, value reflect.Value, present bool) ([]byte, error) {
+	switch value.Type() {
+	case timeType:
+		t := value.Interface().(time.Time)
+		if t.IsZero() && !present {
+			return buf, nil
+		}
+		return appendBytes(buf, number, encodeTimestamp(t)), nil
+	case structType:
+		if value.IsNil() {
+			return buf, nil
+		}
+		encoded, err := encodeStruct(value.Interface().(map[string]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(buf, number, encoded), nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		if value.Len() == 0 && !present {
+			return buf, nil
+		}
+		return appendBytes(buf, number, []byte(value.String())), nil
+	case reflect.Bool:
+		if !value.Bool() && !present {
+			return buf, nil
+		}
+		buf = appendTag(buf, number, wireVarint)
+		if value.Bool() {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case reflect.Int32, reflect.Int64:
+		if value.Int() == 0 && !present {
+			return buf, nil
+		}
+		buf = appendTag(buf, number, wireVarint)
+		return binary.AppendUvarint(buf, uint64(value.Int())), nil
+	case reflect.Float64:
+		if value.Float() == 0 && !present {
+			return buf, nil
+		}
+		buf = appendTag(buf, number, wireFixed64)
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(value.Float())), nil
+	case reflect.Ptr:
+		if value.IsNil() {
+			return buf, nil
+		}
+		elem := value.Elem()
+		if elem.Kind() == reflect.Struct && elem.Type() != timeType {
+			encoded, err := appendMessage(nil, elem)
+			if err != nil {
+				return nil, err
+			}
+			return appendBytes(buf, number, encoded), nil
+		}
+		return appendField(buf, number, elem, true)
+	case reflect.Slice:
+		var err error
+		for i := 0; i < value.Len(); i++ {
+			if buf, err = appendField(buf, number, value.Index(i), true); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("tipo de campo no soportado: %s", value.Type())
+}
+
+func appendTag(buf []byte, number, wire int) []byte {
+	return binary.AppendUvarint(buf, uint64(number)<<3|uint64(wire))
+}
+
+func appendBytes(buf []byte, number int, data []byte) []byte {
+	buf = appendTag(buf, number, wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+func appendVarint(buf []byte, number int, value uint64) []byte {
+	buf = appendTag(buf, number, wireVarint)
+	return binary.AppendUvarint(buf, value)
+}
+
+func appendDouble(buf []byte, number int, value float64) []byte {
+	buf = appendTag(buf, number, wireFixed64)
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(value))
+}
+
+// reader recorre los campos de un mensaje codificado
+type reader struct {
+	data []byte
+}
+
+var errTruncated = errors.New("mensaje truncado")
+
+func (r *reader) done() bool {
+	return len(r.data) == 0
+}
+
+func (r *reader) varint() (uint64, error) {
+	value, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	r.data = r.data[n:]
+	return value, nil
+}
+
+func (r *reader) fixed64() (uint64, error) {
+	if len(r.data) < 8 {
+		return 0, errTruncated
+	}
+	value := binary.LittleEndian.Uint64(r.data)
+	r.data = r.data[8:]
+	return value, nil
+}
+
+func (r *reader) bytes() ([]byte, error) {
+	length, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(r.data)) {
+		return nil, errTruncated
+	}
+	value := r.data[:length]
+	r.data = r.data[length:]
+	return value, nil
+}
+
+// tag lee el número y el tipo de cable del siguiente campo
+func (r *reader) tag() (int, int, error) {
+	key, err := r.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	number := int(key >> 3)
+	if number <= 0 {
+		return 0, 0, fmt.Errorf("número de campo inválido %d", number)
+	}
+	return number, int(key & 7), nil
+}
+
+// skip descarta el valor de un campo desconocido
+func (r *reader) skip(wire int) error {
+	var err error
+	switch wire {
+	case wireVarint:
+		_, err = r.varint()
+	case wireFixed64:
+		_, err = r.fixed64()
+	case wireBytes:
+		_, err = r.bytes()
+	case wireFixed32:
+		if len(r.data) < 4 {
+			return errTruncated
+		}
+		r.data = r.data[4:]
+	default:
+		return fmt.Errorf("tipo de cable %d no soportado", wire)
+	}
+	return err
+}
+
+func decodeMessage(data []byte, message reflect.Value) error {
+	fields, err := fieldsOf(message.Type())
+	if err != nil {
+		return err
+	}
+	byNumber := make(map[int]int, len(fields))
+	for _, field := range fields {
+		byNumber[field.number] = field.index
+	}
+
+	r := &reader{data: data}
+	for !r.done() {
+		number, wire, err := r.tag()
+		if err != nil {
+			return err
+		}
+		index, known := byNumber[number]
+		if !known {
+			if err := r.skip(wire); err != nil {
+				return err
+			}
+			continue
+		}
+		field := message.Field(index)
+		if err := decodeField(r, wire, field); err != nil {
+			return fmt.Errorf("%s.%s: %v", message.Type().Name(), message.Type().Field(index).Name, err)
+		}
+	}
+	return nil
+}
+
+// decodeField lee el valor del campo según el tipo del struct
+func decodeField(r *reader, wire int, field reflect.Value) error {
+	expect := func(want int) error {
+		if wire != want {
+			return fmt.Errorf("tipo de cable %d, se esperaba %d", wire, want)
+		}
+		return nil
+	}
+
+	switch field.Type() {
+	case timeType:
+		if err := expect(wireBytes); err != nil {
+			return err
+		}
+		data, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		t, err := decodeTimestamp(data)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	case structType:
+		if err := expect(wireBytes); err != nil {
+			return err
+		}
+		data, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		decoded, err := decodeStruct(data)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(decoded))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		if err := expect(wireBytes); err != nil {
+			return err
+		}
+		data, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		field.SetString(string(data))
+	case reflect.Bool:
+		if err := expect(wireVarint); err != nil {
+			return err
+		}
+		value, err := r.varint()
+		if err != nil {
+			return err
+		}
+		field.SetBool(value != 0)
+	case reflect.Int32, reflect.Int64:
+		if err := expect(wireVarint); err != nil {
+			return err
+		}
+		value, err := r.varint()
+		if err != nil {
+			return err
+		}
+		if field.Kind() == reflect.Int32 {
+			field.SetInt(int64(int32(value)))
+		} else {
+			field.SetInt(int64(value))
+		}
+	case reflect.Float64:
+		if err := expect(wireFixed64); err != nil {
+			return err
+		}
+		value, err := r.fixed64()
+		if err != nil {
+			return err
+		}
+		field.SetFloat(math.Float64frombits(value))
+	case reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+		if elem.Elem().Kind() == reflect.Struct && elem.Elem().Type() != timeType {
+			if err := expect(wireBytes); err != nil {
+				return err
+			}
+			data, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			// Un mensaje repetido en el cable se combina con el anterior
+			if !field.IsNil() {
+				elem = field
+			}
+			if err := decodeMessage(data, elem.Elem()); err != nil {
+				return err
+			}
+		} else if err := decodeField(r, wire, elem.Elem()); err != nil {
+			return err
+		}
+		field.Set(elem)
+	case reflect.Slice:
+		item := reflect.New(field.Type().Elem()).Elem()
+		if err := decodeField(r, wire, item); err != nil {
+			return err
+		}
+		field.Set(reflect.Append(field, item))
+	default:
+		return fmt.Errorf("tipo de campo no soportado: %s", field.Type())
+	}
+	return nil
+}
+
+// encodeTimestamp codifica un google.protobuf.Timestamp {seconds = 1, nanos = 2}
+func encodeTimestamp(t time.Time) []byte {
+	var buf []byte
+	if seconds := t.Unix(); seconds != 0 {
+		buf = appendVarint(buf, 1, uint64(seconds))
+	}
+	if nanos := t.Nanosecond(); nanos != 0 {
+		buf = appendVarint(buf, 2, uint64(nanos))
+	}
+	return buf
+}
+
+func decodeTimestamp(data []byte) (time.Time, error) {
+	var seconds, nanos int64
+	r := &reader{data: data}
+	for !r.done() {
+		number, wire, err := r.tag()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if wire != wireVarint || (number != 1 && number != 2) {
+			if err := r.skip(wire); err != nil {
+				return time.Time{}, err
+			}
+			continue
+		}
+		value, err := r.varint()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if number == 1 {
+			seconds = int64(value)
+		} else {
+			nanos = int64(int32(value))
+		}
+	}
+	return time.Unix(seconds, nanos).UTC(), nil
+}
+
+// encodeStruct codifica un google.protobuf.Struct. El mapa se normaliza primero por JSON,
+// como lo recibiría un cliente REST: los números quedan como double y los structs de Go
+// como objetos.
+func encodeStruct(value map[string]interface{}) ([]byte, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return nil, err
+	}
+	return encodeStructFields(normalized), nil
+}
+
+// encodeStructFields escribe los campos del Struct (map<string, Value> fields = 1) en
+// orden de llave, para que la codificación sea estable
+func encodeStructFields(value map[string]interface{}) []byte {
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf []byte
+	for _, key := range keys {
+		entry := appendBytes(nil, 1, []byte(key))
+		entry = appendBytes(entry, 2, encodeValue(value[key]))
+		buf = appendBytes(buf, 1, entry)
+	}
+	return buf
+}
+
+// encodeValue codifica un google.protobuf.Value normalizado por JSON. Es un oneof, así
+// que el caso elegido se escribe aunque tenga el valor por defecto.
+func encodeValue(value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return appendVarint(nil, 1, 0)
+	case float64:
+		return appendDouble(nil, 2, v)
+	case string:
+		return appendBytes(nil, 3, []byte(v))
+	case bool:
+		if v {
+			return appendVarint(nil, 4, 1)
+		}
+		return appendVarint(nil, 4, 0)
+	case map[string]interface{}:
+		return appendBytes(nil, 5, encodeStructFields(v))
+	case []interface{}:
+		var list []byte
+		for _, item := range v {
+			list = appendBytes(list, 1, encodeValue(item))
+		}
+		return appendBytes(nil, 6, list)
+	}
+	// No ocurre con valores normalizados por JSON
+	return appendVarint(nil, 1, 0)
+}
+
+func decodeStruct(data []byte) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	r := &reader{data: data}
+	for !r.done() {
+		number, wire, err := r.tag()
+		if err != nil {
+			return nil, err
+		}
+		if number != 1 || wire != wireBytes {
+			if err := r.skip(wire); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		entry, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		key, value, err := decodeStructEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+func decodeStructEntry(data []byte) (string, interface{}, error) {
+	var key string
+	var value interface{}
+	r := &reader{data: data}
+	for !r.done() {
+		number, wire, err := r.tag()
+		if err != nil {
+			return "", nil, err
+		}
+		if wire != wireBytes || (number != 1 && number != 2) {
+			if err := r.skip(wire); err != nil {
+				return "", nil, err
+			}
+			continue
+		}
+		field, err := r.bytes()
+		if err != nil {
+			return "", nil, err
+		}
+		if number == 1 {
+			key = string(field)
+		} else if value, err = decodeValue(field); err != nil {
+			return "", nil, err
+		}
+	}
+	return key, value, nil
+}
+
+func decodeValue(data []byte) (interface{}, error) {
+	var value interface{}
+	r := &reader{data: data}
+	for !r.done() {
+		number, wire, err := r.tag()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case number == 1 && wire == wireVarint:
+			_, err = r.varint()
+			value = nil
+		case number == 2 && wire == wireFixed64:
+			var bits uint64
+			bits, err = r.fixed64()
+			value = math.Float64frombits(bits)
+		case number == 4 && wire == wireVarint:
+			var flag uint64
+			flag, err = r.varint()
+			value = flag != 0
+		case (number == 3 || number == 5 || number == 6) && wire == wireBytes:
+			var field []byte
+			if field, err = r.bytes(); err != nil {
+				return nil, err
+			}
+			switch number {
+			case 3:
+				value = string(field)
+			case 5:
+				value, err = decodeStruct(field)
+			case 6:
+				value, err = decodeList(field)
+			}
+		default:
+			err = r.skip(wire)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// decodeList decodifica un google.protobuf.ListValue (repeated Value values = 1)
+func decodeList(data []byte) ([]interface{}, error) {
+	list := []interface{}{}
+	r := &reader{data: data}
+	for !r.done() {
+		number, wire, err := r.tag()
+		if err != nil {
+			return nil, err
+		}
+		if number != 1 || wire != wireBytes {
+			if err := r.skip(wire); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		field, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		item, err := decodeValue(field)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
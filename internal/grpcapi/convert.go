@@ -0,0 +1,238 @@
+package grpcapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"secop-blockchain/pkg/blockchain"
+)
+
+// Conversión entre los tipos de la cadena y los mensajes del proto
+
+// FromBlock convierte un bloque de la cadena
+func FromBlock(block *blockchain.Block) *Block {
+	message := &Block{
+		Index:        int64(block.Index),
+		Timestamp:    block.Timestamp,
+		Data:         block.Data,
+		PreviousHash: block.PreviousHash,
+		Hash:         block.Hash,
+		Nonce:        int64(block.Nonce),
+		Type:         block.Type,
+		Pruned:       block.Pruned,
+		Withheld:     block.Withheld,
+		Signature:    block.Signature,
+		SignerNodeID: block.SignerNodeID,
+		SignerKeyID:  block.SignerKeyID,
+		MerkleRoot:   block.MerkleRoot,
+		HashVersion:  int32(block.HashVersion),
+	}
+	for _, tx := range block.Transactions {
+		message.Transactions = append(message.Transactions, &Transaction{
+			ID:         tx.ID,
+			Type:       tx.Type,
+			ContractID: tx.ContractID,
+			DataHash:   tx.DataHash,
+			Timestamp:  tx.Timestamp,
+		})
+	}
+	return message
+}
+
+// FromBlocks convierte una lista de bloques
+func FromBlocks(blocks []blockchain.Block) []*Block {
+	messages := make([]*Block, len(blocks))
+	for i := range blocks {
+		messages[i] = FromBlock(&blocks[i])
+	}
+	return messages
+}
+
+// ToBlock convierte el mensaje en un bloque de la cadena. Los datos llegan normalizados
+// como los de un bloque recibido por JSON, así que el hash se verifica igual que por REST.
+func (m *Block) ToBlock() blockchain.Block {
+	block := blockchain.Block{
+		Index:        int(m.Index),
+		Timestamp:    m.Timestamp,
+		Data:         m.Data,
+		PreviousHash: m.PreviousHash,
+		Hash:         m.Hash,
+		Nonce:        int(m.Nonce),
+		Type:         m.Type,
+		Pruned:       m.Pruned,
+		Withheld:     m.Withheld,
+		Signature:    m.Signature,
+		SignerNodeID: m.SignerNodeID,
+		SignerKeyID:  m.SignerKeyID,
+		MerkleRoot:   m.MerkleRoot,
+		HashVersion:  int(m.HashVersion),
+	}
+	for _, tx := range m.Transactions {
+		block.Transactions = append(block.Transactions, blockchain.Transaction{
+			ID:         tx.ID,
+			Type:       tx.Type,
+			ContractID: tx.ContractID,
+			DataHash:   tx.DataHash,
+			Timestamp:  tx.Timestamp,
+		})
+	}
+	return block
+}
+
+// FromWorkflowStep convierte un paso del flujo del sistema
+func FromWorkflowStep(step blockchain.WorkflowStep) *WorkflowStep {
+	return &WorkflowStep{
+		StepNumber: int32(step.StepNumber),
+		Role:       string(step.Role),
+		Name:       step.Name,
+		Required:   step.Required,
+	}
+}
+
+// FromValidationSteps convierte los pasos del flujo de un contrato
+func FromValidationSteps(steps []blockchain.ValidationStep) []*ValidationStep {
+	messages := make([]*ValidationStep, len(steps))
+	for i, step := range steps {
+		messages[i] = &ValidationStep{
+			StepNumber:     int32(step.StepNumber),
+			Role:           string(step.Role),
+			ValidatorID:    step.ValidatorID,
+			ValidatorName:  step.ValidatorName,
+			Status:         string(step.Status),
+			Timestamp:      step.Timestamp,
+			Comments:       step.Comments,
+			Required:       step.Required,
+			DigitalSign:    step.DigitalSign,
+			SignatureKeyID: step.SignatureKeyID,
+			SignedAt:       step.SignedAt,
+			AutoApproved:   step.AutoApproved,
+			RuleReference:  step.RuleReference,
+			ActRequired:    step.ActRequired,
+			Documents:      step.Documents,
+		}
+		if step.Act != nil {
+			messages[i].Act = &StepAct{Hash: step.Act.Hash, Reference: step.Act.Reference}
+		}
+	}
+	return messages
+}
+
+// FromAuditTrail convierte la línea de auditoría de un contrato
+func FromAuditTrail(entries []blockchain.AuditEntry) []*AuditEntry {
+	messages := make([]*AuditEntry, len(entries))
+	for i, entry := range entries {
+		messages[i] = &AuditEntry{
+			ID:          entry.ID,
+			Action:      entry.Action,
+			UserID:      entry.UserID,
+			UserRole:    string(entry.UserRole),
+			Timestamp:   entry.Timestamp,
+			Description: entry.Description,
+			IPAddress:   entry.IPAddress,
+			BlockHash:   entry.BlockHash,
+		}
+	}
+	return messages
+}
+
+// FromAuditObservation convierte una observación de auditoría
+func FromAuditObservation(observation *blockchain.AuditObservation) *AuditObservation {
+	return &AuditObservation{
+		ID:          observation.ID,
+		AuditorID:   observation.AuditorID,
+		Role:        string(observation.Role),
+		Observation: observation.Observation,
+		FiledAt:     observation.FiledAt,
+		DueAt:       observation.DueAt,
+		Response:    observation.Response,
+		RespondedBy: observation.RespondedBy,
+		RespondedAt: observation.RespondedAt,
+		EscalatedAt: observation.EscalatedAt,
+	}
+}
+
+// FromPayment convierte un pago del contrato
+func FromPayment(payment *blockchain.ContractPayment) *ContractPayment {
+	return &ContractPayment{
+		ID:           payment.ID,
+		Amount:       payment.Amount,
+		Reference:    payment.Reference,
+		PaidAt:       payment.PaidAt,
+		RegisteredBy: payment.RegisteredBy,
+		BlockHash:    payment.BlockHash,
+		MilestoneID:  payment.MilestoneID,
+	}
+}
+
+// FromContract convierte un contrato; lo que el mensaje no tipa (calendario, preguntas al
+// pliego, consorcio, evidencias, hitos, modificaciones...) viaja en Extra
+func FromContract(contract *blockchain.Contract) (*Contract, error) {
+	message := &Contract{
+		ID:              contract.ID,
+		EntityCode:      contract.EntityCode,
+		EntityName:      contract.EntityName,
+		ContractType:    contract.ContractType,
+		Description:     contract.Description,
+		Amount:          contract.Amount,
+		Status:          string(contract.Status),
+		CreatedBy:       contract.CreatedBy,
+		CreatedAt:       contract.CreatedAt,
+		UpdatedAt:       contract.UpdatedAt,
+		ValidationSteps: FromValidationSteps(contract.ValidationSteps),
+		CurrentStep:     int32(contract.CurrentStep),
+		RequiredRoles:   contract.RequiredRoles,
+		AuditTrail:      FromAuditTrail(contract.AuditTrail),
+		AwardedTo:       contract.AwardedTo,
+		OriginSystem:    contract.OriginSystem,
+		Reserved:        contract.Reserved,
+		TemplateVersion: int32(contract.TemplateVersion),
+		ProcessNumber:   contract.ProcessNumber,
+		Sequence:        int64(contract.Sequence),
+	}
+	for i := range contract.Observations {
+		message.Observations = append(message.Observations, FromAuditObservation(&contract.Observations[i]))
+	}
+	for i := range contract.Payments {
+		message.Payments = append(message.Payments, FromPayment(&contract.Payments[i]))
+	}
+	for _, carryover := range contract.Carryovers {
+		message.Carryovers = append(message.Carryovers, &Carryover{
+			FiscalYear:    int32(carryover.FiscalYear),
+			Kind:          string(carryover.Kind),
+			Amount:        carryover.Amount,
+			Justification: carryover.Justification,
+			MarkedBy:      carryover.MarkedBy,
+			MarkedAt:      carryover.MarkedAt,
+			BlockHash:     carryover.BlockHash,
+		})
+	}
+
+	encoded, err := json.Marshal(contract)
+	if err != nil {
+		return nil, err
+	}
+	var extra map[string]interface{}
+	if err := json.Unmarshal(encoded, &extra); err != nil {
+		return nil, err
+	}
+	for _, key := range typedContractKeys() {
+		delete(extra, key)
+	}
+	if len(extra) > 0 {
+		message.Extra = extra
+	}
+	return message, nil
+}
+
+// typedContractKeys retorna las llaves JSON del contrato que el mensaje tipa
+func typedContractKeys() []string {
+	t := reflect.TypeOf(Contract{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,246 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TestWellKnownTypesMatchProtobuf compara la codificación de Timestamp y Struct con la de
+// la implementación oficial de protobuf
+func TestWellKnownTypesMatchProtobuf(t *testing.T) {
+	instant := time.Date(2026, 3, 14, 15, 9, 26, 535897932, time.UTC)
+	data := map[string]interface{}{
+		"contract_id": "c-1",
+		"amount":      1500000.5,
+		"approved":    true,
+		"reason":      nil,
+		"steps":       []interface{}{1.0, "dos", false},
+		"act":         map[string]interface{}{"hash": "abc", "reference": ""},
+	}
+
+	tests := []struct {
+		name     string
+		ours     func() ([]byte, error)
+		official proto.Message
+	}{
+		{"timestamp", func() ([]byte, error) { return encodeTimestamp(instant), nil }, timestamppb.New(instant)},
+		{"timestamp en cero segundos", func() ([]byte, error) { return encodeTimestamp(time.Unix(0, 0)), nil }, timestamppb.New(time.Unix(0, 0))},
+		{"struct", func() ([]byte, error) { return encodeStruct(data) }, mustStruct(t, data)},
+		{"struct vacío", func() ([]byte, error) { return encodeStruct(map[string]interface{}{}) }, mustStruct(t, map[string]interface{}{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ours, err := tt.ours()
+			if err != nil {
+				t.Fatal(err)
+			}
+			official, err := proto.MarshalOptions{Deterministic: true}.Marshal(tt.official)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(ours, official) {
+				t.Fatalf("codificación distinta:\n nuestra: %x\n oficial: %x", ours, official)
+			}
+		})
+	}
+}
+
+func mustStruct(t *testing.T, data map[string]interface{}) *structpb.Struct {
+	s, err := structpb.NewStruct(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestMarshalWireFormat(t *testing.T) {
+	height := int64(0)
+	tests := []struct {
+		name    string
+		message interface{}
+		want    []byte
+	}{
+		// step_number = 1 (varint), role = 2 ("A"), name vacío omitido, required = 4
+		{"escalares", &WorkflowStep{StepNumber: 1, Role: "A", Required: true}, []byte{0x08, 0x01, 0x12, 0x01, 'A', 0x20, 0x01}},
+		{"valores por defecto omitidos", &WorkflowStep{}, nil},
+		// Un int32 negativo ocupa 10 bytes, como en cualquier implementación de proto3
+		{"int32 negativo", &WorkflowStep{StepNumber: -1}, []byte{0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}},
+		// El caso de un oneof se codifica aunque tenga el valor por defecto
+		{"oneof en cero", &GetBlockRequest{Height: &height}, []byte{0x10, 0x00}},
+		{"mensaje anidado vacío", &ValidateStepRequest{Act: &StepAct{}}, []byte{0x42, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("Marshal = %x, se esperaba %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	signedAt := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	minAmount := 0.0
+	tests := []struct {
+		name    string
+		message interface{}
+		empty   interface{}
+	}{
+		{
+			name: "bloque",
+			message: &Block{
+				Index:        42,
+				Timestamp:    time.Unix(1767225600, 0).UTC(),
+				Data:         map[string]interface{}{"type": "VALIDATION", "step": 3.0, "approved": false, "documents": []interface{}{}},
+				PreviousHash: "abc",
+				Hash:         "def",
+				Type:         "VALIDATION",
+				Transactions: []*Transaction{{ID: "tx-1", Type: "VALIDATION", DataHash: "01"}, {ID: "tx-2"}},
+				HashVersion:  3,
+			},
+			empty: &Block{},
+		},
+		{
+			name: "contrato",
+			message: &Contract{
+				ID:              "c-1",
+				Amount:          1e9,
+				ValidationSteps: []*ValidationStep{{StepNumber: 1, SignedAt: &signedAt, Act: &StepAct{Hash: "h"}, Documents: []string{"a", "b"}}},
+				RequiredRoles:   []string{"PROJECT_DEVELOPER"},
+				Sequence:        7,
+				Extra:           map[string]interface{}{"questions": []interface{}{map[string]interface{}{"id": "q-1"}}},
+			},
+			empty: &Contract{},
+		},
+		{
+			name:    "optional presente en cero",
+			message: &ListContractsRequest{MinAmount: &minAmount, Status: "DRAFT"},
+			empty:   &ListContractsRequest{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Marshal(tt.message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Unmarshal(data, tt.empty); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.empty, tt.message) {
+				t.Fatalf("ida y vuelta distinta:\n %+v\n %+v", tt.empty, tt.message)
+			}
+		})
+	}
+}
+
+func TestUnmarshalSkipsUnknownFields(t *testing.T) {
+	// Campo 99 (desconocido) antes de role = 2
+	data := []byte{0x98, 0x06, 0x01, 0x12, 0x01, 'A'}
+	var step WorkflowStep
+	if err := Unmarshal(data, &step); err != nil {
+		t.Fatal(err)
+	}
+	if step.Role != "A" {
+		t.Fatalf("role = %q", step.Role)
+	}
+	if err := Unmarshal([]byte{0x12, 0x05, 'A'}, &step); err == nil {
+		t.Fatal("un mensaje truncado debe fallar")
+	}
+}
+
+// newTestServer atiende el servidor por h2c, como ListenAndServe sin TLS
+func newTestServer(t *testing.T, server *Server) *Client {
+	httpServer := httptest.NewUnstartedServer(h2c.NewHandler(server, &http2.Server{}))
+	httpServer.Start()
+	t.Cleanup(httpServer.Close)
+	return NewClient(strings.TrimPrefix(httpServer.URL, "http://"), nil)
+}
+
+func TestServerRPCs(t *testing.T) {
+	server := NewServer()
+	Unary(server, "/test.Service/Get", func(ctx context.Context, req *GetContractRequest) (*Contract, error) {
+		if req.ContractID == "" {
+			return nil, Errorf(InvalidArgument, "contrato requerido: año %d, ñandú 100%%", 2026)
+		}
+		return &Contract{ID: req.ContractID, CreatedBy: Request(ctx).Header.Get("X-User")}, nil
+	})
+	ServerStream(server, "/test.Service/Watch", func(ctx context.Context, req *WatchBlocksRequest, send func(*Block) error) error {
+		for i := int64(1); i <= 3; i++ {
+			if err := send(&Block{Index: *req.AfterHeight + i}); err != nil {
+				return err
+			}
+		}
+		return Errorf(FailedPrecondition, "la cadena fue reemplazada")
+	})
+	client := newTestServer(t, server)
+	client.Metadata.Set("X-User", "funcionario@entidad.gov.co")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("unaria", func(t *testing.T) {
+		contract, err := Call[Contract](ctx, client, "/test.Service/Get", &GetContractRequest{ContractID: "c-1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contract.ID != "c-1" || contract.CreatedBy != "funcionario@entidad.gov.co" {
+			t.Fatalf("respuesta inesperada: %+v", contract)
+		}
+	})
+
+	statusTests := []struct {
+		name    string
+		path    string
+		code    Code
+		message string
+	}{
+		{"error del handler", "/test.Service/Get", InvalidArgument, "contrato requerido: año 2026, ñandú 100%"},
+		{"método desconocido", "/test.Service/Missing", Unimplemented, "método /test.Service/Missing no implementado"},
+	}
+	for _, tt := range statusTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Call[Contract](ctx, client, tt.path, &GetContractRequest{})
+			var status *Error
+			if !errors.As(err, &status) {
+				t.Fatalf("se esperaba un estado gRPC, no %v", err)
+			}
+			if status.Code != tt.code || status.Message != tt.message {
+				t.Fatalf("estado = %d %q, se esperaba %d %q", status.Code, status.Message, tt.code, tt.message)
+			}
+		})
+	}
+
+	t.Run("flujo", func(t *testing.T) {
+		after := int64(10)
+		var heights []int64
+		err := Watch(ctx, client, "/test.Service/Watch", &WatchBlocksRequest{AfterHeight: &after}, func(block *Block) error {
+			heights = append(heights, block.Index)
+			return nil
+		})
+		if !reflect.DeepEqual(heights, []int64{11, 12, 13}) {
+			t.Fatalf("bloques recibidos = %v", heights)
+		}
+		if status := StatusOf(err); status.Code != FailedPrecondition {
+			t.Fatalf("el flujo debía terminar con FAILED_PRECONDITION, no %v", err)
+		}
+	})
+}
@@ -0,0 +1,310 @@
+package grpcapi
+
+import "time"
+
+// Mensajes de api/proto/secop.proto. La etiqueta proto es el número del campo; los campos
+// optional y oneof son punteros.
+
+// Empty es google.protobuf.Empty
+type Empty struct{}
+
+// Transaction es la transacción de un lote, hoja del árbol de Merkle del bloque
+type Transaction struct {
+	ID         string    `proto:"1"`
+	Type       string    `proto:"2"`
+	ContractID string    `proto:"3"`
+	DataHash   string    `proto:"4"`
+	Timestamp  time.Time `proto:"5"`
+}
+
+// Block es un bloque de la cadena
+type Block struct {
+	Index        int64                  `proto:"1"`
+	Timestamp    time.Time              `proto:"2"`
+	Data         map[string]interface{} `proto:"3"`
+	PreviousHash string                 `proto:"4"`
+	Hash         string                 `proto:"5"`
+	Nonce        int64                  `proto:"6"`
+	Type         string                 `proto:"7"`
+	Pruned       bool                   `proto:"8"`
+	Withheld     bool                   `proto:"9"`
+	Signature    string                 `proto:"10"`
+	SignerNodeID string                 `proto:"11"`
+	SignerKeyID  string                 `proto:"12"`
+	MerkleRoot   string                 `proto:"13"`
+	Transactions []*Transaction         `proto:"14"`
+	HashVersion  int32                  `proto:"15"`
+}
+
+// WorkflowStep es un paso del flujo de validación definido por el sistema
+type WorkflowStep struct {
+	StepNumber int32  `proto:"1"`
+	Role       string `proto:"2"`
+	Name       string `proto:"3"`
+	Required   bool   `proto:"4"`
+}
+
+// StepAct es el acta del comité que respalda una decisión
+type StepAct struct {
+	Hash      string `proto:"1"`
+	Reference string `proto:"2"`
+}
+
+// ValidationStep es un paso del flujo de un contrato, con su decisión
+type ValidationStep struct {
+	StepNumber     int32      `proto:"1"`
+	Role           string     `proto:"2"`
+	ValidatorID    string     `proto:"3"`
+	ValidatorName  string     `proto:"4"`
+	Status         string     `proto:"5"`
+	Timestamp      time.Time  `proto:"6"`
+	Comments       string     `proto:"7"`
+	Required       bool       `proto:"8"`
+	DigitalSign    string     `proto:"9"`
+	SignatureKeyID string     `proto:"10"`
+	SignedAt       *time.Time `proto:"11"`
+	AutoApproved   bool       `proto:"12"`
+	RuleReference  string     `proto:"13"`
+	ActRequired    bool       `proto:"14"`
+	Act            *StepAct   `proto:"15"`
+	Documents      []string   `proto:"16"`
+}
+
+// AuditEntry es una entrada de la línea de auditoría de un contrato
+type AuditEntry struct {
+	ID          string    `proto:"1"`
+	Action      string    `proto:"2"`
+	UserID      string    `proto:"3"`
+	UserRole    string    `proto:"4"`
+	Timestamp   time.Time `proto:"5"`
+	Description string    `proto:"6"`
+	IPAddress   string    `proto:"7"`
+	BlockHash   string    `proto:"8"`
+}
+
+// AuditObservation es una observación de un ente de control y su respuesta
+type AuditObservation struct {
+	ID          string     `proto:"1"`
+	AuditorID   string     `proto:"2"`
+	Role        string     `proto:"3"`
+	Observation string     `proto:"4"`
+	FiledAt     time.Time  `proto:"5"`
+	DueAt       time.Time  `proto:"6"`
+	Response    string     `proto:"7"`
+	RespondedBy string     `proto:"8"`
+	RespondedAt *time.Time `proto:"9"`
+	EscalatedAt *time.Time `proto:"10"`
+}
+
+// ContractPayment es un pago registrado contra el contrato
+type ContractPayment struct {
+	ID           string    `proto:"1"`
+	Amount       float64   `proto:"2"`
+	Reference    string    `proto:"3"`
+	PaidAt       time.Time `proto:"4"`
+	RegisteredBy string    `proto:"5"`
+	BlockHash    string    `proto:"6"`
+	MilestoneID  string    `proto:"7"`
+}
+
+// Carryover es un saldo constituido al cierre de una vigencia
+type Carryover struct {
+	FiscalYear    int32     `proto:"1"`
+	Kind          string    `proto:"2"`
+	Amount        float64   `proto:"3"`
+	Justification string    `proto:"4"`
+	MarkedBy      string    `proto:"5"`
+	MarkedAt      time.Time `proto:"6"`
+	BlockHash     string    `proto:"7"`
+}
+
+// Contract es un contrato con su flujo. La etiqueta json de cada campo es la llave de la
+// API REST que cubre; las demás llaves viajan en Extra con la misma forma JSON.
+type Contract struct {
+	ID              string                 `proto:"1" json:"id"`
+	EntityCode      string                 `proto:"2" json:"entity_code"`
+	EntityName      string                 `proto:"3" json:"entity_name"`
+	ContractType    string                 `proto:"4" json:"contract_type"`
+	Description     string                 `proto:"5" json:"description"`
+	Amount          float64                `proto:"6" json:"amount"`
+	Status          string                 `proto:"7" json:"status"`
+	CreatedBy       string                 `proto:"8" json:"created_by"`
+	CreatedAt       time.Time              `proto:"9" json:"created_at"`
+	UpdatedAt       time.Time              `proto:"10" json:"updated_at"`
+	ValidationSteps []*ValidationStep      `proto:"11" json:"validation_steps"`
+	CurrentStep     int32                  `proto:"12" json:"current_step"`
+	RequiredRoles   []string               `proto:"13" json:"required_roles"`
+	AuditTrail      []*AuditEntry          `proto:"14" json:"audit_trail"`
+	Observations    []*AuditObservation    `proto:"15" json:"observations"`
+	AwardedTo       string                 `proto:"16" json:"awarded_to"`
+	OriginSystem    string                 `proto:"17" json:"origin_system"`
+	Reserved        bool                   `proto:"18" json:"reserved"`
+	TemplateVersion int32                  `proto:"19" json:"template_version"`
+	ProcessNumber   string                 `proto:"20" json:"process_number"`
+	Sequence        int64                  `proto:"21" json:"sequence"`
+	Payments        []*ContractPayment     `proto:"22" json:"payments"`
+	Carryovers      []*Carryover           `proto:"23" json:"carryovers"`
+	Extra           map[string]interface{} `proto:"24" json:"-"`
+}
+
+// LoginRequest son las credenciales de un funcionario
+type LoginRequest struct {
+	UserID   string `proto:"1"`
+	Password string `proto:"2"`
+}
+
+// LoginResponse es la sesión emitida al funcionario
+type LoginResponse struct {
+	Token     string    `proto:"1"`
+	ExpiresAt time.Time `proto:"2"`
+	Role      string    `proto:"3"`
+}
+
+// ListBlocksRequest pide bloques completos desde la altura Offset
+type ListBlocksRequest struct {
+	Limit  int32 `proto:"1"`
+	Offset int32 `proto:"2"`
+}
+
+// ListBlocksResponse es una página de bloques y la altura de la cadena
+type ListBlocksResponse struct {
+	Blocks []*Block `proto:"1"`
+	Height int64    `proto:"2"`
+}
+
+// GetBlockRequest busca un bloque por hash o por altura (oneof selector)
+type GetBlockRequest struct {
+	Hash   *string `proto:"1"`
+	Height *int64  `proto:"2"`
+}
+
+// WatchBlocksRequest pide los bloques posteriores a AfterHeight; sin él, solo los nuevos
+type WatchBlocksRequest struct {
+	AfterHeight *int64 `proto:"1"`
+}
+
+// ListContractsRequest son los filtros de GET /api/contracts
+type ListContractsRequest struct {
+	EntityCode   string    `proto:"1"`
+	Status       string    `proto:"2"`
+	From         time.Time `proto:"3"`
+	To           time.Time `proto:"4"`
+	MinAmount    *float64  `proto:"5"`
+	MaxAmount    *float64  `proto:"6"`
+	Limit        int32     `proto:"7"`
+	Offset       int32     `proto:"8"`
+	ContractType string    `proto:"9"`
+	Sort         string    `proto:"10"`
+	Ascending    bool      `proto:"11"`
+}
+
+// ListContractsResponse son los contratos que cumplen los filtros
+type ListContractsResponse struct {
+	Contracts []*Contract `proto:"1"`
+	Total     int64       `proto:"2"`
+}
+
+// GetContractRequest identifica un contrato
+type GetContractRequest struct {
+	ContractID string `proto:"1"`
+}
+
+// CreateContractRequest son los datos con que se radica un contrato. Como en REST, el
+// metadato idempotency-key evita radicarlo dos veces en los reintentos.
+type CreateContractRequest struct {
+	EntityCode    string  `proto:"1"`
+	EntityName    string  `proto:"2"`
+	ContractType  string  `proto:"3"`
+	Description   string  `proto:"4"`
+	Amount        float64 `proto:"5"`
+	ProcessNumber string  `proto:"6"`
+	Reserved      bool    `proto:"7"`
+}
+
+// CreateContractResponse identifica el contrato radicado; Replayed indica que la
+// petición ya se había procesado con la misma llave de idempotencia
+type CreateContractResponse struct {
+	ContractID string `proto:"1"`
+	Replayed   bool   `proto:"2"`
+}
+
+// WorkflowStepsResponse son los pasos del flujo del sistema
+type WorkflowStepsResponse struct {
+	Steps []*WorkflowStep `proto:"1"`
+}
+
+// WorkflowStatus es el avance del flujo de un contrato
+type WorkflowStatus struct {
+	ContractID      string            `proto:"1"`
+	Status          string            `proto:"2"`
+	CurrentStep     int32             `proto:"3"`
+	TotalSteps      int32             `proto:"4"`
+	CompletedSteps  int32             `proto:"5"`
+	Progress        float64           `proto:"6"`
+	ValidationSteps []*ValidationStep `proto:"7"`
+	AuditTrail      []*AuditEntry     `proto:"8"`
+	CreatedAt       time.Time         `proto:"9"`
+	UpdatedAt       time.Time         `proto:"10"`
+}
+
+// ValidateStepRequest es la decisión de un validador sobre un paso del flujo
+type ValidateStepRequest struct {
+	ContractID string    `proto:"1"`
+	StepNumber int32     `proto:"2"`
+	Approved   bool      `proto:"3"`
+	Comments   string    `proto:"4"`
+	Signature  string    `proto:"5"`
+	KeyID      string    `proto:"6"`
+	SignedAt   time.Time `proto:"7"`
+	Act        *StepAct  `proto:"8"`
+}
+
+// AddObservationRequest es la observación de un ente de control
+type AddObservationRequest struct {
+	ContractID  string `proto:"1"`
+	Observation string `proto:"2"`
+}
+
+// RespondObservationRequest es la respuesta de la entidad a una observación
+type RespondObservationRequest struct {
+	ContractID    string `proto:"1"`
+	ObservationID string `proto:"2"`
+	Response      string `proto:"3"`
+}
+
+// RegisterPaymentRequest es un pago contra el contrato
+type RegisterPaymentRequest struct {
+	ContractID string    `proto:"1"`
+	Amount     float64   `proto:"2"`
+	Reference  string    `proto:"3"`
+	PaidAt     time.Time `proto:"4"`
+}
+
+// HealthResponse es el estado del nodo
+type HealthResponse struct {
+	Status    string `proto:"1"`
+	NodeID    string `proto:"2"`
+	Blocks    int64  `proto:"3"`
+	Contracts int64  `proto:"4"`
+}
+
+// BlocksPageRequest pide una página de bloques desde una altura
+type BlocksPageRequest struct {
+	From  int64 `proto:"1"`
+	Limit int32 `proto:"2"`
+}
+
+// BlocksPage es una página de bloques para la sincronización incremental
+type BlocksPage struct {
+	Blocks     []*Block `proto:"1"`
+	FromHeight int64    `proto:"2"`
+	Height     int64    `proto:"3"`
+	HasMore    bool     `proto:"4"`
+	NodeID     string   `proto:"5"`
+}
+
+// ReceiveBlockResponse confirma que el bloque enviado por un peer se procesó; si se
+// rechaza, la RPC termina con INVALID_ARGUMENT
+type ReceiveBlockResponse struct {
+	Accepted bool `proto:"1"`
+}
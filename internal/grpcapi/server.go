@@ -0,0 +1,231 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Servidor gRPC sobre HTTP/2. Implementa el protocolo de gRPC (tramas de 5 bytes,
+// grpc-status y grpc-message en los trailers, grpc-timeout) para las RPC unarias y las de
+// flujo desde el servidor, que son las únicas que define api/proto/secop.proto.
+
+// DefaultMaxMessageSize es el tamaño máximo de un mensaje recibido, el mismo de grpc-go
+const DefaultMaxMessageSize = 4 << 20
+
+// Tipo de contenido de las RPC
+const contentType = "application/grpc"
+
+// method atiende una RPC: decodifica la petición y envía las respuestas con send
+type method func(ctx context.Context, request []byte, send func(interface{}) error) error
+
+// Server enruta las RPC por su ruta /paquete.Servicio/Método
+type Server struct {
+	MaxMessageSize int
+	methods        map[string]method
+}
+
+// NewServer crea un servidor sin RPC registradas
+func NewServer() *Server {
+	return &Server{
+		MaxMessageSize: DefaultMaxMessageSize,
+		methods:        make(map[string]method),
+	}
+}
+
+// Unary registra una RPC unaria, p. ej. "/secop.v1.SecopService/GetContract"
+func Unary[Req, Resp any](s *Server, path string, handler func(context.Context, *Req) (*Resp, error)) {
+	s.methods[path] = func(ctx context.Context, request []byte, send func(interface{}) error) error {
+		req := new(Req)
+		if err := Unmarshal(request, req); err != nil {
+			return Errorf(InvalidArgument, "mensaje inválido: %v", err)
+		}
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return err
+		}
+		return send(resp)
+	}
+}
+
+// ServerStream registra una RPC con flujo de respuestas; el handler envía cada mensaje
+// con send y el flujo termina cuando retorna
+func ServerStream[Req, Resp any](s *Server, path string, handler func(context.Context, *Req, func(*Resp) error) error) {
+	s.methods[path] = func(ctx context.Context, request []byte, send func(interface{}) error) error {
+		req := new(Req)
+		if err := Unmarshal(request, req); err != nil {
+			return Errorf(InvalidArgument, "mensaje inválido: %v", err)
+		}
+		return handler(ctx, req, func(resp *Resp) error {
+			return send(resp)
+		})
+	}
+}
+
+// requestKey es la llave del contexto donde queda la petición HTTP/2 de la RPC
+type requestKey struct{}
+
+// Request retorna la petición HTTP/2 de la RPC en curso: sus encabezados son los metadatos
+// de gRPC (authorization, x-api-key, x-node-id) y su TLS trae el certificado del cliente
+func Request(ctx context.Context) *http.Request {
+	req, _ := ctx.Value(requestKey{}).(*http.Request)
+	return req
+}
+
+// ServeHTTP atiende una RPC
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "gRPC requiere POST", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.ProtoMajor != 2 {
+		http.Error(w, "gRPC requiere HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), contentType) {
+		http.Error(w, "tipo de contenido no soportado", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Trailer", "Grpc-Status")
+	w.Header().Add("Trailer", "Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+
+	err := s.serve(w, r)
+	status := StatusOf(err)
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(status.Code)))
+	if status.Message != "" {
+		w.Header().Set("Grpc-Message", encodeStatusMessage(status.Message))
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) error {
+	handler, exists := s.methods[r.URL.Path]
+	if !exists {
+		return Errorf(Unimplemented, "método %s no implementado", r.URL.Path)
+	}
+	if encoding := r.Header.Get("Grpc-Encoding"); encoding != "" && encoding != "identity" {
+		return Errorf(Unimplemented, "compresión %s no soportada", encoding)
+	}
+
+	ctx := context.WithValue(r.Context(), requestKey{}, r)
+	if value := r.Header.Get("Grpc-Timeout"); value != "" {
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			return Errorf(InvalidArgument, "grpc-timeout inválido: %s", value)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	request, err := readFrame(r.Body, s.MaxMessageSize)
+	if errors.Is(err, io.EOF) {
+		return Errorf(InvalidArgument, "la RPC no trae mensaje")
+	}
+	if err != nil {
+		return err
+	}
+
+	flusher, _ := w.(http.Flusher)
+	return handler(ctx, request, func(message interface{}) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := Marshal(message)
+		if err != nil {
+			return Errorf(Internal, "respuesta inválida: %v", err)
+		}
+		if _, err := w.Write(appendFrame(nil, data)); err != nil {
+			return Errorf(Unavailable, "el cliente cerró la conexión: %v", err)
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+}
+
+// appendFrame antepone al mensaje la cabecera de gRPC: bandera de compresión y longitud
+func appendFrame(buf []byte, message []byte) []byte {
+	buf = append(buf, 0)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(message)))
+	return append(buf, message...)
+}
+
+// readFrame lee un mensaje con su cabecera de gRPC; retorna io.EOF si el cuerpo terminó
+// antes del mensaje
+func readFrame(body io.Reader, maxSize int) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(body, header[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, Errorf(InvalidArgument, "cabecera de mensaje incompleta: %v", err)
+	}
+	if header[0] != 0 {
+		return nil, Errorf(Unimplemented, "mensajes comprimidos no soportados")
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if maxSize > 0 && int64(length) > int64(maxSize) {
+		return nil, Errorf(ResourceExhausted, "mensaje de %d bytes supera el máximo de %d", length, maxSize)
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(body, message); err != nil {
+		return nil, Errorf(InvalidArgument, "mensaje incompleto: %v", err)
+	}
+	return message, nil
+}
+
+// parseTimeout lee grpc-timeout: hasta 8 dígitos y la unidad (H, M, S, m, u o n)
+func parseTimeout(value string) (time.Duration, error) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, errors.New("longitud inválida")
+	}
+	amount, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if err != nil || amount < 0 {
+		return 0, errors.New("cantidad inválida")
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return 0, errors.New("unidad inválida")
+	}
+	return time.Duration(amount) * unit, nil
+}
+
+// ListenAndServe atiende en la dirección. Con configuración TLS negocia HTTP/2 por ALPN;
+// sin ella atiende HTTP/2 en claro (h2c), que es lo que usan los clientes gRPC con
+// credenciales inseguras.
+func (s *Server) ListenAndServe(address string, config *tls.Config) error {
+	if config == nil {
+		return http.ListenAndServe(address, h2c.NewHandler(s, &http2.Server{}))
+	}
+
+	server := &http.Server{
+		Addr:      address,
+		Handler:   s,
+		TLSConfig: config.Clone(),
+	}
+	if err := http2.ConfigureServer(server, &http2.Server{}); err != nil {
+		return err
+	}
+	return server.ListenAndServeTLS("", "")
+}
@@ -0,0 +1,98 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Code es el código de estado de una RPC (grpc-status)
+type Code uint32
+
+// Códigos de estado de gRPC
+const (
+	OK Code = iota
+	Canceled
+	Unknown
+	InvalidArgument
+	DeadlineExceeded
+	NotFound
+	AlreadyExists
+	PermissionDenied
+	ResourceExhausted
+	FailedPrecondition
+	Aborted
+	OutOfRange
+	Unimplemented
+	Internal
+	Unavailable
+	DataLoss
+	Unauthenticated
+)
+
+// Error es el error con el que termina una RPC
+type Error struct {
+	Code    Code
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("grpc %d: %s", e.Code, e.Message)
+}
+
+// Errorf crea el error de una RPC con su código de estado
+func Errorf(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// StatusOf retorna el estado con el que termina la RPC que falló con err. Los errores sin
+// código se reportan como Unknown, salvo la cancelación y el vencimiento del contexto.
+func StatusOf(err error) *Error {
+	if err == nil {
+		return &Error{Code: OK}
+	}
+	var status *Error
+	if errors.As(err, &status) {
+		return status
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return &Error{Code: Canceled, Message: err.Error()}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{Code: DeadlineExceeded, Message: err.Error()}
+	}
+	return &Error{Code: Unknown, Message: err.Error()}
+}
+
+// encodeStatusMessage aplica a grpc-message la codificación por porcentaje del protocolo:
+// todo byte fuera de ASCII imprimible, y el propio %, viaja como %XX
+func encodeStatusMessage(message string) string {
+	var builder strings.Builder
+	for i := 0; i < len(message); i++ {
+		b := message[i]
+		if b < 0x20 || b > 0x7e || b == '%' {
+			fmt.Fprintf(&builder, "%%%02X", b)
+			continue
+		}
+		builder.WriteByte(b)
+	}
+	return builder.String()
+}
+
+// decodeStatusMessage revierte encodeStatusMessage; las secuencias inválidas se conservan
+func decodeStatusMessage(message string) string {
+	var decoded []byte
+	for i := 0; i < len(message); i++ {
+		if message[i] == '%' && i+2 < len(message) {
+			var b byte
+			if _, err := fmt.Sscanf(message[i+1:i+3], "%02X", &b); err == nil {
+				decoded = append(decoded, b)
+				i += 2
+				continue
+			}
+		}
+		decoded = append(decoded, message[i])
+	}
+	return string(decoded)
+}
@@ -0,0 +1,283 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Mapa de calor de la actividad de validación: cuándo aprueban y rechazan pasos los
+// funcionarios, agrupado por hora o día y por rol o entidad, para que los entes de
+// control detecten patrones sospechosos como aprobaciones de madrugada o ráfagas de
+// decisiones justo antes de un plazo.
+
+// Tamaños de intervalo y agrupaciones del mapa de calor
+const (
+	ActivityBucketHour  = "hour"
+	ActivityBucketDay   = "day"
+	ActivityGroupRole   = "role"
+	ActivityGroupEntity = "entity"
+)
+
+// Tipos de señal de actividad sospechosa
+const (
+	ActivityFlagOffHours       = "off_hours"       // Aprobación de noche o en fin de semana
+	ActivityFlagBurst          = "burst"           // Muchas decisiones del mismo validador en una hora
+	ActivityFlagBeforeDeadline = "before_deadline" // Decisión en las horas previas a un plazo del cronograma
+)
+
+// Umbrales de las señales
+const (
+	ActivityNightStart     = 22 // Hora local desde la que una aprobación es nocturna
+	ActivityNightEnd       = 6  // Hora local hasta la que una aprobación es nocturna
+	ActivityBurstThreshold = 5  // Decisiones en una hora a partir de las cuales hay ráfaga
+	activityDeadlineWindow = 24 * time.Hour
+)
+
+// ActivityQuery filtra y agrupa la actividad de validación
+type ActivityQuery struct {
+	Bucket     string         // hour o day
+	GroupBy    string         // role o entity
+	From       *time.Time     // Decisiones desde esta fecha
+	To         *time.Time     // Decisiones hasta esta fecha
+	EntityCode string         // Solo decisiones sobre contratos de la entidad
+	Role       AdminRole      // Solo decisiones de este rol
+	Location   *time.Location // Zona horaria de los intervalos y las señales; UTC si es nil
+}
+
+// Validate verifica el intervalo y la agrupación
+func (q ActivityQuery) Validate() error {
+	if q.Bucket != ActivityBucketHour && q.Bucket != ActivityBucketDay {
+		return fmt.Errorf("intervalo inválido: %s (se admite %s o %s)", q.Bucket, ActivityBucketHour, ActivityBucketDay)
+	}
+	if q.GroupBy != ActivityGroupRole && q.GroupBy != ActivityGroupEntity {
+		return fmt.Errorf("agrupación inválida: %s (se admite %s o %s)", q.GroupBy, ActivityGroupRole, ActivityGroupEntity)
+	}
+	if q.From != nil && q.To != nil && q.To.Before(*q.From) {
+		return fmt.Errorf("el fin del periodo es anterior a su inicio")
+	}
+	return nil
+}
+
+// ActivityBucket son las decisiones de un grupo en un intervalo
+type ActivityBucket struct {
+	Start    time.Time `json:"start"`
+	Group    string    `json:"group"`
+	Approved int       `json:"approved"`
+	Rejected int       `json:"rejected"`
+}
+
+// ActivityFlag es una decisión o un conjunto de decisiones con un patrón sospechoso
+type ActivityFlag struct {
+	Kind        string    `json:"kind"`
+	ValidatorID string    `json:"validator_id"`
+	Role        AdminRole `json:"role"`
+	EntityCode  string    `json:"entity_code,omitempty"`
+	ContractID  string    `json:"contract_id,omitempty"`
+	StepNumber  int       `json:"step_number,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	Count       int       `json:"count,omitempty"` // Decisiones de la ráfaga
+	Detail      string    `json:"detail"`
+}
+
+// ActivityReport es el mapa de calor de la actividad de validación
+type ActivityReport struct {
+	Bucket    string           `json:"bucket"`
+	GroupBy   string           `json:"group_by"`
+	Timezone  string           `json:"timezone"`
+	Total     int              `json:"total"`
+	Buckets   []ActivityBucket `json:"buckets"`
+	HourOfDay [24]int          `json:"hour_of_day"`
+	DayOfWeek [7]int           `json:"day_of_week"` // 0 es domingo
+	Heatmap   [7][24]int       `json:"heatmap"`     // Día de la semana por hora del día
+	Flags     []ActivityFlag   `json:"flags"`
+}
+
+// activityDecision es un paso aprobado o rechazado por un funcionario
+type activityDecision struct {
+	contract *Contract
+	step     ValidationStep
+	at       time.Time // En la zona horaria de la consulta
+}
+
+// ActivityHeatmap agrupa las decisiones de los validadores según la consulta. Las
+// aprobaciones automáticas de las plantillas no cuentan: no las toma un funcionario.
+func (bc *Blockchain) ActivityHeatmap(query ActivityQuery) (*ActivityReport, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	location := query.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	var decisions []activityDecision
+	for _, contract := range bc.GetAllContracts() {
+		if query.EntityCode != "" && contract.EntityCode != query.EntityCode {
+			continue
+		}
+		for _, step := range contract.ValidationSteps {
+			if step.Status != ValidationApproved && step.Status != ValidationRejected {
+				continue
+			}
+			if step.AutoApproved || step.Timestamp.IsZero() {
+				continue
+			}
+			if query.Role != "" && step.Role != query.Role {
+				continue
+			}
+			if query.From != nil && step.Timestamp.Before(*query.From) {
+				continue
+			}
+			if query.To != nil && step.Timestamp.After(*query.To) {
+				continue
+			}
+			decisions = append(decisions, activityDecision{contract: contract, step: step, at: step.Timestamp.In(location)})
+		}
+	}
+	sort.Slice(decisions, func(i, j int) bool { return decisions[i].at.Before(decisions[j].at) })
+
+	report := &ActivityReport{
+		Bucket:   query.Bucket,
+		GroupBy:  query.GroupBy,
+		Timezone: location.String(),
+		Total:    len(decisions),
+		Buckets:  []ActivityBucket{},
+		Flags:    []ActivityFlag{},
+	}
+
+	type bucketKey struct {
+		start time.Time
+		group string
+	}
+	buckets := make(map[bucketKey]*ActivityBucket)
+	type burstKey struct {
+		validator string
+		hour      time.Time
+	}
+	bursts := make(map[burstKey][]activityDecision)
+
+	for _, decision := range decisions {
+		at := decision.at
+		report.HourOfDay[at.Hour()]++
+		report.DayOfWeek[at.Weekday()]++
+		report.Heatmap[at.Weekday()][at.Hour()]++
+
+		hour := time.Date(at.Year(), at.Month(), at.Day(), at.Hour(), 0, 0, 0, location)
+		start := hour
+		if query.Bucket == ActivityBucketDay {
+			start = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, location)
+		}
+		group := string(decision.step.Role)
+		if query.GroupBy == ActivityGroupEntity {
+			group = decision.contract.EntityCode
+		}
+		key := bucketKey{start: start, group: group}
+		bucket, exists := buckets[key]
+		if !exists {
+			bucket = &ActivityBucket{Start: start, Group: group}
+			buckets[key] = bucket
+		}
+		if decision.step.Status == ValidationApproved {
+			bucket.Approved++
+		} else {
+			bucket.Rejected++
+		}
+
+		if flag, suspicious := offHoursFlag(decision); suspicious {
+			report.Flags = append(report.Flags, flag)
+		}
+		if flag, suspicious := beforeDeadlineFlag(decision); suspicious {
+			report.Flags = append(report.Flags, flag)
+		}
+		burst := burstKey{validator: decision.step.ValidatorID, hour: hour}
+		bursts[burst] = append(bursts[burst], decision)
+	}
+
+	for _, bucket := range buckets {
+		report.Buckets = append(report.Buckets, *bucket)
+	}
+	sort.Slice(report.Buckets, func(i, j int) bool {
+		if !report.Buckets[i].Start.Equal(report.Buckets[j].Start) {
+			return report.Buckets[i].Start.Before(report.Buckets[j].Start)
+		}
+		return report.Buckets[i].Group < report.Buckets[j].Group
+	})
+
+	for key, burst := range bursts {
+		if len(burst) < ActivityBurstThreshold {
+			continue
+		}
+		report.Flags = append(report.Flags, ActivityFlag{
+			Kind:        ActivityFlagBurst,
+			ValidatorID: key.validator,
+			Role:        burst[0].step.Role,
+			Timestamp:   key.hour,
+			Count:       len(burst),
+			Detail:      fmt.Sprintf("%d decisiones entre las %s y las %s", len(burst), key.hour.Format("15:04"), key.hour.Add(time.Hour).Format("15:04")),
+		})
+	}
+	sort.Slice(report.Flags, func(i, j int) bool {
+		if !report.Flags[i].Timestamp.Equal(report.Flags[j].Timestamp) {
+			return report.Flags[i].Timestamp.Before(report.Flags[j].Timestamp)
+		}
+		return report.Flags[i].Kind < report.Flags[j].Kind
+	})
+	return report, nil
+}
+
+// offHoursFlag señala las aprobaciones de noche o en fin de semana
+func offHoursFlag(decision activityDecision) (ActivityFlag, bool) {
+	if decision.step.Status != ValidationApproved {
+		return ActivityFlag{}, false
+	}
+	at := decision.at
+	night := at.Hour() >= ActivityNightStart || at.Hour() < ActivityNightEnd
+	weekend := at.Weekday() == time.Saturday || at.Weekday() == time.Sunday
+	if !night && !weekend {
+		return ActivityFlag{}, false
+	}
+
+	detail := fmt.Sprintf("aprobación a las %s", at.Format("15:04"))
+	if weekend {
+		detail = fmt.Sprintf("aprobación en fin de semana (%s %s)", at.Format("2006-01-02"), at.Format("15:04"))
+	}
+	return decisionFlag(ActivityFlagOffHours, decision, detail), true
+}
+
+// beforeDeadlineFlag señala las decisiones tomadas en las horas previas a un plazo del
+// cronograma del proceso
+func beforeDeadlineFlag(decision activityDecision) (ActivityFlag, bool) {
+	calendar := decision.contract.Calendar
+	if calendar == nil {
+		return ActivityFlag{}, false
+	}
+	deadlines := []struct {
+		name string
+		at   time.Time
+	}{
+		{"cierre de observaciones", calendar.QuestionsDeadline},
+		{"cierre de respuestas", calendar.ResponsesDeadline},
+	}
+	for _, deadline := range deadlines {
+		remaining := deadline.at.Sub(decision.step.Timestamp)
+		if remaining >= 0 && remaining <= activityDeadlineWindow {
+			detail := fmt.Sprintf("decisión %s antes del %s", remaining.Round(time.Minute), deadline.name)
+			return decisionFlag(ActivityFlagBeforeDeadline, decision, detail), true
+		}
+	}
+	return ActivityFlag{}, false
+}
+
+func decisionFlag(kind string, decision activityDecision, detail string) ActivityFlag {
+	return ActivityFlag{
+		Kind:        kind,
+		ValidatorID: decision.step.ValidatorID,
+		Role:        decision.step.Role,
+		EntityCode:  decision.contract.EntityCode,
+		ContractID:  decision.contract.ID,
+		StepNumber:  decision.step.StepNumber,
+		Timestamp:   decision.at,
+		Detail:      detail,
+	}
+}
@@ -0,0 +1,52 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// StepAct referencia el acta firmada del comité que respalda una decisión de validación.
+// El documento no se guarda en la cadena, solo su hash SHA-256 y su referencia.
+type StepAct struct {
+	Hash      string `json:"hash"`
+	Reference string `json:"reference,omitempty"` // p. ej. "Acta 014 de 2026"
+}
+
+// validate verifica que el hash del acta sea un SHA-256 en hexadecimal
+func (act *StepAct) validate() error {
+	decoded, err := hex.DecodeString(act.Hash)
+	if err != nil || len(decoded) != 32 {
+		return errors.New("el hash del acta debe ser un SHA-256 en hexadecimal")
+	}
+	return nil
+}
+
+// StepActRecord es el acta de un paso decidido, para la línea de tiempo del contrato
+type StepActRecord struct {
+	StepNumber  int              `json:"step_number"`
+	Role        AdminRole        `json:"role"`
+	ValidatorID string           `json:"validator_id"`
+	Status      ValidationStatus `json:"status"`
+	Timestamp   time.Time        `json:"timestamp"`
+	Act         StepAct          `json:"act"`
+}
+
+// StepActs lista las actas de comité adjuntas a los pasos decididos del contrato
+func (c *Contract) StepActs() []StepActRecord {
+	acts := []StepActRecord{}
+	for _, step := range c.ValidationSteps {
+		if step.Act == nil {
+			continue
+		}
+		acts = append(acts, StepActRecord{
+			StepNumber:  step.StepNumber,
+			Role:        step.Role,
+			ValidatorID: step.ValidatorID,
+			Status:      step.Status,
+			Timestamp:   step.Timestamp,
+			Act:         *step.Act,
+		})
+	}
+	return acts
+}
@@ -0,0 +1,319 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+
+	"github.com/google/uuid"
+)
+
+// Tipos de reglas de alerta
+const (
+	// AlertAmountThreshold se dispara con cada contrato cuyo monto supera el umbral
+	AlertAmountThreshold = "AMOUNT_THRESHOLD"
+	// AlertEntityMonthlyTotal se dispara cuando una entidad supera el umbral de contratación en un mes
+	AlertEntityMonthlyTotal = "ENTITY_MONTHLY_TOTAL"
+)
+
+// Cantidad máxima de alertas disparadas que se conservan en memoria
+const maxAlerts = 1000
+
+// AlertRule representa una regla de alerta configurada por un ente de control
+type AlertRule struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Kind         string    `json:"kind"`
+	Threshold    float64   `json:"threshold"`
+	ContractType string    `json:"contract_type,omitempty"`
+	EntityCode   string    `json:"entity_code,omitempty"`
+	CallbackURL  string    `json:"callback_url,omitempty"`
+	CreatedBy    string    `json:"created_by"`
+	Role         AdminRole `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Alert representa una alerta disparada por un bloque
+type Alert struct {
+	ID          string    `json:"id"`
+	RuleID      string    `json:"rule_id"`
+	RuleName    string    `json:"rule_name"`
+	Kind        string    `json:"kind,omitempty"` // Solo en las alertas que genera el sistema, sin regla
+	Role        AdminRole `json:"role"`
+	ContractID  string    `json:"contract_id"`
+	EntityCode  string    `json:"entity_code"`
+	Amount      float64   `json:"amount"`
+	Total       float64   `json:"total,omitempty"`
+	Message     string    `json:"message"`
+	BlockHash   string    `json:"block_hash"`
+	BlockHeight int       `json:"block_height"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// AlertManager evalúa las reglas de alerta a medida que llegan bloques
+type AlertManager struct {
+	blockchain    *Blockchain
+	rules         map[string]*AlertRule
+	alerts        []Alert
+	monthlyTotals map[string]float64
+	fired         map[string]bool
+	client        *http.Client
+	mutex         sync.Mutex
+}
+
+// NewAlertManager crea el gestor de alertas, carga las reglas guardadas y lo conecta al bus de eventos
+func NewAlertManager(bc *Blockchain) *AlertManager {
+	am := &AlertManager{
+		blockchain:    bc,
+		rules:         make(map[string]*AlertRule),
+		monthlyTotals: make(map[string]float64),
+		fired:         make(map[string]bool),
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+
+	bc.store.ForEach(storage.BucketAlertRules, func(key string, value []byte) error {
+		var rule AlertRule
+		if err := json.Unmarshal(value, &rule); err == nil {
+			am.rules[key] = &rule
+		}
+		return nil
+	})
+
+	// Los acumulados mensuales se reconstruyen desde la cadena sin disparar alertas
+	for height, block := range bc.Blocks() {
+		if block.Type != "CONTRACT_CREATION" && block.Type != BatchBlockType {
+			continue
+		}
+		if block.Pruned {
+			full, err := bc.BlockAt(height)
+			if err != nil {
+				logf("❌ %v\n", err)
+				continue
+			}
+			block = full
+		}
+		for _, event := range block.Events() {
+			if event.Type != "CONTRACT_CREATION" {
+				continue
+			}
+			entityCode, amount, _ := contractCreationFields(event)
+			am.monthlyTotals[monthlyKey(entityCode, block.Timestamp)] += amount
+		}
+	}
+
+	bc.Projections.Subscribe(ProjectionAlerts, am.evaluate)
+	return am
+}
+
+// AddRule registra una regla de alerta; solo Contraloría y Procuraduría pueden hacerlo
+func (am *AlertManager) AddRule(rule AlertRule) (*AlertRule, error) {
+	if rule.Role != RoleComptroller && rule.Role != RoleProsecutor {
+		return nil, errors.New("solo Contraloría y Procuraduría pueden configurar alertas")
+	}
+	if rule.Kind != AlertAmountThreshold && rule.Kind != AlertEntityMonthlyTotal {
+		return nil, fmt.Errorf("tipo de alerta desconocido: %s", rule.Kind)
+	}
+	if rule.Threshold <= 0 {
+		return nil, errors.New("el umbral debe ser mayor que cero")
+	}
+	if rule.CallbackURL != "" {
+		parsed, err := url.Parse(rule.CallbackURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, errors.New("URL de callback inválida")
+		}
+	}
+
+	rule.ID = uuid.New().String()
+	rule.CreatedAt = time.Now()
+
+	am.mutex.Lock()
+	am.rules[rule.ID] = &rule
+	am.mutex.Unlock()
+
+	am.blockchain.saveState(storage.BucketAlertRules, rule.ID, &rule)
+	logf("🚨 Regla de alerta %s registrada por %s\n", rule.Name, rule.CreatedBy)
+	return &rule, nil
+}
+
+// RemoveRule elimina una regla de alerta
+func (am *AlertManager) RemoveRule(ruleID string, role AdminRole) error {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	rule, exists := am.rules[ruleID]
+	if !exists {
+		return errors.New("regla no encontrada")
+	}
+	if rule.Role != role {
+		return errors.New("solo el ente de control que creó la regla puede eliminarla")
+	}
+	delete(am.rules, ruleID)
+	am.blockchain.deleteState(storage.BucketAlertRules, ruleID)
+	return nil
+}
+
+// GetRules retorna las reglas configuradas, opcionalmente filtradas por rol
+func (am *AlertManager) GetRules(role AdminRole) []AlertRule {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	rules := make([]AlertRule, 0, len(am.rules))
+	for _, rule := range am.rules {
+		if role == "" || rule.Role == role {
+			rules = append(rules, *rule)
+		}
+	}
+	return rules
+}
+
+// GetAlerts retorna las alertas disparadas, las más recientes primero, opcionalmente filtradas por rol
+func (am *AlertManager) GetAlerts(role AdminRole) []Alert {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	alerts := make([]Alert, 0, len(am.alerts))
+	for i := len(am.alerts) - 1; i >= 0; i-- {
+		if role == "" || am.alerts[i].Role == role {
+			alerts = append(alerts, am.alerts[i])
+		}
+	}
+	return alerts
+}
+
+// Raise registra una alerta que genera el propio sistema, sin una regla configurada, para
+// el rol que debe atenderla
+func (am *AlertManager) Raise(alert Alert) {
+	alert.ID = uuid.New().String()
+	alert.TriggeredAt = time.Now()
+
+	am.mutex.Lock()
+	am.alerts = append(am.alerts, alert)
+	if len(am.alerts) > maxAlerts {
+		am.alerts = am.alerts[len(am.alerts)-maxAlerts:]
+	}
+	am.mutex.Unlock()
+
+	logf("🚨 Alerta %s para %s: %s\n", alert.RuleName, alert.Role, alert.Message)
+}
+
+// evaluate revisa las reglas contra cada contrato nuevo que llega a la cadena
+func (am *AlertManager) evaluate(event ChainEvent) {
+	if event.Type != "CONTRACT_CREATION" {
+		return
+	}
+	entityCode, amount, contractType := contractCreationFields(event)
+	contractID := event.ContractID
+	if contractID == "" {
+		contractID, _ = eventPayload(event)["contract_id"].(string)
+	}
+
+	am.mutex.Lock()
+	key := monthlyKey(entityCode, event.Timestamp)
+	am.monthlyTotals[key] += amount
+	total := am.monthlyTotals[key]
+
+	var triggered []Alert
+	var callbacks []string
+	for _, rule := range am.rules {
+		if rule.EntityCode != "" && rule.EntityCode != entityCode {
+			continue
+		}
+		if rule.ContractType != "" && rule.ContractType != contractType {
+			continue
+		}
+
+		alert := Alert{
+			ID:          uuid.New().String(),
+			RuleID:      rule.ID,
+			RuleName:    rule.Name,
+			Role:        rule.Role,
+			ContractID:  contractID,
+			EntityCode:  entityCode,
+			Amount:      amount,
+			BlockHash:   event.BlockHash,
+			BlockHeight: event.Height,
+			TriggeredAt: time.Now(),
+		}
+
+		switch rule.Kind {
+		case AlertAmountThreshold:
+			if amount <= rule.Threshold {
+				continue
+			}
+			alert.Message = fmt.Sprintf("Contrato por %.2f supera el umbral de %.2f", amount, rule.Threshold)
+		case AlertEntityMonthlyTotal:
+			firedKey := rule.ID + "|" + key
+			if total <= rule.Threshold || am.fired[firedKey] {
+				continue
+			}
+			am.fired[firedKey] = true
+			alert.Total = total
+			alert.Message = fmt.Sprintf("La entidad %s acumula %.2f en %s, supera el umbral de %.2f", entityCode, total, event.Timestamp.Format("2006-01"), rule.Threshold)
+		}
+
+		triggered = append(triggered, alert)
+		callbacks = append(callbacks, rule.CallbackURL)
+	}
+
+	am.alerts = append(am.alerts, triggered...)
+	if len(am.alerts) > maxAlerts {
+		am.alerts = am.alerts[len(am.alerts)-maxAlerts:]
+	}
+	am.mutex.Unlock()
+
+	for i, alert := range triggered {
+		logf("🚨 Alerta %s: %s (bloque %d)\n", alert.RuleName, alert.Message, alert.BlockHeight)
+		if callbacks[i] != "" {
+			go am.notify(callbacks[i], alert)
+		}
+	}
+}
+
+// notify envía la alerta al sistema de notificaciones del ente de control
+func (am *AlertManager) notify(callbackURL string, alert Alert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return
+	}
+
+	resp, err := am.client.Post(callbackURL, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		logf("❌ Error notificando alerta %s: %v\n", alert.ID, err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logf("❌ Error notificando alerta %s: status %d\n", alert.ID, resp.StatusCode)
+	}
+}
+
+// eventPayload retorna los datos de la transacción; los bloques recibidos de
+// peers traen los datos originales anidados en "data"
+func eventPayload(event ChainEvent) map[string]interface{} {
+	if nested, ok := event.Data["data"].(map[string]interface{}); ok {
+		return nested
+	}
+	return event.Data
+}
+
+// contractCreationFields extrae entidad, monto y modalidad de un evento de creación de contrato
+func contractCreationFields(event ChainEvent) (string, float64, string) {
+	payload := eventPayload(event)
+	entityCode, _ := payload["entity_code"].(string)
+	amount, _ := payload["amount"].(float64)
+	contractType, _ := payload["contract_type"].(string)
+	return entityCode, amount, contractType
+}
+
+// monthlyKey identifica el acumulado de una entidad en un mes
+func monthlyKey(entityCode string, timestamp time.Time) string {
+	return entityCode + "|" + timestamp.Format("2006-01")
+}
@@ -0,0 +1,304 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Adiciones y prórrogas: un contrato adjudicado puede aumentar su valor, extender su plazo
+// o ambas cosas. La modificación se radica en un bloque AMENDMENT y pasa por un flujo
+// abreviado (revisión jurídica y firma del ordenador del gasto) cuyas decisiones quedan en
+// bloques AMENDMENT_DECISION. Mientras una modificación espera aprobación el contrato no
+// admite otra ni su liquidación (ver OperationGuard).
+
+// Tipos de bloque de las modificaciones y sus decisiones
+const (
+	AmendmentBlockType         = "AMENDMENT"
+	AmendmentDecisionBlockType = "AMENDMENT_DECISION"
+)
+
+// MaxAmendedFraction es el tope de las adiciones sobre el valor inicial del contrato
+// (Ley 80 de 1993, art. 40: no más del 50%)
+const MaxAmendedFraction = 0.5
+
+// AmendmentStatus es el estado de una modificación
+type AmendmentStatus string
+
+const (
+	AmendmentPending  AmendmentStatus = "PENDING"
+	AmendmentApproved AmendmentStatus = "APPROVED"
+	AmendmentRejected AmendmentStatus = "REJECTED"
+)
+
+// AmendmentApprovalRoles es el flujo abreviado de una modificación, en orden
+var AmendmentApprovalRoles = []AdminRole{RoleLegalCommission, RoleAdminChief}
+
+// AmendmentStep es un paso del flujo de aprobación de la modificación
+type AmendmentStep struct {
+	Role      AdminRole        `json:"role"`
+	Status    ValidationStatus `json:"status"`
+	DecidedBy string           `json:"decided_by,omitempty"`
+	Comments  string           `json:"comments,omitempty"`
+	DecidedAt *time.Time       `json:"decided_at,omitempty"`
+	BlockHash string           `json:"block_hash,omitempty"`
+}
+
+// Amendment es una adición (valor adicional), una prórroga (nueva fecha de terminación)
+// o ambas sobre un contrato
+type Amendment struct {
+	ID               string          `json:"id"`
+	AdditionalAmount float64         `json:"additional_amount"`
+	NewEndDate       *time.Time      `json:"new_end_date,omitempty"`
+	Justification    string          `json:"justification"`
+	Status           AmendmentStatus `json:"status"`
+	Steps            []AmendmentStep `json:"steps"`
+	CurrentStep      int             `json:"current_step"` // Índice del paso pendiente
+	RequestedBy      string          `json:"requested_by"`
+	RequestedAt      time.Time       `json:"requested_at"`
+	BlockHash        string          `json:"block_hash"`
+}
+
+// newAmendment arma la modificación pendiente con su flujo abreviado
+func newAmendment(id string, amount float64, newEndDate *time.Time, justification string, requestedBy string, requestedAt time.Time) Amendment {
+	steps := make([]AmendmentStep, len(AmendmentApprovalRoles))
+	for i, role := range AmendmentApprovalRoles {
+		steps[i] = AmendmentStep{Role: role, Status: ValidationPending}
+	}
+	return Amendment{
+		ID:               id,
+		AdditionalAmount: amount,
+		NewEndDate:       newEndDate,
+		Justification:    justification,
+		Status:           AmendmentPending,
+		Steps:            steps,
+		RequestedBy:      requestedBy,
+		RequestedAt:      requestedAt,
+	}
+}
+
+// description resume la modificación para la línea de auditoría
+func (a *Amendment) description() string {
+	switch {
+	case a.AdditionalAmount > 0 && a.NewEndDate != nil:
+		return fmt.Sprintf("Adición por %.2f y prórroga hasta %s solicitadas", a.AdditionalAmount, a.NewEndDate.Format("2006-01-02"))
+	case a.NewEndDate != nil:
+		return fmt.Sprintf("Prórroga hasta %s solicitada", a.NewEndDate.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("Adición por %.2f solicitada", a.AdditionalAmount)
+}
+
+// checkDecision verifica que la modificación espere la decisión del rol
+func (a *Amendment) checkDecision(role AdminRole) error {
+	if a.Status != AmendmentPending {
+		return fmt.Errorf("la modificación ya fue decidida (%s)", a.Status)
+	}
+	if a.CurrentStep >= len(a.Steps) {
+		return errors.New("la modificación no tiene pasos pendientes")
+	}
+	if expected := a.Steps[a.CurrentStep].Role; role != expected {
+		return fmt.Errorf("la modificación espera la decisión de %s", expected)
+	}
+	return nil
+}
+
+// Amendment retorna la modificación del contrato, o nil
+func (c *Contract) Amendment(amendmentID string) *Amendment {
+	for i := range c.Amendments {
+		if c.Amendments[i].ID == amendmentID {
+			return &c.Amendments[i]
+		}
+	}
+	return nil
+}
+
+// TotalValue es el valor del contrato con las adiciones aprobadas
+func (c *Contract) TotalValue() float64 {
+	return c.Amount + c.AmendedValue
+}
+
+// applyAmendmentDecision registra la decisión del paso pendiente; con la última aprobación
+// la adición se suma al valor del contrato y la prórroga fija su nueva terminación
+func (c *Contract) applyAmendmentDecision(amendment *Amendment, approved bool, decidedBy string, comments string, at time.Time, blockHash string) {
+	step := &amendment.Steps[amendment.CurrentStep]
+	step.DecidedBy = decidedBy
+	step.Comments = comments
+	step.DecidedAt = &at
+	step.BlockHash = blockHash
+	if !approved {
+		step.Status = ValidationRejected
+		amendment.Status = AmendmentRejected
+		return
+	}
+
+	step.Status = ValidationApproved
+	amendment.CurrentStep++
+	if amendment.CurrentStep < len(amendment.Steps) {
+		return
+	}
+	amendment.Status = AmendmentApproved
+	c.AmendedValue += amendment.AdditionalAmount
+	if amendment.NewEndDate != nil {
+		endDate := *amendment.NewEndDate
+		c.EndDate = &endDate
+	}
+}
+
+// amendmentDecisionDescription resume la decisión para la línea de auditoría
+func amendmentDecisionDescription(contract *Contract, amendment *Amendment, role AdminRole, approved bool) string {
+	if !approved {
+		return fmt.Sprintf("Modificación %s rechazada por %s", amendment.ID, role)
+	}
+	if amendment.Status == AmendmentApproved {
+		return fmt.Sprintf("Modificación %s aprobada; valor total del contrato %.2f", amendment.ID, contract.TotalValue())
+	}
+	return fmt.Sprintf("Modificación %s aprobada por %s", amendment.ID, role)
+}
+
+// AmendmentManager radica las modificaciones de los contratos y registra sus decisiones
+type AmendmentManager struct {
+	blockchain *Blockchain
+	mutex      sync.Mutex
+}
+
+// NewAmendmentManager crea el gestor y vuelve a bloquear los contratos con modificaciones
+// pendientes, porque las operaciones en curso no sobreviven un reinicio
+func NewAmendmentManager(bc *Blockchain) *AmendmentManager {
+	am := &AmendmentManager{blockchain: bc}
+	for id, contract := range bc.contractMap() {
+		for _, amendment := range contract.Amendments {
+			if amendment.Status == AmendmentPending {
+				bc.Operations.Begin(id, OperationAmendment, amendment.ID, amendment.RequestedBy)
+			}
+		}
+	}
+	return am
+}
+
+// Request radica una adición, una prórroga o ambas sobre un contrato adjudicado. Si el
+// contrato tiene otra operación en curso retorna un *OperationConflictError.
+func (am *AmendmentManager) Request(contractID string, amount float64, newEndDate *time.Time, justification string, requestedBy string, role AdminRole) (*Amendment, error) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	unlock := am.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := am.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if _, committed := contractAwardedAt(contract); !committed || contract.Status == StatusRejected || contract.Status == StatusCompleted {
+		return nil, errors.New("solo se modifican contratos adjudicados en ejecución")
+	}
+	if amount < 0 {
+		return nil, errors.New("el valor adicional no puede ser negativo")
+	}
+	if amount == 0 && newEndDate == nil {
+		return nil, errors.New("la modificación debe adicionar valor, prorrogar el plazo o ambas")
+	}
+	if justification == "" {
+		return nil, errors.New("la modificación requiere una justificación")
+	}
+	if limit := contract.Amount * MaxAmendedFraction; contract.AmendedValue+amount > limit {
+		return nil, fmt.Errorf("las adiciones superarían el %.0f%% del valor inicial (%.2f disponibles)", MaxAmendedFraction*100, limit-contract.AmendedValue)
+	}
+	now := time.Now()
+	if newEndDate != nil {
+		if !newEndDate.After(now) {
+			return nil, errors.New("la nueva fecha de terminación debe ser futura")
+		}
+		if contract.EndDate != nil && !newEndDate.After(*contract.EndDate) {
+			return nil, fmt.Errorf("la nueva fecha de terminación debe ser posterior a la vigente (%s)", contract.EndDate.Format("2006-01-02"))
+		}
+	}
+
+	amendment := newAmendment(uuid.New().String(), amount, newEndDate, justification, requestedBy, now)
+	if err := am.blockchain.Operations.Begin(contractID, OperationAmendment, amendment.ID, requestedBy); err != nil {
+		return nil, err
+	}
+
+	blockData := map[string]interface{}{
+		"type":              AmendmentBlockType,
+		"contract_id":       contractID,
+		"amendment_id":      amendment.ID,
+		"additional_amount": amount,
+		"justification":     justification,
+		"requested_by":      requestedBy,
+		"role":              string(role),
+		"timestamp":         now,
+	}
+	if newEndDate != nil {
+		blockData["new_end_date"] = *newEndDate
+	}
+	block, err := am.blockchain.SealBlock(blockData)
+	if err != nil {
+		am.blockchain.Operations.End(contractID, amendment.ID)
+		return nil, err
+	}
+
+	amendment.BlockHash = block.Hash
+	contract.Amendments = append(contract.Amendments, amendment)
+	contract.UpdatedAt = now
+	am.blockchain.WorkflowManager.addAuditEntry(contract, AmendmentBlockType, requestedBy, role, amendment.description())
+	am.blockchain.saveContract(contract)
+
+	logf("📝 Modificación %s radicada en el contrato %s\n", amendment.ID, contractID)
+	return &amendment, nil
+}
+
+// Decide registra la decisión del rol sobre el paso pendiente de la modificación. Al
+// aprobarse el último paso o al rechazarse se libera el contrato.
+func (am *AmendmentManager) Decide(contractID string, amendmentID string, approved bool, comments string, decidedBy string, role AdminRole) (*Amendment, error) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	unlock := am.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := am.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	amendment := contract.Amendment(amendmentID)
+	if amendment == nil {
+		return nil, errors.New("modificación no encontrada")
+	}
+	if err := amendment.checkDecision(role); err != nil {
+		return nil, err
+	}
+	if !approved && comments == "" {
+		return nil, errors.New("el rechazo de una modificación requiere comentarios")
+	}
+
+	now := time.Now()
+	blockData := map[string]interface{}{
+		"type":         AmendmentDecisionBlockType,
+		"contract_id":  contractID,
+		"amendment_id": amendmentID,
+		"approved":     approved,
+		"comments":     comments,
+		"decided_by":   decidedBy,
+		"role":         string(role),
+		"timestamp":    now,
+	}
+	block, err := am.blockchain.SealBlock(blockData)
+	if err != nil {
+		return nil, err
+	}
+
+	contract.applyAmendmentDecision(amendment, approved, decidedBy, comments, now, block.Hash)
+	contract.UpdatedAt = now
+	am.blockchain.WorkflowManager.addAuditEntry(contract, AmendmentDecisionBlockType, decidedBy, role, amendmentDecisionDescription(contract, amendment, role, approved))
+	am.blockchain.saveContract(contract)
+
+	if amendment.Status != AmendmentPending {
+		am.blockchain.Operations.End(contractID, amendmentID)
+		logf("📝 Modificación %s del contrato %s: %s\n", amendmentID, contractID, amendment.Status)
+	}
+	decided := *amendment
+	return &decided, nil
+}
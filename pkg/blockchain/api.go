@@ -0,0 +1,188 @@
+package blockchain
+
+import (
+	"crypto/tls"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// API estable del paquete para otros servicios del ecosistema SECOP que embeben el ledger.
+// Las interfaces cubren las operaciones que se mantienen entre versiones; el resto de
+// métodos exportados de los tipos concretos puede cambiar.
+
+// Ledger es la cadena de bloques con el estado de los contratos
+type Ledger interface {
+	AddContract(contract *Contract) error
+	GetContract(contractID string) (*Contract, error)
+	GetAllContracts() []*Contract
+	ValidateContractStep(contractID string, stepNumber int, validatorID string, validatorName string, role AdminRole, approved bool, comments string, signature StepSignature, act *StepAct) error
+	AddAuditObservation(contractID string, auditorID string, role AdminRole, observation string) (*AuditObservation, error)
+	GetContractWorkflowStatus(contractID string) (*WorkflowStatus, error)
+	AddBlock(blockData map[string]interface{}) error
+	SealBlock(blockData map[string]interface{}) (*Block, error)
+	Blocks() []*Block
+	BlockAt(height int) (*Block, error)
+	BlockByHash(hash string) (*Block, error)
+	HasBlock(hash string) bool
+	Len() int
+	TipHash() string
+	GenesisHash() string
+	VerifyChain() error
+	ReplaceChain(chain []*Block) error
+	Close() error
+}
+
+// Workflow es el flujo de validación SECOP de los contratos
+type Workflow interface {
+	GetWorkflowSteps() []WorkflowStep
+	InitializeContractWorkflow(contract *Contract) error
+	ValidateStep(contractID string, stepNumber int, validatorID string, validatorName string, role AdminRole, approved bool, comments string, signature StepSignature, act *StepAct) error
+	AddAuditObservation(contractID string, auditorID string, role AdminRole, observation string) (*AuditObservation, error)
+	GetContractWorkflowStatus(contractID string) (*WorkflowStatus, error)
+}
+
+// Network es la red de nodos que replica la cadena
+type Network interface {
+	AddPeer(peerID, address, port string)
+	RemovePeer(peerID string) bool
+	GetActivePeers() []*Peer
+	BroadcastBlock(block Block) error
+	ReceiveBlock(block Block, sender string) error
+	SyncWithPeers() error
+	HealthCheck()
+}
+
+var (
+	_ Ledger   = (*Blockchain)(nil)
+	_ Workflow = (*WorkflowManager)(nil)
+	_ Network  = (*P2PNetwork)(nil)
+)
+
+// ledgerOptions reúne la configuración de New
+type ledgerOptions struct {
+	store    storage.Store
+	walPath  string
+	signerID string
+	keys     *NodeKeyring
+	state    func() State
+}
+
+// Option configura el ledger que crea New
+type Option func(*ledgerOptions)
+
+// WithStore persiste la cadena en el almacenamiento dado; sin ella vive solo en memoria
+func WithStore(store storage.Store) Option {
+	return func(o *ledgerOptions) { o.store = store }
+}
+
+// WithWAL registra los bloques en el WAL de la ruta dada y recupera appends interrumpidos
+func WithWAL(path string) Option {
+	return func(o *ledgerOptions) { o.walPath = path }
+}
+
+// WithSigner firma los bloques que cree el ledger con las llaves del nodo
+func WithSigner(nodeID string, keys *NodeKeyring) Option {
+	return func(o *ledgerOptions) {
+		o.signerID = nodeID
+		o.keys = keys
+	}
+}
+
+// WithState guarda el estado de los contratos en la implementación que crea factory
+func WithState(factory func() State) Option {
+	return func(o *ledgerOptions) { o.state = factory }
+}
+
+// New crea el ledger con las opciones dadas y reconstruye el estado de los contratos
+// desde la cadena restaurada
+func New(opts ...Option) (*Blockchain, error) {
+	var o ledgerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	bc, err := NewBlockchain(o.store)
+	if err != nil {
+		return nil, err
+	}
+	if o.walPath != "" {
+		if err := bc.OpenWAL(o.walPath); err != nil {
+			bc.Close()
+			return nil, err
+		}
+	}
+	if o.state != nil {
+		bc.UseState(o.state)
+	}
+	if err := bc.ReplayState(); err != nil {
+		bc.Close()
+		return nil, err
+	}
+	if o.keys != nil {
+		bc.SetSigner(o.signerID, o.keys)
+	}
+	return bc, nil
+}
+
+// networkOptions reúne la configuración de NewNetwork
+type networkOptions struct {
+	keys    *NodeKeyring
+	fanout  *int
+	backend string
+	libp2p  *Libp2pConfig
+	tls     *tls.Config
+}
+
+// NetworkOption configura la red que crea NewNetwork
+type NetworkOption func(*networkOptions)
+
+// WithNodeKeys anuncia a los peers las llaves públicas del nodo
+func WithNodeKeys(keys *NodeKeyring) NetworkOption {
+	return func(o *networkOptions) { o.keys = keys }
+}
+
+// WithGossipFanout fija a cuántos peers se envía cada bloque (0 para todos)
+func WithGossipFanout(fanout int) NetworkOption {
+	return func(o *networkOptions) { o.fanout = &fanout }
+}
+
+// WithBackend elige el transporte con el que se entregan los mensajes (ver P2P_BACKEND)
+func WithBackend(name string) NetworkOption {
+	return func(o *networkOptions) { o.backend = name }
+}
+
+// WithLibp2p configura el transporte libp2p; se usa con WithBackend(P2PBackendLibp2p)
+func WithLibp2p(config Libp2pConfig) NetworkOption {
+	return func(o *networkOptions) { o.libp2p = &config }
+}
+
+// WithTLS usa TLS mutuo en el tráfico hacia los peers
+func WithTLS(config *tls.Config) NetworkOption {
+	return func(o *networkOptions) { o.tls = config }
+}
+
+// NewNetwork crea la red del nodo sobre el ledger con las opciones dadas
+func NewNetwork(nodeID, address, port string, ledger *Blockchain, opts ...NetworkOption) (*P2PNetwork, error) {
+	var o networkOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p2p := NewP2PNetwork(nodeID, address, port, ledger)
+	p2p.Keys = o.keys
+	if o.fanout != nil {
+		p2p.GossipFanout = *o.fanout
+	}
+	if o.libp2p != nil {
+		p2p.Libp2p = *o.libp2p
+	}
+	if o.backend != "" {
+		if err := p2p.SetBackend(o.backend); err != nil {
+			return nil, err
+		}
+	}
+	if o.tls != nil {
+		p2p.SetTLS(o.tls)
+	}
+	return p2p, nil
+}
@@ -0,0 +1,403 @@
+package blockchain
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ArchivePolicy define qué bloques se archivan fuera de la memoria
+type ArchivePolicy struct {
+	Dir         string
+	KeepRecent  int
+	SegmentSize int
+}
+
+// ArchiveSegment representa un archivo comprimido con los bloques [Start, End)
+type ArchiveSegment struct {
+	Start     int       `json:"start"`
+	End       int       `json:"end"`
+	File      string    `json:"file"`
+	SHA256    string    `json:"sha256"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ArchiveStatus resume el estado del archivo de bloques
+type ArchiveStatus struct {
+	Dir         string           `json:"dir"`
+	KeepRecent  int              `json:"keep_recent"`
+	SegmentSize int              `json:"segment_size"`
+	Archived    int              `json:"archived"`
+	InMemory    int              `json:"in_memory"`
+	Segments    []ArchiveSegment `json:"segments"`
+}
+
+// BlockArchive mueve los bloques antiguos a segmentos comprimidos en disco y deja en
+// memoria solo sus encabezados, para que los nodos de larga duración no crezcan sin límite
+type BlockArchive struct {
+	policy   ArchivePolicy
+	segments []ArchiveSegment
+	// Último segmento leído, para no descomprimir el mismo archivo en lecturas seguidas
+	cached      *ArchiveSegment
+	cacheBlocks []*Block
+	mutex       sync.Mutex
+}
+
+// EnableArchive activa el archivo de bloques: carga el índice de segmentos y libera de
+// memoria los bloques que ya estaban archivados
+func (bc *Blockchain) EnableArchive(policy ArchivePolicy) error {
+	if policy.KeepRecent < 1 {
+		return errors.New("se debe conservar al menos un bloque reciente en memoria")
+	}
+	if policy.SegmentSize < 1 {
+		return errors.New("tamaño de segmento inválido")
+	}
+	if err := os.MkdirAll(policy.Dir, 0755); err != nil {
+		return err
+	}
+
+	archive := &BlockArchive{policy: policy}
+	data, err := os.ReadFile(archive.indexPath())
+	if err == nil {
+		if err := json.Unmarshal(data, &archive.segments); err != nil {
+			return fmt.Errorf("índice de archivo inválido: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	// Solo se liberan los bloques cuyo segmento coincide con la cadena actual
+	chain := bc.Blocks()
+	for i, segment := range archive.segments {
+		blocks, err := archive.readSegment(&archive.segments[i])
+		if err == nil && segment.End <= len(chain) && sameBlocks(blocks, chain[segment.Start:segment.End]) {
+			bc.pruneRange(segment.Start, segment.End)
+			continue
+		}
+		logf("⚠️ Segmento %s no corresponde a la cadena, se reconstruye el archivo\n", segment.File)
+		archive.segments = archive.segments[:i]
+		if err := archive.saveIndex(); err != nil {
+			return err
+		}
+		break
+	}
+
+	bc.archive = archive
+	logf("🗄️ Archivo de bloques activo en %s (%d segmentos)\n", policy.Dir, len(archive.segments))
+	return nil
+}
+
+// ArchiveOldBlocks archiva en segmentos completos los bloques más antiguos que los
+// KeepRecent recientes y retorna cuántos bloques se liberaron de memoria
+func (bc *Blockchain) ArchiveOldBlocks() (int, error) {
+	if bc.archive == nil {
+		return 0, nil
+	}
+	archive := bc.archive
+	archived := 0
+
+	// Nadie agrega ni reemplaza bloques mientras se archiva
+	bc.writeMutex.Lock()
+	defer bc.writeMutex.Unlock()
+
+	for {
+		start := archive.archivedUpTo()
+		end := start + archive.policy.SegmentSize
+		chain := bc.Blocks()
+		if end > len(chain)-archive.policy.KeepRecent {
+			break
+		}
+		if err := archive.writeSegment(chain[start:end]); err != nil {
+			return archived, err
+		}
+		bc.pruneRange(start, end)
+		archived += end - start
+	}
+
+	if archived > 0 {
+		logf("🗄️ %d bloques archivados (hasta la altura %d)\n", archived, archive.archivedUpTo())
+	}
+	return archived, nil
+}
+
+// RunArchive archiva periódicamente los bloques antiguos
+func (bc *Blockchain) RunArchive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := bc.ArchiveOldBlocks(); err != nil {
+			logf("❌ Error archivando bloques: %v\n", err)
+		}
+	}
+}
+
+// ArchiveStatus retorna el estado del archivo, o nil si no está activo
+func (bc *Blockchain) ArchiveStatus() *ArchiveStatus {
+	if bc.archive == nil {
+		return nil
+	}
+	archive := bc.archive
+	archive.mutex.Lock()
+	defer archive.mutex.Unlock()
+
+	archived := 0
+	if len(archive.segments) > 0 {
+		archived = archive.segments[len(archive.segments)-1].End
+	}
+	return &ArchiveStatus{
+		Dir:         archive.policy.Dir,
+		KeepRecent:  archive.policy.KeepRecent,
+		SegmentSize: archive.policy.SegmentSize,
+		Archived:    archived,
+		InMemory:    bc.Len() - archived,
+		Segments:    append([]ArchiveSegment(nil), archive.segments...),
+	}
+}
+
+// BlockAt retorna el bloque completo en la altura indicada, leyéndolo del archivo si fue archivado
+func (bc *Blockchain) BlockAt(height int) (*Block, error) {
+	chain := bc.Blocks()
+	if height < 0 || height >= len(chain) {
+		return nil, fmt.Errorf("altura %d fuera de rango", height)
+	}
+	block := chain[height]
+	if !block.Pruned {
+		return block, nil
+	}
+	if bc.archive == nil {
+		return nil, fmt.Errorf("bloque %d archivado pero el archivo no está activo", height)
+	}
+	return bc.archive.blockAt(height)
+}
+
+// FullChain retorna la cadena con todos los bloques completos, leyendo los archivados del disco
+func (bc *Blockchain) FullChain() ([]*Block, error) {
+	blocks := bc.Blocks()
+	chain := make([]*Block, len(blocks))
+	for i, block := range blocks {
+		if !block.Pruned {
+			chain[i] = block
+			continue
+		}
+		full, err := bc.BlockAt(i)
+		if err != nil {
+			return nil, err
+		}
+		chain[i] = full
+	}
+	return chain, nil
+}
+
+// resetArchive descarta los segmentos al reemplazar la cadena; se vuelven a generar después
+func (bc *Blockchain) resetArchive() {
+	if bc.archive == nil {
+		return
+	}
+	archive := bc.archive
+	archive.mutex.Lock()
+	defer archive.mutex.Unlock()
+
+	for _, segment := range archive.segments {
+		os.Remove(filepath.Join(archive.policy.Dir, segment.File))
+	}
+	archive.segments = nil
+	archive.cached = nil
+	archive.cacheBlocks = nil
+	if err := archive.saveIndex(); err != nil {
+		logf("❌ Error reiniciando índice de archivo: %v\n", err)
+	}
+}
+
+// pruneRange reemplaza los bloques del rango por sus encabezados. Arma un slice nuevo
+// para no modificar las vistas de la cadena que tengan los lectores.
+func (bc *Blockchain) pruneRange(start int, end int) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	chain := make([]*Block, len(bc.chain))
+	copy(chain, bc.chain)
+	for i := start; i < end; i++ {
+		block := chain[i]
+		chain[i] = &Block{
+			Index:        block.Index,
+			Timestamp:    block.Timestamp,
+			PreviousHash: block.PreviousHash,
+			Hash:         block.Hash,
+			Nonce:        block.Nonce,
+			Type:         block.Type,
+			Pruned:       true,
+			Signature:    block.Signature,
+			SignerNodeID: block.SignerNodeID,
+			SignerKeyID:  block.SignerKeyID,
+			MerkleRoot:   block.MerkleRoot,
+			Transactions: block.Transactions,
+			HashVersion:  block.HashVersion,
+		}
+	}
+	bc.chain = chain
+}
+
+// archivedUpTo retorna la altura hasta la que hay bloques archivados
+func (ba *BlockArchive) archivedUpTo() int {
+	ba.mutex.Lock()
+	defer ba.mutex.Unlock()
+
+	if len(ba.segments) == 0 {
+		return 0
+	}
+	return ba.segments[len(ba.segments)-1].End
+}
+
+// writeSegment guarda los bloques en un segmento gzip y actualiza el índice
+func (ba *BlockArchive) writeSegment(blocks []*Block) error {
+	start := blocks[0].Index
+	end := start + len(blocks)
+	segment := ArchiveSegment{
+		Start:     start,
+		End:       end,
+		File:      fmt.Sprintf("segment-%010d-%010d.jsonl.gz", start, end),
+		CreatedAt: time.Now(),
+	}
+	path := filepath.Join(ba.policy.Dir, segment.File)
+
+	file, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	hasher := sha256.New()
+	writer := gzip.NewWriter(io.MultiWriter(file, hasher))
+	encoder := json.NewEncoder(writer)
+	for _, block := range blocks {
+		if block.Pruned {
+			file.Close()
+			return fmt.Errorf("el bloque %d ya fue archivado", block.Index)
+		}
+		if err := encoder.Encode(block); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	info, err := file.Stat()
+	file.Close()
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+
+	segment.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+	segment.Size = info.Size()
+
+	ba.mutex.Lock()
+	defer ba.mutex.Unlock()
+	ba.segments = append(ba.segments, segment)
+	return ba.saveIndex()
+}
+
+// blockAt lee un bloque archivado desde su segmento
+func (ba *BlockArchive) blockAt(height int) (*Block, error) {
+	ba.mutex.Lock()
+	defer ba.mutex.Unlock()
+
+	for i := range ba.segments {
+		segment := &ba.segments[i]
+		if height < segment.Start || height >= segment.End {
+			continue
+		}
+		if ba.cached != segment {
+			blocks, err := ba.readSegment(segment)
+			if err != nil {
+				return nil, err
+			}
+			ba.cached = segment
+			ba.cacheBlocks = blocks
+		}
+		return ba.cacheBlocks[height-segment.Start], nil
+	}
+	return nil, fmt.Errorf("bloque %d no encontrado en el archivo", height)
+}
+
+// readSegment descomprime un segmento verificando su checksum y el hash de cada bloque
+func (ba *BlockArchive) readSegment(segment *ArchiveSegment) ([]*Block, error) {
+	data, err := os.ReadFile(filepath.Join(ba.policy.Dir, segment.File))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != segment.SHA256 {
+		return nil, fmt.Errorf("segmento %s corrupto: checksum no coincide", segment.File)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var blocks []*Block
+	decoder := json.NewDecoder(reader)
+	for decoder.More() {
+		var block Block
+		if err := decoder.Decode(&block); err != nil {
+			return nil, fmt.Errorf("segmento %s corrupto: %v", segment.File, err)
+		}
+		if !block.Withheld && !block.IsValid() {
+			return nil, fmt.Errorf("segmento %s corrupto: hash inválido en el bloque %d", segment.File, block.Index)
+		}
+		blocks = append(blocks, &block)
+	}
+	if len(blocks) != segment.End-segment.Start {
+		return nil, fmt.Errorf("segmento %s incompleto", segment.File)
+	}
+	return blocks, nil
+}
+
+// saveIndex guarda el índice de segmentos; requiere el lock del archivo
+func (ba *BlockArchive) saveIndex() error {
+	data, err := json.MarshalIndent(ba.segments, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := ba.indexPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ba.indexPath())
+}
+
+func (ba *BlockArchive) indexPath() string {
+	return filepath.Join(ba.policy.Dir, "index.json")
+}
+
+// sameBlocks indica si dos tramos de cadena tienen los mismos hashes
+func sameBlocks(a []*Block, b []*Block) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Hash != b[i].Hash {
+			return false
+		}
+	}
+	return true
+}
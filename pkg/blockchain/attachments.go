@@ -0,0 +1,350 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+
+	"github.com/google/uuid"
+)
+
+// MaxAttachmentSize limita el tamaño de cada documento adjunto (50 MB)
+const MaxAttachmentSize = 50 << 20
+
+// Orden de los estados del proceso, para las condiciones "visible desde el estado".
+// REJECTED no está: un proceso rechazado nunca alcanza los estados posteriores.
+var contractLifecycle = []ContractStatus{
+	StatusDraft,
+	StatusTechnicalReview,
+	StatusTechnicalApproved,
+	StatusLegalReview,
+	StatusLegalApproved,
+	StatusContractsReview,
+	StatusContractsApproved,
+	StatusAdminReview,
+	StatusAdminApproved,
+	StatusBudgetReview,
+	StatusAuthorizedForPublication,
+	StatusPublished,
+	StatusProposalsReceived,
+	StatusEvaluated,
+	StatusAwarded,
+	StatusExecuted,
+	StatusCompleted,
+	StatusUnderAudit,
+	StatusAuditObservations,
+}
+
+// AttachmentVisibility define desde cuándo un adjunto es público. Si se indican ambas
+// condiciones deben cumplirse las dos; sin condiciones el adjunto es público desde que se carga.
+type AttachmentVisibility struct {
+	VisibleAfter      *time.Time     `json:"visible_after,omitempty"`
+	VisibleFromStatus ContractStatus `json:"visible_from_status,omitempty"`
+}
+
+// Attachment es un documento de un proceso (p. ej. la planilla de evaluación) guardado
+// fuera de la cadena. Su hash y sus condiciones de visibilidad se anclan al cargarlo.
+type Attachment struct {
+	ID          string               `json:"id"`
+	ContractID  string               `json:"contract_id"`
+	Category    string               `json:"category"`
+	FileName    string               `json:"file_name"`
+	MediaType   string               `json:"media_type"`
+	Size        int64                `json:"size"`
+	SHA256      string               `json:"sha256"`
+	Description string               `json:"description,omitempty"`
+	Visibility  AttachmentVisibility `json:"visibility"`
+	UploadedBy  string               `json:"uploaded_by"`
+	Role        AdminRole            `json:"role"`
+	UploadedAt  time.Time            `json:"uploaded_at"`
+	BlockHash   string               `json:"block_hash"`
+	NoIndex     bool                 `json:"no_index,omitempty"` // Excluido de la búsqueda por contenido (procesos reservados)
+}
+
+// AttachmentStore guarda los documentos adjuntos en disco y su registro en el almacenamiento
+type AttachmentStore struct {
+	dir         string
+	blockchain  *Blockchain
+	attachments map[string]*Attachment
+	Index       *ContentIndex
+	mutex       sync.RWMutex
+}
+
+// NewAttachmentStore crea el almacén de adjuntos en el directorio indicado
+func NewAttachmentStore(dir string, bc *Blockchain) (*AttachmentStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	as := &AttachmentStore{
+		dir:         dir,
+		blockchain:  bc,
+		attachments: make(map[string]*Attachment),
+		Index:       newContentIndex(bc.store),
+	}
+	bc.store.ForEach(storage.BucketAttachments, func(key string, value []byte) error {
+		var attachment Attachment
+		if err := json.Unmarshal(value, &attachment); err == nil {
+			as.attachments[key] = &attachment
+		}
+		return nil
+	})
+	return as, nil
+}
+
+// ValidateVisibility verifica que las condiciones de visibilidad sean interpretables
+func ValidateVisibility(visibility AttachmentVisibility) error {
+	if visibility.VisibleFromStatus != "" && lifecycleIndex(visibility.VisibleFromStatus) < 0 {
+		return fmt.Errorf("estado de visibilidad inválido: %s", visibility.VisibleFromStatus)
+	}
+	return nil
+}
+
+// IsDue indica si ya se cumplieron las condiciones para revelar el adjunto al público
+func (v AttachmentVisibility) IsDue(contract *Contract, now time.Time) bool {
+	if v.VisibleAfter != nil && now.Before(*v.VisibleAfter) {
+		return false
+	}
+	if v.VisibleFromStatus != "" {
+		current := lifecycleIndex(contract.Status)
+		if current < 0 || current < lifecycleIndex(v.VisibleFromStatus) {
+			return false
+		}
+	}
+	return true
+}
+
+// lifecycleIndex retorna la posición del estado en el ciclo del proceso, o -1
+func lifecycleIndex(status ContractStatus) int {
+	for i, candidate := range contractLifecycle {
+		if candidate == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// Upload guarda un documento adjunto de un funcionario y ancla su hash y sus condiciones
+// de visibilidad en la cadena. El nombre y la descripción no se anclan para no revelarlos.
+// Salvo que se excluya con noIndex, el texto del documento se indexa en este nodo.
+func (as *AttachmentStore) Upload(contractID string, uploadedBy string, role AdminRole, category string, fileName string, mediaType string, description string, visibility AttachmentVisibility, noIndex bool, content []byte) (*Attachment, error) {
+	unlock := as.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := as.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if category == "" {
+		return nil, errors.New("el adjunto requiere una categoría")
+	}
+	if len(content) == 0 {
+		return nil, errors.New("archivo vacío")
+	}
+	if len(content) > MaxAttachmentSize {
+		return nil, errors.New("el archivo supera el tamaño máximo permitido")
+	}
+	if err := ValidateVisibility(visibility); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(content)
+	attachment := &Attachment{
+		ID:          uuid.New().String(),
+		ContractID:  contractID,
+		Category:    category,
+		FileName:    filepath.Base(fileName),
+		MediaType:   mediaType,
+		Size:        int64(len(content)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		Description: description,
+		Visibility:  visibility,
+		UploadedBy:  uploadedBy,
+		Role:        role,
+		UploadedAt:  time.Now(),
+		NoIndex:     noIndex,
+	}
+
+	if err := os.WriteFile(as.path(attachment), content, 0644); err != nil {
+		return nil, err
+	}
+
+	blockData := map[string]interface{}{
+		"type":          "CONTRACT_ATTACHMENT",
+		"contract_id":   contractID,
+		"attachment_id": attachment.ID,
+		"category":      category,
+		"sha256":        attachment.SHA256,
+		"uploaded_by":   uploadedBy,
+		"role":          string(role),
+		"timestamp":     attachment.UploadedAt,
+	}
+	if visibility.VisibleAfter != nil {
+		blockData["visible_after"] = *visibility.VisibleAfter
+	}
+	if visibility.VisibleFromStatus != "" {
+		blockData["visible_from_status"] = string(visibility.VisibleFromStatus)
+	}
+	block, err := as.blockchain.SealBlock(blockData)
+	if err != nil {
+		os.Remove(as.path(attachment))
+		return nil, err
+	}
+	attachment.BlockHash = block.Hash
+
+	as.mutex.Lock()
+	as.attachments[attachment.ID] = attachment
+	as.mutex.Unlock()
+	as.blockchain.saveState(storage.BucketAttachments, attachment.ID, attachment)
+
+	contract.UpdatedAt = time.Now()
+	as.blockchain.WorkflowManager.addAuditEntry(contract, "CONTRACT_ATTACHMENT", uploadedBy, role, "Adjunto cargado: "+category)
+	as.blockchain.saveContract(contract)
+
+	if !noIndex {
+		if err := as.Index.Index(attachment, content); err != nil {
+			logf("⚠️ Adjunto %s sin indexar: %v\n", attachment.ID, err)
+		}
+	}
+
+	copied := *attachment
+	return &copied, nil
+}
+
+// SetIndexing incluye o excluye un adjunto de la búsqueda por contenido. Al excluirlo se
+// borra su texto del índice del nodo.
+func (as *AttachmentStore) SetIndexing(contractID string, attachmentID string, noIndex bool) (*Attachment, error) {
+	as.mutex.Lock()
+	attachment, exists := as.attachments[attachmentID]
+	if !exists || attachment.ContractID != contractID {
+		as.mutex.Unlock()
+		return nil, errors.New("adjunto no encontrado")
+	}
+	attachment.NoIndex = noIndex
+	copied := *attachment
+	as.mutex.Unlock()
+	as.blockchain.saveState(storage.BucketAttachments, attachmentID, &copied)
+
+	if noIndex {
+		if err := as.Index.Remove(attachmentID); err != nil {
+			return nil, err
+		}
+		return &copied, nil
+	}
+	_, content, err := as.Open(contractID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := as.Index.Index(&copied, content); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+// IndexPending indexa los adjuntos que aún no están en el índice, como los cargados antes
+// de activar la búsqueda por contenido o con un extractor que no estaba disponible
+func (as *AttachmentStore) IndexPending() {
+	as.mutex.RLock()
+	pending := make([]Attachment, 0)
+	for _, attachment := range as.attachments {
+		if !attachment.NoIndex && !as.Index.IsIndexed(attachment.ID) {
+			pending = append(pending, *attachment)
+		}
+	}
+	as.mutex.RUnlock()
+
+	indexed := 0
+	for _, attachment := range pending {
+		_, content, err := as.Open(attachment.ContractID, attachment.ID)
+		if err != nil {
+			continue
+		}
+		if err := as.Index.Index(&attachment, content); err != nil {
+			logf("⚠️ Adjunto %s sin indexar: %v\n", attachment.ID, err)
+			continue
+		}
+		if as.Index.IsIndexed(attachment.ID) {
+			indexed++
+		}
+	}
+	if indexed > 0 {
+		logf("🔎 %d adjuntos indexados para búsqueda por contenido\n", indexed)
+	}
+}
+
+// SearchContent busca en el texto de los adjuntos indexados que no fueron excluidos
+func (as *AttachmentStore) SearchContent(query string) []ContentMatch {
+	matches := as.Index.Search(query)
+
+	as.mutex.RLock()
+	defer as.mutex.RUnlock()
+
+	result := make([]ContentMatch, 0, len(matches))
+	for _, match := range matches {
+		if attachment, exists := as.attachments[match.AttachmentID]; exists && !attachment.NoIndex {
+			result = append(result, match)
+		}
+	}
+	return result
+}
+
+// Get retorna un adjunto por su ID
+func (as *AttachmentStore) Get(attachmentID string) (*Attachment, error) {
+	as.mutex.RLock()
+	defer as.mutex.RUnlock()
+
+	attachment, exists := as.attachments[attachmentID]
+	if !exists {
+		return nil, errors.New("adjunto no encontrado")
+	}
+	copied := *attachment
+	return &copied, nil
+}
+
+// List retorna los adjuntos de un contrato en orden de carga
+func (as *AttachmentStore) List(contractID string) []Attachment {
+	as.mutex.RLock()
+	defer as.mutex.RUnlock()
+
+	attachments := []Attachment{}
+	for _, attachment := range as.attachments {
+		if attachment.ContractID == contractID {
+			attachments = append(attachments, *attachment)
+		}
+	}
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].UploadedAt.Before(attachments[j].UploadedAt) })
+	return attachments
+}
+
+// Open retorna el contenido de un adjunto verificando que coincida con el hash anclado
+func (as *AttachmentStore) Open(contractID string, attachmentID string) (*Attachment, []byte, error) {
+	as.mutex.RLock()
+	attachment, exists := as.attachments[attachmentID]
+	as.mutex.RUnlock()
+	if !exists || attachment.ContractID != contractID {
+		return nil, nil, errors.New("adjunto no encontrado")
+	}
+
+	content, err := os.ReadFile(as.path(attachment))
+	if err != nil {
+		return nil, nil, errors.New("archivo adjunto no disponible en este nodo")
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != attachment.SHA256 {
+		return nil, nil, errors.New("el archivo no coincide con el hash anclado en la cadena")
+	}
+	copied := *attachment
+	return &copied, content, nil
+}
+
+func (as *AttachmentStore) path(attachment *Attachment) string {
+	return filepath.Join(as.dir, attachment.ID)
+}
@@ -0,0 +1,615 @@
+package blockchain
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Modos de consenso soportados
+const (
+	ConsensusLongestChain = "longest-chain"
+	ConsensusPoA          = "poa"
+)
+
+// Tipo de bloque de gobernanza que modifica el conjunto de validadores autorizados
+const GovernanceBlockType = "VALIDATOR_SET_UPDATE"
+
+// Acciones de los bloques de gobernanza
+const (
+	GovernanceAddValidator    = "ADD_VALIDATOR"
+	GovernanceRemoveValidator = "REMOVE_VALIDATOR"
+	GovernanceUpdateKeys      = "UPDATE_KEYS"
+)
+
+// Authority es un nodo autorizado para firmar bloques en modo prueba de autoridad
+type Authority struct {
+	NodeID     string          `json:"node_id"`
+	PublicKeys []PublicKeyInfo `json:"public_keys"`
+	AddedAt    int             `json:"added_at"` // altura del bloque de gobernanza que lo agregó (0 si es de génesis)
+}
+
+// governanceChange es el contenido de un bloque de gobernanza
+type governanceChange struct {
+	Action           string          `json:"action"`
+	NodeID           string          `json:"node_id"`
+	PublicKeys       []PublicKeyInfo `json:"public_keys,omitempty"`
+	ProposedBy       string          `json:"proposed_by"`
+	EndorsementKeyID string          `json:"endorsement_kid,omitempty"`
+	Endorsement      string          `json:"endorsement,omitempty"`
+}
+
+// authoritySet es el conjunto de validadores vigente a una altura
+type authoritySet map[string]*Authority
+
+// proofOfAuthority guarda la configuración de consenso PoA y el conjunto vigente en la punta
+type proofOfAuthority struct {
+	activationHeight int
+	genesis          authoritySet
+	current          authoritySet
+	mutex            sync.RWMutex
+}
+
+// ParseAuthorities interpreta el conjunto de validadores de génesis en formato
+// "NODO:llave_publica_base64,NODO2:llave_publica_base64"
+func ParseAuthorities(value string) ([]Authority, error) {
+	var authorities []Authority
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("validador inválido %q, se esperaba NODO:llave_publica", entry)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("validador %s repetido", parts[0])
+		}
+		seen[parts[0]] = true
+
+		publicKey, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil || len(publicKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("llave pública inválida para el validador %s", parts[0])
+		}
+		authorities = append(authorities, Authority{
+			NodeID: parts[0],
+			PublicKeys: []PublicKeyInfo{{
+				KeyID:     KeyIDFor(publicKey),
+				PublicKey: parts[1],
+			}},
+		})
+	}
+	if len(authorities) == 0 {
+		return nil, errors.New("el conjunto de validadores no puede estar vacío")
+	}
+	return authorities, nil
+}
+
+// EnableProofOfAuthority activa el consenso por prueba de autoridad: desde la altura de
+// activación solo se aceptan bloques firmados por un validador autorizado, y el conjunto
+// de validadores parte de los de génesis y cambia únicamente por bloques de gobernanza.
+// La cadena local debe cumplir esas reglas.
+func (bc *Blockchain) EnableProofOfAuthority(genesis []Authority, activationHeight int) error {
+	if activationHeight < 1 {
+		return errors.New("la altura de activación debe ser al menos 1 (el bloque génesis no tiene firma)")
+	}
+	if len(genesis) == 0 {
+		return errors.New("el conjunto de validadores no puede estar vacío")
+	}
+
+	poa := &proofOfAuthority{
+		activationHeight: activationHeight,
+		genesis:          make(authoritySet),
+	}
+	for i := range genesis {
+		authority := genesis[i]
+		if err := validateAuthorityKeys(authority.PublicKeys); err != nil {
+			return fmt.Errorf("validador %s: %v", authority.NodeID, err)
+		}
+		authority.AddedAt = 0
+		poa.genesis[authority.NodeID] = &authority
+	}
+
+	chain, err := bc.FullChain()
+	if err != nil {
+		return err
+	}
+	current, err := poa.verifyChain(chain)
+	if err != nil {
+		return fmt.Errorf("la cadena local no cumple el consenso por autoridad: %v", err)
+	}
+	poa.current = current
+	bc.poa = poa
+
+	logf("🏛️ Consenso por autoridad desde la altura %d con %d validadores\n", activationHeight, len(current))
+	return nil
+}
+
+// ConsensusMode retorna el modo de consenso del nodo
+func (bc *Blockchain) ConsensusMode() string {
+	if bc.poa == nil {
+		return ConsensusLongestChain
+	}
+	return ConsensusPoA
+}
+
+// AuthorityActivationHeight retorna la altura desde la que rige la prueba de autoridad (0 si no está activa)
+func (bc *Blockchain) AuthorityActivationHeight() int {
+	if bc.poa == nil {
+		return 0
+	}
+	return bc.poa.activationHeight
+}
+
+// Authorities lista los validadores autorizados en la punta de la cadena
+func (bc *Blockchain) Authorities() []Authority {
+	if bc.poa == nil {
+		return []Authority{}
+	}
+	bc.poa.mutex.RLock()
+	defer bc.poa.mutex.RUnlock()
+
+	authorities := make([]Authority, 0, len(bc.poa.current))
+	for _, authority := range bc.poa.current {
+		authorities = append(authorities, *authority)
+	}
+	sort.Slice(authorities, func(i, j int) bool { return authorities[i].NodeID < authorities[j].NodeID })
+	return authorities
+}
+
+// IsAuthority indica si el nodo está autorizado para firmar bloques. Sin prueba de
+// autoridad cualquier nodo puede hacerlo.
+func (bc *Blockchain) IsAuthority(nodeID string) bool {
+	if bc.poa == nil {
+		return true
+	}
+	bc.poa.mutex.RLock()
+	defer bc.poa.mutex.RUnlock()
+	_, authorized := bc.poa.current[nodeID]
+	return authorized
+}
+
+// authorityKeys retorna las llaves que la cadena tiene registradas para el validador;
+// requiere la prueba de autoridad activa
+func (bc *Blockchain) authorityKeys(nodeID string) ([]PublicKeyInfo, bool) {
+	bc.poa.mutex.RLock()
+	defer bc.poa.mutex.RUnlock()
+	authority, exists := bc.poa.current[nodeID]
+	if !exists {
+		return nil, false
+	}
+	return authority.PublicKeys, true
+}
+
+// ProposeValidatorChange registra un bloque de gobernanza que agrega o retira un validador.
+// Solo un validador autorizado puede proponerlo, y el bloque queda firmado por él.
+func (bc *Blockchain) ProposeValidatorChange(action string, nodeID string, publicKeys []PublicKeyInfo) error {
+	if bc.poa == nil {
+		return errors.New("el nodo no usa consenso por autoridad")
+	}
+	if action != GovernanceAddValidator && action != GovernanceRemoveValidator {
+		return fmt.Errorf("acción de gobernanza desconocida: %s", action)
+	}
+
+	change := governanceChange{
+		Action:     action,
+		NodeID:     nodeID,
+		PublicKeys: publicKeys,
+		ProposedBy: bc.signerID,
+	}
+	return bc.addGovernanceBlock(change)
+}
+
+// PublishSignerKeys registra en la cadena las llaves actuales del nodo después de una
+// rotación, endosadas con la llave anterior para que los demás validadores las acepten.
+// Sin prueba de autoridad o si el nodo no es validador no hace nada.
+func (bc *Blockchain) PublishSignerKeys(previousKeyID string) error {
+	if bc.poa == nil || bc.signerKeys == nil || !bc.IsAuthority(bc.signerID) {
+		return nil
+	}
+
+	keys := bc.signerKeys.PublicKeys()
+	endorsement, err := bc.signerKeys.SignWith(previousKeyID, keyUpdatePayload(bc.signerID, keys))
+	if err != nil {
+		return err
+	}
+
+	change := governanceChange{
+		Action:           GovernanceUpdateKeys,
+		NodeID:           bc.signerID,
+		PublicKeys:       keys,
+		ProposedBy:       bc.signerID,
+		EndorsementKeyID: previousKeyID,
+		Endorsement:      endorsement,
+	}
+	return bc.addGovernanceBlock(change)
+}
+
+// addGovernanceBlock valida el cambio contra el conjunto vigente y lo agrega a la cadena
+func (bc *Blockchain) addGovernanceBlock(change governanceChange) error {
+	bc.poa.mutex.RLock()
+	_, err := bc.poa.current.apply(change, bc.Len())
+	bc.poa.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	blockData := map[string]interface{}{
+		"type":        GovernanceBlockType,
+		"action":      change.Action,
+		"node_id":     change.NodeID,
+		"proposed_by": change.ProposedBy,
+		"timestamp":   time.Now(),
+	}
+	if len(change.PublicKeys) > 0 {
+		// Como valores JSON genéricos, para que el hash no cambie al retransmitir el bloque
+		var keys []interface{}
+		encoded, _ := json.Marshal(change.PublicKeys)
+		if err := json.Unmarshal(encoded, &keys); err != nil {
+			return err
+		}
+		blockData["public_keys"] = keys
+	}
+	if change.Endorsement != "" {
+		blockData["endorsement_kid"] = change.EndorsementKeyID
+		blockData["endorsement"] = change.Endorsement
+	}
+	if err := bc.AddBlock(blockData); err != nil {
+		return err
+	}
+
+	logf("🏛️ Gobernanza: %s %s\n", change.Action, change.NodeID)
+	return nil
+}
+
+// checkAuthority verifica antes de agregar un bloque que su firmante esté autorizado.
+// Los bloques creados por este nodo requieren que sea validador; los recibidos de peers
+// ya se verificaron contra el conjunto vigente al recibirlos.
+func (bc *Blockchain) checkAuthority(block *Block) error {
+	if bc.poa == nil || block.Index < bc.poa.activationHeight || isReceivedBlock(block) {
+		return nil
+	}
+	if !bc.IsAuthority(bc.signerID) {
+		return fmt.Errorf("el nodo %s no es un validador autorizado y no puede crear bloques", bc.signerID)
+	}
+	return nil
+}
+
+// verifyAuthorityBlock verifica que un bloque recibido esté firmado por un validador
+// autorizado con una llave registrada en la cadena y, si es de gobernanza, que el
+// cambio sea aplicable al conjunto vigente
+func (bc *Blockchain) verifyAuthorityBlock(block Block) error {
+	bc.poa.mutex.RLock()
+	defer bc.poa.mutex.RUnlock()
+
+	height := bc.Len()
+	if height >= bc.poa.activationHeight {
+		if err := bc.poa.current.verifyBlock(block, height); err != nil {
+			return err
+		}
+	}
+	if block.Type != GovernanceBlockType {
+		return nil
+	}
+	change, err := parseGovernanceChange(&block)
+	if err != nil {
+		return err
+	}
+	_, err = bc.poa.current.apply(change, height)
+	return err
+}
+
+// applyGovernance actualiza el conjunto vigente con un bloque ya agregado a la cadena
+func (bc *Blockchain) applyGovernance(block *Block) {
+	if bc.poa == nil || block.Type != GovernanceBlockType {
+		return
+	}
+	change, err := parseGovernanceChange(block)
+	if err != nil {
+		logf("❌ Bloque de gobernanza %d ilegible: %v\n", block.Index, err)
+		return
+	}
+
+	bc.poa.mutex.Lock()
+	defer bc.poa.mutex.Unlock()
+	next, err := bc.poa.current.apply(change, block.Index)
+	if err != nil {
+		logf("❌ Bloque de gobernanza %d no aplicable: %v\n", block.Index, err)
+		return
+	}
+	bc.poa.current = next
+}
+
+// rebuildAuthorities recalcula el conjunto vigente después de reemplazar la cadena
+func (bc *Blockchain) rebuildAuthorities() {
+	if bc.poa == nil {
+		return
+	}
+	chain, err := bc.FullChain()
+	if err != nil {
+		logf("❌ Error recalculando validadores: %v\n", err)
+		return
+	}
+	current, err := bc.poa.verifyChain(chain)
+	if err != nil {
+		logf("❌ La cadena no cumple el consenso por autoridad: %v\n", err)
+		return
+	}
+	bc.poa.mutex.Lock()
+	bc.poa.current = current
+	bc.poa.mutex.Unlock()
+}
+
+// verifyAuthorityChain verifica que una cadena candidata cumpla la prueba de autoridad.
+// Sin PoA activo no hay restricciones.
+func (bc *Blockchain) verifyAuthorityChain(chain []*Block) error {
+	if bc.poa == nil {
+		return nil
+	}
+	_, err := bc.poa.verifyChain(chain)
+	return err
+}
+
+// genesisAuthorities lista los validadores de génesis
+func (poa *proofOfAuthority) genesisAuthorities() []Authority {
+	authorities := make([]Authority, 0, len(poa.genesis))
+	for _, authority := range poa.genesis {
+		authorities = append(authorities, *authority)
+	}
+	sort.Slice(authorities, func(i, j int) bool { return authorities[i].NodeID < authorities[j].NodeID })
+	return authorities
+}
+
+// verifyChain recorre la cadena desde génesis aplicando los bloques de gobernanza y
+// comprobando que cada bloque desde la activación lo firme un validador vigente a su altura
+func (poa *proofOfAuthority) verifyChain(chain []*Block) (authoritySet, error) {
+	set := poa.genesis.clone()
+	for _, block := range chain {
+		if block.Index >= poa.activationHeight {
+			if err := set.verifyBlock(*block, block.Index); err != nil {
+				return nil, fmt.Errorf("bloque %d: %v", block.Index, err)
+			}
+		}
+		if block.Type != GovernanceBlockType {
+			continue
+		}
+		change, err := parseGovernanceChange(block)
+		if err != nil {
+			return nil, fmt.Errorf("bloque %d: %v", block.Index, err)
+		}
+		next, err := set.apply(change, block.Index)
+		if err != nil {
+			return nil, fmt.Errorf("bloque %d: %v", block.Index, err)
+		}
+		set = next
+	}
+	return set, nil
+}
+
+// verifyBlock comprueba que el firmante del bloque pertenezca al conjunto. Un bloque que
+// registra las llaves nuevas de su propio firmante se verifica con esas llaves, siempre que
+// el endoso de la llave anterior sea válido.
+func (set authoritySet) verifyBlock(block Block, height int) error {
+	// Los bloques retransmitidos los firma el nodo que los recibió; vale la firma de quien los creó
+	if isReceivedBlock(&block) {
+		origin, err := relayedOrigin(block)
+		if err != nil {
+			return err
+		}
+		block = origin
+	}
+
+	if _, authorized := set[block.SignerNodeID]; !authorized {
+		if block.SignerNodeID == "" {
+			return errors.New("bloque sin firma")
+		}
+		return fmt.Errorf("el nodo %s no es un validador autorizado", block.SignerNodeID)
+	}
+
+	err := VerifyBlockSignature(block, set.publicKeys())
+	if err == nil || block.Type != GovernanceBlockType {
+		return err
+	}
+	change, parseErr := parseGovernanceChange(&block)
+	if parseErr != nil || change.Action != GovernanceUpdateKeys || change.NodeID != block.SignerNodeID {
+		return err
+	}
+	next, applyErr := set.apply(change, height)
+	if applyErr != nil {
+		return applyErr
+	}
+	return VerifyBlockSignature(block, next.publicKeys())
+}
+
+// relayedOrigin reconstruye el bloque original a partir de un bloque retransmitido y
+// comprueba que su hash corresponda al contenido
+func relayedOrigin(block Block) (Block, error) {
+	var header struct {
+		Index        int    `json:"index"`
+		Hash         string `json:"hash"`
+		Signature    string `json:"signature"`
+		SignerNodeID string `json:"signer_node_id"`
+		SignerKeyID  string `json:"signer_kid"`
+		HashVersion  int    `json:"hash_version"`
+	}
+	encoded, err := json.Marshal(block.Data["origin"])
+	if err != nil || json.Unmarshal(encoded, &header) != nil || header.Hash == "" {
+		return Block{}, errors.New("bloque retransmitido sin encabezado de origen")
+	}
+
+	var timestamp time.Time
+	switch value := block.Data["timestamp"].(type) {
+	case time.Time:
+		timestamp = value
+	case string:
+		if timestamp, err = time.Parse(time.RFC3339Nano, value); err != nil {
+			return Block{}, errors.New("bloque retransmitido con fecha de origen inválida")
+		}
+	}
+	var nonce int
+	switch value := block.Data["nonce"].(type) {
+	case int:
+		nonce = value
+	case float64:
+		nonce = int(value)
+	}
+	previousHash, _ := block.Data["previous_hash"].(string)
+	data, _ := block.Data["data"].(map[string]interface{})
+
+	origin := Block{
+		Index:        header.Index,
+		Timestamp:    timestamp,
+		Data:         data,
+		PreviousHash: previousHash,
+		Hash:         header.Hash,
+		Nonce:        nonce,
+		Type:         block.Type,
+		Signature:    header.Signature,
+		SignerNodeID: header.SignerNodeID,
+		SignerKeyID:  header.SignerKeyID,
+		MerkleRoot:   block.MerkleRoot,
+		Transactions: block.Transactions,
+		HashVersion:  header.HashVersion,
+	}
+	// De un contrato reservado el nodo no autorizado solo recibió el encabezado firmado
+	origin.Withheld = isWithheldCopy(&block)
+	if !origin.Withheld && !origin.IsValid() {
+		return Block{}, errors.New("el encabezado de origen no corresponde al contenido retransmitido")
+	}
+	return origin, nil
+}
+
+// apply retorna el conjunto resultante de aplicar un cambio de gobernanza, sin modificar el actual
+func (set authoritySet) apply(change governanceChange, height int) (authoritySet, error) {
+	if change.NodeID == "" {
+		return nil, errors.New("el cambio de gobernanza requiere el nodo")
+	}
+	next := set.clone()
+	existing, exists := set[change.NodeID]
+
+	switch change.Action {
+	case GovernanceAddValidator:
+		if exists {
+			return nil, fmt.Errorf("el nodo %s ya es validador", change.NodeID)
+		}
+		if err := validateAuthorityKeys(change.PublicKeys); err != nil {
+			return nil, err
+		}
+		next[change.NodeID] = &Authority{NodeID: change.NodeID, PublicKeys: change.PublicKeys, AddedAt: height}
+
+	case GovernanceRemoveValidator:
+		if !exists {
+			return nil, fmt.Errorf("el nodo %s no es validador", change.NodeID)
+		}
+		if len(set) == 1 {
+			return nil, errors.New("no se puede retirar al último validador")
+		}
+		delete(next, change.NodeID)
+
+	case GovernanceUpdateKeys:
+		if !exists {
+			return nil, fmt.Errorf("el nodo %s no es validador", change.NodeID)
+		}
+		if err := validateAuthorityKeys(change.PublicKeys); err != nil {
+			return nil, err
+		}
+		// Las llaves anteriores se conservan para poder verificar los bloques que firmaron
+		for _, previous := range existing.PublicKeys {
+			if !containsKey(change.PublicKeys, previous.KeyID) {
+				return nil, fmt.Errorf("la actualización omite la llave anterior %s", previous.KeyID)
+			}
+		}
+		payload := keyUpdatePayload(change.NodeID, change.PublicKeys)
+		if err := VerifyWithKeys(existing.PublicKeys, change.EndorsementKeyID, payload, change.Endorsement); err != nil {
+			return nil, fmt.Errorf("endoso de llaves inválido: %v", err)
+		}
+		next[change.NodeID] = &Authority{NodeID: change.NodeID, PublicKeys: change.PublicKeys, AddedAt: existing.AddedAt}
+
+	default:
+		return nil, fmt.Errorf("acción de gobernanza desconocida: %s", change.Action)
+	}
+	return next, nil
+}
+
+// clone copia el conjunto; las autoridades no se modifican en sitio
+func (set authoritySet) clone() authoritySet {
+	copied := make(authoritySet, len(set))
+	for nodeID, authority := range set {
+		copied[nodeID] = authority
+	}
+	return copied
+}
+
+// publicKeys retorna las llaves de cada validador en el formato de VerifyBlockSignature
+func (set authoritySet) publicKeys() map[string][]PublicKeyInfo {
+	keys := make(map[string][]PublicKeyInfo, len(set))
+	for nodeID, authority := range set {
+		keys[nodeID] = authority.PublicKeys
+	}
+	return keys
+}
+
+// parseGovernanceChange extrae el cambio de un bloque de gobernanza; los recibidos de
+// peers traen los datos originales anidados en "data"
+func parseGovernanceChange(block *Block) (governanceChange, error) {
+	var change governanceChange
+	data := block.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	if data == nil {
+		return change, errors.New("bloque de gobernanza sin datos")
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return change, err
+	}
+	if err := json.Unmarshal(encoded, &change); err != nil {
+		return change, fmt.Errorf("bloque de gobernanza inválido: %v", err)
+	}
+	return change, nil
+}
+
+// validateAuthorityKeys verifica que las llaves estén bien formadas y su kid corresponda
+func validateAuthorityKeys(keys []PublicKeyInfo) error {
+	if len(keys) == 0 {
+		return errors.New("el validador requiere al menos una llave pública")
+	}
+	for _, key := range keys {
+		publicKey, err := base64.StdEncoding.DecodeString(key.PublicKey)
+		if err != nil || len(publicKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("llave pública inválida: %s", key.KeyID)
+		}
+		if KeyIDFor(publicKey) != key.KeyID {
+			return fmt.Errorf("el kid %s no corresponde a la llave pública", key.KeyID)
+		}
+	}
+	return nil
+}
+
+// containsKey indica si la lista incluye la llave con el kid indicado
+func containsKey(keys []PublicKeyInfo, keyID string) bool {
+	for _, key := range keys {
+		if key.KeyID == keyID {
+			return true
+		}
+	}
+	return false
+}
+
+// keyUpdatePayload arma el mensaje que endosa la llave anterior al publicar las nuevas
+func keyUpdatePayload(nodeID string, keys []PublicKeyInfo) []byte {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.KeyID + ":" + key.PublicKey
+	}
+	return []byte(GovernanceUpdateKeys + "|" + nodeID + "|" + strings.Join(parts, ","))
+}
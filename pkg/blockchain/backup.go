@@ -0,0 +1,138 @@
+package blockchain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// BackupVersion es la versión del formato de respaldo cifrado
+const BackupVersion = 1
+
+// Algoritmo con el que se cifra el snapshot usando la llave de datos
+const backupAlgorithm = "AES-256-GCM"
+
+// BackupEncryption describe el cifrado de sobre de un respaldo: la llave de datos
+// viaja envuelta por la llave maestra del KMS
+type BackupEncryption struct {
+	Algorithm   string `json:"algorithm"`
+	KMSProvider string `json:"kms_provider"`
+	KeyID       string `json:"key_id"`
+	WrappedKey  string `json:"wrapped_key"`
+}
+
+// BackupManifest describe el contenido de un respaldo y cómo descifrarlo
+type BackupManifest struct {
+	Version         int              `json:"version"`
+	NodeID          string           `json:"node_id"`
+	CreatedAt       time.Time        `json:"created_at"`
+	SnapshotVersion int              `json:"snapshot_version"`
+	From            int              `json:"from"`
+	Height          int              `json:"height"`
+	TipHash         string           `json:"tip_hash"`
+	Contracts       int              `json:"contracts"`
+	Size            int              `json:"size"`   // bytes del snapshot sin cifrar
+	SHA256          string           `json:"sha256"` // huella del snapshot sin cifrar
+	Encryption      BackupEncryption `json:"encryption"`
+}
+
+// EncryptedBackup es un snapshot cifrado junto con su manifiesto
+type EncryptedBackup struct {
+	Manifest   BackupManifest `json:"manifest"`
+	Ciphertext string         `json:"ciphertext"`
+}
+
+// EncryptBackup cifra un snapshot con una llave de datos nueva envuelta por el KMS
+func EncryptBackup(snapshot *Snapshot, nodeID string, kms KMS) (*EncryptedBackup, error) {
+	plaintext, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	ciphertext, err := sealAESGCM(dataKey, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := kms.WrapKey(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo envolver la llave de datos: %v", err)
+	}
+
+	sum := sha256.Sum256(plaintext)
+	return &EncryptedBackup{
+		Manifest: BackupManifest{
+			Version:         BackupVersion,
+			NodeID:          nodeID,
+			CreatedAt:       time.Now(),
+			SnapshotVersion: snapshot.Version,
+			From:            snapshot.From,
+			Height:          snapshot.Height,
+			TipHash:         snapshot.TipHash,
+			Contracts:       len(snapshot.Contracts),
+			Size:            len(plaintext),
+			SHA256:          hex.EncodeToString(sum[:]),
+			Encryption: BackupEncryption{
+				Algorithm:   backupAlgorithm,
+				KMSProvider: kms.Provider(),
+				KeyID:       kms.KeyID(),
+				WrappedKey:  base64.StdEncoding.EncodeToString(wrapped),
+			},
+		},
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+// DecryptBackup descifra un respaldo y comprueba que el snapshot corresponda a su manifiesto
+func DecryptBackup(backup *EncryptedBackup, kms KMS) (*Snapshot, error) {
+	manifest := backup.Manifest
+	if manifest.Version != BackupVersion {
+		return nil, fmt.Errorf("versión de respaldo no soportada: %d", manifest.Version)
+	}
+	if manifest.Encryption.Algorithm != backupAlgorithm {
+		return nil, fmt.Errorf("algoritmo de cifrado no soportado: %s", manifest.Encryption.Algorithm)
+	}
+	if manifest.Encryption.KMSProvider != kms.Provider() {
+		return nil, fmt.Errorf("el respaldo requiere el KMS %s y el nodo usa %s", manifest.Encryption.KMSProvider, kms.Provider())
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(manifest.Encryption.WrappedKey)
+	if err != nil {
+		return nil, errors.New("llave de datos envuelta mal codificada")
+	}
+	dataKey, err := kms.UnwrapKey(wrapped, manifest.Encryption.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo recuperar la llave de datos: %v", err)
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(backup.Ciphertext)
+	if err != nil {
+		return nil, errors.New("contenido cifrado mal codificado")
+	}
+	plaintext, err := openAESGCM(dataKey, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(plaintext)
+	if hex.EncodeToString(sum[:]) != manifest.SHA256 {
+		return nil, errors.New("la huella del snapshot no coincide con el manifiesto")
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(plaintext, &snapshot); err != nil {
+		return nil, fmt.Errorf("snapshot descifrado inválido: %v", err)
+	}
+	if snapshot.From != manifest.From || snapshot.Height != manifest.Height || snapshot.TipHash != manifest.TipHash {
+		return nil, errors.New("el snapshot descifrado no corresponde al manifiesto")
+	}
+	return &snapshot, nil
+}
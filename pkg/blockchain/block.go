@@ -0,0 +1,201 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Block representa un bloque en la blockchain SECOP
+type Block struct {
+	Index        int                    `json:"index"`
+	Timestamp    time.Time              `json:"timestamp"`
+	Data         map[string]interface{} `json:"data"`
+	PreviousHash string                 `json:"previous_hash"`
+	Hash         string                 `json:"hash"`
+	Nonce        int                    `json:"nonce"`
+	Type         string                 `json:"type"` // Tipo de bloque: CONTRACT_CREATION, VALIDATION, etc.
+	Pruned       bool                   `json:"pruned,omitempty"` // Solo encabezado; el bloque completo está archivado
+	Withheld     bool                   `json:"withheld,omitempty"` // Solo encabezado; el bloque toca un contrato reservado (ver reserved.go)
+	Signature    string                 `json:"signature,omitempty"` // Firma Ed25519 del hash por el nodo que creó el bloque
+	SignerNodeID string                 `json:"signer_node_id,omitempty"`
+	SignerKeyID  string                 `json:"signer_kid,omitempty"`
+	MerkleRoot   string                 `json:"merkle_root,omitempty"` // Raíz del árbol de transacciones, incluida en el hash
+	Transactions []Transaction          `json:"transactions,omitempty"`
+	HashVersion  int                    `json:"hash_version,omitempty"` // 0: JSON legado; 3: CBOR canónico
+}
+
+// Contract representa un contrato estatal con flujo completo de validación
+type Contract struct {
+	ID              string             `json:"id"`
+	EntityCode      string             `json:"entity_code" binding:"required,entity_code"`
+	EntityName      string             `json:"entity_name" binding:"required"`
+	ContractType    string             `json:"contract_type"`
+	Description     string             `json:"description" binding:"required"`
+	Amount          float64            `json:"amount" binding:"gt=0,lte=1000000000000000"` // lte es MaxContractAmount
+	Status          ContractStatus     `json:"status"`
+	CreatedBy       string             `json:"created_by" binding:"omitempty,email"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	ValidationSteps []ValidationStep   `json:"validation_steps"`
+	CurrentStep     int                `json:"current_step"`
+	RequiredRoles   []string           `json:"required_roles"`
+	AuditTrail      []AuditEntry       `json:"audit_trail"`
+	Calendar        *ProcessCalendar   `json:"calendar,omitempty"`
+	Questions       []PliegoQuestion   `json:"questions"`
+	Observations    []AuditObservation `json:"observations,omitempty"`
+	AwardedTo       string             `json:"awarded_to,omitempty"`
+	Consortium      *Consortium        `json:"consortium,omitempty"`
+	OriginSystem    string             `json:"origin_system,omitempty"`
+	RetentionHold   bool               `json:"retention_hold,omitempty"`
+	Reserved        bool               `json:"reserved,omitempty"` // Reservado (p. ej. defensa): solo los nodos autorizados reciben sus bloques completos
+	StaleFlaggedAt  *time.Time         `json:"stale_flagged_at,omitempty"`
+	Evidence        []Evidence         `json:"evidence,omitempty"`
+	Payments        []ContractPayment  `json:"payments,omitempty"`
+	Carryovers      []Carryover        `json:"carryovers,omitempty"` // Saldos constituidos al cierre de cada vigencia
+	Milestones      []Milestone        `json:"milestones,omitempty"` // Plan de hitos de la ejecución
+	Amendments      []Amendment        `json:"amendments,omitempty"`
+	AmendedValue    float64            `json:"amended_value,omitempty"` // Suma de las adiciones aprobadas
+	EndDate         *time.Time         `json:"end_date,omitempty"`      // Terminación fijada por la última prórroga aprobada
+	Claim           *ReviewClaim       `json:"claim,omitempty"`
+	TemplateVersion int                `json:"template_version,omitempty"` // Versión de la plantilla de flujo fijada al contrato
+	ProcessNumber   string             `json:"process_number,omitempty"`   // Número de proceso que le asigna la entidad
+	Sequence        int                `json:"sequence"`                   // Última transacción aplicada al contrato
+}
+
+// ContractStatus define los estados del contrato en el flujo SECOP
+type ContractStatus string
+
+const (
+	StatusDraft                   ContractStatus = "DRAFT"
+	StatusTechnicalReview         ContractStatus = "TECHNICAL_REVIEW"
+	StatusTechnicalApproved       ContractStatus = "TECHNICAL_APPROVED"
+	StatusLegalReview             ContractStatus = "LEGAL_REVIEW"
+	StatusLegalApproved           ContractStatus = "LEGAL_APPROVED"
+	StatusContractsReview         ContractStatus = "CONTRACTS_REVIEW"
+	StatusContractsApproved       ContractStatus = "CONTRACTS_APPROVED"
+	StatusAdminReview             ContractStatus = "ADMIN_REVIEW"
+	StatusAdminApproved           ContractStatus = "ADMIN_APPROVED"
+	StatusBudgetReview            ContractStatus = "BUDGET_REVIEW"
+	StatusAuthorizedForPublication ContractStatus = "AUTHORIZED_FOR_PUBLICATION"
+	StatusPublished               ContractStatus = "PUBLISHED"
+	StatusProposalsReceived       ContractStatus = "PROPOSALS_RECEIVED"
+	StatusEvaluated               ContractStatus = "EVALUATED"
+	StatusAwarded                 ContractStatus = "AWARDED"
+	StatusExecuted                ContractStatus = "EXECUTED"
+	StatusCompleted               ContractStatus = "COMPLETED"
+	// Estados de control (no bloquean el proceso)
+	StatusUnderAudit              ContractStatus = "UNDER_AUDIT"
+	StatusAuditObservations       ContractStatus = "AUDIT_OBSERVATIONS"
+	StatusRejected                ContractStatus = "REJECTED"
+)
+
+// ValidationStep representa un paso de validación en el flujo
+type ValidationStep struct {
+	StepNumber     int                    `json:"step_number"`
+	Role           AdminRole              `json:"role"`
+	ValidatorID    string                 `json:"validator_id"`
+	ValidatorName  string                 `json:"validator_name"`
+	Status         ValidationStatus       `json:"status"`
+	Timestamp      time.Time              `json:"timestamp"`
+	Comments       string                 `json:"comments"`
+	Required       bool                   `json:"required"`
+	DigitalSign    string                 `json:"digital_sign"`
+	SignatureKeyID string                 `json:"signature_kid,omitempty"`
+	SignedAt       *time.Time             `json:"signed_at,omitempty"`
+	AutoApproved   bool                   `json:"auto_approved,omitempty"`  // Lo aprueba el sistema según la plantilla
+	RuleReference  string                 `json:"rule_reference,omitempty"` // Norma que permite la aprobación automática
+	ActRequired    bool                   `json:"act_required,omitempty"`   // La plantilla exige el acta del comité para aprobar
+	Act            *StepAct               `json:"act,omitempty"`            // Acta firmada del comité que respalda la decisión
+	Documents      []string               `json:"documents"`
+}
+
+// AdminRole define los roles administrativos internos
+type AdminRole string
+
+const (
+	RoleProjectDeveloper  AdminRole = "PROJECT_DEVELOPER"
+	RoleTechnicalCommission AdminRole = "TECHNICAL_COMMISSION"
+	RoleLegalCommission   AdminRole = "LEGAL_COMMISSION"
+	RoleContractsChief    AdminRole = "CONTRACTS_CHIEF"
+	RoleAdminChief        AdminRole = "ADMIN_CHIEF"
+	RoleBudgetAuthority   AdminRole = "BUDGET_AUTHORITY"
+	RoleSupervisor        AdminRole = "SUPERVISOR" // Supervisión de la ejecución
+	// Roles de control externo (solo auditoría)
+	RoleComptroller       AdminRole = "COMPTROLLER"
+	RoleProsecutor        AdminRole = "PROSECUTOR"
+	RoleCitizen           AdminRole = "CITIZEN"
+)
+
+// ValidationStatus define el estado de una validación
+type ValidationStatus string
+
+const (
+	ValidationPending   ValidationStatus = "PENDING"
+	ValidationApproved  ValidationStatus = "APPROVED"
+	ValidationRejected  ValidationStatus = "REJECTED"
+	ValidationInReview  ValidationStatus = "IN_REVIEW"
+)
+
+// AuditEntry representa una entrada de auditoría
+type AuditEntry struct {
+	ID          string    `json:"id"`
+	Action      string    `json:"action"`
+	UserID      string    `json:"user_id"`
+	UserRole    AdminRole `json:"user_role"`
+	Timestamp   time.Time `json:"timestamp"`
+	Description string    `json:"description"`
+	IPAddress   string    `json:"ip_address"`
+	BlockHash   string    `json:"block_hash"`
+}
+
+// NewBlock crea un nuevo bloque
+func NewBlock(data map[string]interface{}, previousHash string) *Block {
+	block := &Block{
+		Index:        0,
+		Timestamp:    time.Now(),
+		Data:         data,
+		PreviousHash: previousHash,
+		Nonce:        0,
+	}
+	
+	block.Hash = block.calculateHash()
+	return block
+}
+
+// calculateHash calcula el hash SHA-256 del bloque con la codificación de su versión
+func (b *Block) calculateHash() string {
+	record := map[string]interface{}{
+		"index":         b.Index,
+		"timestamp":     b.Timestamp.Unix(),
+		"data":          b.Data,
+		"previous_hash": b.PreviousHash,
+		"nonce":         b.Nonce,
+		"type":          b.Type,
+	}
+	// Los bloques anteriores al árbol de transacciones conservan su hash original
+	if b.MerkleRoot != "" {
+		record["merkle_root"] = b.MerkleRoot
+	}
+	
+	if b.HashVersion == HashVersionLegacy {
+		recordBytes, _ := json.Marshal(record)
+		hash := sha256.Sum256(recordBytes)
+		return hex.EncodeToString(hash[:])
+	}
+
+	record["hash_version"] = b.HashVersion
+	recordBytes, err := canonicalEncode(record)
+	if err != nil {
+		// Un hash vacío nunca coincide, así que el bloque se rechaza como inválido
+		return ""
+	}
+	hash := sha256.Sum256(recordBytes)
+	return hex.EncodeToString(hash[:])
+}
+
+// IsValid verifica si el bloque es válido
+func (b *Block) IsValid() bool {
+	return b.Hash == b.calculateHash() && b.checkTransactions()
+}
@@ -0,0 +1,532 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+
+	"github.com/google/uuid"
+)
+
+// Blockchain representa la cadena de bloques SECOP
+type Blockchain struct {
+	WorkflowManager *WorkflowManager            `json:"-"`
+	Protocol        *ProtocolManager            `json:"-"`
+	Views           *ViewCache                  `json:"-"`
+	Events          *EventBus                   `json:"-"`
+	Projections     *ProjectionMonitor          `json:"-"`
+	Outbox          *Outbox                     `json:"-"`
+	Finality        *FinalityTracker            `json:"-"`
+	Conflicts       *ConflictRegistry           `json:"-"`
+	Operations      *OperationGuard             `json:"-"`
+	IdempotencyKeys *IdempotencyCache           `json:"-"`
+	SearchIndex     *ContractSearchIndex        `json:"-"`
+	Mempool         *Mempool                    `json:"-"` // nil: un bloque por transacción
+	Checkpoints     *CheckpointManager          `json:"-"` // nil: sin autoridad de checkpoints
+	Maintenance     *MaintenanceMode            `json:"-"` // nil: las escrituras nunca se congelan
+	suppliers       map[string]*Supplier        // Ver registries.go: se accede con bc.mutex
+	systemKeys      map[string]*EntitySystemKey // Ver registries.go: se accede con bc.mutex
+	validatorKeys   map[string]*ValidatorKey    // Ver registries.go: se accede con bc.mutex
+	usedSignatures  map[string]bool             // Ver signatures.go: se accede con bc.mutex
+	staged          map[string]*Contract        // Ver contractlocks.go: se accede con bc.mutex
+	store           storage.Store
+	contractStore   ContractStore
+	wal             *WAL
+	archive         *BlockArchive
+	sequences       map[string]int
+	signerID        string
+	signerKeys      *NodeKeyring
+	tip             *tipNotifier
+	poa             *proofOfAuthority
+	hashIndex       *blockHashIndex
+	chain           []*Block       // Ver chainstate.go: se accede con bc.mutex
+	state           State          // Ver chainstate.go: la referencia se accede con bc.mutex
+	newState        func() State   // Crea los estados que arman las reconstrucciones
+	mutex           sync.RWMutex
+	writeMutex      sync.Mutex
+	creationMutex   sync.Mutex // Serializa las altas de contratos (ver addContract)
+	registryMutex   sync.Mutex // Serializa las inscripciones en los registros (ver registries.go)
+	contractLocks   contractLocks
+}
+
+// NewBlockchain crea la blockchain restaurándola desde el almacenamiento o, si está
+// vacío, con un bloque génesis nuevo. Con store nil la cadena vive solo en memoria.
+func NewBlockchain(store storage.Store) (*Blockchain, error) {
+	if store == nil {
+		store = storage.NewMemoryStore()
+	}
+
+	bc := &Blockchain{
+		state:          NewMemoryState(),
+		newState:       NewMemoryState,
+		suppliers:      make(map[string]*Supplier),
+		systemKeys:     make(map[string]*EntitySystemKey),
+		validatorKeys:  make(map[string]*ValidatorKey),
+		Protocol:       NewProtocolManager(),
+		Views:          NewViewCache(),
+		Events:         NewEventBus(),
+		Operations:     NewOperationGuard(),
+		SearchIndex:    newContractSearchIndex(),
+		usedSignatures: make(map[string]bool),
+		sequences:      make(map[string]int),
+		tip:            newTipNotifier(),
+		hashIndex:      newBlockHashIndex(),
+		store:          store,
+	}
+	
+	bc.contractStore = newMemoryContractStore(bc.Contract)
+	bc.Outbox = newOutbox(bc)
+	bc.Finality = newFinalityTracker(bc)
+	bc.Conflicts = newConflictRegistry(bc)
+	bc.IdempotencyKeys = newIdempotencyCache(store)
+	bc.Projections = NewProjectionMonitor(bc)
+
+	// Inicializar el gestor de flujo de trabajo
+	bc.WorkflowManager = NewWorkflowManager(bc)
+	
+	if err := bc.loadFromStorage(); err != nil {
+		return nil, err
+	}
+	
+	if bc.Len() == 0 {
+		genesisBlock := &Block{
+			Index:        0,
+			Timestamp:    time.Now(),
+			Data:         map[string]interface{}{"message": "SECOP Blockchain Genesis Block"},
+			PreviousHash: "",
+			Nonce:        0,
+		}
+		genesisBlock.Hash = genesisBlock.calculateHash()
+	
+		if err := bc.persistBlock(genesisBlock); err != nil {
+			return nil, fmt.Errorf("error guardando bloque génesis: %v", err)
+		}
+		bc.setChain([]*Block{genesisBlock})
+	}
+
+	// El store de contratos queda al día con el estado cargado
+	bc.Projections.register(ProjectionContractStore)
+	
+	return bc, nil
+}
+
+// Errores del alta y la validación de contratos
+var (
+	ErrContractNotFound = errors.New("contrato no encontrado")
+	ErrContractExists   = errors.New("ya existe un contrato con ese ID")
+	ErrContractRejected = errors.New("el contrato ya fue rechazado")
+)
+
+// ContractFieldError es un dato del contrato que no cumple las reglas del ledger; Field
+// es el nombre del campo en el JSON y Rule la regla incumplida, como en la validación de la API
+type ContractFieldError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+func (e *ContractFieldError) Error() string {
+	return e.Message
+}
+
+// AddContract agrega un nuevo contrato a la blockchain con flujo de trabajo
+func (bc *Blockchain) AddContract(contract *Contract) error {
+	return bc.addContract(contract, "")
+}
+
+// addContract agrega el contrato; originSignature es la firma del payload cuando lo envía
+// el sistema de una entidad, y queda en el bloque para que no se pueda reenviar
+func (bc *Blockchain) addContract(contract *Contract, originSignature string) error {
+	// Validar contrato
+	if err := bc.validateContract(contract); err != nil {
+		return err
+	}
+
+	// Generar ID único si no existe
+	if contract.ID == "" {
+		contract.ID = uuid.New().String()
+	}
+
+	// Los IDs de contrato no se repiten: el alta se serializa hasta que su bloque entra
+	bc.creationMutex.Lock()
+	defer bc.creationMutex.Unlock()
+	if _, exists := bc.Contract(contract.ID); exists {
+		return fmt.Errorf("%w: %s", ErrContractExists, contract.ID)
+	}
+
+	// Establecer timestamp y estado inicial
+	contract.CreatedAt = time.Now()
+	contract.UpdatedAt = time.Now()
+	contract.Status = StatusDraft
+
+	// Inicializar flujo de trabajo
+	if err := bc.WorkflowManager.InitializeContractWorkflow(contract); err != nil {
+		return fmt.Errorf("error inicializando flujo de trabajo: %v", err)
+	}
+
+	// Agregar a la blockchain cuando entre su bloque
+	bc.stageContract(contract)
+
+	// Crear bloque para el contrato
+	blockData := map[string]interface{}{
+		"type":          "CONTRACT_CREATION",
+		"contract_id":   contract.ID,
+		"entity_code":   contract.EntityCode,
+		"entity_name":   contract.EntityName,
+		"contract_type": contract.ContractType,
+		"description":   contract.Description,
+		"amount":        contract.Amount,
+		"created_by":    contract.CreatedBy,
+		"timestamp":     contract.CreatedAt,
+	}
+	if contract.ProcessNumber != "" {
+		blockData["process_number"] = contract.ProcessNumber
+	}
+	if contract.OriginSystem != "" {
+		blockData["origin_system"] = contract.OriginSystem
+	}
+	if originSignature != "" {
+		blockData["origin_signature"] = originSignature
+	}
+	if contract.TemplateVersion != 0 {
+		blockData["template_version"] = contract.TemplateVersion
+	}
+	if contract.Reserved {
+		// Marca el bloque para que solo los nodos autorizados lo reciban completo
+		blockData["reserved"] = true
+	}
+
+	if err := bc.AddBlock(blockData); err != nil {
+		bc.unstageContract(contract.ID)
+		return err
+	}
+	return nil
+}
+
+// ValidateContractStep valida un paso del flujo de trabajo
+func (bc *Blockchain) ValidateContractStep(contractID string, stepNumber int, validatorID string, validatorName string, role AdminRole, approved bool, comments string, signature StepSignature, act *StepAct) error {
+	return bc.WorkflowManager.ValidateStep(contractID, stepNumber, validatorID, validatorName, role, approved, comments, signature, act)
+}
+
+// AddAuditObservation agrega una observación de auditoría
+func (bc *Blockchain) AddAuditObservation(contractID string, auditorID string, role AdminRole, observation string) (*AuditObservation, error) {
+	return bc.WorkflowManager.AddAuditObservation(contractID, auditorID, role, observation)
+}
+
+// GetContractWorkflowStatus obtiene el estado del flujo de trabajo de un contrato
+func (bc *Blockchain) GetContractWorkflowStatus(contractID string) (*WorkflowStatus, error) {
+	return bc.WorkflowManager.GetContractWorkflowStatus(contractID)
+}
+
+// GetContractsByStatus obtiene contratos por estado
+func (bc *Blockchain) GetContractsByStatus(status ContractStatus) []*Contract {
+	var contracts []*Contract
+	for _, contract := range bc.contractMap() {
+		if contract.Status == status {
+			contracts = append(contracts, contract)
+		}
+	}
+	return contracts
+}
+
+// GetContractsByRole obtiene contratos que requieren validación de un rol específico
+func (bc *Blockchain) GetContractsByRole(role AdminRole) []*Contract {
+	var contracts []*Contract
+	for _, contract := range bc.contractMap() {
+		if contract.CurrentStep <= len(contract.ValidationSteps) {
+			currentStepRole := contract.ValidationSteps[contract.CurrentStep-1].Role
+			if currentStepRole == role && contract.ValidationSteps[contract.CurrentStep-1].Status == ValidationPending {
+				contracts = append(contracts, contract)
+			}
+		}
+	}
+	return contracts
+}
+
+// ValidateContract valida un contrato por parte de un nodo; el rechazo se aplica sobre una
+// copia que entra en vigencia con el bloque
+func (bc *Blockchain) ValidateContract(contractID string, nodeID string, approved bool, reason string) error {
+	unlock := bc.lockContract(contractID)
+	defer unlock()
+
+	contract, err := bc.editContract(contractID)
+	if err != nil {
+		return err
+	}
+	if contract.Status == StatusRejected {
+		return ErrContractRejected
+	}
+
+	// Crear bloque de validación
+	validationData := map[string]interface{}{
+		"type":        "VALIDATION",
+		"contract_id": contractID,
+		"node_id":     nodeID,
+		"approved":    approved,
+		"reason":      reason,
+		"timestamp":   time.Now(),
+	}
+
+	// Actualizar estado del contrato basado en el flujo de trabajo
+	if approved {
+		// El estado se maneja ahora a través del WorkflowManager
+		logf("✅ Validación aprobada para contrato %s por nodo %s\n", contractID, nodeID)
+	} else {
+		contract.Status = StatusRejected
+		logf("❌ Validación rechazada para contrato %s por nodo %s: %s\n", contractID, nodeID, reason)
+	}
+
+	bc.stageContract(contract)
+	if err := bc.AddBlock(validationData); err != nil {
+		bc.unstageContract(contractID)
+		return err
+	}
+	return nil
+}
+
+// GetContract obtiene un contrato por ID
+func (bc *Blockchain) GetContract(contractID string) (*Contract, error) {
+	contract, exists := bc.Contract(contractID)
+	if !exists {
+		return nil, ErrContractNotFound
+	}
+	return contract, nil
+}
+
+// GetAllContracts obtiene todos los contratos
+func (bc *Blockchain) GetAllContracts() []*Contract {
+	contracts := make([]*Contract, 0, bc.ContractCount())
+	for _, contract := range bc.contractMap() {
+		contracts = append(contracts, contract)
+	}
+	return contracts
+}
+
+// IsChainValid verifica la integridad de la blockchain
+func (bc *Blockchain) IsChainValid() bool {
+	return bc.VerifyChain() == nil
+}
+
+// VerifyChain verifica la integridad de la blockchain y retorna la primera falla encontrada
+func (bc *Blockchain) VerifyChain() error {
+	return newLocalChainVerifier().Verify(bc.Blocks())
+}
+
+// TipHash retorna el hash del último bloque de la cadena
+func (bc *Blockchain) TipHash() string {
+	return bc.getLatestBlock().Hash
+}
+
+// getLatestBlock obtiene el último bloque de la cadena
+func (bc *Blockchain) getLatestBlock() *Block {
+	chain := bc.Blocks()
+	return chain[len(chain)-1]
+}
+
+// EntityCodePattern es el formato del código de entidad: el código DANE de 5 dígitos o el
+// código de la entidad en SECOP, de hasta 12
+const EntityCodePattern = `^[0-9]{5,12}$`
+
+// MaxContractAmount es el mayor valor de contrato que acepta la API, en pesos
+const MaxContractAmount = 1e15
+
+var entityCodeFormat = regexp.MustCompile(EntityCodePattern)
+
+// ValidEntityCode indica si el código de entidad tiene el formato esperado
+func ValidEntityCode(code string) bool {
+	return entityCodeFormat.MatchString(code)
+}
+
+// validateContract valida los datos del contrato, lleguen por la API o en un payload firmado
+func (bc *Blockchain) validateContract(contract *Contract) error {
+	if contract.EntityCode == "" {
+		return &ContractFieldError{Field: "entity_code", Rule: "required", Message: "código de entidad requerido"}
+	}
+	if !ValidEntityCode(contract.EntityCode) {
+		return &ContractFieldError{Field: "entity_code", Rule: "entity_code", Message: fmt.Sprintf("código de entidad %s inválido: deben ser de 5 a 12 dígitos", contract.EntityCode)}
+	}
+	if contract.EntityName == "" {
+		return &ContractFieldError{Field: "entity_name", Rule: "required", Message: "nombre de entidad requerido"}
+	}
+	if contract.Description == "" {
+		return &ContractFieldError{Field: "description", Rule: "required", Message: "descripción requerida"}
+	}
+	if contract.Amount <= 0 {
+		return &ContractFieldError{Field: "amount", Rule: "gt", Message: "monto debe ser mayor a cero"}
+	}
+	if contract.Amount > MaxContractAmount {
+		return &ContractFieldError{Field: "amount", Rule: "lte", Message: fmt.Sprintf("monto supera el máximo permitido (%.0f)", float64(MaxContractAmount))}
+	}
+	if contract.CreatedBy == "" {
+		return &ContractFieldError{Field: "created_by", Rule: "required", Message: "creador requerido"}
+	}
+	return nil
+}
+
+// IsValidBlock valida si un bloque es válido
+func (bc *Blockchain) IsValidBlock(block Block) bool {
+	// Verificar que el hash no esté vacío
+	if block.Hash == "" {
+		return false
+	}
+	
+	// Verificar que el timestamp sea razonable
+	if block.Timestamp.IsZero() {
+		return false
+	}
+	
+	// Verificar que tenga un hash previo válido (excepto el bloque génesis)
+	if bc.Len() > 0 && block.PreviousHash != bc.getLatestBlock().Hash {
+		return false
+	}
+	
+	return true
+}
+
+// HasBlock verifica si ya tenemos un bloque con el hash dado, incluido el hash con que
+// lo creó su autor si nos llegó retransmitido
+func (bc *Blockchain) HasBlock(hash string) bool {
+	return bc.blockIndex(hash) >= 0
+}
+
+// AddBlock agrega un nuevo bloque a la cadena con datos
+func (bc *Blockchain) AddBlock(blockData map[string]interface{}) error {
+	_, err := bc.SealBlock(blockData)
+	return err
+}
+
+// SealBlock agrega los datos a la cadena como AddBlock y retorna el bloque en que quedaron:
+// el propio o, si esperaron en el mempool, el lote que los selló aquí o en otro nodo. Quien
+// guarda el hash del bloque debe tomarlo de aquí y no de TipHash, que pudo avanzar con
+// bloques de otros escritores.
+func (bc *Blockchain) SealBlock(blockData map[string]interface{}) (*Block, error) {
+	if bc.inMaintenance() {
+		return nil, ErrMaintenance
+	}
+	if bc.Mempool != nil && bc.Mempool.accepts(blockData) {
+		return bc.Mempool.submit(blockData)
+	}
+	return bc.addBlock(blockData, nil)
+}
+
+// addBlock agrega el bloque con las transacciones indicadas o, con nil, con una que
+// describe sus datos. Los bloques retransmitidos conservan las del bloque original para
+// que sus pruebas de inclusión sigan valiendo.
+func (bc *Blockchain) addBlock(blockData map[string]interface{}, transactions []Transaction) (*Block, error) {
+	block, err := bc.appendNewBlock(blockData, transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los eventos se publican ya liberada la cadena para que los suscriptores puedan usarla
+	for _, event := range block.Events() {
+		bc.Events.Publish(event)
+	}
+	bc.tip.notify()
+	return block, nil
+}
+
+// appendNewBlock arma, persiste y agrega el bloque. Retiene bc.writeMutex de principio a
+// fin para que dos bloques concurrentes no tomen la misma altura ni el mismo consecutivo.
+func (bc *Blockchain) appendNewBlock(blockData map[string]interface{}, transactions []Transaction) (*Block, error) {
+	bc.writeMutex.Lock()
+	defer bc.writeMutex.Unlock()
+
+	// El congelamiento vale para todo escritor, no solo para la API: mempool, checkpoints,
+	// tareas periódicas y bloques recibidos
+	if bc.inMaintenance() {
+		return nil, ErrMaintenance
+	}
+
+	// Numerar las transacciones de contrato y verificar que sean las siguientes
+	bc.stampSequence(blockData)
+	if err := bc.checkSequence(blockData); err != nil {
+		return nil, err
+	}
+
+	// Crear el bloque con los datos proporcionados
+	block := NewBlock(blockData, bc.getLatestBlock().Hash)
+	block.Index = bc.Len()
+	if transactions == nil {
+		transactions = []Transaction{newTransaction(blockData)}
+	}
+	block.Transactions = transactions
+	block.MerkleRoot = MerkleRoot(transactions)
+	if bc.Protocol.IsActive(CanonicalHashingFeature, block.Index) {
+		block.HashVersion = HashVersionCanonical
+	}
+	if err := bc.checkAuthority(block); err != nil {
+		return nil, err
+	}
+	
+	// Establecer tipo de bloque si está especificado
+	if blockType, ok := blockData["type"].(string); ok {
+		block.Type = blockType
+	}
+	
+	// Verificar que el tipo de transacción esté activo a esta altura
+	if block.Type != "" && !bc.Protocol.IsActive(block.Type, block.Index) {
+		return nil, fmt.Errorf("tipo de transacción %s aún no activo en la altura %d", block.Type, block.Index)
+	}
+	
+	// Recalcular hash con el índice correcto y firmarlo con la llave del nodo
+	block.Hash = block.calculateHash()
+	bc.signBlock(block)
+
+	// Verificar que el bloque sea válido
+	if !bc.IsValidBlock(*block) {
+		return nil, errors.New("bloque inválido")
+	}
+
+	// El estado que cambia un bloque recibido se deriva de sus transacciones, no de lo que
+	// diga el peer (ver statesync.go)
+	var derived *stateReplay
+	if isReceivedBlock(block) {
+		derived = bc.deriveBlockState(block)
+	}
+
+	// Registrar en el WAL el bloque y su estado antes de aplicarlos
+	changes := bc.stateChanges(block, derived)
+	seq, err := bc.wal.Begin(block, changes)
+	if err != nil {
+		return nil, fmt.Errorf("error escribiendo WAL: %v", err)
+	}
+
+	// Persistir antes de agregar a la cadena en memoria
+	if err := bc.persistBlock(block); err != nil {
+		bc.wal.Abort(seq)
+		return nil, fmt.Errorf("error guardando bloque: %v", err)
+	}
+
+	// Agregar a la cadena y poner en vigencia los contratos que cambió el bloque
+	bc.appendBlock(block)
+	bc.commitStaged(block.Data)
+	bc.commitDerived(derived)
+	logf("✅ Bloque %d agregado a la cadena\n", block.Index)
+	bc.applySequence(block.Data)
+	bc.applySignatures(block.Data)
+	bc.applyGovernance(block)
+	bc.applyCheckpoint(block)
+	bc.Finality.track(block)
+	
+	bc.applyStateChanges(changes)
+	bc.Projections.applied(ProjectionContractStore, block.Index)
+	if err := bc.wal.Commit(seq); err != nil {
+		logf("❌ Error confirmando bloque %d en el WAL: %v\n", block.Index, err)
+	}
+	return block, nil
+}
+
+// VerifyPeerChain valida una cadena completa recibida de un peer, incluidas las firmas de sus
+// bloques con las llaves conocidas de cada nodo
+func (bc *Blockchain) VerifyPeerChain(chain []Block, keys map[string][]PublicKeyInfo) error {
+	blocks := make([]*Block, len(chain))
+	for i := range chain {
+		blocks[i] = &chain[i]
+	}
+	return NewChainVerifier(keys).Verify(blocks)
+}
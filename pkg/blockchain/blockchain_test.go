@@ -0,0 +1,440 @@
+package blockchain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+func TestAddContractRejectsInvalidData(t *testing.T) {
+	bc := newTestBlockchain(t)
+
+	for name, contract := range map[string]*Contract{
+		"código de entidad con letras": {EntityCode: "ABC123", EntityName: "Entidad", Description: "Contrato", Amount: 1000, CreatedBy: "creador"},
+		"código de entidad corto":      {EntityCode: "1234", EntityName: "Entidad", Description: "Contrato", Amount: 1000, CreatedBy: "creador"},
+		"monto sobre el máximo":        {EntityCode: "123456", EntityName: "Entidad", Description: "Contrato", Amount: MaxContractAmount * 2, CreatedBy: "creador"},
+	} {
+		var field *ContractFieldError
+		if err := bc.AddContract(contract); !errors.As(err, &field) {
+			t.Errorf("%s: se esperaba un error de campo y se obtuvo %v", name, err)
+		}
+	}
+	if count := bc.ContractCount(); count != 0 {
+		t.Errorf("se esperaban 0 contratos, hay %d", count)
+	}
+}
+
+func TestContractErrors(t *testing.T) {
+	bc := newTestBlockchain(t)
+	contract := newTestContract(t, bc)
+
+	duplicate := *contract
+	if err := bc.AddContract(&duplicate); !errors.Is(err, ErrContractExists) {
+		t.Errorf("alta con un ID repetido: %v", err)
+	}
+	if err := bc.ValidateContract("no-existe", "nodo", true, ""); !errors.Is(err, ErrContractNotFound) {
+		t.Errorf("validación de un contrato inexistente: %v", err)
+	}
+	if err := bc.ValidateContract(contract.ID, "nodo", false, "incompleto"); err != nil {
+		t.Fatalf("rechazando el contrato: %v", err)
+	}
+	if err := bc.ValidateContract(contract.ID, "nodo", true, ""); !errors.Is(err, ErrContractRejected) {
+		t.Errorf("validación de un contrato rechazado: %v", err)
+	}
+}
+
+func TestRelayedSignerKeys(t *testing.T) {
+	signer, _ := LoadOrCreateNodeKeyring("")
+	trusted := signer.PublicKeys()
+	signer.Rotate()
+	rotated, endorsement := signer.EndorseKeys("firmante")
+	attacker, _ := LoadOrCreateNodeKeyring("")
+	forged, forgedEndorsement := attacker.EndorseKeys("firmante")
+	replaced := append([]PublicKeyInfo{{KeyID: trusted[0].KeyID, PublicKey: forged[0].PublicKey}}, forged...)
+
+	p2p := NewP2PNetwork("nodo", "127.0.0.1", "1", newTestBlockchain(t))
+	tests := []struct {
+		name        string
+		trusted     []PublicKeyInfo
+		offered     []PublicKeyInfo
+		endorsement *KeySetEndorsement
+		accepted    bool
+	}{
+		{"rotación endosada por una llave conocida", trusted, rotated, &endorsement, true},
+		{"firmante sin llaves conocidas", nil, rotated, &endorsement, false},
+		{"rotación sin endoso", trusted, rotated, nil, false},
+		{"llaves de otro nodo endosadas por él mismo", trusted, forged, &forgedEndorsement, false},
+		{"llave conocida con otro valor", trusted, replaced, &forgedEndorsement, false},
+	}
+	for _, tt := range tests {
+		var endorsement KeySetEndorsement
+		if tt.endorsement != nil {
+			endorsement = *tt.endorsement
+		}
+		err := p2p.checkRelayedKeys("firmante", tt.trusted, tt.offered, endorsement, tt.endorsement != nil)
+		if (err == nil) != tt.accepted {
+			t.Errorf("%s: error %v, se esperaba aceptada=%v", tt.name, err, tt.accepted)
+		}
+	}
+
+	// En prueba de autoridad solo valen las llaves que la cadena registra para el validador
+	bc := newTestBlockchain(t)
+	if err := bc.EnableProofOfAuthority([]Authority{{NodeID: "firmante", PublicKeys: trusted}}, 1); err != nil {
+		t.Fatalf("activando la prueba de autoridad: %v", err)
+	}
+	p2p = NewP2PNetwork("nodo", "127.0.0.1", "1", bc)
+	if err := p2p.checkRelayedKeys("firmante", nil, trusted, KeySetEndorsement{}, false); err != nil {
+		t.Errorf("se rechazaron las llaves registradas: %v", err)
+	}
+	if err := p2p.checkRelayedKeys("firmante", nil, rotated, endorsement, true); err == nil {
+		t.Error("se aceptó una llave que la cadena no registra")
+	}
+	if err := p2p.checkRelayedKeys("otro", nil, forged, forgedEndorsement, true); err == nil {
+		t.Error("se aceptaron llaves de un nodo que no es validador")
+	}
+
+	merged, added := mergeKeys(trusted, rotated)
+	if !added || len(merged) != 2 || merged[0] != trusted[0] {
+		t.Fatalf("llaves combinadas %+v", merged)
+	}
+	if _, added := mergeKeys(merged, trusted); added {
+		t.Error("se agregaron llaves ya conocidas")
+	}
+}
+
+func TestMaintenanceFreezesLedger(t *testing.T) {
+	bc := newTestBlockchain(t)
+	mempool, err := NewMempool(bc, 4, time.Hour)
+	if err != nil {
+		t.Fatalf("creando el mempool: %v", err)
+	}
+	bc.Mempool = mempool
+	bc.Maintenance = NewMaintenanceMode()
+	bc.Maintenance.Enable("respaldo", time.Time{})
+	height := bc.Len()
+
+	// Ni los escritores locales ni los bloques que arma el nodo por su cuenta pasan
+	data := map[string]interface{}{"type": "EXECUTION_EVIDENCE", "evidence_id": "local", "tx_id": "local"}
+	if _, err := bc.SealBlock(data); !errors.Is(err, ErrMaintenance) {
+		t.Errorf("SealBlock en mantenimiento: %v", err)
+	}
+	if _, err := bc.addBlock(map[string]interface{}{"type": "CHECKPOINT"}, nil); !errors.Is(err, ErrMaintenance) {
+		t.Errorf("addBlock en mantenimiento: %v", err)
+	}
+
+	// Las transacciones de otros nodos esperan en el mempool hasta que termine
+	remote := MempoolTransaction{ID: "remota", Data: map[string]interface{}{"type": "EXECUTION_EVIDENCE", "evidence_id": "remota", "tx_id": "remota"}, ExpiresAt: time.Now().Add(time.Minute)}
+	if _, err := mempool.AddRemote(remote); err != nil {
+		t.Fatalf("recibiendo la transacción: %v", err)
+	}
+	mempool.seal()
+	if bc.Len() != height || len(mempool.Pending()) != 1 {
+		t.Fatalf("se selló durante el mantenimiento: altura %d, pendientes %d", bc.Len(), len(mempool.Pending()))
+	}
+
+	bc.Maintenance.Disable()
+	mempool.seal()
+	if bc.Len() != height+1 || len(mempool.Pending()) != 0 {
+		t.Fatalf("no se selló al terminar el mantenimiento: altura %d, pendientes %d", bc.Len(), len(mempool.Pending()))
+	}
+}
+
+func TestWALRecovery(t *testing.T) {
+	tests := []struct {
+		name     string
+		linked   bool // el bloque enlaza con la punta de la cadena guardada
+		write    func(w *WAL, block *Block)
+		replayed bool
+	}{
+		{"sin confirmar", true, func(w *WAL, block *Block) {
+			w.Begin(block, walTestState)
+		}, true},
+		{"confirmado", true, func(w *WAL, block *Block) {
+			seq, _ := w.Begin(block, walTestState)
+			w.Commit(seq)
+		}, false},
+		{"descartado", true, func(w *WAL, block *Block) {
+			seq, _ := w.Begin(block, walTestState)
+			w.Abort(seq)
+		}, false},
+		{"no enlaza con la cadena", false, func(w *WAL, block *Block) {
+			w.Begin(block, walTestState)
+		}, false},
+		{"escritura interrumpida", true, func(w *WAL, block *Block) {
+			w.file.WriteString(`{"seq":1,"op":"begin","block":{"index":`)
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			bc, err := NewBlockchain(store)
+			if err != nil {
+				t.Fatalf("creando la blockchain: %v", err)
+			}
+			height := bc.Len()
+
+			// La caída deja el WAL escrito sin que el bloque llegue al almacenamiento
+			previousHash := bc.TipHash()
+			if !tt.linked {
+				previousHash = "otra-cadena"
+			}
+			block := NewBlock(map[string]interface{}{"type": "EXECUTION_EVIDENCE", "evidence_id": "wal"}, previousHash)
+			block.Index = height
+			block.Hash = block.calculateHash()
+
+			path := filepath.Join(t.TempDir(), "blocks.wal")
+			file, err := os.Create(path)
+			if err != nil {
+				t.Fatalf("creando el WAL: %v", err)
+			}
+			tt.write(&WAL{path: path, file: file}, block)
+			file.Close()
+
+			restarted, err := NewBlockchain(store)
+			if err != nil {
+				t.Fatalf("reiniciando la blockchain: %v", err)
+			}
+			if err := restarted.OpenWAL(path); err != nil {
+				t.Fatalf("recuperando el WAL: %v", err)
+			}
+			defer restarted.Close()
+
+			want := height
+			if tt.replayed {
+				want++
+			}
+			if restarted.Len() != want {
+				t.Fatalf("altura %d tras recuperar, se esperaba %d", restarted.Len(), want)
+			}
+			if tt.replayed && restarted.TipHash() != block.Hash {
+				t.Errorf("la punta %s no es el bloque reaplicado %s", restarted.TipHash(), block.Hash)
+			}
+			var state int
+			store.ForEach(walTestState[0].Bucket, func(string, []byte) error {
+				state++
+				return nil
+			})
+			if (state == 1) != tt.replayed {
+				t.Errorf("se reaplicaron %d valores de estado", state)
+			}
+			if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+				t.Errorf("el WAL no quedó vacío tras recuperarlo: %v", err)
+			}
+		})
+	}
+
+	// Con el WAL abierto, cada bloque agregado queda confirmado y no se repite al reiniciar
+	store := storage.NewMemoryStore()
+	bc, err := NewBlockchain(store)
+	if err != nil {
+		t.Fatalf("creando la blockchain: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "blocks.wal")
+	if err := bc.OpenWAL(path); err != nil {
+		t.Fatalf("abriendo el WAL: %v", err)
+	}
+	if err := bc.AddBlock(map[string]interface{}{"type": "EXECUTION_EVIDENCE", "evidence_id": "confirmada"}); err != nil {
+		t.Fatalf("agregando el bloque: %v", err)
+	}
+	bc.Close()
+	records, err := readWAL(path)
+	if err != nil || len(records) != 2 || records[0].Op != walBegin || records[1].Op != walCommit {
+		t.Fatalf("registros del WAL %+v: %v", records, err)
+	}
+	restarted, err := NewBlockchain(store)
+	if err != nil {
+		t.Fatalf("reiniciando la blockchain: %v", err)
+	}
+	if err := restarted.OpenWAL(path); err != nil {
+		t.Fatalf("recuperando el WAL: %v", err)
+	}
+	defer restarted.Close()
+	if restarted.Len() != bc.Len() || restarted.TipHash() != bc.TipHash() {
+		t.Errorf("altura %d tras reiniciar, se esperaba %d", restarted.Len(), bc.Len())
+	}
+}
+
+// walTestState es el estado que acompaña al bloque en las entradas del WAL de prueba
+var walTestState = []stateChange{{Bucket: "wal_prueba", Key: "evidencia", Value: []byte(`{"ok":true}`)}}
+
+func TestValidatorGovernance(t *testing.T) {
+	keysA, _ := LoadOrCreateNodeKeyring("")
+	keysB, _ := LoadOrCreateNodeKeyring("")
+	keysC, _ := LoadOrCreateNodeKeyring("")
+	validatorA := &Authority{NodeID: "nodo-a", PublicKeys: keysA.PublicKeys()}
+	validatorB := &Authority{NodeID: "nodo-b", PublicKeys: keysB.PublicKeys()}
+	badKeys := []PublicKeyInfo{{KeyID: keysC.ActiveKeyID(), PublicKey: keysA.PublicKeys()[0].PublicKey}}
+
+	tests := []struct {
+		name    string
+		set     authoritySet
+		change  governanceChange
+		members []string
+	}{
+		{"agrega un validador", authoritySet{"nodo-a": validatorA},
+			governanceChange{Action: GovernanceAddValidator, NodeID: "nodo-c", PublicKeys: keysC.PublicKeys()}, []string{"nodo-a", "nodo-c"}},
+		{"agrega uno que ya es validador", authoritySet{"nodo-a": validatorA},
+			governanceChange{Action: GovernanceAddValidator, NodeID: "nodo-a", PublicKeys: keysA.PublicKeys()}, nil},
+		{"agrega sin llaves", authoritySet{"nodo-a": validatorA},
+			governanceChange{Action: GovernanceAddValidator, NodeID: "nodo-c"}, nil},
+		{"agrega con un kid ajeno a la llave", authoritySet{"nodo-a": validatorA},
+			governanceChange{Action: GovernanceAddValidator, NodeID: "nodo-c", PublicKeys: badKeys}, nil},
+		{"retira un validador", authoritySet{"nodo-a": validatorA, "nodo-b": validatorB},
+			governanceChange{Action: GovernanceRemoveValidator, NodeID: "nodo-b"}, []string{"nodo-a"}},
+		{"retira uno que no es validador", authoritySet{"nodo-a": validatorA, "nodo-b": validatorB},
+			governanceChange{Action: GovernanceRemoveValidator, NodeID: "nodo-c"}, nil},
+		{"retira al último validador", authoritySet{"nodo-a": validatorA},
+			governanceChange{Action: GovernanceRemoveValidator, NodeID: "nodo-a"}, nil},
+		{"acción desconocida", authoritySet{"nodo-a": validatorA},
+			governanceChange{Action: "SUSPEND_VALIDATOR", NodeID: "nodo-a"}, nil},
+	}
+	for _, tt := range tests {
+		before := len(tt.set)
+		next, err := tt.set.apply(tt.change, 5)
+		if (err == nil) != (tt.members != nil) {
+			t.Errorf("%s: error %v", tt.name, err)
+			continue
+		}
+		if len(tt.set) != before {
+			t.Errorf("%s: se modificó el conjunto vigente", tt.name)
+		}
+		if err != nil {
+			continue
+		}
+		if len(next) != len(tt.members) {
+			t.Errorf("%s: quedaron %d validadores, se esperaban %v", tt.name, len(next), tt.members)
+		}
+		for _, nodeID := range tt.members {
+			if next[nodeID] == nil {
+				t.Errorf("%s: falta el validador %s", tt.name, nodeID)
+			}
+		}
+	}
+
+	// En la cadena solo un validador propone cambios, y el conjunto cambia con el bloque
+	bc := newTestBlockchain(t)
+	bc.SetSigner("nodo-a", keysA)
+	if err := bc.EnableProofOfAuthority([]Authority{*validatorA}, 1); err != nil {
+		t.Fatalf("activando la prueba de autoridad: %v", err)
+	}
+	if err := bc.ProposeValidatorChange(GovernanceAddValidator, "nodo-b", keysB.PublicKeys()); err != nil {
+		t.Fatalf("agregando el validador: %v", err)
+	}
+	if !bc.IsAuthority("nodo-b") || bc.getLatestBlock().Type != GovernanceBlockType {
+		t.Fatal("el validador no quedó registrado en un bloque de gobernanza")
+	}
+	if err := bc.ProposeValidatorChange(GovernanceRemoveValidator, "nodo-b", nil); err != nil {
+		t.Fatalf("retirando el validador: %v", err)
+	}
+	if bc.IsAuthority("nodo-b") || len(bc.Authorities()) != 1 {
+		t.Error("el validador retirado sigue autorizado")
+	}
+	if err := bc.ProposeValidatorChange(GovernanceRemoveValidator, "nodo-a", nil); err == nil {
+		t.Error("se retiró al último validador")
+	}
+
+	bc.SetSigner("nodo-c", keysC)
+	height := bc.Len()
+	if err := bc.ProposeValidatorChange(GovernanceAddValidator, "nodo-c", keysC.PublicKeys()); err == nil || bc.Len() != height {
+		t.Errorf("un nodo que no es validador propuso un cambio: %v", err)
+	}
+
+	// Los bloques de un nodo que no es validador se rechazan al recibirlos
+	block := NewBlock(map[string]interface{}{"type": "EXECUTION_EVIDENCE"}, bc.TipHash())
+	block.Index = height
+	block.Hash = block.calculateHash()
+	bc.signBlock(block)
+	if err := bc.verifyAuthorityBlock(*block); err == nil {
+		t.Error("se aceptó un bloque firmado por un nodo que no es validador")
+	}
+	bc.SetSigner("nodo-a", keysA)
+	bc.signBlock(block)
+	if err := bc.verifyAuthorityBlock(*block); err != nil {
+		t.Errorf("se rechazó el bloque de un validador: %v", err)
+	}
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		prepare  func(ic *IdempotencyCache)
+		scope    string
+		hash     string
+		contract string // contrato del registro que se repite; vacío si la petición se procesa
+		err      error
+	}{
+		{"llave nueva", func(ic *IdempotencyCache) {}, "ana", "h1", "", nil},
+		{"reintento de una petición completada", func(ic *IdempotencyCache) {
+			ic.Begin("ana", "llave", "h1")
+			ic.Complete("ana", "llave", "contrato-1")
+		}, "ana", "h1", "contrato-1", nil},
+		{"misma llave con otro cuerpo", func(ic *IdempotencyCache) {
+			ic.Begin("ana", "llave", "h1")
+			ic.Complete("ana", "llave", "contrato-1")
+		}, "ana", "h2", "", ErrIdempotencyKeyReused},
+		{"reintento mientras la original sigue en curso", func(ic *IdempotencyCache) {
+			ic.Begin("ana", "llave", "h1")
+		}, "ana", "h1", "", ErrIdempotencyKeyInFlight},
+		{"otro cuerpo mientras la original sigue en curso", func(ic *IdempotencyCache) {
+			ic.Begin("ana", "llave", "h1")
+		}, "ana", "h2", "", ErrIdempotencyKeyReused},
+		{"reintento de una petición fallida", func(ic *IdempotencyCache) {
+			ic.Begin("ana", "llave", "h1")
+			ic.Release("ana", "llave")
+		}, "ana", "h1", "", nil},
+		{"la misma llave de otro usuario", func(ic *IdempotencyCache) {
+			ic.Begin("ana", "llave", "h1")
+			ic.Complete("ana", "llave", "contrato-1")
+		}, "luis", "h2", "", nil},
+		{"llave vencida", func(ic *IdempotencyCache) {
+			ic.TTL = time.Millisecond
+			ic.Begin("ana", "llave", "h1")
+			ic.Complete("ana", "llave", "contrato-1")
+			time.Sleep(5 * time.Millisecond)
+		}, "ana", "h2", "", nil},
+	}
+	for _, tt := range tests {
+		ic := newIdempotencyCache(storage.NewMemoryStore())
+		tt.prepare(ic)
+		record, err := ic.Begin(tt.scope, "llave", tt.hash)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: error %v, se esperaba %v", tt.name, err, tt.err)
+			continue
+		}
+		switch {
+		case tt.contract == "" && record != nil:
+			t.Errorf("%s: se repitió el contrato %s", tt.name, record.ContractID)
+		case tt.contract != "" && (record == nil || record.ContractID != tt.contract):
+			t.Errorf("%s: registro %+v, se esperaba el contrato %s", tt.name, record, tt.contract)
+		}
+	}
+
+	// Las llaves completadas sobreviven al reinicio del nodo
+	bc := newTestBlockchain(t)
+	request := map[string]interface{}{"entity_code": "123456", "amount": 1000000}
+	hash, err := IdempotencyRequestHash(request)
+	if err != nil {
+		t.Fatalf("calculando la huella: %v", err)
+	}
+	if record, err := bc.IdempotencyKeys.Begin("ana", "radicar-1", hash); record != nil || err != nil {
+		t.Fatalf("reservando la llave: %+v, %v", record, err)
+	}
+	contract := newTestContract(t, bc)
+	bc.IdempotencyKeys.Complete("ana", "radicar-1", contract.ID)
+
+	restarted, err := NewBlockchain(bc.store)
+	if err != nil {
+		t.Fatalf("reiniciando la blockchain: %v", err)
+	}
+	record, err := restarted.IdempotencyKeys.Begin("ana", "radicar-1", hash)
+	if err != nil || record == nil || record.ContractID != contract.ID {
+		t.Fatalf("reintento tras reiniciar: %+v, %v", record, err)
+	}
+	if removed := restarted.IdempotencyKeys.Sweep(time.Now().Add(DefaultIdempotencyTTL)); removed != 1 {
+		t.Errorf("se descartaron %d llaves vencidas", removed)
+	}
+}
@@ -0,0 +1,63 @@
+package blockchain
+
+import (
+	"fmt"
+	"sync"
+)
+
+// blockHashIndex ubica los bloques de la cadena local por hash sin recorrerla. Cada bloque
+// queda indexado por su hash local y, si llegó retransmitido, por el hash de su autor. La
+// búsqueda por altura es la posición en la cadena y no necesita índice.
+type blockHashIndex struct {
+	heights map[string]int
+	mutex   sync.RWMutex
+}
+
+func newBlockHashIndex() *blockHashIndex {
+	return &blockHashIndex{heights: make(map[string]int)}
+}
+
+// add indexa el bloque en su altura
+func (idx *blockHashIndex) add(block *Block, height int) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	idx.heights[block.Hash] = height
+	if origin := originHash(block); origin != block.Hash {
+		idx.heights[origin] = height
+	}
+}
+
+// reset reindexa la cadena completa
+func (idx *blockHashIndex) reset(chain []*Block) {
+	heights := make(map[string]int, len(chain))
+	for i, block := range chain {
+		heights[block.Hash] = i
+		if origin := originHash(block); origin != block.Hash {
+			heights[origin] = i
+		}
+	}
+
+	idx.mutex.Lock()
+	idx.heights = heights
+	idx.mutex.Unlock()
+}
+
+// lookup retorna la altura indexada para el hash
+func (idx *blockHashIndex) lookup(hash string) (int, bool) {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	height, exists := idx.heights[hash]
+	return height, exists
+}
+
+// BlockByHash retorna el bloque de la cadena local con el hash dado, local o de origen.
+// Si el bloque está archivado lo lee completo del archivo.
+func (bc *Blockchain) BlockByHash(hash string) (*Block, error) {
+	height := bc.blockIndex(hash)
+	if height < 0 {
+		return nil, fmt.Errorf("bloque %s no encontrado", hash)
+	}
+	return bc.BlockAt(height)
+}
@@ -0,0 +1,510 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// Parámetros de entrega al puente con SECOP II
+const (
+	bridgeMaxAttempts = 8
+	bridgeBaseBackoff = 5 * time.Second
+	bridgeMaxBackoff  = 30 * time.Minute
+)
+
+// Evento usado cuando la reconciliación reenvía el estado completo de un contrato
+const bridgeSyncEvent = "SINCRONIZACION"
+
+// BridgeMappings son las tablas de equivalencia entre la cadena y SECOP II
+type BridgeMappings struct {
+	Statuses      map[ContractStatus]string `json:"statuses"`
+	Events        map[string]string         `json:"events"`
+	ContractTypes map[string]string         `json:"contract_types"`
+}
+
+// DefaultBridgeMappings retorna las equivalencias por defecto con los estados y modalidades de SECOP II
+func DefaultBridgeMappings() BridgeMappings {
+	return BridgeMappings{
+		Statuses: map[ContractStatus]string{
+			StatusDraft:                    "Borrador",
+			StatusTechnicalReview:          "En aprobación",
+			StatusTechnicalApproved:        "En aprobación",
+			StatusLegalReview:              "En aprobación",
+			StatusLegalApproved:            "En aprobación",
+			StatusContractsReview:          "En aprobación",
+			StatusContractsApproved:        "En aprobación",
+			StatusAdminReview:              "En aprobación",
+			StatusAdminApproved:            "En aprobación",
+			StatusBudgetReview:             "En aprobación",
+			StatusAuthorizedForPublication: "Aprobado",
+			StatusPublished:                "Publicado",
+			StatusProposalsReceived:        "Presentación de ofertas",
+			StatusEvaluated:                "Evaluación",
+			StatusAwarded:                  "Adjudicado",
+			StatusExecuted:                 "En ejecución",
+			StatusCompleted:                "Liquidado",
+			StatusUnderAudit:               "En ejecución",
+			StatusAuditObservations:        "En ejecución",
+			StatusRejected:                 "Cancelado",
+		},
+		Events: map[string]string{
+			"CONTRACT_CREATION":          "CreacionProceso",
+			"VALIDATION":                 "AprobacionInterna",
+			"AUDIT_OBSERVATION":          "ObservacionControl",
+			ObservationResponseBlockType: "RespuestaObservacionControl",
+			"CONTRACT_PUBLICATION":       "PublicacionProceso",
+			"PLIEGO_QUESTION":            "ObservacionPliego",
+			"PLIEGO_RESPONSE":            "RespuestaObservacion",
+			"CONTRACT_AWARD":             "Adjudicacion",
+			"EXECUTION_EVIDENCE":         "EvidenciaEjecucion",
+			PaymentBlockType:             "RegistroPago",
+			AmendmentBlockType:           "ModificacionContrato",
+			PaymentActBlockType:          "RegistroPago",
+		},
+		ContractTypes: map[string]string{
+			"OBRA_PUBLICA":         "Licitación pública Obra Publica",
+			"SUMINISTRO":           "Selección abreviada subasta inversa",
+			"CONTRATACION_DIRECTA": "Contratación directa",
+			"PRESTACION_SERVICIOS": "Contratación directa",
+			"MINIMA_CUANTIA":       "Mínima cuantía",
+		},
+	}
+}
+
+// LoadBridgeMappings lee las equivalencias desde un archivo JSON; lo que no venga en
+// el archivo conserva el valor por defecto
+func LoadBridgeMappings(path string) (BridgeMappings, error) {
+	mappings := DefaultBridgeMappings()
+	if path == "" {
+		return mappings, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return mappings, err
+	}
+	var custom BridgeMappings
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return mappings, fmt.Errorf("archivo de equivalencias inválido: %v", err)
+	}
+	for key, value := range custom.Statuses {
+		mappings.Statuses[key] = value
+	}
+	for key, value := range custom.Events {
+		mappings.Events[key] = value
+	}
+	for key, value := range custom.ContractTypes {
+		mappings.ContractTypes[key] = value
+	}
+	return mappings, nil
+}
+
+// SecopPayload es el mensaje enviado al puente en el formato de SECOP II
+type SecopPayload struct {
+	Secuencia        int         `json:"secuencia"`
+	Evento           string      `json:"evento"`
+	IDProceso        string      `json:"id_proceso"`
+	CodigoEntidad    string      `json:"codigo_entidad"`
+	NombreEntidad    string      `json:"nombre_entidad"`
+	Modalidad        string      `json:"modalidad"`
+	Descripcion      string      `json:"descripcion"`
+	ValorEstimado    float64     `json:"valor_estimado"`
+	EstadoProceso    string      `json:"estado_proceso"`
+	Adjudicatario    string      `json:"adjudicatario,omitempty"`
+	FechaEvento      time.Time   `json:"fecha_evento"`
+	HashBloque       string      `json:"hash_bloque"`
+	AlturaBloque     int         `json:"altura_bloque"`
+	EstadoBlockchain string      `json:"estado_blockchain"`
+	TipoEventoCadena string      `json:"tipo_evento_cadena"`
+	NodoOrigen       string      `json:"nodo_origen,omitempty"`
+	DatosTransaccion interface{} `json:"datos_transaccion,omitempty"`
+}
+
+// BridgeRecord guarda lo último que SECOP II confirmó de un contrato
+type BridgeRecord struct {
+	ContractID  string    `json:"contract_id"`
+	Sequence    int       `json:"sequence"`
+	SecopStatus string    `json:"secop_status"`
+	BlockHeight int       `json:"block_height"`
+	SyncedAt    time.Time `json:"synced_at"`
+}
+
+// BridgeFailure representa un envío descartado tras agotar los reintentos
+type BridgeFailure struct {
+	Payload  SecopPayload `json:"payload"`
+	Error    string       `json:"error"`
+	FailedAt time.Time    `json:"failed_at"`
+}
+
+// BridgeMismatch representa un contrato cuyo estado en SECOP II no coincide con la cadena
+type BridgeMismatch struct {
+	ContractID   string `json:"contract_id"`
+	ChainStatus  string `json:"chain_status"`
+	SecopStatus  string `json:"secop_status"`
+	RemoteStatus string `json:"remote_status,omitempty"`
+}
+
+// BridgeReport es el reporte de reconciliación entre la cadena y SECOP II
+type BridgeReport struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Contracts   int              `json:"contracts"`
+	InSync      int              `json:"in_sync"`
+	Pending     int              `json:"pending"`
+	Failed      []BridgeFailure  `json:"failed"`
+	Mismatched  []BridgeMismatch `json:"mismatched"`
+	Requeued    int              `json:"requeued"`
+}
+
+// bridgeDelivery representa un envío pendiente al puente
+type bridgeDelivery struct {
+	payload     SecopPayload
+	attempts    int
+	nextAttempt time.Time
+}
+
+// SecopBridge transforma los eventos de la cadena al formato de SECOP II y los envía
+// en orden al endpoint del puente, con reintentos y reportes de reconciliación
+type SecopBridge struct {
+	blockchain *Blockchain
+	endpoint   string
+	token      string
+	mappings   BridgeMappings
+	client     *http.Client
+	sequence   int
+	queue      []*bridgeDelivery
+	records    map[string]*BridgeRecord
+	failed     []BridgeFailure
+	mutex      sync.Mutex
+}
+
+// NewSecopBridge crea el puente, recupera lo ya sincronizado y lo conecta al bus de eventos
+func NewSecopBridge(bc *Blockchain, endpoint string, token string, mappings BridgeMappings) *SecopBridge {
+	sb := &SecopBridge{
+		blockchain: bc,
+		endpoint:   strings.TrimRight(endpoint, "/"),
+		token:      token,
+		mappings:   mappings,
+		client:     &http.Client{Timeout: 15 * time.Second},
+		records:    make(map[string]*BridgeRecord),
+	}
+
+	bc.store.ForEach(storage.BucketBridge, func(key string, value []byte) error {
+		var record BridgeRecord
+		if err := json.Unmarshal(value, &record); err == nil {
+			sb.records[key] = &record
+			if record.Sequence > sb.sequence {
+				sb.sequence = record.Sequence
+			}
+		}
+		return nil
+	})
+
+	bc.Projections.Subscribe(ProjectionSecopBridge, sb.enqueue)
+	return sb
+}
+
+// Mappings retorna las tablas de equivalencia en uso
+func (sb *SecopBridge) Mappings() BridgeMappings {
+	return sb.mappings
+}
+
+// enqueue transforma el evento y lo encola para el puente
+func (sb *SecopBridge) enqueue(event ChainEvent) {
+	payload := eventPayload(event)
+	contractID := event.ContractID
+	if contractID == "" {
+		contractID, _ = payload["contract_id"].(string)
+	}
+	if contractID == "" {
+		return
+	}
+
+	secopEvent, mapped := sb.mappings.Events[event.Type]
+	if !mapped {
+		return
+	}
+
+	message := sb.payloadFor(contractID, payload)
+	message.Evento = secopEvent
+	message.TipoEventoCadena = event.Type
+	message.FechaEvento = event.Timestamp
+	message.HashBloque = event.BlockHash
+	message.AlturaBloque = event.Height
+	message.DatosTransaccion = payload
+
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+	sb.push(message)
+}
+
+// payloadFor construye el mensaje base con el estado actual del contrato
+func (sb *SecopBridge) payloadFor(contractID string, data map[string]interface{}) SecopPayload {
+	message := SecopPayload{IDProceso: contractID}
+
+	contract, exists := sb.blockchain.Contract(contractID)
+	if !exists {
+		// Contrato conocido solo por el bloque (p. ej. recibido de un peer)
+		message.CodigoEntidad, _ = data["entity_code"].(string)
+		message.NombreEntidad, _ = data["entity_name"].(string)
+		message.ValorEstimado, _ = data["amount"].(float64)
+		contractType, _ := data["contract_type"].(string)
+		message.Modalidad = sb.mapContractType(contractType)
+		return message
+	}
+
+	message.CodigoEntidad = contract.EntityCode
+	message.NombreEntidad = contract.EntityName
+	message.Modalidad = sb.mapContractType(contract.ContractType)
+	message.Descripcion = contract.Description
+	message.ValorEstimado = contract.Amount
+	message.EstadoProceso = sb.mapStatus(contract.Status)
+	message.EstadoBlockchain = string(contract.Status)
+	message.Adjudicatario = contract.AwardedTo
+	message.NodoOrigen = contract.OriginSystem
+	return message
+}
+
+// push agrega el mensaje a la cola; requiere el lock del puente
+func (sb *SecopBridge) push(message SecopPayload) {
+	sb.sequence++
+	message.Secuencia = sb.sequence
+	sb.queue = append(sb.queue, &bridgeDelivery{payload: message, nextAttempt: time.Now()})
+}
+
+func (sb *SecopBridge) mapStatus(status ContractStatus) string {
+	if mapped, ok := sb.mappings.Statuses[status]; ok {
+		return mapped
+	}
+	return string(status)
+}
+
+func (sb *SecopBridge) mapContractType(contractType string) string {
+	if mapped, ok := sb.mappings.ContractTypes[contractType]; ok {
+		return mapped
+	}
+	return contractType
+}
+
+// Run envía periódicamente los mensajes pendientes
+func (sb *SecopBridge) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sb.deliverPending()
+	}
+}
+
+// deliverPending envía los mensajes en orden; si uno falla se espera antes de seguir
+// para no desordenar la historia del proceso en SECOP II
+func (sb *SecopBridge) deliverPending() {
+	for {
+		sb.mutex.Lock()
+		if len(sb.queue) == 0 || sb.queue[0].nextAttempt.After(time.Now()) {
+			sb.mutex.Unlock()
+			return
+		}
+		delivery := sb.queue[0]
+		sb.mutex.Unlock()
+
+		err := sb.post(delivery.payload)
+
+		sb.mutex.Lock()
+		if err == nil {
+			sb.queue = sb.queue[1:]
+			sb.acknowledge(delivery.payload)
+			sb.mutex.Unlock()
+			continue
+		}
+
+		delivery.attempts++
+		if delivery.attempts >= bridgeMaxAttempts {
+			logf("❌ Mensaje %d al puente SECOP II descartado: %v\n", delivery.payload.Secuencia, err)
+			sb.queue = sb.queue[1:]
+			sb.failed = append(sb.failed, BridgeFailure{Payload: delivery.payload, Error: err.Error(), FailedAt: time.Now()})
+			sb.mutex.Unlock()
+			continue
+		}
+		backoff := bridgeBaseBackoff << uint(delivery.attempts-1)
+		if backoff > bridgeMaxBackoff {
+			backoff = bridgeMaxBackoff
+		}
+		delivery.nextAttempt = time.Now().Add(backoff)
+		sb.mutex.Unlock()
+		return
+	}
+}
+
+// acknowledge registra lo confirmado por SECOP II; requiere el lock del puente
+func (sb *SecopBridge) acknowledge(payload SecopPayload) {
+	record := &BridgeRecord{
+		ContractID:  payload.IDProceso,
+		Sequence:    payload.Secuencia,
+		SecopStatus: payload.EstadoProceso,
+		BlockHeight: payload.AlturaBloque,
+		SyncedAt:    time.Now(),
+	}
+	sb.records[payload.IDProceso] = record
+	sb.blockchain.saveState(storage.BucketBridge, payload.IDProceso, record)
+}
+
+// post envía un mensaje al endpoint del puente
+func (sb *SecopBridge) post(payload SecopPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sb.endpoint+"/eventos", bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if sb.token != "" {
+		req.Header.Set("Authorization", "Bearer "+sb.token)
+	}
+
+	resp, err := sb.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("puente respondió con status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// remoteStatus consulta el estado del proceso en SECOP II a través del puente
+func (sb *SecopBridge) remoteStatus(contractID string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, sb.endpoint+"/procesos/"+contractID, nil)
+	if err != nil {
+		return "", err
+	}
+	if sb.token != "" {
+		req.Header.Set("Authorization", "Bearer "+sb.token)
+	}
+
+	resp, err := sb.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("puente respondió con status %d", resp.StatusCode)
+	}
+	var remote struct {
+		EstadoProceso string `json:"estado_proceso"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&remote); err != nil {
+		return "", err
+	}
+	return remote.EstadoProceso, nil
+}
+
+// Status retorna un resumen del puente sin generar reconciliación
+func (sb *SecopBridge) Status() map[string]interface{} {
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+
+	status := map[string]interface{}{
+		"endpoint": sb.endpoint,
+		"pending":  len(sb.queue),
+		"failed":   len(sb.failed),
+		"synced":   len(sb.records),
+		"sequence": sb.sequence,
+	}
+	if len(sb.queue) > 0 {
+		status["next_attempt"] = sb.queue[0].nextAttempt
+		status["attempts"] = sb.queue[0].attempts
+	}
+	return status
+}
+
+// Reconcile compara el estado de cada contrato en la cadena con lo confirmado por
+// SECOP II y, si checkRemote es verdadero, con lo que reporta el puente. Los contratos
+// desalineados sin envíos pendientes se reencolan con su estado completo.
+func (sb *SecopBridge) Reconcile(checkRemote bool) BridgeReport {
+	contracts := make([]*Contract, 0, sb.blockchain.ContractCount())
+	for _, contract := range sb.blockchain.contractMap() {
+		contracts = append(contracts, contract)
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].CreatedAt.Before(contracts[j].CreatedAt)
+	})
+
+	remote := make(map[string]string)
+	if checkRemote {
+		for _, contract := range contracts {
+			if status, err := sb.remoteStatus(contract.ID); err == nil {
+				remote[contract.ID] = status
+			}
+		}
+	}
+
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+
+	queued := make(map[string]bool)
+	for _, delivery := range sb.queue {
+		queued[delivery.payload.IDProceso] = true
+	}
+
+	report := BridgeReport{
+		GeneratedAt: time.Now(),
+		Contracts:   len(contracts),
+		Pending:     len(sb.queue),
+		Failed:      append([]BridgeFailure{}, sb.failed...),
+		Mismatched:  []BridgeMismatch{},
+	}
+
+	for _, contract := range contracts {
+		expected := sb.mapStatus(contract.Status)
+		acknowledged := ""
+		if record, exists := sb.records[contract.ID]; exists {
+			acknowledged = record.SecopStatus
+		}
+		remoteStatus, checked := remote[contract.ID]
+
+		if acknowledged == expected && (!checked || remoteStatus == expected) {
+			report.InSync++
+			continue
+		}
+
+		report.Mismatched = append(report.Mismatched, BridgeMismatch{
+			ContractID:   contract.ID,
+			ChainStatus:  string(contract.Status),
+			SecopStatus:  acknowledged,
+			RemoteStatus: remoteStatus,
+		})
+		if !queued[contract.ID] {
+			message := sb.payloadFor(contract.ID, nil)
+			message.Evento = bridgeSyncEvent
+			message.TipoEventoCadena = bridgeSyncEvent
+			message.FechaEvento = time.Now()
+			// Hash y altura del mismo bloque: leerlos por separado puede mezclar dos puntas
+			tip := sb.blockchain.getLatestBlock()
+			message.HashBloque = tip.Hash
+			message.AlturaBloque = tip.Index
+			sb.push(message)
+			report.Requeued++
+		}
+	}
+
+	// Los fallidos ya quedaron reencolados por la reconciliación
+	sb.failed = nil
+	report.Pending = len(sb.queue)
+	logf("🔁 Reconciliación SECOP II: %d/%d alineados, %d reencolados\n", report.InSync, report.Contracts, report.Requeued)
+	return report
+}
@@ -0,0 +1,107 @@
+//go:build byzantine
+
+package blockchain
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Compilación de depuración: el nodo puede configurarse para comportarse de forma
+// maliciosa y así probar el consenso y la reputación frente a nodos adversarios.
+// Se activa con `go build -tags byzantine` y la variable BYZANTINE_BEHAVIORS, p. ej.
+// BYZANTINE_BEHAVIORS=invalid_hash,equivocate,withhold,lie_height:50
+
+// byzantineConfig define los comportamientos maliciosos activos
+type byzantineConfig struct {
+	invalidHash bool
+	equivocate  bool
+	withhold    bool
+	heightLie   int
+}
+
+var byzantine = loadByzantineConfig()
+
+// equivocationCounter alterna las versiones de bloque enviadas a cada peer
+var equivocationCounter uint64
+
+// loadByzantineConfig lee los comportamientos desde BYZANTINE_BEHAVIORS
+func loadByzantineConfig() byzantineConfig {
+	var cfg byzantineConfig
+	for _, behavior := range strings.Split(os.Getenv("BYZANTINE_BEHAVIORS"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(behavior), ":")
+		switch name {
+		case "invalid_hash":
+			cfg.invalidHash = true
+		case "equivocate":
+			cfg.equivocate = true
+		case "withhold":
+			cfg.withhold = true
+		case "lie_height":
+			cfg.heightLie = 100
+			if n, err := strconv.Atoi(value); err == nil {
+				cfg.heightLie = n
+			}
+		}
+	}
+	return cfg
+}
+
+func init() {
+	if behaviors := ByzantineBehaviors(); len(behaviors) > 0 {
+		logf("☠️ Nodo bizantino activo: %s\n", strings.Join(behaviors, ", "))
+	}
+}
+
+// ByzantineBehaviors retorna los comportamientos maliciosos activos
+func ByzantineBehaviors() []string {
+	var behaviors []string
+	if byzantine.invalidHash {
+		behaviors = append(behaviors, "invalid_hash")
+	}
+	if byzantine.equivocate {
+		behaviors = append(behaviors, "equivocate")
+	}
+	if byzantine.withhold {
+		behaviors = append(behaviors, "withhold")
+	}
+	if byzantine.heightLie != 0 {
+		behaviors = append(behaviors, fmt.Sprintf("lie_height:%d", byzantine.heightLie))
+	}
+	return behaviors
+}
+
+// ByzantineReportedHeight retorna la altura que el nodo anuncia a los demás
+func ByzantineReportedHeight(height int) int {
+	return height + byzantine.heightLie
+}
+
+// byzantineOutgoingBlock altera un bloque antes de enviarlo a un peer; el segundo
+// valor indica si el bloque debe enviarse
+func byzantineOutgoingBlock(block Block) (Block, bool) {
+	if byzantine.withhold {
+		return block, false
+	}
+
+	if byzantine.equivocate && atomic.AddUint64(&equivocationCounter, 1)%2 == 0 {
+		// Versión alternativa con hash válido pero contenido distinto
+		data := make(map[string]interface{}, len(block.Data)+1)
+		for k, v := range block.Data {
+			data[k] = v
+		}
+		data["equivocation"] = equivocationCounter
+		block.Data = data
+		block.Transactions = []Transaction{newTransaction(data)}
+		block.MerkleRoot = MerkleRoot(block.Transactions)
+		block.Hash = block.calculateHash()
+	}
+
+	if byzantine.invalidHash {
+		block.Hash = strings.Repeat("0", len(block.Hash))
+	}
+
+	return block, true
+}
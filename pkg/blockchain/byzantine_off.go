@@ -0,0 +1,18 @@
+//go:build !byzantine
+
+package blockchain
+
+// ByzantineBehaviors retorna los comportamientos maliciosos activos (ninguno en compilaciones normales)
+func ByzantineBehaviors() []string {
+	return nil
+}
+
+// ByzantineReportedHeight retorna la altura real de la cadena
+func ByzantineReportedHeight(height int) int {
+	return height
+}
+
+// byzantineOutgoingBlock no altera el bloque en compilaciones normales
+func byzantineOutgoingBlock(block Block) (Block, bool) {
+	return block, true
+}
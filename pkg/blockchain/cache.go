@@ -0,0 +1,71 @@
+package blockchain
+
+import "sync"
+
+// ViewCache guarda vistas derivadas costosas indexadas por (recurso, hash del último bloque).
+// Cuando llega un bloque nuevo el hash de la punta cambia y todas las entradas se descartan.
+type ViewCache struct {
+	tip     string
+	entries map[string]interface{}
+	hits    uint64
+	misses  uint64
+	mutex   sync.Mutex
+}
+
+// CacheStats representa las métricas del cache de vistas
+type CacheStats struct {
+	Tip     string `json:"tip"`
+	Entries int    `json:"entries"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+}
+
+// NewViewCache crea un cache de vistas vacío
+func NewViewCache() *ViewCache {
+	return &ViewCache{
+		entries: make(map[string]interface{}),
+	}
+}
+
+// GetOrCompute retorna la vista en cache para la punta dada o la calcula y la guarda
+func (vc *ViewCache) GetOrCompute(resource string, tip string, compute func() (interface{}, error)) (interface{}, error) {
+	vc.mutex.Lock()
+	if vc.tip != tip {
+		vc.tip = tip
+		vc.entries = make(map[string]interface{})
+	}
+	if value, ok := vc.entries[resource]; ok {
+		vc.hits++
+		vc.mutex.Unlock()
+		return value, nil
+	}
+	vc.misses++
+	vc.mutex.Unlock()
+
+	value, err := compute()
+	if err != nil {
+		return nil, err
+	}
+
+	vc.mutex.Lock()
+	// Solo guardar si la punta no cambió mientras se calculaba
+	if vc.tip == tip {
+		vc.entries[resource] = value
+	}
+	vc.mutex.Unlock()
+
+	return value, nil
+}
+
+// Stats retorna las métricas del cache
+func (vc *ViewCache) Stats() CacheStats {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	return CacheStats{
+		Tip:     vc.tip,
+		Entries: len(vc.entries),
+		Hits:    vc.hits,
+		Misses:  vc.misses,
+	}
+}
@@ -0,0 +1,49 @@
+package blockchain
+
+import (
+	"errors"
+	"time"
+)
+
+// Plazos por defecto del cronograma del proceso (en días)
+const (
+	DefaultQuestionsWindowDays = 5
+	DefaultResponsesWindowDays = 3
+)
+
+// ProcessCalendar representa el cronograma de un proceso publicado
+type ProcessCalendar struct {
+	PublishedAt       time.Time `json:"published_at"`
+	QuestionsDeadline time.Time `json:"questions_deadline"`
+	ResponsesDeadline time.Time `json:"responses_deadline"`
+}
+
+// NewProcessCalendar crea el cronograma a partir de la fecha de publicación
+func NewProcessCalendar(publishedAt time.Time, questionsDays int, responsesDays int) (*ProcessCalendar, error) {
+	if questionsDays <= 0 {
+		questionsDays = DefaultQuestionsWindowDays
+	}
+	if responsesDays <= 0 {
+		responsesDays = DefaultResponsesWindowDays
+	}
+	if questionsDays > 60 || responsesDays > 60 {
+		return nil, errors.New("los plazos del cronograma no pueden superar 60 días")
+	}
+
+	questionsDeadline := publishedAt.AddDate(0, 0, questionsDays)
+	return &ProcessCalendar{
+		PublishedAt:       publishedAt,
+		QuestionsDeadline: questionsDeadline,
+		ResponsesDeadline: questionsDeadline.AddDate(0, 0, responsesDays),
+	}, nil
+}
+
+// QuestionsOpen indica si aún se reciben observaciones al pliego
+func (pc *ProcessCalendar) QuestionsOpen(now time.Time) bool {
+	return !now.Before(pc.PublishedAt) && now.Before(pc.QuestionsDeadline)
+}
+
+// ResponsesOpen indica si la entidad aún puede publicar respuestas
+func (pc *ProcessCalendar) ResponsesOpen(now time.Time) bool {
+	return !now.Before(pc.PublishedAt) && now.Before(pc.ResponsesDeadline)
+}
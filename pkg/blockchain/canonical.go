@@ -0,0 +1,157 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Versiones de hash de los bloques. Los bloques legados se hashean con el JSON de Go; los
+// canónicos con CBOR determinista (RFC 8949, sección 4.2.1), que no depende del orden de
+// las llaves ni de cómo cada nodo formatea los números.
+const (
+	HashVersionLegacy    = 0
+	HashVersionCanonical = 3
+)
+
+// CanonicalHashingFeature es el feature del protocolo que activa el hash canónico. Si no
+// tiene altura programada en PROTOCOL_ACTIVATIONS, los bloques nuevos lo usan de inmediato.
+const CanonicalHashingFeature = "CANONICAL_HASHING"
+
+// canonicalEncode codifica un valor en CBOR determinista. El valor se normaliza primero
+// con un viaje de ida y vuelta por JSON para que los datos locales (int, time.Time,
+// structs) y los recibidos de peers (float64, string) produzcan los mismos bytes.
+func canonicalEncode(value interface{}) ([]byte, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var normalized interface{}
+	if err := decoder.Decode(&normalized); err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	if err := encodeCBOR(&buffer, normalized); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// encodeCBOR escribe un valor normalizado (nil, bool, json.Number, string, arreglo o mapa)
+func encodeCBOR(buffer *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buffer.WriteByte(0xf6)
+	case bool:
+		if v {
+			buffer.WriteByte(0xf5)
+		} else {
+			buffer.WriteByte(0xf4)
+		}
+	case json.Number:
+		return encodeCBORNumber(buffer, v)
+	case string:
+		writeCBORHead(buffer, 3, uint64(len(v)))
+		buffer.WriteString(v)
+	case []interface{}:
+		writeCBORHead(buffer, 4, uint64(len(v)))
+		for _, item := range v {
+			if err := encodeCBOR(buffer, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		// Las llaves se ordenan por sus bytes codificados
+		keys := make([][]byte, 0, len(v))
+		values := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			var encodedKey bytes.Buffer
+			writeCBORHead(&encodedKey, 3, uint64(len(key)))
+			encodedKey.WriteString(key)
+			keys = append(keys, encodedKey.Bytes())
+			values[string(encodedKey.Bytes())] = item
+		}
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+
+		writeCBORHead(buffer, 5, uint64(len(v)))
+		for _, key := range keys {
+			buffer.Write(key)
+			if err := encodeCBOR(buffer, values[string(key)]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("tipo no soportado en la codificación canónica: %T", value)
+	}
+	return nil
+}
+
+// encodeCBORNumber escribe los números enteros como enteros CBOR, sin importar si el nodo
+// los tenía como int o float64, y los demás como flotantes de 64 bits
+func encodeCBORNumber(buffer *bytes.Buffer, number json.Number) error {
+	text := number.String()
+	if !strings.ContainsAny(text, ".eE") {
+		if integer, err := strconv.ParseInt(text, 10, 64); err == nil {
+			writeCBORInteger(buffer, integer)
+			return nil
+		}
+	}
+
+	float, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return fmt.Errorf("número inválido en la codificación canónica: %s", text)
+	}
+	if float == math.Trunc(float) && math.Abs(float) < 1<<53 {
+		writeCBORInteger(buffer, int64(float))
+		return nil
+	}
+	buffer.WriteByte(0xfb)
+	var bits [8]byte
+	binary.BigEndian.PutUint64(bits[:], math.Float64bits(float))
+	buffer.Write(bits[:])
+	return nil
+}
+
+// writeCBORInteger escribe un entero con signo
+func writeCBORInteger(buffer *bytes.Buffer, integer int64) {
+	if integer >= 0 {
+		writeCBORHead(buffer, 0, uint64(integer))
+		return
+	}
+	writeCBORHead(buffer, 1, uint64(-(integer + 1)))
+}
+
+// writeCBORHead escribe el tipo mayor y el argumento con la codificación más corta
+func writeCBORHead(buffer *bytes.Buffer, major byte, argument uint64) {
+	head := major << 5
+	switch {
+	case argument < 24:
+		buffer.WriteByte(head | byte(argument))
+	case argument <= math.MaxUint8:
+		buffer.WriteByte(head | 24)
+		buffer.WriteByte(byte(argument))
+	case argument <= math.MaxUint16:
+		buffer.WriteByte(head | 25)
+		var bytes2 [2]byte
+		binary.BigEndian.PutUint16(bytes2[:], uint16(argument))
+		buffer.Write(bytes2[:])
+	case argument <= math.MaxUint32:
+		buffer.WriteByte(head | 26)
+		var bytes4 [4]byte
+		binary.BigEndian.PutUint32(bytes4[:], uint32(argument))
+		buffer.Write(bytes4[:])
+	default:
+		buffer.WriteByte(head | 27)
+		var bytes8 [8]byte
+		binary.BigEndian.PutUint64(bytes8[:], argument)
+		buffer.Write(bytes8[:])
+	}
+}
@@ -0,0 +1,81 @@
+package blockchain
+
+import "sync"
+
+// Puesta al día inmediata: cuando llega un bloque cuyo padre no se conoce y que está
+// adelante de la punta local, este nodo se quedó atrás. En lugar de esperar a la
+// sincronización periódica se sincroniza de una vez con el peer que lo envió, que por
+// haberlo enviado tiene los bloques que faltan.
+
+// catchUpTracker evita lanzar más de una puesta al día a la vez con el mismo peer
+type catchUpTracker struct {
+	pending   map[string]bool
+	scheduled int // Puestas al día lanzadas desde que inició el nodo
+	mutex     sync.Mutex
+}
+
+func newCatchUpTracker() *catchUpTracker {
+	return &catchUpTracker{pending: make(map[string]bool)}
+}
+
+// start marca la puesta al día con el peer como en curso; false si ya había una
+func (ct *catchUpTracker) start(peerID string) bool {
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+	if ct.pending[peerID] {
+		return false
+	}
+	ct.pending[peerID] = true
+	ct.scheduled++
+	return true
+}
+
+func (ct *catchUpTracker) finish(peerID string) {
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+	delete(ct.pending, peerID)
+}
+
+// CatchUpSyncs retorna cuántas puestas al día se lanzaron al recibir bloques adelantados
+func (p2p *P2PNetwork) CatchUpSyncs() int {
+	p2p.catchUps.mutex.Lock()
+	defer p2p.catchUps.mutex.Unlock()
+	return p2p.catchUps.scheduled
+}
+
+// isAhead indica si el bloque está adelante de la punta local sin que se conozca su padre
+func (p2p *P2PNetwork) isAhead(block Block) bool {
+	return block.Index >= p2p.Blockchain.Len() && p2p.Blockchain.blockIndex(block.PreviousHash) < 0
+}
+
+// scheduleCatchUp sincroniza en segundo plano con el remitente del bloque adelantado y
+// luego incorpora los huérfanos que queden encadenados. No bloquea a quien recibe el bloque.
+func (p2p *P2PNetwork) scheduleCatchUp(sender string, block Block) {
+	if !p2p.catchUps.start(sender) {
+		return
+	}
+	logf("⏩ Bloque %s (altura %d) adelante de la punta local %d, sincronizando con %s\n", block.Hash, block.Index, p2p.Blockchain.Len()-1, sender)
+
+	go func() {
+		defer p2p.catchUps.finish(sender)
+		if p2p.Maintenance != nil && p2p.Maintenance.IsEnabled() {
+			return
+		}
+
+		p2p.mutex.RLock()
+		peer, exists := p2p.Peers[sender]
+		p2p.mutex.RUnlock()
+		if !exists || !peer.Active || p2p.Reputation.IsBanned(sender) {
+			logf("⏳ Huérfano %s en espera: el remitente %s no está disponible para sincronizar\n", block.Hash, sender)
+			return
+		}
+		p2p.syncWithPeer(sender, peer)
+
+		p2p.Forks.resolving.Lock()
+		defer p2p.Forks.resolving.Unlock()
+		if p2p.Blockchain.HasBlock(block.Hash) {
+			p2p.Forks.removeOrphan(block.Hash)
+		}
+		p2p.connectOrphans()
+	}()
+}
@@ -0,0 +1,111 @@
+package blockchain
+
+// Acceso sincronizado a la cadena y al estado de contratos. Los handlers HTTP, la
+// sincronización periódica, la difusión y los trabajos de fondo los usan a la vez, así
+// que nadie toca los campos directamente:
+//
+//   - bc.mutex protege el slice de bloques, la referencia al estado de contratos y las
+//     secuencias de cada contrato; solo se retiene el tiempo de leer o reemplazar la
+//     referencia, nunca mientras se llama a otro método. El estado (ver state.go) se
+//     sincroniza por su cuenta.
+//   - bc.writeMutex serializa a quienes escriben la cadena (agregar un bloque, reemplazar
+//     la cadena, archivar bloques) para que la altura que calcula uno no la tome otro.
+//
+// Los lectores reciben una vista de la cadena que no cambia: agregar un bloque escribe
+// más allá de su capacidad y reemplazar o archivar bloques crea un slice nuevo.
+
+// Blocks retorna la cadena actual. El slice es de solo lectura y no ve los bloques que
+// se agreguen después.
+func (bc *Blockchain) Blocks() []*Block {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	return bc.chain[:len(bc.chain):len(bc.chain)]
+}
+
+// Len retorna la cantidad de bloques de la cadena
+func (bc *Blockchain) Len() int {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	return len(bc.chain)
+}
+
+// appendBlock agrega el bloque a la cadena en memoria y lo indexa
+func (bc *Blockchain) appendBlock(block *Block) {
+	bc.mutex.Lock()
+	bc.chain = append(bc.chain, block)
+	bc.mutex.Unlock()
+	bc.hashIndex.add(block, block.Index)
+}
+
+// setChain reemplaza la cadena en memoria y reconstruye el índice por hash
+func (bc *Blockchain) setChain(chain []*Block) {
+	bc.mutex.Lock()
+	bc.chain = chain
+	bc.mutex.Unlock()
+	bc.hashIndex.reset(chain)
+}
+
+// currentState retorna el estado de contratos vigente
+func (bc *Blockchain) currentState() State {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	return bc.state
+}
+
+// swapState pone en vigencia un estado de contratos ya armado y retorna el anterior
+func (bc *Blockchain) swapState(state State) State {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	previous := bc.state
+	bc.state = state
+	return previous
+}
+
+// UseState cambia la implementación del estado de contratos, p. ej. por otro backend o
+// por una falsa en pruebas. El nuevo estado recibe los contratos actuales y se usa
+// también en las reconstrucciones posteriores.
+func (bc *Blockchain) UseState(factory func() State) {
+	state := factory()
+	state.Restore(bc.contractMap())
+	bc.mutex.Lock()
+	bc.newState = factory
+	bc.state = state
+	bc.mutex.Unlock()
+}
+
+// Contract retorna el contrato con el ID dado
+func (bc *Blockchain) Contract(contractID string) (*Contract, bool) {
+	return bc.currentState().Get(contractID)
+}
+
+// ContractCount retorna la cantidad de contratos
+func (bc *Blockchain) ContractCount() int {
+	return bc.currentState().Len()
+}
+
+// contractMap retorna una copia del mapa de contratos
+func (bc *Blockchain) contractMap() map[string]*Contract {
+	return bc.currentState().Snapshot()
+}
+
+// putContract guarda el contrato en el estado vigente
+func (bc *Blockchain) putContract(contract *Contract) {
+	bc.currentState().Put(contract)
+}
+
+// removeContract elimina el contrato del estado vigente
+func (bc *Blockchain) removeContract(contractID string) {
+	bc.currentState().Delete(contractID)
+}
+
+// setContracts arma un estado nuevo con los contratos dados y lo intercambia por el
+// vigente, sin que los lectores vean el estado a medio armar
+func (bc *Blockchain) setContracts(contracts map[string]*Contract) {
+	bc.mutex.RLock()
+	factory := bc.newState
+	bc.mutex.RUnlock()
+
+	state := factory()
+	state.Restore(contracts)
+	bc.swapState(state)
+}
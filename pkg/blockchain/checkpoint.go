@@ -0,0 +1,281 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CheckpointBlockType es el tipo de los bloques con que la autoridad de checkpoints fija
+// la historia de la cadena
+const CheckpointBlockType = "CHECKPOINT"
+
+// Checkpoint es la declaración firmada de la autoridad de que el bloque de esa altura, con
+// ese hash de origen, es definitivo. Ninguna sincronización reorganiza la cadena por
+// debajo del último checkpoint.
+type Checkpoint struct {
+	Height     int       `json:"height"`
+	Hash       string    `json:"hash"` // Hash de origen del bloque, igual en todos los nodos
+	Authority  string    `json:"authority"`
+	KeyID      string    `json:"kid"`
+	Signature  string    `json:"signature"`
+	BlockIndex int       `json:"block_index"` // Altura del bloque que registra el checkpoint
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CheckpointManager crea los checkpoints en el nodo autoridad (el nodo raíz del DNP) y
+// lleva en todos los nodos el último checkpoint válido de la cadena
+type CheckpointManager struct {
+	blockchain *Blockchain
+	authority  Authority
+	interval   int
+	latest     *Checkpoint
+	mutex      sync.RWMutex
+}
+
+// EnableCheckpoints designa la autoridad cuyos checkpoints acepta la cadena. interval es
+// la cantidad de bloques entre checkpoints que crea el nodo autoridad.
+func (bc *Blockchain) EnableCheckpoints(authority Authority, interval int) error {
+	if interval < 2 {
+		return errors.New("el intervalo de checkpoints debe ser de al menos 2 bloques")
+	}
+	if err := validateAuthorityKeys(authority.PublicKeys); err != nil {
+		return fmt.Errorf("autoridad de checkpoints %s: %v", authority.NodeID, err)
+	}
+
+	cm := &CheckpointManager{blockchain: bc, authority: authority, interval: interval}
+	bc.Checkpoints = cm
+	bc.rebuildCheckpoints()
+
+	if latest := cm.Latest(); latest != nil {
+		logf("📌 Checkpoints de %s cada %d bloques; último en la altura %d\n", authority.NodeID, interval, latest.Height)
+	} else {
+		logf("📌 Checkpoints de %s cada %d bloques\n", authority.NodeID, interval)
+	}
+	return nil
+}
+
+// checkpointPayload arma el mensaje que firma la autoridad
+func checkpointPayload(height int, hash string) []byte {
+	return []byte("CHECKPOINT|" + strconv.Itoa(height) + "|" + hash)
+}
+
+// Latest retorna el último checkpoint de la cadena, o nil si aún no hay
+func (cm *CheckpointManager) Latest() *Checkpoint {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	if cm.latest == nil {
+		return nil
+	}
+	latest := *cm.latest
+	return &latest
+}
+
+// Authority retorna el nodo autorizado para firmar checkpoints
+func (cm *CheckpointManager) Authority() Authority {
+	return cm.authority
+}
+
+// Interval retorna la cantidad de bloques entre checkpoints
+func (cm *CheckpointManager) Interval() int {
+	return cm.interval
+}
+
+// IsAuthority indica si este nodo firma los checkpoints
+func (cm *CheckpointManager) IsAuthority() bool {
+	bc := cm.blockchain
+	return bc.signerKeys != nil && bc.signerID == cm.authority.NodeID &&
+		containsKey(cm.authority.PublicKeys, bc.signerKeys.ActiveKeyID())
+}
+
+// Create firma un checkpoint sobre la punta actual y lo agrega a la cadena. Solo lo puede
+// hacer el nodo autoridad.
+func (cm *CheckpointManager) Create() (*Checkpoint, error) {
+	if !cm.IsAuthority() {
+		return nil, fmt.Errorf("solo %s con su llave registrada puede firmar checkpoints", cm.authority.NodeID)
+	}
+	bc := cm.blockchain
+	tip := bc.getLatestBlock()
+	if latest := cm.Latest(); latest != nil && latest.BlockIndex == tip.Index {
+		return nil, errors.New("la punta ya es un checkpoint")
+	}
+
+	checkpoint := Checkpoint{Height: tip.Index, Hash: originHash(tip), Authority: cm.authority.NodeID}
+	checkpoint.KeyID, checkpoint.Signature = bc.signerKeys.Sign(checkpointPayload(checkpoint.Height, checkpoint.Hash))
+
+	blockData := map[string]interface{}{
+		"type":              CheckpointBlockType,
+		"checkpoint_height": checkpoint.Height,
+		"checkpoint_hash":   checkpoint.Hash,
+		"authority":         checkpoint.Authority,
+		"kid":               checkpoint.KeyID,
+		"signature":         checkpoint.Signature,
+		"timestamp":         time.Now(),
+	}
+	if err := bc.AddBlock(blockData); err != nil {
+		return nil, err
+	}
+	return cm.Latest(), nil
+}
+
+// Run crea un checkpoint cada vez que la cadena avanza el intervalo de bloques desde el
+// último. Solo hace algo en el nodo autoridad.
+func (cm *CheckpointManager) Run(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !cm.IsAuthority() || cm.blockchain.inMaintenance() {
+			continue
+		}
+		since := 0
+		if latest := cm.Latest(); latest != nil {
+			since = latest.BlockIndex
+		}
+		if cm.blockchain.Len()-1-since < cm.interval {
+			continue
+		}
+		if _, err := cm.Create(); err != nil {
+			logf("❌ Error creando checkpoint: %v\n", err)
+		}
+	}
+}
+
+// parseCheckpoint lee el checkpoint que registra un bloque, original o retransmitido
+func parseCheckpoint(block *Block) (*Checkpoint, error) {
+	entries := eventData(block.Data)
+	if len(entries) != 1 {
+		return nil, errors.New("bloque de checkpoint sin datos")
+	}
+	data := entries[0]
+
+	checkpoint := &Checkpoint{BlockIndex: block.Index, CreatedAt: block.Timestamp}
+	switch height := data["checkpoint_height"].(type) {
+	case int:
+		checkpoint.Height = height
+	case float64:
+		checkpoint.Height = int(height)
+	default:
+		return nil, errors.New("checkpoint sin altura")
+	}
+	checkpoint.Hash, _ = data["checkpoint_hash"].(string)
+	checkpoint.Authority, _ = data["authority"].(string)
+	checkpoint.KeyID, _ = data["kid"].(string)
+	checkpoint.Signature, _ = data["signature"].(string)
+	if checkpoint.Hash == "" || checkpoint.Signature == "" {
+		return nil, errors.New("checkpoint incompleto")
+	}
+	return checkpoint, nil
+}
+
+// verifySignature verifica que el checkpoint lo haya firmado la autoridad designada
+func (cm *CheckpointManager) verifySignature(checkpoint *Checkpoint) error {
+	if checkpoint.Authority != cm.authority.NodeID {
+		return fmt.Errorf("checkpoint firmado por %s, la autoridad es %s", checkpoint.Authority, cm.authority.NodeID)
+	}
+	if err := VerifyWithKeys(cm.authority.PublicKeys, checkpoint.KeyID, checkpointPayload(checkpoint.Height, checkpoint.Hash), checkpoint.Signature); err != nil {
+		return fmt.Errorf("firma del checkpoint inválida: %v", err)
+	}
+	return nil
+}
+
+// verifyBlock verifica la firma de un bloque de checkpoint recibido
+func (cm *CheckpointManager) verifyBlock(block Block) error {
+	checkpoint, err := parseCheckpoint(&block)
+	if err != nil {
+		return err
+	}
+	return cm.verifySignature(checkpoint)
+}
+
+// contains indica si la cadena tiene en la altura del checkpoint el bloque que este fija
+func (checkpoint *Checkpoint) contains(chain []*Block) bool {
+	return checkpoint.Height < len(chain) && originHash(chain[checkpoint.Height]) == checkpoint.Hash
+}
+
+// applyCheckpoint registra el checkpoint de un bloque ya agregado a la cadena si su firma
+// es válida y fija un bloque de la cadena local
+func (bc *Blockchain) applyCheckpoint(block *Block) {
+	if bc.Checkpoints == nil || block.Type != CheckpointBlockType {
+		return
+	}
+	cm := bc.Checkpoints
+	checkpoint, err := parseCheckpoint(block)
+	if err == nil {
+		err = cm.verifySignature(checkpoint)
+	}
+	if err != nil {
+		logf("❌ Checkpoint del bloque %d descartado: %v\n", block.Index, err)
+		return
+	}
+	if !checkpoint.contains(bc.Blocks()) {
+		logf("⚠️ Checkpoint del bloque %d fija un bloque que no está en la cadena local (altura %d)\n", block.Index, checkpoint.Height)
+		return
+	}
+
+	cm.mutex.Lock()
+	cm.latest = checkpoint
+	cm.mutex.Unlock()
+	logf("📌 Checkpoint de %s en la altura %d\n", checkpoint.Authority, checkpoint.Height)
+}
+
+// rebuildCheckpoints recalcula el último checkpoint después de cargar o reemplazar la
+// cadena. Un checkpoint ya conocido que la nueva cadena conserva no se pierde aunque la
+// cadena no traiga su bloque.
+func (bc *Blockchain) rebuildCheckpoints() {
+	if bc.Checkpoints == nil {
+		return
+	}
+	cm := bc.Checkpoints
+	chain, err := bc.FullChain()
+	if err != nil {
+		logf("❌ Error recalculando checkpoints: %v\n", err)
+		return
+	}
+
+	var latest *Checkpoint
+	for _, block := range chain {
+		if block.Type != CheckpointBlockType {
+			continue
+		}
+		checkpoint, err := parseCheckpoint(block)
+		if err != nil || cm.verifySignature(checkpoint) != nil || !checkpoint.contains(chain) {
+			continue
+		}
+		latest = checkpoint
+	}
+
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	if cm.latest != nil && cm.latest.contains(chain) && (latest == nil || latest.Height < cm.latest.Height) {
+		return
+	}
+	cm.latest = latest
+}
+
+// checkCheckpoint verifica que una cadena candidata conserve el último checkpoint, para
+// no reorganizar la historia por debajo de él
+func (bc *Blockchain) checkCheckpoint(chain []*Block) error {
+	if bc.Checkpoints == nil {
+		return nil
+	}
+	latest := bc.Checkpoints.Latest()
+	if latest == nil || latest.contains(chain) {
+		return nil
+	}
+	return fmt.Errorf("la cadena no conserva el checkpoint de la altura %d (%s)", latest.Height, latest.Hash)
+}
+
+// checkpointedHeight retorna la altura del último checkpoint, o -1 si no hay
+func (bc *Blockchain) checkpointedHeight() int {
+	if bc.Checkpoints == nil {
+		return -1
+	}
+	if latest := bc.Checkpoints.Latest(); latest != nil {
+		return latest.Height
+	}
+	return -1
+}
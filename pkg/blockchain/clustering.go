@@ -0,0 +1,289 @@
+package blockchain
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Parámetros por defecto del agrupamiento de contratos por objeto
+const (
+	DefaultClusterSimilarity = 0.5 // Similitud mínima (Jaccard) entre descripciones
+	DefaultClusterWindowDays = 180 // Días máximos entre contratos del mismo grupo
+)
+
+// ClusterOptions configura el agrupamiento de contratos por similitud de su objeto
+type ClusterOptions struct {
+	Similarity float64 `json:"similarity"`
+	WindowDays int     `json:"window_days"`
+	// Thresholds son los montos máximos de las modalidades; sin umbral para una modalidad
+	// se usa el monto máximo de su plantilla de flujo
+	Thresholds map[string]float64 `json:"thresholds,omitempty"`
+}
+
+// ContractCluster es un grupo de contratos con objeto similar, de una o varias entidades.
+// PossibleSplit marca los grupos de una misma modalidad en que cada contrato queda por
+// debajo del umbral de la modalidad pero la suma lo supera (posible fraccionamiento).
+type ContractCluster struct {
+	ID            string    `json:"id"`
+	Terms         []string  `json:"terms"` // Términos comunes a todas las descripciones
+	ContractIDs   []string  `json:"contract_ids"`
+	Entities      []string  `json:"entities"`
+	ContractTypes []string  `json:"contract_types"`
+	TotalAmount   float64   `json:"total_amount"`
+	MaxAmount     float64   `json:"max_amount"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	PossibleSplit bool      `json:"possible_split"`
+	Threshold     float64   `json:"threshold,omitempty"`
+}
+
+// ClusterReport es el resultado de una ejecución del agrupamiento
+type ClusterReport struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Height      int               `json:"height"`
+	Contracts   int               `json:"contracts"`
+	Options     ClusterOptions    `json:"options"`
+	Clusters    []ContractCluster `json:"clusters"`
+	Duration    string            `json:"duration"`
+}
+
+// ContractClusterer agrupa periódicamente los contratos por similitud de su descripción
+// para que los analistas detecten contratos fraccionados
+type ContractClusterer struct {
+	blockchain *Blockchain
+	options    ClusterOptions
+	latest     *ClusterReport
+	mutex      sync.Mutex
+}
+
+// NewContractClusterer crea el agrupador con las opciones dadas, completando las que faltan
+func NewContractClusterer(bc *Blockchain, options ClusterOptions) (*ContractClusterer, error) {
+	if options.Similarity == 0 {
+		options.Similarity = DefaultClusterSimilarity
+	}
+	if options.WindowDays == 0 {
+		options.WindowDays = DefaultClusterWindowDays
+	}
+	if options.Similarity < 0 || options.Similarity > 1 {
+		return nil, errors.New("la similitud debe estar entre 0 y 1")
+	}
+	if options.WindowDays < 0 {
+		return nil, errors.New("la ventana de días debe ser positiva")
+	}
+	return &ContractClusterer{blockchain: bc, options: options}, nil
+}
+
+// clusterMember es un contrato con los términos de su descripción
+type clusterMember struct {
+	contract *Contract
+	terms    map[string]bool
+}
+
+// Cluster agrupa los contratos actuales y guarda el resultado como el último informe
+func (cc *ContractClusterer) Cluster() *ClusterReport {
+	start := time.Now()
+	window := time.Duration(cc.options.WindowDays) * 24 * time.Hour
+	contracts := cc.blockchain.GetAllContracts()
+	sort.Slice(contracts, func(i, j int) bool { return contracts[i].CreatedAt.Before(contracts[j].CreatedAt) })
+
+	members := make([]clusterMember, 0, len(contracts))
+	for _, contract := range contracts {
+		terms := make(map[string]bool)
+		for _, term := range tokenize(contract.Description) {
+			terms[term] = true
+		}
+		if len(terms) > 0 {
+			members = append(members, clusterMember{contract: contract, terms: terms})
+		}
+	}
+
+	// Solo se comparan los contratos que comparten al menos un término
+	byTerm := make(map[string][]int)
+	for i, member := range members {
+		for term := range member.terms {
+			byTerm[term] = append(byTerm[term], i)
+		}
+	}
+
+	parent := make([]int, len(members))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	compared := make(map[[2]int]bool)
+	for _, indexes := range byTerm {
+		for a := 0; a < len(indexes); a++ {
+			for b := a + 1; b < len(indexes); b++ {
+				i, j := indexes[a], indexes[b]
+				if compared[[2]int{i, j}] {
+					continue
+				}
+				compared[[2]int{i, j}] = true
+				if members[j].contract.CreatedAt.Sub(members[i].contract.CreatedAt) > window {
+					continue
+				}
+				if jaccard(members[i].terms, members[j].terms) >= cc.options.Similarity {
+					parent[find(j)] = find(i)
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]clusterMember)
+	var roots []int
+	for i, member := range members {
+		root := find(i)
+		if _, exists := groups[root]; !exists {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], member)
+	}
+
+	clusters := []ContractCluster{}
+	for _, root := range roots {
+		if len(groups[root]) < 2 {
+			continue
+		}
+		clusters = append(clusters, cc.describe(groups[root]))
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if clusters[i].PossibleSplit != clusters[j].PossibleSplit {
+			return clusters[i].PossibleSplit
+		}
+		return clusters[i].TotalAmount > clusters[j].TotalAmount
+	})
+
+	report := &ClusterReport{
+		GeneratedAt: time.Now(),
+		Height:      cc.blockchain.Len() - 1,
+		Contracts:   len(contracts),
+		Options:     cc.options,
+		Clusters:    clusters,
+		Duration:    time.Since(start).String(),
+	}
+
+	cc.mutex.Lock()
+	cc.latest = report
+	cc.mutex.Unlock()
+
+	splits := 0
+	for _, cluster := range clusters {
+		if cluster.PossibleSplit {
+			splits++
+		}
+	}
+	logf("🧩 Agrupamiento de contratos: %d grupos, %d posibles fraccionamientos\n", len(clusters), splits)
+	return report
+}
+
+// describe resume un grupo de contratos y evalúa si puede ser un fraccionamiento
+func (cc *ContractClusterer) describe(members []clusterMember) ContractCluster {
+	first := members[0].contract
+	cluster := ContractCluster{ID: first.ID, From: first.CreatedAt, To: first.CreatedAt}
+
+	common := make(map[string]bool)
+	for term := range members[0].terms {
+		common[term] = true
+	}
+	entities := make(map[string]bool)
+	types := make(map[string]bool)
+	for _, member := range members {
+		contract := member.contract
+		cluster.ContractIDs = append(cluster.ContractIDs, contract.ID)
+		cluster.TotalAmount += contract.Amount
+		if contract.Amount > cluster.MaxAmount {
+			cluster.MaxAmount = contract.Amount
+		}
+		if contract.CreatedAt.Before(cluster.From) {
+			cluster.From = contract.CreatedAt
+		}
+		if contract.CreatedAt.After(cluster.To) {
+			cluster.To = contract.CreatedAt
+		}
+		entities[contract.EntityCode] = true
+		types[contract.ContractType] = true
+		for term := range common {
+			if !member.terms[term] {
+				delete(common, term)
+			}
+		}
+	}
+	cluster.Terms = sortedKeys(common)
+	cluster.Entities = sortedKeys(entities)
+	cluster.ContractTypes = sortedKeys(types)
+
+	if len(cluster.ContractTypes) == 1 {
+		threshold := cc.threshold(cluster.ContractTypes[0])
+		if threshold > 0 && cluster.MaxAmount <= threshold && cluster.TotalAmount > threshold {
+			cluster.PossibleSplit = true
+			cluster.Threshold = threshold
+		}
+	}
+	return cluster
+}
+
+// threshold retorna el monto máximo de la modalidad, configurado o de su plantilla de flujo
+func (cc *ContractClusterer) threshold(contractType string) float64 {
+	if threshold, exists := cc.options.Thresholds[contractType]; exists {
+		return threshold
+	}
+	for _, template := range cc.blockchain.WorkflowManager.Templates() {
+		if template.ContractType == contractType {
+			return template.MaxAmount
+		}
+	}
+	return 0
+}
+
+// Latest retorna el último informe de agrupamiento, o nil si aún no se ha ejecutado
+func (cc *ContractClusterer) Latest() *ClusterReport {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+	return cc.latest
+}
+
+// Run agrupa los contratos periódicamente
+func (cc *ContractClusterer) Run(every time.Duration) {
+	cc.Cluster()
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cc.Cluster()
+	}
+}
+
+// jaccard retorna la similitud entre dos conjuntos de términos
+func jaccard(a, b map[string]bool) float64 {
+	shared := 0
+	for term := range a {
+		if b[term] {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// sortedKeys retorna las llaves del conjunto en orden
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,292 @@
+package blockchain
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Pruebas de concurrencia: ejecutar con go test -race ./pkg/blockchain/
+
+func newTestBlockchain(t *testing.T) *Blockchain {
+	t.Helper()
+	bc, err := NewBlockchain(nil)
+	if err != nil {
+		t.Fatalf("creando la blockchain: %v", err)
+	}
+	return bc
+}
+
+func newTestContract(t *testing.T, bc *Blockchain) *Contract {
+	t.Helper()
+	contract := &Contract{
+		EntityCode:  "123456",
+		EntityName:  "Entidad de prueba",
+		Description: "Contrato de prueba",
+		Amount:      1000000,
+		CreatedBy:   "creador@entidad.gov.co",
+	}
+	if err := bc.AddContract(contract); err != nil {
+		t.Fatalf("creando el contrato: %v", err)
+	}
+	return contract
+}
+
+func newTestKey(t *testing.T) (string, ed25519.PrivateKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generando la llave: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(public), private
+}
+
+func TestConcurrentSuppliers(t *testing.T) {
+	bc := newTestBlockchain(t)
+
+	const workers = 8
+	var wg sync.WaitGroup
+	var registered int32
+	for i := 0; i < workers; i++ {
+		nit := fmt.Sprintf("900%06d", i)
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if bc.RegisterSupplier(&Supplier{NIT: nit, Name: "Proveedor " + nit}) == nil {
+					atomic.AddInt32(&registered, 1)
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bc.GetAllSuppliers()
+		}()
+	}
+	wg.Wait()
+
+	if registered != workers {
+		t.Fatalf("se esperaban %d inscripciones, hubo %d", workers, registered)
+	}
+
+	for _, supplier := range bc.GetAllSuppliers() {
+		wg.Add(2)
+		nit := supplier.NIT
+		go func() {
+			defer wg.Done()
+			if err := bc.AddSanction(nit, "contralor", RoleComptroller, "incumplimiento", nil); err != nil {
+				t.Errorf("sancionando %s: %v", nit, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, supplier := range bc.GetAllSuppliers() {
+				supplier.IsSanctioned(time.Now())
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, supplier := range bc.GetAllSuppliers() {
+		if len(supplier.Sanctions) != 1 {
+			t.Errorf("el proveedor %s tiene %d sanciones", supplier.NIT, len(supplier.Sanctions))
+		}
+	}
+}
+
+func TestConcurrentRegistryKeys(t *testing.T) {
+	bc := newTestBlockchain(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		systemKey, _ := newTestKey(t)
+		validatorKey, _ := newTestKey(t)
+		systemID := fmt.Sprintf("erp-%d", i)
+		go func() {
+			defer wg.Done()
+			if _, err := bc.RegisterSystemKey("123456", systemID, systemKey); err != nil {
+				t.Errorf("registrando %s: %v", systemID, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := bc.RegisterValidatorKey("validador", RoleLegalCommission, validatorKey); err != nil {
+				t.Errorf("registrando la llave del validador: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			bc.GetSystemKeys("123456")
+			bc.GetValidatorKeys("validador")
+			bc.Snapshot(0)
+		}()
+	}
+	wg.Wait()
+
+	if keys := bc.GetSystemKeys("123456"); len(keys) != 8 {
+		t.Errorf("se esperaban 8 llaves de sistema, hay %d", len(keys))
+	}
+	if keys := bc.GetValidatorKeys("validador"); len(keys) != 8 {
+		t.Errorf("se esperaban 8 llaves de validador, hay %d", len(keys))
+	}
+}
+
+func TestConcurrentContracts(t *testing.T) {
+	bc := newTestBlockchain(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			newTestContract(t, bc)
+		}()
+		go func() {
+			defer wg.Done()
+			for _, contract := range bc.GetAllContracts() {
+				_ = contract.Status
+			}
+		}()
+	}
+	wg.Wait()
+
+	if contracts := bc.GetAllContracts(); len(contracts) != 8 {
+		t.Errorf("se esperaban 8 contratos, hay %d", len(contracts))
+	}
+}
+
+func TestConcurrentValidateStep(t *testing.T) {
+	bc := newTestBlockchain(t)
+	contract := newTestContract(t, bc)
+	contract, _ = bc.Contract(contract.ID)
+	step := contract.ValidationSteps[contract.CurrentStep-1]
+
+	// Cada validador declara y firma antes de que empiecen las decisiones concurrentes
+	const validators = 6
+	signatures := make(map[string]StepSignature, validators)
+	for i := 0; i < validators; i++ {
+		validatorID := fmt.Sprintf("validador-%d", i)
+		publicKey, privateKey := newTestKey(t)
+		key, err := bc.RegisterValidatorKey(validatorID, step.Role, publicKey)
+		if err != nil {
+			t.Fatalf("registrando la llave: %v", err)
+		}
+		if _, err := bc.Conflicts.Declare(contract.ID, validatorID, validatorID, step.Role, false, "", ""); err != nil {
+			t.Fatalf("declarando conflictos: %v", err)
+		}
+
+		signedAt := time.Now()
+		payload := StepApprovalPayload(contract.ID, step.StepNumber, true, signedAt, "")
+		signatures[validatorID] = StepSignature{
+			KeyID:     key.KeyID,
+			Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, payload)),
+			SignedAt:  signedAt,
+		}
+	}
+
+	var wg sync.WaitGroup
+	var approved int32
+	for validatorID, signature := range signatures {
+		validatorID, signature := validatorID, signature
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			err := bc.ValidateContractStep(contract.ID, step.StepNumber, validatorID, validatorID, step.Role, true, "aprobado", signature, nil)
+			if err == nil {
+				atomic.AddInt32(&approved, 1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			bc.GetContractWorkflowStatus(contract.ID)
+			bc.ValidateContract(contract.ID, "nodo", true, "")
+		}()
+	}
+	wg.Wait()
+
+	if approved != 1 {
+		t.Fatalf("el paso %d lo aprobaron %d validadores", step.StepNumber, approved)
+	}
+	current, _ := bc.Contract(contract.ID)
+	if current.ValidationSteps[step.StepNumber-1].Status != ValidationApproved || current.CurrentStep != step.StepNumber+1 {
+		t.Errorf("el paso quedó en %s y el contrato en el paso %d", current.ValidationSteps[step.StepNumber-1].Status, current.CurrentStep)
+	}
+}
+
+func TestConcurrentClaimSignature(t *testing.T) {
+	bc := newTestBlockchain(t)
+
+	var wg sync.WaitGroup
+	var claimed int32
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if bc.claimSignature("firma") {
+				atomic.AddInt32(&claimed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if claimed != 1 {
+		t.Errorf("la firma se reservó %d veces", claimed)
+	}
+}
+
+func TestConcurrentSealBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		mempool bool
+	}{
+		{"directo", false},
+		{"mempool", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := newTestBlockchain(t)
+			if tt.mempool {
+				mempool, err := NewMempool(bc, 4, 20*time.Millisecond)
+				if err != nil {
+					t.Fatalf("creando el mempool: %v", err)
+				}
+				bc.Mempool = mempool
+				go mempool.Run()
+			}
+
+			// Cada escritor debe recibir el bloque que contiene sus datos, aunque la punta
+			// ya haya avanzado con los de otro
+			const writers = 12
+			var wg sync.WaitGroup
+			for i := 0; i < writers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					id := fmt.Sprintf("evidencia-%d", i)
+					block, err := bc.SealBlock(map[string]interface{}{"type": "EXECUTION_EVIDENCE", "evidence_id": id})
+					if err != nil {
+						t.Errorf("sellando %s: %v", id, err)
+						return
+					}
+					found := false
+					for _, entry := range eventData(block.Data) {
+						if entry["evidence_id"] == id {
+							found = true
+						}
+					}
+					if !found {
+						t.Errorf("el bloque %s retornado para %s no contiene sus datos", block.Hash, id)
+					}
+				}(i)
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+
+	"github.com/google/uuid"
+)
+
+// ConflictDeclaration es la declaración de conflicto de interés que un validador presenta
+// para un paso de un contrato antes de decidirlo. En la cadena se ancla la huella de la
+// declaración; el texto completo solo lo consultan los entes de control.
+type ConflictDeclaration struct {
+	ID            string    `json:"id"`
+	ContractID    string    `json:"contract_id"`
+	Step          int       `json:"step"`
+	ValidatorID   string    `json:"validator_id"`
+	ValidatorName string    `json:"validator_name"`
+	Role          AdminRole `json:"role"`
+	HasConflict   bool      `json:"has_conflict"`
+	Statement     string    `json:"statement,omitempty"`
+	AlternateID   string    `json:"alternate_id,omitempty"` // Validador al que se remite el paso si hay conflicto
+	Digest        string    `json:"digest"`
+	DeclaredAt    time.Time `json:"declared_at"`
+	BlockHash     string    `json:"block_hash"`
+}
+
+// ConflictRegistry guarda las declaraciones de conflicto de interés de los validadores
+type ConflictRegistry struct {
+	blockchain   *Blockchain
+	declarations map[string]*ConflictDeclaration
+	mutex        sync.RWMutex
+}
+
+// newConflictRegistry carga las declaraciones guardadas
+func newConflictRegistry(bc *Blockchain) *ConflictRegistry {
+	cr := &ConflictRegistry{
+		blockchain:   bc,
+		declarations: make(map[string]*ConflictDeclaration),
+	}
+	bc.store.ForEach(storage.BucketConflictDeclarations, func(key string, value []byte) error {
+		var declaration ConflictDeclaration
+		if err := json.Unmarshal(value, &declaration); err == nil {
+			cr.declarations[key] = &declaration
+		}
+		return nil
+	})
+	return cr
+}
+
+// Declare registra la declaración del validador para el paso actual del contrato y la
+// ancla en la cadena. Si declara conflicto, queda impedido para ese paso y se libera la
+// toma que tuviera para que lo decida otro validador del comité.
+func (cr *ConflictRegistry) Declare(contractID string, validatorID string, validatorName string, role AdminRole, hasConflict bool, statement string, alternateID string) (*ConflictDeclaration, error) {
+	unlock := cr.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := cr.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Status == StatusRejected || contract.CurrentStep < 1 || contract.CurrentStep > len(contract.ValidationSteps) {
+		return nil, errors.New("el contrato no tiene pasos pendientes")
+	}
+	step := contract.ValidationSteps[contract.CurrentStep-1]
+	if step.Role != role || step.Status != ValidationPending {
+		return nil, fmt.Errorf("el paso %d no está pendiente para el rol %s", step.StepNumber, role)
+	}
+	if hasConflict && statement == "" {
+		return nil, errors.New("la declaración de conflicto debe describirlo")
+	}
+	if alternateID != "" && (!hasConflict || alternateID == validatorID) {
+		return nil, errors.New("solo se remite a otro validador el paso con conflicto declarado")
+	}
+	if cr.Find(contractID, step.StepNumber, validatorID) != nil {
+		return nil, fmt.Errorf("ya hay una declaración de %s para el paso %d", validatorID, step.StepNumber)
+	}
+
+	declaration := &ConflictDeclaration{
+		ID:            uuid.New().String(),
+		ContractID:    contractID,
+		Step:          step.StepNumber,
+		ValidatorID:   validatorID,
+		ValidatorName: validatorName,
+		Role:          role,
+		HasConflict:   hasConflict,
+		Statement:     statement,
+		AlternateID:   alternateID,
+		DeclaredAt:    time.Now(),
+	}
+	declaration.Digest = declaration.digest()
+
+	blockData := map[string]interface{}{
+		"type":           "CONFLICT_DECLARATION",
+		"contract_id":    contractID,
+		"declaration_id": declaration.ID,
+		"step":           declaration.Step,
+		"validator":      validatorID,
+		"validator_name": validatorName,
+		"role":           string(role),
+		"has_conflict":   hasConflict,
+		"digest":         declaration.Digest,
+		"timestamp":      declaration.DeclaredAt,
+	}
+
+	if hasConflict {
+		cr.blockchain.WorkflowManager.addAuditEntry(contract, "CONFLICT_DECLARED", validatorID, role,
+			fmt.Sprintf("Paso %d: %s declaró conflicto de interés y queda impedido", step.StepNumber, validatorName))
+		if contract.Claim != nil && contract.Claim.ReviewerID == validatorID {
+			contract.Claim = nil
+		}
+	} else {
+		cr.blockchain.WorkflowManager.addAuditEntry(contract, "NO_CONFLICT_DECLARED", validatorID, role,
+			fmt.Sprintf("Paso %d: %s declaró no tener conflicto de interés", step.StepNumber, validatorName))
+	}
+	contract.UpdatedAt = declaration.DeclaredAt
+
+	cr.blockchain.stageContract(contract)
+	block, err := cr.blockchain.SealBlock(blockData)
+	if err != nil {
+		cr.blockchain.unstageContract(contractID)
+		return nil, err
+	}
+	declaration.BlockHash = block.Hash
+
+	cr.mutex.Lock()
+	cr.declarations[declaration.ID] = declaration
+	cr.mutex.Unlock()
+	cr.blockchain.saveState(storage.BucketConflictDeclarations, declaration.ID, declaration)
+
+	if hasConflict {
+		logf("⚖️ %s declaró conflicto de interés en el paso %d del contrato %s\n", validatorID, step.StepNumber, contractID)
+	}
+
+	copied := *declaration
+	return &copied, nil
+}
+
+// Find retorna la declaración del validador para el paso del contrato, o nil si no la presentó
+func (cr *ConflictRegistry) Find(contractID string, step int, validatorID string) *ConflictDeclaration {
+	cr.mutex.RLock()
+	defer cr.mutex.RUnlock()
+
+	for _, declaration := range cr.declarations {
+		if declaration.ContractID == contractID && declaration.Step == step && declaration.ValidatorID == validatorID {
+			copied := *declaration
+			return &copied
+		}
+	}
+	return nil
+}
+
+// IsRecused indica si el validador declaró conflicto de interés en el paso del contrato
+func (cr *ConflictRegistry) IsRecused(contractID string, step int, validatorID string) bool {
+	declaration := cr.Find(contractID, step, validatorID)
+	return declaration != nil && declaration.HasConflict
+}
+
+// List retorna las declaraciones de un contrato en el orden en que se presentaron
+func (cr *ConflictRegistry) List(contractID string) []ConflictDeclaration {
+	cr.mutex.RLock()
+	defer cr.mutex.RUnlock()
+
+	declarations := []ConflictDeclaration{}
+	for _, declaration := range cr.declarations {
+		if declaration.ContractID == contractID {
+			declarations = append(declarations, *declaration)
+		}
+	}
+	sort.Slice(declarations, func(i, j int) bool { return declarations[i].DeclaredAt.Before(declarations[j].DeclaredAt) })
+	return declarations
+}
+
+// checkDeclaration exige que el validador haya declarado no tener conflicto de interés
+// en el paso antes de decidirlo
+func (cr *ConflictRegistry) checkDeclaration(contractID string, step int, validatorID string) error {
+	declaration := cr.Find(contractID, step, validatorID)
+	if declaration == nil {
+		return fmt.Errorf("debe presentar la declaración de conflicto de interés del paso %d antes de decidirlo", step)
+	}
+	if declaration.HasConflict {
+		return fmt.Errorf("declaró conflicto de interés en el paso %d; debe decidirlo otro validador", step)
+	}
+	return nil
+}
+
+// digest calcula la huella anclada en la cadena, con la que los entes de control
+// verifican que la declaración no cambió después de presentarse
+func (d *ConflictDeclaration) digest() string {
+	record, _ := json.Marshal(map[string]interface{}{
+		"contract_id":  d.ContractID,
+		"step":         d.Step,
+		"validator_id": d.ValidatorID,
+		"has_conflict": d.HasConflict,
+		"statement":    d.Statement,
+		"alternate_id": d.AlternateID,
+		"declared_at":  d.DeclaredAt.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(record)
+	return hex.EncodeToString(sum[:])
+}
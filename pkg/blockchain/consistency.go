@@ -0,0 +1,130 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Niveles de consistencia para las consultas de lectura
+const (
+	ConsistencyLocal  = "local"
+	ConsistencyQuorum = "quorum"
+)
+
+// ChainTip representa la punta de la cadena de un nodo
+type ChainTip struct {
+	NodeID string `json:"node_id"`
+	Height int    `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+// QuorumResult representa el resultado de contrastar la punta local con los peers
+type QuorumResult struct {
+	Local     ChainTip   `json:"local"`
+	Agreeing  int        `json:"agreeing"`
+	Total     int        `json:"total"`
+	Reached   bool       `json:"reached"`
+	Behind    bool       `json:"behind"`
+	PeerTips  []ChainTip `json:"peer_tips"`
+	Unreached []string   `json:"unreached,omitempty"`
+}
+
+// LocalTip retorna la punta de la cadena de este nodo
+func (p2p *P2PNetwork) LocalTip() ChainTip {
+	return ChainTip{
+		NodeID: p2p.NodeID,
+		Height: ByzantineReportedHeight(p2p.Blockchain.Len()),
+		Hash:   p2p.Blockchain.TipHash(),
+	}
+}
+
+// CheckQuorum consulta en paralelo la punta de los peers activos y verifica que
+// la mayoría de los nodos (incluido este) coincida con la punta local
+func (p2p *P2PNetwork) CheckQuorum() QuorumResult {
+	local := p2p.LocalTip()
+
+	p2p.mutex.RLock()
+	var peers []*Peer
+	for _, peer := range p2p.Peers {
+		if peer.Active && !peer.Maintenance {
+			peers = append(peers, peer)
+		}
+	}
+	p2p.mutex.RUnlock()
+
+	result := QuorumResult{Local: local, Agreeing: 1, Total: len(peers) + 1}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer *Peer) {
+			defer wg.Done()
+			tip, err := p2p.requestTip(peer)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				result.Unreached = append(result.Unreached, peer.ID)
+				return
+			}
+			result.PeerTips = append(result.PeerTips, *tip)
+			if tip.Height == local.Height && tip.Hash == local.Hash {
+				result.Agreeing++
+			} else if tip.Height > local.Height {
+				result.Behind = true
+			}
+		}(peer)
+	}
+	wg.Wait()
+
+	result.Reached = result.Agreeing*2 > result.Total
+	return result
+}
+
+// EnsureQuorum verifica el quórum y, si este nodo está atrasado, sincroniza
+// una vez con los peers antes de volver a verificar
+func (p2p *P2PNetwork) EnsureQuorum() (QuorumResult, error) {
+	result := p2p.CheckQuorum()
+	if result.Reached {
+		return result, nil
+	}
+
+	if result.Behind {
+		logf("🔄 Nodo atrasado frente al quórum, sincronizando antes de responder\n")
+		if err := p2p.SyncWithPeers(); err == nil {
+			result = p2p.CheckQuorum()
+			if result.Reached {
+				return result, nil
+			}
+		}
+	}
+
+	return result, errors.New("no se alcanzó quórum sobre la punta de la cadena")
+}
+
+// requestTip solicita la punta de la cadena a un peer
+func (p2p *P2PNetwork) requestTip(peer *Peer) (*ChainTip, error) {
+	url := p2p.peerURL(peer, "/api/p2p/tip")
+
+	client := p2p.peerClient(3 * time.Second)
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer respondió con status %d", resp.StatusCode)
+	}
+
+	var tip ChainTip
+	if err := json.NewDecoder(resp.Body).Decode(&tip); err != nil {
+		return nil, err
+	}
+	return &tip, nil
+}
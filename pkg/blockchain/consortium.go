@@ -0,0 +1,175 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ConsortiumMember representa un integrante de un consorcio o unión temporal
+type ConsortiumMember struct {
+	NIT           string  `json:"nit"`
+	Participation float64 `json:"participation"` // Porcentaje de participación
+}
+
+// Consortium representa un proponente plural (consorcio o unión temporal)
+type Consortium struct {
+	Name    string             `json:"name"`
+	Type    string             `json:"type"` // CONSORCIO o UNION_TEMPORAL
+	Members []ConsortiumMember `json:"members"`
+}
+
+// Sanction representa una sanción o inhabilidad vigente de un proveedor
+type Sanction struct {
+	Reason     string     `json:"reason"`
+	IssuedBy   string     `json:"issued_by"`
+	IssuedAt   time.Time  `json:"issued_at"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+}
+
+// SupplierHistoryEntry representa la participación de un proveedor en un contrato
+type SupplierHistoryEntry struct {
+	ContractID    string         `json:"contract_id"`
+	EntityName    string         `json:"entity_name"`
+	Description   string         `json:"description"`
+	Amount        float64        `json:"amount"`
+	Status        ContractStatus `json:"status"`
+	Consortium    string         `json:"consortium,omitempty"`
+	Participation float64        `json:"participation"`
+}
+
+// IsSanctioned indica si el proveedor tiene sanciones vigentes a la fecha dada
+func (s *Supplier) IsSanctioned(at time.Time) bool {
+	for _, sanction := range s.Sanctions {
+		if sanction.ValidUntil == nil || at.Before(*sanction.ValidUntil) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddSanction registra una sanción o inhabilidad a un proveedor (solo entes de control).
+// La sanción se aplica sobre una copia del proveedor que reemplaza a la vigente cuando el
+// bloque entra a la cadena.
+func (bc *Blockchain) AddSanction(nit string, issuedBy string, role AdminRole, reason string, validUntil *time.Time) error {
+	if role != RoleComptroller && role != RoleProsecutor {
+		return errors.New("rol no autorizado para registrar sanciones")
+	}
+	if reason == "" {
+		return errors.New("razón de la sanción requerida")
+	}
+
+	bc.registryMutex.Lock()
+	defer bc.registryMutex.Unlock()
+	current, err := bc.GetSupplier(nit)
+	if err != nil {
+		return err
+	}
+	supplier := *current
+
+	sanction := Sanction{
+		Reason:     reason,
+		IssuedBy:   issuedBy,
+		IssuedAt:   time.Now(),
+		ValidUntil: validUntil,
+	}
+	supplier.Sanctions = append(append([]Sanction{}, current.Sanctions...), sanction)
+
+	blockData := map[string]interface{}{
+		"type":        "SUPPLIER_SANCTION",
+		"nit":         nit,
+		"issued_by":   issuedBy,
+		"role":        string(role),
+		"reason":      reason,
+		"valid_until": validUntil,
+		"timestamp":   sanction.IssuedAt,
+	}
+
+	if err := bc.AddBlock(blockData); err != nil {
+		return err
+	}
+	bc.putSupplier(&supplier)
+	return nil
+}
+
+// validateConsortium verifica que los integrantes estén habilitados y que la participación sume 100%
+func (bc *Blockchain) validateConsortium(consortium *Consortium) error {
+	if consortium.Name == "" {
+		return errors.New("nombre del consorcio requerido")
+	}
+	if consortium.Type != "CONSORCIO" && consortium.Type != "UNION_TEMPORAL" {
+		return errors.New("tipo de proponente plural inválido, use CONSORCIO o UNION_TEMPORAL")
+	}
+	if len(consortium.Members) < 2 {
+		return errors.New("el consorcio debe tener al menos dos integrantes")
+	}
+
+	total := 0.0
+	seen := make(map[string]bool)
+	for _, member := range consortium.Members {
+		if seen[member.NIT] {
+			return fmt.Errorf("integrante %s duplicado", member.NIT)
+		}
+		seen[member.NIT] = true
+
+		if member.Participation <= 0 {
+			return fmt.Errorf("participación inválida para %s", member.NIT)
+		}
+		total += member.Participation
+
+		if err := bc.checkSupplierEligible(member.NIT); err != nil {
+			return err
+		}
+	}
+
+	if math.Abs(total-100) > 0.001 {
+		return fmt.Errorf("la participación de los integrantes suma %.2f%%, debe ser 100%%", total)
+	}
+	return nil
+}
+
+// checkSupplierEligible verifica que un proveedor esté registrado y sin inhabilidades vigentes
+func (bc *Blockchain) checkSupplierEligible(nit string) error {
+	supplier, err := bc.GetSupplier(nit)
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, nit)
+	}
+	if supplier.IsSanctioned(time.Now()) {
+		return fmt.Errorf("el proveedor %s tiene sanciones o inhabilidades vigentes", nit)
+	}
+	return nil
+}
+
+// GetSupplierHistory retorna los contratos adjudicados a un proveedor, directamente o como integrante de consorcios
+func (bc *Blockchain) GetSupplierHistory(nit string) ([]SupplierHistoryEntry, error) {
+	if _, err := bc.GetSupplier(nit); err != nil {
+		return nil, err
+	}
+
+	var history []SupplierHistoryEntry
+	for _, contract := range bc.contractMap() {
+		entry := SupplierHistoryEntry{
+			ContractID:  contract.ID,
+			EntityName:  contract.EntityName,
+			Description: contract.Description,
+			Amount:      contract.Amount,
+			Status:      contract.Status,
+		}
+
+		if contract.Consortium != nil {
+			for _, member := range contract.Consortium.Members {
+				if member.NIT == nit {
+					entry.Consortium = contract.Consortium.Name
+					entry.Participation = member.Participation
+					history = append(history, entry)
+					break
+				}
+			}
+		} else if contract.AwardedTo == nit {
+			entry.Participation = 100
+			history = append(history, entry)
+		}
+	}
+	return history, nil
+}
@@ -0,0 +1,406 @@
+package blockchain
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// Límites del índice de contenido
+const (
+	MaxIndexedText   = 1 << 20 // Texto máximo indexado por documento (1 MB)
+	SnippetRadius    = 80      // Caracteres de contexto a cada lado del término en los fragmentos
+	minIndexedLength = 3       // Los términos más cortos no se indexan
+)
+
+// stopWords son palabras demasiado comunes en los documentos del proceso para buscar por ellas
+var stopWords = map[string]bool{
+	"las": true, "los": true, "del": true, "por": true, "para": true, "con": true, "una": true,
+	"que": true, "sus": true, "son": true, "como": true, "este": true, "esta": true, "sobre": true,
+	"entre": true, "cual": true, "cuando": true, "donde": true, "the": true, "and": true,
+}
+
+// TextExtractor extrae el texto de un tipo de documento para indexarlo
+type TextExtractor interface {
+	// Supports indica si el extractor procesa el tipo de contenido
+	Supports(mediaType string) bool
+	// Extract retorna el texto del documento
+	Extract(content []byte) (string, error)
+}
+
+// IndexedDocument es el texto extraído de un adjunto, guardado solo en este nodo
+type IndexedDocument struct {
+	AttachmentID string    `json:"attachment_id"`
+	ContractID   string    `json:"contract_id"`
+	Text         string    `json:"text"`
+	Extractor    string    `json:"extractor"`
+	IndexedAt    time.Time `json:"indexed_at"`
+}
+
+// ContentMatch es un adjunto cuyo contenido coincide con una búsqueda
+type ContentMatch struct {
+	AttachmentID string `json:"attachment_id"`
+	ContractID   string `json:"contract_id"`
+	Score        int    `json:"score"`
+	Snippet      string `json:"snippet"`
+}
+
+// ContentIndex es un índice invertido del texto de los adjuntos. El texto extraído se
+// guarda en el almacenamiento del nodo y el índice se reconstruye en memoria al iniciar;
+// no se replica ni se ancla en la cadena.
+type ContentIndex struct {
+	store      storage.Store
+	extractors []TextExtractor
+	documents  map[string]*IndexedDocument
+	terms      map[string]map[string]int // término -> adjunto -> ocurrencias
+	mutex      sync.RWMutex
+}
+
+// newContentIndex carga los textos guardados con los extractores incluidos por defecto
+func newContentIndex(store storage.Store) *ContentIndex {
+	ci := &ContentIndex{
+		store:      store,
+		extractors: []TextExtractor{plainTextExtractor{}, pdfTextExtractor{}},
+		documents:  make(map[string]*IndexedDocument),
+		terms:      make(map[string]map[string]int),
+	}
+	store.ForEach(storage.BucketContentIndex, func(key string, value []byte) error {
+		var document IndexedDocument
+		if err := json.Unmarshal(value, &document); err == nil {
+			ci.add(&document)
+		}
+		return nil
+	})
+	return ci
+}
+
+// RegisterExtractor agrega un extractor con prioridad sobre los ya registrados
+func (ci *ContentIndex) RegisterExtractor(extractor TextExtractor) {
+	ci.mutex.Lock()
+	defer ci.mutex.Unlock()
+	ci.extractors = append([]TextExtractor{extractor}, ci.extractors...)
+}
+
+// Index extrae el texto del adjunto y lo agrega al índice. Los tipos sin extractor
+// se omiten sin error.
+func (ci *ContentIndex) Index(attachment *Attachment, content []byte) error {
+	ci.mutex.RLock()
+	var extractor TextExtractor
+	for _, candidate := range ci.extractors {
+		if candidate.Supports(attachment.MediaType) {
+			extractor = candidate
+			break
+		}
+	}
+	ci.mutex.RUnlock()
+	if extractor == nil {
+		return nil
+	}
+
+	text, err := extractor.Extract(content)
+	if err != nil {
+		return fmt.Errorf("error extrayendo texto de %s: %v", attachment.FileName, err)
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) > MaxIndexedText {
+		text = strings.ToValidUTF8(text[:MaxIndexedText], "")
+	}
+
+	document := &IndexedDocument{
+		AttachmentID: attachment.ID,
+		ContractID:   attachment.ContractID,
+		Text:         text,
+		Extractor:    fmt.Sprintf("%T", extractor),
+		IndexedAt:    time.Now(),
+	}
+	data, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	if err := ci.store.Put(storage.BucketContentIndex, attachment.ID, data); err != nil {
+		return err
+	}
+
+	ci.mutex.Lock()
+	ci.remove(attachment.ID)
+	ci.add(document)
+	ci.mutex.Unlock()
+	return nil
+}
+
+// Remove saca un adjunto del índice y borra su texto del almacenamiento
+func (ci *ContentIndex) Remove(attachmentID string) error {
+	ci.mutex.Lock()
+	ci.remove(attachmentID)
+	ci.mutex.Unlock()
+	return ci.store.Delete(storage.BucketContentIndex, attachmentID)
+}
+
+// IsIndexed indica si el adjunto tiene texto en el índice
+func (ci *ContentIndex) IsIndexed(attachmentID string) bool {
+	ci.mutex.RLock()
+	defer ci.mutex.RUnlock()
+	_, exists := ci.documents[attachmentID]
+	return exists
+}
+
+// Search retorna los adjuntos que contienen todos los términos de la búsqueda, los de
+// más ocurrencias primero
+func (ci *ContentIndex) Search(query string) []ContentMatch {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return []ContentMatch{}
+	}
+
+	ci.mutex.RLock()
+	defer ci.mutex.RUnlock()
+
+	scores := make(map[string]int)
+	for i, term := range terms {
+		postings := ci.terms[term]
+		for attachmentID, count := range postings {
+			if i == 0 {
+				scores[attachmentID] = count
+			} else if _, matched := scores[attachmentID]; matched {
+				scores[attachmentID] += count
+			}
+		}
+		for attachmentID := range scores {
+			if _, found := postings[attachmentID]; !found {
+				delete(scores, attachmentID)
+			}
+		}
+	}
+
+	matches := make([]ContentMatch, 0, len(scores))
+	for attachmentID, score := range scores {
+		document := ci.documents[attachmentID]
+		matches = append(matches, ContentMatch{
+			AttachmentID: attachmentID,
+			ContractID:   document.ContractID,
+			Score:        score,
+			Snippet:      snippet(document.Text, terms[0]),
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].AttachmentID < matches[j].AttachmentID
+	})
+	return matches
+}
+
+// add agrega un documento al índice en memoria
+func (ci *ContentIndex) add(document *IndexedDocument) {
+	ci.documents[document.AttachmentID] = document
+	for _, term := range tokenize(document.Text) {
+		postings, exists := ci.terms[term]
+		if !exists {
+			postings = make(map[string]int)
+			ci.terms[term] = postings
+		}
+		postings[document.AttachmentID]++
+	}
+}
+
+// remove saca un documento del índice en memoria
+func (ci *ContentIndex) remove(attachmentID string) {
+	document, exists := ci.documents[attachmentID]
+	if !exists {
+		return
+	}
+	for _, term := range tokenize(document.Text) {
+		delete(ci.terms[term], attachmentID)
+		if len(ci.terms[term]) == 0 {
+			delete(ci.terms, term)
+		}
+	}
+	delete(ci.documents, attachmentID)
+}
+
+// MatchesText indica si los campos contienen todos los términos de la búsqueda
+func MatchesText(query string, fields ...string) bool {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return false
+	}
+	present := make(map[string]bool)
+	for _, field := range fields {
+		for _, term := range tokenize(field) {
+			present[term] = true
+		}
+	}
+	for _, term := range terms {
+		if !present[term] {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenize normaliza el texto (minúsculas, sin tildes) y lo divide en términos indexables
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(foldText(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if len([]rune(field)) < minIndexedLength || stopWords[field] {
+			continue
+		}
+		terms = append(terms, field)
+	}
+	return terms
+}
+
+// accents quita las tildes para que "licitación" y "licitacion" coincidan
+var accents = map[rune]rune{'á': 'a', 'é': 'e', 'í': 'i', 'ó': 'o', 'ú': 'u', 'ü': 'u', 'ñ': 'n'}
+
+// foldText pasa el texto a minúsculas sin tildes, conservando una runa por runa
+func foldText(text string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if folded, ok := accents[r]; ok {
+			return folded
+		}
+		return r
+	}, text)
+}
+
+// snippet retorna el fragmento del texto alrededor de la primera aparición del término
+func snippet(text string, term string) string {
+	original := []rune(text)
+	folded := []rune(foldText(text))
+	position := 0
+	if index := strings.Index(string(folded), term); index >= 0 {
+		position = len([]rune(string(folded)[:index]))
+	}
+
+	start := position - SnippetRadius
+	if start < 0 {
+		start = 0
+	}
+	end := position + len([]rune(term)) + SnippetRadius
+	if end > len(original) {
+		end = len(original)
+	}
+	fragment := string(original[start:end])
+	if start > 0 {
+		fragment = "…" + fragment
+	}
+	if end < len(original) {
+		fragment += "…"
+	}
+	return fragment
+}
+
+// plainTextExtractor indexa los documentos de texto tal como están
+type plainTextExtractor struct{}
+
+func (plainTextExtractor) Supports(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") || strings.HasPrefix(mediaType, "application/json") ||
+		strings.HasPrefix(mediaType, "application/xml")
+}
+
+func (plainTextExtractor) Extract(content []byte) (string, error) {
+	return string(content), nil
+}
+
+// Operadores de texto de los flujos de contenido PDF: (texto) Tj y [(texto) n (texto)] TJ
+var (
+	pdfStream    = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+	pdfShowText  = regexp.MustCompile(`(?s)\((.*?[^\\])\)\s*Tj|\[(.*?)\]\s*TJ`)
+	pdfTextChunk = regexp.MustCompile(`(?s)\((.*?[^\\])\)`)
+)
+
+// pdfTextExtractor extrae el texto de los operadores Tj/TJ de los PDF generados con
+// fuentes estándar. No interpreta fuentes con codificaciones propias; para esos
+// documentos se registra un extractor externo (PDF_TEXT_COMMAND).
+type pdfTextExtractor struct{}
+
+func (pdfTextExtractor) Supports(mediaType string) bool {
+	return mediaType == "application/pdf"
+}
+
+func (pdfTextExtractor) Extract(content []byte) (string, error) {
+	if !bytes.HasPrefix(content, []byte("%PDF")) {
+		return "", errors.New("el archivo no es un PDF")
+	}
+
+	var text strings.Builder
+	for _, stream := range pdfStream.FindAllSubmatch(content, -1) {
+		data := stream[1]
+		if reader, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+			if inflated, err := io.ReadAll(reader); err == nil {
+				data = inflated
+			}
+			reader.Close()
+		}
+		for _, operator := range pdfShowText.FindAllSubmatch(data, -1) {
+			if operator[1] != nil {
+				text.WriteString(unescapePDF(operator[1]))
+			} else {
+				for _, chunk := range pdfTextChunk.FindAllSubmatch(operator[2], -1) {
+					text.WriteString(unescapePDF(chunk[1]))
+				}
+			}
+			text.WriteByte(' ')
+		}
+	}
+	return text.String(), nil
+}
+
+// unescapePDF interpreta los escapes de las cadenas literales de PDF
+func unescapePDF(literal []byte) string {
+	replacer := strings.NewReplacer(`\(`, "(", `\)`, ")", `\\`, `\`, `\n`, " ", `\r`, " ", `\t`, " ")
+	return replacer.Replace(string(literal))
+}
+
+// CommandExtractor delega la extracción en un programa externo (p. ej. pdftotext) que
+// recibe el documento por la entrada estándar y escribe el texto en la salida estándar
+type CommandExtractor struct {
+	MediaType string
+	Command   []string
+	Timeout   time.Duration
+}
+
+// Supports indica si el extractor procesa el tipo de contenido configurado
+func (ce CommandExtractor) Supports(mediaType string) bool {
+	return mediaType == ce.MediaType
+}
+
+// Extract ejecuta el programa con el documento y retorna su salida
+func (ce CommandExtractor) Extract(content []byte) (string, error) {
+	if len(ce.Command) == 0 {
+		return "", errors.New("extractor sin comando configurado")
+	}
+	timeout := ce.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, ce.Command[0], ce.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(content)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %v", ce.Command[0], err)
+	}
+	return output.String(), nil
+}
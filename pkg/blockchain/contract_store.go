@@ -0,0 +1,349 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Órdenes de una consulta de contratos
+const (
+	ContractSortCreatedAt = "created_at"
+	ContractSortAmount    = "amount"
+)
+
+// ContractQuery representa los filtros, el orden y la página de una consulta de contratos.
+// Sin orden se retornan del más reciente al más antiguo.
+type ContractQuery struct {
+	EntityCode   string
+	ContractType string
+	Status       ContractStatus
+	From         *time.Time
+	To           *time.Time
+	MinAmount    *float64
+	MaxAmount    *float64
+	Sort         string // ContractSortCreatedAt o ContractSortAmount
+	Ascending    bool
+	Limit        int
+	Offset       int
+}
+
+// Validate verifica que el orden pedido exista
+func (q ContractQuery) Validate() error {
+	if q.Sort != "" && q.Sort != ContractSortCreatedAt && q.Sort != ContractSortAmount {
+		return fmt.Errorf("orden inválido: %s (se admite %s o %s)", q.Sort, ContractSortCreatedAt, ContractSortAmount)
+	}
+	return nil
+}
+
+// Matches indica si un contrato cumple los filtros de la consulta
+func (q ContractQuery) Matches(contract *Contract) bool {
+	if q.EntityCode != "" && contract.EntityCode != q.EntityCode {
+		return false
+	}
+	if q.ContractType != "" && contract.ContractType != q.ContractType {
+		return false
+	}
+	if q.Status != "" && contract.Status != q.Status {
+		return false
+	}
+	if q.From != nil && contract.CreatedAt.Before(*q.From) {
+		return false
+	}
+	if q.To != nil && contract.CreatedAt.After(*q.To) {
+		return false
+	}
+	if q.MinAmount != nil && contract.Amount < *q.MinAmount {
+		return false
+	}
+	if q.MaxAmount != nil && contract.Amount > *q.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// less indica si a va antes que b en el orden de la consulta; los empates se resuelven
+// por ID para que las páginas sean estables
+func (q ContractQuery) less(a, b *Contract) bool {
+	var before, after bool
+	if q.Sort == ContractSortAmount {
+		before, after = a.Amount < b.Amount, a.Amount > b.Amount
+	} else {
+		before, after = a.CreatedAt.Before(b.CreatedAt), a.CreatedAt.After(b.CreatedAt)
+	}
+	if !before && !after {
+		return a.ID < b.ID
+	}
+	if q.Ascending {
+		return before
+	}
+	return after
+}
+
+// ContractStore guarda el estado consultable de los contratos. La cadena sigue siendo
+// la fuente de verdad; el store solo sirve las consultas de lectura con filtros.
+type ContractStore interface {
+	Save(contract *Contract) error
+	Query(query ContractQuery) ([]*Contract, error)
+	// Count retorna cuántos contratos cumplen los filtros, sin tener en cuenta la página
+	Count(query ContractQuery) (int, error)
+	Close() error
+}
+
+// indexedContract son los valores con que un contrato quedó indexado
+type indexedContract struct {
+	id           string
+	entityCode   string
+	contractType string
+	status       ContractStatus
+	amount       float64
+	createdAt    time.Time
+}
+
+// memoryContractStore resuelve las consultas con índices en memoria: conjuntos por
+// entidad, modalidad y estado, y listas ordenadas por fecha de creación y por monto para
+// los rangos. Los índices solo acotan los candidatos; cada uno se vuelve a leer del
+// estado y a comparar con los filtros, así un índice atrasado no entrega contratos que
+// ya no los cumplen ni que dejaron de existir.
+type memoryContractStore struct {
+	lookup     func(contractID string) (*Contract, bool)
+	indexed    map[string]indexedContract
+	byEntity   map[string]map[string]bool
+	byType     map[string]map[string]bool
+	byStatus   map[string]map[string]bool
+	byCreation []indexedContract // Ordenados por createdAt e ID
+	byAmount   []indexedContract // Ordenados por amount e ID
+	mutex      sync.RWMutex
+}
+
+func newMemoryContractStore(lookup func(contractID string) (*Contract, bool)) *memoryContractStore {
+	return &memoryContractStore{
+		lookup:   lookup,
+		indexed:  make(map[string]indexedContract),
+		byEntity: make(map[string]map[string]bool),
+		byType:   make(map[string]map[string]bool),
+		byStatus: make(map[string]map[string]bool),
+	}
+}
+
+func byCreationLess(a, b indexedContract) bool {
+	if !a.createdAt.Equal(b.createdAt) {
+		return a.createdAt.Before(b.createdAt)
+	}
+	return a.id < b.id
+}
+
+func byAmountLess(a, b indexedContract) bool {
+	if a.amount != b.amount {
+		return a.amount < b.amount
+	}
+	return a.id < b.id
+}
+
+// Save reindexa el contrato si cambió alguno de los valores indexados
+func (ms *memoryContractStore) Save(contract *Contract) error {
+	entry := indexedContract{
+		id:           contract.ID,
+		entityCode:   contract.EntityCode,
+		contractType: contract.ContractType,
+		status:       contract.Status,
+		amount:       contract.Amount,
+		createdAt:    contract.CreatedAt,
+	}
+
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	if previous, exists := ms.indexed[contract.ID]; exists {
+		if previous == entry {
+			return nil
+		}
+		ms.remove(previous)
+	}
+	ms.indexed[entry.id] = entry
+	addToSet(ms.byEntity, entry.entityCode, entry.id)
+	addToSet(ms.byType, entry.contractType, entry.id)
+	addToSet(ms.byStatus, string(entry.status), entry.id)
+	ms.byCreation = insertSorted(ms.byCreation, entry, byCreationLess)
+	ms.byAmount = insertSorted(ms.byAmount, entry, byAmountLess)
+	return nil
+}
+
+// remove quita el contrato de todos los índices; requiere el lock
+func (ms *memoryContractStore) remove(entry indexedContract) {
+	delete(ms.indexed, entry.id)
+	removeFromSet(ms.byEntity, entry.entityCode, entry.id)
+	removeFromSet(ms.byType, entry.contractType, entry.id)
+	removeFromSet(ms.byStatus, string(entry.status), entry.id)
+	ms.byCreation = removeSorted(ms.byCreation, entry, byCreationLess)
+	ms.byAmount = removeSorted(ms.byAmount, entry, byAmountLess)
+}
+
+func (ms *memoryContractStore) Query(query ContractQuery) ([]*Contract, error) {
+	result := ms.matching(query)
+	if query.Offset > 0 {
+		if query.Offset >= len(result) {
+			return nil, nil
+		}
+		result = result[query.Offset:]
+	}
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+func (ms *memoryContractStore) Count(query ContractQuery) (int, error) {
+	return len(ms.matching(query)), nil
+}
+
+// matching retorna los contratos que cumplen los filtros en el orden de la consulta
+func (ms *memoryContractStore) matching(query ContractQuery) []*Contract {
+	ms.mutex.RLock()
+	candidates := ms.candidates(query)
+	ms.mutex.RUnlock()
+
+	result := make([]*Contract, 0, len(candidates))
+	for _, id := range candidates {
+		if contract, exists := ms.lookup(id); exists && query.Matches(contract) {
+			result = append(result, contract)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return query.less(result[i], result[j]) })
+	return result
+}
+
+// candidates elige el índice más selectivo para la consulta: el conjunto más pequeño
+// entre los filtros exactos o, si no hay, el rango de fechas o de montos. Requiere el lock.
+func (ms *memoryContractStore) candidates(query ContractQuery) []string {
+	var smallest map[string]bool
+	exact := false
+	for _, filter := range []struct {
+		index map[string]map[string]bool
+		value string
+	}{
+		{ms.byEntity, query.EntityCode},
+		{ms.byType, query.ContractType},
+		{ms.byStatus, string(query.Status)},
+	} {
+		if filter.value == "" {
+			continue
+		}
+		set := filter.index[filter.value]
+		if !exact || len(set) < len(smallest) {
+			smallest, exact = set, true
+		}
+	}
+	if exact {
+		ids := make([]string, 0, len(smallest))
+		for id := range smallest {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	var entries []indexedContract
+	if query.MinAmount != nil || query.MaxAmount != nil {
+		low, high := 0, len(ms.byAmount)
+		if query.MinAmount != nil {
+			low = sort.Search(len(ms.byAmount), func(i int) bool { return ms.byAmount[i].amount >= *query.MinAmount })
+		}
+		if query.MaxAmount != nil {
+			high = sort.Search(len(ms.byAmount), func(i int) bool { return ms.byAmount[i].amount > *query.MaxAmount })
+		}
+		if low < high {
+			entries = ms.byAmount[low:high]
+		}
+	} else {
+		low, high := 0, len(ms.byCreation)
+		if query.From != nil {
+			low = sort.Search(len(ms.byCreation), func(i int) bool { return !ms.byCreation[i].createdAt.Before(*query.From) })
+		}
+		if query.To != nil {
+			high = sort.Search(len(ms.byCreation), func(i int) bool { return ms.byCreation[i].createdAt.After(*query.To) })
+		}
+		if low < high {
+			entries = ms.byCreation[low:high]
+		}
+	}
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.id)
+	}
+	return ids
+}
+
+func (ms *memoryContractStore) Close() error {
+	return nil
+}
+
+func addToSet(index map[string]map[string]bool, key string, id string) {
+	set, exists := index[key]
+	if !exists {
+		set = make(map[string]bool)
+		index[key] = set
+	}
+	set[id] = true
+}
+
+func removeFromSet(index map[string]map[string]bool, key string, id string) {
+	if set, exists := index[key]; exists {
+		delete(set, id)
+		if len(set) == 0 {
+			delete(index, key)
+		}
+	}
+}
+
+// insertSorted agrega la entrada en su posición dentro de la lista ordenada
+func insertSorted(list []indexedContract, entry indexedContract, less func(a, b indexedContract) bool) []indexedContract {
+	i := sort.Search(len(list), func(i int) bool { return !less(list[i], entry) })
+	list = append(list, indexedContract{})
+	copy(list[i+1:], list[i:])
+	list[i] = entry
+	return list
+}
+
+// removeSorted quita la entrada de la lista ordenada
+func removeSorted(list []indexedContract, entry indexedContract, less func(a, b indexedContract) bool) []indexedContract {
+	i := sort.Search(len(list), func(i int) bool { return !less(list[i], entry) })
+	if i < len(list) && list[i].id == entry.id {
+		list = append(list[:i], list[i+1:]...)
+	}
+	return list
+}
+
+// SetContractStore cambia el store de contratos y le carga el estado actual
+func (bc *Blockchain) SetContractStore(store ContractStore) error {
+	bc.contractStore = store
+	return bc.reindexContracts()
+}
+
+// reindexContracts vuelve a guardar todos los contratos en el store (p. ej. tras adoptar otra cadena)
+func (bc *Blockchain) reindexContracts() error {
+	contracts := bc.contractMap()
+	bc.SearchIndex.Rebuild(contracts)
+
+	for _, contract := range contracts {
+		if err := bc.contractStore.Save(contract); err != nil {
+			err = fmt.Errorf("error indexando contrato %s: %v", contract.ID, err)
+			bc.Projections.failed(ProjectionContractStore, err)
+			return err
+		}
+	}
+	bc.Projections.applied(ProjectionContractStore, bc.Len()-1)
+	return nil
+}
+
+// QueryContracts consulta contratos con filtros a través del store de contratos
+func (bc *Blockchain) QueryContracts(query ContractQuery) ([]*Contract, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return bc.contractStore.Query(query)
+}
+
+// CountContracts cuenta los contratos que cumplen los filtros, p. ej. para paginar
+func (bc *Blockchain) CountContracts(query ContractQuery) (int, error) {
+	return bc.contractStore.Count(query)
+}
@@ -0,0 +1,168 @@
+package blockchain
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq" // Driver de PostgreSQL
+)
+
+// postgresContractSchema crea la tabla de contratos y los índices de los filtros habituales
+const postgresContractSchema = `
+CREATE TABLE IF NOT EXISTS contracts (
+	id          TEXT PRIMARY KEY,
+	entity_code TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	amount      DOUBLE PRECISION NOT NULL,
+	created_at  TIMESTAMPTZ NOT NULL,
+	updated_at  TIMESTAMPTZ NOT NULL,
+	data        JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS contracts_entity_idx ON contracts (entity_code);
+CREATE INDEX IF NOT EXISTS contracts_status_idx ON contracts (status);
+CREATE INDEX IF NOT EXISTS contracts_created_idx ON contracts (created_at);
+CREATE INDEX IF NOT EXISTS contracts_amount_idx ON contracts (amount);
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS contract_type TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS contracts_type_idx ON contracts (contract_type);
+`
+
+// PostgresContractStore guarda el estado de los contratos en PostgreSQL para
+// poder filtrarlos por entidad, modalidad, estado, fechas y monto sin recorrer la memoria
+type PostgresContractStore struct {
+	db *sql.DB
+}
+
+// NewPostgresContractStore abre la conexión y crea el esquema si no existe
+func NewPostgresContractStore(dsn string) (*PostgresContractStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("no se pudo conectar a PostgreSQL: %v", err)
+	}
+	if _, err := db.Exec(postgresContractSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creando esquema de contratos: %v", err)
+	}
+	return &PostgresContractStore{db: db}, nil
+}
+
+// Save inserta o actualiza el estado de un contrato
+func (ps *PostgresContractStore) Save(contract *Contract) error {
+	data, err := json.Marshal(contract)
+	if err != nil {
+		return err
+	}
+	_, err = ps.db.Exec(`
+		INSERT INTO contracts (id, entity_code, status, amount, created_at, updated_at, data, contract_type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO UPDATE SET
+			entity_code = EXCLUDED.entity_code,
+			contract_type = EXCLUDED.contract_type,
+			status = EXCLUDED.status,
+			amount = EXCLUDED.amount,
+			created_at = EXCLUDED.created_at,
+			updated_at = EXCLUDED.updated_at,
+			data = EXCLUDED.data`,
+		contract.ID, contract.EntityCode, string(contract.Status), contract.Amount,
+		contract.CreatedAt, contract.UpdatedAt, data, contract.ContractType)
+	return err
+}
+
+// where arma las condiciones de los filtros de la consulta
+func (ps *PostgresContractStore) where(query ContractQuery) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.EntityCode != "" {
+		add("entity_code = $%d", query.EntityCode)
+	}
+	if query.ContractType != "" {
+		add("contract_type = $%d", query.ContractType)
+	}
+	if query.Status != "" {
+		add("status = $%d", string(query.Status))
+	}
+	if query.From != nil {
+		add("created_at >= $%d", *query.From)
+	}
+	if query.To != nil {
+		add("created_at <= $%d", *query.To)
+	}
+	if query.MinAmount != nil {
+		add("amount >= $%d", *query.MinAmount)
+	}
+	if query.MaxAmount != nil {
+		add("amount <= $%d", *query.MaxAmount)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// Query consulta los contratos que cumplen los filtros en el orden pedido
+func (ps *PostgresContractStore) Query(query ContractQuery) ([]*Contract, error) {
+	where, args := ps.where(query)
+	column := "created_at"
+	if query.Sort == ContractSortAmount {
+		column = "amount"
+	}
+	direction := "DESC"
+	if query.Ascending {
+		direction = "ASC"
+	}
+
+	statement := "SELECT data FROM contracts" + where
+	statement += fmt.Sprintf(" ORDER BY %s %s, id", column, direction)
+	if query.Limit > 0 {
+		args = append(args, query.Limit)
+		statement += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if query.Offset > 0 {
+		args = append(args, query.Offset)
+		statement += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := ps.db.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*Contract
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var contract Contract
+		if err := json.Unmarshal(data, &contract); err != nil {
+			return nil, err
+		}
+		result = append(result, &contract)
+	}
+	return result, rows.Err()
+}
+
+// Count cuenta los contratos que cumplen los filtros
+func (ps *PostgresContractStore) Count(query ContractQuery) (int, error) {
+	where, args := ps.where(query)
+	var count int
+	err := ps.db.QueryRow("SELECT COUNT(*) FROM contracts"+where, args...).Scan(&count)
+	return count, err
+}
+
+// Close cierra la conexión con la base de datos
+func (ps *PostgresContractStore) Close() error {
+	return ps.db.Close()
+}
@@ -0,0 +1,114 @@
+package blockchain
+
+import "sync"
+
+// Cambios de contratos. Quien modifica un contrato no toca el que ven los lectores:
+//
+//   - toma el candado del contrato (lockContract) durante toda la operación, de modo que
+//     dos cambios concurrentes sobre el mismo contrato no validen contra el mismo estado;
+//   - trabaja sobre una copia (cloneContract) y la deja a la espera de su bloque con
+//     stageContract;
+//   - appendNewBlock registra la copia en el bloque y la pone en vigencia solo cuando el
+//     bloque entra a la cadena. Si el bloque falla, unstageContract la descarta y el
+//     contrato vigente queda como estaba.
+
+// contractLocks lleva un candado por contrato; los candados se crean al pedirlos y se
+// liberan cuando nadie los retiene
+type contractLocks struct {
+	locks map[string]*contractLock
+	mutex sync.Mutex
+}
+
+type contractLock struct {
+	mutex   sync.Mutex
+	holders int
+}
+
+// lockContract toma el candado del contrato y retorna la función que lo libera
+func (bc *Blockchain) lockContract(contractID string) func() {
+	cl := &bc.contractLocks
+	cl.mutex.Lock()
+	if cl.locks == nil {
+		cl.locks = make(map[string]*contractLock)
+	}
+	lock, exists := cl.locks[contractID]
+	if !exists {
+		lock = &contractLock{}
+		cl.locks[contractID] = lock
+	}
+	lock.holders++
+	cl.mutex.Unlock()
+
+	lock.mutex.Lock()
+	return func() {
+		lock.mutex.Unlock()
+		cl.mutex.Lock()
+		lock.holders--
+		if lock.holders == 0 {
+			delete(cl.locks, contractID)
+		}
+		cl.mutex.Unlock()
+	}
+}
+
+// editContract retorna una copia del contrato vigente para modificarla; requiere el
+// candado del contrato
+func (bc *Blockchain) editContract(contractID string) (*Contract, error) {
+	contract, err := bc.GetContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	return cloneContract(contract)
+}
+
+// stageContract deja la copia modificada del contrato a la espera del bloque que registra
+// el cambio
+func (bc *Blockchain) stageContract(contract *Contract) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	if bc.staged == nil {
+		bc.staged = make(map[string]*Contract)
+	}
+	bc.staged[contract.ID] = contract
+}
+
+// unstageContract descarta la copia de un cambio cuyo bloque no entró a la cadena
+func (bc *Blockchain) unstageContract(contractID string) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	delete(bc.staged, contractID)
+}
+
+// pendingContract retorna la copia a la espera de bloque o, si no hay, el contrato vigente.
+// Es el estado que registra el bloque en armado.
+func (bc *Blockchain) pendingContract(contractID string) (*Contract, bool) {
+	bc.mutex.RLock()
+	contract, staged := bc.staged[contractID]
+	bc.mutex.RUnlock()
+	if staged {
+		return contract, true
+	}
+	return bc.Contract(contractID)
+}
+
+// commitStaged pone en vigencia las copias de los contratos que toca un bloque ya agregado,
+// con la secuencia que les asignó el bloque
+func (bc *Blockchain) commitStaged(data map[string]interface{}) {
+	bc.mutex.Lock()
+	for _, entry := range eventData(data) {
+		if contractID, sequence, ok := transactionSequence(entry); ok && bc.staged[contractID] != nil {
+			bc.staged[contractID].Sequence = sequence
+		}
+	}
+	bc.mutex.Unlock()
+
+	for _, contractID := range blockContractIDs(data) {
+		bc.mutex.Lock()
+		contract, staged := bc.staged[contractID]
+		delete(bc.staged, contractID)
+		bc.mutex.Unlock()
+		if staged {
+			bc.putContract(contract)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package blockchain
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Índice invertido de los datos de los contratos para la búsqueda de texto completo: se
+// actualiza junto con el store de consultas y ordena los resultados por relevancia,
+// dando más peso a los términos raros y a los campos más específicos.
+
+// Campos de los contratos que se buscan
+const (
+	SearchFieldDescription  = "description"
+	SearchFieldEntityName   = "entity_name"
+	SearchFieldCreatedBy    = "created_by"
+	SearchFieldEntityCode   = "entity_code"
+	SearchFieldContractType = "contract_type"
+)
+
+// searchFieldWeights es el peso de una coincidencia en cada campo
+var searchFieldWeights = map[string]float64{
+	SearchFieldDescription:  1.0,
+	SearchFieldEntityName:   1.5,
+	SearchFieldCreatedBy:    1.2,
+	SearchFieldEntityCode:   2.0,
+	SearchFieldContractType: 0.5,
+}
+
+// Marcas con las que se resaltan los términos encontrados; el resto del texto se escapa
+const (
+	HighlightOpen  = "<mark>"
+	HighlightClose = "</mark>"
+)
+
+// ContractSearchHit es un contrato que contiene todos los términos de la búsqueda
+type ContractSearchHit struct {
+	ContractID string            `json:"contract_id"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"` // Campo -> texto con los términos resaltados
+}
+
+// searchDocument son los textos de un contrato tal como se indexaron
+type searchDocument struct {
+	fields map[string]string
+	terms  map[string]map[string]int // término -> campo -> ocurrencias
+}
+
+// ContractSearchIndex es el índice invertido de los datos de los contratos
+type ContractSearchIndex struct {
+	documents map[string]*searchDocument
+	postings  map[string]map[string]bool // término -> contratos que lo contienen
+	mutex     sync.RWMutex
+}
+
+// newContractSearchIndex crea el índice vacío
+func newContractSearchIndex() *ContractSearchIndex {
+	return &ContractSearchIndex{
+		documents: make(map[string]*searchDocument),
+		postings:  make(map[string]map[string]bool),
+	}
+}
+
+// Update indexa la versión actual del contrato, reemplazando la anterior
+func (si *ContractSearchIndex) Update(contract *Contract) {
+	document := &searchDocument{
+		fields: map[string]string{
+			SearchFieldDescription:  contract.Description,
+			SearchFieldEntityName:   contract.EntityName,
+			SearchFieldCreatedBy:    contract.CreatedBy,
+			SearchFieldEntityCode:   contract.EntityCode,
+			SearchFieldContractType: contract.ContractType,
+		},
+		terms: make(map[string]map[string]int),
+	}
+	for field, text := range document.fields {
+		for _, term := range tokenize(text) {
+			if document.terms[term] == nil {
+				document.terms[term] = make(map[string]int)
+			}
+			document.terms[term][field]++
+		}
+	}
+
+	si.mutex.Lock()
+	defer si.mutex.Unlock()
+	si.remove(contract.ID)
+	si.documents[contract.ID] = document
+	for term := range document.terms {
+		if si.postings[term] == nil {
+			si.postings[term] = make(map[string]bool)
+		}
+		si.postings[term][contract.ID] = true
+	}
+}
+
+// Rebuild reemplaza el índice por uno con los contratos dados
+func (si *ContractSearchIndex) Rebuild(contracts map[string]*Contract) {
+	si.mutex.Lock()
+	si.documents = make(map[string]*searchDocument)
+	si.postings = make(map[string]map[string]bool)
+	si.mutex.Unlock()
+	for _, contract := range contracts {
+		si.Update(contract)
+	}
+}
+
+// remove saca un contrato del índice; requiere el lock tomado
+func (si *ContractSearchIndex) remove(contractID string) {
+	document, exists := si.documents[contractID]
+	if !exists {
+		return
+	}
+	for term := range document.terms {
+		delete(si.postings[term], contractID)
+		if len(si.postings[term]) == 0 {
+			delete(si.postings, term)
+		}
+	}
+	delete(si.documents, contractID)
+}
+
+// Search retorna los contratos que contienen todos los términos, los más relevantes
+// primero. Cada término suma según su frecuencia en el campo (con rendimiento
+// decreciente), el peso del campo y qué tan raro es entre los contratos.
+func (si *ContractSearchIndex) Search(query string) []ContractSearchHit {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return []ContractSearchHit{}
+	}
+
+	si.mutex.RLock()
+	defer si.mutex.RUnlock()
+
+	// Se parte del término con menos contratos para recorrer la menor cantidad posible
+	candidates := si.postings[terms[0]]
+	for _, term := range terms[1:] {
+		if len(si.postings[term]) < len(candidates) {
+			candidates = si.postings[term]
+		}
+	}
+
+	total := float64(len(si.documents))
+	hits := []ContractSearchHit{}
+	for contractID := range candidates {
+		document := si.documents[contractID]
+		score := 0.0
+		matched := true
+		for _, term := range terms {
+			fields, exists := document.terms[term]
+			if !exists {
+				matched = false
+				break
+			}
+			idf := math.Log(1 + total/float64(len(si.postings[term])))
+			for field, count := range fields {
+				frequency := float64(count)
+				score += searchFieldWeights[field] * idf * frequency / (frequency + 1)
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		highlights := make(map[string]string)
+		for field, text := range document.fields {
+			if highlighted, found := highlight(text, terms); found {
+				highlights[field] = highlighted
+			}
+		}
+		hits = append(hits, ContractSearchHit{ContractID: contractID, Score: math.Round(score*1000) / 1000, Highlights: highlights})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ContractID < hits[j].ContractID
+	})
+	return hits
+}
+
+// highlight escapa el texto y envuelve en HighlightOpen/HighlightClose las palabras que
+// coinciden con algún término; indica si hubo alguna
+func highlight(text string, terms []string) (string, bool) {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	var builder strings.Builder
+	found := false
+	runes := []rune(text)
+	for start := 0; start < len(runes); {
+		end := start
+		isWord := unicode.IsLetter(runes[start]) || unicode.IsDigit(runes[start])
+		for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) == isWord {
+			end++
+		}
+		segment := string(runes[start:end])
+		if isWord && wanted[foldText(segment)] {
+			builder.WriteString(HighlightOpen + html.EscapeString(segment) + HighlightClose)
+			found = true
+		} else {
+			builder.WriteString(html.EscapeString(segment))
+		}
+		start = end
+	}
+	return builder.String(), found
+}
+
+// SearchContracts busca en la descripción, la entidad, el creador y la modalidad de los
+// contratos que este nodo conoce
+func (bc *Blockchain) SearchContracts(query string) []ContractSearchHit {
+	hits := bc.SearchIndex.Search(query)
+	current := hits[:0]
+	for _, hit := range hits {
+		if _, exists := bc.Contract(hit.ContractID); exists {
+			current = append(current, hit)
+		}
+	}
+	return current
+}
@@ -0,0 +1,84 @@
+package blockchain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Matriz de decisiones de un contrato: cada aprobación o rechazo de un paso tal como quedó
+// registrado en la cadena, con el bloque que lo respalda, para los papeles de trabajo de
+// las contralorías.
+
+// Decisiones posibles sobre un paso
+const (
+	DecisionApproved = "APROBADO"
+	DecisionRejected = "RECHAZADO"
+)
+
+// ContractDecision es una decisión de un validador sobre un paso del flujo
+type ContractDecision struct {
+	Number         int       `json:"number"` // Orden de la decisión en la cadena, desde 1
+	StepNumber     int       `json:"step_number"`
+	Role           AdminRole `json:"role"`
+	ValidatorID    string    `json:"validator_id"`
+	ValidatorName  string    `json:"validator_name,omitempty"`
+	Decision       string    `json:"decision"`
+	AutoApproved   bool      `json:"auto_approved,omitempty"`
+	DecidedAt      time.Time `json:"decided_at"`
+	Comments       string    `json:"comments"`
+	SignatureKeyID string    `json:"signature_kid,omitempty"`
+	ActReference   string    `json:"act_reference,omitempty"`
+	ActHash        string    `json:"act_hash,omitempty"`
+	BlockIndex     int       `json:"block_index"`
+	BlockHash      string    `json:"block_hash"`
+}
+
+// ContractDecisions retorna las decisiones registradas en la cadena sobre el contrato, en
+// el orden en que se tomaron
+func (bc *Blockchain) ContractDecisions(contractID string) ([]ContractDecision, error) {
+	contract, exists := bc.Contract(contractID)
+	if !exists {
+		return nil, fmt.Errorf("contrato %s no encontrado", contractID)
+	}
+	decisions := []ContractDecision{}
+	err := bc.eachContractTransaction(contractID, func(block *Block, _ int, entry map[string]interface{}) {
+		if kind, _ := entry["type"].(string); kind != "VALIDATION" {
+			return
+		}
+
+		decision := ContractDecision{
+			Number:     len(decisions) + 1,
+			Decision:   DecisionRejected,
+			DecidedAt:  block.Timestamp,
+			BlockIndex: block.Index,
+			BlockHash:  originHash(block),
+		}
+		var role string
+		var approved bool
+		decodeField(entry, "step", &decision.StepNumber)
+		decodeField(entry, "role", &role)
+		decodeField(entry, "validator", &decision.ValidatorID)
+		decodeField(entry, "approved", &approved)
+		decodeField(entry, "auto_approved", &decision.AutoApproved)
+		decodeField(entry, "timestamp", &decision.DecidedAt)
+		decodeField(entry, "comments", &decision.Comments)
+		decodeField(entry, "signature_kid", &decision.SignatureKeyID)
+		decodeField(entry, "act_reference", &decision.ActReference)
+		decodeField(entry, "act_hash", &decision.ActHash)
+		decision.Role = AdminRole(role)
+		if approved {
+			decision.Decision = DecisionApproved
+		}
+		// El nombre del validador no viaja en la cadena; se toma del paso si lo decidió él
+		for _, step := range contract.ValidationSteps {
+			if step.StepNumber == decision.StepNumber && step.ValidatorID == decision.ValidatorID {
+				decision.ValidatorName = step.ValidatorName
+			}
+		}
+		decisions = append(decisions, decision)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
@@ -0,0 +1,138 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// Tamaño de las páginas de bloques que se intercambian al sincronizar
+const (
+	SyncPageSize    = 500  // Bloques que pide un nodo por página
+	MaxSyncPageSize = 1000 // Bloques que entrega un nodo como máximo por página
+)
+
+// BlocksPage es una página de bloques de la cadena a partir de una altura
+type BlocksPage struct {
+	Blocks     []Block `json:"blocks"`
+	FromHeight int     `json:"from_height"`
+	Height     int     `json:"height"` // Cantidad de bloques de la cadena del nodo
+	HasMore    bool    `json:"has_more"`
+	NodeID     string  `json:"node_id,omitempty"`
+}
+
+// BlocksFrom retorna hasta limit bloques completos desde la altura dada, leyendo los
+// archivados del disco
+func (bc *Blockchain) BlocksFrom(from, limit int) (BlocksPage, error) {
+	if from < 0 {
+		return BlocksPage{}, fmt.Errorf("altura %d inválida", from)
+	}
+	if limit <= 0 || limit > MaxSyncPageSize {
+		limit = MaxSyncPageSize
+	}
+
+	height := bc.Len()
+	page := BlocksPage{Blocks: []Block{}, FromHeight: from, Height: height}
+	to := from + limit
+	if to > height {
+		to = height
+	}
+	for i := from; i < to; i++ {
+		block, err := bc.BlockAt(i)
+		if err != nil {
+			return BlocksPage{}, err
+		}
+		page.Blocks = append(page.Blocks, *block)
+	}
+	page.HasMore = to < height
+	return page, nil
+}
+
+// syncFromTip descarga del peer solo los bloques posteriores a la punta local y, si la
+// cadena del peer la contiene, los agrega uno a uno con la misma validación de un bloque
+// recibido, sin reescribir la cadena ni reconstruir el estado. Retorna error si no hay
+// punta local o la cadena del peer diverge, para que se descargue la cadena completa.
+func (p2p *P2PNetwork) syncFromTip(peerID string, peer *Peer) error {
+	height := p2p.Blockchain.Len()
+	if height == 0 {
+		return fmt.Errorf("cadena local vacía")
+	}
+	tip := p2p.Blockchain.getLatestBlock()
+
+	// La primera página empieza en la punta local para comprobar que el peer la tiene
+	blocks, peerHeight, err := p2p.requestBlocksFromPeer(peer, height-1)
+	if err != nil {
+		return err
+	}
+	if peerHeight <= height {
+		return nil
+	}
+	if len(blocks) == 0 || blocks[0].Hash != tip.Hash {
+		return fmt.Errorf("la cadena del peer diverge de la local en la altura %d", height-1)
+	}
+
+	logf("🔄 %d bloques nuevos de %s desde la altura %d\n", len(blocks)-1, peerID, height)
+	for _, block := range blocks[1:] {
+		if p2p.Blockchain.HasBlock(block.Hash) {
+			continue
+		}
+		// La punta pudo avanzar con bloques de otro peer mientras se descargaban estos
+		if !p2p.Blockchain.extendsTip(block) {
+			logf("⚠️ La punta local avanzó durante la sincronización con %s; se retoma en la siguiente\n", peerID)
+			return nil
+		}
+		if reason := p2p.invalidBlockReason(block, peerID); reason != "" {
+			p2p.rejectInvalidBlock(block, peerID, reason)
+			logf("🚫 Bloque %s de %s rechazado al sincronizar: %s\n", block.Hash, peerID, reason)
+			return nil
+		}
+		if err := p2p.appendReceived(block); err != nil {
+			logf("❌ Error agregando bloque %s de %s: %v\n", block.Hash, peerID, err)
+			return nil
+		}
+		p2p.seen.markSeen(block.Hash)
+		p2p.Reputation.RecordValidBlock(peerID)
+	}
+	return nil
+}
+
+// requestBlocksFromPeer solicita al peer, página por página, los bloques desde la
+// altura dada. Retorna también la cantidad de bloques de la cadena del peer.
+func (p2p *P2PNetwork) requestBlocksFromPeer(peer *Peer, from int) ([]Block, int, error) {
+	var blocks []Block
+	for {
+		page, err := p2p.backend.RequestBlocks(peer, from+len(blocks))
+		if err != nil {
+			return nil, 0, err
+		}
+		blocks = append(blocks, page.Blocks...)
+		if !page.HasMore || len(page.Blocks) == 0 {
+			return blocks, page.Height, nil
+		}
+	}
+}
+
+// fetchBlocksPage solicita al peer por HTTP una página de bloques desde la altura dada
+func (p2p *P2PNetwork) fetchBlocksPage(peer *Peer, from int) (*BlocksPage, error) {
+	resp, err := p2p.peerGet(peer, fmt.Sprintf("/api/p2p/blocks?from_height=%d&limit=%d", from, SyncPageSize), 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer respondió con status %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &BlocksPage{}
+	if err := json.Unmarshal(body, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
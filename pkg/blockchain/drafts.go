@@ -0,0 +1,214 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// DraftPolicy define cuándo un borrador se considera abandonado y cuándo se archiva
+type DraftPolicy struct {
+	StaleAfter  time.Duration
+	GracePeriod time.Duration
+	// Notify avisa al creador del borrador; por defecto solo se registra en el log
+	Notify func(contract *Contract, message string)
+}
+
+// DraftSweepReport resume el resultado de una revisión de borradores
+type DraftSweepReport struct {
+	SweptAt  time.Time `json:"swept_at"`
+	Flagged  []string  `json:"flagged"`
+	Archived []string  `json:"archived"`
+	Held     []string  `json:"held"`
+}
+
+// DraftJanitor marca y archiva (fuera de la cadena) los borradores abandonados
+type DraftJanitor struct {
+	blockchain *Blockchain
+	policy     DraftPolicy
+	archived   map[string]*Contract
+	mutex      sync.Mutex
+}
+
+// NewDraftJanitor crea un limpiador de borradores con la política dada
+func NewDraftJanitor(bc *Blockchain, policy DraftPolicy) *DraftJanitor {
+	if policy.Notify == nil {
+		policy.Notify = func(contract *Contract, message string) {
+			logf("📧 Aviso a %s sobre borrador %s: %s\n", contract.CreatedBy, contract.ID, message)
+		}
+	}
+	dj := &DraftJanitor{
+		blockchain: bc,
+		policy:     policy,
+		archived:   make(map[string]*Contract),
+	}
+
+	// Recuperar los borradores archivados en ejecuciones anteriores
+	bc.store.ForEach(storage.BucketArchived, func(key string, value []byte) error {
+		var contract Contract
+		if err := json.Unmarshal(value, &contract); err == nil {
+			dj.archived[key] = &contract
+		}
+		return nil
+	})
+	return dj
+}
+
+// Sweep revisa los borradores: marca los inactivos, notifica a su creador y archiva
+// los que siguen sin cambios después del periodo de gracia
+func (dj *DraftJanitor) Sweep(now time.Time) DraftSweepReport {
+	return dj.sweep(now, "")
+}
+
+// SweepEntity revisa solo los borradores de una entidad; es la revisión que pide su
+// administrador
+func (dj *DraftJanitor) SweepEntity(now time.Time, entityCode string) DraftSweepReport {
+	return dj.sweep(now, entityCode)
+}
+
+// sweep revisa los borradores de la entidad indicada, o de todas con ""
+func (dj *DraftJanitor) sweep(now time.Time, entityCode string) DraftSweepReport {
+	dj.mutex.Lock()
+	defer dj.mutex.Unlock()
+
+	report := DraftSweepReport{SweptAt: now}
+	for id, current := range dj.blockchain.contractMap() {
+		if current.Status != StatusDraft || (entityCode != "" && current.EntityCode != entityCode) {
+			continue
+		}
+		dj.sweepDraft(id, now, entityCode, &report)
+	}
+
+	if len(report.Flagged) > 0 || len(report.Archived) > 0 {
+		logf("🧹 Revisión de borradores: %d marcados, %d archivados\n", len(report.Flagged), len(report.Archived))
+	}
+	return report
+}
+
+// sweepDraft revisa un borrador bajo su candado y anota el resultado en report; requiere
+// el lock del janitor
+func (dj *DraftJanitor) sweepDraft(id string, now time.Time, entityCode string, report *DraftSweepReport) {
+	unlock := dj.blockchain.lockContract(id)
+	defer unlock()
+
+	contract, err := dj.blockchain.editContract(id)
+	if err != nil || contract.Status != StatusDraft || (entityCode != "" && contract.EntityCode != entityCode) {
+		return
+	}
+	if contract.RetentionHold {
+		report.Held = append(report.Held, id)
+		return
+	}
+
+	// Si el borrador se modificó después de marcarlo, se desmarca
+	if contract.StaleFlaggedAt != nil && contract.UpdatedAt.After(*contract.StaleFlaggedAt) {
+		contract.StaleFlaggedAt = nil
+	}
+
+	if contract.StaleFlaggedAt == nil {
+		if now.Sub(contract.UpdatedAt) >= dj.policy.StaleAfter {
+			flaggedAt := now
+			contract.StaleFlaggedAt = &flaggedAt
+			report.Flagged = append(report.Flagged, id)
+			dj.blockchain.saveContract(contract)
+			dj.policy.Notify(contract, fmt.Sprintf("borrador sin cambios desde %s, será archivado el %s",
+				contract.UpdatedAt.Format("2006-01-02"), now.Add(dj.policy.GracePeriod).Format("2006-01-02")))
+		}
+		return
+	}
+
+	if now.Sub(*contract.StaleFlaggedAt) >= dj.policy.GracePeriod {
+		dj.archived[id] = contract
+		dj.blockchain.removeContract(id)
+		dj.blockchain.saveState(storage.BucketArchived, id, contract)
+		dj.blockchain.store.Delete(storage.BucketContracts, id)
+		report.Archived = append(report.Archived, id)
+		dj.policy.Notify(contract, "borrador archivado por inactividad")
+	}
+}
+
+// SetHold activa o retira la retención de un borrador (solo administrador de la entidad)
+func (dj *DraftJanitor) SetHold(contractID string, adminID string, role AdminRole, hold bool) error {
+	if role != RoleAdminChief {
+		return errors.New("solo el jefe administrativo de la entidad puede retener borradores")
+	}
+
+	dj.mutex.Lock()
+	defer dj.mutex.Unlock()
+
+	unlock := dj.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := dj.blockchain.editContract(contractID)
+	if err != nil {
+		return err
+	}
+	if contract.Status != StatusDraft {
+		return errors.New("solo los borradores pueden retenerse")
+	}
+
+	contract.RetentionHold = hold
+	contract.StaleFlaggedAt = nil
+	action := "DRAFT_HOLD_RELEASED"
+	if hold {
+		action = "DRAFT_HOLD"
+	}
+	dj.blockchain.WorkflowManager.addAuditEntry(contract, action, adminID, role, "Retención de borrador actualizada")
+	dj.blockchain.saveContract(contract)
+	return nil
+}
+
+// ArchivedDraft retorna un borrador archivado, o false si no existe
+func (dj *DraftJanitor) ArchivedDraft(contractID string) (*Contract, bool) {
+	dj.mutex.Lock()
+	defer dj.mutex.Unlock()
+
+	contract, exists := dj.archived[contractID]
+	return contract, exists
+}
+
+// Restore devuelve un borrador archivado al conjunto de contratos
+func (dj *DraftJanitor) Restore(contractID string) error {
+	dj.mutex.Lock()
+	defer dj.mutex.Unlock()
+
+	contract, exists := dj.archived[contractID]
+	if !exists {
+		return errors.New("borrador archivado no encontrado")
+	}
+	unlock := dj.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract.StaleFlaggedAt = nil
+	contract.UpdatedAt = time.Now()
+	delete(dj.archived, contractID)
+	dj.blockchain.saveContract(contract)
+	dj.blockchain.store.Delete(storage.BucketArchived, contractID)
+	return nil
+}
+
+// Archived retorna los borradores archivados
+func (dj *DraftJanitor) Archived() []*Contract {
+	dj.mutex.Lock()
+	defer dj.mutex.Unlock()
+
+	contracts := make([]*Contract, 0, len(dj.archived))
+	for _, contract := range dj.archived {
+		contracts = append(contracts, contract)
+	}
+	return contracts
+}
+
+// Run ejecuta la revisión de borradores periódicamente
+func (dj *DraftJanitor) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		dj.Sweep(time.Now())
+	}
+}
@@ -0,0 +1,129 @@
+package blockchain
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EntitySystemKey representa la llave pública Ed25519 de un sistema externo (ERP) de una entidad
+type EntitySystemKey struct {
+	EntityCode   string    `json:"entity_code"`
+	SystemID     string    `json:"system_id"`
+	PublicKey    string    `json:"public_key"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// systemKeyID construye la llave del mapa de sistemas registrados
+func systemKeyID(entityCode, systemID string) string {
+	return entityCode + "/" + systemID
+}
+
+// RegisterSystemKey registra la llave pública de un sistema de una entidad. La llave queda
+// habilitada solo cuando su bloque entra a la cadena.
+func (bc *Blockchain) RegisterSystemKey(entityCode string, systemID string, publicKey string) (*EntitySystemKey, error) {
+	if entityCode == "" || systemID == "" {
+		return nil, errors.New("código de entidad y sistema requeridos")
+	}
+	raw, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("llave pública Ed25519 inválida")
+	}
+
+	bc.registryMutex.Lock()
+	defer bc.registryMutex.Unlock()
+
+	key := &EntitySystemKey{
+		EntityCode:   entityCode,
+		SystemID:     systemID,
+		PublicKey:    publicKey,
+		RegisteredAt: time.Now(),
+	}
+
+	blockData := map[string]interface{}{
+		"type":        "SYSTEM_KEY_REGISTRATION",
+		"entity_code": entityCode,
+		"system_id":   systemID,
+		"public_key":  publicKey,
+		"timestamp":   key.RegisteredAt,
+	}
+
+	if err := bc.AddBlock(blockData); err != nil {
+		return nil, err
+	}
+
+	bc.putSystemKey(key)
+	return key, nil
+}
+
+// GetSystemKeys obtiene los sistemas registrados de una entidad
+func (bc *Blockchain) GetSystemKeys(entityCode string) []*EntitySystemKey {
+	var keys []*EntitySystemKey
+	for _, key := range bc.AllSystemKeys() {
+		if key.EntityCode == entityCode {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// verifySystemSignature verifica la firma de un payload con la llave del sistema y la
+// reserva; quien llama la libera si el contrato no llega a la cadena
+func (bc *Blockchain) verifySystemSignature(entityCode string, systemID string, payload []byte, signature string) error {
+	key, exists := bc.systemKey(systemKeyID(entityCode, systemID))
+	if !exists {
+		return errors.New("sistema no registrado para la entidad")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errors.New("firma mal codificada")
+	}
+
+	publicKey, _ := base64.StdEncoding.DecodeString(key.PublicKey)
+	if !ed25519.Verify(ed25519.PublicKey(publicKey), payload, sig) {
+		return errors.New("firma inválida")
+	}
+	if !bc.claimSignature(signature) {
+		return errors.New("payload firmado ya fue procesado")
+	}
+	return nil
+}
+
+// AddSignedContract crea un contrato a partir de un payload firmado por el sistema de una entidad
+func (bc *Blockchain) AddSignedContract(entityCode string, systemID string, payload []byte, signature string) (*Contract, error) {
+	if err := bc.verifySystemSignature(entityCode, systemID, payload, signature); err != nil {
+		return nil, err
+	}
+
+	contract, err := bc.addSignedContract(entityCode, systemID, payload, signature)
+	if err != nil {
+		bc.releaseSignature(signature)
+		return nil, err
+	}
+	return contract, nil
+}
+
+// addSignedContract decodifica el payload ya verificado y crea el contrato con la firma en
+// su bloque, que la marca como usada
+func (bc *Blockchain) addSignedContract(entityCode string, systemID string, payload []byte, signature string) (*Contract, error) {
+	var contract Contract
+	if err := json.Unmarshal(payload, &contract); err != nil {
+		return nil, fmt.Errorf("payload inválido: %v", err)
+	}
+	if contract.EntityCode != entityCode {
+		return nil, errors.New("el payload pertenece a otra entidad")
+	}
+	if contract.CreatedBy == "" {
+		contract.CreatedBy = "system:" + systemID
+	}
+	contract.OriginSystem = systemID
+
+	if err := bc.addContract(&contract, signature); err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
@@ -0,0 +1,107 @@
+package blockchain
+
+import (
+	"sync"
+	"time"
+)
+
+// ChainEvent representa un evento emitido cuando se agrega un bloque a la cadena
+type ChainEvent struct {
+	Type       string                 `json:"type"`
+	ContractID string                 `json:"contract_id,omitempty"`
+	BlockHash  string                 `json:"block_hash"`
+	Height     int                    `json:"height"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// EventBus distribuye los eventos de la cadena a los suscriptores internos
+type EventBus struct {
+	listeners map[int]func(ChainEvent)
+	nextID    int
+	mutex     sync.RWMutex
+}
+
+// NewEventBus crea un bus de eventos sin suscriptores
+func NewEventBus() *EventBus {
+	return &EventBus{
+		listeners: make(map[int]func(ChainEvent)),
+	}
+}
+
+// Subscribe registra un listener y retorna su identificador. Los listeners se
+// ejecutan de forma síncrona, por lo que no deben bloquear.
+func (eb *EventBus) Subscribe(listener func(ChainEvent)) int {
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	eb.nextID++
+	eb.listeners[eb.nextID] = listener
+	return eb.nextID
+}
+
+// Unsubscribe elimina un listener
+func (eb *EventBus) Unsubscribe(id int) {
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+	delete(eb.listeners, id)
+}
+
+// Publish envía un evento a todos los listeners
+func (eb *EventBus) Publish(event ChainEvent) {
+	eb.mutex.RLock()
+	defer eb.mutex.RUnlock()
+
+	for _, listener := range eb.listeners {
+		listener(event)
+	}
+}
+
+// Events retorna un evento por cada transacción del bloque. Los lotes del mempool
+// generan uno por transacción; si el lote está archivado los eventos solo traen el tipo
+// y el contrato de sus transacciones.
+func (b *Block) Events() []ChainEvent {
+	if b.Type != BatchBlockType {
+		return []ChainEvent{eventFromBlock(b)}
+	}
+
+	events := make([]ChainEvent, 0, len(b.Transactions))
+	if b.Pruned {
+		for _, tx := range b.Transactions {
+			events = append(events, ChainEvent{
+				Type:       tx.Type,
+				ContractID: tx.ContractID,
+				BlockHash:  b.Hash,
+				Height:     b.Index,
+				Timestamp:  b.Timestamp,
+			})
+		}
+		return events
+	}
+	for _, entry := range eventData(b.Data) {
+		eventType, _ := entry["type"].(string)
+		contractID, _ := entry["contract_id"].(string)
+		events = append(events, ChainEvent{
+			Type:       eventType,
+			ContractID: contractID,
+			BlockHash:  b.Hash,
+			Height:     b.Index,
+			Timestamp:  b.Timestamp,
+			Data:       entry,
+		})
+	}
+	return events
+}
+
+// eventFromBlock construye el evento correspondiente a un bloque
+func eventFromBlock(block *Block) ChainEvent {
+	contractID, _ := block.Data["contract_id"].(string)
+	return ChainEvent{
+		Type:       block.Type,
+		ContractID: contractID,
+		BlockHash:  block.Hash,
+		Height:     block.Index,
+		Timestamp:  block.Timestamp,
+		Data:       block.Data,
+	}
+}
@@ -0,0 +1,268 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg" // Decodificador para el hash perceptual y codificador de miniaturas
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxEvidenceSize limita el tamaño de cada archivo de evidencia (50 MB)
+const MaxEvidenceSize = 50 << 20
+
+// Lado mayor, en píxeles, de las miniaturas de evidencia
+const (
+	DefaultThumbnailSize = 160
+	MinThumbnailSize     = 32
+	MaxThumbnailSize     = 512
+)
+
+// Evidence representa una evidencia de ejecución (foto o video) guardada fuera de la cadena
+type Evidence struct {
+	ID             string    `json:"id"`
+	ContractID     string    `json:"contract_id"`
+	UploadedBy     string    `json:"uploaded_by"`
+	FileName       string    `json:"file_name"`
+	MediaType      string    `json:"media_type"`
+	Size           int64     `json:"size"`
+	SHA256         string    `json:"sha256"`
+	PerceptualHash string    `json:"perceptual_hash,omitempty"`
+	Description    string    `json:"description"`
+	UploadedAt     time.Time `json:"uploaded_at"`
+	BlockHash      string    `json:"block_hash"`
+}
+
+// EvidenceStore guarda los archivos de evidencia en disco y ancla sus hashes en la cadena
+type EvidenceStore struct {
+	dir        string
+	blockchain *Blockchain
+}
+
+// NewEvidenceStore crea el almacén de evidencias en el directorio indicado
+func NewEvidenceStore(dir string, bc *Blockchain) (*EvidenceStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &EvidenceStore{dir: dir, blockchain: bc}, nil
+}
+
+// Upload guarda un archivo de evidencia de un supervisor y ancla su hash en la cadena
+func (es *EvidenceStore) Upload(contractID string, uploadedBy string, role AdminRole, fileName string, mediaType string, description string, content []byte) (*Evidence, error) {
+	unlock := es.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := es.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if role != RoleSupervisor {
+		return nil, errors.New("solo el supervisor del contrato puede cargar evidencias")
+	}
+	if contract.Status != StatusAwarded && contract.Status != StatusExecuted {
+		return nil, fmt.Errorf("el contrato no está en ejecución (%s)", contract.Status)
+	}
+	if len(content) == 0 {
+		return nil, errors.New("archivo vacío")
+	}
+	if len(content) > MaxEvidenceSize {
+		return nil, errors.New("el archivo supera el tamaño máximo permitido")
+	}
+	if !strings.HasPrefix(mediaType, "image/") && !strings.HasPrefix(mediaType, "video/") {
+		return nil, errors.New("solo se aceptan fotos o videos")
+	}
+
+	sum := sha256.Sum256(content)
+	evidence := &Evidence{
+		ID:          uuid.New().String(),
+		ContractID:  contractID,
+		UploadedBy:  uploadedBy,
+		FileName:    filepath.Base(fileName),
+		MediaType:   mediaType,
+		Size:        int64(len(content)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		Description: description,
+		UploadedAt:  time.Now(),
+	}
+	if strings.HasPrefix(mediaType, "image/") {
+		evidence.PerceptualHash, _ = PerceptualHash(content)
+	}
+
+	if err := os.WriteFile(es.path(evidence), content, 0644); err != nil {
+		return nil, err
+	}
+
+	blockData := map[string]interface{}{
+		"type":            "EXECUTION_EVIDENCE",
+		"contract_id":     contractID,
+		"evidence_id":     evidence.ID,
+		"uploaded_by":     uploadedBy,
+		"role":            string(role),
+		"file_name":       evidence.FileName,
+		"media_type":      mediaType,
+		"size":            evidence.Size,
+		"sha256":          evidence.SHA256,
+		"perceptual_hash": evidence.PerceptualHash,
+		"description":     description,
+		"timestamp":       evidence.UploadedAt,
+	}
+	block, err := es.blockchain.SealBlock(blockData)
+	if err != nil {
+		os.Remove(es.path(evidence))
+		return nil, err
+	}
+
+	evidence.BlockHash = block.Hash
+	contract.Evidence = append(contract.Evidence, *evidence)
+	contract.UpdatedAt = time.Now()
+	es.blockchain.WorkflowManager.addAuditEntry(contract, "EXECUTION_EVIDENCE", uploadedBy, role, "Evidencia de ejecución cargada: "+evidence.FileName)
+	es.blockchain.saveContract(contract)
+
+	return evidence, nil
+}
+
+// Open retorna el contenido de una evidencia verificando que coincida con el hash anclado
+func (es *EvidenceStore) Open(contractID string, evidenceID string) (*Evidence, []byte, error) {
+	contract, exists := es.blockchain.Contract(contractID)
+	if !exists {
+		return nil, nil, errors.New("contrato no encontrado")
+	}
+
+	for i := range contract.Evidence {
+		evidence := &contract.Evidence[i]
+		if evidence.ID != evidenceID {
+			continue
+		}
+		content, err := os.ReadFile(es.path(evidence))
+		if err != nil {
+			return nil, nil, errors.New("archivo de evidencia no disponible en este nodo")
+		}
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != evidence.SHA256 {
+			return nil, nil, errors.New("el archivo no coincide con el hash anclado en la cadena")
+		}
+		return evidence, content, nil
+	}
+	return nil, nil, errors.New("evidencia no encontrada")
+}
+
+func (es *EvidenceStore) path(evidence *Evidence) string {
+	return filepath.Join(es.dir, evidence.ID)
+}
+
+// Thumbnail retorna una miniatura JPEG de una evidencia fotográfica cuyo lado mayor mide
+// size píxeles. La miniatura se guarda junto al original para no recalcularla; como se
+// deriva de un archivo verificado contra su hash, no necesita anclarse en la cadena.
+func (es *EvidenceStore) Thumbnail(contractID string, evidenceID string, size int) ([]byte, error) {
+	if size < MinThumbnailSize || size > MaxThumbnailSize {
+		return nil, fmt.Errorf("el tamaño de la miniatura debe estar entre %d y %d píxeles", MinThumbnailSize, MaxThumbnailSize)
+	}
+
+	evidence, content, err := es.Open(contractID, evidenceID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(evidence.MediaType, "image/") {
+		return nil, errors.New("la evidencia no es una foto")
+	}
+
+	cached := fmt.Sprintf("%s.thumb-%d.jpg", es.path(evidence), size)
+	if thumbnail, err := os.ReadFile(cached); err == nil {
+		return thumbnail, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer la imagen: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaleImage(img, size), &jpeg.Options{Quality: 75}); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(cached, buf.Bytes(), 0644); err != nil {
+		logf("⚠️ No se pudo guardar la miniatura de la evidencia %s: %v\n", evidence.ID, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleImage reduce la imagen por vecino más cercano para que su lado mayor mida size
+// píxeles; las imágenes más pequeñas se conservan en su tamaño
+func scaleImage(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	targetWidth, targetHeight := size, height*size/width
+	if height > width {
+		targetWidth, targetHeight = width*size/height, size
+	}
+	if targetWidth < 1 {
+		targetWidth = 1
+	}
+	if targetHeight < 1 {
+		targetHeight = 1
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+	for y := 0; y < targetHeight; y++ {
+		for x := 0; x < targetWidth; x++ {
+			scaled.Set(x, y, img.At(bounds.Min.X+x*width/targetWidth, bounds.Min.Y+y*height/targetHeight))
+		}
+	}
+	return scaled
+}
+
+// PerceptualHash calcula un dHash de 64 bits de una imagen, estable ante
+// recompresión o cambios de tamaño, para detectar fotos reutilizadas
+func PerceptualHash(content []byte) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+
+	// Reducir a 9x8 en escala de grises muestreando por bloques
+	const width, height = 9, 8
+	bounds := img.Bounds()
+	var gray [height][width]float64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+			y0 := bounds.Min.Y + y*bounds.Dy()/height
+			y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+			var sum float64
+			var count int
+			for py := y0; py < y1 || py == y0; py++ {
+				for px := x0; px < x1 || px == x0; px++ {
+					r, g, b, _ := img.At(px, py).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+					count++
+				}
+			}
+			gray[y][x] = sum / float64(count)
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width-1; x++ {
+			hash <<= 1
+			if gray[y][x] > gray[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%016x", hash), nil
+}
@@ -0,0 +1,189 @@
+package blockchain
+
+import (
+	"sort"
+	"time"
+)
+
+// Explorador de la cadena para los portales de transparencia: bloques paginados con sus
+// transacciones decodificadas en campos tipados en lugar de los mapas crudos. De los
+// bloques que tocan contratos reservados solo se muestra el encabezado.
+
+// Tamaño de página del explorador
+const (
+	DefaultExplorerPageSize = 20
+	MaxExplorerPageSize     = 100
+)
+
+// ExplorerTransaction es una transacción decodificada
+type ExplorerTransaction struct {
+	Position    int       `json:"position"` // Posición dentro del bloque (los lotes del mempool traen varias)
+	ID          string    `json:"id,omitempty"`
+	Type        string    `json:"type"`
+	ContractID  string    `json:"contract_id,omitempty"`
+	EntityCode  string    `json:"entity_code,omitempty"`
+	EntityName  string    `json:"entity_name,omitempty"`
+	Actor       string    `json:"actor,omitempty"`
+	Role        AdminRole `json:"role,omitempty"`
+	Step        int       `json:"step,omitempty"`
+	Approved    *bool     `json:"approved,omitempty"`
+	Amount      *float64  `json:"amount,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Comments    string    `json:"comments,omitempty"`
+	Summary     string    `json:"summary,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	DataHash    string    `json:"data_hash"`
+}
+
+// ExplorerBlock es el encabezado de un bloque con sus transacciones decodificadas
+type ExplorerBlock struct {
+	Height           int                   `json:"height"`
+	Hash             string                `json:"hash"`
+	OriginHash       string                `json:"origin_hash,omitempty"` // Hash en el nodo que lo creó, si lo recibió de otro
+	PreviousHash     string                `json:"previous_hash"`
+	Timestamp        time.Time             `json:"timestamp"`
+	Type             string                `json:"type"`
+	SignerNodeID     string                `json:"signer_node_id,omitempty"`
+	MerkleRoot       string                `json:"merkle_root,omitempty"`
+	Withheld         bool                  `json:"withheld,omitempty"` // Toca un contrato reservado: sin transacciones
+	TransactionCount int                   `json:"transaction_count"`
+	Transactions     []ExplorerTransaction `json:"transactions"`
+}
+
+// ExplorerQuery pide una página de bloques, de los más recientes a los más antiguos
+// salvo que Ascending sea true
+type ExplorerQuery struct {
+	Page      int    // Desde 1
+	PageSize  int    // Hasta MaxExplorerPageSize
+	Type      string // Solo bloques de este tipo
+	Ascending bool
+}
+
+// ExplorerPage es una página de bloques del explorador
+type ExplorerPage struct {
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+	Total      int             `json:"total"`
+	TotalPages int             `json:"total_pages"`
+	Blocks     []ExplorerBlock `json:"blocks"`
+}
+
+// ExplorerBlocks retorna la página de bloques pedida
+func (bc *Blockchain) ExplorerBlocks(query ExplorerQuery) (*ExplorerPage, error) {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = DefaultExplorerPageSize
+	}
+	if query.PageSize > MaxExplorerPageSize {
+		query.PageSize = MaxExplorerPageSize
+	}
+
+	// Los encabezados de la cadena en memoria bastan para filtrar, aun los archivados
+	var heights []int
+	for height, block := range bc.Blocks() {
+		if query.Type == "" || block.Type == query.Type {
+			heights = append(heights, height)
+		}
+	}
+	if !query.Ascending {
+		sort.Sort(sort.Reverse(sort.IntSlice(heights)))
+	}
+
+	page := &ExplorerPage{
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		Total:      len(heights),
+		TotalPages: (len(heights) + query.PageSize - 1) / query.PageSize,
+		Blocks:     []ExplorerBlock{},
+	}
+	start := (query.Page - 1) * query.PageSize
+	if start >= len(heights) {
+		return page, nil
+	}
+	end := start + query.PageSize
+	if end > len(heights) {
+		end = len(heights)
+	}
+	for _, height := range heights[start:end] {
+		block, err := bc.BlockAt(height)
+		if err != nil {
+			return nil, err
+		}
+		page.Blocks = append(page.Blocks, bc.decodeBlock(block))
+	}
+	return page, nil
+}
+
+// ExplorerBlock retorna el bloque decodificado por su hash local o el de su autor
+func (bc *Blockchain) ExplorerBlock(hash string) (*ExplorerBlock, error) {
+	block, err := bc.BlockByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	decoded := bc.decodeBlock(block)
+	return &decoded, nil
+}
+
+// BlockTypeCounts cuenta los bloques de la cadena por tipo
+func (bc *Blockchain) BlockTypeCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, block := range bc.Blocks() {
+		counts[block.Type]++
+	}
+	return counts
+}
+
+// decodeBlock arma la vista del explorador de un bloque
+func (bc *Blockchain) decodeBlock(block *Block) ExplorerBlock {
+	decoded := ExplorerBlock{
+		Height:       block.Index,
+		Hash:         block.Hash,
+		PreviousHash: block.PreviousHash,
+		Timestamp:    block.Timestamp,
+		Type:         block.Type,
+		SignerNodeID: block.SignerNodeID,
+		MerkleRoot:   block.MerkleRoot,
+		Transactions: []ExplorerTransaction{},
+	}
+	if origin := originHash(block); origin != block.Hash {
+		decoded.OriginHash = origin
+	}
+	if bc.IsReservedBlock(block) {
+		decoded.Withheld = true
+		decoded.TransactionCount = len(block.Transactions)
+		return decoded
+	}
+
+	for position, entry := range eventData(block.Data) {
+		tx := ExplorerTransaction{Position: position, Timestamp: block.Timestamp, DataHash: hashData(entry)}
+		if position < len(block.Transactions) {
+			tx.ID = block.Transactions[position].ID
+		}
+		var role string
+		decodeField(entry, "type", &tx.Type)
+		decodeField(entry, "contract_id", &tx.ContractID)
+		decodeField(entry, "entity_code", &tx.EntityCode)
+		decodeField(entry, "entity_name", &tx.EntityName)
+		decodeField(entry, "role", &role)
+		decodeField(entry, "step", &tx.Step)
+		decodeField(entry, "description", &tx.Description)
+		decodeField(entry, "comments", &tx.Comments)
+		decodeField(entry, "timestamp", &tx.Timestamp)
+		if _, exists := entry["approved"]; exists {
+			tx.Approved = new(bool)
+			decodeField(entry, "approved", tx.Approved)
+		}
+		if _, exists := entry["amount"]; exists {
+			tx.Amount = new(float64)
+			decodeField(entry, "amount", tx.Amount)
+		}
+		tx.Role = AdminRole(role)
+		tx.Actor = transactionActor(entry)
+		tx.Summary = contractEventSummary(tx.Type, entry)
+		decoded.Transactions = append(decoded.Transactions, tx)
+	}
+	decoded.TransactionCount = len(decoded.Transactions)
+	return decoded
+}
@@ -0,0 +1,226 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+)
+
+// Exportaciones columnares para los equipos de analítica de los entes de control
+
+// Columnas fijas de la exportación de transacciones; los campos de los datos de cada
+// transacción se agregan después con el prefijo "data_"
+var blockExportFields = map[string]bool{"type": true, "contract_id": true, "sequence": true}
+
+// ExportContracts arma las columnas con el estado actual de los contratos
+func (bc *Blockchain) ExportContracts() []*ParquetColumn {
+	id := &ParquetColumn{Name: "id", Type: ParquetString}
+	entityCode := &ParquetColumn{Name: "entity_code", Type: ParquetString}
+	entityName := &ParquetColumn{Name: "entity_name", Type: ParquetString}
+	contractType := &ParquetColumn{Name: "contract_type", Type: ParquetString}
+	description := &ParquetColumn{Name: "description", Type: ParquetString}
+	amount := &ParquetColumn{Name: "amount", Type: ParquetDouble}
+	status := &ParquetColumn{Name: "status", Type: ParquetString}
+	createdBy := &ParquetColumn{Name: "created_by", Type: ParquetString}
+	createdAt := &ParquetColumn{Name: "created_at", Type: ParquetTimestamp}
+	updatedAt := &ParquetColumn{Name: "updated_at", Type: ParquetTimestamp}
+	currentStep := &ParquetColumn{Name: "current_step", Type: ParquetInt64}
+	totalSteps := &ParquetColumn{Name: "total_steps", Type: ParquetInt64}
+	sequence := &ParquetColumn{Name: "sequence", Type: ParquetInt64}
+	templateVersion := &ParquetColumn{Name: "template_version", Type: ParquetInt64, Optional: true}
+	processNumber := &ParquetColumn{Name: "process_number", Type: ParquetString, Optional: true}
+	awardedTo := &ParquetColumn{Name: "awarded_to", Type: ParquetString, Optional: true}
+	originSystem := &ParquetColumn{Name: "origin_system", Type: ParquetString, Optional: true}
+	retentionHold := &ParquetColumn{Name: "retention_hold", Type: ParquetBool}
+
+	contracts := make([]*Contract, 0, bc.ContractCount())
+	for _, contract := range bc.contractMap() {
+		contracts = append(contracts, contract)
+	}
+	sort.Slice(contracts, func(i, j int) bool { return contracts[i].CreatedAt.Before(contracts[j].CreatedAt) })
+
+	for _, contract := range contracts {
+		id.append(contract.ID)
+		entityCode.append(contract.EntityCode)
+		entityName.append(contract.EntityName)
+		contractType.append(contract.ContractType)
+		description.append(contract.Description)
+		amount.append(contract.Amount)
+		status.append(string(contract.Status))
+		createdBy.append(contract.CreatedBy)
+		createdAt.append(contract.CreatedAt)
+		updatedAt.append(contract.UpdatedAt)
+		currentStep.append(int64(contract.CurrentStep))
+		totalSteps.append(int64(len(contract.ValidationSteps)))
+		sequence.append(int64(contract.Sequence))
+		templateVersion.append(optionalInt(contract.TemplateVersion))
+		processNumber.append(optionalString(contract.ProcessNumber))
+		awardedTo.append(optionalString(contract.AwardedTo))
+		originSystem.append(optionalString(contract.OriginSystem))
+		retentionHold.append(contract.RetentionHold)
+	}
+
+	return []*ParquetColumn{id, entityCode, entityName, contractType, description, amount, status,
+		createdBy, createdAt, updatedAt, currentStep, totalSteps, sequence, templateVersion,
+		processNumber, awardedTo, originSystem, retentionHold}
+}
+
+// ExportBlocks arma las columnas con una fila por transacción de la cadena. Los campos de
+// los datos se vuelven columnas opcionales con el tipo inferido de sus valores, para que
+// el esquema evolucione con los tipos de transacción del protocolo: un campo nuevo agrega
+// una columna nula en las filas anteriores, y un campo cuyo tipo cambió entre versiones
+// se exporta como texto JSON. known_kind marca las transacciones que este nodo no conoce.
+func (bc *Blockchain) ExportBlocks() ([]*ParquetColumn, error) {
+	chain, err := bc.FullChain()
+	if err != nil {
+		return nil, err
+	}
+
+	height := &ParquetColumn{Name: "height", Type: ParquetInt64}
+	blockHash := &ParquetColumn{Name: "block_hash", Type: ParquetString}
+	blockType := &ParquetColumn{Name: "block_type", Type: ParquetString, Optional: true}
+	blockTimestamp := &ParquetColumn{Name: "block_timestamp", Type: ParquetTimestamp}
+	hashVersion := &ParquetColumn{Name: "hash_version", Type: ParquetInt64}
+	txIndex := &ParquetColumn{Name: "tx_index", Type: ParquetInt64}
+	txType := &ParquetColumn{Name: "tx_type", Type: ParquetString, Optional: true}
+	known := &ParquetColumn{Name: "known_kind", Type: ParquetBool}
+	contractID := &ParquetColumn{Name: "contract_id", Type: ParquetString, Optional: true}
+	sequence := &ParquetColumn{Name: "sequence", Type: ParquetInt64, Optional: true}
+
+	var rows []map[string]interface{}
+	for _, block := range chain {
+		for i, event := range block.Events() {
+			height.append(int64(block.Index))
+			blockHash.append(block.Hash)
+			blockType.append(optionalString(block.Type))
+			blockTimestamp.append(block.Timestamp)
+			hashVersion.append(int64(block.HashVersion))
+			txIndex.append(int64(i))
+			txType.append(optionalString(event.Type))
+			known.append(event.Type == "" || IsKnownTransactionKind(event.Type))
+
+			data := eventPayload(event)
+			id, _ := data["contract_id"].(string)
+			contractID.append(optionalString(id))
+			if value, ok := numericValue(data["sequence"]); ok {
+				sequence.append(int64(value))
+			} else {
+				sequence.append(nil)
+			}
+			rows = append(rows, data)
+		}
+	}
+
+	columns := []*ParquetColumn{height, blockHash, blockType, blockTimestamp, hashVersion, txIndex, txType, known, contractID, sequence}
+	return append(columns, payloadColumns(rows)...), nil
+}
+
+// payloadColumns infiere una columna por cada campo de los datos de las transacciones
+func payloadColumns(rows []map[string]interface{}) []*ParquetColumn {
+	kinds := make(map[string]string)
+	for _, row := range rows {
+		for field, value := range row {
+			if blockExportFields[field] || value == nil {
+				continue
+			}
+			kind := payloadKind(value)
+			if previous, seen := kinds[field]; seen && previous != kind {
+				if (previous == ParquetInt64 && kind == ParquetDouble) || (previous == ParquetDouble && kind == ParquetInt64) {
+					kind = ParquetDouble
+				} else {
+					kind = ParquetString
+				}
+			}
+			kinds[field] = kind
+		}
+	}
+
+	fields := make([]string, 0, len(kinds))
+	for field := range kinds {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	columns := make([]*ParquetColumn, len(fields))
+	for i, field := range fields {
+		column := &ParquetColumn{Name: "data_" + field, Type: kinds[field], Optional: true}
+		for _, row := range rows {
+			column.append(payloadValue(row[field], column.Type))
+		}
+		columns[i] = column
+	}
+	return columns
+}
+
+// payloadKind retorna el tipo de columna para un valor de los datos
+func payloadKind(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return ParquetBool
+	case int, int64:
+		return ParquetInt64
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return ParquetInt64
+		}
+		return ParquetDouble
+	}
+	return ParquetString
+}
+
+// payloadValue convierte un valor de los datos al tipo de su columna
+func payloadValue(value interface{}, kind string) interface{} {
+	if value == nil {
+		return nil
+	}
+	switch kind {
+	case ParquetBool:
+		return value
+	case ParquetInt64:
+		number, _ := numericValue(value)
+		return int64(number)
+	case ParquetDouble:
+		number, _ := numericValue(value)
+		return number
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		// Los bloques locales tienen time.Time y los retransmitidos el texto RFC 3339
+		return v.Format(time.RFC3339Nano)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return string(encoded)
+}
+
+// numericValue lee un número que puede venir como int (datos locales) o float64 (JSON)
+func numericValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func optionalString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func optionalInt(value int) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return int64(value)
+}
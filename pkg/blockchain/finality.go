@@ -0,0 +1,360 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// Cantidad de bloques recientes cuyo estado de finalización se reporta
+const finalityRecentBlocks = 10
+
+// FinalityVote es el voto firmado de un validador que aceptó un bloque. Se vota sobre el
+// hash con el que el autor creó el bloque, que es el mismo para todos los nodos.
+type FinalityVote struct {
+	BlockHash string    `json:"block_hash"`
+	Voter     string    `json:"voter"`
+	KeyID     string    `json:"kid"`
+	Signature string    `json:"signature"`
+	VotedAt   time.Time `json:"voted_at"`
+}
+
+// BlockFinality es el estado de votación de un bloque de la cadena local
+type BlockFinality struct {
+	Height      int            `json:"height"`
+	Hash        string         `json:"hash"`
+	OriginHash  string         `json:"origin_hash"` // Hash con que lo creó su autor; difiere en bloques retransmitidos
+	Votes       []FinalityVote `json:"votes"`
+	Validators  int            `json:"validators"`
+	Required    int            `json:"required"`
+	Finalized   bool           `json:"finalized"`
+	FinalizedAt *time.Time     `json:"finalized_at,omitempty"`
+}
+
+// FinalityCertificate reúne los votos con los que un bloque alcanzó el quórum, para que
+// los demás nodos lo marquen como finalizado verificándolos por su cuenta
+type FinalityCertificate struct {
+	BlockHash string         `json:"block_hash"`
+	Votes     []FinalityVote `json:"votes"`
+}
+
+// FinalitySummary resume la finalización de la cadena
+type FinalitySummary struct {
+	FinalizedHeight int             `json:"finalized_height"`
+	Pending         int             `json:"pending"`
+	Recent          []BlockFinality `json:"recent"`
+}
+
+// FinalityTracker lleva los votos de cada bloque y lo marca como finalizado cuando
+// lo aprueban 2/3 de los validadores activos
+type FinalityTracker struct {
+	blockchain *Blockchain
+	blocks     map[string]*BlockFinality // por hash de origen
+	mutex      sync.Mutex
+}
+
+// newFinalityTracker carga los bloques ya finalizados
+func newFinalityTracker(bc *Blockchain) *FinalityTracker {
+	ft := &FinalityTracker{
+		blockchain: bc,
+		blocks:     make(map[string]*BlockFinality),
+	}
+	bc.store.ForEach(storage.BucketFinality, func(key string, value []byte) error {
+		var finality BlockFinality
+		if err := json.Unmarshal(value, &finality); err == nil {
+			ft.blocks[key] = &finality
+		}
+		return nil
+	})
+	return ft
+}
+
+// QuorumSize retorna los votos necesarios para finalizar con n validadores (2/3, redondeado hacia arriba)
+func QuorumSize(validators int) int {
+	return (2*validators + 2) / 3
+}
+
+// votePayload arma el mensaje que firma un validador al votar por un bloque
+func votePayload(blockHash string) []byte {
+	return []byte("FINALIZE|" + blockHash)
+}
+
+// originHash retorna el hash con el que el autor creó el bloque
+func originHash(block *Block) string {
+	if isReceivedBlock(block) {
+		if origin, ok := block.Data["origin"].(map[string]interface{}); ok {
+			if hash, ok := origin["hash"].(string); ok && hash != "" {
+				return hash
+			}
+		}
+	}
+	return block.Hash
+}
+
+// track empieza a llevar los votos de un bloque recién agregado a la cadena
+func (ft *FinalityTracker) track(block *Block) {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+
+	origin := originHash(block)
+	if _, exists := ft.blocks[origin]; exists {
+		return
+	}
+	ft.blocks[origin] = &BlockFinality{
+		Height:     block.Index,
+		Hash:       block.Hash,
+		OriginHash: origin,
+		Votes:      []FinalityVote{},
+	}
+}
+
+// retain descarta los bloques que ya no están en la cadena, p. ej. tras adoptar la de un peer
+func (ft *FinalityTracker) retain(chain []*Block) {
+	present := make(map[string]bool, len(chain))
+	for _, block := range chain {
+		present[block.Hash] = true
+	}
+
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	for origin, finality := range ft.blocks {
+		if !present[finality.Hash] {
+			delete(ft.blocks, origin)
+			ft.blockchain.deleteState(storage.BucketFinality, origin)
+		}
+	}
+}
+
+// AddVote registra el voto de un validador, verificando su firma contra las llaves de los
+// validadores activos. Retorna true si con este voto el bloque alcanzó el quórum.
+func (ft *FinalityTracker) AddVote(vote FinalityVote, validators map[string][]PublicKeyInfo) (bool, error) {
+	keys, isValidator := validators[vote.Voter]
+	if !isValidator {
+		return false, fmt.Errorf("el nodo %s no es un validador activo", vote.Voter)
+	}
+	if err := VerifyWithKeys(keys, vote.KeyID, votePayload(vote.BlockHash), vote.Signature); err != nil {
+		return false, fmt.Errorf("voto de %s inválido: %v", vote.Voter, err)
+	}
+
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+
+	finality, exists := ft.blocks[vote.BlockHash]
+	if !exists {
+		return false, fmt.Errorf("bloque %s desconocido", vote.BlockHash)
+	}
+	for _, existing := range finality.Votes {
+		if existing.Voter == vote.Voter {
+			return false, nil
+		}
+	}
+	finality.Votes = append(finality.Votes, vote)
+	return ft.tally(finality, validators), nil
+}
+
+// tally recalcula el quórum del bloque y lo finaliza si lo alcanzó; requiere el lock
+func (ft *FinalityTracker) tally(finality *BlockFinality, validators map[string][]PublicKeyInfo) bool {
+	finality.Validators = len(validators)
+	finality.Required = QuorumSize(len(validators))
+	if finality.Finalized {
+		return false
+	}
+
+	approvals := 0
+	for _, vote := range finality.Votes {
+		if _, active := validators[vote.Voter]; active {
+			approvals++
+		}
+	}
+	if approvals < finality.Required {
+		return false
+	}
+
+	now := time.Now()
+	finality.Finalized = true
+	finality.FinalizedAt = &now
+	ft.blockchain.saveState(storage.BucketFinality, finality.OriginHash, finality)
+	logf("🏁 Bloque %d finalizado con %d/%d votos\n", finality.Height, approvals, finality.Validators)
+	return true
+}
+
+// Certificate retorna los votos de un bloque finalizado
+func (ft *FinalityTracker) Certificate(blockHash string) (*FinalityCertificate, error) {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+
+	finality, exists := ft.blocks[blockHash]
+	if !exists || !finality.Finalized {
+		return nil, errors.New("el bloque no está finalizado")
+	}
+	votes := make([]FinalityVote, len(finality.Votes))
+	copy(votes, finality.Votes)
+	return &FinalityCertificate{BlockHash: blockHash, Votes: votes}, nil
+}
+
+// Status retorna el estado de finalización de un bloque de la cadena local
+func (ft *FinalityTracker) Status(hash string) (*BlockFinality, bool) {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+
+	for _, finality := range ft.blocks {
+		if finality.Hash == hash {
+			copied := *finality
+			return &copied, true
+		}
+	}
+	return nil, false
+}
+
+// statusByOrigin retorna el estado de finalización de un bloque por el hash con que lo creó su autor
+func (ft *FinalityTracker) statusByOrigin(origin string) (*BlockFinality, bool) {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+
+	finality, exists := ft.blocks[origin]
+	if !exists {
+		return nil, false
+	}
+	copied := *finality
+	return &copied, true
+}
+
+// Summary resume la finalización: la altura del último bloque finalizado y el estado
+// de los bloques más recientes
+func (ft *FinalityTracker) Summary() FinalitySummary {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+
+	summary := FinalitySummary{Recent: []BlockFinality{}}
+	all := make([]BlockFinality, 0, len(ft.blocks))
+	for _, finality := range ft.blocks {
+		if finality.Finalized {
+			if finality.Height > summary.FinalizedHeight {
+				summary.FinalizedHeight = finality.Height
+			}
+		} else {
+			summary.Pending++
+		}
+		all = append(all, *finality)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Height > all[j].Height })
+	if len(all) > finalityRecentBlocks {
+		all = all[:finalityRecentBlocks]
+	}
+	summary.Recent = append(summary.Recent, all...)
+	return summary
+}
+
+// activeValidators retorna las llaves de los validadores que votan la finalización: en
+// prueba de autoridad el conjunto autorizado; si no, este nodo y los peers activos con
+// llaves conocidas
+func (p2p *P2PNetwork) activeValidators() map[string][]PublicKeyInfo {
+	if p2p.Blockchain.poa != nil {
+		p2p.Blockchain.poa.mutex.RLock()
+		defer p2p.Blockchain.poa.mutex.RUnlock()
+		return p2p.Blockchain.poa.current.publicKeys()
+	}
+
+	p2p.mutex.RLock()
+	defer p2p.mutex.RUnlock()
+
+	validators := make(map[string][]PublicKeyInfo)
+	if p2p.Keys != nil {
+		validators[p2p.NodeID] = p2p.Keys.PublicKeys()
+	}
+	for peerID, peer := range p2p.Peers {
+		if peer.Active && len(peer.PublicKeys) > 0 {
+			validators[peerID] = peer.PublicKeys
+		}
+	}
+	return validators
+}
+
+// Vote firma el voto de este nodo por un bloque que aceptó y lo registra localmente.
+// Retorna nil si el nodo no es validador.
+func (p2p *P2PNetwork) Vote(blockHash string) *FinalityVote {
+	if p2p.Keys == nil || !p2p.Blockchain.IsAuthority(p2p.NodeID) {
+		return nil
+	}
+	vote := FinalityVote{BlockHash: blockHash, Voter: p2p.NodeID, VotedAt: time.Now()}
+	vote.KeyID, vote.Signature = p2p.Keys.Sign(votePayload(blockHash))
+
+	// El voto propio cuenta también para el quórum local (el de un nodo único basta)
+	if finalized, err := p2p.Blockchain.Finality.AddVote(vote, p2p.activeValidators()); err == nil && finalized {
+		go p2p.broadcastCertificate(blockHash)
+	}
+	return &vote
+}
+
+// recordVote registra un voto y, si con él el bloque quedó finalizado, difunde el certificado
+func (p2p *P2PNetwork) recordVote(vote FinalityVote) {
+	finalized, err := p2p.Blockchain.Finality.AddVote(vote, p2p.activeValidators())
+	if err != nil {
+		logf("⚠️ Voto de finalización descartado: %v\n", err)
+		return
+	}
+	if finalized {
+		go p2p.broadcastCertificate(vote.BlockHash)
+	}
+}
+
+// ReceiveCertificate verifica por cuenta propia los votos de un certificado y marca el
+// bloque como finalizado si alcanzan el quórum de los validadores que este nodo conoce
+func (p2p *P2PNetwork) ReceiveCertificate(certificate FinalityCertificate) (*BlockFinality, error) {
+	validators := p2p.activeValidators()
+	for _, vote := range certificate.Votes {
+		if vote.BlockHash != certificate.BlockHash {
+			return nil, errors.New("el certificado incluye votos de otro bloque")
+		}
+		if _, err := p2p.Blockchain.Finality.AddVote(vote, validators); err != nil {
+			logf("⚠️ Voto del certificado descartado: %v\n", err)
+		}
+	}
+
+	finality, exists := p2p.Blockchain.Finality.statusByOrigin(certificate.BlockHash)
+	if !exists {
+		return nil, fmt.Errorf("bloque %s desconocido", certificate.BlockHash)
+	}
+	return finality, nil
+}
+
+// broadcastCertificate envía a los peers los votos con que se finalizó un bloque
+func (p2p *P2PNetwork) broadcastCertificate(blockHash string) {
+	certificate, err := p2p.Blockchain.Finality.Certificate(blockHash)
+	if err != nil {
+		return
+	}
+	body, err := json.Marshal(certificate)
+	if err != nil {
+		return
+	}
+
+	p2p.mutex.RLock()
+	defer p2p.mutex.RUnlock()
+	for peerID, peer := range p2p.Peers {
+		if !peer.Active || peer.Maintenance {
+			continue
+		}
+		go func(peerID string, peer *Peer) {
+			req, err := http.NewRequest(http.MethodPost, p2p.peerURL(peer, "/api/p2p/finality"), bytes.NewReader(body))
+			if err != nil {
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Node-ID", p2p.NodeID)
+			resp, err := p2p.peerClient(10 * time.Second).Do(req)
+			if err != nil {
+				logf("❌ Error enviando certificado de finalización a %s: %v\n", peerID, err)
+				return
+			}
+			resp.Body.Close()
+		}(peerID, peer)
+	}
+}
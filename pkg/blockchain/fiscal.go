@@ -0,0 +1,487 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+
+	"github.com/google/uuid"
+)
+
+// Cierre de vigencia fiscal: los pagos de los contratos, la constitución de los compromisos
+// que pasan a la vigencia siguiente y el informe de cierre por entidad, cuya huella queda
+// anclada en la cadena.
+
+// Tipos de bloque de los pagos, los compromisos que pasan de vigencia y los cierres
+const (
+	PaymentBlockType       = "CONTRACT_PAYMENT"
+	CarryoverBlockType     = "BUDGET_CARRYOVER"
+	FiscalClosingBlockType = "FISCAL_CLOSING"
+)
+
+// CarryoverKind es la forma en que un compromiso sin pagar pasa a la vigencia siguiente
+type CarryoverKind string
+
+const (
+	// CarryoverBudgetReserve: el bien o servicio aún no se recibió al cierre
+	CarryoverBudgetReserve CarryoverKind = "RESERVA_PRESUPUESTAL"
+	// CarryoverPayable: el bien o servicio se recibió pero no se alcanzó a pagar
+	CarryoverPayable CarryoverKind = "CUENTA_POR_PAGAR"
+)
+
+// ContractPayment es un pago registrado sobre un contrato adjudicado
+type ContractPayment struct {
+	ID           string    `json:"id"`
+	Amount       float64   `json:"amount"`
+	Reference    string    `json:"reference,omitempty"`    // p. ej. número de la orden de pago
+	MilestoneID  string    `json:"milestone_id,omitempty"` // Hito que paga el acta de pago, si la hay
+	PaidAt       time.Time `json:"paid_at"`
+	RegisteredBy string    `json:"registered_by"`
+	BlockHash    string    `json:"block_hash"`
+}
+
+// Carryover marca el saldo sin pagar de un contrato al cierre de una vigencia como
+// compromiso que pasa a la siguiente
+type Carryover struct {
+	FiscalYear    int           `json:"fiscal_year"`
+	Kind          CarryoverKind `json:"kind"`
+	Amount        float64       `json:"amount"`
+	Justification string        `json:"justification"`
+	MarkedBy      string        `json:"marked_by"`
+	MarkedAt      time.Time     `json:"marked_at"`
+	BlockHash     string        `json:"block_hash"`
+}
+
+// ClosingContract es un contrato que pasa de vigencia con saldo por pagar
+type ClosingContract struct {
+	ContractID  string     `json:"contract_id"`
+	CommittedAt time.Time  `json:"committed_at"`
+	Committed   float64    `json:"committed"`
+	Paid        float64    `json:"paid"`
+	Pending     float64    `json:"pending"`
+	Carryover   *Carryover `json:"carryover,omitempty"` // nil: el saldo no se constituyó
+}
+
+// EntityClosing es el cierre de la vigencia de una entidad. Lo comprometido y lo pagado
+// son los compromisos adquiridos en la vigencia; lo pagado de vigencias anteriores son los
+// pagos de la vigencia sobre compromisos que venían de antes.
+type EntityClosing struct {
+	EntityCode        string            `json:"entity_code"`
+	EntityName        string            `json:"entity_name"`
+	Committed         float64           `json:"committed"`
+	Paid              float64           `json:"paid"`
+	Pending           float64           `json:"pending"`
+	PaidFromPrevious  float64           `json:"paid_from_previous"`
+	BudgetReserves    float64           `json:"budget_reserves"`
+	Payables          float64           `json:"payables"`
+	Unconstituted     float64           `json:"unconstituted"` // Saldos que pasan de vigencia sin constituirse
+	CrossingContracts []ClosingContract `json:"crossing_contracts"`
+}
+
+// FiscalClosingReport es el informe de cierre de una vigencia para todas las entidades
+type FiscalClosingReport struct {
+	FiscalYear int             `json:"fiscal_year"`
+	ClosedBy   string          `json:"closed_by"`
+	ClosedAt   time.Time       `json:"closed_at"`
+	Height     int             `json:"height"`
+	Entities   []EntityClosing `json:"entities"`
+	Digest     string          `json:"digest"`
+	BlockHash  string          `json:"block_hash"`
+}
+
+// ForEntity retorna una copia del informe solo con la entidad indicada
+func (report *FiscalClosingReport) ForEntity(entityCode string) *FiscalClosingReport {
+	filtered := *report
+	filtered.Entities = []EntityClosing{}
+	for _, entity := range report.Entities {
+		if entity.EntityCode == entityCode {
+			filtered.Entities = append(filtered.Entities, entity)
+		}
+	}
+	return &filtered
+}
+
+// digest calcula la huella anclada en la cadena sobre la codificación canónica del informe
+// sin la huella ni el bloque que la ancla
+func (report *FiscalClosingReport) digest() (string, error) {
+	unsigned := *report
+	unsigned.Digest = ""
+	unsigned.BlockHash = ""
+	encoded, err := canonicalEncode(&unsigned)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// fiscalYearEnd retorna el último instante de la vigencia
+func fiscalYearEnd(year int) time.Time {
+	return time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+}
+
+// PaidThrough suma los pagos del contrato hasta el instante indicado
+func (c *Contract) PaidThrough(until time.Time) float64 {
+	paid := 0.0
+	for _, payment := range c.Payments {
+		if !payment.PaidAt.After(until) {
+			paid += payment.Amount
+		}
+	}
+	return paid
+}
+
+// carryoverFor retorna el compromiso constituido al cierre de la vigencia, o nil
+func (c *Contract) carryoverFor(year int) *Carryover {
+	for i := range c.Carryovers {
+		if c.Carryovers[i].FiscalYear == year {
+			carryover := c.Carryovers[i]
+			return &carryover
+		}
+	}
+	return nil
+}
+
+// FiscalLedger registra los pagos y los compromisos que pasan de vigencia y genera los
+// informes de cierre
+type FiscalLedger struct {
+	blockchain *Blockchain
+	reports    map[int]*FiscalClosingReport
+	mutex      sync.Mutex
+}
+
+// NewFiscalLedger crea el registro y carga los informes de cierre guardados
+func NewFiscalLedger(bc *Blockchain) *FiscalLedger {
+	fl := &FiscalLedger{
+		blockchain: bc,
+		reports:    make(map[int]*FiscalClosingReport),
+	}
+	bc.store.ForEach(storage.BucketFiscalClosing, func(key string, value []byte) error {
+		var report FiscalClosingReport
+		if err := json.Unmarshal(value, &report); err == nil {
+			fl.reports[report.FiscalYear] = &report
+		}
+		return nil
+	})
+	return fl
+}
+
+// isClosed indica si la vigencia ya tiene informe de cierre
+func (fl *FiscalLedger) isClosed(year int) bool {
+	fl.mutex.Lock()
+	defer fl.mutex.Unlock()
+	_, closed := fl.reports[year]
+	return closed
+}
+
+// RegisterPayment registra un pago sobre un contrato adjudicado. Sin fecha se toma la
+// actual; no se aceptan pagos en vigencias cerradas ni que superen el valor del contrato.
+func (fl *FiscalLedger) RegisterPayment(contractID string, amount float64, reference string, paidAt time.Time, registeredBy string, role AdminRole) (*ContractPayment, error) {
+	unlock := fl.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := fl.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	paidAt, err = fl.checkPayment(contract, amount, paidAt, now)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &ContractPayment{
+		ID:           uuid.New().String(),
+		Amount:       amount,
+		Reference:    reference,
+		PaidAt:       paidAt,
+		RegisteredBy: registeredBy,
+	}
+	blockData := map[string]interface{}{
+		"type":          PaymentBlockType,
+		"contract_id":   contractID,
+		"payment_id":    payment.ID,
+		"amount":        amount,
+		"reference":     reference,
+		"paid_at":       paidAt,
+		"registered_by": registeredBy,
+		"role":          string(role),
+		"timestamp":     now,
+	}
+	block, err := fl.blockchain.SealBlock(blockData)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.BlockHash = block.Hash
+	contract.Payments = append(contract.Payments, *payment)
+	contract.UpdatedAt = now
+	fl.blockchain.WorkflowManager.addAuditEntry(contract, PaymentBlockType, registeredBy, role, payment.description())
+	fl.blockchain.saveContract(contract)
+
+	logf("💵 Pago de %.2f registrado en el contrato %s\n", amount, contractID)
+	return payment, nil
+}
+
+// checkPayment verifica que el pago pueda registrarse sobre el contrato y retorna su fecha
+func (fl *FiscalLedger) checkPayment(contract *Contract, amount float64, paidAt time.Time, now time.Time) (time.Time, error) {
+	committedAt, committed := contractAwardedAt(contract)
+	if !committed || contract.Status == StatusRejected {
+		return paidAt, errors.New("solo se registran pagos de contratos adjudicados")
+	}
+	if amount <= 0 {
+		return paidAt, errors.New("el valor del pago debe ser positivo")
+	}
+	if paidAt.IsZero() {
+		paidAt = now
+	}
+	if paidAt.After(now) || paidAt.Before(committedAt) {
+		return paidAt, errors.New("la fecha del pago debe estar entre la adjudicación y hoy")
+	}
+	if fl.isClosed(paidAt.Year()) {
+		return paidAt, fmt.Errorf("la vigencia %d ya está cerrada", paidAt.Year())
+	}
+	if paid := contract.PaidThrough(now); paid+amount > contract.TotalValue() {
+		return paidAt, fmt.Errorf("el pago supera el saldo del contrato (%.2f por pagar)", contract.TotalValue()-paid)
+	}
+	return paidAt, nil
+}
+
+// description resume el pago para la línea de auditoría
+func (payment *ContractPayment) description() string {
+	if payment.Reference == "" {
+		return fmt.Sprintf("Pago registrado por %.2f", payment.Amount)
+	}
+	return fmt.Sprintf("Pago registrado por %.2f (%s)", payment.Amount, payment.Reference)
+}
+
+// MarkCarryover constituye el saldo sin pagar del contrato al cierre de la vigencia como
+// reserva presupuestal o cuenta por pagar. Debe hacerse antes de cerrar la vigencia.
+func (fl *FiscalLedger) MarkCarryover(contractID string, year int, kind CarryoverKind, justification string, markedBy string, role AdminRole) (*Carryover, error) {
+	unlock := fl.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := fl.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if kind != CarryoverBudgetReserve && kind != CarryoverPayable {
+		return nil, fmt.Errorf("tipo de compromiso inválido: %s", kind)
+	}
+	if justification == "" {
+		return nil, errors.New("la justificación es requerida")
+	}
+	if fl.isClosed(year) {
+		return nil, fmt.Errorf("la vigencia %d ya está cerrada", year)
+	}
+	committedAt, committed := contractAwardedAt(contract)
+	if !committed || contract.Status == StatusRejected || committedAt.Year() > year {
+		return nil, fmt.Errorf("el contrato no tiene compromisos en la vigencia %d", year)
+	}
+	if contract.carryoverFor(year) != nil {
+		return nil, fmt.Errorf("el saldo del contrato ya se constituyó al cierre de %d", year)
+	}
+	pending := contract.TotalValue() - contract.PaidThrough(fiscalYearEnd(year))
+	if pending <= 0 {
+		return nil, fmt.Errorf("el contrato no tiene saldo por pagar al cierre de %d", year)
+	}
+
+	now := time.Now()
+	carryover := &Carryover{
+		FiscalYear:    year,
+		Kind:          kind,
+		Amount:        pending,
+		Justification: justification,
+		MarkedBy:      markedBy,
+		MarkedAt:      now,
+	}
+	blockData := map[string]interface{}{
+		"type":          CarryoverBlockType,
+		"contract_id":   contractID,
+		"fiscal_year":   year,
+		"kind":          string(kind),
+		"amount":        pending,
+		"justification": justification,
+		"marked_by":     markedBy,
+		"role":          string(role),
+		"timestamp":     now,
+	}
+	block, err := fl.blockchain.SealBlock(blockData)
+	if err != nil {
+		return nil, err
+	}
+
+	carryover.BlockHash = block.Hash
+	contract.Carryovers = append(contract.Carryovers, *carryover)
+	contract.UpdatedAt = now
+	fl.blockchain.WorkflowManager.addAuditEntry(contract, CarryoverBlockType, markedBy, role, carryover.description())
+	fl.blockchain.saveContract(contract)
+
+	logf("📒 Saldo de %.2f del contrato %s constituido como %s de %d\n", pending, contractID, kind, year)
+	return carryover, nil
+}
+
+// description resume el compromiso para la línea de auditoría
+func (carryover *Carryover) description() string {
+	return fmt.Sprintf("Saldo de %.2f constituido como %s al cierre de %d: %s", carryover.Amount, carryover.Kind, carryover.FiscalYear, carryover.Justification)
+}
+
+// Close genera el informe de cierre de la vigencia, lo guarda y ancla su huella en la
+// cadena. Cada vigencia se cierra una vez y no antes de diciembre; después del cierre no
+// se aceptan pagos ni compromisos de esa vigencia.
+func (fl *FiscalLedger) Close(year int, closedBy string) (*FiscalClosingReport, error) {
+	now := time.Now()
+	if year > now.Year() || (year == now.Year() && now.Month() < time.December) {
+		return nil, fmt.Errorf("la vigencia %d solo se puede cerrar a partir de diciembre", year)
+	}
+	if fl.isClosed(year) {
+		return nil, fmt.Errorf("la vigencia %d ya está cerrada", year)
+	}
+
+	report := &FiscalClosingReport{
+		FiscalYear: year,
+		ClosedBy:   closedBy,
+		ClosedAt:   now,
+		Height:     fl.blockchain.Len() - 1,
+		Entities:   []EntityClosing{},
+	}
+	from := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := fiscalYearEnd(year)
+
+	byEntity := make(map[string]*EntityClosing)
+	for _, contract := range fl.blockchain.contractMap() {
+		committedAt, committed := contractAwardedAt(contract)
+		if !committed || contract.Status == StatusRejected || committedAt.After(end) {
+			continue
+		}
+		entity, exists := byEntity[contract.EntityCode]
+		if !exists {
+			entity = &EntityClosing{EntityCode: contract.EntityCode, EntityName: contract.EntityName, CrossingContracts: []ClosingContract{}}
+			byEntity[contract.EntityCode] = entity
+		}
+
+		paid := contract.PaidThrough(end)
+		if committedAt.Before(from) {
+			entity.PaidFromPrevious += paid - contract.PaidThrough(from.Add(-time.Nanosecond))
+		} else {
+			entity.Committed += contract.TotalValue()
+			entity.Paid += paid
+		}
+		if paid >= contract.TotalValue() {
+			continue
+		}
+
+		crossing := ClosingContract{
+			ContractID:  contract.ID,
+			CommittedAt: committedAt,
+			Committed:   contract.TotalValue(),
+			Paid:        paid,
+			Pending:     contract.TotalValue() - paid,
+			Carryover:   contract.carryoverFor(year),
+		}
+		switch {
+		case crossing.Carryover == nil:
+			entity.Unconstituted += crossing.Pending
+		case crossing.Carryover.Kind == CarryoverBudgetReserve:
+			entity.BudgetReserves += crossing.Carryover.Amount
+		default:
+			entity.Payables += crossing.Carryover.Amount
+		}
+		entity.CrossingContracts = append(entity.CrossingContracts, crossing)
+	}
+
+	for _, entity := range byEntity {
+		entity.Pending = entity.Committed - entity.Paid
+		sort.Slice(entity.CrossingContracts, func(i, j int) bool {
+			return entity.CrossingContracts[i].ContractID < entity.CrossingContracts[j].ContractID
+		})
+		// Las entidades sin compromisos ni pagos en la vigencia no aparecen en el cierre
+		if entity.Committed == 0 && entity.PaidFromPrevious == 0 && len(entity.CrossingContracts) == 0 {
+			continue
+		}
+		report.Entities = append(report.Entities, *entity)
+	}
+	sort.Slice(report.Entities, func(i, j int) bool { return report.Entities[i].EntityCode < report.Entities[j].EntityCode })
+
+	digest, err := report.digest()
+	if err != nil {
+		return nil, err
+	}
+	report.Digest = digest
+
+	blockData := map[string]interface{}{
+		"type":        FiscalClosingBlockType,
+		"fiscal_year": year,
+		"digest":      digest,
+		"entities":    len(report.Entities),
+		"closed_by":   closedBy,
+		"timestamp":   now,
+	}
+	block, err := fl.blockchain.SealBlock(blockData)
+	if err != nil {
+		return nil, err
+	}
+	report.BlockHash = block.Hash
+
+	fl.mutex.Lock()
+	fl.reports[year] = report
+	fl.mutex.Unlock()
+	fl.blockchain.saveState(storage.BucketFiscalClosing, strconv.Itoa(year), report)
+
+	logf("📒 Vigencia %d cerrada para %d entidades (huella %s)\n", year, len(report.Entities), digest)
+	return report, nil
+}
+
+// Report retorna el informe de cierre de la vigencia
+func (fl *FiscalLedger) Report(year int) (*FiscalClosingReport, bool) {
+	fl.mutex.Lock()
+	defer fl.mutex.Unlock()
+	report, exists := fl.reports[year]
+	return report, exists
+}
+
+// Years lista las vigencias cerradas, la más reciente primero
+func (fl *FiscalLedger) Years() []int {
+	fl.mutex.Lock()
+	defer fl.mutex.Unlock()
+
+	years := make([]int, 0, len(fl.reports))
+	for year := range fl.reports {
+		years = append(years, year)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+	return years
+}
+
+// Verify recalcula la huella del informe guardado y la compara con la anclada en la cadena
+func (fl *FiscalLedger) Verify(year int) error {
+	report, exists := fl.Report(year)
+	if !exists {
+		return fmt.Errorf("la vigencia %d no tiene informe de cierre", year)
+	}
+	digest, err := report.digest()
+	if err != nil {
+		return err
+	}
+	if digest != report.Digest {
+		return errors.New("el informe de cierre no coincide con su huella")
+	}
+	block, err := fl.blockchain.BlockByHash(report.BlockHash)
+	if err != nil {
+		return err
+	}
+	for _, entry := range eventData(block.Data) {
+		if entry["type"] == FiscalClosingBlockType && entry["digest"] == digest {
+			return nil
+		}
+	}
+	return errors.New("la huella del informe no está anclada en la cadena")
+}
@@ -0,0 +1,399 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Límites del manejo de bifurcaciones
+const (
+	MaxOrphanBlocks    = 256              // Bloques huérfanos retenidos a la espera de sus ancestros
+	OrphanTTL          = 10 * time.Minute // Tiempo tras el cual un huérfano se descarta
+	MaxForkDepth       = 64               // Ancestros que se piden al remitente para encontrar el punto común
+	MaxForkResolutions = 100              // Resoluciones que se conservan para consulta
+)
+
+// Ramas que pueden ganar una bifurcación
+const (
+	ForkWinnerLocal  = "local"
+	ForkWinnerRemote = "remote"
+)
+
+// OrphanBlock es un bloque recibido cuyo padre no es la punta de la cadena local
+type OrphanBlock struct {
+	Block      Block     `json:"block"`
+	Sender     string    `json:"sender"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// ForkResolution registra cómo se resolvió una bifurcación entre la cadena local y la de un peer
+type ForkResolution struct {
+	ResolvedAt   time.Time `json:"resolved_at"`
+	Sender       string    `json:"sender"`
+	ForkHeight   int       `json:"fork_height"` // Altura del último bloque común
+	LocalBranch  []string  `json:"local_branch"`
+	RemoteBranch []string  `json:"remote_branch"`
+	Winner       string    `json:"winner"`
+	Reason       string    `json:"reason"`
+}
+
+// ForkManager guarda los bloques huérfanos y el historial de bifurcaciones resueltas.
+// Las resoluciones se hacen de a una para no reorganizar la cadena en paralelo.
+type ForkManager struct {
+	orphans     map[string]*OrphanBlock
+	resolutions []ForkResolution
+	mutex       sync.RWMutex
+	resolving   sync.Mutex
+}
+
+// NewForkManager crea un administrador de bifurcaciones vacío
+func NewForkManager() *ForkManager {
+	return &ForkManager{orphans: make(map[string]*OrphanBlock)}
+}
+
+// addOrphan guarda un bloque huérfano descartando los vencidos y, si se supera el
+// límite, el más antiguo
+func (fm *ForkManager) addOrphan(block Block, sender string) {
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+
+	now := time.Now()
+	for hash, orphan := range fm.orphans {
+		if now.Sub(orphan.ReceivedAt) > OrphanTTL {
+			delete(fm.orphans, hash)
+		}
+	}
+	if _, exists := fm.orphans[block.Hash]; !exists && len(fm.orphans) >= MaxOrphanBlocks {
+		oldest := ""
+		for hash, orphan := range fm.orphans {
+			if oldest == "" || orphan.ReceivedAt.Before(fm.orphans[oldest].ReceivedAt) {
+				oldest = hash
+			}
+		}
+		delete(fm.orphans, oldest)
+	}
+	fm.orphans[block.Hash] = &OrphanBlock{Block: block, Sender: sender, ReceivedAt: now}
+}
+
+// removeOrphan saca un bloque del depósito de huérfanos
+func (fm *ForkManager) removeOrphan(hash string) {
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+	delete(fm.orphans, hash)
+}
+
+// Orphans lista los bloques huérfanos en espera, del más antiguo al más reciente
+func (fm *ForkManager) Orphans() []OrphanBlock {
+	fm.mutex.RLock()
+	defer fm.mutex.RUnlock()
+
+	orphans := make([]OrphanBlock, 0, len(fm.orphans))
+	for _, orphan := range fm.orphans {
+		orphans = append(orphans, *orphan)
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].ReceivedAt.Before(orphans[j].ReceivedAt) })
+	return orphans
+}
+
+// Resolutions lista las bifurcaciones resueltas, de la más reciente a la más antigua
+func (fm *ForkManager) Resolutions() []ForkResolution {
+	fm.mutex.RLock()
+	defer fm.mutex.RUnlock()
+
+	resolutions := make([]ForkResolution, len(fm.resolutions))
+	for i, resolution := range fm.resolutions {
+		resolutions[len(fm.resolutions)-1-i] = resolution
+	}
+	return resolutions
+}
+
+// record guarda una resolución en el historial
+func (fm *ForkManager) record(resolution ForkResolution) {
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+
+	resolution.ResolvedAt = time.Now()
+	fm.resolutions = append(fm.resolutions, resolution)
+	if len(fm.resolutions) > MaxForkResolutions {
+		fm.resolutions = fm.resolutions[len(fm.resolutions)-MaxForkResolutions:]
+	}
+}
+
+// blockIndex retorna la posición en la cadena local del bloque con el hash dado, ya sea
+// el hash local o el que le dio su autor si llegó retransmitido; -1 si no está
+func (bc *Blockchain) blockIndex(hash string) int {
+	chain := bc.Blocks()
+	i, exists := bc.hashIndex.lookup(hash)
+	if !exists || i >= len(chain) {
+		return -1
+	}
+	// El índice puede quedar atrás de una cadena que se está reemplazando
+	if chain[i].Hash != hash && originHash(chain[i]) != hash {
+		return -1
+	}
+	return i
+}
+
+// extendsTip indica si el bloque recibido se encadena sobre la punta local, directamente
+// o sobre el bloque original del que la punta es una retransmisión
+func (bc *Blockchain) extendsTip(block Block) bool {
+	if bc.Len() == 0 {
+		return true
+	}
+	tip := bc.getLatestBlock()
+	return block.PreviousHash == tip.Hash || block.PreviousHash == originHash(tip)
+}
+
+// Ancestors retorna hasta limit bloques de la cadena local que terminan en el bloque
+// con el hash dado, del más antiguo al más reciente
+func (bc *Blockchain) Ancestors(hash string, limit int) ([]Block, error) {
+	index := bc.blockIndex(hash)
+	if index < 0 {
+		return nil, errors.New("bloque no encontrado")
+	}
+	if limit <= 0 || limit > MaxForkDepth {
+		limit = MaxForkDepth
+	}
+	start := index - limit + 1
+	if start < 0 {
+		start = 0
+	}
+
+	blocks := make([]Block, 0, index-start+1)
+	for _, block := range bc.Blocks()[start : index+1] {
+		blocks = append(blocks, *block)
+	}
+	return blocks, nil
+}
+
+// preferRemoteBranch decide entre dos ramas que salen del mismo ancestro: gana la más
+// larga y, a igual longitud, la que empieza con el menor hash de origen. Como el hash de
+// origen es el mismo en todos los nodos, todos eligen la misma rama.
+func preferRemoteBranch(local []*Block, remote []Block) (bool, string) {
+	if len(remote) != len(local) {
+		if len(remote) > len(local) {
+			return true, "rama más larga"
+		}
+		return false, "rama más larga"
+	}
+	if originHash(&remote[0]) < originHash(local[0]) {
+		return true, "misma longitud, menor hash de origen"
+	}
+	return false, "misma longitud, menor hash de origen"
+}
+
+// handleOrphan procesa un bloque cuyo padre no es la punta local: lo guarda como
+// huérfano, pide al remitente los ancestros que falten hasta un bloque común y resuelve
+// la bifurcación. Retorna nil si el bloque quedó en espera o perdió frente a la rama
+// local, porque el remitente no envió nada inválido.
+func (p2p *P2PNetwork) handleOrphan(block Block, sender string) error {
+	p2p.Forks.addOrphan(block, sender)
+	logf("🧩 Bloque %s de %s no extiende la punta local, guardado como huérfano\n", block.Hash, sender)
+
+	p2p.Forks.resolving.Lock()
+	defer p2p.Forks.resolving.Unlock()
+
+	// Otra resolución pudo haberlo incorporado mientras esperaba
+	if p2p.Blockchain.HasBlock(block.Hash) {
+		p2p.Forks.removeOrphan(block.Hash)
+		return nil
+	}
+
+	// Si el bloque está adelante de la punta, este nodo se quedó atrás: no es una
+	// bifurcación sino bloques que faltan, y se piden de una vez a quien lo envió
+	if p2p.isAhead(block) {
+		p2p.scheduleCatchUp(sender, block)
+		return nil
+	}
+
+	branch, forkIndex, err := p2p.remoteBranch(block, sender)
+	if err != nil {
+		logf("⏳ Huérfano %s en espera: %v\n", block.Hash, err)
+		return nil
+	}
+	if branch == nil {
+		// Sin ancestro común reciente: la cadena del peer se evalúa completa
+		logf("🔄 Sin ancestro común con %s en los últimos %d bloques, sincronizando\n", sender, MaxForkDepth)
+		p2p.SyncWithPeers()
+		if p2p.Blockchain.HasBlock(block.Hash) {
+			p2p.Forks.removeOrphan(block.Hash)
+		}
+		return nil
+	}
+
+	// Validar la rama completa antes de tocar la cadena
+	for _, candidate := range branch {
+		if reason := p2p.invalidBlockReason(candidate, sender); reason != "" {
+			p2p.Forks.removeOrphan(block.Hash)
+			p2p.rejectInvalidBlock(candidate, sender, reason)
+			return fmt.Errorf("rama de %s inválida en el bloque %s: %s", sender, candidate.Hash, reason)
+		}
+	}
+
+	if err := p2p.resolveFork(branch, forkIndex, sender); err != nil {
+		return err
+	}
+	p2p.Forks.removeOrphan(block.Hash)
+	p2p.connectOrphans()
+	return nil
+}
+
+// remoteBranch arma la rama del remitente que termina en el bloque huérfano, desde el
+// bloque siguiente al último que ambas cadenas comparten. Retorna una rama nil si no hay
+// ancestro común dentro de MaxForkDepth.
+func (p2p *P2PNetwork) remoteBranch(block Block, sender string) ([]Block, int, error) {
+	if parent := p2p.Blockchain.blockIndex(block.PreviousHash); parent >= 0 {
+		return []Block{block}, parent, nil
+	}
+
+	p2p.mutex.RLock()
+	peer, exists := p2p.Peers[sender]
+	p2p.mutex.RUnlock()
+	if !exists {
+		return nil, 0, fmt.Errorf("remitente %s desconocido, no se pueden pedir los ancestros", sender)
+	}
+
+	ancestors, err := p2p.requestAncestors(peer, block.PreviousHash, MaxForkDepth)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error pidiendo ancestros a %s: %v", sender, err)
+	}
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		index := p2p.Blockchain.blockIndex(ancestors[i].Hash)
+		if index < 0 {
+			index = p2p.Blockchain.blockIndex(originHash(&ancestors[i]))
+		}
+		if index < 0 {
+			continue
+		}
+
+		linked := append(ancestors[i:], block)
+		for j := 1; j < len(linked); j++ {
+			if linked[j].PreviousHash != linked[j-1].Hash {
+				return nil, 0, fmt.Errorf("ancestros de %s no encadenados", sender)
+			}
+		}
+		return linked[1:], index, nil
+	}
+	return nil, 0, nil
+}
+
+// resolveFork aplica la rama remota sobre el ancestro común si gana frente a la rama
+// local; nunca se revierten bloques ya finalizados por el quórum de validadores ni
+// bloques anteriores al último checkpoint
+func (p2p *P2PNetwork) resolveFork(branch []Block, forkIndex int, sender string) error {
+	bc := p2p.Blockchain
+	local := bc.Blocks()[forkIndex+1:]
+
+	resolution := ForkResolution{
+		Sender:     sender,
+		ForkHeight: forkIndex,
+		Winner:     ForkWinnerRemote,
+	}
+	for _, block := range local {
+		resolution.LocalBranch = append(resolution.LocalBranch, block.Hash)
+	}
+	for _, block := range branch {
+		resolution.RemoteBranch = append(resolution.RemoteBranch, block.Hash)
+	}
+
+	if len(local) == 0 {
+		// La cadena local solo estaba atrasada: no hay bifurcación que resolver
+		for _, block := range branch {
+			if err := p2p.appendReceived(block); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	remoteWins, reason := preferRemoteBranch(local, branch)
+	for _, block := range local {
+		if finality, exists := bc.Finality.Status(block.Hash); exists && finality.Finalized {
+			remoteWins, reason = false, "la rama local tiene bloques finalizados"
+			break
+		}
+	}
+	if forkIndex < bc.checkpointedHeight() {
+		remoteWins, reason = false, "la bifurcación es anterior al último checkpoint"
+	}
+	resolution.Reason = reason
+	if !remoteWins {
+		resolution.Winner = ForkWinnerLocal
+		p2p.Forks.record(resolution)
+		logf("🍴 Bifurcación en la altura %d con %s: se conserva la rama local (%s)\n", forkIndex, sender, reason)
+		return nil
+	}
+
+	logf("🍴 Bifurcación en la altura %d con %s: se adopta la rama remota (%s), %d bloques locales descartados\n",
+		forkIndex, sender, reason, len(local))
+	previous := bc.Blocks()
+	if err := bc.ReplaceChain(previous[:forkIndex+1]); err != nil {
+		return fmt.Errorf("error revirtiendo la rama local: %v", err)
+	}
+	for _, block := range branch {
+		if err := p2p.appendReceived(block); err != nil {
+			// Volver a la cadena anterior para no quedar a mitad de camino
+			if restoreErr := bc.ReplaceChain(previous); restoreErr != nil {
+				logf("❌ Error restaurando la cadena tras una bifurcación fallida: %v\n", restoreErr)
+			}
+			p2p.rebuildContractsFromChain()
+			return fmt.Errorf("error aplicando la rama de %s: %v", sender, err)
+		}
+	}
+	p2p.rebuildContractsFromChain()
+	p2p.Forks.record(resolution)
+	return nil
+}
+
+// connectOrphans incorpora los huérfanos que quedaron encadenados a la nueva punta
+func (p2p *P2PNetwork) connectOrphans() {
+	for {
+		connected := false
+		for _, orphan := range p2p.Forks.Orphans() {
+			if p2p.Blockchain.HasBlock(orphan.Block.Hash) {
+				p2p.Forks.removeOrphan(orphan.Block.Hash)
+				continue
+			}
+			if !p2p.Blockchain.extendsTip(orphan.Block) {
+				continue
+			}
+			if reason := p2p.invalidBlockReason(orphan.Block, orphan.Sender); reason != "" {
+				p2p.rejectInvalidBlock(orphan.Block, orphan.Sender, reason)
+			} else if err := p2p.appendReceived(orphan.Block); err != nil {
+				logf("❌ Error incorporando huérfano %s: %v\n", orphan.Block.Hash, err)
+			} else {
+				connected = true
+			}
+			p2p.Forks.removeOrphan(orphan.Block.Hash)
+		}
+		if !connected {
+			return
+		}
+	}
+}
+
+// requestAncestors pide a un peer los bloques que terminan en el hash indicado
+func (p2p *P2PNetwork) requestAncestors(peer *Peer, hash string, limit int) ([]Block, error) {
+	resp, err := p2p.peerGet(peer, fmt.Sprintf("/api/p2p/ancestors/%s?limit=%d", hash, limit), 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer respondió con status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Blocks []Block `json:"blocks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response.Blocks, nil
+}
@@ -0,0 +1,241 @@
+package blockchain
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Difusión de bloques por gossip: cada nodo envía los bloques nuevos a un subconjunto
+// aleatorio de sus peers y estos los reenvían a otro subconjunto, así un bloque llega a
+// nodos que no son peers directos de quien lo creó. Los hashes ya vistos se recuerdan
+// para cortar los ciclos.
+
+// Parámetros por defecto del gossip
+const (
+	DefaultGossipFanout = 3     // Peers a los que se envía cada bloque
+	gossipSeenCapacity  = 10000 // Hashes de bloques vistos que se recuerdan
+)
+
+// seenCache recuerda los últimos hashes de bloques vistos, descartando los más antiguos
+type seenCache struct {
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	mutex    sync.Mutex
+}
+
+func newSeenCache(capacity int) *seenCache {
+	return &seenCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// contains indica si el hash ya se vio
+func (sc *seenCache) contains(hash string) bool {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	_, exists := sc.entries[hash]
+	return exists
+}
+
+// markSeen registra el hash y retorna true si ya se había visto
+func (sc *seenCache) markSeen(hash string) bool {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+
+	if element, exists := sc.entries[hash]; exists {
+		sc.order.MoveToFront(element)
+		return true
+	}
+	sc.entries[hash] = sc.order.PushFront(hash)
+	if sc.order.Len() > sc.capacity {
+		oldest := sc.order.Back()
+		sc.order.Remove(oldest)
+		delete(sc.entries, oldest.Value.(string))
+	}
+	return false
+}
+
+// gossipPeers elige al azar hasta GossipFanout peers activos, omitiendo los indicados;
+// con GossipFanout en 0 o menos se eligen todos
+func (p2p *P2PNetwork) gossipPeers(exclude ...string) map[string]*Peer {
+	p2p.mutex.RLock()
+	var ids []string
+	for peerID, peer := range p2p.Peers {
+		if !peer.Active || peer.Maintenance || containsString(exclude, peerID) || p2p.Reputation.IsBanned(peerID) {
+			continue
+		}
+		ids = append(ids, peerID)
+	}
+	rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	if p2p.GossipFanout > 0 && len(ids) > p2p.GossipFanout {
+		ids = ids[:p2p.GossipFanout]
+	}
+
+	peers := make(map[string]*Peer, len(ids))
+	for _, peerID := range ids {
+		peers[peerID] = p2p.Peers[peerID]
+	}
+	p2p.mutex.RUnlock()
+	return peers
+}
+
+// forwardBlock reenvía un bloque recibido a otros peers, sin devolverlo a quien lo envió
+// ni a quien lo creó. Los que no lo reciban lo obtendrán en la sincronización periódica.
+func (p2p *P2PNetwork) forwardBlock(block Block, sender string) {
+	peers := p2p.gossipPeers(sender, block.SignerNodeID)
+	if len(peers) == 0 {
+		return
+	}
+	logf("🗣️ Reenviando bloque %s a %d peers\n", block.Hash, len(peers))
+	if err := p2p.sendToPeers(block, peers); err != nil {
+		logf("⚠️ %v\n", err)
+	}
+}
+
+// learnSignerKeys pide al peer que retransmitió un bloque las llaves que conoce de su
+// firmante; sirve para verificar bloques de nodos que no son peers directos. Quien
+// retransmite no es garantía de nada: las llaves solo se aceptan si coinciden con las que
+// la cadena registra para el validador o si las endosa una llave del firmante en la que
+// este nodo ya confía, y nunca reemplazan a una llave conocida.
+func (p2p *P2PNetwork) learnSignerKeys(block Block, sender string) bool {
+	p2p.mutex.RLock()
+	peer, exists := p2p.Peers[sender]
+	p2p.mutex.RUnlock()
+	if !exists {
+		return false
+	}
+
+	keys, endorsements, err := p2p.requestValidatorKeys(peer)
+	if err != nil {
+		logf("❌ Error obteniendo llaves de validadores de %s: %v\n", sender, err)
+		return false
+	}
+	offered, known := keys[block.SignerNodeID]
+	if !known {
+		return false
+	}
+
+	p2p.mutex.Lock()
+	defer p2p.mutex.Unlock()
+	trusted := p2p.relayedKeys[block.SignerNodeID]
+	endorsement, endorsed := endorsements[block.SignerNodeID]
+	if err := p2p.checkRelayedKeys(block.SignerNodeID, trusted, offered, endorsement, endorsed); err != nil {
+		logf("🚫 Llaves de %s recibidas de %s rechazadas: %v\n", block.SignerNodeID, sender, err)
+		return false
+	}
+	merged, added := mergeKeys(trusted, offered)
+	if !added {
+		return false
+	}
+	p2p.relayedKeys[block.SignerNodeID] = merged
+	if endorsed {
+		p2p.relayedEndorsements[block.SignerNodeID] = endorsement
+	}
+	logf("🔑 Llaves de %s obtenidas a través de %s\n", block.SignerNodeID, sender)
+	return true
+}
+
+// checkRelayedKeys valida las llaves de un firmante recibidas de un tercero. En prueba de
+// autoridad todas deben estar registradas en la cadena para ese validador; sin ella deben
+// venir endosadas por una llave que ya se tenía del firmante, así que un nodo del que no se
+// conoce ninguna llave solo se aprende directamente (handshake). Una llave conocida no
+// puede llegar con otro valor.
+func (p2p *P2PNetwork) checkRelayedKeys(nodeID string, trusted []PublicKeyInfo, offered []PublicKeyInfo, endorsement KeySetEndorsement, endorsed bool) error {
+	for _, key := range offered {
+		for _, known := range trusted {
+			if key.KeyID == known.KeyID && key.PublicKey != known.PublicKey {
+				return fmt.Errorf("la llave %s no coincide con la conocida", key.KeyID)
+			}
+		}
+	}
+
+	if p2p.Blockchain.poa != nil {
+		registered, authorized := p2p.Blockchain.authorityKeys(nodeID)
+		if !authorized {
+			return fmt.Errorf("%s no es un validador autorizado", nodeID)
+		}
+		for _, key := range offered {
+			if !sameKey(registered, key) {
+				return fmt.Errorf("la llave %s no está registrada en la cadena", key.KeyID)
+			}
+		}
+		return nil
+	}
+
+	if len(trusted) == 0 {
+		return errors.New("no se conoce ninguna llave del firmante para verificar el endoso")
+	}
+	if !endorsed {
+		return errors.New("llaves sin endoso del firmante")
+	}
+	if err := VerifyWithKeys(trusted, endorsement.KeyID, keyUpdatePayload(nodeID, offered), endorsement.Signature); err != nil {
+		return fmt.Errorf("endoso inválido: %v", err)
+	}
+	return nil
+}
+
+// sameKey indica si la llave está en el conjunto con el mismo identificador y valor
+func sameKey(keys []PublicKeyInfo, key PublicKeyInfo) bool {
+	for _, candidate := range keys {
+		if candidate.KeyID == key.KeyID && candidate.PublicKey == key.PublicKey {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeKeys agrega a las llaves conocidas las nuevas, sin tocar las que ya estaban, e
+// indica si se agregó alguna
+func mergeKeys(known []PublicKeyInfo, offered []PublicKeyInfo) ([]PublicKeyInfo, bool) {
+	merged := append([]PublicKeyInfo{}, known...)
+	for _, key := range offered {
+		if !containsKey(merged, key.KeyID) {
+			merged = append(merged, key)
+		}
+	}
+	return merged, len(merged) > len(known)
+}
+
+// requestValidatorKeys solicita a un peer las llaves de los validadores que conoce y los
+// endosos con que las respalda
+func (p2p *P2PNetwork) requestValidatorKeys(peer *Peer) (map[string][]PublicKeyInfo, map[string]KeySetEndorsement, error) {
+	url := p2p.peerURL(peer, "/api/p2p/validator-keys")
+
+	resp, err := p2p.peerClient(5 * time.Second).Get(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("peer respondió con status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Validators   map[string][]PublicKeyInfo   `json:"validators"`
+		Endorsements map[string]KeySetEndorsement `json:"endorsements"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, nil, err
+	}
+	return response.Validators, response.Endorsements, nil
+}
+
+// containsString indica si el valor está en la lista
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package blockchain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ContractEvent es una transacción de la cadena que se refiere a un contrato, con el
+// bloque donde quedó registrada
+type ContractEvent struct {
+	Type        string    `json:"type"`
+	BlockIndex  int       `json:"block_index"`
+	BlockHash   string    `json:"block_hash"`
+	Transaction int       `json:"transaction"` // Posición dentro del bloque (los lotes del mempool traen varias)
+	Timestamp   time.Time `json:"timestamp"`
+	Actor       string    `json:"actor,omitempty"`
+	Summary     string    `json:"summary,omitempty"`
+}
+
+// eachContractTransaction recorre la cadena completa, incluidos los bloques archivados, y
+// llama a visit con cada transacción del contrato en orden
+func (bc *Blockchain) eachContractTransaction(contractID string, visit func(block *Block, position int, entry map[string]interface{})) error {
+	chain, err := bc.FullChain()
+	if err != nil {
+		return fmt.Errorf("error leyendo la cadena: %v", err)
+	}
+	for _, block := range chain {
+		for position, entry := range eventData(block.Data) {
+			if id, _ := entry["contract_id"].(string); id == contractID {
+				visit(block, position, entry)
+			}
+		}
+	}
+	return nil
+}
+
+// ContractHistory retorna los eventos del contrato registrados en la cadena, del más
+// antiguo al más reciente
+func (bc *Blockchain) ContractHistory(contractID string) ([]ContractEvent, error) {
+	if _, exists := bc.Contract(contractID); !exists {
+		return nil, fmt.Errorf("contrato %s no encontrado", contractID)
+	}
+
+	events := []ContractEvent{}
+	err := bc.eachContractTransaction(contractID, func(block *Block, position int, entry map[string]interface{}) {
+		event := ContractEvent{
+			BlockIndex:  block.Index,
+			BlockHash:   originHash(block),
+			Transaction: position,
+			Timestamp:   block.Timestamp,
+		}
+		decodeField(entry, "type", &event.Type)
+		decodeField(entry, "timestamp", &event.Timestamp)
+		event.Actor = transactionActor(entry)
+		event.Summary = contractEventSummary(event.Type, entry)
+		events = append(events, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// transactionActor retorna el usuario que originó la transacción, según el campo que
+// use su tipo
+func transactionActor(entry map[string]interface{}) string {
+	var actor string
+	for _, key := range []string{"validator", "created_by", "auditor", "registered_by", "uploaded_by", "awarded_by"} {
+		if decodeField(entry, key, &actor) && actor != "" {
+			return actor
+		}
+	}
+	return ""
+}
+
+// contractEventSummaries describe los tipos de transacción de un contrato
+var contractEventSummaries = map[string]string{
+	"CONTRACT_CREATION":          "Contrato radicado",
+	"CONTRACT_PUBLICATION":       "Proceso publicado",
+	"PLIEGO_QUESTION":            "Observación al pliego",
+	"PLIEGO_RESPONSE":            "Respuesta a una observación al pliego",
+	"CONTRACT_AWARD":             "Contrato adjudicado",
+	"CONTRACT_ATTACHMENT":        "Documento adjunto",
+	"EXECUTION_EVIDENCE":         "Evidencia de ejecución",
+	"CONFLICT_DECLARATION":       "Declaración de conflicto de interés",
+	"AUDIT_OBSERVATION":          "Observación de un ente de control",
+	ObservationResponseBlockType: "Respuesta a una observación de control",
+	PaymentBlockType:             "Pago registrado",
+	CarryoverBlockType:           "Saldo constituido para la siguiente vigencia",
+	AmendmentBlockType:           "Adición o prórroga solicitada",
+	AmendmentDecisionBlockType:   "Decisión sobre una adición o prórroga",
+	MilestoneBlockType:           "Hito de ejecución planeado",
+	MilestoneCompletionBlockType: "Hito de ejecución cumplido",
+	PaymentActBlockType:          "Acta de pago",
+	TemplateMigrationBlockType:   "Migración de plantilla de flujo",
+}
+
+// contractEventSummary describe la transacción en una línea
+func contractEventSummary(kind string, entry map[string]interface{}) string {
+	if kind != "VALIDATION" {
+		return contractEventSummaries[kind]
+	}
+	var step int
+	var approved bool
+	decodeField(entry, "step", &step)
+	decodeField(entry, "approved", &approved)
+	if approved {
+		return fmt.Sprintf("Paso %d aprobado", step)
+	}
+	return fmt.Sprintf("Paso %d rechazado", step)
+}
@@ -0,0 +1,156 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// Llaves de idempotencia para radicar contratos: un cliente que reintenta después de un
+// timeout envía la misma llave y recibe el contrato que ya se creó, sin que se agregue
+// otro bloque. Las llaves son de cada usuario y se guardan para sobrevivir reinicios
+// hasta que vencen.
+
+// DefaultIdempotencyTTL es cuánto se recuerda una llave de idempotencia
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// Errores de IdempotencyCache
+var (
+	ErrIdempotencyKeyReused   = errors.New("la llave de idempotencia ya se usó con otra petición")
+	ErrIdempotencyKeyInFlight = errors.New("hay una petición en curso con la misma llave de idempotencia")
+)
+
+// IdempotencyRecord es el resultado recordado de una petición con llave de idempotencia
+type IdempotencyRecord struct {
+	Key         string    `json:"key"`
+	Scope       string    `json:"scope"`        // Usuario que envió la llave
+	RequestHash string    `json:"request_hash"` // Huella del cuerpo de la petición original
+	ContractID  string    `json:"contract_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// IdempotencyCache recuerda las llaves de idempotencia ya usadas
+type IdempotencyCache struct {
+	TTL      time.Duration
+	records  map[string]*IdempotencyRecord
+	inFlight map[string]string // Llave -> huella de la petición que se está procesando
+	store    storage.Store
+	mutex    sync.Mutex
+}
+
+// newIdempotencyCache carga las llaves guardadas; las vencidas se descartan con Sweep
+func newIdempotencyCache(store storage.Store) *IdempotencyCache {
+	ic := &IdempotencyCache{
+		TTL:      DefaultIdempotencyTTL,
+		records:  make(map[string]*IdempotencyRecord),
+		inFlight: make(map[string]string),
+		store:    store,
+	}
+	store.ForEach(storage.BucketIdempotencyKeys, func(key string, value []byte) error {
+		var record IdempotencyRecord
+		if err := json.Unmarshal(value, &record); err == nil {
+			ic.records[key] = &record
+		}
+		return nil
+	})
+	return ic
+}
+
+// idempotencyID identifica la llave dentro del almacenamiento sin importar sus caracteres
+func idempotencyID(scope string, key string) string {
+	sum := sha256.Sum256([]byte(scope + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Begin reserva la llave antes de procesar la petición. Si ya se procesó una petición
+// idéntica retorna su registro para responder lo mismo; si la llave se usó con otro cuerpo
+// o sigue en curso retorna un error. Con registro nil y sin error la petición debe
+// procesarse y cerrarse con Complete o Release.
+func (ic *IdempotencyCache) Begin(scope string, key string, requestHash string) (*IdempotencyRecord, error) {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+
+	id := idempotencyID(scope, key)
+	if record, exists := ic.records[id]; exists {
+		if time.Since(record.CreatedAt) < ic.TTL {
+			if record.RequestHash != requestHash {
+				return nil, ErrIdempotencyKeyReused
+			}
+			replay := *record
+			return &replay, nil
+		}
+		delete(ic.records, id)
+		ic.store.Delete(storage.BucketIdempotencyKeys, id)
+	}
+	if hash, running := ic.inFlight[id]; running {
+		if hash != requestHash {
+			return nil, ErrIdempotencyKeyReused
+		}
+		return nil, ErrIdempotencyKeyInFlight
+	}
+	ic.inFlight[id] = requestHash
+	return nil, nil
+}
+
+// Complete recuerda el contrato creado por la petición reservada con Begin
+func (ic *IdempotencyCache) Complete(scope string, key string, contractID string) {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+
+	id := idempotencyID(scope, key)
+	record := &IdempotencyRecord{
+		Key:         key,
+		Scope:       scope,
+		RequestHash: ic.inFlight[id],
+		ContractID:  contractID,
+		CreatedAt:   time.Now(),
+	}
+	delete(ic.inFlight, id)
+	ic.records[id] = record
+
+	data, err := json.Marshal(record)
+	if err == nil {
+		err = ic.store.Put(storage.BucketIdempotencyKeys, id, data)
+	}
+	if err != nil {
+		logf("❌ Error guardando la llave de idempotencia %s: %v\n", key, err)
+	}
+}
+
+// Release libera la llave de una petición que falló para que el cliente pueda reintentarla
+func (ic *IdempotencyCache) Release(scope string, key string) {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+	delete(ic.inFlight, idempotencyID(scope, key))
+}
+
+// Sweep descarta las llaves vencidas y retorna cuántas eliminó
+func (ic *IdempotencyCache) Sweep(now time.Time) int {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+
+	removed := 0
+	for id, record := range ic.records {
+		if now.Sub(record.CreatedAt) >= ic.TTL {
+			delete(ic.records, id)
+			ic.store.Delete(storage.BucketIdempotencyKeys, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// IdempotencyRequestHash es la huella de la petición que se compara en los reintentos
+func IdempotencyRequestHash(request interface{}) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
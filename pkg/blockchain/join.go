@@ -0,0 +1,330 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+
+	"github.com/google/uuid"
+)
+
+// Prefijo de los tokens de ingreso, para reconocerlos en configuraciones y logs
+const joinTokenPrefix = "join_"
+
+// Vigencia de un token de ingreso si no se indica otra
+const DefaultJoinTokenTTL = 24 * time.Hour
+
+// Error genérico de token de ingreso; no revela si el token existe, venció o ya se usó
+var ErrInvalidJoinToken = errors.New("token de ingreso inválido")
+
+// JoinToken es un token de un solo uso con el que un nodo nuevo se incorpora a la red.
+// Solo se guarda la huella SHA-256 del secreto.
+type JoinToken struct {
+	ID        string     `json:"id"`
+	Hint      string     `json:"hint"`
+	Hash      string     `json:"-"`
+	Note      string     `json:"note,omitempty"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	UsedBy    string     `json:"used_by,omitempty"` // Nodo que se incorporó con el token
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// storedJoinToken es la forma persistida del token, incluida la huella del secreto
+type storedJoinToken struct {
+	JoinToken
+	Hash string `json:"hash"`
+}
+
+// JoinTokenStore emite y canjea los tokens de ingreso a la red
+type JoinTokenStore struct {
+	blockchain *Blockchain
+	tokens     map[string]*JoinToken
+	mutex      sync.Mutex
+}
+
+// JoinRequest es lo que presenta un nodo nuevo al canjear su token
+type JoinRequest struct {
+	Token      string           `json:"token"`
+	NodeID     string           `json:"node_id"`
+	Address    string           `json:"address"`
+	Port       string           `json:"port"`
+	PublicKeys []PublicKeyInfo  `json:"public_keys"`
+	Features   ProtocolFeatures `json:"features"`
+}
+
+// JoinPeer describe un miembro de la red en la respuesta de ingreso
+type JoinPeer struct {
+	ID         string          `json:"id"`
+	Address    string          `json:"address"`
+	Port       string          `json:"port"`
+	PublicKeys []PublicKeyInfo `json:"public_keys,omitempty"`
+}
+
+// JoinResponse es lo que recibe el nodo nuevo: los miembros de la red y su consenso
+type JoinResponse struct {
+	NodeID            string      `json:"node_id"`
+	Peers             []JoinPeer  `json:"peers"`
+	ConsensusMode     string      `json:"consensus_mode"`
+	ActivationHeight  int         `json:"activation_height,omitempty"`
+	GenesisValidators []Authority `json:"genesis_validators,omitempty"`
+	Validators        []Authority `json:"validators,omitempty"`
+}
+
+// NewJoinTokenStore carga los tokens de ingreso guardados
+func NewJoinTokenStore(bc *Blockchain) *JoinTokenStore {
+	js := &JoinTokenStore{
+		blockchain: bc,
+		tokens:     make(map[string]*JoinToken),
+	}
+
+	bc.store.ForEach(storage.BucketJoinTokens, func(key string, value []byte) error {
+		var stored storedJoinToken
+		if err := json.Unmarshal(value, &stored); err == nil {
+			token := stored.JoinToken
+			token.Hash = stored.Hash
+			js.tokens[key] = &token
+		}
+		return nil
+	})
+	return js
+}
+
+// Issue emite un token nuevo y retorna su secreto, que no vuelve a estar disponible
+func (js *JoinTokenStore) Issue(createdBy string, note string, ttl time.Duration) (*JoinToken, string, error) {
+	if ttl <= 0 {
+		ttl = DefaultJoinTokenTTL
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	secret := joinTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now()
+	token := &JoinToken{
+		ID:        uuid.New().String(),
+		Hint:      secret[:len(joinTokenPrefix)+4],
+		Hash:      hashJoinToken(secret),
+		Note:      note,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+
+	if err := js.save(token); err != nil {
+		return nil, "", err
+	}
+	js.tokens[token.ID] = token
+
+	logf("🎟️ Token de ingreso %s emitido, vence %s\n", token.ID, token.ExpiresAt.Format(time.RFC3339))
+	copied := *token
+	return &copied, secret, nil
+}
+
+// Redeem canjea un token para el nodo indicado. El uso queda persistido antes de
+// retornar, de modo que el token no sirve una segunda vez aunque el nodo se reinicie.
+func (js *JoinTokenStore) Redeem(secret string, nodeID string) (*JoinToken, error) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+
+	hash := hashJoinToken(secret)
+	for _, token := range js.tokens {
+		if token.Hash != hash {
+			continue
+		}
+		if token.UsedAt != nil || token.RevokedAt != nil || time.Now().After(token.ExpiresAt) {
+			return nil, ErrInvalidJoinToken
+		}
+
+		now := time.Now()
+		token.UsedAt = &now
+		token.UsedBy = nodeID
+		if err := js.save(token); err != nil {
+			token.UsedAt = nil
+			token.UsedBy = ""
+			return nil, err
+		}
+
+		logf("🎟️ Token de ingreso %s canjeado por %s\n", token.ID, nodeID)
+		copied := *token
+		return &copied, nil
+	}
+	return nil, ErrInvalidJoinToken
+}
+
+// List retorna los tokens emitidos, sin sus secretos
+func (js *JoinTokenStore) List() []JoinToken {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+
+	tokens := make([]JoinToken, 0, len(js.tokens))
+	for _, token := range js.tokens {
+		tokens = append(tokens, *token)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.Before(tokens[j].CreatedAt) })
+	return tokens
+}
+
+// Revoke invalida un token que aún no se ha usado
+func (js *JoinTokenStore) Revoke(id string) error {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+
+	token, exists := js.tokens[id]
+	if !exists {
+		return errors.New("token de ingreso no encontrado")
+	}
+	if token.UsedAt != nil {
+		return errors.New("el token ya fue usado")
+	}
+	if token.RevokedAt != nil {
+		return errors.New("el token ya está revocado")
+	}
+	now := time.Now()
+	token.RevokedAt = &now
+	if err := js.save(token); err != nil {
+		token.RevokedAt = nil
+		return err
+	}
+	return nil
+}
+
+// save persiste un token con la huella de su secreto
+func (js *JoinTokenStore) save(token *JoinToken) error {
+	data, err := json.Marshal(storedJoinToken{JoinToken: *token, Hash: token.Hash})
+	if err != nil {
+		return err
+	}
+	return js.blockchain.store.Put(storage.BucketJoinTokens, token.ID, data)
+}
+
+// hashJoinToken calcula la huella con la que se busca un token
+func hashJoinToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// AcceptJoin canjea el token de un nodo nuevo, lo registra como peer con sus llaves y
+// retorna los miembros de la red y el conjunto de validadores
+func (p2p *P2PNetwork) AcceptJoin(tokens *JoinTokenStore, req JoinRequest) (*JoinResponse, error) {
+	if req.NodeID == "" || req.Address == "" || req.Port == "" {
+		return nil, errors.New("el nodo debe indicar su ID, dirección y puerto")
+	}
+	if req.NodeID == p2p.NodeID {
+		return nil, errors.New("el ID del nodo ya está en uso")
+	}
+	if err := validateAuthorityKeys(req.PublicKeys); err != nil {
+		return nil, err
+	}
+	if _, err := tokens.Redeem(req.Token, req.NodeID); err != nil {
+		return nil, err
+	}
+
+	response := &JoinResponse{
+		NodeID:           p2p.NodeID,
+		ConsensusMode:    p2p.Blockchain.ConsensusMode(),
+		ActivationHeight: p2p.Blockchain.AuthorityActivationHeight(),
+		Validators:       p2p.Blockchain.Authorities(),
+	}
+	if p2p.Blockchain.poa != nil {
+		response.GenesisValidators = p2p.Blockchain.poa.genesisAuthorities()
+	}
+
+	self := JoinPeer{ID: p2p.NodeID, Address: p2p.Address, Port: p2p.Port}
+	if p2p.Keys != nil {
+		self.PublicKeys = p2p.Keys.PublicKeys()
+	}
+	response.Peers = append(response.Peers, self)
+
+	p2p.mutex.Lock()
+	for peerID, peer := range p2p.Peers {
+		if peerID == req.NodeID || !peer.Active {
+			continue
+		}
+		response.Peers = append(response.Peers, JoinPeer{
+			ID:         peerID,
+			Address:    peer.Address,
+			Port:       peer.Port,
+			PublicKeys: peer.PublicKeys,
+		})
+	}
+	peer := &Peer{
+		ID:       req.NodeID,
+		Address:  req.Address,
+		Port:     req.Port,
+		LastSeen: time.Now(),
+		Active:   true,
+	}
+	p2p.Peers[req.NodeID] = peer
+	p2p.applyHandshake(peer, &HandshakeInfo{NodeID: req.NodeID, Features: req.Features, PublicKeys: req.PublicKeys})
+	p2p.mutex.Unlock()
+
+	logf("🔗 Nodo %s (%s:%s) incorporado a la red con token de ingreso\n", req.NodeID, req.Address, req.Port)
+	return response, nil
+}
+
+// Join incorpora este nodo a la red canjeando un token en un miembro existente, y agrega
+// como peers a los miembros que este le informa
+func (p2p *P2PNetwork) Join(address string, port string, token string) (*JoinResponse, error) {
+	req := JoinRequest{
+		Token:    token,
+		NodeID:   p2p.NodeID,
+		Address:  p2p.Address,
+		Port:     p2p.Port,
+		Features: p2p.Blockchain.Protocol.LocalFeatures(),
+	}
+	if p2p.Keys != nil {
+		req.PublicKeys = p2p.Keys.PublicKeys()
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	url := p2p.peerURL(&Peer{Address: address, Port: port}, "/api/p2p/join")
+	resp, err := p2p.peerClient(10*time.Second).Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		return nil, fmt.Errorf("el miembro rechazó el ingreso (status %d): %s", resp.StatusCode, failure.Error)
+	}
+
+	var response JoinResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	for _, peer := range response.Peers {
+		if peer.ID == p2p.NodeID {
+			continue
+		}
+		p2p.AddPeer(peer.ID, peer.Address, peer.Port)
+	}
+	logf("🔗 Ingreso a la red por %s: %d miembros, consenso %s\n", response.NodeID, len(response.Peers), response.ConsensusMode)
+	return &response, nil
+}
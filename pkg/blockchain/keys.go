@@ -0,0 +1,251 @@
+package blockchain
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// NodeKey representa una llave de firma Ed25519 del nodo
+type NodeKey struct {
+	KeyID      string     `json:"kid"`
+	PublicKey  string     `json:"public_key"`
+	Seed       string     `json:"seed,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RetiredAt  *time.Time `json:"retired_at,omitempty"`
+	privateKey ed25519.PrivateKey
+}
+
+// PublicKeyInfo representa la parte pública de una llave, segura para compartir
+type PublicKeyInfo struct {
+	KeyID     string     `json:"kid"`
+	PublicKey string     `json:"public_key"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+// NodeKeyring guarda las llaves de firma del nodo; la última es la activa y las
+// anteriores se conservan retiradas para poder verificar firmas históricas
+type NodeKeyring struct {
+	path  string
+	keys  []*NodeKey
+	mutex sync.RWMutex
+}
+
+// KeyIDFor calcula el identificador de una llave pública
+func KeyIDFor(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// LoadOrCreateNodeKeyring carga las llaves desde el archivo o genera una nueva.
+// Si path está vacío las llaves son efímeras y se pierden al reiniciar.
+func LoadOrCreateNodeKeyring(path string) (*NodeKeyring, error) {
+	kr := &NodeKeyring{path: path}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			if err := json.Unmarshal(data, &kr.keys); err != nil {
+				return nil, fmt.Errorf("archivo de llaves inválido: %v", err)
+			}
+			for _, key := range kr.keys {
+				seed, err := base64.StdEncoding.DecodeString(key.Seed)
+				if err != nil || len(seed) != ed25519.SeedSize {
+					return nil, fmt.Errorf("semilla inválida para la llave %s", key.KeyID)
+				}
+				key.privateKey = ed25519.NewKeyFromSeed(seed)
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	if len(kr.keys) == 0 {
+		if _, err := kr.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return kr, nil
+}
+
+// Rotate genera una nueva llave activa y retira la anterior
+func (kr *NodeKeyring) Rotate() (*PublicKeyInfo, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	kr.mutex.Lock()
+	defer kr.mutex.Unlock()
+
+	now := time.Now()
+	if len(kr.keys) > 0 {
+		kr.keys[len(kr.keys)-1].RetiredAt = &now
+	}
+
+	key := &NodeKey{
+		KeyID:      KeyIDFor(publicKey),
+		PublicKey:  base64.StdEncoding.EncodeToString(publicKey),
+		Seed:       base64.StdEncoding.EncodeToString(privateKey.Seed()),
+		CreatedAt:  now,
+		privateKey: privateKey,
+	}
+	kr.keys = append(kr.keys, key)
+
+	if err := kr.save(); err != nil {
+		return nil, err
+	}
+
+	logf("🔑 Nueva llave de firma del nodo: %s\n", key.KeyID)
+	info := key.publicInfo()
+	return &info, nil
+}
+
+// save persiste las llaves en disco con permisos restringidos
+func (kr *NodeKeyring) save() error {
+	if kr.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(kr.keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(kr.path, data, 0600)
+}
+
+// Sign firma datos con la llave activa y retorna el identificador de la llave y la firma en base64
+func (kr *NodeKeyring) Sign(data []byte) (string, string) {
+	kr.mutex.RLock()
+	defer kr.mutex.RUnlock()
+
+	active := kr.keys[len(kr.keys)-1]
+	signature := ed25519.Sign(active.privateKey, data)
+	return active.KeyID, base64.StdEncoding.EncodeToString(signature)
+}
+
+// SignWith firma datos con una llave específica del nodo, aunque esté retirada
+func (kr *NodeKeyring) SignWith(keyID string, data []byte) (string, error) {
+	kr.mutex.RLock()
+	defer kr.mutex.RUnlock()
+
+	for _, key := range kr.keys {
+		if key.KeyID == keyID {
+			return base64.StdEncoding.EncodeToString(ed25519.Sign(key.privateKey, data)), nil
+		}
+	}
+	return "", fmt.Errorf("llave %s desconocida", keyID)
+}
+
+// ActiveKeyID retorna el identificador de la llave activa
+func (kr *NodeKeyring) ActiveKeyID() string {
+	kr.mutex.RLock()
+	defer kr.mutex.RUnlock()
+	return kr.keys[len(kr.keys)-1].KeyID
+}
+
+// PublicKeys retorna la parte pública de todas las llaves del nodo
+func (kr *NodeKeyring) PublicKeys() []PublicKeyInfo {
+	kr.mutex.RLock()
+	defer kr.mutex.RUnlock()
+
+	infos := make([]PublicKeyInfo, len(kr.keys))
+	for i, key := range kr.keys {
+		infos[i] = key.publicInfo()
+	}
+	return infos
+}
+
+// KeySetEndorsement es la firma de un nodo sobre su conjunto de llaves, con la que los
+// demás aceptan llaves nuevas que reciben a través de otro peer
+type KeySetEndorsement struct {
+	KeyID     string `json:"kid"`
+	Signature string `json:"signature"`
+}
+
+// EndorseKeys retorna las llaves públicas del nodo firmadas con la más antigua: las llaves
+// retiradas se conservan, así que quien confía en cualquier versión anterior del conjunto
+// ya conoce la llave que firma
+func (kr *NodeKeyring) EndorseKeys(nodeID string) ([]PublicKeyInfo, KeySetEndorsement) {
+	kr.mutex.RLock()
+	defer kr.mutex.RUnlock()
+
+	infos := make([]PublicKeyInfo, len(kr.keys))
+	for i, key := range kr.keys {
+		infos[i] = key.publicInfo()
+	}
+	oldest := kr.keys[0]
+	signature := ed25519.Sign(oldest.privateKey, keyUpdatePayload(nodeID, infos))
+	return infos, KeySetEndorsement{KeyID: oldest.KeyID, Signature: base64.StdEncoding.EncodeToString(signature)}
+}
+
+func (key *NodeKey) publicInfo() PublicKeyInfo {
+	return PublicKeyInfo{
+		KeyID:     key.KeyID,
+		PublicKey: key.PublicKey,
+		CreatedAt: key.CreatedAt,
+		RetiredAt: key.RetiredAt,
+	}
+}
+
+// VerifyWithKeys verifica una firma contra la llave indicada de un conjunto de llaves públicas
+func VerifyWithKeys(keys []PublicKeyInfo, keyID string, data []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errors.New("firma mal codificada")
+	}
+	for _, key := range keys {
+		if key.KeyID != keyID {
+			continue
+		}
+		publicKey, err := base64.StdEncoding.DecodeString(key.PublicKey)
+		if err != nil || len(publicKey) != ed25519.PublicKeySize {
+			return errors.New("llave pública inválida")
+		}
+		if !ed25519.Verify(ed25519.PublicKey(publicKey), data, sig) {
+			return errors.New("firma inválida")
+		}
+		return nil
+	}
+	return fmt.Errorf("llave %s desconocida", keyID)
+}
+
+// JWK representa una llave pública en formato JSON Web Key (RFC 8037 para Ed25519)
+type JWK struct {
+	Kty       string     `json:"kty"`
+	Crv       string     `json:"crv"`
+	X         string     `json:"x"`
+	Kid       string     `json:"kid"`
+	Use       string     `json:"use"`
+	Alg       string     `json:"alg"`
+	Owner     string     `json:"owner,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+// ToJWK convierte una llave pública al formato JWK
+func (info PublicKeyInfo) ToJWK(owner string) (JWK, error) {
+	raw, err := base64.StdEncoding.DecodeString(info.PublicKey)
+	if err != nil {
+		return JWK{}, err
+	}
+	return JWK{
+		Kty:       "OKP",
+		Crv:       "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(raw),
+		Kid:       info.KeyID,
+		Use:       "sig",
+		Alg:       "EdDSA",
+		Owner:     owner,
+		CreatedAt: info.CreatedAt,
+		RetiredAt: info.RetiredAt,
+	}, nil
+}
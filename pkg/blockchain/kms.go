@@ -0,0 +1,384 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Proveedores de KMS soportados para cifrar respaldos
+const (
+	KMSLocal = "local"
+	KMSAWS   = "aws"
+	KMSGCP   = "gcp"
+)
+
+// KMS protege las llaves de datos de los respaldos (cifrado de sobre): el respaldo se
+// cifra con una llave de datos aleatoria y solo esa llave, envuelta, pasa por el KMS
+type KMS interface {
+	// Provider retorna el nombre del proveedor
+	Provider() string
+	// KeyID identifica la llave maestra con la que se envuelven las llaves de datos
+	KeyID() string
+	// WrapKey cifra una llave de datos con la llave maestra
+	WrapKey(dataKey []byte) ([]byte, error)
+	// UnwrapKey descifra una llave de datos envuelta por la llave maestra keyID
+	UnwrapKey(wrapped []byte, keyID string) ([]byte, error)
+}
+
+// LocalKMS envuelve las llaves de datos con una llave maestra AES-256 guardada en un archivo
+type LocalKMS struct {
+	keyID string
+	key   []byte
+}
+
+// LoadOrCreateLocalKMS carga la llave maestra desde el archivo o genera una nueva
+func LoadOrCreateLocalKMS(path string) (*LocalKMS, error) {
+	if path == "" {
+		return nil, errors.New("ruta de la llave maestra requerida")
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		data = []byte(base64.StdEncoding.EncodeToString(key))
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return nil, err
+		}
+		logf("🔑 Nueva llave maestra de respaldos en %s\n", path)
+	} else if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("llave maestra de respaldos inválida: se esperan 32 bytes en base64")
+	}
+
+	sum := sha256.Sum256(key)
+	return &LocalKMS{keyID: hex.EncodeToString(sum[:8]), key: key}, nil
+}
+
+// Provider retorna el nombre del proveedor
+func (k *LocalKMS) Provider() string { return KMSLocal }
+
+// KeyID identifica la llave maestra por su huella
+func (k *LocalKMS) KeyID() string { return k.keyID }
+
+// WrapKey cifra la llave de datos con AES-256-GCM
+func (k *LocalKMS) WrapKey(dataKey []byte) ([]byte, error) {
+	return sealAESGCM(k.key, dataKey)
+}
+
+// UnwrapKey descifra la llave de datos; falla si fue envuelta con otra llave maestra
+func (k *LocalKMS) UnwrapKey(wrapped []byte, keyID string) ([]byte, error) {
+	if keyID != k.keyID {
+		return nil, fmt.Errorf("el respaldo fue cifrado con la llave maestra %s y la configurada es %s", keyID, k.keyID)
+	}
+	return openAESGCM(k.key, wrapped)
+}
+
+// AWSKMSConfig configura el acceso a AWS KMS
+type AWSKMSConfig struct {
+	Region          string
+	KeyID           string // ARN o alias de la llave
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSKMS envuelve las llaves de datos con AWS KMS usando su API JSON firmada con SigV4
+type AWSKMS struct {
+	config   AWSKMSConfig
+	endpoint string
+	client   *http.Client
+}
+
+// NewAWSKMS crea el cliente de AWS KMS
+func NewAWSKMS(config AWSKMSConfig) (*AWSKMS, error) {
+	if config.Region == "" || config.KeyID == "" {
+		return nil, errors.New("AWS KMS requiere región y llave")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("AWS KMS requiere credenciales")
+	}
+	return &AWSKMS{
+		config:   config,
+		endpoint: fmt.Sprintf("https://kms.%s.amazonaws.com/", config.Region),
+		client:   &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Provider retorna el nombre del proveedor
+func (k *AWSKMS) Provider() string { return KMSAWS }
+
+// KeyID retorna el ARN o alias configurado
+func (k *AWSKMS) KeyID() string { return k.config.KeyID }
+
+// WrapKey cifra la llave de datos con la operación Encrypt de KMS
+func (k *AWSKMS) WrapKey(dataKey []byte) ([]byte, error) {
+	var resp struct {
+		CiphertextBlob []byte `json:"CiphertextBlob"`
+	}
+	err := k.call("Encrypt", map[string]interface{}{
+		"KeyId":     k.config.KeyID,
+		"Plaintext": dataKey,
+	}, &resp)
+	return resp.CiphertextBlob, err
+}
+
+// UnwrapKey descifra la llave de datos con la operación Decrypt de KMS
+func (k *AWSKMS) UnwrapKey(wrapped []byte, keyID string) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	err := k.call("Decrypt", map[string]interface{}{
+		"KeyId":          keyID,
+		"CiphertextBlob": wrapped,
+	}, &resp)
+	return resp.Plaintext, err
+}
+
+// call invoca una operación de KMS; los []byte viajan en base64 como exige la API
+func (k *AWSKMS) call(operation string, payload interface{}, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, k.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+operation)
+	k.sign(req, body, time.Now().UTC())
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("AWS KMS no disponible: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("AWS KMS respondió %d en %s: %s", resp.StatusCode, operation, strings.TrimSpace(string(respBody)))
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// sign agrega la firma AWS Signature Version 4 a la petición
+func (k *AWSKMS) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if k.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", k.config.SessionToken)
+	}
+
+	headers := []string{"content-type", "host", "x-amz-date", "x-amz-target"}
+	values := map[string]string{
+		"content-type": req.Header.Get("Content-Type"),
+		"host":         req.URL.Host,
+		"x-amz-date":   amzDate,
+		"x-amz-target": req.Header.Get("X-Amz-Target"),
+	}
+	if k.config.SessionToken != "" {
+		headers = append(headers, "x-amz-security-token")
+		values["x-amz-security-token"] = k.config.SessionToken
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headers {
+		canonicalHeaders.WriteString(name + ":" + values[name] + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method, "/", "", canonicalHeaders.String(), signedHeaders, payloadHash,
+	}, "\n")
+	scope := date + "/" + k.config.Region + "/kms/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+k.config.SecretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, k.config.Region)
+	signingKey = hmacSHA256(signingKey, "kms")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		k.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// GCPKMS envuelve las llaves de datos con Cloud KMS usando su API REST
+type GCPKMS struct {
+	keyName     string // projects/.../locations/.../keyRings/.../cryptoKeys/...
+	accessToken string
+	client      *http.Client
+}
+
+// NewGCPKMS crea el cliente de Cloud KMS. Sin accessToken se pide uno al servidor de
+// metadatos, como ocurre al correr dentro de GCP con una cuenta de servicio.
+func NewGCPKMS(keyName string, accessToken string) (*GCPKMS, error) {
+	if !strings.HasPrefix(keyName, "projects/") || !strings.Contains(keyName, "/cryptoKeys/") {
+		return nil, errors.New("nombre de llave de Cloud KMS inválido")
+	}
+	return &GCPKMS{
+		keyName:     keyName,
+		accessToken: accessToken,
+		client:      &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Provider retorna el nombre del proveedor
+func (k *GCPKMS) Provider() string { return KMSGCP }
+
+// KeyID retorna el nombre completo de la llave
+func (k *GCPKMS) KeyID() string { return k.keyName }
+
+// WrapKey cifra la llave de datos con la versión primaria de la llave
+func (k *GCPKMS) WrapKey(dataKey []byte) ([]byte, error) {
+	var resp struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	err := k.call(k.keyName+":encrypt", map[string]interface{}{"plaintext": dataKey}, &resp)
+	return resp.Ciphertext, err
+}
+
+// UnwrapKey descifra la llave de datos; Cloud KMS identifica la versión por el texto cifrado
+func (k *GCPKMS) UnwrapKey(wrapped []byte, keyID string) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+	err := k.call(keyID+":decrypt", map[string]interface{}{"ciphertext": wrapped}, &resp)
+	return resp.Plaintext, err
+}
+
+// call invoca un método de la API de Cloud KMS
+func (k *GCPKMS) call(resource string, payload interface{}, out interface{}) error {
+	token, err := k.token()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "https://cloudkms.googleapis.com/v1/"+resource, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Cloud KMS no disponible: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Cloud KMS respondió %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// token retorna el token configurado o uno de la cuenta de servicio de la instancia
+func (k *GCPKMS) token() (string, error) {
+	if k.accessToken != "" {
+		return k.accessToken, nil
+	}
+
+	metadataURL := "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token?scopes=" +
+		url.QueryEscape("https://www.googleapis.com/auth/cloudkms")
+	req, err := http.NewRequest(http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("no se pudo obtener token de GCP: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil || token.AccessToken == "" {
+		return "", errors.New("el servidor de metadatos no entregó un token de GCP")
+	}
+	return token.AccessToken, nil
+}
+
+// sealAESGCM cifra con AES-GCM y antepone el nonce al texto cifrado
+func sealAESGCM(key []byte, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// openAESGCM descifra un texto producido por sealAESGCM
+func openAESGCM(key []byte, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("texto cifrado demasiado corto")
+	}
+	plaintext, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("no se pudo descifrar: llave incorrecta o datos alterados")
+	}
+	return plaintext, nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
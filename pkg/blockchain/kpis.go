@@ -0,0 +1,340 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// Indicadores de gestión contractual que el DNP mide a cada entidad
+const (
+	KPIDaysToAward       = "days_to_award"            // Días promedio entre la radicación y la adjudicación
+	KPICompetitive       = "competitive_percent"      // Porcentaje de procesos por modalidades competitivas
+	KPIDirectContracting = "direct_contracting_share" // Porcentaje del valor contratado de forma directa
+)
+
+// Formato del periodo mensual de los informes de cumplimiento
+const compliancePeriodLayout = "2006-01"
+
+// directContractTypes son los tipos de contrato que se adjudican sin competencia; coinciden
+// con los que el puente SECOP reporta como "Contratación directa"
+var directContractTypes = map[string]bool{
+	"CONTRATACION_DIRECTA": true,
+	"PRESTACION_SERVICIOS": true,
+}
+
+// KPITargets son las metas que el DNP fija para los indicadores de las entidades
+type KPITargets struct {
+	MaxDaysToAward        float64   `json:"max_days_to_award"`
+	MinCompetitivePercent float64   `json:"min_competitive_percent"`
+	MaxDirectShare        float64   `json:"max_direct_contracting_share"`
+	UpdatedBy             string    `json:"updated_by,omitempty"`
+	UpdatedAt             time.Time `json:"updated_at,omitempty"`
+}
+
+// DefaultKPITargets retorna las metas que aplican mientras el DNP no configure otras
+func DefaultKPITargets() KPITargets {
+	return KPITargets{
+		MaxDaysToAward:        90,
+		MinCompetitivePercent: 60,
+		MaxDirectShare:        40,
+	}
+}
+
+// validate verifica que las metas estén en rangos válidos
+func (targets KPITargets) validate() error {
+	if targets.MaxDaysToAward <= 0 {
+		return errors.New("la meta de días a la adjudicación debe ser positiva")
+	}
+	if targets.MinCompetitivePercent < 0 || targets.MinCompetitivePercent > 100 {
+		return errors.New("el porcentaje mínimo de procesos competitivos debe estar entre 0 y 100")
+	}
+	if targets.MaxDirectShare < 0 || targets.MaxDirectShare > 100 {
+		return errors.New("la participación máxima de contratación directa debe estar entre 0 y 100")
+	}
+	return nil
+}
+
+// KPIIndicator es el valor de un indicador de la entidad frente a su meta
+type KPIIndicator struct {
+	Name   string  `json:"name"`
+	Value  float64 `json:"value"`
+	Target float64 `json:"target"`
+	Met    bool    `json:"met"`
+}
+
+// EntityCompliance es el cumplimiento de las metas de una entidad en el periodo. El
+// puntaje es el porcentaje de indicadores evaluados que cumplen su meta.
+type EntityCompliance struct {
+	EntityCode string         `json:"entity_code"`
+	EntityName string         `json:"entity_name"`
+	Processes  int            `json:"processes"`
+	Awarded    int            `json:"awarded"`
+	Amount     float64        `json:"amount"`
+	Indicators []KPIIndicator `json:"indicators"`
+	Score      float64        `json:"score"`
+}
+
+// ComplianceReport es el cumplimiento de todas las entidades en un mes
+type ComplianceReport struct {
+	Period      string             `json:"period"`
+	From        time.Time          `json:"from"`
+	To          time.Time          `json:"to"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	Height      int                `json:"height"`
+	Targets     KPITargets         `json:"targets"`
+	Entities    []EntityCompliance `json:"entities"`
+}
+
+// ForEntity retorna una copia del informe solo con la entidad indicada
+func (report *ComplianceReport) ForEntity(entityCode string) *ComplianceReport {
+	filtered := *report
+	filtered.Entities = []EntityCompliance{}
+	for _, entity := range report.Entities {
+		if entity.EntityCode == entityCode {
+			filtered.Entities = append(filtered.Entities, entity)
+		}
+	}
+	return &filtered
+}
+
+// ComplianceScorer calcula cada mes el cumplimiento de las metas de cada entidad
+type ComplianceScorer struct {
+	blockchain *Blockchain
+	targets    KPITargets
+	reports    map[string]*ComplianceReport
+	mutex      sync.Mutex
+}
+
+// NewComplianceScorer crea el calculador y carga las metas y los informes guardados
+func NewComplianceScorer(bc *Blockchain) *ComplianceScorer {
+	cs := &ComplianceScorer{
+		blockchain: bc,
+		targets:    DefaultKPITargets(),
+		reports:    make(map[string]*ComplianceReport),
+	}
+
+	bc.store.ForEach(storage.BucketKPICompliance, func(key string, value []byte) error {
+		if key == "targets" {
+			var targets KPITargets
+			if err := json.Unmarshal(value, &targets); err == nil {
+				cs.targets = targets
+			}
+			return nil
+		}
+		if strings.HasPrefix(key, "report:") {
+			var report ComplianceReport
+			if err := json.Unmarshal(value, &report); err == nil {
+				cs.reports[report.Period] = &report
+			}
+		}
+		return nil
+	})
+	return cs
+}
+
+// Targets retorna las metas vigentes
+func (cs *ComplianceScorer) Targets() KPITargets {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	return cs.targets
+}
+
+// SetTargets reemplaza las metas; aplican desde el siguiente cálculo
+func (cs *ComplianceScorer) SetTargets(targets KPITargets, updatedBy string) (KPITargets, error) {
+	if err := targets.validate(); err != nil {
+		return targets, err
+	}
+	targets.UpdatedBy = updatedBy
+	targets.UpdatedAt = time.Now()
+
+	cs.mutex.Lock()
+	cs.targets = targets
+	cs.mutex.Unlock()
+
+	cs.blockchain.saveState(storage.BucketKPICompliance, "targets", &targets)
+	logf("🎯 Metas de gestión contractual actualizadas por %s\n", updatedBy)
+	return targets, nil
+}
+
+// Score calcula el cumplimiento de las entidades en el periodo ("2026-09") con las metas
+// vigentes y lo guarda, reemplazando el informe anterior del mismo mes
+func (cs *ComplianceScorer) Score(period string) (*ComplianceReport, error) {
+	from, err := time.Parse(compliancePeriodLayout, period)
+	if err != nil {
+		return nil, fmt.Errorf("periodo inválido, se espera AAAA-MM: %s", period)
+	}
+	to := from.AddDate(0, 1, 0).Add(-time.Nanosecond)
+
+	contracts, err := cs.blockchain.QueryContracts(ContractQuery{From: &from, To: &to})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ComplianceReport{
+		Period:      period,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now(),
+		Height:      cs.blockchain.Len() - 1,
+		Targets:     cs.Targets(),
+		Entities:    []EntityCompliance{},
+	}
+
+	byEntity := make(map[string][]*Contract)
+	for _, contract := range contracts {
+		if contract.Status == StatusRejected {
+			continue
+		}
+		byEntity[contract.EntityCode] = append(byEntity[contract.EntityCode], contract)
+	}
+	for entityCode, entityContracts := range byEntity {
+		report.Entities = append(report.Entities, scoreEntity(entityCode, entityContracts, report.Targets))
+	}
+	sort.Slice(report.Entities, func(i, j int) bool { return report.Entities[i].EntityCode < report.Entities[j].EntityCode })
+
+	cs.mutex.Lock()
+	cs.reports[period] = report
+	cs.mutex.Unlock()
+
+	cs.blockchain.saveState(storage.BucketKPICompliance, "report:"+period, report)
+	logf("🎯 Cumplimiento de metas de %s calculado para %d entidades\n", period, len(report.Entities))
+	return report, nil
+}
+
+// scoreEntity calcula los indicadores de una entidad. Los días a la adjudicación solo se
+// evalúan si la entidad adjudicó algún proceso en el periodo.
+func scoreEntity(entityCode string, contracts []*Contract, targets KPITargets) EntityCompliance {
+	entity := EntityCompliance{EntityCode: entityCode, Indicators: []KPIIndicator{}}
+
+	competitive := 0
+	directAmount := 0.0
+	awardDays := 0.0
+	for _, contract := range contracts {
+		if entity.EntityName == "" {
+			entity.EntityName = contract.EntityName
+		}
+		entity.Processes++
+		entity.Amount += contract.Amount
+		if directContractTypes[contract.ContractType] {
+			directAmount += contract.Amount
+		} else {
+			competitive++
+		}
+		if awardedAt, awarded := contractAwardedAt(contract); awarded {
+			entity.Awarded++
+			awardDays += awardedAt.Sub(contract.CreatedAt).Hours() / 24
+		}
+	}
+
+	if entity.Awarded > 0 {
+		average := awardDays / float64(entity.Awarded)
+		entity.Indicators = append(entity.Indicators, KPIIndicator{
+			Name:   KPIDaysToAward,
+			Value:  average,
+			Target: targets.MaxDaysToAward,
+			Met:    average <= targets.MaxDaysToAward,
+		})
+	}
+	competitivePercent := 100 * float64(competitive) / float64(entity.Processes)
+	entity.Indicators = append(entity.Indicators, KPIIndicator{
+		Name:   KPICompetitive,
+		Value:  competitivePercent,
+		Target: targets.MinCompetitivePercent,
+		Met:    competitivePercent >= targets.MinCompetitivePercent,
+	})
+	directShare := 0.0
+	if entity.Amount > 0 {
+		directShare = 100 * directAmount / entity.Amount
+	}
+	entity.Indicators = append(entity.Indicators, KPIIndicator{
+		Name:   KPIDirectContracting,
+		Value:  directShare,
+		Target: targets.MaxDirectShare,
+		Met:    directShare <= targets.MaxDirectShare,
+	})
+
+	met := 0
+	for _, indicator := range entity.Indicators {
+		if indicator.Met {
+			met++
+		}
+	}
+	entity.Score = 100 * float64(met) / float64(len(entity.Indicators))
+	return entity
+}
+
+// contractAwardedAt retorna cuándo se adjudicó el contrato según su línea de auditoría
+func contractAwardedAt(contract *Contract) (time.Time, bool) {
+	for _, entry := range contract.AuditTrail {
+		if entry.Action == "CONTRACT_AWARDED" {
+			return entry.Timestamp, true
+		}
+	}
+	if contract.AwardedTo != "" {
+		return contract.UpdatedAt, true
+	}
+	return time.Time{}, false
+}
+
+// Report retorna el informe guardado del periodo
+func (cs *ComplianceScorer) Report(period string) (*ComplianceReport, bool) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	report, exists := cs.reports[period]
+	return report, exists
+}
+
+// Latest retorna el informe del periodo más reciente, o nil si aún no hay ninguno
+func (cs *ComplianceScorer) Latest() *ComplianceReport {
+	periods := cs.Periods()
+	if len(periods) == 0 {
+		return nil
+	}
+	report, _ := cs.Report(periods[0])
+	return report
+}
+
+// Periods lista los periodos con informe, el más reciente primero
+func (cs *ComplianceScorer) Periods() []string {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	periods := make([]string, 0, len(cs.reports))
+	for period := range cs.reports {
+		periods = append(periods, period)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(periods)))
+	return periods
+}
+
+// Run calcula el cumplimiento del mes anterior en cuanto cierra; revisa cada every si
+// ya hay informe de ese mes
+func (cs *ComplianceScorer) Run(every time.Duration) {
+	cs.scoreClosedMonth()
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cs.scoreClosedMonth()
+	}
+}
+
+// scoreClosedMonth calcula el informe del mes anterior si aún no existe
+func (cs *ComplianceScorer) scoreClosedMonth() {
+	now := time.Now()
+	period := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0).Format(compliancePeriodLayout)
+	if _, exists := cs.Report(period); exists {
+		return
+	}
+	if _, err := cs.Score(period); err != nil {
+		logf("❌ Error calculando el cumplimiento de metas de %s: %v\n", period, err)
+	}
+}
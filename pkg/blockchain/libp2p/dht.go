@@ -0,0 +1,343 @@
+package libp2p
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/bits"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// DHT Kademlia para descubrir nodos: cada nodo guarda peers en k-buckets según cuántos bits
+// iniciales comparte el SHA-256 de su peer ID con el propio, y responde FIND_NODE con los
+// que conoce más cercanos a una llave. Buscar el propio ID y uno al azar cada cierto tiempo
+// llena la tabla; los peers que se encuentran así quedan conectados. Los mensajes son los de
+// la DHT de libp2p, con un protocolo propio para no mezclarse con la DHT pública de IPFS.
+
+// DHTProtocol es el ID del protocolo de la DHT de la red
+const DHTProtocol = "/secop/kad/1.0.0"
+
+// Parámetros de Kademlia
+const (
+	bucketSize      = 20 // K: peers por bucket y peers que retorna una búsqueda
+	lookupAlpha     = 3  // Consultas simultáneas en una búsqueda
+	queryTimeout    = 10 * time.Second
+	messageFindNode = 4
+)
+
+// Campos del mensaje de la DHT y de cada peer que retorna
+const (
+	dhtType        = 1
+	dhtKey         = 2
+	dhtCloserPeers = 8
+	dhtPeerID      = 1
+	dhtPeerAddrs   = 2
+	dhtPeerConn    = 3
+)
+
+// Estado de conexión de un peer en las respuestas
+const connectionConnected = 1
+
+// DHT es la tabla de ruteo Kademlia del host
+type DHT struct {
+	host    *Host
+	self    [32]byte
+	buckets [257][]PeerID // Índice: bits iniciales en común con el propio ID
+	mutex   sync.Mutex
+}
+
+// NewDHT atiende el protocolo de la DHT en el host y agrega a la tabla los peers que se
+// conectan y lo anuncian en identify
+func NewDHT(h *Host) *DHT {
+	d := &DHT{host: h, self: sha256.Sum256(h.ID().Bytes())}
+	h.SetStreamHandler(DHTProtocol, d.handle)
+	h.Notify(func(conn *Conn) {
+		if h.SupportsProtocol(conn.RemotePeer(), DHTProtocol) {
+			d.addPeer(conn.RemotePeer())
+		}
+	})
+	return d
+}
+
+// commonPrefix retorna cuántos bits iniciales comparten dos llaves
+func commonPrefix(a, b [32]byte) int {
+	for i := range a {
+		if x := a[i] ^ b[i]; x != 0 {
+			return i*8 + bits.LeadingZeros8(x)
+		}
+	}
+	return len(a) * 8
+}
+
+// closer indica si a está más cerca de target que b en la métrica XOR
+func closer(a, b, target [32]byte) bool {
+	for i := range target {
+		da, db := a[i]^target[i], b[i]^target[i]
+		if da != db {
+			return da < db
+		}
+	}
+	return false
+}
+
+// addPeer agrega el peer a su bucket o lo marca como el más reciente. Con el bucket lleno
+// reemplaza al más antiguo solo si ya no está conectado, como en Kademlia, para que
+// inundar la red de identidades nuevas no desplace a los peers estables.
+func (d *DHT) addPeer(peer PeerID) {
+	if peer == d.host.ID() {
+		return
+	}
+	index := commonPrefix(d.self, sha256.Sum256(peer.Bytes()))
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	bucket := d.buckets[index]
+	for i, known := range bucket {
+		if known == peer {
+			d.buckets[index] = append(append(bucket[:i:i], bucket[i+1:]...), peer)
+			return
+		}
+	}
+	if len(bucket) < bucketSize {
+		d.buckets[index] = append(bucket, peer)
+		return
+	}
+	if !d.host.IsConnected(bucket[0]) {
+		d.buckets[index] = append(bucket[1:len(bucket):len(bucket)], peer)
+	}
+}
+
+// removePeer saca el peer de la tabla
+func (d *DHT) removePeer(peer PeerID) {
+	index := commonPrefix(d.self, sha256.Sum256(peer.Bytes()))
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	bucket := d.buckets[index]
+	for i, known := range bucket {
+		if known == peer {
+			d.buckets[index] = append(bucket[:i:i], bucket[i+1:]...)
+			return
+		}
+	}
+}
+
+// Peers retorna los peers de la tabla de ruteo
+func (d *DHT) Peers() []PeerID {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var peers []PeerID
+	for _, bucket := range d.buckets {
+		peers = append(peers, bucket...)
+	}
+	return peers
+}
+
+// closest retorna los n peers de la tabla más cercanos a la llave
+func (d *DHT) closest(target [32]byte, n int) []PeerID {
+	peers := d.Peers()
+	hashes := make(map[PeerID][32]byte, len(peers))
+	for _, peer := range peers {
+		hashes[peer] = sha256.Sum256(peer.Bytes())
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return closer(hashes[peers[i]], hashes[peers[j]], target)
+	})
+	if len(peers) > n {
+		peers = peers[:n]
+	}
+	return peers
+}
+
+// handle responde un FIND_NODE con los peers conocidos más cercanos a la llave
+func (d *DHT) handle(stream *Stream) {
+	message, err := ReadMessage(stream)
+	if err != nil {
+		stream.Reset()
+		return
+	}
+	var messageType uint64
+	var key []byte
+	err = consumeFields(message, func(number protowire.Number, typ protowire.Type, value []byte, varint uint64) {
+		switch {
+		case number == dhtType && typ == protowire.VarintType:
+			messageType = varint
+		case number == dhtKey && typ == protowire.BytesType:
+			key = value
+		}
+	})
+	if err != nil || messageType != messageFindNode {
+		stream.Reset()
+		return
+	}
+	// Quien pregunta atiende la DHT: entra a la tabla como cualquier peer conectado
+	d.addPeer(stream.Conn().RemotePeer())
+
+	response := appendVarintField(nil, dhtType, messageFindNode)
+	response = appendBytesField(response, dhtKey, key)
+	for _, peer := range d.closest(sha256.Sum256(key), bucketSize) {
+		if peer == stream.Conn().RemotePeer() {
+			continue
+		}
+		var entry []byte
+		entry = appendBytesField(entry, dhtPeerID, peer.Bytes())
+		for _, addr := range d.host.PeerAddrs(peer) {
+			entry = appendBytesField(entry, dhtPeerAddrs, addr.Bytes())
+		}
+		if d.host.IsConnected(peer) {
+			entry = appendVarintField(entry, dhtPeerConn, connectionConnected)
+		}
+		response = appendBytesField(response, dhtCloserPeers, entry)
+	}
+	if err := WriteMessage(stream, response); err != nil {
+		stream.Reset()
+	}
+}
+
+// FindNode le pregunta a un peer por los más cercanos a la llave y guarda sus direcciones
+func (d *DHT) FindNode(ctx context.Context, peer PeerID, key []byte) ([]PeerID, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	stream, err := d.host.NewStream(ctx, peer, DHTProtocol)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	request := appendVarintField(nil, dhtType, messageFindNode)
+	request = appendBytesField(request, dhtKey, key)
+	if err := WriteMessage(stream, request); err != nil {
+		return nil, err
+	}
+	response, err := ReadMessage(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []PeerID
+	err = consumeFields(response, func(number protowire.Number, typ protowire.Type, value []byte, _ uint64) {
+		if number != dhtCloserPeers || typ != protowire.BytesType || len(found) >= bucketSize {
+			return
+		}
+		var id PeerID
+		var addrs []Addr
+		consumeFields(value, func(number protowire.Number, typ protowire.Type, value []byte, _ uint64) {
+			switch {
+			case number == dhtPeerID && typ == protowire.BytesType:
+				id, _ = peerIDFromBytes(value)
+			case number == dhtPeerAddrs && typ == protowire.BytesType:
+				if addr, err := decodeAddr(value); err == nil && len(addrs) < maxIdentifyAddrs {
+					addrs = append(addrs, addr)
+				}
+			}
+		})
+		if id == "" || id == d.host.ID() {
+			return
+		}
+		d.host.AddAddrs(id, addrs...)
+		found = append(found, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// Lookup busca iterativamente los peers más cercanos a la llave: consulta de a lookupAlpha
+// a los candidatos más cercanos aún no consultados hasta que los bucketSize más cercanos
+// hayan respondido o fallado. Los que responden entran a la tabla de ruteo.
+func (d *DHT) Lookup(ctx context.Context, key []byte) []PeerID {
+	target := sha256.Sum256(key)
+	hashes := make(map[PeerID][32]byte)
+	queried := make(map[PeerID]bool)
+	responded := make(map[PeerID]bool)
+	var candidates []PeerID
+	add := func(peer PeerID) {
+		if _, known := hashes[peer]; known || peer == d.host.ID() {
+			return
+		}
+		hashes[peer] = sha256.Sum256(peer.Bytes())
+		candidates = append(candidates, peer)
+	}
+	for _, peer := range d.closest(target, bucketSize) {
+		add(peer)
+	}
+
+	for ctx.Err() == nil {
+		sort.Slice(candidates, func(i, j int) bool {
+			return closer(hashes[candidates[i]], hashes[candidates[j]], target)
+		})
+		var batch []PeerID
+		for i := 0; i < len(candidates) && i < bucketSize && len(batch) < lookupAlpha; i++ {
+			if !queried[candidates[i]] {
+				batch = append(batch, candidates[i])
+				queried[candidates[i]] = true
+			}
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		type result struct {
+			peer  PeerID
+			found []PeerID
+			err   error
+		}
+		results := make(chan result, len(batch))
+		for _, peer := range batch {
+			go func(peer PeerID) {
+				found, err := d.FindNode(ctx, peer, key)
+				results <- result{peer, found, err}
+			}(peer)
+		}
+		for range batch {
+			r := <-results
+			if r.err != nil {
+				d.removePeer(r.peer)
+				continue
+			}
+			responded[r.peer] = true
+			d.addPeer(r.peer)
+			for _, peer := range r.found {
+				add(peer)
+			}
+		}
+	}
+
+	var closest []PeerID
+	for _, peer := range candidates {
+		if responded[peer] && len(closest) < bucketSize {
+			closest = append(closest, peer)
+		}
+	}
+	return closest
+}
+
+// Bootstrap se conecta con los nodos de arranque y busca el propio ID para llenar la tabla
+func (d *DHT) Bootstrap(ctx context.Context, addrs []Addr) error {
+	var errs []error
+	for _, addr := range addrs {
+		conn, err := d.host.Connect(ctx, addr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		d.addPeer(conn.RemotePeer())
+	}
+	if len(addrs) > 0 && len(errs) == len(addrs) {
+		return errors.Join(errs...)
+	}
+	d.Lookup(ctx, d.host.ID().Bytes())
+	return nil
+}
+
+// Refresh busca el propio ID y uno al azar, para encontrar peers cercanos y lejanos
+func (d *DHT) Refresh(ctx context.Context) {
+	d.Lookup(ctx, d.host.ID().Bytes())
+	random := make([]byte, 32)
+	rand.Read(random)
+	d.Lookup(ctx, random)
+}
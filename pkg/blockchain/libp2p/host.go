@@ -0,0 +1,566 @@
+package libp2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-yamux/v4"
+	"github.com/multiformats/go-multistream"
+)
+
+// Una conexión libp2p se arma en capas: TCP, multistream-select para acordar /noise, el
+// canal cifrado, multistream-select otra vez para acordar /yamux/1.0.0 y el multiplexor.
+// Cada flujo de yamux negocia a su vez su protocolo (p. ej. /ipfs/id/1.0.0) con
+// multistream-select. La conexión sirve en ambos sentidos: un nodo detrás de un NAT que
+// marca a otro recibe por esa misma conexión los flujos que el otro abra hacia él.
+
+// yamuxProtocol es el ID del multiplexor en multistream-select
+const yamuxProtocol = "/yamux/1.0.0"
+
+// Tiempos de la conexión
+const (
+	upgradeTimeout = 15 * time.Second // Handshake Noise y negociación del multiplexor
+	dialTimeout    = 10 * time.Second
+)
+
+// Config configura un Host
+type Config struct {
+	Identity   *Identity
+	ListenAddr string                                   // Multiaddr de escucha, p. ej. /ip4/0.0.0.0/tcp/4001; vacía para no aceptar conexiones
+	Announce   []Addr                                   // Direcciones públicas a anunciar además de las de escucha (p. ej. la del NAT)
+	Logf       func(format string, args ...interface{}) // Registro de los errores en segundo plano
+}
+
+// StreamHandler atiende un flujo entrante; el host cierra el flujo cuando retorna
+type StreamHandler func(stream *Stream)
+
+// Host es el nodo libp2p: acepta y abre conexiones y despacha los flujos por protocolo
+type Host struct {
+	identity  *Identity
+	listener  net.Listener
+	listening []Addr
+	announce  []Addr
+	handlers  *multistream.MultistreamMuxer[string]
+	peers     map[PeerID]*peerInfo
+	conns     map[PeerID][]*Conn
+	observed  map[string]map[PeerID]bool // Dirección propia vista por otros → quiénes la vieron
+	notify    []func(*Conn)
+	logf      func(format string, args ...interface{})
+	closed    chan struct{}
+	closeOnce sync.Once
+	mutex     sync.RWMutex
+}
+
+// peerInfo es lo que el host sabe de un peer por identify
+type peerInfo struct {
+	addrs     []Addr
+	protocols []string
+	agent     string
+}
+
+// Conn es una conexión cifrada y multiplexada con un peer
+type Conn struct {
+	host       *Host
+	session    *yamux.Session
+	remote     PeerID
+	remoteAddr Addr
+	outbound   bool
+	openedAt   time.Time
+}
+
+// Stream es un flujo de una conexión con su protocolo negociado
+type Stream struct {
+	*yamux.Stream
+	conn     *Conn
+	protocol string
+}
+
+// NewHost crea el host y, si tiene dirección de escucha, empieza a aceptar conexiones
+func NewHost(config Config) (*Host, error) {
+	if config.Identity == nil {
+		return nil, errors.New("el host libp2p requiere una identidad")
+	}
+	h := &Host{
+		identity: config.Identity,
+		announce: config.Announce,
+		handlers: multistream.NewMultistreamMuxer[string](),
+		peers:    make(map[PeerID]*peerInfo),
+		conns:    make(map[PeerID][]*Conn),
+		observed: make(map[string]map[PeerID]bool),
+		logf:     config.Logf,
+		closed:   make(chan struct{}),
+	}
+	if h.logf == nil {
+		h.logf = func(string, ...interface{}) {}
+	}
+	h.SetStreamHandler(identifyProtocol, h.handleIdentify)
+
+	if config.ListenAddr != "" {
+		addr, err := ParseAddr(config.ListenAddr)
+		if err != nil {
+			return nil, err
+		}
+		listener, err := net.Listen("tcp", addr.DialAddress())
+		if err != nil {
+			return nil, err
+		}
+		h.listener = listener
+		h.listening = listenAddrs(listener.Addr().(*net.TCPAddr))
+		go h.acceptLoop()
+	}
+	return h, nil
+}
+
+// listenAddrs retorna las direcciones en que se puede contactar al host; si escucha en
+// todas las interfaces, las de cada interfaz
+func listenAddrs(listening *net.TCPAddr) []Addr {
+	if !listening.IP.IsUnspecified() {
+		addr, _ := AddrFromNet(listening)
+		return []Addr{addr}
+	}
+	interfaces, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var addrs []Addr
+	for _, ifaceAddr := range interfaces {
+		ipNet, ok := ifaceAddr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		// Escuchando en 0.0.0.0 solo se atiende IPv4
+		if listening.IP.To4() != nil && ipNet.IP.To4() == nil {
+			continue
+		}
+		addr, err := AddrFromNet(&net.TCPAddr{IP: ipNet.IP, Port: listening.Port})
+		if err == nil {
+			addrs = append(addrs, addr)
+		}
+	}
+	// Las de loopback al final: solo sirven para nodos en la misma máquina
+	sort.SliceStable(addrs, func(i, j int) bool {
+		return !net.ParseIP(addrs[i].Host).IsLoopback() && net.ParseIP(addrs[j].Host).IsLoopback()
+	})
+	return addrs
+}
+
+// ID retorna el peer ID del host
+func (h *Host) ID() PeerID {
+	return h.identity.ID()
+}
+
+// Addrs retorna las direcciones que el host anuncia, con su peer ID: las configuradas, las
+// de escucha y las que al menos observedThreshold peers le reportaron (ver identify)
+func (h *Host) Addrs() []Addr {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	seen := make(map[string]bool)
+	var addrs []Addr
+	add := func(addr Addr) {
+		addr = addr.WithPeer(h.ID())
+		if !seen[addr.String()] {
+			seen[addr.String()] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	for _, addr := range h.announce {
+		add(addr)
+	}
+	for _, addr := range h.listening {
+		add(addr)
+	}
+	observed := make([]string, 0, len(h.observed))
+	for addr, observers := range h.observed {
+		if len(observers) >= observedThreshold {
+			observed = append(observed, addr)
+		}
+	}
+	sort.Strings(observed)
+	for _, value := range observed {
+		if addr, err := ParseAddr(value); err == nil {
+			add(addr)
+		}
+	}
+	return addrs
+}
+
+// SetStreamHandler registra quién atiende los flujos entrantes de un protocolo
+func (h *Host) SetStreamHandler(protocol string, handler StreamHandler) {
+	h.handlers.AddHandler(protocol, func(_ string, rwc io.ReadWriteCloser) error {
+		handler(rwc.(*Stream))
+		return nil
+	})
+}
+
+// Protocols retorna los protocolos que atiende el host
+func (h *Host) Protocols() []string {
+	return h.handlers.Protocols()
+}
+
+// Notify registra un aviso para cada conexión nueva, una vez identificado el peer
+func (h *Host) Notify(connected func(*Conn)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.notify = append(h.notify, connected)
+}
+
+// AddAddrs guarda direcciones conocidas de un peer
+func (h *Host) AddAddrs(peer PeerID, addrs ...Addr) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	info := h.peerInfo(peer)
+	for _, addr := range addrs {
+		addr.Peer = ""
+		if !containsAddr(info.addrs, addr) {
+			info.addrs = append(info.addrs, addr)
+		}
+	}
+}
+
+// PeerAddrs retorna las direcciones conocidas de un peer
+func (h *Host) PeerAddrs(peer PeerID) []Addr {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	if info, exists := h.peers[peer]; exists {
+		return append([]Addr(nil), info.addrs...)
+	}
+	return nil
+}
+
+// SupportsProtocol indica si el peer anunció el protocolo en identify
+func (h *Host) SupportsProtocol(peer PeerID, protocol string) bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	if info, exists := h.peers[peer]; exists {
+		for _, supported := range info.protocols {
+			if supported == protocol {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// peerInfo retorna el registro del peer, creándolo; requiere el lock de escritura
+func (h *Host) peerInfo(peer PeerID) *peerInfo {
+	info, exists := h.peers[peer]
+	if !exists {
+		info = &peerInfo{}
+		h.peers[peer] = info
+	}
+	return info
+}
+
+func containsAddr(addrs []Addr, addr Addr) bool {
+	for _, known := range addrs {
+		if known == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Connect abre una conexión con la dirección, o reutiliza la que haya con su peer
+func (h *Host) Connect(ctx context.Context, addr Addr) (*Conn, error) {
+	if addr.Peer == h.ID() {
+		return nil, errors.New("no se puede conectar el nodo consigo mismo")
+	}
+	if addr.Peer != "" {
+		if conn := h.connTo(addr.Peer); conn != nil {
+			return conn, nil
+		}
+	}
+
+	dialer := net.Dialer{Timeout: dialTimeout}
+	raw, err := dialer.DialContext(ctx, "tcp", addr.DialAddress())
+	if err != nil {
+		return nil, err
+	}
+	conn, err := h.upgrade(raw, true, addr.Peer)
+	if err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("conexión con %s: %v", addr, err)
+	}
+	h.AddAddrs(conn.remote, addr)
+	return conn, nil
+}
+
+// NewStream abre un flujo con el peer para el protocolo, conectándose con sus direcciones
+// conocidas si aún no hay conexión
+func (h *Host) NewStream(ctx context.Context, peer PeerID, protocol string) (*Stream, error) {
+	conn := h.connTo(peer)
+	if conn == nil {
+		var err error
+		if conn, err = h.dialPeer(ctx, peer); err != nil {
+			return nil, err
+		}
+	}
+	return conn.NewStream(ctx, protocol)
+}
+
+// dialPeer se conecta con el peer probando sus direcciones conocidas en orden
+func (h *Host) dialPeer(ctx context.Context, peer PeerID) (*Conn, error) {
+	addrs := h.PeerAddrs(peer)
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("sin conexión ni direcciones conocidas de %s", peer)
+	}
+	var errs []error
+	for _, addr := range addrs {
+		conn, err := h.Connect(ctx, addr.WithPeer(peer))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// connTo retorna la conexión abierta más antigua con el peer, o nil
+func (h *Host) connTo(peer PeerID) *Conn {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for _, conn := range h.conns[peer] {
+		if !conn.session.IsClosed() {
+			return conn
+		}
+	}
+	return nil
+}
+
+// IsConnected indica si hay una conexión abierta con el peer
+func (h *Host) IsConnected(peer PeerID) bool {
+	return h.connTo(peer) != nil
+}
+
+// Conns retorna las conexiones abiertas
+func (h *Host) Conns() []*Conn {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	var conns []*Conn
+	for _, peerConns := range h.conns {
+		for _, conn := range peerConns {
+			if !conn.session.IsClosed() {
+				conns = append(conns, conn)
+			}
+		}
+	}
+	sort.Slice(conns, func(i, j int) bool { return conns[i].remote < conns[j].remote })
+	return conns
+}
+
+// Close cierra el listener y todas las conexiones
+func (h *Host) Close() error {
+	h.closeOnce.Do(func() {
+		close(h.closed)
+		if h.listener != nil {
+			h.listener.Close()
+		}
+		for _, conn := range h.Conns() {
+			conn.Close()
+		}
+	})
+	return nil
+}
+
+// acceptLoop acepta las conexiones entrantes hasta que se cierra el host
+func (h *Host) acceptLoop() {
+	for {
+		raw, err := h.listener.Accept()
+		if err != nil {
+			select {
+			case <-h.closed:
+				return
+			default:
+			}
+			h.logf("❌ Error aceptando conexión libp2p: %v\n", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go func() {
+			if _, err := h.upgrade(raw, false, ""); err != nil {
+				h.logf("⚠️ Conexión libp2p entrante de %s rechazada: %v\n", raw.RemoteAddr(), err)
+				raw.Close()
+			}
+		}()
+	}
+}
+
+// upgrade cifra y multiplexa la conexión TCP, la registra e identifica al peer
+func (h *Host) upgrade(raw net.Conn, outbound bool, expected PeerID) (*Conn, error) {
+	raw.SetDeadline(time.Now().Add(upgradeTimeout))
+	if err := negotiate(raw, outbound, noiseProtocol); err != nil {
+		return nil, err
+	}
+	secure, err := secureConnection(raw, h.identity, outbound, expected)
+	if err != nil {
+		return nil, err
+	}
+	if err := negotiate(secure, outbound, yamuxProtocol); err != nil {
+		return nil, err
+	}
+	raw.SetDeadline(time.Time{})
+
+	config := yamux.DefaultConfig()
+	config.LogOutput = io.Discard
+	var session *yamux.Session
+	if outbound {
+		session, err = yamux.Client(secure, config, nil)
+	} else {
+		session, err = yamux.Server(secure, config, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	remoteAddr, _ := AddrFromNet(raw.RemoteAddr())
+	conn := &Conn{
+		host:       h,
+		session:    session,
+		remote:     secure.RemotePeer(),
+		remoteAddr: remoteAddr,
+		outbound:   outbound,
+		openedAt:   time.Now(),
+	}
+	h.mutex.Lock()
+	h.conns[conn.remote] = append(h.conns[conn.remote], conn)
+	h.mutex.Unlock()
+
+	go h.acceptStreams(conn)
+	go h.identified(conn)
+	return conn, nil
+}
+
+// negotiate acuerda con multistream-select el protocolo de la conexión: propone el que
+// marca y acepta solo ese el que recibe
+func negotiate(rwc io.ReadWriteCloser, initiator bool, protocol string) error {
+	if initiator {
+		return multistream.SelectProtoOrFail(protocol, rwc)
+	}
+	mux := multistream.NewMultistreamMuxer[string]()
+	mux.AddHandler(protocol, nil)
+	_, _, err := mux.Negotiate(rwc)
+	return err
+}
+
+// identified consulta identify del peer recién conectado y avisa a los interesados
+func (h *Host) identified(conn *Conn) {
+	if err := h.identify(conn); err != nil {
+		h.logf("⚠️ identify con %s falló: %v\n", conn.remote, err)
+	}
+	h.mutex.RLock()
+	notify := append([]func(*Conn){}, h.notify...)
+	h.mutex.RUnlock()
+	for _, connected := range notify {
+		connected(conn)
+	}
+}
+
+// acceptStreams atiende los flujos que abre el peer hasta que se cierra la conexión
+func (h *Host) acceptStreams(conn *Conn) {
+	defer h.removeConn(conn)
+	for {
+		yamuxStream, err := conn.session.AcceptStream()
+		if err != nil {
+			return
+		}
+		go func() {
+			stream := &Stream{Stream: yamuxStream, conn: conn}
+			stream.SetDeadline(time.Now().Add(upgradeTimeout))
+			protocol, handler, err := h.handlers.Negotiate(stream)
+			if err != nil {
+				stream.Reset()
+				return
+			}
+			stream.SetDeadline(time.Time{})
+			stream.protocol = protocol
+			handler(protocol, stream)
+			stream.Close()
+		}()
+	}
+}
+
+// removeConn olvida la conexión cerrada
+func (h *Host) removeConn(conn *Conn) {
+	conn.Close()
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	conns := h.conns[conn.remote]
+	for i, known := range conns {
+		if known == conn {
+			conns = append(conns[:i], conns[i+1:]...)
+			break
+		}
+	}
+	if len(conns) == 0 {
+		delete(h.conns, conn.remote)
+	} else {
+		h.conns[conn.remote] = conns
+	}
+}
+
+// RemotePeer retorna el peer ID autenticado del otro extremo
+func (c *Conn) RemotePeer() PeerID {
+	return c.remote
+}
+
+// RemoteAddr retorna la dirección desde la que se ve al peer
+func (c *Conn) RemoteAddr() Addr {
+	return c.remoteAddr
+}
+
+// Outbound indica si este nodo abrió la conexión
+func (c *Conn) Outbound() bool {
+	return c.outbound
+}
+
+// OpenedAt retorna cuándo se abrió la conexión
+func (c *Conn) OpenedAt() time.Time {
+	return c.openedAt
+}
+
+// Close cierra la conexión y sus flujos
+func (c *Conn) Close() error {
+	return c.session.Close()
+}
+
+// NewStream abre un flujo en la conexión y negocia el protocolo
+func (c *Conn) NewStream(ctx context.Context, protocol string) (*Stream, error) {
+	yamuxStream, err := c.session.OpenStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream := &Stream{Stream: yamuxStream, conn: c, protocol: protocol}
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetDeadline(deadline)
+	} else {
+		stream.SetDeadline(time.Now().Add(upgradeTimeout))
+	}
+	if err := multistream.SelectProtoOrFail(protocol, stream); err != nil {
+		stream.Reset()
+		return nil, fmt.Errorf("%s no atiende %s: %v", c.remote, protocol, err)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		stream.SetDeadline(time.Time{})
+	}
+	return stream, nil
+}
+
+// Conn retorna la conexión del flujo
+func (s *Stream) Conn() *Conn {
+	return s.conn
+}
+
+// Protocol retorna el protocolo negociado del flujo
+func (s *Stream) Protocol() string {
+	return s.protocol
+}
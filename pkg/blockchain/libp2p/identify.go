@@ -0,0 +1,157 @@
+package libp2p
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// identify: al conectarse, cada nodo le pregunta al otro su llave, las direcciones en que
+// escucha, los protocolos que atiende y desde qué dirección lo ve. Esta última es la que
+// permite a un nodo detrás de un NAT conocer su dirección pública: cuando al menos
+// observedThreshold peers distintos la reportan, el host la anuncia como propia.
+
+// identifyProtocol es el ID del protocolo identify de libp2p
+const identifyProtocol = "/ipfs/id/1.0.0"
+
+// Versiones que el nodo anuncia en identify
+const (
+	protocolVersion = "ipfs/0.1.0"
+	agentVersion    = "secop-blockchain"
+)
+
+// Límites de lo que se guarda de identify
+const (
+	observedThreshold = 2  // Peers distintos que deben reportar una dirección para anunciarla
+	maxObservedAddrs  = 32 // Direcciones observadas distintas que se siguen a la vez
+	maxIdentifyAddrs  = 16 // Direcciones de escucha que se aceptan de un peer
+)
+
+// Campos del mensaje Identify
+const (
+	identifyPublicKey       = 1
+	identifyListenAddrs     = 2
+	identifyProtocols       = 3
+	identifyObservedAddr    = 4
+	identifyProtocolVersion = 5
+	identifyAgentVersion    = 6
+)
+
+// handleIdentify responde con lo que el host sabe de sí mismo
+func (h *Host) handleIdentify(stream *Stream) {
+	var message []byte
+	message = appendBytesField(message, identifyPublicKey, h.identity.publicKey())
+	for _, addr := range h.Addrs() {
+		message = appendBytesField(message, identifyListenAddrs, addr.WithPeer("").Bytes())
+	}
+	for _, protocol := range h.Protocols() {
+		message = appendBytesField(message, identifyProtocols, []byte(protocol))
+	}
+	message = appendBytesField(message, identifyObservedAddr, stream.Conn().RemoteAddr().Bytes())
+	message = appendBytesField(message, identifyProtocolVersion, []byte(protocolVersion))
+	message = appendBytesField(message, identifyAgentVersion, []byte(agentVersion))
+	if err := WriteMessage(stream, message); err != nil {
+		stream.Reset()
+	}
+}
+
+// identify consulta al peer de la conexión y guarda sus direcciones y protocolos
+func (h *Host) identify(conn *Conn) error {
+	ctx, cancel := context.WithTimeout(context.Background(), upgradeTimeout)
+	defer cancel()
+	stream, err := conn.NewStream(ctx, identifyProtocol)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	message, err := ReadMessage(stream)
+	if err != nil {
+		return err
+	}
+
+	var publicKey []byte
+	var addrs []Addr
+	var protocols []string
+	var observed Addr
+	var agent string
+	err = consumeFields(message, func(number protowire.Number, typ protowire.Type, value []byte, _ uint64) {
+		if typ != protowire.BytesType {
+			return
+		}
+		switch number {
+		case identifyPublicKey:
+			publicKey = value
+		case identifyListenAddrs:
+			if addr, err := decodeAddr(value); err == nil && len(addrs) < maxIdentifyAddrs {
+				addrs = append(addrs, addr)
+			}
+		case identifyProtocols:
+			protocols = append(protocols, string(value))
+		case identifyObservedAddr:
+			observed, _ = decodeAddr(value)
+		case identifyAgentVersion:
+			agent = string(value)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if publicKey != nil {
+		key, err := unmarshalPublicKey(publicKey)
+		if err != nil || IDFromPublicKey(key) != conn.remote {
+			return errors.New("la llave anunciada en identify no corresponde al peer de la conexión")
+		}
+	}
+
+	h.AddAddrs(conn.remote, addrs...)
+	h.mutex.Lock()
+	info := h.peerInfo(conn.remote)
+	info.protocols = protocols
+	info.agent = agent
+	h.mutex.Unlock()
+	h.recordObserved(conn.remote, observed)
+	return nil
+}
+
+// recordObserved anota la dirección desde la que un peer ve al host. Solo interesa la IP:
+// el puerto observado es el que el NAT asignó a la conexión, así que se anuncia con el de
+// escucha, que es el que el NAT reenvía si está configurado para ello.
+func (h *Host) recordObserved(observer PeerID, observed Addr) {
+	if observed.Network == "" || h.listener == nil {
+		return
+	}
+	ip := net.ParseIP(observed.Host)
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	port := h.listener.Addr().(*net.TCPAddr).Port
+	for _, addr := range h.listening {
+		if addr.Host == observed.Host {
+			return
+		}
+	}
+	key := Addr{Network: observed.Network, Host: observed.Host, Port: port}.String()
+	observers, exists := h.observed[key]
+	if !exists {
+		if len(h.observed) >= maxObservedAddrs {
+			return
+		}
+		observers = make(map[PeerID]bool)
+		h.observed[key] = observers
+	}
+	observers[observer] = true
+}
+
+// Agent retorna la versión de agente que el peer anunció en identify
+func (h *Host) Agent(peer PeerID) string {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	if info, exists := h.peers[peer]; exists {
+		return info.agent
+	}
+	return ""
+}
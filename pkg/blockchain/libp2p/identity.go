@@ -0,0 +1,205 @@
+// Package libp2p implementa el subconjunto de libp2p que usa el backend P2P_BACKEND=libp2p:
+// identidades Ed25519 con peer IDs, conexiones TCP cifradas con Noise y multiplexadas con
+// yamux (negociadas con multistream-select), el protocolo identify y una DHT Kademlia para
+// descubrir nodos. Los formatos en la red son los de las especificaciones de libp2p.
+package libp2p
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mr-tron/base58"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Tipo de llave Ed25519 en los mensajes PublicKey y PrivateKey de libp2p
+const keyTypeEd25519 = 1
+
+// Código multihash "identity": el peer ID de una llave Ed25519 es la llave misma
+const multihashIdentity = 0x00
+
+// PeerID identifica un nodo libp2p: el multihash de su llave pública, en base58 ("12D3KooW...")
+type PeerID string
+
+// Identity es la llave Ed25519 con la que el nodo se autentica en el handshake Noise
+type Identity struct {
+	privateKey ed25519.PrivateKey
+	id         PeerID
+}
+
+// NewIdentity genera una identidad efímera
+func NewIdentity() (*Identity, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newIdentity(privateKey), nil
+}
+
+func newIdentity(privateKey ed25519.PrivateKey) *Identity {
+	return &Identity{
+		privateKey: privateKey,
+		id:         IDFromPublicKey(privateKey.Public().(ed25519.PublicKey)),
+	}
+}
+
+// LoadOrCreateIdentity carga la identidad del archivo o genera una y la guarda, para que el
+// peer ID del nodo no cambie al reiniciar. El archivo guarda la llave privada serializada
+// como en libp2p (mensaje PrivateKey).
+func LoadOrCreateIdentity(path string) (*Identity, error) {
+	if path == "" {
+		return NewIdentity()
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		privateKey, err := unmarshalPrivateKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("identidad libp2p inválida en %s: %v", path, err)
+		}
+		return newIdentity(privateKey), nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	identity, err := NewIdentity()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, marshalKey(identity.privateKey), 0600); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// ID retorna el peer ID de la identidad
+func (identity *Identity) ID() PeerID {
+	return identity.id
+}
+
+// sign firma con la llave de la identidad
+func (identity *Identity) sign(data []byte) []byte {
+	return ed25519.Sign(identity.privateKey, data)
+}
+
+// publicKey retorna la llave pública serializada como en libp2p (mensaje PublicKey)
+func (identity *Identity) publicKey() []byte {
+	return marshalKey(identity.privateKey.Public().(ed25519.PublicKey))
+}
+
+// IDFromPublicKey calcula el peer ID de una llave pública Ed25519
+func IDFromPublicKey(publicKey ed25519.PublicKey) PeerID {
+	return PeerID(base58.Encode(idBytes(marshalKey(publicKey))))
+}
+
+// idBytes arma el multihash identity de la llave serializada
+func idBytes(serializedKey []byte) []byte {
+	digest := protowire.AppendVarint(nil, multihashIdentity)
+	digest = protowire.AppendVarint(digest, uint64(len(serializedKey)))
+	return append(digest, serializedKey...)
+}
+
+// ParsePeerID valida un peer ID en base58
+func ParsePeerID(value string) (PeerID, error) {
+	raw, err := base58.Decode(value)
+	if err != nil {
+		return "", fmt.Errorf("peer ID inválido: %v", err)
+	}
+	return peerIDFromBytes(raw)
+}
+
+// peerIDFromBytes valida el multihash de un peer ID
+func peerIDFromBytes(raw []byte) (PeerID, error) {
+	if _, err := publicKeyFromID(raw); err != nil {
+		return "", err
+	}
+	return PeerID(base58.Encode(raw)), nil
+}
+
+// Bytes retorna el multihash del peer ID, como viaja en los mensajes de la DHT y en /p2p/
+func (id PeerID) Bytes() []byte {
+	raw, _ := base58.Decode(string(id))
+	return raw
+}
+
+// String abrevia el peer ID para los registros
+func (id PeerID) String() string {
+	if len(id) <= 16 {
+		return string(id)
+	}
+	return string(id[:8]) + "…" + string(id[len(id)-6:])
+}
+
+// publicKeyFromID extrae la llave Ed25519 de un peer ID con multihash identity
+func publicKeyFromID(raw []byte) (ed25519.PublicKey, error) {
+	code, n := protowire.ConsumeVarint(raw)
+	if n < 0 || code != multihashIdentity {
+		return nil, errors.New("peer ID sin llave embebida: solo se admiten llaves Ed25519")
+	}
+	raw = raw[n:]
+	length, n := protowire.ConsumeVarint(raw)
+	if n < 0 || uint64(len(raw)-n) != length {
+		return nil, errors.New("multihash del peer ID mal formado")
+	}
+	return unmarshalPublicKey(raw[n:])
+}
+
+// marshalKey serializa una llave Ed25519 como los mensajes PublicKey y PrivateKey de libp2p
+func marshalKey(key []byte) []byte {
+	data := protowire.AppendTag(nil, 1, protowire.VarintType)
+	data = protowire.AppendVarint(data, keyTypeEd25519)
+	data = protowire.AppendTag(data, 2, protowire.BytesType)
+	return protowire.AppendBytes(data, key)
+}
+
+// unmarshalKey lee el mensaje de una llave y retorna su contenido
+func unmarshalKey(data []byte) ([]byte, error) {
+	var keyType uint64
+	var key []byte
+	err := consumeFields(data, func(number protowire.Number, typ protowire.Type, value []byte, varint uint64) {
+		switch {
+		case number == 1 && typ == protowire.VarintType:
+			keyType = varint
+		case number == 2 && typ == protowire.BytesType:
+			key = value
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if keyType != keyTypeEd25519 {
+		return nil, fmt.Errorf("tipo de llave %d no soportado: solo Ed25519", keyType)
+	}
+	return key, nil
+}
+
+// unmarshalPublicKey lee una llave pública Ed25519 serializada
+func unmarshalPublicKey(data []byte) (ed25519.PublicKey, error) {
+	key, err := unmarshalKey(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("llave pública Ed25519 de tamaño inválido")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// unmarshalPrivateKey lee una llave privada Ed25519 serializada (semilla y llave pública)
+func unmarshalPrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	key, err := unmarshalKey(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("llave privada Ed25519 de tamaño inválido")
+	}
+	return ed25519.NewKeyFromSeed(key[:ed25519.SeedSize]), nil
+}
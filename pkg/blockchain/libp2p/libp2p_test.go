@@ -0,0 +1,233 @@
+package libp2p
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestHost(t *testing.T) *Host {
+	t.Helper()
+	identity, err := NewIdentity()
+	if err != nil {
+		t.Fatalf("generando la identidad: %v", err)
+	}
+	h, err := NewHost(Config{Identity: identity, ListenAddr: "/ip4/127.0.0.1/tcp/0"})
+	if err != nil {
+		t.Fatalf("creando el host: %v", err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+// waitFor espera hasta que la condición se cumpla o vence el plazo
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("no se cumplió: %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "libp2p.key")
+	first, err := LoadOrCreateIdentity(path)
+	if err != nil {
+		t.Fatalf("creando la identidad: %v", err)
+	}
+	second, err := LoadOrCreateIdentity(path)
+	if err != nil {
+		t.Fatalf("cargando la identidad: %v", err)
+	}
+	if first.ID() != second.ID() {
+		t.Fatalf("el peer ID cambió al recargar: %s != %s", first.ID(), second.ID())
+	}
+	if !strings.HasPrefix(string(first.ID()), "12D3KooW") {
+		t.Fatalf("peer ID %s sin el prefijo de las llaves Ed25519", first.ID())
+	}
+	parsed, err := ParsePeerID(string(first.ID()))
+	if err != nil || parsed != first.ID() {
+		t.Fatalf("ParsePeerID(%s) = %s, %v", first.ID(), parsed, err)
+	}
+	if _, err := ParsePeerID("QmYyQSo1c1Ym7orWxLYvCrM2EmxFTANf8wXmmE7DWjhx5N"); err == nil {
+		t.Fatal("se aceptó un peer ID sin llave embebida")
+	}
+}
+
+func TestAddr(t *testing.T) {
+	identity, _ := NewIdentity()
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"/ip4/10.0.0.5/tcp/4001", true},
+		{"/ip6/::1/tcp/4001", true},
+		{"/dns4/nodo.secop.gov.co/tcp/4001", true},
+		{"/ip4/10.0.0.5/tcp/4001/p2p/" + string(identity.ID()), true},
+		{"/ip4/10.0.0.5/udp/4001/quic", false},
+		{"/ip4/::1/tcp/4001", false},
+		{"/ip4/10.0.0.5/tcp/99999", false},
+		{"/ip4/10.0.0.5/tcp/4001/p2p/no-es-un-peer", false},
+	}
+	for _, tt := range tests {
+		addr, err := ParseAddr(tt.value)
+		if (err == nil) != tt.valid {
+			t.Errorf("ParseAddr(%s): error %v, se esperaba válida=%v", tt.value, err, tt.valid)
+			continue
+		}
+		if !tt.valid {
+			continue
+		}
+		if addr.String() != tt.value {
+			t.Errorf("ParseAddr(%s).String() = %s", tt.value, addr.String())
+		}
+		decoded, err := decodeAddr(addr.Bytes())
+		if err != nil || decoded != addr {
+			t.Errorf("decodeAddr(%s) = %v, %v", tt.value, decoded, err)
+		}
+	}
+}
+
+func TestStreams(t *testing.T) {
+	server := newTestHost(t)
+	client := newTestHost(t)
+
+	server.SetStreamHandler("/secop/echo/1.0.0", func(stream *Stream) {
+		if stream.Conn().RemotePeer() != client.ID() {
+			t.Errorf("el flujo llegó de %s y no de %s", stream.Conn().RemotePeer(), client.ID())
+		}
+		message, err := ReadMessage(stream)
+		if err != nil {
+			t.Errorf("leyendo el mensaje: %v", err)
+			return
+		}
+		WriteMessage(stream, message)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Connect(ctx, server.Addrs()[0]); err != nil {
+		t.Fatalf("conectando: %v", err)
+	}
+	// Mensajes de más de un frame Noise
+	payload := []byte(strings.Repeat("secop", 30000))
+	for i := 0; i < 3; i++ {
+		stream, err := client.NewStream(ctx, server.ID(), "/secop/echo/1.0.0")
+		if err != nil {
+			t.Fatalf("abriendo el flujo: %v", err)
+		}
+		if err := WriteMessage(stream, payload); err != nil {
+			t.Fatalf("escribiendo: %v", err)
+		}
+		echo, err := ReadMessage(stream)
+		if err != nil || string(echo) != string(payload) {
+			t.Fatalf("eco de %d bytes, error %v", len(echo), err)
+		}
+		stream.Close()
+	}
+	if len(client.Conns()) != 1 {
+		t.Fatalf("se esperaba una sola conexión reutilizada, hay %d", len(client.Conns()))
+	}
+
+	// El servidor abre flujos por la conexión que marcó el cliente
+	client.SetStreamHandler("/secop/ping/1.0.0", func(stream *Stream) {
+		io.WriteString(stream, "pong")
+	})
+	waitFor(t, "el servidor registra la conexión", func() bool { return server.IsConnected(client.ID()) })
+	stream, err := server.NewStream(ctx, client.ID(), "/secop/ping/1.0.0")
+	if err != nil {
+		t.Fatalf("abriendo el flujo de vuelta: %v", err)
+	}
+	reply, _ := io.ReadAll(stream)
+	if string(reply) != "pong" {
+		t.Fatalf("respuesta %q", reply)
+	}
+
+	if _, err := client.NewStream(ctx, server.ID(), "/secop/desconocido/1.0.0"); err == nil {
+		t.Fatal("se abrió un flujo de un protocolo que el servidor no atiende")
+	}
+}
+
+func TestConnectRejectsWrongPeer(t *testing.T) {
+	server := newTestHost(t)
+	client := newTestHost(t)
+	impostor, _ := NewIdentity()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Connect(ctx, server.Addrs()[0].WithPeer(impostor.ID())); err == nil {
+		t.Fatal("se aceptó un nodo con un peer ID distinto al de la dirección")
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	server := newTestHost(t)
+	client := newTestHost(t)
+	NewDHT(server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Connect(ctx, server.Addrs()[0]); err != nil {
+		t.Fatalf("conectando: %v", err)
+	}
+	waitFor(t, "identify anuncia la DHT", func() bool { return client.SupportsProtocol(server.ID(), DHTProtocol) })
+	if agent := client.Agent(server.ID()); agent != agentVersion {
+		t.Fatalf("agente %q", agent)
+	}
+
+	// Una dirección observada se anuncia solo cuando la reportan dos peers distintos
+	observed := Addr{Network: "ip4", Host: "203.0.113.7", Port: 5555}
+	port := server.listener.Addr().(*net.TCPAddr).Port
+	announced := Addr{Network: "ip4", Host: "203.0.113.7", Port: port}.WithPeer(server.ID())
+	hasAnnounced := func() bool {
+		for _, addr := range server.Addrs() {
+			if addr == announced {
+				return true
+			}
+		}
+		return false
+	}
+	server.recordObserved(client.ID(), observed)
+	server.recordObserved(client.ID(), observed)
+	if hasAnnounced() {
+		t.Fatal("se anunció una dirección reportada por un solo peer")
+	}
+	other, _ := NewIdentity()
+	server.recordObserved(other.ID(), observed)
+	if !hasAnnounced() {
+		t.Fatalf("no se anunció la dirección observada; direcciones: %v", server.Addrs())
+	}
+}
+
+func TestDHTDiscovery(t *testing.T) {
+	const nodes = 6
+	hosts := make([]*Host, nodes)
+	dhts := make([]*DHT, nodes)
+	for i := range hosts {
+		hosts[i] = newTestHost(t)
+		dhts[i] = NewDHT(hosts[i])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Cada nodo arranca solo con el anterior; la DHT debe conectar al último con el primero
+	for i := 1; i < nodes; i++ {
+		if err := dhts[i].Bootstrap(ctx, []Addr{hosts[i-1].Addrs()[0]}); err != nil {
+			t.Fatalf("bootstrap del nodo %d: %v", i, err)
+		}
+	}
+	found := dhts[nodes-1].Lookup(ctx, hosts[0].ID().Bytes())
+	if len(found) == 0 || found[0] != hosts[0].ID() {
+		t.Fatalf("la búsqueda del primer nodo retornó %v", found)
+	}
+	if !hosts[nodes-1].IsConnected(hosts[0].ID()) {
+		t.Fatal("el último nodo no quedó conectado con el primero")
+	}
+}
@@ -0,0 +1,193 @@
+package libp2p
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Códigos de los protocolos de multiaddr que usa el nodo
+const (
+	codeIP4  = 0x04
+	codeTCP  = 0x06
+	codeIP6  = 0x29
+	codeDNS  = 0x35
+	codeDNS4 = 0x36
+	codeDNS6 = 0x37
+	codeP2P  = 0x01a5
+)
+
+// Addr es una multiaddr TCP: /ip4, /ip6, /dns, /dns4 o /dns6 con el host, /tcp con el puerto
+// y opcionalmente /p2p con el peer ID del nodo que atiende, p. ej.
+// /ip4/10.0.0.5/tcp/4001/p2p/12D3KooW...
+type Addr struct {
+	Network string // ip4, ip6, dns, dns4 o dns6
+	Host    string
+	Port    int
+	Peer    PeerID // Vacío si la dirección no nombra al nodo
+}
+
+// ParseAddr lee una multiaddr en texto
+func ParseAddr(value string) (Addr, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) < 5 || parts[0] != "" || parts[3] != "tcp" {
+		return Addr{}, fmt.Errorf("multiaddr %q inválida: se espera /ip4|ip6|dns|dns4|dns6/<host>/tcp/<puerto>[/p2p/<peer ID>]", value)
+	}
+
+	addr := Addr{Network: parts[1], Host: parts[2]}
+	switch addr.Network {
+	case "ip4", "ip6":
+		ip := net.ParseIP(addr.Host)
+		if ip == nil || (addr.Network == "ip4") != (ip.To4() != nil) {
+			return Addr{}, fmt.Errorf("multiaddr %q: dirección %s inválida", value, addr.Network)
+		}
+		addr.Host = ip.String()
+	case "dns", "dns4", "dns6":
+		if addr.Host == "" {
+			return Addr{}, fmt.Errorf("multiaddr %q: nombre vacío", value)
+		}
+	default:
+		return Addr{}, fmt.Errorf("multiaddr %q: protocolo %s no soportado", value, addr.Network)
+	}
+
+	port, err := strconv.ParseUint(parts[4], 10, 16)
+	if err != nil {
+		return Addr{}, fmt.Errorf("multiaddr %q: puerto inválido", value)
+	}
+	addr.Port = int(port)
+
+	switch rest := parts[5:]; {
+	case len(rest) == 0:
+	case len(rest) == 2 && (rest[0] == "p2p" || rest[0] == "ipfs"):
+		if addr.Peer, err = ParsePeerID(rest[1]); err != nil {
+			return Addr{}, fmt.Errorf("multiaddr %q: %v", value, err)
+		}
+	default:
+		return Addr{}, fmt.Errorf("multiaddr %q: componentes no soportados después del puerto", value)
+	}
+	return addr, nil
+}
+
+// String retorna la multiaddr en texto
+func (addr Addr) String() string {
+	value := fmt.Sprintf("/%s/%s/tcp/%d", addr.Network, addr.Host, addr.Port)
+	if addr.Peer != "" {
+		value += "/p2p/" + string(addr.Peer)
+	}
+	return value
+}
+
+// WithPeer retorna la dirección con el peer ID del nodo que atiende
+func (addr Addr) WithPeer(id PeerID) Addr {
+	addr.Peer = id
+	return addr
+}
+
+// DialAddress retorna host:puerto para abrir la conexión TCP
+func (addr Addr) DialAddress() string {
+	return net.JoinHostPort(addr.Host, strconv.Itoa(addr.Port))
+}
+
+// AddrFromNet convierte la dirección de una conexión TCP en multiaddr
+func AddrFromNet(netAddr net.Addr) (Addr, error) {
+	tcp, ok := netAddr.(*net.TCPAddr)
+	if !ok {
+		return Addr{}, fmt.Errorf("dirección %s no es TCP", netAddr)
+	}
+	addr := Addr{Network: "ip6", Host: tcp.IP.String(), Port: tcp.Port}
+	if tcp.IP.To4() != nil {
+		addr.Network = "ip4"
+		addr.Host = tcp.IP.To4().String()
+	}
+	return addr, nil
+}
+
+// Bytes retorna la multiaddr en binario, como viaja en identify y en la DHT
+func (addr Addr) Bytes() []byte {
+	var data []byte
+	switch addr.Network {
+	case "ip4":
+		data = protowire.AppendVarint(data, codeIP4)
+		data = append(data, net.ParseIP(addr.Host).To4()...)
+	case "ip6":
+		data = protowire.AppendVarint(data, codeIP6)
+		data = append(data, net.ParseIP(addr.Host).To16()...)
+	default:
+		codes := map[string]uint64{"dns": codeDNS, "dns4": codeDNS4, "dns6": codeDNS6}
+		data = protowire.AppendVarint(data, codes[addr.Network])
+		data = protowire.AppendBytes(data, []byte(addr.Host))
+	}
+	data = protowire.AppendVarint(data, codeTCP)
+	data = append(data, byte(addr.Port>>8), byte(addr.Port))
+	if addr.Peer != "" {
+		data = protowire.AppendVarint(data, codeP2P)
+		data = protowire.AppendBytes(data, addr.Peer.Bytes())
+	}
+	return data
+}
+
+// errUnsupportedAddr indica una multiaddr válida pero que no es TCP (p. ej. QUIC o un relay)
+var errUnsupportedAddr = errors.New("multiaddr no soportada")
+
+// decodeAddr lee una multiaddr en binario. Las que no son TCP directo retornan
+// errUnsupportedAddr para que se ignoren sin descartar el resto del mensaje.
+func decodeAddr(data []byte) (Addr, error) {
+	var addr Addr
+	for len(data) > 0 {
+		code, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return Addr{}, errors.New("multiaddr binaria mal formada")
+		}
+		data = data[n:]
+
+		switch code {
+		case codeIP4, codeIP6:
+			size := net.IPv4len
+			addr.Network = "ip4"
+			if code == codeIP6 {
+				size = net.IPv6len
+				addr.Network = "ip6"
+			}
+			if len(data) < size {
+				return Addr{}, errors.New("multiaddr binaria truncada")
+			}
+			addr.Host = net.IP(data[:size]).String()
+			data = data[size:]
+		case codeDNS, codeDNS4, codeDNS6:
+			name, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return Addr{}, errors.New("multiaddr binaria truncada")
+			}
+			addr.Network = map[uint64]string{codeDNS: "dns", codeDNS4: "dns4", codeDNS6: "dns6"}[code]
+			addr.Host = string(name)
+			data = data[n:]
+		case codeTCP:
+			if len(data) < 2 {
+				return Addr{}, errors.New("multiaddr binaria truncada")
+			}
+			addr.Port = int(data[0])<<8 | int(data[1])
+			data = data[2:]
+		case codeP2P:
+			raw, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return Addr{}, errors.New("multiaddr binaria truncada")
+			}
+			id, err := peerIDFromBytes(raw)
+			if err != nil {
+				return Addr{}, err
+			}
+			addr.Peer = id
+			data = data[n:]
+		default:
+			return Addr{}, errUnsupportedAddr
+		}
+	}
+	if addr.Network == "" || addr.Port == 0 {
+		return Addr{}, errUnsupportedAddr
+	}
+	return addr, nil
+}
@@ -0,0 +1,231 @@
+package libp2p
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/flynn/noise"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Canal cifrado de libp2p: handshake Noise XX (Noise_XX_25519_ChaChaPoly_SHA256) en el que
+// cada lado envía, cifrada, su llave de identidad y una firma de su llave estática Noise
+// hecha con ella. Así el canal queda atado al peer ID y nadie en el medio puede leer ni
+// suplantar. Cada mensaje viaja con su longitud en 2 bytes big-endian.
+
+// noiseProtocol es el ID del canal cifrado en multistream-select
+const noiseProtocol = "/noise"
+
+// Prefijo de lo que firma la llave de identidad en el handshake
+const noiseSignaturePrefix = "noise-libp2p-static-key:"
+
+// Tamaño máximo de un mensaje Noise y del texto plano que cabe en él
+const (
+	noiseMaxMessage   = 65535
+	noiseMaxPlaintext = noiseMaxMessage - 16
+)
+
+var noiseSuite = noise.NewCipherSuite(noise.DH25519, noise.CipherChaChaPoly, noise.HashSHA256)
+
+// secureConn es una conexión cifrada con el peer autenticado
+type secureConn struct {
+	net.Conn
+	remote     PeerID
+	send       *noise.CipherState
+	recv       *noise.CipherState
+	pending    []byte // Texto plano ya descifrado que aún no se ha leído
+	readMutex  sync.Mutex
+	writeMutex sync.Mutex
+}
+
+// secureConnection hace el handshake Noise sobre la conexión. El lado que marca es el
+// iniciador y, si conoce el peer ID que espera, rechaza a cualquier otro.
+func secureConnection(conn net.Conn, identity *Identity, initiator bool, expected PeerID) (*secureConn, error) {
+	static, err := noiseSuite.GenerateKeypair(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	state, err := noise.NewHandshakeState(noise.Config{
+		CipherSuite:   noiseSuite,
+		Pattern:       noise.HandshakeXX,
+		Initiator:     initiator,
+		StaticKeypair: static,
+	})
+	if err != nil {
+		return nil, err
+	}
+	payload := handshakePayload(identity, static.Public)
+
+	if initiator {
+		// -> e
+		if err := writeHandshakeMessage(conn, state, nil); err != nil {
+			return nil, err
+		}
+		// <- e, ee, s, es con la identidad del que responde
+		remotePayload, _, _, err := readHandshakeMessage(conn, state)
+		if err != nil {
+			return nil, err
+		}
+		remote, err := verifyHandshakePayload(remotePayload, state.PeerStatic())
+		if err != nil {
+			return nil, err
+		}
+		if expected != "" && remote != expected {
+			return nil, fmt.Errorf("el nodo respondió como %s y se esperaba %s", remote, expected)
+		}
+		// -> s, se con la identidad propia
+		message, cs1, cs2, err := state.WriteMessage(nil, payload)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeNoiseFrame(conn, message); err != nil {
+			return nil, err
+		}
+		return &secureConn{Conn: conn, remote: remote, send: cs1, recv: cs2}, nil
+	}
+
+	// <- e
+	if _, _, _, err := readHandshakeMessage(conn, state); err != nil {
+		return nil, err
+	}
+	// -> e, ee, s, es
+	if err := writeHandshakeMessage(conn, state, payload); err != nil {
+		return nil, err
+	}
+	// <- s, se
+	remotePayload, cs1, cs2, err := readHandshakeMessage(conn, state)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := verifyHandshakePayload(remotePayload, state.PeerStatic())
+	if err != nil {
+		return nil, err
+	}
+	return &secureConn{Conn: conn, remote: remote, send: cs2, recv: cs1}, nil
+}
+
+// handshakePayload arma el NoiseHandshakePayload: llave de identidad y firma de la llave estática
+func handshakePayload(identity *Identity, static []byte) []byte {
+	signature := identity.sign(append([]byte(noiseSignaturePrefix), static...))
+	payload := appendBytesField(nil, 1, identity.publicKey())
+	return appendBytesField(payload, 2, signature)
+}
+
+// verifyHandshakePayload comprueba que la llave de identidad del peer firmó su llave
+// estática Noise y retorna su peer ID
+func verifyHandshakePayload(payload []byte, static []byte) (PeerID, error) {
+	var identityKey, signature []byte
+	err := consumeFields(payload, func(number protowire.Number, typ protowire.Type, value []byte, _ uint64) {
+		switch {
+		case number == 1 && typ == protowire.BytesType:
+			identityKey = value
+		case number == 2 && typ == protowire.BytesType:
+			signature = value
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	publicKey, err := unmarshalPublicKey(identityKey)
+	if err != nil {
+		return "", fmt.Errorf("llave de identidad del peer: %v", err)
+	}
+	if !ed25519.Verify(publicKey, append([]byte(noiseSignaturePrefix), static...), signature) {
+		return "", errors.New("la firma de la llave estática Noise no corresponde a la identidad del peer")
+	}
+	return IDFromPublicKey(publicKey), nil
+}
+
+func writeHandshakeMessage(conn net.Conn, state *noise.HandshakeState, payload []byte) error {
+	message, _, _, err := state.WriteMessage(nil, payload)
+	if err != nil {
+		return err
+	}
+	return writeNoiseFrame(conn, message)
+}
+
+func readHandshakeMessage(conn net.Conn, state *noise.HandshakeState) ([]byte, *noise.CipherState, *noise.CipherState, error) {
+	message, err := readNoiseFrame(conn)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	payload, cs1, cs2, err := state.ReadMessage(nil, message)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("handshake Noise inválido: %v", err)
+	}
+	return payload, cs1, cs2, nil
+}
+
+// writeNoiseFrame escribe un mensaje Noise con su longitud
+func writeNoiseFrame(w io.Writer, message []byte) error {
+	frame := make([]byte, 2, 2+len(message))
+	binary.BigEndian.PutUint16(frame, uint16(len(message)))
+	_, err := w.Write(append(frame, message...))
+	return err
+}
+
+// readNoiseFrame lee un mensaje Noise con su longitud
+func readNoiseFrame(r io.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	message := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// RemotePeer retorna el peer ID autenticado en el handshake
+func (sc *secureConn) RemotePeer() PeerID {
+	return sc.remote
+}
+
+// Read descifra el siguiente mensaje cuando ya se entregó el anterior
+func (sc *secureConn) Read(b []byte) (int, error) {
+	sc.readMutex.Lock()
+	defer sc.readMutex.Unlock()
+
+	for len(sc.pending) == 0 {
+		message, err := readNoiseFrame(sc.Conn)
+		if err != nil {
+			return 0, err
+		}
+		if sc.pending, err = sc.recv.Decrypt(nil, nil, message); err != nil {
+			return 0, fmt.Errorf("mensaje cifrado inválido: %v", err)
+		}
+	}
+	n := copy(b, sc.pending)
+	sc.pending = sc.pending[n:]
+	return n, nil
+}
+
+// Write cifra los datos en mensajes de hasta noiseMaxPlaintext bytes
+func (sc *secureConn) Write(b []byte) (int, error) {
+	sc.writeMutex.Lock()
+	defer sc.writeMutex.Unlock()
+
+	written := 0
+	for written < len(b) {
+		end := written + noiseMaxPlaintext
+		if end > len(b) {
+			end = len(b)
+		}
+		message, err := sc.send.Encrypt(nil, nil, b[written:end])
+		if err != nil {
+			return written, err
+		}
+		if err := writeNoiseFrame(sc.Conn, message); err != nil {
+			return written, err
+		}
+		written = end
+	}
+	return written, nil
+}
@@ -0,0 +1,123 @@
+package libp2p
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MaxMessageSize es el tamaño máximo de un mensaje con prefijo de longitud
+const MaxMessageSize = 16 << 20
+
+// WriteMessage escribe un mensaje con su longitud como varint al inicio, el formato con el
+// que viajan identify, la DHT y los protocolos del nodo
+func WriteMessage(w io.Writer, message []byte) error {
+	if len(message) > MaxMessageSize {
+		return fmt.Errorf("mensaje de %d bytes supera el máximo de %d", len(message), MaxMessageSize)
+	}
+	frame := protowire.AppendVarint(make([]byte, 0, len(message)+binary.MaxVarintLen64), uint64(len(message)))
+	_, err := w.Write(append(frame, message...))
+	return err
+}
+
+// ReadMessage lee un mensaje escrito con WriteMessage
+func ReadMessage(r io.Reader) ([]byte, error) {
+	reader, ok := r.(io.ByteReader)
+	if !ok {
+		reader = &byteReader{r: r}
+	}
+	length, err := readUvarint(reader)
+	if err != nil {
+		return nil, err
+	}
+	if length > MaxMessageSize {
+		return nil, fmt.Errorf("mensaje de %d bytes supera el máximo de %d", length, MaxMessageSize)
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// readUvarint lee un varint sin pedirle al lector más bytes de los necesarios
+func readUvarint(r io.ByteReader) (uint64, error) {
+	var value uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b, err := r.ReadByte()
+		if err != nil {
+			if shift > 0 && errors.Is(err, io.EOF) {
+				return 0, io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		value |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return value, nil
+		}
+	}
+	return 0, errors.New("varint demasiado largo")
+}
+
+// byteReader lee de a un byte sin bufferizar, para no consumir datos del mensaje siguiente
+type byteReader struct {
+	r   io.Reader
+	buf [1]byte
+}
+
+func (br *byteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(br.r, br.buf[:]); err != nil {
+		return 0, err
+	}
+	return br.buf[0], nil
+}
+
+// consumeFields recorre los campos de un mensaje protobuf; para los de tipo bytes entrega
+// su contenido y para los varint su valor. Los demás tipos se saltan.
+func consumeFields(data []byte, field func(number protowire.Number, typ protowire.Type, value []byte, varint uint64)) error {
+	for len(data) > 0 {
+		number, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fmt.Errorf("mensaje protobuf mal formado: %v", protowire.ParseError(n))
+		}
+		data = data[n:]
+		switch typ {
+		case protowire.BytesType:
+			value, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return fmt.Errorf("campo %d mal formado: %v", number, protowire.ParseError(n))
+			}
+			field(number, typ, value, 0)
+			data = data[n:]
+		case protowire.VarintType:
+			value, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return fmt.Errorf("campo %d mal formado: %v", number, protowire.ParseError(n))
+			}
+			field(number, typ, nil, value)
+			data = data[n:]
+		default:
+			n := protowire.ConsumeFieldValue(number, typ, data)
+			if n < 0 {
+				return fmt.Errorf("campo %d mal formado: %v", number, protowire.ParseError(n))
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
+
+// appendBytesField agrega un campo de tipo bytes
+func appendBytesField(data []byte, number protowire.Number, value []byte) []byte {
+	data = protowire.AppendTag(data, number, protowire.BytesType)
+	return protowire.AppendBytes(data, value)
+}
+
+// appendVarintField agrega un campo varint
+func appendVarintField(data []byte, number protowire.Number, value uint64) []byte {
+	data = protowire.AppendTag(data, number, protowire.VarintType)
+	return protowire.AppendVarint(data, value)
+}
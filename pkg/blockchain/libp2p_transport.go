@@ -0,0 +1,543 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/libp2p"
+)
+
+// Transporte libp2p (P2P_BACKEND=libp2p): los nodos se conectan por TCP cifrado con Noise y
+// multiplexado con yamux, se descubren por una DHT Kademlia y cada mensaje viaja en un flujo
+// propio. Como las conexiones sirven en ambos sentidos, un nodo detrás de un NAT que marca a
+// la red recibe los bloques por esa misma conexión aunque nadie pueda llegar a su puerto
+// HTTP; identify le informa además su dirección pública (ver libp2p/identify.go).
+//
+// El peer ID libp2p solo autentica la llave del canal. Para saber qué nodo hay detrás, al
+// abrir una conexión ambos extremos intercambian un saludo con su handshake firmado con la
+// llave de nodo sobre el peer ID libp2p; hasta verificarlo, lo que llegue por la conexión se
+// procesa como anónimo (ver ReceiveBlock) y los envíos a ese nodo siguen por HTTP.
+
+// Protocolos del nodo sobre libp2p
+const (
+	libp2pHelloProtocol  = "/secop/hello/1.0.0"
+	libp2pBlockProtocol  = "/secop/block/1.0.0"
+	libp2pTxProtocol     = "/secop/tx/1.0.0"
+	libp2pBlocksProtocol = "/secop/blocks/1.0.0"
+)
+
+// Prefijo de lo que firma la llave de nodo en el saludo
+const libp2pHelloPrefix = "secop-libp2p-hello:"
+
+// errNoLibp2pPeer indica que no hay un peer libp2p verificado para el nodo
+var errNoLibp2pPeer = errors.New("sin peer libp2p verificado para el nodo")
+
+// Libp2pConfig configura el transporte libp2p; se fija antes de SetBackend
+type Libp2pConfig struct {
+	ListenAddr string   // Multiaddr de escucha, p. ej. /ip4/0.0.0.0/tcp/4001
+	KeyFile    string   // Archivo de la identidad libp2p; vacío para una efímera
+	Announce   []string // Multiaddrs públicas a anunciar, p. ej. la del NAT con el puerto reenviado
+	Bootstrap  []string // Multiaddrs con /p2p de los nodos de arranque de la DHT
+}
+
+// libp2pHello es el saludo con el que un nodo se identifica en una conexión libp2p
+type libp2pHello struct {
+	Handshake HandshakeInfo `json:"handshake"`
+	KeyID     string        `json:"kid,omitempty"`
+	Signature string        `json:"signature,omitempty"` // Llave de nodo sobre libp2pHelloPrefix + nodo + peer ID
+}
+
+// libp2pReply es la respuesta a un bloque, una transacción o una página de bloques
+type libp2pReply struct {
+	Error string        `json:"error,omitempty"`
+	Vote  *FinalityVote `json:"vote,omitempty"`
+	Page  *BlocksPage   `json:"page,omitempty"`
+}
+
+// libp2pTransport entrega los mensajes por libp2p a los nodos verificados y recurre al
+// transporte que envuelve con los demás o si el flujo falla
+type libp2pTransport struct {
+	p2p       *P2PNetwork
+	host      *libp2p.Host
+	dht       *libp2p.DHT
+	bootstrap []libp2p.Addr
+	fallback  P2PTransport
+	nodes     map[libp2p.PeerID]string // Peer libp2p → nodo verificado en el saludo
+	peers     map[string]libp2p.PeerID // Nodo → peer libp2p
+	mutex     sync.RWMutex
+}
+
+// newLibp2pTransport abre el host libp2p con la configuración y registra los protocolos
+func newLibp2pTransport(p2p *P2PNetwork, config Libp2pConfig, fallback P2PTransport) (*libp2pTransport, error) {
+	identity, err := libp2p.LoadOrCreateIdentity(config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	var announce []libp2p.Addr
+	for _, value := range config.Announce {
+		addr, err := libp2p.ParseAddr(value)
+		if err != nil {
+			return nil, err
+		}
+		announce = append(announce, addr)
+	}
+	var bootstrap []libp2p.Addr
+	for _, value := range config.Bootstrap {
+		addr, err := libp2p.ParseAddr(value)
+		if err != nil {
+			return nil, err
+		}
+		if addr.Peer == "" {
+			return nil, fmt.Errorf("nodo de arranque %s sin /p2p/<peer ID>", value)
+		}
+		bootstrap = append(bootstrap, addr)
+	}
+
+	host, err := libp2p.NewHost(libp2p.Config{
+		Identity:   identity,
+		ListenAddr: config.ListenAddr,
+		Announce:   announce,
+		Logf:       logf,
+	})
+	if err != nil {
+		return nil, err
+	}
+	t := &libp2pTransport{
+		p2p:       p2p,
+		host:      host,
+		dht:       libp2p.NewDHT(host),
+		bootstrap: bootstrap,
+		fallback:  fallback,
+		nodes:     make(map[libp2p.PeerID]string),
+		peers:     make(map[string]libp2p.PeerID),
+	}
+	host.SetStreamHandler(libp2pHelloProtocol, t.handleHello)
+	host.SetStreamHandler(libp2pBlockProtocol, t.handleBlock)
+	host.SetStreamHandler(libp2pTxProtocol, t.handleTransaction)
+	host.SetStreamHandler(libp2pBlocksProtocol, t.handleBlocks)
+	// Saluda quien abrió la conexión; el otro extremo responde con su propio saludo
+	host.Notify(func(conn *libp2p.Conn) {
+		if conn.Outbound() {
+			t.hello(conn)
+		}
+	})
+	return t, nil
+}
+
+func (t *libp2pTransport) Name() string {
+	return P2PBackendLibp2p
+}
+
+func (t *libp2pTransport) SendBlock(peer *Peer, block Block) error {
+	var reply libp2pReply
+	err := t.request(peer.ID, libp2pBlockProtocol, block, &reply)
+	if err == nil {
+		if reply.Error != "" {
+			return fmt.Errorf("peer rechazó el bloque: %s", reply.Error)
+		}
+		if reply.Vote != nil && reply.Vote.BlockHash == block.Hash {
+			t.p2p.recordVote(*reply.Vote)
+		}
+		return nil
+	}
+	if err != errNoLibp2pPeer {
+		logf("⚠️ %v, enviando bloque %s por %s\n", err, block.Hash, t.fallback.Name())
+	}
+	return t.fallback.SendBlock(peer, block)
+}
+
+func (t *libp2pTransport) SendTransaction(peer *Peer, tx MempoolTransaction) error {
+	var reply libp2pReply
+	err := t.request(peer.ID, libp2pTxProtocol, tx, &reply)
+	if err == nil {
+		if reply.Error != "" {
+			return fmt.Errorf("peer rechazó la transacción: %s", reply.Error)
+		}
+		return nil
+	}
+	return t.fallback.SendTransaction(peer, tx)
+}
+
+func (t *libp2pTransport) RequestBlocks(peer *Peer, from int) (*BlocksPage, error) {
+	var reply libp2pReply
+	err := t.request(peer.ID, libp2pBlocksProtocol, chainRequest{FromHeight: from, Limit: SyncPageSize}, &reply)
+	if err == nil {
+		if reply.Error != "" {
+			return nil, errors.New(reply.Error)
+		}
+		if reply.Page == nil {
+			return nil, fmt.Errorf("%s respondió sin página de bloques", peer.ID)
+		}
+		return reply.Page, nil
+	}
+	if err != errNoLibp2pPeer {
+		logf("⚠️ %v, pidiendo bloques por %s\n", err, t.fallback.Name())
+	}
+	return t.fallback.RequestBlocks(peer, from)
+}
+
+// request abre un flujo con el peer libp2p del nodo, envía la solicitud y lee la respuesta
+func (t *libp2pTransport) request(nodeID, protocol string, request, reply interface{}) error {
+	t.mutex.RLock()
+	peerID, exists := t.peers[nodeID]
+	t.mutex.RUnlock()
+	if !exists {
+		return errNoLibp2pPeer
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), peerRequestTimeout)
+	defer cancel()
+	stream, err := t.host.NewStream(ctx, peerID, protocol)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	if err := libp2p.WriteMessage(stream, data); err != nil {
+		return err
+	}
+	response, err := libp2p.ReadMessage(stream)
+	if err != nil {
+		return fmt.Errorf("%s no respondió por libp2p: %v", nodeID, err)
+	}
+	return json.Unmarshal(response, reply)
+}
+
+// sender retorna el nodo verificado detrás del flujo, o "" si no se ha verificado
+func (t *libp2pTransport) sender(stream *libp2p.Stream) string {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.nodes[stream.Conn().RemotePeer()]
+}
+
+// serveLibp2p lee la solicitud del flujo, la procesa y escribe la respuesta
+func serveLibp2p(stream *libp2p.Stream, request interface{}, handle func() libp2pReply) {
+	stream.SetDeadline(time.Now().Add(peerRequestTimeout))
+	data, err := libp2p.ReadMessage(stream)
+	if err != nil {
+		stream.Reset()
+		return
+	}
+	var reply libp2pReply
+	if err := json.Unmarshal(data, request); err != nil {
+		reply.Error = err.Error()
+	} else {
+		reply = handle()
+	}
+	response, _ := json.Marshal(reply)
+	libp2p.WriteMessage(stream, response)
+}
+
+// handleBlock procesa un bloque como POST /api/p2p/receive-block y responde con el voto
+// de finalización si este nodo es validador
+func (t *libp2pTransport) handleBlock(stream *libp2p.Stream) {
+	var block Block
+	serveLibp2p(stream, &block, func() libp2pReply {
+		if t.p2p.Maintenance != nil && t.p2p.Maintenance.IsEnabled() {
+			return libp2pReply{Error: "nodo en mantenimiento"}
+		}
+		if err := t.p2p.ReceiveBlock(block, t.sender(stream)); err != nil {
+			return libp2pReply{Error: err.Error()}
+		}
+		var reply libp2pReply
+		if t.p2p.Blockchain.HasBlock(block.Hash) {
+			reply.Vote = t.p2p.Vote(block.Hash)
+		}
+		return reply
+	})
+}
+
+// handleTransaction agrega al mempool una transacción enviada por un peer
+func (t *libp2pTransport) handleTransaction(stream *libp2p.Stream) {
+	var tx MempoolTransaction
+	serveLibp2p(stream, &tx, func() libp2pReply {
+		if t.p2p.Maintenance != nil && t.p2p.Maintenance.IsEnabled() {
+			return libp2pReply{Error: "nodo en mantenimiento"}
+		}
+		if err := t.p2p.ReceiveTransaction(tx, t.sender(stream)); err != nil {
+			return libp2pReply{Error: err.Error()}
+		}
+		return libp2pReply{}
+	})
+}
+
+// handleBlocks responde una página de bloques como GET /api/p2p/blocks, sin los contratos
+// reservados si el nodo que pide no está autorizado
+func (t *libp2pTransport) handleBlocks(stream *libp2p.Stream) {
+	var request chainRequest
+	serveLibp2p(stream, &request, func() libp2pReply {
+		page, err := t.p2p.Blockchain.BlocksFrom(request.FromHeight, request.Limit)
+		if err != nil {
+			return libp2pReply{Error: err.Error()}
+		}
+		page.Blocks = t.p2p.BlocksFor(t.sender(stream), page.Blocks)
+		return libp2pReply{Page: &page}
+	})
+}
+
+// localHello arma el saludo de este nodo
+func (t *libp2pTransport) localHello() libp2pHello {
+	hello := libp2pHello{Handshake: t.p2p.LocalHandshake()}
+	if t.p2p.Keys != nil {
+		hello.KeyID, hello.Signature = t.p2p.Keys.Sign(helloPayload(t.p2p.NodeID, t.host.ID()))
+	}
+	return hello
+}
+
+// helloPayload es lo que firma el nodo: su ID y el peer ID libp2p con que se conecta
+func helloPayload(nodeID string, peerID libp2p.PeerID) []byte {
+	return []byte(libp2pHelloPrefix + nodeID + ":" + string(peerID))
+}
+
+// hello saluda al peer de una conexión que abrió este nodo y verifica su respuesta
+func (t *libp2pTransport) hello(conn *libp2p.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), peerRequestTimeout)
+	defer cancel()
+	stream, err := conn.NewStream(ctx, libp2pHelloProtocol)
+	if err != nil {
+		return
+	}
+	defer stream.Close()
+
+	data, _ := json.Marshal(t.localHello())
+	if err := libp2p.WriteMessage(stream, data); err != nil {
+		return
+	}
+	response, err := libp2p.ReadMessage(stream)
+	if err != nil {
+		logf("⚠️ %s no respondió el saludo libp2p: %v\n", conn.RemotePeer(), err)
+		return
+	}
+	var remote libp2pHello
+	if err := json.Unmarshal(response, &remote); err != nil {
+		return
+	}
+	if err := t.acceptHello(conn.RemotePeer(), remote); err != nil {
+		logf("⚠️ Saludo libp2p de %s rechazado: %v\n", conn.RemotePeer(), err)
+	}
+}
+
+// handleHello verifica el saludo de quien abrió la conexión y responde con el propio
+func (t *libp2pTransport) handleHello(stream *libp2p.Stream) {
+	stream.SetDeadline(time.Now().Add(peerRequestTimeout))
+	data, err := libp2p.ReadMessage(stream)
+	if err != nil {
+		stream.Reset()
+		return
+	}
+	var remote libp2pHello
+	if err := json.Unmarshal(data, &remote); err != nil {
+		stream.Reset()
+		return
+	}
+	remotePeer := stream.Conn().RemotePeer()
+	if err := t.acceptHello(remotePeer, remote); err != nil {
+		logf("⚠️ Saludo libp2p de %s rechazado: %v\n", remotePeer, err)
+	}
+	response, _ := json.Marshal(t.localHello())
+	libp2p.WriteMessage(stream, response)
+}
+
+// acceptHello asocia el peer libp2p con el nodo del saludo si la firma se verifica con las
+// llaves ya conocidas del nodo. Un nodo desconocido se agrega como peer, con las llaves que
+// anuncia, solo si se podría registrar como en RegisterPeer y firmó con ellas; las llaves
+// de un nodo conocido nunca se reemplazan por las del saludo.
+func (t *libp2pTransport) acceptHello(remote libp2p.PeerID, hello libp2pHello) error {
+	p2p := t.p2p
+	info := hello.Handshake
+	if info.NodeID == "" || info.NodeID == p2p.NodeID {
+		return errors.New("saludo sin nodo o del propio nodo")
+	}
+	if hello.Signature == "" {
+		return errors.New("saludo sin firma de nodo")
+	}
+	if p2p.Isolated() {
+		return ErrNetworkIsolated
+	}
+	if p2p.isRemoved(info.NodeID) {
+		return fmt.Errorf("peer %s retirado por un administrador", info.NodeID)
+	}
+	if p2p.Reputation.IsBanned(info.NodeID) {
+		return fmt.Errorf("peer %s vetado", info.NodeID)
+	}
+	if err := p2p.checkGenesis(&info); err != nil {
+		return err
+	}
+
+	payload := helloPayload(info.NodeID, remote)
+	knownKeys := p2p.ValidatorKeys()[info.NodeID]
+	if len(knownKeys) > 0 {
+		if err := VerifyWithKeys(knownKeys, hello.KeyID, payload, hello.Signature); err != nil {
+			return fmt.Errorf("firma del saludo de %s: %v", info.NodeID, err)
+		}
+	} else if err := VerifyWithKeys(info.PublicKeys, hello.KeyID, payload, hello.Signature); err != nil {
+		return fmt.Errorf("firma del saludo de %s: %v", info.NodeID, err)
+	}
+
+	p2p.mutex.RLock()
+	_, exists := p2p.Peers[info.NodeID]
+	count := len(p2p.Peers)
+	p2p.mutex.RUnlock()
+	if !exists && count >= MaxKnownPeers {
+		return fmt.Errorf("se alcanzó el máximo de %d peers", MaxKnownPeers)
+	}
+	if !exists {
+		p2p.registerPeer(info.NodeID, info.Address, info.Port)
+	}
+	p2p.mutex.Lock()
+	if peer, exists := p2p.Peers[info.NodeID]; exists {
+		peer.activate(time.Now())
+		if len(knownKeys) == 0 {
+			p2p.applyHandshake(peer, &info)
+		}
+		peer.Libp2pAddrs = info.Libp2pAddrs
+	}
+	p2p.mutex.Unlock()
+
+	t.mutex.Lock()
+	if previous, bound := t.peers[info.NodeID]; bound && previous != remote {
+		delete(t.nodes, previous)
+	}
+	t.nodes[remote] = info.NodeID
+	t.peers[info.NodeID] = remote
+	t.mutex.Unlock()
+	logf("🛰️ Nodo %s conectado por libp2p como %s\n", info.NodeID, remote)
+	return nil
+}
+
+// connectedTo indica si hay una conexión libp2p abierta con el nodo verificado
+func (t *libp2pTransport) connectedTo(nodeID string) bool {
+	t.mutex.RLock()
+	peerID, exists := t.peers[nodeID]
+	t.mutex.RUnlock()
+	return exists && t.host.IsConnected(peerID)
+}
+
+// disconnect cierra las conexiones con el nodo y olvida su peer libp2p
+func (t *libp2pTransport) disconnect(nodeID string) {
+	t.mutex.Lock()
+	peerID, exists := t.peers[nodeID]
+	delete(t.peers, nodeID)
+	delete(t.nodes, peerID)
+	t.mutex.Unlock()
+	if !exists {
+		return
+	}
+	for _, conn := range t.host.Conns() {
+		if conn.RemotePeer() == peerID {
+			conn.Close()
+		}
+	}
+}
+
+// addrs retorna las multiaddrs del nodo con su peer ID, como se anuncian en el handshake
+func (t *libp2pTransport) addrs() []string {
+	var addrs []string
+	for _, addr := range t.host.Addrs() {
+		addrs = append(addrs, addr.String())
+	}
+	return addrs
+}
+
+// refresh arranca o refresca la DHT, se conecta con los peers que anunciaron direcciones
+// libp2p y repite el saludo en las conexiones propias que aún no se verificaron (p. ej.
+// porque el nodo rotó su llave o aún no se conocía su génesis)
+func (t *libp2pTransport) refresh() {
+	if t.p2p.Isolated() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if len(t.dht.Peers()) == 0 && len(t.bootstrap) > 0 {
+		if err := t.dht.Bootstrap(ctx, t.bootstrap); err != nil {
+			logf("❌ Error conectando con los nodos de arranque libp2p: %v\n", err)
+		}
+	} else {
+		t.dht.Refresh(ctx)
+	}
+
+	for _, peer := range t.p2p.GetActivePeers() {
+		if t.connectedTo(peer.ID) {
+			continue
+		}
+		t.p2p.mutex.RLock()
+		addrs := append([]string(nil), peer.Libp2pAddrs...)
+		t.p2p.mutex.RUnlock()
+		for _, value := range addrs {
+			addr, err := libp2p.ParseAddr(value)
+			if err != nil || addr.Peer == "" {
+				continue
+			}
+			if _, err := t.host.Connect(ctx, addr); err == nil {
+				break
+			}
+		}
+	}
+
+	for _, conn := range t.host.Conns() {
+		t.mutex.RLock()
+		_, verified := t.nodes[conn.RemotePeer()]
+		t.mutex.RUnlock()
+		if conn.Outbound() && !verified {
+			t.hello(conn)
+		}
+	}
+}
+
+// RunLibp2p mantiene cada every la DHT y las conexiones libp2p; no hace nada si el
+// transporte no es libp2p
+func (p2p *P2PNetwork) RunLibp2p(every time.Duration) {
+	if p2p.libp2p == nil {
+		return
+	}
+	p2p.libp2p.refresh()
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p2p.libp2p.refresh()
+	}
+}
+
+// Libp2pID retorna el peer ID libp2p del nodo y sus multiaddrs, o "" si no usa libp2p
+func (p2p *P2PNetwork) Libp2pID() (string, []string) {
+	if p2p.libp2p == nil {
+		return "", nil
+	}
+	return string(p2p.libp2p.host.ID()), p2p.libp2p.addrs()
+}
+
+// libp2pConnections retorna el estado de las conexiones libp2p, con el nodo verificado de
+// cada una o su peer ID si aún no se verificó
+func (p2p *P2PNetwork) libp2pConnections() []PeerConnStatus {
+	t := p2p.libp2p
+	var statuses []PeerConnStatus
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	for _, conn := range t.host.Conns() {
+		status := PeerConnStatus{
+			PeerID:      t.nodes[conn.RemotePeer()],
+			Transport:   P2PBackendLibp2p,
+			Libp2pPeer:  string(conn.RemotePeer()),
+			Outbound:    conn.Outbound(),
+			ConnectedAt: conn.OpenedAt(),
+		}
+		if status.PeerID == "" {
+			status.PeerID = string(conn.RemotePeer())
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].PeerID < statuses[j].PeerID })
+	return statuses
+}
@@ -0,0 +1,101 @@
+package blockchain
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newLibp2pNetwork crea un nodo con transporte libp2p cuyo puerto HTTP no responde, de modo
+// que todo lo que intercambie con otros nodos tiene que viajar por libp2p
+func newLibp2pNetwork(t *testing.T, nodeID string, bootstrap ...string) *P2PNetwork {
+	t.Helper()
+	keys, err := LoadOrCreateNodeKeyring("")
+	if err != nil {
+		t.Fatalf("generando las llaves de %s: %v", nodeID, err)
+	}
+	p2p, err := NewNetwork(nodeID, "127.0.0.1", "1", newTestBlockchain(t),
+		WithNodeKeys(keys),
+		WithLibp2p(Libp2pConfig{ListenAddr: "/ip4/127.0.0.1/tcp/0", Bootstrap: bootstrap}),
+		WithBackend(P2PBackendLibp2p),
+	)
+	if err != nil {
+		t.Fatalf("creando la red de %s: %v", nodeID, err)
+	}
+	t.Cleanup(func() { p2p.libp2p.host.Close() })
+	return p2p
+}
+
+func waitUntil(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("no se cumplió: %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLibp2pTransport(t *testing.T) {
+	a := newLibp2pNetwork(t, "nodo-a")
+	_, addrs := a.Libp2pID()
+	b := newLibp2pNetwork(t, "nodo-b", addrs[0])
+	if b.Backend() != P2PBackendLibp2p {
+		t.Fatalf("backend %s", b.Backend())
+	}
+
+	// El nodo b solo conoce la dirección de arranque; el saludo empareja a ambos nodos
+	b.libp2p.refresh()
+	waitUntil(t, "ambos nodos se verifican", func() bool {
+		return a.libp2p.connectedTo("nodo-b") && b.libp2p.connectedTo("nodo-a")
+	})
+	a.mutex.RLock()
+	peerB, exists := a.Peers["nodo-b"]
+	a.mutex.RUnlock()
+	if !exists || len(peerB.PublicKeys) == 0 || len(peerB.Libp2pAddrs) == 0 {
+		t.Fatalf("nodo-b no quedó registrado con sus llaves y direcciones: %+v", peerB)
+	}
+
+	// a no puede llegar a b por HTTP: la página de bloques tiene que venir por libp2p
+	b.mutex.RLock()
+	peerA := b.Peers["nodo-a"]
+	b.mutex.RUnlock()
+	page, err := b.backend.RequestBlocks(peerA, 0)
+	if err != nil {
+		t.Fatalf("pidiendo bloques por libp2p: %v", err)
+	}
+	if len(page.Blocks) != a.Blockchain.Len() {
+		t.Fatalf("se recibieron %d bloques y a tiene %d", len(page.Blocks), a.Blockchain.Len())
+	}
+
+	// El health check da por vivo al peer alcanzable solo por libp2p
+	a.checkPeers()
+	if len(a.GetActivePeers()) != 1 {
+		t.Fatal("el health check desactivó al peer conectado por libp2p")
+	}
+	statuses := a.PeerConnections()
+	if len(statuses) != 1 || statuses[0].PeerID != "nodo-b" || statuses[0].Transport != P2PBackendLibp2p {
+		t.Fatalf("conexiones %+v", statuses)
+	}
+
+	// Un tercero que se presenta como nodo-a no puede firmar con sus llaves conocidas
+	impostor := newLibp2pNetwork(t, "nodo-a")
+	bAddr := b.libp2p.host.Addrs()[0]
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := impostor.libp2p.host.Connect(ctx, bAddr); err != nil {
+		t.Fatalf("conectando el impostor: %v", err)
+	}
+	waitUntil(t, "b registra la conexión del impostor", func() bool {
+		return b.libp2p.host.IsConnected(impostor.libp2p.host.ID())
+	})
+	time.Sleep(100 * time.Millisecond)
+	b.libp2p.mutex.RLock()
+	bound := b.libp2p.peers["nodo-a"]
+	_, impostorVerified := b.libp2p.nodes[impostor.libp2p.host.ID()]
+	b.libp2p.mutex.RUnlock()
+	if bound != a.libp2p.host.ID() || impostorVerified {
+		t.Fatal("el saludo del impostor reemplazó al nodo-a verificado")
+	}
+}
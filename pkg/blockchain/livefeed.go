@@ -0,0 +1,174 @@
+package blockchain
+
+import (
+	"sync"
+	"time"
+)
+
+// Eventos en vivo para los tableros: en lugar de consultar /api/stats y /api/contracts
+// periódicamente, los clientes reciben por Server-Sent Events los bloques nuevos, los
+// contratos radicados, los pasos validados y los peers que se unen a la red.
+
+// Nombres de los eventos en vivo
+const (
+	LiveContractCreated = "contract_created"
+	LiveStepValidated   = "step_validated"
+	LiveBlockAdded      = "block_added"
+	LivePeerJoined      = "peer_joined"
+)
+
+// Eventos recientes que se conservan para que un cliente reconectado retome desde el
+// último que recibió (Last-Event-ID)
+const liveFeedHistory = 256
+
+// Eventos que puede acumular un cliente lento antes de que se le descarten
+const liveClientBuffer = 64
+
+// LiveEvent es un evento entregado a los clientes en vivo
+type LiveEvent struct {
+	ID        int64                  `json:"id"`
+	Name      string                 `json:"event"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// liveClient es un cliente conectado; dropped cuenta los eventos que no alcanzó a recibir
+type liveClient struct {
+	events  chan LiveEvent
+	dropped int
+}
+
+// LiveFeed reparte los eventos en vivo a los clientes conectados sin bloquear la cadena:
+// si un cliente no consume, sus eventos se descartan
+type LiveFeed struct {
+	blockchain *Blockchain
+	clients    map[int]*liveClient
+	history    []LiveEvent
+	nextID     int
+	lastID     int64
+	lastBlock  string
+	mutex      sync.Mutex
+}
+
+// NewLiveFeed crea el repartidor y lo suscribe a los bloques de la cadena
+func NewLiveFeed(bc *Blockchain) *LiveFeed {
+	lf := &LiveFeed{blockchain: bc, clients: make(map[int]*liveClient)}
+	bc.Events.Subscribe(lf.observe)
+	return lf
+}
+
+// observe traduce los eventos de la cadena. Un bloque produce un evento por transacción,
+// así que block_added se emite solo con la primera de cada bloque.
+func (lf *LiveFeed) observe(event ChainEvent) {
+	lf.mutex.Lock()
+	newBlock := lf.lastBlock != event.BlockHash
+	lf.lastBlock = event.BlockHash
+	lf.mutex.Unlock()
+	if newBlock {
+		lf.publish(LiveBlockAdded, map[string]interface{}{
+			"height":     event.Height,
+			"block_hash": event.BlockHash,
+			"type":       event.Type,
+		})
+	}
+
+	for _, entry := range eventData(event.Data) {
+		kind, _ := entry["type"].(string)
+		contractID, _ := entry["contract_id"].(string)
+		switch kind {
+		case "CONTRACT_CREATION":
+			lf.publish(LiveContractCreated, map[string]interface{}{
+				"contract_id":   contractID,
+				"entity_code":   entry["entity_code"],
+				"entity_name":   entry["entity_name"],
+				"contract_type": entry["contract_type"],
+				"amount":        entry["amount"],
+				"block_hash":    event.BlockHash,
+			})
+		case "VALIDATION":
+			// La validación no trae la entidad; se toma del contrato para poder filtrar por ella
+			entityCode := ""
+			if contract, exists := lf.blockchain.Contract(contractID); exists {
+				entityCode = contract.EntityCode
+			}
+			lf.publish(LiveStepValidated, map[string]interface{}{
+				"contract_id": contractID,
+				"entity_code": entityCode,
+				"step":        entry["step"],
+				"validator":   entry["validator"],
+				"role":        entry["role"],
+				"approved":    entry["approved"],
+				"block_hash":  event.BlockHash,
+			})
+		}
+	}
+}
+
+// PeerJoined publica la llegada de un peer a la red
+func (lf *LiveFeed) PeerJoined(peer Peer) {
+	lf.publish(LivePeerJoined, map[string]interface{}{
+		"peer_id": peer.ID,
+		"address": peer.Address,
+		"port":    peer.Port,
+	})
+}
+
+// publish numera el evento, lo guarda en el historial y lo entrega a cada cliente
+func (lf *LiveFeed) publish(name string, data map[string]interface{}) {
+	lf.mutex.Lock()
+	defer lf.mutex.Unlock()
+
+	lf.lastID++
+	event := LiveEvent{ID: lf.lastID, Name: name, Timestamp: time.Now(), Data: data}
+	lf.history = append(lf.history, event)
+	if len(lf.history) > liveFeedHistory {
+		lf.history = lf.history[len(lf.history)-liveFeedHistory:]
+	}
+	for _, client := range lf.clients {
+		select {
+		case client.events <- event:
+		default:
+			client.dropped++
+		}
+	}
+}
+
+// Subscribe conecta un cliente y retorna su identificador, los eventos posteriores a
+// lastID que aún están en el historial (ninguno con lastID 0) y el canal de los nuevos
+func (lf *LiveFeed) Subscribe(lastID int64) (int, []LiveEvent, <-chan LiveEvent) {
+	lf.mutex.Lock()
+	defer lf.mutex.Unlock()
+
+	var missed []LiveEvent
+	if lastID > 0 {
+		for _, event := range lf.history {
+			if event.ID > lastID {
+				missed = append(missed, event)
+			}
+		}
+	}
+	lf.nextID++
+	client := &liveClient{events: make(chan LiveEvent, liveClientBuffer)}
+	lf.clients[lf.nextID] = client
+	return lf.nextID, missed, client.events
+}
+
+// Unsubscribe desconecta al cliente y retorna cuántos eventos se le descartaron
+func (lf *LiveFeed) Unsubscribe(id int) int {
+	lf.mutex.Lock()
+	defer lf.mutex.Unlock()
+
+	client, exists := lf.clients[id]
+	if !exists {
+		return 0
+	}
+	delete(lf.clients, id)
+	return client.dropped
+}
+
+// Clients retorna cuántos clientes están conectados
+func (lf *LiveFeed) Clients() int {
+	lf.mutex.Lock()
+	defer lf.mutex.Unlock()
+	return len(lf.clients)
+}
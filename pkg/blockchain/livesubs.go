@@ -0,0 +1,241 @@
+package blockchain
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Suscripciones en vivo por WebSocket para los frontends ciudadanos: cada conexión pide
+// con mensajes como {"subscribe":"contracts","entity_code":"11001"} solo los eventos de
+// las entidades o contratos que le interesan, en lugar de recibir todo el feed. Cada
+// conexión tiene un límite de mensajes y de eventos por segundo.
+
+// Temas a los que se puede suscribir una conexión
+const (
+	LiveTopicContracts = "contracts" // contract_created y step_validated
+	LiveTopicBlocks    = "blocks"    // block_added
+	LiveTopicPeers     = "peers"     // peer_joined
+)
+
+// Límites por conexión
+const (
+	LiveMaxSubscriptions  = 32
+	LiveMessagesPerSecond = 2  // Mensajes de suscripción que acepta por segundo
+	LiveMessagesBurst     = 10 // Mensajes seguidos que acepta antes de limitar
+	LiveEventsPerSecond   = 20 // Eventos que entrega por segundo; el resto se descarta
+	LiveEventsBurst       = 50 // Eventos seguidos que entrega antes de limitar
+)
+
+// Espera máxima para escribir un mensaje a un suscriptor
+const liveWriteTimeout = 10 * time.Second
+
+// LiveSubscription es un filtro de una conexión: un tema y, para los contratos,
+// opcionalmente una entidad o un contrato
+type LiveSubscription struct {
+	Topic      string `json:"topic"`
+	EntityCode string `json:"entity_code,omitempty"`
+	ContractID string `json:"contract_id,omitempty"`
+}
+
+// liveTopics indica qué eventos abarca cada tema
+var liveTopics = map[string][]string{
+	LiveTopicContracts: {LiveContractCreated, LiveStepValidated},
+	LiveTopicBlocks:    {LiveBlockAdded},
+	LiveTopicPeers:     {LivePeerJoined},
+}
+
+// Validate verifica que el tema exista y que los filtros apliquen a él
+func (s LiveSubscription) Validate() error {
+	if _, exists := liveTopics[s.Topic]; !exists {
+		return fmt.Errorf("tema desconocido: %q", s.Topic)
+	}
+	if s.Topic != LiveTopicContracts && (s.EntityCode != "" || s.ContractID != "") {
+		return fmt.Errorf("el tema %s no admite filtros por entidad ni contrato", s.Topic)
+	}
+	return nil
+}
+
+// Matches indica si el evento corresponde al tema y a los filtros de la suscripción
+func (s LiveSubscription) Matches(event LiveEvent) bool {
+	inTopic := false
+	for _, name := range liveTopics[s.Topic] {
+		if name == event.Name {
+			inTopic = true
+			break
+		}
+	}
+	if !inTopic {
+		return false
+	}
+	if s.EntityCode != "" {
+		if entityCode, _ := event.Data["entity_code"].(string); entityCode != s.EntityCode {
+			return false
+		}
+	}
+	if s.ContractID != "" {
+		if contractID, _ := event.Data["contract_id"].(string); contractID != s.ContractID {
+			return false
+		}
+	}
+	return true
+}
+
+// liveRequest es un mensaje del cliente: suscribirse a un tema o retirar las
+// suscripciones de un tema
+type liveRequest struct {
+	Subscribe   string `json:"subscribe,omitempty"`
+	Unsubscribe string `json:"unsubscribe,omitempty"`
+	EntityCode  string `json:"entity_code,omitempty"`
+	ContractID  string `json:"contract_id,omitempty"`
+	limited     bool
+}
+
+// liveReply es un mensaje al cliente
+type liveReply struct {
+	Type          string             `json:"type"` // event, subscribed, unsubscribed, dropped o error
+	Event         *LiveEvent         `json:"event,omitempty"`
+	Subscription  *LiveSubscription  `json:"subscription,omitempty"`
+	Subscriptions []LiveSubscription `json:"subscriptions,omitempty"`
+	Dropped       int                `json:"dropped,omitempty"`
+	Error         string             `json:"error,omitempty"`
+}
+
+// rateLimiter es un balde de fichas: se recarga a rate por segundo hasta burst
+type rateLimiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate, burst int) *rateLimiter {
+	return &rateLimiter{rate: float64(rate), burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// allow consume una ficha si hay disponible
+func (rl *rateLimiter) allow(now time.Time) bool {
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+	rl.last = now
+	if rl.tokens < 1 {
+		return false
+	}
+	rl.tokens--
+	return true
+}
+
+// ServeSubscriber atiende la conexión de un suscriptor hasta que se cierre. Una sola
+// goroutine escribe en la conexión; la lectura corre aparte y le pasa los mensajes.
+func (lf *LiveFeed) ServeSubscriber(ws *websocket.Conn) {
+	defer ws.Close()
+
+	id, _, events := lf.Subscribe(0)
+	defer lf.Unsubscribe(id)
+
+	requests := make(chan liveRequest)
+	done := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		defer close(done)
+		messages := newRateLimiter(LiveMessagesPerSecond, LiveMessagesBurst)
+		for {
+			var request liveRequest
+			if err := websocket.JSON.Receive(ws, &request); err != nil {
+				return
+			}
+			request.limited = !messages.allow(time.Now())
+			select {
+			case requests <- request:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var subscriptions []LiveSubscription
+	delivery := newRateLimiter(LiveEventsPerSecond, LiveEventsBurst)
+	dropped := 0
+	send := func(reply liveReply) error {
+		ws.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		return websocket.JSON.Send(ws, reply)
+	}
+
+	for {
+		var reply *liveReply
+		select {
+		case <-done:
+			return
+		case request := <-requests:
+			reply = handleLiveRequest(request, &subscriptions)
+		case event := <-events:
+			matched := false
+			for _, subscription := range subscriptions {
+				if subscription.Matches(event) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+			if !delivery.allow(time.Now()) {
+				dropped++
+				continue
+			}
+			// Antes del siguiente evento se avisa cuántos se perdieron por el límite
+			if dropped > 0 {
+				if err := send(liveReply{Type: "dropped", Dropped: dropped}); err != nil {
+					return
+				}
+				dropped = 0
+			}
+			reply = &liveReply{Type: "event", Event: &event}
+		}
+		if err := send(*reply); err != nil {
+			return
+		}
+	}
+}
+
+// handleLiveRequest aplica un mensaje del cliente a sus suscripciones y retorna la respuesta
+func handleLiveRequest(request liveRequest, subscriptions *[]LiveSubscription) *liveReply {
+	if request.limited {
+		return &liveReply{Type: "error", Error: fmt.Sprintf("demasiados mensajes, máximo %d por segundo", LiveMessagesPerSecond)}
+	}
+
+	switch {
+	case request.Subscribe != "" && request.Unsubscribe != "":
+		return &liveReply{Type: "error", Error: "subscribe y unsubscribe son excluyentes"}
+	case request.Subscribe != "":
+		subscription := LiveSubscription{Topic: request.Subscribe, EntityCode: request.EntityCode, ContractID: request.ContractID}
+		if err := subscription.Validate(); err != nil {
+			return &liveReply{Type: "error", Error: err.Error()}
+		}
+		for _, existing := range *subscriptions {
+			if existing == subscription {
+				return &liveReply{Type: "subscribed", Subscription: &subscription, Subscriptions: *subscriptions}
+			}
+		}
+		if len(*subscriptions) >= LiveMaxSubscriptions {
+			return &liveReply{Type: "error", Error: fmt.Sprintf("máximo %d suscripciones por conexión", LiveMaxSubscriptions)}
+		}
+		*subscriptions = append(*subscriptions, subscription)
+		return &liveReply{Type: "subscribed", Subscription: &subscription, Subscriptions: *subscriptions}
+	case request.Unsubscribe != "":
+		kept := (*subscriptions)[:0]
+		for _, existing := range *subscriptions {
+			if existing.Topic != request.Unsubscribe {
+				kept = append(kept, existing)
+			}
+		}
+		*subscriptions = kept
+		return &liveReply{Type: "unsubscribed", Subscriptions: *subscriptions}
+	default:
+		return &liveReply{Type: "error", Error: "se esperaba subscribe o unsubscribe"}
+	}
+}
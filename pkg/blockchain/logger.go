@@ -0,0 +1,32 @@
+package blockchain
+
+import "sync"
+
+// Logger recibe los mensajes operativos del ledger (bloques agregados, peers, WAL...).
+// *log.Logger lo satisface.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// Por defecto el paquete no escribe nada: quien lo embebe decide a dónde van los mensajes
+var (
+	logger      Logger
+	loggerMutex sync.RWMutex
+)
+
+// SetLogger define a dónde van los mensajes del paquete; con nil se descartan
+func SetLogger(l Logger) {
+	loggerMutex.Lock()
+	defer loggerMutex.Unlock()
+	logger = l
+}
+
+// logf entrega el mensaje al logger configurado, si hay alguno
+func logf(format string, args ...interface{}) {
+	loggerMutex.RLock()
+	l := logger
+	loggerMutex.RUnlock()
+	if l != nil {
+		l.Printf(format, args...)
+	}
+}
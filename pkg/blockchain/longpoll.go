@@ -0,0 +1,83 @@
+package blockchain
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MaxBlockWait limita la espera de un long-polling para no retener conexiones indefinidamente
+const MaxBlockWait = 60 * time.Second
+
+// Máximo de bloques entregados en una respuesta; el cliente vuelve a consultar por el resto
+const maxWaitBlocks = 100
+
+// tipNotifier despierta a quienes esperan un cambio en la punta de la cadena. Cada
+// cambio cierra el canal actual y crea uno nuevo.
+type tipNotifier struct {
+	changed chan struct{}
+	mutex   sync.Mutex
+}
+
+func newTipNotifier() *tipNotifier {
+	return &tipNotifier{changed: make(chan struct{})}
+}
+
+// wait retorna un canal que se cierra en el próximo cambio de la punta
+func (tn *tipNotifier) wait() <-chan struct{} {
+	tn.mutex.Lock()
+	defer tn.mutex.Unlock()
+	return tn.changed
+}
+
+// notify avisa a todos los que esperan
+func (tn *tipNotifier) notify() {
+	tn.mutex.Lock()
+	defer tn.mutex.Unlock()
+	close(tn.changed)
+	tn.changed = make(chan struct{})
+}
+
+// WaitForBlocks espera hasta que la cadena tenga bloques posteriores a afterHeight y los
+// retorna (como máximo maxWaitBlocks), o retorna una lista vacía al vencer el timeout o
+// cancelarse ctx. Si afterHeight es mayor que la punta (la cadena fue reemplazada por
+// una más corta) retorna de inmediato sin bloques para que el cliente se resincronice.
+func (bc *Blockchain) WaitForBlocks(ctx context.Context, afterHeight int, timeout time.Duration) ([]*Block, error) {
+	if timeout > MaxBlockWait {
+		timeout = MaxBlockWait
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		changed := bc.tip.wait()
+
+		tip := bc.Len() - 1
+		if tip != afterHeight {
+			if tip < afterHeight {
+				return []*Block{}, nil
+			}
+			end := tip
+			if end-afterHeight > maxWaitBlocks {
+				end = afterHeight + maxWaitBlocks
+			}
+			blocks := make([]*Block, 0, end-afterHeight)
+			for height := afterHeight + 1; height <= end; height++ {
+				block, err := bc.BlockAt(height)
+				if err != nil {
+					return nil, err
+				}
+				blocks = append(blocks, block)
+			}
+			return blocks, nil
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return []*Block{}, nil
+		case <-ctx.Done():
+			return []*Block{}, nil
+		}
+	}
+}
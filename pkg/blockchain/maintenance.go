@@ -0,0 +1,83 @@
+package blockchain
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrMaintenance indica que el nodo no agrega bloques mientras está en mantenimiento
+var ErrMaintenance = errors.New("nodo en mantenimiento, escrituras congeladas")
+
+// MaintenanceMode controla el congelamiento de escrituras del nodo
+type MaintenanceMode struct {
+	enabled bool
+	reason  string
+	since   time.Time
+	eta     time.Time
+	mutex   sync.RWMutex
+}
+
+// MaintenanceStatus representa el estado del modo mantenimiento
+type MaintenanceStatus struct {
+	Enabled bool       `json:"enabled"`
+	Reason  string     `json:"reason,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+	ETA     *time.Time `json:"eta,omitempty"`
+}
+
+// NewMaintenanceMode crea un modo mantenimiento desactivado
+func NewMaintenanceMode() *MaintenanceMode {
+	return &MaintenanceMode{}
+}
+
+// Enable activa el modo mantenimiento con una razón y un tiempo estimado de fin
+func (m *MaintenanceMode) Enable(reason string, eta time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.enabled = true
+	m.reason = reason
+	m.since = time.Now()
+	m.eta = eta
+}
+
+// Disable desactiva el modo mantenimiento
+func (m *MaintenanceMode) Disable() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.enabled = false
+	m.reason = ""
+	m.since = time.Time{}
+	m.eta = time.Time{}
+}
+
+// IsEnabled indica si el nodo está en mantenimiento
+func (m *MaintenanceMode) IsEnabled() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.enabled
+}
+
+// Status retorna una copia del estado actual
+func (m *MaintenanceMode) Status() MaintenanceStatus {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	status := MaintenanceStatus{Enabled: m.enabled, Reason: m.reason}
+	if m.enabled {
+		since := m.since
+		status.Since = &since
+		if !m.eta.IsZero() {
+			eta := m.eta
+			status.ETA = &eta
+		}
+	}
+	return status
+}
+
+// inMaintenance indica si las escrituras de la cadena están congeladas
+func (bc *Blockchain) inMaintenance() bool {
+	return bc.Maintenance != nil && bc.Maintenance.IsEnabled()
+}
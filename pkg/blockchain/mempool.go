@@ -0,0 +1,453 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BatchBlockType es el tipo de los bloques que agrupan varias transacciones del mempool
+const BatchBlockType = "TRANSACTION_BATCH"
+
+// batchableKinds son los tipos de transacción que pueden esperar en el mempool. Los de
+// gobierno y registro de llaves se agregan de inmediato porque la autoridad y la
+// verificación de firmas de los bloques siguientes dependen de ellos.
+var batchableKinds = map[string]bool{
+	"CONTRACT_CREATION":          true,
+	"VALIDATION":                 true,
+	"AUDIT_OBSERVATION":          true,
+	ObservationResponseBlockType: true,
+	"SUPPLIER_REGISTRATION":      true,
+	"CONTRACT_PUBLICATION":       true,
+	"PLIEGO_QUESTION":            true,
+	"PLIEGO_RESPONSE":            true,
+	"CONTRACT_AWARD":             true,
+	"SUPPLIER_SANCTION":          true,
+	"EXECUTION_EVIDENCE":         true,
+	"CONTRACT_ATTACHMENT":        true,
+	"CONFLICT_DECLARATION":       true,
+	TemplateMigrationBlockType:   true,
+	PaymentBlockType:             true,
+	CarryoverBlockType:           true,
+	AmendmentBlockType:           true,
+	AmendmentDecisionBlockType:   true,
+	MilestoneBlockType:           true,
+	MilestoneCompletionBlockType: true,
+	PaymentActBlockType:          true,
+	FiscalClosingBlockType:       true,
+}
+
+// DefaultMempoolTTL es cuánto espera una transacción recibida de otro nodo antes de
+// descartarse si nadie la sella
+const DefaultMempoolTTL = 10 * time.Minute
+
+// pendingTransaction es una transacción en espera y el canal por el que se avisa a
+// quien la envió si quedó en la cadena; las recibidas de otros nodos no tienen canal
+type pendingTransaction struct {
+	id        string
+	data      map[string]interface{}
+	done      chan sealResult
+	origin    string
+	expiresAt time.Time
+}
+
+// sealResult es el bloque que selló una transacción o el error que la dejó fuera
+type sealResult struct {
+	block *Block
+	err   error
+}
+
+// MempoolTransaction es una transacción en espera tal como se difunde a los peers
+type MempoolTransaction struct {
+	ID        string                 `json:"id"`
+	Origin    string                 `json:"origin"` // Nodo donde se envió la transacción
+	Data      map[string]interface{} `json:"data"`
+	ExpiresAt time.Time              `json:"expires_at"`
+}
+
+// MempoolStats resume la actividad del mempool
+type MempoolStats struct {
+	Pending              int        `json:"pending"`
+	MaxTransactions      int        `json:"max_transactions"`
+	IntervalSeconds      float64    `json:"interval_seconds"`
+	SealedBlocks         int        `json:"sealed_blocks"`
+	BatchedTransactions  int        `json:"batched_transactions"`
+	RejectedTransactions int        `json:"rejected_transactions"`
+	RemoteTransactions   int        `json:"remote_transactions"`  // Recibidas de otros nodos
+	SealedElsewhere      int        `json:"sealed_elsewhere"`     // Propias que selló otro nodo
+	ExpiredTransactions  int        `json:"expired_transactions"` // Recibidas que vencieron sin sellarse
+	LastSealedAt         *time.Time `json:"last_sealed_at,omitempty"`
+}
+
+// Mempool acumula las transacciones de contrato y las sella en un solo bloque cada
+// intervalo o al completar el máximo de transacciones, para que la cadena no crezca un
+// bloque por acción bajo carga. Quien envía una transacción espera a que se selle, de
+// modo que AddBlock conserva su comportamiento para los llamadores.
+//
+// Las transacciones se difunden a los demás nodos, de modo que el que selle el siguiente
+// bloque incluye las enviadas en cualquiera. Cada una lleva un ID (tx_id) con el que se
+// descartan las copias repetidas y las que ya quedaron en la cadena.
+type Mempool struct {
+	blockchain      *Blockchain
+	maxTransactions int
+	interval        time.Duration
+	// TTL es cuánto se conserva una transacción recibida de otro nodo sin sellarse
+	TTL       time.Duration
+	pending   []*pendingTransaction
+	seen      map[string]time.Time // IDs ya recibidos o sellados, hasta su vencimiento
+	broadcast func(MempoolTransaction)
+	full      chan struct{}
+	stats     MempoolStats
+	mutex     sync.Mutex
+}
+
+// NewMempool crea el mempool de la cadena; se activa al asignarlo a bc.Mempool
+func NewMempool(bc *Blockchain, maxTransactions int, interval time.Duration) (*Mempool, error) {
+	if maxTransactions < 2 {
+		return nil, errors.New("el mempool requiere al menos 2 transacciones por bloque")
+	}
+	if interval <= 0 {
+		return nil, errors.New("el intervalo de sellado del mempool debe ser positivo")
+	}
+	mp := &Mempool{
+		blockchain:      bc,
+		maxTransactions: maxTransactions,
+		interval:        interval,
+		TTL:             DefaultMempoolTTL,
+		seen:            make(map[string]time.Time),
+		full:            make(chan struct{}, 1),
+	}
+	bc.Events.Subscribe(mp.observe)
+	return mp, nil
+}
+
+// accepts indica si la transacción puede esperar en el mempool
+func (mp *Mempool) accepts(data map[string]interface{}) bool {
+	blockType, _ := data["type"].(string)
+	return batchableKinds[blockType]
+}
+
+// submit deja la transacción en espera, la difunde a los peers y bloquea hasta que este
+// u otro nodo la selle en un bloque, que retorna
+func (mp *Mempool) submit(data map[string]interface{}) (*Block, error) {
+	id, _ := data["tx_id"].(string)
+	if id == "" {
+		id = uuid.New().String()
+		data["tx_id"] = id
+	}
+	tx := &pendingTransaction{id: id, data: data, done: make(chan sealResult, 1)}
+
+	mp.mutex.Lock()
+	mp.pending = append(mp.pending, tx)
+	mp.seen[id] = time.Now().Add(mp.TTL)
+	broadcast := mp.broadcast
+	mp.mutex.Unlock()
+
+	if broadcast != nil {
+		// Se difunde una copia: al sellar se le agrega la secuencia a los datos
+		go broadcast(MempoolTransaction{ID: id, Data: copyTransactionData(data), ExpiresAt: time.Now().Add(mp.TTL)})
+	}
+	mp.notifyIfFull()
+	result := <-tx.done
+	if result.err != nil {
+		return nil, result.err
+	}
+	return result.block, nil
+}
+
+// AddRemote deja en espera una transacción recibida de otro nodo. Retorna false si ya se
+// conocía, ya está en la cadena o venció, en cuyo caso no debe reenviarse.
+func (mp *Mempool) AddRemote(tx MempoolTransaction) (bool, error) {
+	if tx.ID == "" {
+		return false, errors.New("transacción sin ID")
+	}
+	if !mp.accepts(tx.Data) {
+		return false, fmt.Errorf("tipo de transacción no admitido en el mempool: %v", tx.Data["type"])
+	}
+	if id, _ := tx.Data["tx_id"].(string); id != tx.ID {
+		return false, errors.New("el ID no coincide con el de los datos de la transacción")
+	}
+	if !tx.ExpiresAt.After(time.Now()) {
+		return false, nil
+	}
+	// Una transacción recibida se conserva a lo sumo el TTL local, aunque el origen diga más
+	expiresAt := tx.ExpiresAt
+	if limit := time.Now().Add(mp.TTL); expiresAt.After(limit) {
+		expiresAt = limit
+	}
+
+	mp.mutex.Lock()
+	if _, known := mp.seen[tx.ID]; known {
+		mp.mutex.Unlock()
+		return false, nil
+	}
+	mp.seen[tx.ID] = expiresAt
+	mp.pending = append(mp.pending, &pendingTransaction{id: tx.ID, data: tx.Data, origin: tx.Origin, expiresAt: expiresAt})
+	mp.stats.RemoteTransactions++
+	mp.mutex.Unlock()
+
+	mp.notifyIfFull()
+	return true, nil
+}
+
+// SetBroadcast define cómo se difunden a los peers las transacciones enviadas en este nodo
+func (mp *Mempool) SetBroadcast(broadcast func(MempoolTransaction)) {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+	mp.broadcast = broadcast
+}
+
+// notifyIfFull adelanta el sellado si ya hay un lote completo en espera
+func (mp *Mempool) notifyIfFull() {
+	mp.mutex.Lock()
+	full := len(mp.pending) >= mp.maxTransactions
+	mp.mutex.Unlock()
+
+	if full {
+		select {
+		case mp.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// observe saca del mempool las transacciones que quedaron en un bloque, sea de este nodo
+// o recibido de un peer, y libera a quien las envió aquí si las selló otro nodo
+func (mp *Mempool) observe(event ChainEvent) {
+	var sealedElsewhere []*pendingTransaction
+
+	mp.mutex.Lock()
+	for _, entry := range eventData(event.Data) {
+		id, _ := entry["tx_id"].(string)
+		if id == "" {
+			continue
+		}
+		mp.seen[id] = time.Now().Add(mp.TTL)
+		for i, tx := range mp.pending {
+			if tx.id != id {
+				continue
+			}
+			mp.pending = append(mp.pending[:i], mp.pending[i+1:]...)
+			if tx.done != nil {
+				sealedElsewhere = append(sealedElsewhere, tx)
+				mp.stats.SealedElsewhere++
+			}
+			break
+		}
+	}
+	mp.mutex.Unlock()
+
+	if len(sealedElsewhere) == 0 {
+		return
+	}
+	block, err := mp.blockchain.BlockByHash(event.BlockHash)
+	for _, tx := range sealedElsewhere {
+		tx.done <- sealResult{block: block, err: err}
+	}
+}
+
+// expire descarta las transacciones recibidas vencidas y olvida los IDs vencidos
+func (mp *Mempool) expire(now time.Time) {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	remaining := mp.pending[:0]
+	for _, tx := range mp.pending {
+		if tx.done == nil && !tx.expiresAt.After(now) {
+			mp.stats.ExpiredTransactions++
+			logf("⌛ Transacción %s de %s vencida sin sellarse\n", tx.id, tx.origin)
+			continue
+		}
+		remaining = append(remaining, tx)
+	}
+	mp.pending = remaining
+
+	for id, expiresAt := range mp.seen {
+		if !expiresAt.After(now) {
+			delete(mp.seen, id)
+		}
+	}
+}
+
+// Pending retorna las transacciones en espera
+func (mp *Mempool) Pending() []MempoolTransaction {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	transactions := make([]MempoolTransaction, 0, len(mp.pending))
+	for _, tx := range mp.pending {
+		transactions = append(transactions, MempoolTransaction{ID: tx.id, Origin: tx.origin, Data: copyTransactionData(tx.data), ExpiresAt: tx.expiresAt})
+	}
+	return transactions
+}
+
+// copyTransactionData copia el primer nivel de los datos de una transacción
+func copyTransactionData(data map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		copied[key] = value
+	}
+	return copied
+}
+
+// Run sella los bloques del mempool cada intervalo o cuando se completa un lote
+func (mp *Mempool) Run() {
+	ticker := time.NewTicker(mp.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-mp.full:
+		}
+		mp.expire(time.Now())
+		for mp.seal() {
+		}
+	}
+}
+
+// seal sella un bloque con las transacciones en espera. Un lote lleva como máximo una
+// transacción por contrato, para que sus secuencias no dependan unas de otras; las demás
+// quedan para el siguiente. Retorna true si quedó un lote completo en espera.
+func (mp *Mempool) seal() bool {
+	// En mantenimiento el lote espera: las transacciones locales se sellan al terminar y
+	// las recibidas de otros nodos vencen con su TTL
+	if mp.blockchain.inMaintenance() {
+		return false
+	}
+	mp.mutex.Lock()
+	batch := make([]*pendingTransaction, 0, mp.maxTransactions)
+	remaining := make([]*pendingTransaction, 0)
+	contracts := make(map[string]bool)
+	for _, tx := range mp.pending {
+		contractID, _ := tx.data["contract_id"].(string)
+		if len(batch) == mp.maxTransactions || (contractID != "" && contracts[contractID]) {
+			remaining = append(remaining, tx)
+			continue
+		}
+		if contractID != "" {
+			contracts[contractID] = true
+		}
+		batch = append(batch, tx)
+	}
+	mp.pending = remaining
+	mp.mutex.Unlock()
+
+	if len(batch) == 0 {
+		return false
+	}
+
+	var block *Block
+	var errs []error
+	if len(batch) == 1 {
+		// Sin carga no vale la pena agrupar: el bloque es igual al de siempre
+		var err error
+		block, err = mp.blockchain.addBlock(batch[0].data, nil)
+		errs = []error{err}
+	} else {
+		entries := make([]map[string]interface{}, len(batch))
+		for i, tx := range batch {
+			entries[i] = tx.data
+		}
+		block, errs = mp.blockchain.addBatch(entries)
+	}
+
+	mp.mutex.Lock()
+	now := time.Now()
+	mp.stats.LastSealedAt = &now
+	for i, tx := range batch {
+		if errs[i] != nil {
+			mp.stats.RejectedTransactions++
+		} else {
+			mp.stats.BatchedTransactions++
+		}
+		if tx.done != nil {
+			tx.done <- sealResult{block: block, err: errs[i]}
+		} else if errs[i] != nil {
+			logf("⚠️ Transacción %s de %s descartada: %v\n", tx.id, tx.origin, errs[i])
+		}
+	}
+	mp.stats.SealedBlocks++
+	more := len(mp.pending) >= mp.maxTransactions
+	mp.mutex.Unlock()
+	return more
+}
+
+// Stats retorna el estado del mempool
+func (mp *Mempool) Stats() MempoolStats {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	stats := mp.stats
+	stats.Pending = len(mp.pending)
+	stats.MaxTransactions = mp.maxTransactions
+	stats.IntervalSeconds = mp.interval.Seconds()
+	return stats
+}
+
+// addBatch agrega un bloque con varias transacciones del mempool y lo retorna. Las
+// transacciones que no son las siguientes de su contrato o cuyo tipo no está activo se
+// rechazan sin afectar a las demás; si el bloque no se puede agregar se rechazan todas.
+func (bc *Blockchain) addBatch(entries []map[string]interface{}) (*Block, []error) {
+	errs := make([]error, len(entries))
+	height := bc.Len()
+	accepted := make([]interface{}, 0, len(entries))
+	transactions := make([]Transaction, 0, len(entries))
+	members := make([]int, 0, len(entries))
+	for i, entry := range entries {
+		bc.stampSequence(entry)
+		if err := bc.checkSequence(entry); err != nil {
+			errs[i] = err
+			continue
+		}
+		if blockType, _ := entry["type"].(string); !bc.Protocol.IsActive(blockType, height) {
+			errs[i] = fmt.Errorf("tipo de transacción %s aún no activo en la altura %d", blockType, height)
+			continue
+		}
+		accepted = append(accepted, entry)
+		transactions = append(transactions, newTransaction(entry))
+		members = append(members, i)
+	}
+	if len(accepted) == 0 {
+		return nil, errs
+	}
+
+	blockData := map[string]interface{}{
+		"type":         BatchBlockType,
+		"transactions": accepted,
+		"count":        len(accepted),
+		"timestamp":    time.Now(),
+	}
+	block, err := bc.addBlock(blockData, transactions)
+	if err != nil {
+		for _, i := range members {
+			errs[i] = err
+		}
+		return nil, errs
+	}
+	logf("📦 Bloque %d sella %d transacciones del mempool\n", height, len(accepted))
+	return block, errs
+}
+
+// eventData retorna los datos de cada transacción de un bloque: uno por transacción en
+// los lotes del mempool y los datos originales en los bloques retransmitidos
+func eventData(data map[string]interface{}) []map[string]interface{} {
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	if blockType, _ := data["type"].(string); blockType != BatchBlockType {
+		return []map[string]interface{}{data}
+	}
+
+	items, _ := data["transactions"].([]interface{})
+	entries := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if entry, ok := item.(map[string]interface{}); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,207 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lado en que queda el hermano en cada paso de una prueba de inclusión
+const (
+	MerkleLeft  = "left"
+	MerkleRight = "right"
+)
+
+// Transaction es un evento de contrato incluido en un bloque. Solo guarda la huella de
+// sus datos; el contenido completo está en Data del bloque.
+type Transaction struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	ContractID string    `json:"contract_id,omitempty"`
+	DataHash   string    `json:"data_hash"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// MerkleStep es un hermano en el camino de una hoja a la raíz del árbol
+type MerkleStep struct {
+	Hash     string `json:"hash"`
+	Position string `json:"position"` // left o right
+}
+
+// MerkleProof demuestra que una transacción está incluida en un bloque sin descargarlo:
+// basta recalcular la raíz desde la hoja y compararla con la del encabezado
+type MerkleProof struct {
+	Transaction Transaction            `json:"transaction"`
+	Data        map[string]interface{} `json:"data,omitempty"` // Datos del evento, si el bloque está disponible
+	Leaf        string                 `json:"leaf"`
+	Path        []MerkleStep           `json:"path"`
+	MerkleRoot  string                 `json:"merkle_root"`
+	BlockIndex  int                    `json:"block_index"`
+	BlockHash   string                 `json:"block_hash"`
+}
+
+// newTransaction describe como transacción el evento de un bloque nuevo
+func newTransaction(data map[string]interface{}) Transaction {
+	tx := Transaction{
+		ID:        uuid.New().String(),
+		DataHash:  hashData(data),
+		Timestamp: time.Now(),
+	}
+	tx.Type, _ = data["type"].(string)
+	tx.ContractID, _ = data["contract_id"].(string)
+	return tx
+}
+
+// hashData calcula la huella de los datos de un evento (JSON con llaves ordenadas)
+func hashData(data map[string]interface{}) string {
+	encoded, _ := json.Marshal(data)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// leafHash calcula la hoja del árbol correspondiente a una transacción
+func (tx Transaction) leafHash() string {
+	encoded, _ := json.Marshal(tx)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashPair combina dos nodos del árbol
+func hashPair(left string, right string) string {
+	l, _ := hex.DecodeString(left)
+	r, _ := hex.DecodeString(right)
+	sum := sha256.Sum256(append(l, r...))
+	return hex.EncodeToString(sum[:])
+}
+
+// merkleLevels construye el árbol desde las hojas hasta la raíz. En los niveles con
+// cantidad impar de nodos el último se combina consigo mismo.
+func merkleLevels(transactions []Transaction) [][]string {
+	level := make([]string, len(transactions))
+	for i, tx := range transactions {
+		level[i] = tx.leafHash()
+	}
+	levels := [][]string{level}
+	for len(level) > 1 {
+		next := make([]string, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			right := level[i]
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			next = append(next, hashPair(level[i], right))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// MerkleRoot calcula la raíz del árbol de transacciones, o "" si no hay transacciones
+func MerkleRoot(transactions []Transaction) string {
+	if len(transactions) == 0 {
+		return ""
+	}
+	levels := merkleLevels(transactions)
+	return levels[len(levels)-1][0]
+}
+
+// checkTransactions verifica que la raíz del encabezado corresponda a las transacciones
+// y estas a los datos del bloque. Los bloques anteriores al árbol no tienen raíz.
+func (b *Block) checkTransactions() bool {
+	if b.MerkleRoot == "" {
+		return len(b.Transactions) == 0
+	}
+	if MerkleRoot(b.Transactions) != b.MerkleRoot {
+		return false
+	}
+	if b.Pruned {
+		return true
+	}
+	entries := eventData(b.Data)
+	if len(entries) != len(b.Transactions) {
+		return false
+	}
+	for i, tx := range b.Transactions {
+		if tx.DataHash != hashData(entries[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetMerkleProof construye la prueba de inclusión de una transacción de la cadena local
+func (bc *Blockchain) GetMerkleProof(txID string) (*MerkleProof, error) {
+	chain := bc.Blocks()
+	for height := len(chain) - 1; height >= 0; height-- {
+		header := chain[height]
+		for position, tx := range header.Transactions {
+			if tx.ID != txID {
+				continue
+			}
+
+			levels := merkleLevels(header.Transactions)
+			proof := &MerkleProof{
+				Transaction: tx,
+				Leaf:        levels[0][position],
+				Path:        []MerkleStep{},
+				MerkleRoot:  header.MerkleRoot,
+				BlockIndex:  header.Index,
+				BlockHash:   header.Hash,
+			}
+			index := position
+			for _, level := range levels[:len(levels)-1] {
+				if index%2 == 0 {
+					sibling := level[index]
+					if index+1 < len(level) {
+						sibling = level[index+1]
+					}
+					proof.Path = append(proof.Path, MerkleStep{Hash: sibling, Position: MerkleRight})
+				} else {
+					proof.Path = append(proof.Path, MerkleStep{Hash: level[index-1], Position: MerkleLeft})
+				}
+				index /= 2
+			}
+
+			// Los datos del evento se incluyen si el bloque completo está disponible
+			if block, err := bc.BlockAt(height); err == nil {
+				if entries := eventData(block.Data); position < len(entries) {
+					proof.Data = entries[position]
+				}
+			}
+			return proof, nil
+		}
+	}
+	return nil, errors.New("transacción no encontrada")
+}
+
+// VerifyMerkleProof recalcula la raíz desde la transacción y su camino, y si la prueba
+// trae los datos del evento verifica también su huella
+func VerifyMerkleProof(proof MerkleProof) error {
+	if proof.Data != nil && hashData(proof.Data) != proof.Transaction.DataHash {
+		return errors.New("los datos no corresponden a la huella de la transacción")
+	}
+	node := proof.Transaction.leafHash()
+	if node != proof.Leaf {
+		return errors.New("la hoja no corresponde a la transacción")
+	}
+	for _, step := range proof.Path {
+		switch step.Position {
+		case MerkleLeft:
+			node = hashPair(step.Hash, node)
+		case MerkleRight:
+			node = hashPair(node, step.Hash)
+		default:
+			return fmt.Errorf("posición inválida en la prueba: %s", step.Position)
+		}
+	}
+	if node != proof.MerkleRoot {
+		return errors.New("la raíz calculada no corresponde a la del bloque")
+	}
+	return nil
+}
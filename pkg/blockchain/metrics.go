@@ -0,0 +1,181 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Métricas del nodo. Se exponen en formato de texto de Prometheus para que el monitoreo
+// las recolecte y, en los nodos detrás de firewalls que no se pueden consultar, se envían
+// periódicamente como JSON a un colector configurado.
+
+// Metric es una medición del nodo en un instante
+type Metric struct {
+	Name  string  `json:"name"`
+	Help  string  `json:"help"`
+	Type  string  `json:"type"` // "gauge" o "counter", como en Prometheus
+	Value float64 `json:"value"`
+}
+
+// MetricsReport es el mensaje que se envía al colector
+type MetricsReport struct {
+	NodeID    string    `json:"node_id"`
+	Version   string    `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+	Metrics   []Metric  `json:"metrics"`
+}
+
+// MetricsPushStatus resume los envíos al colector
+type MetricsPushStatus struct {
+	Endpoint    string     `json:"endpoint"`
+	Pushed      int        `json:"pushed"`
+	Failed      int        `json:"failed"`
+	LastPushAt  *time.Time `json:"last_push_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Metrics retorna las métricas actuales del nodo, ordenadas por nombre
+func (p2p *P2PNetwork) Metrics() []Metric {
+	bc := p2p.Blockchain
+	finality := bc.Finality.Summary()
+	outbox := bc.Outbox.Status()
+
+	metrics := []Metric{
+		{Name: "secop_chain_height", Help: "Bloques en la cadena local", Type: "gauge", Value: float64(bc.Len())},
+		{Name: "secop_contracts", Help: "Contratos registrados", Type: "gauge", Value: float64(bc.ContractCount())},
+		{Name: "secop_peers_active", Help: "Peers activos", Type: "gauge", Value: float64(len(p2p.GetActivePeers()))},
+		{Name: "secop_peers_known", Help: "Nodos conocidos por intercambio de peers", Type: "gauge", Value: float64(len(p2p.KnownPeers()))},
+		{Name: "secop_finalized_height", Help: "Altura del último bloque finalizado", Type: "gauge", Value: float64(finality.FinalizedHeight)},
+		{Name: "secop_finality_pending_blocks", Help: "Bloques recientes sin finalizar", Type: "gauge", Value: float64(finality.Pending)},
+		{Name: "secop_outbox_pending", Help: "Mensajes del outbox pendientes de entrega", Type: "gauge", Value: float64(outbox.Pending)},
+		{Name: "secop_outbox_delivered_total", Help: "Mensajes del outbox entregados", Type: "counter", Value: float64(outbox.Delivered)},
+		{Name: "secop_catch_up_syncs_total", Help: "Sincronizaciones inmediatas al recibir bloques adelantados", Type: "counter", Value: float64(p2p.CatchUpSyncs())},
+		{Name: "secop_quarantined_blocks", Help: "Bloques rechazados en cuarentena", Type: "gauge", Value: float64(len(p2p.Quarantine.List("")))},
+	}
+	if bc.Mempool != nil {
+		stats := bc.Mempool.Stats()
+		metrics = append(metrics,
+			Metric{Name: "secop_mempool_pending", Help: "Transacciones pendientes en el mempool", Type: "gauge", Value: float64(stats.Pending)},
+			Metric{Name: "secop_mempool_sealed_blocks_total", Help: "Bloques sellados por el mempool", Type: "counter", Value: float64(stats.SealedBlocks)},
+		)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+	return metrics
+}
+
+// PrometheusText escribe las métricas en el formato de texto de Prometheus, etiquetadas
+// con el nodo
+func PrometheusText(nodeID string, metrics []Metric) string {
+	var text strings.Builder
+	label := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(nodeID)
+	for _, metric := range metrics {
+		fmt.Fprintf(&text, "# HELP %s %s\n", metric.Name, metric.Help)
+		fmt.Fprintf(&text, "# TYPE %s %s\n", metric.Name, metric.Type)
+		fmt.Fprintf(&text, "%s{node_id=\"%s\"} %g\n", metric.Name, label, metric.Value)
+	}
+	return text.String()
+}
+
+// MetricsPusher envía periódicamente las métricas del nodo a un colector remoto
+type MetricsPusher struct {
+	p2p      *P2PNetwork
+	endpoint string
+	token    string
+	client   *http.Client
+	status   MetricsPushStatus
+	mutex    sync.Mutex
+}
+
+// NewMetricsPusher crea el envío de métricas hacia el colector; el token, si se da, viaja
+// como Bearer
+func NewMetricsPusher(p2p *P2PNetwork, endpoint string, token string) *MetricsPusher {
+	return &MetricsPusher{
+		p2p:      p2p,
+		endpoint: endpoint,
+		token:    token,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		status:   MetricsPushStatus{Endpoint: endpoint},
+	}
+}
+
+// Run envía las métricas cada intervalo. Un colector caído no detiene el nodo: el error
+// se registra una vez y se reintenta en el siguiente envío.
+func (mp *MetricsPusher) Run(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		mp.Push()
+	}
+}
+
+// Push envía las métricas actuales al colector
+func (mp *MetricsPusher) Push() error {
+	report := MetricsReport{
+		NodeID:    mp.p2p.NodeID,
+		Version:   Version,
+		Timestamp: time.Now(),
+		Metrics:   mp.p2p.Metrics(),
+	}
+	err := mp.post(report)
+
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+	if err != nil {
+		if mp.status.LastErrorAt == nil || (mp.status.LastPushAt != nil && mp.status.LastPushAt.After(*mp.status.LastErrorAt)) {
+			logf("⚠️ No se pudieron enviar las métricas a %s: %v\n", mp.endpoint, err)
+		}
+		mp.status.Failed++
+		mp.status.LastError = err.Error()
+		mp.status.LastErrorAt = &report.Timestamp
+		return err
+	}
+	if mp.status.LastErrorAt != nil && (mp.status.LastPushAt == nil || mp.status.LastErrorAt.After(*mp.status.LastPushAt)) {
+		logf("📈 Envío de métricas a %s restablecido\n", mp.endpoint)
+	}
+	mp.status.Pushed++
+	mp.status.LastPushAt = &report.Timestamp
+	return nil
+}
+
+// Status retorna el resumen de los envíos
+func (mp *MetricsPusher) Status() MetricsPushStatus {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+	return mp.status
+}
+
+// post envía un reporte al colector
+func (mp *MetricsPusher) post(report MetricsReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, mp.endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if mp.token != "" {
+		req.Header.Set("Authorization", "Bearer "+mp.token)
+	}
+
+	resp, err := mp.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("colector respondió con status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Seguimiento de la ejecución: el plan de hitos del contrato adjudicado (entregables con
+// fecha y valor), el cumplimiento de cada hito certificado por la supervisión con el hash
+// de su evidencia y el acta de pago (ACTA_PAGO) que paga el hito cumplido. Así la cadena
+// cubre la ejecución del contrato y no solo su aprobación.
+
+// Tipos de bloque de los hitos y las actas de pago
+const (
+	MilestoneBlockType           = "CONTRACT_MILESTONE"
+	MilestoneCompletionBlockType = "MILESTONE_COMPLETION"
+	PaymentActBlockType          = "ACTA_PAGO"
+)
+
+// MilestoneStatus es el estado de un hito de ejecución
+type MilestoneStatus string
+
+const (
+	MilestonePending   MilestoneStatus = "PENDING"
+	MilestoneCompleted MilestoneStatus = "COMPLETED" // Cumplido, pendiente del acta de pago
+	MilestonePaid      MilestoneStatus = "PAID"
+)
+
+// Milestone es un hito de ejecución del contrato
+type Milestone struct {
+	ID           string          `json:"id"`
+	Description  string          `json:"description"`
+	DueDate      time.Time       `json:"due_date"`
+	Amount       float64         `json:"amount"`
+	Status       MilestoneStatus `json:"status"`
+	EvidenceHash string          `json:"evidence_hash,omitempty"` // SHA-256 de la evidencia del cumplimiento
+	EvidenceID   string          `json:"evidence_id,omitempty"`   // Evidencia de ejecución con ese hash, si se subió al nodo
+	CompletedBy  string          `json:"completed_by,omitempty"`
+	CompletedAt  *time.Time      `json:"completed_at,omitempty"`
+	PaymentID    string          `json:"payment_id,omitempty"`
+	RegisteredBy string          `json:"registered_by"`
+	BlockHash    string          `json:"block_hash"`
+}
+
+// overdue indica si el hito sigue pendiente después de su fecha
+func (m *Milestone) overdue(now time.Time) bool {
+	return m.Status == MilestonePending && now.After(m.DueDate)
+}
+
+// ExecutionProgress resume el avance de la ejecución del contrato
+type ExecutionProgress struct {
+	Milestones       int     `json:"milestones"`
+	Completed        int     `json:"completed"` // Cumplidos, pagados o no
+	Paid             int     `json:"paid"`
+	Overdue          int     `json:"overdue"`
+	PlannedAmount    float64 `json:"planned_amount"`
+	CompletedAmount  float64 `json:"completed_amount"`
+	PaidAmount       float64 `json:"paid_amount"`       // Todos los pagos del contrato, con o sin hito
+	PhysicalPercent  float64 `json:"physical_percent"`  // Valor de los hitos cumplidos sobre el valor total
+	FinancialPercent float64 `json:"financial_percent"` // Valor pagado sobre el valor total
+}
+
+// Milestone retorna el hito del contrato, o nil
+func (c *Contract) Milestone(milestoneID string) *Milestone {
+	for i := range c.Milestones {
+		if c.Milestones[i].ID == milestoneID {
+			return &c.Milestones[i]
+		}
+	}
+	return nil
+}
+
+// Progress calcula el avance de la ejecución del contrato al instante indicado
+func (c *Contract) Progress(now time.Time) ExecutionProgress {
+	progress := ExecutionProgress{
+		Milestones: len(c.Milestones),
+		PaidAmount: c.PaidThrough(now),
+	}
+	for i := range c.Milestones {
+		milestone := &c.Milestones[i]
+		progress.PlannedAmount += milestone.Amount
+		switch {
+		case milestone.Status == MilestonePaid:
+			progress.Paid++
+			fallthrough
+		case milestone.Status == MilestoneCompleted:
+			progress.Completed++
+			progress.CompletedAmount += milestone.Amount
+		case milestone.overdue(now):
+			progress.Overdue++
+		}
+	}
+	if total := c.TotalValue(); total > 0 {
+		progress.PhysicalPercent = progress.CompletedAmount / total * 100
+		progress.FinancialPercent = progress.PaidAmount / total * 100
+	}
+	return progress
+}
+
+// completeMilestone marca el hito cumplido y lo enlaza con la evidencia de ejecución del
+// mismo hash
+func (c *Contract) completeMilestone(milestone *Milestone, evidenceHash string, completedBy string, at time.Time) {
+	milestone.Status = MilestoneCompleted
+	milestone.EvidenceHash = evidenceHash
+	milestone.CompletedBy = completedBy
+	milestone.CompletedAt = &at
+	for _, evidence := range c.Evidence {
+		if evidence.SHA256 == evidenceHash {
+			milestone.EvidenceID = evidence.ID
+			break
+		}
+	}
+}
+
+// MilestoneTracker registra el plan de hitos de los contratos, su cumplimiento y sus
+// actas de pago
+type MilestoneTracker struct {
+	blockchain *Blockchain
+	fiscal     *FiscalLedger
+	mutex      sync.Mutex
+}
+
+// NewMilestoneTracker crea el registro de hitos; las actas de pago se validan contra las
+// vigencias del registro fiscal
+func NewMilestoneTracker(bc *Blockchain, fiscal *FiscalLedger) *MilestoneTracker {
+	return &MilestoneTracker{blockchain: bc, fiscal: fiscal}
+}
+
+// executionContract retorna una copia del contrato para modificarla si está adjudicado y en
+// ejecución; requiere el candado del contrato
+func (mt *MilestoneTracker) executionContract(contractID string) (*Contract, error) {
+	contract, err := mt.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if _, committed := contractAwardedAt(contract); !committed || contract.Status == StatusRejected || contract.Status == StatusCompleted {
+		return nil, errors.New("solo se registran hitos de contratos adjudicados en ejecución")
+	}
+	return contract, nil
+}
+
+// AddMilestone agrega un hito al plan de ejecución. Los hitos no pueden sumar más que el
+// valor total del contrato con sus adiciones.
+func (mt *MilestoneTracker) AddMilestone(contractID string, description string, dueDate time.Time, amount float64, registeredBy string, role AdminRole) (*Milestone, error) {
+	mt.mutex.Lock()
+	defer mt.mutex.Unlock()
+
+	unlock := mt.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := mt.executionContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return nil, errors.New("el hito requiere una descripción")
+	}
+	if dueDate.IsZero() {
+		return nil, errors.New("el hito requiere una fecha de vencimiento")
+	}
+	if amount <= 0 {
+		return nil, errors.New("el valor del hito debe ser positivo")
+	}
+	if planned := contract.Progress(time.Now()).PlannedAmount; planned+amount > contract.TotalValue() {
+		return nil, fmt.Errorf("los hitos superarían el valor del contrato (%.2f sin planear)", contract.TotalValue()-planned)
+	}
+
+	now := time.Now()
+	milestone := Milestone{
+		ID:           uuid.New().String(),
+		Description:  description,
+		DueDate:      dueDate,
+		Amount:       amount,
+		Status:       MilestonePending,
+		RegisteredBy: registeredBy,
+	}
+	blockData := map[string]interface{}{
+		"type":          MilestoneBlockType,
+		"contract_id":   contractID,
+		"milestone_id":  milestone.ID,
+		"description":   description,
+		"due_date":      dueDate,
+		"amount":        amount,
+		"registered_by": registeredBy,
+		"role":          string(role),
+		"timestamp":     now,
+	}
+	block, err := mt.blockchain.SealBlock(blockData)
+	if err != nil {
+		return nil, err
+	}
+
+	milestone.BlockHash = block.Hash
+	contract.Milestones = append(contract.Milestones, milestone)
+	contract.UpdatedAt = now
+	mt.blockchain.WorkflowManager.addAuditEntry(contract, MilestoneBlockType, registeredBy, role, milestone.description())
+	mt.blockchain.saveContract(contract)
+
+	logf("🏁 Hito %s agregado al contrato %s\n", milestone.ID, contractID)
+	return &milestone, nil
+}
+
+// CompleteMilestone certifica el cumplimiento del hito con el hash SHA-256 de su evidencia
+func (mt *MilestoneTracker) CompleteMilestone(contractID string, milestoneID string, evidenceHash string, completedBy string, role AdminRole) (*Milestone, error) {
+	mt.mutex.Lock()
+	defer mt.mutex.Unlock()
+
+	unlock := mt.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := mt.executionContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	milestone := contract.Milestone(milestoneID)
+	if milestone == nil {
+		return nil, errors.New("hito no encontrado")
+	}
+	if milestone.Status != MilestonePending {
+		return nil, fmt.Errorf("el hito ya está %s", milestone.Status)
+	}
+	evidenceHash = strings.ToLower(evidenceHash)
+	if decoded, err := hex.DecodeString(evidenceHash); err != nil || len(decoded) != 32 {
+		return nil, errors.New("el hash de la evidencia debe ser un SHA-256 en hexadecimal")
+	}
+
+	now := time.Now()
+	blockData := map[string]interface{}{
+		"type":          MilestoneCompletionBlockType,
+		"contract_id":   contractID,
+		"milestone_id":  milestoneID,
+		"evidence_hash": evidenceHash,
+		"completed_by":  completedBy,
+		"role":          string(role),
+		"timestamp":     now,
+	}
+	if err := mt.blockchain.AddBlock(blockData); err != nil {
+		return nil, err
+	}
+
+	contract.completeMilestone(milestone, evidenceHash, completedBy, now)
+	contract.UpdatedAt = now
+	mt.blockchain.WorkflowManager.addAuditEntry(contract, MilestoneCompletionBlockType, completedBy, role, "Hito cumplido: "+milestone.Description)
+	mt.blockchain.saveContract(contract)
+
+	logf("🏁 Hito %s del contrato %s cumplido\n", milestoneID, contractID)
+	completed := *milestone
+	return &completed, nil
+}
+
+// RegisterPaymentAct paga el hito cumplido por su valor con un acta de pago. El pago queda
+// entre los del contrato y cuenta para su saldo y para el cierre de la vigencia.
+func (mt *MilestoneTracker) RegisterPaymentAct(contractID string, milestoneID string, reference string, paidAt time.Time, registeredBy string, role AdminRole) (*ContractPayment, error) {
+	mt.mutex.Lock()
+	defer mt.mutex.Unlock()
+
+	unlock := mt.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := mt.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	milestone := contract.Milestone(milestoneID)
+	if milestone == nil {
+		return nil, errors.New("hito no encontrado")
+	}
+	if milestone.Status != MilestoneCompleted {
+		return nil, errors.New("solo se pagan hitos cumplidos y sin acta de pago")
+	}
+	if reference == "" {
+		return nil, errors.New("el acta de pago requiere su número o referencia")
+	}
+	now := time.Now()
+	paidAt, err = mt.fiscal.checkPayment(contract, milestone.Amount, paidAt, now)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &ContractPayment{
+		ID:           uuid.New().String(),
+		Amount:       milestone.Amount,
+		Reference:    reference,
+		MilestoneID:  milestoneID,
+		PaidAt:       paidAt,
+		RegisteredBy: registeredBy,
+	}
+	blockData := map[string]interface{}{
+		"type":          PaymentActBlockType,
+		"contract_id":   contractID,
+		"milestone_id":  milestoneID,
+		"payment_id":    payment.ID,
+		"amount":        payment.Amount,
+		"reference":     reference,
+		"paid_at":       paidAt,
+		"registered_by": registeredBy,
+		"role":          string(role),
+		"timestamp":     now,
+	}
+	block, err := mt.blockchain.SealBlock(blockData)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.BlockHash = block.Hash
+	contract.Payments = append(contract.Payments, *payment)
+	milestone.Status = MilestonePaid
+	milestone.PaymentID = payment.ID
+	contract.UpdatedAt = now
+	mt.blockchain.WorkflowManager.addAuditEntry(contract, PaymentActBlockType, registeredBy, role, paymentActDescription(payment))
+	mt.blockchain.saveContract(contract)
+
+	logf("💵 Acta de pago %s por %.2f registrada en el contrato %s\n", reference, payment.Amount, contractID)
+	return payment, nil
+}
+
+// description resume el hito para la línea de auditoría
+func (m *Milestone) description() string {
+	return fmt.Sprintf("Hito agregado: %s por %.2f con vencimiento %s", m.Description, m.Amount, m.DueDate.Format("2006-01-02"))
+}
+
+// paymentActDescription resume el acta de pago para la línea de auditoría
+func paymentActDescription(payment *ContractPayment) string {
+	return fmt.Sprintf("Acta de pago %s por %.2f", payment.Reference, payment.Amount)
+}
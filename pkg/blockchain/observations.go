@@ -0,0 +1,278 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Respuesta de la entidad a las observaciones de los entes de control: cada observación
+// tiene un plazo para que la entidad responsable la conteste; si vence sin respuesta, se
+// escala al ente de control con una alerta. Observación, respuesta y escalamiento quedan
+// en la línea de auditoría pública del contrato.
+
+// DefaultObservationResponseDays es el plazo por defecto para responder una observación
+const DefaultObservationResponseDays = 10
+
+// AlertObservationOverdue se dispara cuando una observación vence sin respuesta
+const AlertObservationOverdue = "AUDIT_OBSERVATION_OVERDUE"
+
+// ObservationResponseBlockType es el bloque con la respuesta de la entidad a una observación
+const ObservationResponseBlockType = "AUDIT_OBSERVATION_RESPONSE"
+
+// Estados de una observación de control
+const (
+	ObservationPending   = "PENDING"
+	ObservationResponded = "RESPONDED"
+	ObservationOverdue   = "OVERDUE"
+	ObservationEscalated = "ESCALATED"
+)
+
+// AuditObservation es una observación de un ente de control y la respuesta de la entidad
+type AuditObservation struct {
+	ID          string     `json:"id"`
+	AuditorID   string     `json:"auditor_id"`
+	Role        AdminRole  `json:"role"`
+	Observation string     `json:"observation"`
+	FiledAt     time.Time  `json:"filed_at"`
+	DueAt       time.Time  `json:"due_at"`
+	Response    string     `json:"response,omitempty"`
+	RespondedBy string     `json:"responded_by,omitempty"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+	EscalatedAt *time.Time `json:"escalated_at,omitempty"`
+}
+
+// Status retorna el estado de la observación en el momento dado
+func (observation *AuditObservation) Status(now time.Time) string {
+	switch {
+	case observation.RespondedAt != nil:
+		return ObservationResponded
+	case observation.EscalatedAt != nil:
+		return ObservationEscalated
+	case now.After(observation.DueAt):
+		return ObservationOverdue
+	default:
+		return ObservationPending
+	}
+}
+
+// RespondedLate indica si la respuesta llegó después del plazo
+func (observation *AuditObservation) RespondedLate() bool {
+	return observation.RespondedAt != nil && observation.RespondedAt.After(observation.DueAt)
+}
+
+// responseDescription describe la respuesta en la línea de auditoría, advirtiendo si fue tardía
+func (observation *AuditObservation) responseDescription() string {
+	if observation.RespondedLate() {
+		return fmt.Sprintf("Respuesta fuera de plazo (vencía el %s) a observación de control: %s",
+			observation.DueAt.Format("2006-01-02"), observation.Response)
+	}
+	return "Respuesta a observación de control: " + observation.Response
+}
+
+// RespondObservation registra la respuesta de la entidad responsable a una observación de
+// control. Una respuesta tardía se acepta, pero queda marcada como tal.
+func (wm *WorkflowManager) RespondObservation(contractID string, observationID string, responderID string, role AdminRole, response string) (*AuditObservation, error) {
+	unlock := wm.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := wm.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if response == "" {
+		return nil, errors.New("respuesta requerida")
+	}
+
+	var observation *AuditObservation
+	for i := range contract.Observations {
+		if contract.Observations[i].ID == observationID {
+			observation = &contract.Observations[i]
+			break
+		}
+	}
+	if observation == nil {
+		return nil, errors.New("observación no encontrada")
+	}
+	if observation.RespondedAt != nil {
+		return nil, errors.New("la observación ya fue respondida")
+	}
+
+	now := time.Now()
+	observation.Response = response
+	observation.RespondedBy = responderID
+	observation.RespondedAt = &now
+	contract.UpdatedAt = now
+
+	wm.addAuditEntry(contract, ObservationResponseBlockType, responderID, role, observation.responseDescription())
+
+	blockData := map[string]interface{}{
+		"type":           ObservationResponseBlockType,
+		"contract_id":    contractID,
+		"observation_id": observationID,
+		"responder":      responderID,
+		"role":           string(role),
+		"response":       response,
+		"late":           observation.RespondedLate(),
+		"timestamp":      now,
+	}
+
+	wm.blockchain.stageContract(contract)
+	if err := wm.blockchain.AddBlock(blockData); err != nil {
+		wm.blockchain.unstageContract(contractID)
+		return nil, err
+	}
+	responded := *observation
+	return &responded, nil
+}
+
+// ObservationTracker vigila los plazos de respuesta de las observaciones de control y
+// escala las que vencen sin respuesta
+type ObservationTracker struct {
+	blockchain *Blockchain
+	alerts     *AlertManager
+	mutex      sync.Mutex
+}
+
+// NewObservationTracker crea el vigilante de plazos; las escalaciones se avisan como alertas
+func NewObservationTracker(bc *Blockchain, alerts *AlertManager) *ObservationTracker {
+	return &ObservationTracker{blockchain: bc, alerts: alerts}
+}
+
+// OverdueObservation es una observación vencida con el contrato al que pertenece
+type OverdueObservation struct {
+	ContractID  string           `json:"contract_id"`
+	EntityCode  string           `json:"entity_code"`
+	EntityName  string           `json:"entity_name"`
+	Observation AuditObservation `json:"observation"`
+	Status      string           `json:"status"`
+}
+
+// Overdue lista las observaciones vencidas sin respuesta, escaladas o no
+func (ot *ObservationTracker) Overdue(now time.Time) []OverdueObservation {
+	overdue := []OverdueObservation{}
+	ot.blockchain.currentState().Iterate(func(contract *Contract) bool {
+		for _, observation := range contract.Observations {
+			status := observation.Status(now)
+			if status == ObservationOverdue || status == ObservationEscalated {
+				overdue = append(overdue, OverdueObservation{
+					ContractID:  contract.ID,
+					EntityCode:  contract.EntityCode,
+					EntityName:  contract.EntityName,
+					Observation: observation,
+					Status:      status,
+				})
+			}
+		}
+		return true
+	})
+	return overdue
+}
+
+// Check escala las observaciones que vencieron sin respuesta y retorna cuántas escaló
+func (ot *ObservationTracker) Check(now time.Time) int {
+	ot.mutex.Lock()
+	defer ot.mutex.Unlock()
+
+	var pending []string
+	ot.blockchain.currentState().Iterate(func(contract *Contract) bool {
+		for i := range contract.Observations {
+			if contract.Observations[i].Status(now) == ObservationOverdue {
+				pending = append(pending, contract.ID)
+				break
+			}
+		}
+		return true
+	})
+
+	escalated := 0
+	for _, contractID := range pending {
+		escalated += ot.escalateContract(contractID, now)
+	}
+
+	if escalated > 0 {
+		logf("⏰ %d observaciones de control vencidas escaladas\n", escalated)
+	}
+	return escalated
+}
+
+// escalateContract escala, bajo el candado del contrato, sus observaciones vencidas y
+// retorna cuántas escaló
+func (ot *ObservationTracker) escalateContract(contractID string, now time.Time) int {
+	unlock := ot.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := ot.blockchain.editContract(contractID)
+	if err != nil {
+		return 0
+	}
+	escalated := 0
+	for i := range contract.Observations {
+		observation := &contract.Observations[i]
+		if observation.Status(now) != ObservationOverdue {
+			continue
+		}
+		escalatedAt := now
+		observation.EscalatedAt = &escalatedAt
+		contract.UpdatedAt = now
+		ot.blockchain.WorkflowManager.addAuditEntry(contract, "AUDIT_OBSERVATION_ESCALATED", "sistema", "",
+			fmt.Sprintf("Observación sin respuesta desde el %s escalada al ente de control", observation.DueAt.Format("2006-01-02")))
+		ot.escalate(contract, observation)
+		escalated++
+	}
+	if escalated > 0 {
+		ot.blockchain.saveContract(contract)
+	}
+	return escalated
+}
+
+// escalate avisa al ente de control que presentó la observación; las de la ciudadanía se
+// escalan a la Contraloría
+func (ot *ObservationTracker) escalate(contract *Contract, observation *AuditObservation) {
+	if ot.alerts == nil {
+		return
+	}
+	role := observation.Role
+	if role == RoleCitizen || role == "" {
+		role = RoleComptroller
+	}
+	ot.alerts.Raise(Alert{
+		RuleName:   "Observación de control sin respuesta",
+		Kind:       AlertObservationOverdue,
+		Role:       role,
+		ContractID: contract.ID,
+		EntityCode: contract.EntityCode,
+		Amount:     contract.Amount,
+		Message: fmt.Sprintf("%s no respondió la observación %s del contrato %s, vencida el %s",
+			contract.EntityName, observation.ID, contract.ID, observation.DueAt.Format("2006-01-02")),
+	})
+}
+
+// Run revisa cada every los plazos de las observaciones
+func (ot *ObservationTracker) Run(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ot.Check(time.Now())
+	}
+}
+
+// newObservation crea la observación con su plazo de respuesta
+func newObservation(auditorID string, role AdminRole, text string, responseDays int) AuditObservation {
+	if responseDays <= 0 {
+		responseDays = DefaultObservationResponseDays
+	}
+	now := time.Now()
+	return AuditObservation{
+		ID:          uuid.New().String(),
+		AuditorID:   auditorID,
+		Role:        role,
+		Observation: text,
+		FiledAt:     now,
+		DueAt:       now.AddDate(0, 0, responseDays),
+	}
+}
@@ -0,0 +1,141 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bloqueo de operaciones de varios pasos sobre un contrato: mientras una adición o
+// prórroga espera aprobación, otra modificación o la liquidación del mismo contrato se
+// rechaza para que sus registros en la cadena no se intercalen. Las operaciones que no
+// chocan entre sí pueden convivir.
+
+// ContractOperation es una operación de varios pasos sobre un contrato
+type ContractOperation string
+
+const (
+	OperationAmendment   ContractOperation = "AMENDMENT"   // Adición o prórroga
+	OperationLiquidation ContractOperation = "LIQUIDATION" // Liquidación del contrato
+)
+
+// conflictingOperations indica con qué operaciones en curso choca cada una
+var conflictingOperations = map[ContractOperation][]ContractOperation{
+	OperationAmendment:   {OperationAmendment, OperationLiquidation},
+	OperationLiquidation: {OperationAmendment, OperationLiquidation},
+}
+
+// Errores de OperationGuard que no son un conflicto
+var (
+	ErrUnknownOperation  = errors.New("operación de contrato desconocida")
+	ErrOperationNotFound = errors.New("la operación no está en curso")
+)
+
+// InFlightOperation es una operación en curso sobre un contrato
+type InFlightOperation struct {
+	ContractID string            `json:"contract_id"`
+	Operation  ContractOperation `json:"operation"`
+	Reference  string            `json:"reference"` // Identificador de la operación, p. ej. de la adición
+	StartedBy  string            `json:"started_by"`
+	StartedAt  time.Time         `json:"started_at"`
+}
+
+// OperationConflictError informa las operaciones en curso que impiden iniciar otra
+type OperationConflictError struct {
+	ContractID string              `json:"contract_id"`
+	Requested  ContractOperation   `json:"requested"`
+	InFlight   []InFlightOperation `json:"in_flight"`
+}
+
+func (e *OperationConflictError) Error() string {
+	pending := make([]string, 0, len(e.InFlight))
+	for _, operation := range e.InFlight {
+		pending = append(pending, fmt.Sprintf("%s %s", operation.Operation, operation.Reference))
+	}
+	return fmt.Sprintf("el contrato %s tiene operaciones en curso (%s) que impiden %s", e.ContractID, strings.Join(pending, ", "), e.Requested)
+}
+
+// OperationGuard lleva las operaciones en curso de cada contrato
+type OperationGuard struct {
+	operations map[string][]InFlightOperation
+	mutex      sync.Mutex
+}
+
+// NewOperationGuard crea el guardián sin operaciones en curso
+func NewOperationGuard() *OperationGuard {
+	return &OperationGuard{operations: make(map[string][]InFlightOperation)}
+}
+
+// Begin registra la operación si ninguna de las que están en curso en el contrato choca
+// con ella; si alguna choca retorna un *OperationConflictError que las lista
+func (og *OperationGuard) Begin(contractID string, operation ContractOperation, reference string, startedBy string) error {
+	conflicts, known := conflictingOperations[operation]
+	if !known {
+		return ErrUnknownOperation
+	}
+
+	og.mutex.Lock()
+	defer og.mutex.Unlock()
+
+	var blocking []InFlightOperation
+	for _, inFlight := range og.operations[contractID] {
+		for _, conflict := range conflicts {
+			if inFlight.Operation == conflict {
+				blocking = append(blocking, inFlight)
+				break
+			}
+		}
+	}
+	if len(blocking) > 0 {
+		return &OperationConflictError{ContractID: contractID, Requested: operation, InFlight: blocking}
+	}
+
+	og.operations[contractID] = append(og.operations[contractID], InFlightOperation{
+		ContractID: contractID,
+		Operation:  operation,
+		Reference:  reference,
+		StartedBy:  startedBy,
+		StartedAt:  time.Now(),
+	})
+	return nil
+}
+
+// End libera la operación al aprobarse, rechazarse o cancelarse
+func (og *OperationGuard) End(contractID string, reference string) error {
+	og.mutex.Lock()
+	defer og.mutex.Unlock()
+
+	operations := og.operations[contractID]
+	for i, inFlight := range operations {
+		if inFlight.Reference == reference {
+			operations = append(operations[:i], operations[i+1:]...)
+			if len(operations) == 0 {
+				delete(og.operations, contractID)
+			} else {
+				og.operations[contractID] = operations
+			}
+			return nil
+		}
+	}
+	return ErrOperationNotFound
+}
+
+// InFlight retorna las operaciones en curso del contrato, de la más antigua a la más reciente
+func (og *OperationGuard) InFlight(contractID string) []InFlightOperation {
+	og.mutex.Lock()
+	defer og.mutex.Unlock()
+
+	operations := append([]InFlightOperation{}, og.operations[contractID]...)
+	sort.Slice(operations, func(i, j int) bool { return operations[i].StartedAt.Before(operations[j].StartedAt) })
+	return operations
+}
+
+// clear descarta todas las operaciones en curso, p. ej. al reiniciar la cadena del sandbox
+func (og *OperationGuard) clear() {
+	og.mutex.Lock()
+	defer og.mutex.Unlock()
+	og.operations = make(map[string][]InFlightOperation)
+}
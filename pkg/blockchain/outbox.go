@@ -0,0 +1,314 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// Parámetros de despacho del outbox
+const (
+	outboxBaseBackoff = time.Second
+	outboxMaxBackoff  = 5 * time.Minute
+	// Tiempo que se recuerda un mensaje entregado para descartar sus réplicas del WAL
+	outboxDoneRetention = 7 * 24 * time.Hour
+)
+
+// OutboxP2PBroadcast es el consumidor que difunde los bloques nuevos a los peers
+const OutboxP2PBroadcast = "p2p_broadcast"
+
+// OutboxMessage representa un efecto secundario de un bloque pendiente de entregar
+// a un consumidor. Su ID es determinístico (consumidor y hash del bloque), por lo que
+// sirve como llave de idempotencia para los receptores.
+type OutboxMessage struct {
+	ID          string    `json:"id"`
+	Consumer    string    `json:"consumer"`
+	BlockHash   string    `json:"block_hash"`
+	Height      int       `json:"height"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// OutboxHandler entrega el efecto secundario de un bloque; si retorna error se reintenta
+type OutboxHandler func(message OutboxMessage, block *Block) error
+
+// OutboxStatus resume el estado del outbox
+type OutboxStatus struct {
+	Consumers []string        `json:"consumers"`
+	Pending   int             `json:"pending"`
+	Delivered int             `json:"delivered"`
+	Messages  []OutboxMessage `json:"messages"`
+}
+
+// Outbox registra los efectos secundarios de cada bloque en la misma escritura que el
+// bloque (vía stateChanges y el WAL) y los despacha con un worker, de modo que no se
+// pierden ante una caída ni se entregan dos veces tras reiniciar
+type Outbox struct {
+	blockchain *Blockchain
+	consumers  []string
+	handlers   map[string]OutboxHandler
+	pending    map[string]*OutboxMessage
+	done       map[string]time.Time
+	delivered  int
+	mutex      sync.Mutex
+}
+
+// newOutbox crea el outbox de la cadena
+func newOutbox(bc *Blockchain) *Outbox {
+	return &Outbox{
+		blockchain: bc,
+		handlers:   make(map[string]OutboxHandler),
+		pending:    make(map[string]*OutboxMessage),
+		done:       make(map[string]time.Time),
+	}
+}
+
+// Register agrega un consumidor; desde ese momento cada bloque nuevo genera un mensaje para él
+func (ob *Outbox) Register(consumer string, handler OutboxHandler) {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+
+	if _, exists := ob.handlers[consumer]; !exists {
+		ob.consumers = append(ob.consumers, consumer)
+	}
+	ob.handlers[consumer] = handler
+}
+
+// messagesFor crea los mensajes de un bloque para los consumidores registrados
+func (ob *Outbox) messagesFor(block *Block) []*OutboxMessage {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+
+	now := time.Now()
+	messages := make([]*OutboxMessage, 0, len(ob.consumers))
+	for _, consumer := range ob.consumers {
+		messages = append(messages, &OutboxMessage{
+			ID:          consumer + ":" + block.Hash,
+			Consumer:    consumer,
+			BlockHash:   block.Hash,
+			Height:      block.Index,
+			NextAttempt: now,
+			CreatedAt:   now,
+		})
+	}
+	return messages
+}
+
+// isDelivered indica si el mensaje ya fue entregado
+func (ob *Outbox) isDelivered(id string) bool {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+	_, done := ob.done[id]
+	return done
+}
+
+// track agrega al despacho un mensaje recién guardado junto con su bloque
+func (ob *Outbox) track(value json.RawMessage) {
+	var message OutboxMessage
+	if err := json.Unmarshal(value, &message); err != nil {
+		return
+	}
+	ob.mutex.Lock()
+	if _, done := ob.done[message.ID]; !done {
+		ob.pending[message.ID] = &message
+	}
+	ob.mutex.Unlock()
+}
+
+// load carga los mensajes pendientes y los entregados recientemente; los pendientes que
+// ya se habían entregado (réplicas del WAL tras una caída) se descartan
+func (ob *Outbox) load() {
+	bc := ob.blockchain
+	cutoff := time.Now().Add(-outboxDoneRetention)
+
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+
+	var expired []string
+	bc.store.ForEach(storage.BucketOutboxDone, func(key string, value []byte) error {
+		var deliveredAt time.Time
+		if err := json.Unmarshal(value, &deliveredAt); err != nil || deliveredAt.Before(cutoff) {
+			expired = append(expired, key)
+			return nil
+		}
+		ob.done[key] = deliveredAt
+		return nil
+	})
+	for _, key := range expired {
+		bc.deleteState(storage.BucketOutboxDone, key)
+	}
+
+	var duplicated []string
+	bc.store.ForEach(storage.BucketOutbox, func(key string, value []byte) error {
+		if _, done := ob.done[key]; done {
+			duplicated = append(duplicated, key)
+			return nil
+		}
+		var message OutboxMessage
+		if err := json.Unmarshal(value, &message); err == nil {
+			ob.pending[key] = &message
+		}
+		return nil
+	})
+	for _, key := range duplicated {
+		bc.deleteState(storage.BucketOutbox, key)
+	}
+
+	if len(ob.pending) > 0 || len(duplicated) > 0 {
+		logf("📤 Outbox: %d mensajes pendientes, %d duplicados descartados\n", len(ob.pending), len(duplicated))
+	}
+}
+
+// Run carga los mensajes guardados y los despacha periódicamente
+func (ob *Outbox) Run(interval time.Duration) {
+	ob.load()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastPrune := time.Now()
+	for range ticker.C {
+		ob.dispatch()
+		if time.Since(lastPrune) > time.Hour {
+			ob.pruneDelivered()
+			lastPrune = time.Now()
+		}
+	}
+}
+
+// pruneDelivered olvida las entregas más antiguas que el período de retención
+func (ob *Outbox) pruneDelivered() {
+	cutoff := time.Now().Add(-outboxDoneRetention)
+
+	ob.mutex.Lock()
+	var expired []string
+	for id, deliveredAt := range ob.done {
+		if deliveredAt.Before(cutoff) {
+			expired = append(expired, id)
+			delete(ob.done, id)
+		}
+	}
+	ob.mutex.Unlock()
+
+	for _, id := range expired {
+		ob.blockchain.deleteState(storage.BucketOutboxDone, id)
+	}
+}
+
+// dispatch entrega los mensajes listos en orden de altura; si un mensaje falla, los
+// siguientes del mismo consumidor esperan para no entregar bloques fuera de orden
+func (ob *Outbox) dispatch() {
+	ob.mutex.Lock()
+	messages := make([]*OutboxMessage, 0, len(ob.pending))
+	for _, message := range ob.pending {
+		messages = append(messages, message)
+	}
+	handlers := make(map[string]OutboxHandler, len(ob.handlers))
+	for consumer, handler := range ob.handlers {
+		handlers[consumer] = handler
+	}
+	ob.mutex.Unlock()
+
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].Height != messages[j].Height {
+			return messages[i].Height < messages[j].Height
+		}
+		return messages[i].ID < messages[j].ID
+	})
+
+	bc := ob.blockchain
+	blocked := make(map[string]bool)
+	now := time.Now()
+	for _, message := range messages {
+		handler, registered := handlers[message.Consumer]
+		if !registered || blocked[message.Consumer] {
+			continue
+		}
+		if message.NextAttempt.After(now) {
+			blocked[message.Consumer] = true
+			continue
+		}
+
+		block, err := bc.BlockAt(message.Height)
+		if err == nil && block.Hash != message.BlockHash {
+			// La cadena fue reemplazada y el bloque ya no existe
+			logf("🗑️ Outbox: mensaje %s descartado, el bloque ya no está en la cadena\n", message.ID)
+			ob.forget(message)
+			continue
+		}
+		if err == nil {
+			err = handler(*message, block)
+		}
+
+		if err != nil {
+			blocked[message.Consumer] = true
+			ob.retry(message, err)
+			continue
+		}
+		ob.markDelivered(message)
+	}
+}
+
+// markDelivered registra la entrega antes de borrar el mensaje, para que una réplica
+// posterior del mismo mensaje se reconozca como duplicada
+func (ob *Outbox) markDelivered(message *OutboxMessage) {
+	deliveredAt := time.Now()
+	ob.blockchain.saveState(storage.BucketOutboxDone, message.ID, deliveredAt)
+
+	ob.mutex.Lock()
+	ob.done[message.ID] = deliveredAt
+	ob.delivered++
+	ob.mutex.Unlock()
+
+	ob.forget(message)
+}
+
+// retry programa un nuevo intento del mensaje con backoff exponencial
+func (ob *Outbox) retry(message *OutboxMessage, err error) {
+	ob.mutex.Lock()
+	message.Attempts++
+	message.LastError = err.Error()
+	backoff := outboxBaseBackoff << uint(message.Attempts-1)
+	if backoff > outboxMaxBackoff || backoff <= 0 {
+		backoff = outboxMaxBackoff
+	}
+	message.NextAttempt = time.Now().Add(backoff)
+	snapshot := *message
+	ob.mutex.Unlock()
+
+	ob.blockchain.saveState(storage.BucketOutbox, snapshot.ID, snapshot)
+	logf("❌ Outbox: error entregando %s (intento %d): %v\n", snapshot.ID, snapshot.Attempts, err)
+}
+
+// forget elimina el mensaje de los pendientes
+func (ob *Outbox) forget(message *OutboxMessage) {
+	ob.mutex.Lock()
+	delete(ob.pending, message.ID)
+	ob.mutex.Unlock()
+	ob.blockchain.deleteState(storage.BucketOutbox, message.ID)
+}
+
+// Status retorna los consumidores y los mensajes pendientes en orden de altura
+func (ob *Outbox) Status() OutboxStatus {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+
+	status := OutboxStatus{
+		Consumers: append([]string{}, ob.consumers...),
+		Pending:   len(ob.pending),
+		Delivered: ob.delivered,
+		Messages:  make([]OutboxMessage, 0, len(ob.pending)),
+	}
+	for _, message := range ob.pending {
+		status.Messages = append(status.Messages, *message)
+	}
+	sort.Slice(status.Messages, func(i, j int) bool {
+		return status.Messages[i].Height < status.Messages[j].Height
+	})
+	return status
+}
@@ -0,0 +1,860 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Peer representa un nodo peer en la red
+type Peer struct {
+	ID          string              `json:"id"`
+	Address     string              `json:"address"`
+	Port        string              `json:"port"`
+	LastSeen    time.Time           `json:"last_seen"`
+	Active      bool                `json:"active"`
+	Maintenance bool                `json:"maintenance"`
+	Features    *ProtocolFeatures   `json:"features,omitempty"`
+	Negotiation *FeatureNegotiation `json:"negotiation,omitempty"`
+	PublicKeys  []PublicKeyInfo     `json:"public_keys,omitempty"`
+	KeysEndorsement *KeySetEndorsement `json:"keys_endorsement,omitempty"`
+	// Multiaddrs libp2p con las que se le puede contactar (ver P2P_BACKEND=libp2p)
+	Libp2pAddrs []string `json:"libp2p_addrs,omitempty"`
+
+	// Mientras está inactivo se reintenta con espera exponencial (ver peers.go)
+	InactiveSince *time.Time `json:"inactive_since,omitempty"`
+	RetryAttempts int        `json:"retry_attempts,omitempty"`
+	NextRetry     *time.Time `json:"next_retry,omitempty"`
+}
+
+// P2PNetwork maneja la comunicación entre nodos
+type P2PNetwork struct {
+	NodeID     string
+	Address    string
+	Port       string
+	Peers      map[string]*Peer
+	Blockchain *Blockchain
+	Quarantine *Quarantine
+	Reputation *PeerReputation
+	Forks      *ForkManager
+	// Reserved define qué nodos reciben completos los bloques de contratos reservados
+	Reserved   *ReservedPolicy
+	Keys       *NodeKeyring
+	// GossipFanout es la cantidad de peers a los que se envía cada bloque (0 para todos)
+	GossipFanout int
+	seen         *seenCache
+	relayedKeys  map[string][]PublicKeyInfo // Llaves de firmantes que no son peers directos
+	relayedEndorsements map[string]KeySetEndorsement // Endosos de esas llaves, para retransmitirlos
+	removed      map[string]bool            // Peers retirados a mano o por inactividad; no se redescubren
+	removals     []PeerRemoval              // Registro de los retiros por inactividad
+	// PeerRetention es cuánto puede seguir inactivo un peer antes de retirarlo (0 nunca)
+	PeerRetention time.Duration
+	// Maintenance congela la recepción de bloques por el canal WebSocket, como maintenanceGuard en HTTP
+	Maintenance  *MaintenanceMode
+	ws           *wsTransport // Canal WebSocket con los peers; nil si solo se usa HTTP
+	// Libp2p configura el transporte libp2p; se aplica al elegirlo con SetBackend
+	Libp2p       Libp2pConfig
+	libp2p       *libp2pTransport // Host libp2p; nil si el transporte no es libp2p
+	backend      P2PTransport // Transporte con el que se entregan los mensajes a los peers
+	peerJoined   func(Peer)   // Aviso de peers nuevos o reactivados; nil si nadie escucha
+	isolated     bool         // Nodo aislado (sandbox): no acepta peers ni difunde a la red
+	catchUps     *catchUpTracker // Puestas al día en curso con los peers que enviaron bloques adelantados
+	mutex      sync.RWMutex
+	tlsConfig  *tls.Config
+	transport  *http.Transport
+}
+
+// NewP2PNetwork crea una nueva instancia de red P2P
+func NewP2PNetwork(nodeID, address, port string, blockchain *Blockchain) *P2PNetwork {
+	p2p := &P2PNetwork{
+		NodeID:     nodeID,
+		Address:    address,
+		Port:       port,
+		Peers:      make(map[string]*Peer),
+		Blockchain: blockchain,
+		Quarantine: NewQuarantine(),
+		Reputation: NewPeerReputation(),
+		Forks:      NewForkManager(),
+		Reserved:   NewReservedPolicy(blockchain),
+		GossipFanout: DefaultGossipFanout,
+		seen:         newSeenCache(gossipSeenCapacity),
+		relayedKeys:  make(map[string][]PublicKeyInfo),
+		relayedEndorsements: make(map[string]KeySetEndorsement),
+		removed:      make(map[string]bool),
+		catchUps:     newCatchUpTracker(),
+		PeerRetention: DefaultPeerRetention,
+	}
+	p2p.loadPeerRemovals()
+	p2p.backend = &httpTransport{p2p: p2p}
+	// Los bloques nuevos se difunden desde el outbox para no perderlos ante una caída
+	blockchain.Outbox.Register(OutboxP2PBroadcast, p2p.broadcastFromOutbox)
+	// Las transacciones pendientes también viajan por la red para que las selle cualquier nodo
+	p2p.enableTransactionGossip()
+	return p2p
+}
+
+// AddPeer agrega un nuevo peer a la red
+func (p2p *P2PNetwork) AddPeer(peerID, address, port string) {
+	if p2p.Isolated() {
+		logf("🧪 Peer %s ignorado: el nodo está aislado\n", peerID)
+		return
+	}
+	p2p.registerPeer(peerID, address, port)
+	
+	// Negociar capacidades del protocolo y registrarse en el peer en segundo plano
+	go p2p.Handshake(peerID)
+}
+
+// registerPeer guarda el peer como activo, sin handshake
+func (p2p *P2PNetwork) registerPeer(peerID, address, port string) *Peer {
+	p2p.mutex.Lock()
+	defer p2p.mutex.Unlock()
+	
+	delete(p2p.removed, peerID)
+	previous, known := p2p.Peers[peerID]
+	peer := &Peer{
+		ID:       peerID,
+		Address:  address,
+		Port:     port,
+		LastSeen: time.Now(),
+		Active:   true,
+	}
+	p2p.Peers[peerID] = peer
+	
+	logf("🔗 Peer agregado: %s (%s:%s)\n", peerID, address, port)
+	if p2p.peerJoined != nil && (!known || !previous.Active) {
+		p2p.peerJoined(*peer)
+	}
+	return peer
+}
+
+// Isolate aísla el nodo de la red: desde ese momento no agrega peers, de modo que sus
+// bloques no llegan a ningún otro nodo. No tiene vuelta atrás.
+func (p2p *P2PNetwork) Isolate() {
+	p2p.mutex.Lock()
+	defer p2p.mutex.Unlock()
+	p2p.isolated = true
+	p2p.Peers = make(map[string]*Peer)
+}
+
+// Isolated indica si el nodo está aislado de la red
+func (p2p *P2PNetwork) Isolated() bool {
+	p2p.mutex.RLock()
+	defer p2p.mutex.RUnlock()
+	return p2p.isolated
+}
+
+// SetPeerListener define a quién se avisa cuando un peer se une a la red o vuelve a estar
+// activo. El aviso se hace con el lock de la red tomado, así que no debe bloquear.
+func (p2p *P2PNetwork) SetPeerListener(listener func(Peer)) {
+	p2p.mutex.Lock()
+	defer p2p.mutex.Unlock()
+	p2p.peerJoined = listener
+}
+
+// BroadcastBlock envía un bloque creado por este nodo a un subconjunto aleatorio de peers
+// activos, que lo reenvían por gossip, y retorna error si alguno no lo recibió; reenviarlo
+// es seguro porque los peers ignoran bloques que ya tienen
+func (p2p *P2PNetwork) BroadcastBlock(block Block) error {
+	p2p.seen.markSeen(block.Hash)
+	peers := p2p.gossipPeers()
+	logf("📡 Broadcasting bloque %s a %d peers\n", block.Hash, len(peers))
+	return p2p.sendToPeers(block, peers)
+}
+
+// sendToPeers envía el bloque en paralelo a los peers indicados, marca inactivos a los
+// que no lo recibieron y retorna error si hubo alguno
+func (p2p *P2PNetwork) sendToPeers(block Block, peers map[string]*Peer) error {
+	var wg sync.WaitGroup
+	var failed []string
+	var failedMutex sync.Mutex
+	for peerID, peer := range peers {
+		if !peer.acceptsKind(block.Type) {
+			logf("⏭️ Peer %s no soporta bloques %s, omitiendo\n", peerID, block.Type)
+			continue
+		}
+		
+		// Los bloques de contratos reservados llegan completos solo a los nodos autorizados
+		outgoing, send := byzantineOutgoingBlock(p2p.BlockFor(peerID, block))
+		if !send {
+			continue
+		}
+		
+		wg.Add(1)
+		go func(peerID string, peer *Peer, block Block) {
+			defer wg.Done()
+			err := p2p.backend.SendBlock(peer, block)
+			if err != nil {
+				logf("❌ Error enviando bloque a %s: %v\n", peerID, err)
+				failedMutex.Lock()
+				failed = append(failed, peerID)
+				failedMutex.Unlock()
+			} else {
+				logf("✅ Bloque enviado a %s\n", peerID)
+			}
+		}(peerID, peer, outgoing)
+	}
+	wg.Wait()
+	
+	for _, peerID := range failed {
+		p2p.markPeerInactive(peerID)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("bloque %s no entregado a %v", block.Hash, failed)
+	}
+	return nil
+}
+
+// broadcastFromOutbox difunde los bloques creados por este nodo; los recibidos de
+// peers se reenvían por gossip al recibirlos, con su encabezado original
+func (p2p *P2PNetwork) broadcastFromOutbox(message OutboxMessage, block *Block) error {
+	if isReceivedBlock(block) {
+		return nil
+	}
+	p2p.Vote(block.Hash)
+	return p2p.BroadcastBlock(*block)
+}
+
+// isReceivedBlock indica si el bloque llegó de un peer (ReceiveBlock anida los datos originales)
+func isReceivedBlock(block *Block) bool {
+	_, nested := block.Data["data"].(map[string]interface{})
+	_, relayed := block.Data["previous_hash"]
+	return nested && relayed
+}
+
+// sendBlockToPeer envía un bloque a un peer específico
+func (p2p *P2PNetwork) sendBlockToPeer(peer *Peer, block Block) error {
+	url := p2p.peerURL(peer, "/api/p2p/receive-block")
+	
+	blockData, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(blockData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Node-ID", p2p.NodeID)
+	
+	resp, err := p2p.peerClient(0).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer respondió con status %d", resp.StatusCode)
+	}
+	
+	// El peer responde con su voto de finalización si es validador
+	var response struct {
+		Vote *FinalityVote `json:"vote"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err == nil && response.Vote != nil && response.Vote.BlockHash == block.Hash {
+		p2p.recordVote(*response.Vote)
+	}
+	return nil
+}
+
+// ReceiveBlock procesa un bloque recibido de otro peer. sender es el ID autenticado del
+// peer (p. ej. por su certificado de nodo) o "" si no se pudo autenticar: un bloque anónimo
+// se valida igual, pero no cuenta para la reputación de nadie ni dispara sincronizaciones.
+func (p2p *P2PNetwork) ReceiveBlock(block Block, sender string) error {
+	authenticated := sender != ""
+	if !authenticated {
+		logf("📥 Bloque recibido de un remitente no autenticado: %s\n", block.Hash)
+	} else {
+		logf("📥 Bloque recibido de peer %s: %s\n", sender, block.Hash)
+	}
+	
+	if authenticated && p2p.Reputation.IsBanned(sender) {
+		return fmt.Errorf("peer %s vetado por enviar datos inválidos", sender)
+	}
+	
+	// Con gossip el mismo bloque llega por varios caminos; solo se procesa la primera vez
+	if p2p.seen.contains(block.Hash) {
+		logf("♻️ Bloque %s ya visto, ignorando\n", block.Hash)
+		return nil
+	}
+	
+	// Validar el bloque; los inválidos se guardan en cuarentena como evidencia
+	if reason := p2p.invalidBlockReason(block, sender); reason != "" {
+		if !authenticated {
+			return fmt.Errorf("bloque inválido recibido: %s", reason)
+		}
+		p2p.rejectInvalidBlock(block, sender, reason)
+		logf("☣️ Bloque %s de %s en cuarentena: %s\n", block.Hash, sender, reason)
+		return fmt.Errorf("bloque inválido recibido: %s", reason)
+	}
+	// Un bloque que no sigue a la punta se resuelve pidiéndole bloques a quien lo envió, y a
+	// un remitente anónimo no se le pide nada; tampoco se marca visto
+	if !authenticated && !p2p.Blockchain.HasBlock(block.Hash) && !p2p.Blockchain.extendsTip(block) {
+		return fmt.Errorf("el bloque %s no extiende la punta local y su remitente no está autenticado", block.Hash)
+	}
+	// Se marca visto solo ya validado, para que una copia alterada no bloquee la auténtica
+	if p2p.seen.markSeen(block.Hash) {
+		return nil
+	}
+	
+	// Verificar si ya tenemos este bloque
+	if p2p.Blockchain.HasBlock(block.Hash) {
+		logf("⚠️ Bloque %s ya existe, ignorando\n", block.Hash)
+		return nil
+	}
+	
+	// Los bloques que no siguen a la punta se tratan como una posible bifurcación
+	if !p2p.Blockchain.extendsTip(block) {
+		if !authenticated {
+			return fmt.Errorf("el bloque %s no extiende la punta local y su remitente no está autenticado", block.Hash)
+		}
+		err := p2p.handleOrphan(block, sender)
+		if err == nil && p2p.Blockchain.HasBlock(block.Hash) {
+			go p2p.forwardBlock(block, sender)
+		}
+		return err
+	}
+	
+	if err := p2p.appendReceived(block); err != nil {
+		return err
+	}
+	if authenticated {
+		p2p.Reputation.RecordValidBlock(sender)
+	}
+	go p2p.forwardBlock(block, sender)
+	
+	logf("✅ Bloque %s agregado exitosamente\n", block.Hash)
+	
+	// El bloque pudo completar la cadena de huérfanos que esperaban a su padre
+	p2p.Forks.resolving.Lock()
+	p2p.connectOrphans()
+	p2p.Forks.resolving.Unlock()
+	return nil
+}
+
+// appendReceived agrega a la cadena un bloque recibido, anidando sus datos originales
+func (p2p *P2PNetwork) appendReceived(block Block) error {
+	// Agregar el bloque a nuestra cadena
+	blockData := map[string]interface{}{
+		"type":          block.Type,
+		"data":          block.Data,
+		"timestamp":     block.Timestamp,
+		"previous_hash": block.PreviousHash,
+		"nonce":         block.Nonce,
+		// Encabezado y firma del bloque original, para verificar quién lo creó
+		"origin": map[string]interface{}{
+			"index":          block.Index,
+			"hash":           block.Hash,
+			"signature":      block.Signature,
+			"signer_node_id": block.SignerNodeID,
+			"signer_kid":     block.SignerKeyID,
+			"hash_version":   block.HashVersion,
+		},
+	}
+	if block.Withheld {
+		// Solo llegó el encabezado de un contrato reservado; el contenido se pide a un nodo autorizado
+		blockData["withheld"] = true
+	}
+	
+	// Un bloque original sin transacciones (anterior al árbol) se retransmite igual, sin ellas
+	transactions := append([]Transaction{}, block.Transactions...)
+	_, err := p2p.Blockchain.addBlock(blockData, transactions)
+	if err != nil {
+		return fmt.Errorf("error agregando bloque: %v", err)
+	}
+	return nil
+}
+
+// invalidBlockReason retorna la razón por la que un bloque recibido es inválido, o "" si es válido
+func (p2p *P2PNetwork) invalidBlockReason(block Block, sender string) string {
+	if block.Hash == "" || block.Timestamp.IsZero() {
+		return "bloque incompleto"
+	}
+	// Un bloque retenido de un contrato reservado no trae el contenido para recalcular el hash
+	if !block.Withheld && !block.IsValid() {
+		return "hash no corresponde al contenido"
+	}
+	// Con la migración programada, los bloques desde la altura de activación deben ser canónicos
+	if activation, scheduled := p2p.Blockchain.Protocol.ActivationHeight(CanonicalHashingFeature); scheduled &&
+		block.HashVersion == HashVersionLegacy && block.Index >= activation {
+		return "bloque con hash legado después de la activación del hash canónico"
+	}
+	if p2p.Blockchain.poa != nil {
+		// En prueba de autoridad solo valen las llaves registradas en la cadena
+		if err := p2p.Blockchain.verifyAuthorityBlock(block); err != nil {
+			return err.Error()
+		}
+	} else if err := VerifyBlockSignature(block, p2p.ValidatorKeys()); err != nil {
+		// El firmante puede ser un peer que rotó su llave después del último handshake
+		if !p2p.refreshSignerKeys(block, sender) {
+			return err.Error()
+		}
+		if err := VerifyBlockSignature(block, p2p.ValidatorKeys()); err != nil {
+			return err.Error()
+		}
+	}
+	if block.Type != "" && !IsKnownTransactionKind(block.Type) {
+		return fmt.Sprintf("tipo de transacción desconocido %s, se requiere actualizar el nodo", block.Type)
+	}
+	if block.Type == CheckpointBlockType && p2p.Blockchain.Checkpoints != nil {
+		if err := p2p.Blockchain.Checkpoints.verifyBlock(block); err != nil {
+			return err.Error()
+		}
+	}
+	if p2p.Blockchain.HasBlock(block.Hash) {
+		return ""
+	}
+	// Los bloques que no extienden la punta se resuelven como bifurcación en ReceiveBlock
+	if !p2p.Blockchain.extendsTip(block) {
+		return ""
+	}
+	if err := p2p.Blockchain.checkSequence(block.Data); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// SyncWithPeers sincroniza la blockchain con todos los peers
+func (p2p *P2PNetwork) SyncWithPeers() error {
+	p2p.mutex.RLock()
+	defer p2p.mutex.RUnlock()
+	
+	logf("🔄 Iniciando sincronización con %d peers\n", len(p2p.Peers))
+	
+	for peerID, peer := range p2p.Peers {
+		if !peer.Active || p2p.Reputation.IsBanned(peerID) {
+			continue
+		}
+		p2p.syncWithPeer(peerID, peer)
+	}
+	
+	return nil
+}
+
+// syncWithPeer trae primero solo los bloques posteriores a la punta local; la cadena
+// completa del peer solo si divergen
+func (p2p *P2PNetwork) syncWithPeer(peerID string, peer *Peer) {
+	err := p2p.syncFromTip(peerID, peer)
+	if err == nil {
+		return
+	}
+	logf("⚠️ Sincronización incremental con %s no aplicable: %v\n", peerID, err)
+
+	chain, err := p2p.requestChainFromPeer(peer)
+	if err != nil {
+		logf("❌ Error obteniendo cadena de %s: %v\n", peerID, err)
+		p2p.Reputation.RecordSyncFailure(peerID)
+		return
+	}
+	p2p.adoptChain(peerID, chain)
+}
+
+// adoptChain adopta la cadena de un peer si es más larga que la local y válida
+func (p2p *P2PNetwork) adoptChain(peerID string, chain []Block) {
+	if len(chain) <= p2p.Blockchain.Len() {
+		return
+	}
+	// Sin importar el modo de consenso, cada bloque debe venir firmado por un nodo conocido
+	p2p.learnChainSigners(chain, peerID)
+	if err := p2p.Blockchain.VerifyPeerChain(chain, p2p.ValidatorKeys()); err != nil {
+		logf("🚫 Cadena de %s rechazada: %v\n", peerID, err)
+		p2p.Reputation.RecordInvalid(peerID, err.Error())
+		return
+	}
+	// Convertir []Block a []*Block
+	adopted := make([]*Block, len(chain))
+	for i, block := range chain {
+		blockCopy := block
+		adopted[i] = &blockCopy
+	}
+	// En prueba de autoridad la longitud no basta: cada bloque debe firmarlo un validador autorizado
+	if err := p2p.Blockchain.verifyAuthorityChain(adopted); err != nil {
+		logf("🚫 Cadena de %s rechazada: %v\n", peerID, err)
+		p2p.Reputation.RecordInvalid(peerID, err.Error())
+		return
+	}
+	// Ninguna cadena, por larga que sea, reescribe la historia anterior al último checkpoint
+	if err := p2p.Blockchain.checkCheckpoint(adopted); err != nil {
+		logf("🚫 Cadena de %s rechazada: %v\n", peerID, err)
+		p2p.Reputation.RecordInvalid(peerID, err.Error())
+		return
+	}
+	logf("🔄 Adoptando cadena más larga de %s (%d bloques)\n", peerID, len(chain))
+	if err := p2p.Blockchain.ReplaceChain(adopted); err != nil {
+		logf("❌ Error adoptando cadena de %s: %v\n", peerID, err)
+		return
+	}
+	p2p.rebuildContractsFromChain()
+}
+
+// requestChainFromPeer solicita la blockchain completa de un peer
+func (p2p *P2PNetwork) requestChainFromPeer(peer *Peer) ([]Block, error) {
+	resp, err := p2p.peerGet(peer, "/api/p2p/get-chain", 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer respondió con status %d", resp.StatusCode)
+	}
+	
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	
+	var response struct {
+		Chain []Block `json:"chain"`
+	}
+	
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, err
+	}
+	
+	return response.Chain, nil
+}
+
+// rebuildContractsFromChain reconstruye el mapa de contratos desde la cadena, aplicando en
+// orden el estado que trae cada bloque. Los contratos de bloques anteriores a la
+// replicación de estado, que no lo traen, conservan la copia local si existe.
+func (p2p *P2PNetwork) rebuildContractsFromChain() {
+	if err := p2p.Blockchain.ReplayState(); err != nil {
+		logf("❌ Error reconstruyendo contratos: %v\n", err)
+	}
+}
+
+// markPeerInactive marca un peer como inactivo
+func (p2p *P2PNetwork) markPeerInactive(peerID string) {
+	p2p.mutex.Lock()
+	defer p2p.mutex.Unlock()
+	
+	if peer, exists := p2p.Peers[peerID]; exists {
+		peer.deactivate(time.Now())
+		logf("⚠️ Peer %s marcado como inactivo\n", peerID)
+	}
+}
+
+// GetActivePeers retorna la lista de peers activos
+func (p2p *P2PNetwork) GetActivePeers() []*Peer {
+	p2p.mutex.RLock()
+	defer p2p.mutex.RUnlock()
+	
+	var activePeers []*Peer
+	for _, peer := range p2p.Peers {
+		if peer.Active {
+			activePeers = append(activePeers, peer)
+		}
+	}
+	
+	return activePeers
+}
+
+// HealthCheck verifica el estado de todos los peers, retira los que llevan demasiado
+// inactivos y aprende de los demás los nodos que aún no conoce
+func (p2p *P2PNetwork) HealthCheck() {
+	p2p.checkPeers()
+	p2p.PruneDeadPeers()
+	p2p.DiscoverPeers()
+}
+
+// checkPeers verifica el estado de todos los peers
+func (p2p *P2PNetwork) checkPeers() {
+	p2p.mutex.Lock()
+	defer p2p.mutex.Unlock()
+	
+	for peerID, peer := range p2p.Peers {
+		// Los inactivos se reintentan con su propia espera en RunPeerRetry
+		if !peer.Active {
+			continue
+		}
+		// Un nodo detrás de un NAT solo es alcanzable por la conexión libp2p que él abrió
+		if p2p.libp2p != nil && p2p.libp2p.connectedTo(peerID) {
+			p2p.Reputation.RecordHealthCheck(peerID, true)
+			peer.LastSeen = time.Now()
+			logf("💚 Peer %s activo (libp2p)\n", peerID)
+			continue
+		}
+		url := p2p.peerURL(peer, "/api/health")
+		
+		client := p2p.peerClient(5 * time.Second)
+		resp, err := client.Get(url)
+		
+		healthy := err == nil && resp.StatusCode == http.StatusOK
+		p2p.Reputation.RecordHealthCheck(peerID, healthy)
+		if !healthy {
+			peer.deactivate(time.Now())
+			logf("💔 Peer %s no responde\n", peerID)
+		} else {
+			peer.LastSeen = time.Now()
+			logf("💚 Peer %s activo\n", peerID)
+			
+			if info, err := p2p.requestHandshake(peer); err == nil {
+				p2p.applyHandshake(peer, info)
+			}
+		}
+		
+		if resp != nil {
+			resp.Body.Close()
+		}
+	}
+}
+
+// SetPeerMaintenance registra que un peer entró o salió de mantenimiento
+func (p2p *P2PNetwork) SetPeerMaintenance(peerID string, enabled bool) bool {
+	p2p.mutex.Lock()
+	defer p2p.mutex.Unlock()
+
+	peer, exists := p2p.Peers[peerID]
+	if !exists {
+		return false
+	}
+	peer.Maintenance = enabled
+	if enabled {
+		logf("🛠️ Peer %s en mantenimiento, se pausan los broadcasts\n", peerID)
+	} else {
+		logf("✅ Peer %s salió de mantenimiento\n", peerID)
+	}
+	return true
+}
+
+// NotifyMaintenance informa a los peers activos que este nodo entró o salió de mantenimiento
+func (p2p *P2PNetwork) NotifyMaintenance(enabled bool) {
+	p2p.mutex.RLock()
+	defer p2p.mutex.RUnlock()
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"node_id": p2p.NodeID,
+		"enabled": enabled,
+	})
+	if err != nil {
+		return
+	}
+
+	for peerID, peer := range p2p.Peers {
+		if !peer.Active {
+			continue
+		}
+
+		go func(peerID string, peer *Peer) {
+			url := p2p.peerURL(peer, "/api/p2p/maintenance")
+			resp, err := p2p.peerClient(0).Post(url, "application/json", bytes.NewBuffer(payload))
+			if err != nil {
+				logf("❌ Error notificando mantenimiento a %s: %v\n", peerID, err)
+				return
+			}
+			resp.Body.Close()
+		}(peerID, peer)
+	}
+}
+
+// HandshakeInfo representa la identidad y capacidades que un nodo anuncia
+type HandshakeInfo struct {
+	NodeID      string           `json:"node_id"`
+	Address     string           `json:"address,omitempty"`
+	Port        string           `json:"port,omitempty"`
+	Height      int              `json:"height"`
+	GenesisHash string           `json:"genesis_hash"`
+	Features    ProtocolFeatures `json:"features"`
+	PublicKeys  []PublicKeyInfo  `json:"public_keys"`
+	KeysEndorsement *KeySetEndorsement `json:"keys_endorsement,omitempty"`
+	Libp2pAddrs []string         `json:"libp2p_addrs,omitempty"`
+}
+
+// LocalHandshake retorna la información de handshake de este nodo
+func (p2p *P2PNetwork) LocalHandshake() HandshakeInfo {
+	info := HandshakeInfo{
+		NodeID:      p2p.NodeID,
+		Address:     p2p.Address,
+		Port:        p2p.Port,
+		Height:      ByzantineReportedHeight(p2p.Blockchain.Len()),
+		GenesisHash: p2p.Blockchain.GenesisHash(),
+		Features:    p2p.Blockchain.Protocol.LocalFeatures(),
+	}
+	if p2p.Keys != nil {
+		keys, endorsement := p2p.Keys.EndorseKeys(p2p.NodeID)
+		info.PublicKeys = keys
+		info.KeysEndorsement = &endorsement
+	}
+	if p2p.libp2p != nil {
+		info.Libp2pAddrs = p2p.libp2p.addrs()
+	}
+	return info
+}
+
+// Handshake obtiene las capacidades del protocolo y las llaves de un peer y las negocia;
+// luego registra este nodo en el peer. Un peer de otra red (ver checkGenesis) se retira.
+func (p2p *P2PNetwork) Handshake(peerID string) error {
+	p2p.mutex.RLock()
+	peer, exists := p2p.Peers[peerID]
+	p2p.mutex.RUnlock()
+	if !exists {
+		return fmt.Errorf("peer %s no encontrado", peerID)
+	}
+
+	info, err := p2p.requestHandshake(peer)
+	if err != nil {
+		logf("❌ Error en handshake con %s: %v\n", peerID, err)
+		return err
+	}
+	if err := p2p.checkGenesis(info); err != nil {
+		p2p.dropIncompatiblePeer(peerID, err)
+		return err
+	}
+
+	p2p.mutex.Lock()
+	negotiation := p2p.applyHandshake(peer, info)
+	p2p.mutex.Unlock()
+
+	if !negotiation.Compatible {
+		logf("⚠️ Peer %s incompatible: %v\n", peerID, negotiation.IncompatibleWith)
+	} else {
+		logf("🤝 Handshake con %s completado (%d tipos compartidos)\n", peerID, len(negotiation.SharedKinds))
+	}
+
+	// Un registro fallido no invalida el handshake: el peer sigue siendo alcanzable desde aquí
+	if err := p2p.registerWithPeer(peer); err != nil {
+		logf("⚠️ No se pudo registrar este nodo en %s: %v\n", peerID, err)
+	}
+	return nil
+}
+
+// applyHandshake guarda en el peer el resultado del handshake; requiere el lock de escritura
+func (p2p *P2PNetwork) applyHandshake(peer *Peer, info *HandshakeInfo) FeatureNegotiation {
+	negotiation := p2p.Blockchain.Protocol.Negotiate(info.Features)
+	features := info.Features
+	peer.Features = &features
+	peer.Negotiation = &negotiation
+	peer.PublicKeys = info.PublicKeys
+	peer.KeysEndorsement = info.KeysEndorsement
+	peer.Libp2pAddrs = info.Libp2pAddrs
+	return negotiation
+}
+
+// requestHandshake solicita la información de handshake a un peer
+func (p2p *P2PNetwork) requestHandshake(peer *Peer) (*HandshakeInfo, error) {
+	url := p2p.peerURL(peer, "/api/p2p/handshake")
+
+	client := p2p.peerClient(5 * time.Second)
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer respondió con status %d", resp.StatusCode)
+	}
+
+	var info HandshakeInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// refreshSignerKeys repite el handshake con el peer que firmó el bloque si aún no
+// conocemos la llave con la que lo firmó; si el firmante no es un peer directo, pide sus
+// llaves al peer que retransmitió el bloque. Retorna true si obtuvo llaves nuevas.
+func (p2p *P2PNetwork) refreshSignerKeys(block Block, sender string) bool {
+	p2p.mutex.RLock()
+	keys := p2p.relayedKeys[block.SignerNodeID]
+	peer, exists := p2p.Peers[block.SignerNodeID]
+	if exists {
+		keys = peer.PublicKeys
+	}
+	knownKey := false
+	for _, key := range keys {
+		if key.KeyID == block.SignerKeyID {
+			knownKey = true
+			break
+		}
+	}
+	p2p.mutex.RUnlock()
+
+	if knownKey {
+		return false
+	}
+	if !exists {
+		return p2p.learnSignerKeys(block, sender)
+	}
+	return p2p.Handshake(block.SignerNodeID) == nil
+}
+
+// learnChainSigners obtiene las llaves de los firmantes de una cadena recibida que este nodo
+// aún no conoce, consultando al peer que la envió
+func (p2p *P2PNetwork) learnChainSigners(chain []Block, sender string) {
+	seen := make(map[string]bool)
+	for i, block := range chain {
+		if i == 0 || block.SignerNodeID == "" || block.SignerNodeID == p2p.NodeID {
+			continue
+		}
+		signer := block.SignerNodeID + "/" + block.SignerKeyID
+		if seen[signer] {
+			continue
+		}
+		seen[signer] = true
+		p2p.refreshSignerKeys(block, sender)
+	}
+}
+
+// ValidatorKeys retorna las llaves públicas conocidas de cada nodo validador, incluido este
+// y los que solo se conocen a través de otros peers
+func (p2p *P2PNetwork) ValidatorKeys() map[string][]PublicKeyInfo {
+	p2p.mutex.RLock()
+	defer p2p.mutex.RUnlock()
+
+	keys := make(map[string][]PublicKeyInfo)
+	for nodeID, relayed := range p2p.relayedKeys {
+		keys[nodeID] = relayed
+	}
+	if p2p.Keys != nil {
+		keys[p2p.NodeID] = p2p.Keys.PublicKeys()
+	}
+	for peerID, peer := range p2p.Peers {
+		if len(peer.PublicKeys) > 0 {
+			keys[peerID] = peer.PublicKeys
+		}
+	}
+	return keys
+}
+
+// ValidatorKeyEndorsements retorna los endosos de las llaves de ValidatorKeys que este nodo
+// puede respaldar: el propio y los que recibió de cada nodo o de quien retransmitió sus llaves
+func (p2p *P2PNetwork) ValidatorKeyEndorsements() map[string]KeySetEndorsement {
+	p2p.mutex.RLock()
+	defer p2p.mutex.RUnlock()
+
+	endorsements := make(map[string]KeySetEndorsement)
+	for nodeID, endorsement := range p2p.relayedEndorsements {
+		endorsements[nodeID] = endorsement
+	}
+	if p2p.Keys != nil {
+		_, endorsements[p2p.NodeID] = p2p.Keys.EndorseKeys(p2p.NodeID)
+	}
+	for peerID, peer := range p2p.Peers {
+		if peer.KeysEndorsement != nil {
+			endorsements[peerID] = *peer.KeysEndorsement
+		}
+	}
+	return endorsements
+}
+
+// acceptsKind indica si el peer puede procesar un tipo de bloque; los peers sin
+// handshake se asumen compatibles
+func (peer *Peer) acceptsKind(kind string) bool {
+	if peer.Negotiation != nil && !peer.Negotiation.Compatible {
+		return false
+	}
+	if peer.Features == nil || kind == "" {
+		return true
+	}
+	return peer.Features.SupportsKind(kind)
+}
@@ -0,0 +1,130 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Registro mutuo de peers: al completar el handshake con un peer, este nodo se registra en
+// él con su propia identidad, de modo que la conexión queda en ambos sentidos sin que un
+// administrador tenga que agregarla en cada nodo. Dos nodos con historia propia sobre
+// bloques génesis distintos pertenecen a redes diferentes y se niegan a emparejarse.
+
+// ErrGenesisMismatch indica que el peer construyó su cadena sobre otro bloque génesis
+var ErrGenesisMismatch = errors.New("el peer tiene un bloque génesis distinto")
+
+// GenesisHash retorna el hash del bloque génesis de la cadena local
+func (bc *Blockchain) GenesisHash() string {
+	blocks := bc.Blocks()
+	if len(blocks) == 0 {
+		return ""
+	}
+	return blocks[0].Hash
+}
+
+// checkGenesis verifica que el peer comparta el bloque génesis local. Cada nodo crea su
+// propio génesis al iniciar, así que uno que aún no tiene más bloques sí puede emparejarse:
+// adoptará la cadena de la red en la siguiente sincronización. Los nodos de versiones
+// anteriores no anuncian su génesis y se aceptan.
+func (p2p *P2PNetwork) checkGenesis(info *HandshakeInfo) error {
+	if info.GenesisHash == "" || info.GenesisHash == p2p.Blockchain.GenesisHash() {
+		return nil
+	}
+	if info.Height <= 1 || p2p.Blockchain.Len() <= 1 {
+		return nil
+	}
+	logf("🧬 %s tiene el génesis %s y este nodo %s\n", info.NodeID, info.GenesisHash, p2p.Blockchain.GenesisHash())
+	return ErrGenesisMismatch
+}
+
+// ConnectPeer agrega un peer y completa el handshake de inmediato, de modo que quien lo
+// agrega sabe si el peer quedó emparejado o lo rechazó por tener otra red
+func (p2p *P2PNetwork) ConnectPeer(peerID, address, port string) error {
+	if p2p.Isolated() {
+		return ErrNetworkIsolated
+	}
+	p2p.registerPeer(peerID, address, port)
+	return p2p.Handshake(peerID)
+}
+
+// RegisterPeer agrega como peer al nodo que se registró en este con su handshake. No se le
+// devuelve el registro: el peer ya tiene a este nodo, y la respuesta lleva el handshake local.
+func (p2p *P2PNetwork) RegisterPeer(info HandshakeInfo) (HandshakeInfo, error) {
+	if info.NodeID == "" || info.Address == "" || info.Port == "" || info.NodeID == p2p.NodeID {
+		return HandshakeInfo{}, errors.New("registro de peer incompleto o propio")
+	}
+	if p2p.Isolated() {
+		return HandshakeInfo{}, ErrNetworkIsolated
+	}
+	if p2p.isRemoved(info.NodeID) {
+		return HandshakeInfo{}, fmt.Errorf("peer %s retirado por un administrador", info.NodeID)
+	}
+	if p2p.Reputation.IsBanned(info.NodeID) {
+		return HandshakeInfo{}, fmt.Errorf("peer %s vetado", info.NodeID)
+	}
+	if err := p2p.checkGenesis(&info); err != nil {
+		return HandshakeInfo{}, err
+	}
+
+	p2p.mutex.RLock()
+	_, exists := p2p.Peers[info.NodeID]
+	count := len(p2p.Peers)
+	p2p.mutex.RUnlock()
+	if !exists && count >= MaxKnownPeers {
+		return HandshakeInfo{}, fmt.Errorf("se alcanzó el máximo de %d peers", MaxKnownPeers)
+	}
+
+	// Como en el descubrimiento, solo se registra si en la dirección anunciada responde el
+	// nodo que dice ser
+	nodeID, err := p2p.requestNodeID(info.Address, info.Port)
+	if err != nil {
+		return HandshakeInfo{}, err
+	}
+	if nodeID != info.NodeID {
+		return HandshakeInfo{}, fmt.Errorf("en %s:%s responde %s y no %s", info.Address, info.Port, nodeID, info.NodeID)
+	}
+
+	peer := p2p.registerPeer(info.NodeID, info.Address, info.Port)
+	p2p.mutex.Lock()
+	negotiation := p2p.applyHandshake(peer, &info)
+	p2p.mutex.Unlock()
+	logf("🤝 Peer %s se registró en este nodo (%d tipos compartidos)\n", info.NodeID, len(negotiation.SharedKinds))
+
+	return p2p.LocalHandshake(), nil
+}
+
+// dropIncompatiblePeer retira un peer de otra red sin marcarlo como retirado por un
+// administrador, para que pueda volver a agregarse si reinicia su cadena
+func (p2p *P2PNetwork) dropIncompatiblePeer(peerID string, err error) {
+	p2p.mutex.Lock()
+	delete(p2p.Peers, peerID)
+	p2p.mutex.Unlock()
+	logf("🚫 Peer %s rechazado: %v\n", peerID, err)
+}
+
+// registerWithPeer envía el handshake local al peer para que este nodo quede registrado en él
+func (p2p *P2PNetwork) registerWithPeer(peer *Peer) error {
+	payload, err := json.Marshal(p2p.LocalHandshake())
+	if err != nil {
+		return err
+	}
+
+	resp, err := p2p.peerClient(10*time.Second).Post(p2p.peerURL(peer, "/api/p2p/handshake"), "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var response struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&response)
+		return fmt.Errorf("peer respondió con status %d: %s", resp.StatusCode, response.Error)
+	}
+	return nil
+}
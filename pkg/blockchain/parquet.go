@@ -0,0 +1,392 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Escritor mínimo de archivos Parquet: un grupo de filas, una página por columna, sin
+// compresión y con codificación PLAIN. Basta para las exportaciones analíticas y lo leen
+// pandas, DuckDB, Spark y Arrow sin dependencias adicionales en el nodo.
+
+// Tipos de las columnas exportadas
+const (
+	ParquetString    = "string"
+	ParquetInt64     = "int64"
+	ParquetDouble    = "double"
+	ParquetBool      = "bool"
+	ParquetTimestamp = "timestamp" // milisegundos desde la época, UTC
+)
+
+// Constantes del formato (parquet.thrift)
+const (
+	parquetMagic = "PAR1"
+
+	parquetTypeBoolean   = 0
+	parquetTypeInt64     = 2
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+	parquetPageData      = 0
+	parquetUncompressed  = 0
+)
+
+// ParquetColumn es una columna de la exportación. Los valores nil son nulos y solo se
+// permiten en columnas opcionales.
+type ParquetColumn struct {
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	Optional bool          `json:"optional"`
+	values   []interface{} // string, int64, float64, bool o time.Time según el tipo
+}
+
+// append agrega el valor de la siguiente fila
+func (column *ParquetColumn) append(value interface{}) {
+	column.values = append(column.values, value)
+}
+
+// physicalType retorna el tipo físico y el tipo convertido de la columna (-1 si no tiene)
+func (column *ParquetColumn) physicalType() (int32, int32, error) {
+	switch column.Type {
+	case ParquetString:
+		return parquetTypeByteArray, parquetConvertedUTF8, nil
+	case ParquetInt64:
+		return parquetTypeInt64, -1, nil
+	case ParquetDouble:
+		return parquetTypeDouble, -1, nil
+	case ParquetBool:
+		return parquetTypeBoolean, -1, nil
+	case ParquetTimestamp:
+		return parquetTypeInt64, parquetConvertedTimestampMillis, nil
+	}
+	return 0, 0, fmt.Errorf("tipo de columna desconocido: %s", column.Type)
+}
+
+// WriteParquet escribe las columnas como un archivo Parquet. Todas las columnas deben
+// tener la misma cantidad de filas.
+func WriteParquet(w io.Writer, columns []*ParquetColumn) error {
+	if len(columns) == 0 {
+		return errors.New("la exportación no tiene columnas")
+	}
+	rows := len(columns[0].values)
+	for _, column := range columns {
+		if len(column.values) != rows {
+			return fmt.Errorf("la columna %s tiene %d filas, se esperaban %d", column.Name, len(column.values), rows)
+		}
+	}
+
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	chunks := make([][]byte, len(columns))
+	var totalSize int64
+	for i, column := range columns {
+		physical, _, err := column.physicalType()
+		if err != nil {
+			return err
+		}
+		page, err := column.encodePage()
+		if err != nil {
+			return err
+		}
+
+		var header thriftWriter
+		header.fieldI32(1, parquetPageData)
+		header.fieldI32(2, int32(len(page)))
+		header.fieldI32(3, int32(len(page)))
+		header.beginStruct(5)
+		header.fieldI32(1, int32(rows))
+		header.fieldI32(2, parquetEncodingPlain)
+		header.fieldI32(3, parquetEncodingRLE)
+		header.fieldI32(4, parquetEncodingRLE)
+		header.endStruct()
+		header.stop()
+
+		offset := int64(file.Len())
+		size := int64(header.buffer.Len() + len(page))
+		file.Write(header.buffer.Bytes())
+		file.Write(page)
+		totalSize += size
+
+		// ColumnChunk con sus ColumnMetaData
+		var chunk thriftWriter
+		chunk.fieldI64(2, offset)
+		chunk.beginStruct(3)
+		chunk.fieldI32(1, physical)
+		chunk.fieldListI32(2, []int32{parquetEncodingPlain, parquetEncodingRLE})
+		chunk.fieldListString(3, []string{column.Name})
+		chunk.fieldI32(4, parquetUncompressed)
+		chunk.fieldI64(5, int64(rows))
+		chunk.fieldI64(6, size)
+		chunk.fieldI64(7, size)
+		chunk.fieldI64(9, offset)
+		chunk.endStruct()
+		chunks[i] = chunk.buffer.Bytes()
+	}
+
+	// FileMetaData
+	var footer thriftWriter
+	footer.fieldI32(1, 1)
+	footer.beginList(2, thriftStruct, len(columns)+1)
+	footer.fieldBinary(4, "schema")
+	footer.fieldI32(5, int32(len(columns)))
+	footer.stop()
+	for _, column := range columns {
+		physical, converted, _ := column.physicalType()
+		repetition := int32(parquetRequired)
+		if column.Optional {
+			repetition = parquetOptional
+		}
+		footer.fieldI32(1, physical)
+		footer.fieldI32(3, repetition)
+		footer.fieldBinary(4, column.Name)
+		if converted >= 0 {
+			footer.fieldI32(6, converted)
+		}
+		footer.stop()
+	}
+	footer.fieldI64(3, int64(rows))
+	footer.beginList(4, thriftStruct, 1)
+	footer.beginList(1, thriftStruct, len(chunks))
+	for _, chunk := range chunks {
+		footer.buffer.Write(chunk)
+		footer.stop()
+	}
+	footer.fieldI64(2, totalSize)
+	footer.fieldI64(3, int64(rows))
+	footer.stop()
+	footer.fieldBinary(6, "secop-blockchain")
+	footer.stop()
+
+	file.Write(footer.buffer.Bytes())
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(footer.buffer.Len()))
+	file.Write(length[:])
+	file.WriteString(parquetMagic)
+
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// encodePage codifica los niveles de definición (si la columna es opcional) y los valores
+// no nulos de la columna
+func (column *ParquetColumn) encodePage() ([]byte, error) {
+	var page bytes.Buffer
+	if column.Optional {
+		levels := encodeDefinitionLevels(column.values)
+		var length [4]byte
+		binary.LittleEndian.PutUint32(length[:], uint32(len(levels)))
+		page.Write(length[:])
+		page.Write(levels)
+	}
+
+	var bits []bool
+	for _, value := range column.values {
+		if value == nil {
+			if !column.Optional {
+				return nil, fmt.Errorf("valor nulo en la columna obligatoria %s", column.Name)
+			}
+			continue
+		}
+		var buffer [8]byte
+		switch column.Type {
+		case ParquetString:
+			text, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("valor inválido en la columna %s: %v", column.Name, value)
+			}
+			binary.LittleEndian.PutUint32(buffer[:4], uint32(len(text)))
+			page.Write(buffer[:4])
+			page.WriteString(text)
+		case ParquetInt64:
+			integer, ok := value.(int64)
+			if !ok {
+				return nil, fmt.Errorf("valor inválido en la columna %s: %v", column.Name, value)
+			}
+			binary.LittleEndian.PutUint64(buffer[:], uint64(integer))
+			page.Write(buffer[:])
+		case ParquetTimestamp:
+			moment, ok := value.(time.Time)
+			if !ok {
+				return nil, fmt.Errorf("valor inválido en la columna %s: %v", column.Name, value)
+			}
+			binary.LittleEndian.PutUint64(buffer[:], uint64(moment.UnixNano()/int64(time.Millisecond)))
+			page.Write(buffer[:])
+		case ParquetDouble:
+			float, ok := value.(float64)
+			if !ok {
+				return nil, fmt.Errorf("valor inválido en la columna %s: %v", column.Name, value)
+			}
+			binary.LittleEndian.PutUint64(buffer[:], math.Float64bits(float))
+			page.Write(buffer[:])
+		case ParquetBool:
+			flag, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("valor inválido en la columna %s: %v", column.Name, value)
+			}
+			bits = append(bits, flag)
+		}
+	}
+
+	// Los booleanos PLAIN van empaquetados de a 8 por byte, el primero en el bit menos significativo
+	if column.Type == ParquetBool {
+		packed := make([]byte, (len(bits)+7)/8)
+		for i, flag := range bits {
+			if flag {
+				packed[i/8] |= 1 << uint(i%8)
+			}
+		}
+		page.Write(packed)
+	}
+	return page.Bytes(), nil
+}
+
+// encodeDefinitionLevels codifica con corridas RLE (ancho de 1 bit) si cada fila tiene valor
+func encodeDefinitionLevels(values []interface{}) []byte {
+	var levels bytes.Buffer
+	for start := 0; start < len(values); {
+		defined := values[start] != nil
+		end := start + 1
+		for end < len(values) && (values[end] != nil) == defined {
+			end++
+		}
+		writeUvarint(&levels, uint64(end-start)<<1)
+		if defined {
+			levels.WriteByte(1)
+		} else {
+			levels.WriteByte(0)
+		}
+		start = end
+	}
+	return levels.Bytes()
+}
+
+// Tipos del protocolo compacto de Thrift usados en los metadatos
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter escribe estructuras con el protocolo compacto de Thrift, llevando el último
+// campo de cada estructura anidada para codificar los identificadores como deltas
+type thriftWriter struct {
+	buffer       bytes.Buffer
+	last         int16
+	stack        []int16
+	pendingLists []int // elementos que faltan por cerrar en cada lista abierta
+}
+
+func (tw *thriftWriter) fieldHeader(id int16, kind byte) {
+	if delta := id - tw.last; delta > 0 && delta <= 15 {
+		tw.buffer.WriteByte(byte(delta)<<4 | kind)
+	} else {
+		tw.buffer.WriteByte(kind)
+		writeUvarint(&tw.buffer, zigzag(int64(id)))
+	}
+	tw.last = id
+}
+
+func (tw *thriftWriter) fieldI32(id int16, value int32) {
+	tw.fieldHeader(id, thriftI32)
+	writeUvarint(&tw.buffer, zigzag(int64(value)))
+}
+
+func (tw *thriftWriter) fieldI64(id int16, value int64) {
+	tw.fieldHeader(id, thriftI64)
+	writeUvarint(&tw.buffer, zigzag(value))
+}
+
+func (tw *thriftWriter) fieldBinary(id int16, value string) {
+	tw.fieldHeader(id, thriftBinary)
+	writeUvarint(&tw.buffer, uint64(len(value)))
+	tw.buffer.WriteString(value)
+}
+
+func (tw *thriftWriter) fieldListI32(id int16, values []int32) {
+	tw.listHeader(id, thriftI32, len(values))
+	for _, value := range values {
+		writeUvarint(&tw.buffer, zigzag(int64(value)))
+	}
+}
+
+func (tw *thriftWriter) fieldListString(id int16, values []string) {
+	tw.listHeader(id, thriftBinary, len(values))
+	for _, value := range values {
+		writeUvarint(&tw.buffer, uint64(len(value)))
+		tw.buffer.WriteString(value)
+	}
+}
+
+func (tw *thriftWriter) listHeader(id int16, kind byte, size int) {
+	tw.fieldHeader(id, thriftList)
+	if size < 15 {
+		tw.buffer.WriteByte(byte(size)<<4 | kind)
+	} else {
+		tw.buffer.WriteByte(0xf0 | kind)
+		writeUvarint(&tw.buffer, uint64(size))
+	}
+}
+
+// beginStruct abre un campo de tipo estructura
+func (tw *thriftWriter) beginStruct(id int16) {
+	tw.fieldHeader(id, thriftStruct)
+	tw.stack = append(tw.stack, tw.last)
+	tw.last = 0
+}
+
+// endStruct cierra la estructura abierta con beginStruct
+func (tw *thriftWriter) endStruct() {
+	tw.buffer.WriteByte(0)
+	tw.last = tw.stack[len(tw.stack)-1]
+	tw.stack = tw.stack[:len(tw.stack)-1]
+}
+
+// beginList abre una lista de estructuras; cada elemento se cierra con stop
+func (tw *thriftWriter) beginList(id int16, kind byte, size int) {
+	tw.listHeader(id, kind, size)
+	tw.stack = append(tw.stack, tw.last)
+	tw.last = 0
+	tw.pendingLists = append(tw.pendingLists, size)
+}
+
+// stop cierra una estructura: un elemento de la lista abierta o el mensaje completo
+func (tw *thriftWriter) stop() {
+	tw.buffer.WriteByte(0)
+	tw.last = 0
+	if len(tw.pendingLists) == 0 {
+		return
+	}
+	top := len(tw.pendingLists) - 1
+	tw.pendingLists[top]--
+	if tw.pendingLists[top] == 0 {
+		tw.pendingLists = tw.pendingLists[:top]
+		tw.last = tw.stack[len(tw.stack)-1]
+		tw.stack = tw.stack[:len(tw.stack)-1]
+	}
+}
+
+func zigzag(value int64) uint64 {
+	return uint64((value << 1) ^ (value >> 63))
+}
+
+func writeUvarint(buffer *bytes.Buffer, value uint64) {
+	var encoded [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(encoded[:], value)
+	buffer.Write(encoded[:n])
+}
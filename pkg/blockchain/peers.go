@@ -0,0 +1,281 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+
+	"github.com/google/uuid"
+)
+
+// Ciclo de vida de los peers: los que dejan de responder quedan inactivos y se reintentan
+// con espera exponencial, en vez de consultarlos en cada verificación de salud o darlos
+// por muertos; un administrador puede retirarlos o reactivarlos a mano. Los que siguen
+// inactivos más allá del periodo de retención (nodos dados de baja) se retiran solos y
+// queda registro de cada retiro.
+
+// Espera entre reintentos de un peer inactivo
+const (
+	PeerRetryInitialDelay = 15 * time.Second
+	PeerRetryMaxDelay     = 10 * time.Minute
+)
+
+// Tiempo que un peer puede seguir inactivo antes de retirarlo de la tabla de peers
+const DefaultPeerRetention = 7 * 24 * time.Hour
+
+// PeerRemoval registra el retiro automático de un peer que llevaba demasiado inactivo
+type PeerRemoval struct {
+	ID            string    `json:"id"`
+	PeerID        string    `json:"peer_id"`
+	Address       string    `json:"address"`
+	Port          string    `json:"port"`
+	LastSeen      time.Time `json:"last_seen"`
+	InactiveSince time.Time `json:"inactive_since"`
+	RetryAttempts int       `json:"retry_attempts"`
+	RemovedAt     time.Time `json:"removed_at"`
+}
+
+// Errores de ReactivatePeer que no provienen del sondeo al peer
+var (
+	ErrPeerNotFound      = errors.New("peer no encontrado")
+	ErrPeerAlreadyActive = errors.New("el peer ya está activo")
+)
+
+// peerRetryDelay retorna la espera antes del reintento número attempts
+func peerRetryDelay(attempts int) time.Duration {
+	delay := PeerRetryInitialDelay
+	for i := 0; i < attempts && delay < PeerRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > PeerRetryMaxDelay {
+		delay = PeerRetryMaxDelay
+	}
+	return delay
+}
+
+// deactivate marca el peer inactivo y programa su próximo reintento; cada falla seguida
+// duplica la espera. Requiere p2p.mutex.
+func (peer *Peer) deactivate(now time.Time) {
+	if peer.Active || peer.InactiveSince == nil {
+		peer.InactiveSince = &now
+		peer.RetryAttempts = 0
+	} else {
+		peer.RetryAttempts++
+	}
+	peer.Active = false
+	next := now.Add(peerRetryDelay(peer.RetryAttempts))
+	peer.NextRetry = &next
+}
+
+// activate marca el peer activo y olvida sus reintentos. Requiere p2p.mutex.
+func (peer *Peer) activate(now time.Time) {
+	peer.Active = true
+	peer.LastSeen = now
+	peer.InactiveSince = nil
+	peer.RetryAttempts = 0
+	peer.NextRetry = nil
+}
+
+// RunPeerRetry revisa cada every los peers inactivos y reintenta los que ya cumplieron
+// su espera
+func (p2p *P2PNetwork) RunPeerRetry(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p2p.retryInactivePeers()
+	}
+}
+
+// retryInactivePeers sondea los peers inactivos cuya espera terminó
+func (p2p *P2PNetwork) retryInactivePeers() {
+	now := time.Now()
+	var due []Peer
+	p2p.mutex.RLock()
+	for _, peer := range p2p.Peers {
+		if !peer.Active && peer.NextRetry != nil && !now.Before(*peer.NextRetry) {
+			due = append(due, *peer)
+		}
+	}
+	p2p.mutex.RUnlock()
+
+	for _, peer := range due {
+		if err := p2p.probePeer(peer.ID); err != nil {
+			logf("💤 Peer %s sigue sin responder (intento %d): %v\n", peer.ID, peer.RetryAttempts+1, err)
+		}
+	}
+}
+
+// probePeer consulta al peer y lo reactiva si responde con su identidad; si no, programa
+// el siguiente reintento
+func (p2p *P2PNetwork) probePeer(peerID string) error {
+	p2p.mutex.RLock()
+	peer, exists := p2p.Peers[peerID]
+	var address, port string
+	if exists {
+		address, port = peer.Address, peer.Port
+	}
+	p2p.mutex.RUnlock()
+	if !exists {
+		return fmt.Errorf("peer %s no encontrado", peerID)
+	}
+
+	// Una conexión libp2p verificada basta para saber que el peer sigue vivo
+	viaLibp2p := p2p.libp2p != nil && p2p.libp2p.connectedTo(peerID)
+	var err error
+	if !viaLibp2p {
+		var nodeID string
+		nodeID, err = p2p.requestNodeID(address, port)
+		if err == nil && nodeID != peerID {
+			err = fmt.Errorf("en %s:%s responde %s y no %s", address, port, nodeID, peerID)
+		}
+	}
+
+	p2p.mutex.Lock()
+	peer, exists = p2p.Peers[peerID]
+	if exists {
+		if err != nil {
+			peer.deactivate(time.Now())
+		} else {
+			peer.activate(time.Now())
+		}
+	}
+	p2p.mutex.Unlock()
+	if !exists {
+		return fmt.Errorf("peer %s retirado durante el reintento", peerID)
+	}
+	if err != nil {
+		return err
+	}
+
+	logf("🔁 Peer %s reactivado\n", peerID)
+	if !viaLibp2p {
+		go p2p.Handshake(peerID)
+	}
+	return nil
+}
+
+// ReactivatePeer sondea de inmediato un peer inactivo, sin esperar su próximo reintento
+func (p2p *P2PNetwork) ReactivatePeer(peerID string) error {
+	p2p.mutex.Lock()
+	peer, exists := p2p.Peers[peerID]
+	active := exists && peer.Active
+	if exists && !active {
+		// Un reintento manual reinicia la espera exponencial
+		peer.InactiveSince = nil
+	}
+	p2p.mutex.Unlock()
+
+	if !exists {
+		return ErrPeerNotFound
+	}
+	if active {
+		return ErrPeerAlreadyActive
+	}
+	return p2p.probePeer(peerID)
+}
+
+// RemovePeer retira un peer: se cierra su canal y deja de descubrirse por intercambio de
+// peers hasta que un administrador lo vuelva a agregar
+func (p2p *P2PNetwork) RemovePeer(peerID string) bool {
+	p2p.mutex.Lock()
+	_, exists := p2p.Peers[peerID]
+	if exists {
+		delete(p2p.Peers, peerID)
+		p2p.removed[peerID] = true
+	}
+	p2p.mutex.Unlock()
+	if !exists {
+		return false
+	}
+
+	p2p.closePeerConn(peerID)
+	logf("✂️ Peer %s retirado\n", peerID)
+	return true
+}
+
+// PruneDeadPeers retira los peers que llevan inactivos más que PeerRetention y registra
+// cada retiro; como los retirados a mano, no se redescubren por intercambio de peers.
+// Retorna los retiros hechos.
+func (p2p *P2PNetwork) PruneDeadPeers() []PeerRemoval {
+	if p2p.PeerRetention <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var removals []PeerRemoval
+	p2p.mutex.Lock()
+	for peerID, peer := range p2p.Peers {
+		if peer.Active || peer.InactiveSince == nil || now.Sub(*peer.InactiveSince) < p2p.PeerRetention {
+			continue
+		}
+		removals = append(removals, PeerRemoval{
+			ID:            uuid.New().String(),
+			PeerID:        peerID,
+			Address:       peer.Address,
+			Port:          peer.Port,
+			LastSeen:      peer.LastSeen,
+			InactiveSince: *peer.InactiveSince,
+			RetryAttempts: peer.RetryAttempts,
+			RemovedAt:     now,
+		})
+		delete(p2p.Peers, peerID)
+		p2p.removed[peerID] = true
+	}
+	p2p.removals = append(p2p.removals, removals...)
+	p2p.mutex.Unlock()
+
+	for _, removal := range removals {
+		p2p.Blockchain.saveState(storage.BucketPeerRemovals, removal.ID, removal)
+		p2p.closePeerConn(removal.PeerID)
+		logf("🪦 Peer %s retirado tras %v inactivo\n", removal.PeerID, now.Sub(removal.InactiveSince).Round(time.Minute))
+	}
+	return removals
+}
+
+// PeerRemovals retorna los retiros automáticos registrados, del más antiguo al más reciente
+func (p2p *P2PNetwork) PeerRemovals() []PeerRemoval {
+	p2p.mutex.RLock()
+	defer p2p.mutex.RUnlock()
+	return append([]PeerRemoval{}, p2p.removals...)
+}
+
+// loadPeerRemovals carga el registro de retiros automáticos guardado
+func (p2p *P2PNetwork) loadPeerRemovals() {
+	p2p.Blockchain.store.ForEach(storage.BucketPeerRemovals, func(key string, value []byte) error {
+		var removal PeerRemoval
+		if err := json.Unmarshal(value, &removal); err == nil {
+			p2p.removals = append(p2p.removals, removal)
+		}
+		return nil
+	})
+	sort.Slice(p2p.removals, func(i, j int) bool { return p2p.removals[i].RemovedAt.Before(p2p.removals[j].RemovedAt) })
+}
+
+// closePeerConn cierra el canal WebSocket y las conexiones libp2p de un peer retirado y
+// olvida su espera
+func (p2p *P2PNetwork) closePeerConn(peerID string) {
+	if p2p.libp2p != nil {
+		p2p.libp2p.disconnect(peerID)
+	}
+	if p2p.ws != nil {
+		p2p.ws.mutex.Lock()
+		pc := p2p.ws.conns[peerID]
+		delete(p2p.ws.backoff, peerID)
+		p2p.ws.mutex.Unlock()
+		if pc != nil {
+			pc.close()
+		}
+	}
+}
+
+// isRemoved indica si un administrador retiró el peer
+func (p2p *P2PNetwork) isRemoved(peerID string) bool {
+	p2p.mutex.RLock()
+	defer p2p.mutex.RUnlock()
+	return p2p.removed[peerID]
+}
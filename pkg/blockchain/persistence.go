@@ -0,0 +1,273 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// loadFromStorage reconstruye la cadena y el estado desde el almacenamiento
+func (bc *Blockchain) loadFromStorage() error {
+	var chain []*Block
+	err := bc.store.Blocks(func(height int, data []byte) error {
+		var block Block
+		if err := json.Unmarshal(data, &block); err != nil {
+			return fmt.Errorf("bloque %d corrupto: %v", height, err)
+		}
+		chain = append(chain, &block)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+
+	if err := newIntegrityVerifier().Verify(chain); err != nil {
+		return fmt.Errorf("la cadena almacenada no es válida: %v", err)
+	}
+	bc.setChain(chain)
+
+	contracts := make(map[string]*Contract)
+	err = bc.store.ForEach(storage.BucketContracts, func(key string, value []byte) error {
+		var contract Contract
+		if err := json.Unmarshal(value, &contract); err != nil {
+			return fmt.Errorf("contrato %s corrupto: %v", key, err)
+		}
+		contracts[key] = &contract
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	bc.setContracts(contracts)
+
+	suppliers := make(map[string]*Supplier)
+	err = bc.store.ForEach(storage.BucketSuppliers, func(key string, value []byte) error {
+		var supplier Supplier
+		if err := json.Unmarshal(value, &supplier); err != nil {
+			return fmt.Errorf("proveedor %s corrupto: %v", key, err)
+		}
+		suppliers[key] = &supplier
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	systemKeys := make(map[string]*EntitySystemKey)
+	err = bc.store.ForEach(storage.BucketSystemKeys, func(key string, value []byte) error {
+		var systemKey EntitySystemKey
+		if err := json.Unmarshal(value, &systemKey); err != nil {
+			return fmt.Errorf("llave de sistema %s corrupta: %v", key, err)
+		}
+		systemKeys[key] = &systemKey
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	validatorKeys := make(map[string]*ValidatorKey)
+	err = bc.store.ForEach(storage.BucketValidatorKeys, func(key string, value []byte) error {
+		var validatorKey ValidatorKey
+		if err := json.Unmarshal(value, &validatorKey); err != nil {
+			return fmt.Errorf("llave de validador %s corrupta: %v", key, err)
+		}
+		validatorKeys[key] = &validatorKey
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	bc.setRegistryMaps(suppliers, systemKeys, validatorKeys)
+
+	bc.rebuildSequences()
+	bc.rebuildSignatures()
+
+	logf("💾 Cadena restaurada desde almacenamiento: %d bloques, %d contratos\n", bc.Len(), bc.ContractCount())
+	return nil
+}
+
+// persistBlock guarda un bloque en el almacenamiento antes de agregarlo a la cadena
+func (bc *Blockchain) persistBlock(block *Block) error {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	return bc.store.AppendBlock(block.Index, data)
+}
+
+// stateChange representa un valor de estado serializado que un bloque modifica
+type stateChange struct {
+	Bucket string          `json:"bucket"`
+	Key    string          `json:"key"`
+	Value  json.RawMessage `json:"value"`
+}
+
+// stateChanges serializa el estado afectado por las transacciones de un bloque (contrato,
+// proveedor, llave de sistema o de validador) y los mensajes del outbox para sus efectos
+// secundarios. El de un bloque recibido se toma del estado derivado de sus transacciones.
+func (bc *Blockchain) stateChanges(block *Block, derived *stateReplay) []stateChange {
+	var changes []stateChange
+	add := func(bucket string, key string, value interface{}) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			logf("❌ Error serializando %s/%s: %v\n", bucket, key, err)
+			return
+		}
+		changes = append(changes, stateChange{Bucket: bucket, Key: key, Value: data})
+	}
+
+	contract, supplier, systemKey, validatorKey := bc.pendingContract, bc.supplier, bc.systemKey, bc.validatorKey
+	if derived != nil {
+		contract = func(id string) (*Contract, bool) {
+			value, exists := derived.contracts[id]
+			return value, exists
+		}
+		supplier = func(nit string) (*Supplier, bool) {
+			value, exists := derived.suppliers[nit]
+			return value, exists
+		}
+		systemKey = func(id string) (*EntitySystemKey, bool) {
+			value, exists := derived.systemKeys[id]
+			return value, exists
+		}
+		validatorKey = func(id string) (*ValidatorKey, bool) {
+			value, exists := derived.validatorKeys[id]
+			return value, exists
+		}
+	}
+
+	for _, data := range eventData(block.Data) {
+		if contractID, ok := data["contract_id"].(string); ok {
+			if value, exists := contract(contractID); exists {
+				add(storage.BucketContracts, contractID, value)
+			}
+		}
+		if nit, ok := data["nit"].(string); ok {
+			if value, exists := supplier(nit); exists {
+				add(storage.BucketSuppliers, nit, value)
+			}
+		}
+		if systemID, ok := data["system_id"].(string); ok {
+			entityCode, _ := data["entity_code"].(string)
+			id := systemKeyID(entityCode, systemID)
+			if value, exists := systemKey(id); exists {
+				add(storage.BucketSystemKeys, id, value)
+			}
+		}
+		if keyID, ok := data["validator_key_id"].(string); ok {
+			if value, exists := validatorKey(keyID); exists {
+				add(storage.BucketValidatorKeys, keyID, value)
+			}
+		}
+	}
+	for _, message := range bc.Outbox.messagesFor(block) {
+		add(storage.BucketOutbox, message.ID, message)
+	}
+	return changes
+}
+
+// applyStateChanges guarda el estado de un bloque y actualiza el store de consultas de contratos
+func (bc *Blockchain) applyStateChanges(changes []stateChange) {
+	for _, change := range changes {
+		if change.Bucket == storage.BucketOutbox && bc.Outbox.isDelivered(change.Key) {
+			continue
+		}
+		if err := bc.store.Put(change.Bucket, change.Key, change.Value); err != nil {
+			logf("❌ Error guardando %s/%s: %v\n", change.Bucket, change.Key, err)
+		}
+		if change.Bucket == storage.BucketOutbox {
+			bc.Outbox.track(change.Value)
+		}
+		if change.Bucket == storage.BucketContracts {
+			if contract, exists := bc.Contract(change.Key); exists {
+				bc.SearchIndex.Update(contract)
+				if err := bc.contractStore.Save(contract); err != nil {
+					bc.Projections.failed(ProjectionContractStore, fmt.Errorf("error indexando contrato %s: %v", contract.ID, err))
+				}
+			}
+		}
+	}
+}
+
+// saveContract pone en vigencia el contrato (la copia modificada bajo su candado, ver
+// contractlocks.go), lo guarda y lo actualiza en el store de consultas. La copia se tomó
+// antes del bloque que registra el cambio, así que lleva la secuencia que este le asignó.
+func (bc *Blockchain) saveContract(contract *Contract) {
+	if sequence := bc.lastSequence(contract.ID); sequence > contract.Sequence {
+		contract.Sequence = sequence
+	}
+	bc.putContract(contract)
+	bc.storeContract(contract)
+}
+
+// storeContract guarda el contrato y lo actualiza en el store de consultas
+func (bc *Blockchain) storeContract(contract *Contract) {
+	bc.saveState(storage.BucketContracts, contract.ID, contract)
+	bc.SearchIndex.Update(contract)
+	if err := bc.contractStore.Save(contract); err != nil {
+		bc.Projections.failed(ProjectionContractStore, fmt.Errorf("error indexando contrato %s: %v", contract.ID, err))
+	}
+}
+
+// saveState serializa y guarda un valor de estado; los errores se registran pero no
+// detienen la operación porque la cadena es la fuente de verdad
+func (bc *Blockchain) saveState(bucket string, key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = bc.store.Put(bucket, key, data)
+	}
+	if err != nil {
+		logf("❌ Error guardando %s/%s: %v\n", bucket, key, err)
+	}
+}
+
+// deleteState elimina un valor de estado; los errores se registran igual que en saveState
+func (bc *Blockchain) deleteState(bucket string, key string) {
+	if err := bc.store.Delete(bucket, key); err != nil {
+		logf("❌ Error eliminando %s/%s: %v\n", bucket, key, err)
+	}
+}
+
+// ReplaceChain reemplaza la cadena completa (p. ej. al adoptar la de un peer) y la persiste
+func (bc *Blockchain) ReplaceChain(chain []*Block) error {
+	bc.writeMutex.Lock()
+	defer bc.writeMutex.Unlock()
+
+	encoded := make([][]byte, len(chain))
+	for i, block := range chain {
+		data, err := json.Marshal(block)
+		if err != nil {
+			return err
+		}
+		encoded[i] = data
+	}
+	if err := bc.store.ReplaceChain(encoded); err != nil {
+		return fmt.Errorf("error guardando cadena: %v", err)
+	}
+
+	bc.setChain(chain)
+	bc.rebuildSequences()
+	bc.rebuildSignatures()
+	bc.resetArchive()
+	bc.rebuildAuthorities()
+	bc.rebuildCheckpoints()
+	bc.Finality.retain(chain)
+	bc.tip.notify()
+	return nil
+}
+
+// Close cierra el almacenamiento de la blockchain, el WAL y el store de contratos
+func (bc *Blockchain) Close() error {
+	if err := bc.wal.Close(); err != nil {
+		return err
+	}
+	if err := bc.contractStore.Close(); err != nil {
+		return err
+	}
+	return bc.store.Close()
+}
@@ -0,0 +1,152 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Intercambio de peers (PEX): cada nodo pregunta a sus peers qué otros nodos conocen y
+// agrega los que no tenía, de modo que basta configurar un nodo de arranque en vez de la
+// malla completa. Al preguntar, el nodo se anuncia para que el otro también lo aprenda.
+
+// MaxKnownPeers limita los peers que un nodo agrega por descubrimiento
+const MaxKnownPeers = 64
+
+// KnownPeer es un nodo que este nodo conoce, tal como se comparte con los demás
+type KnownPeer struct {
+	ID       string    `json:"id"`
+	Address  string    `json:"address"`
+	Port     string    `json:"port"`
+	Active   bool      `json:"active"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// KnownPeers lista los nodos que este nodo conoce, incluido él mismo
+func (p2p *P2PNetwork) KnownPeers() []KnownPeer {
+	p2p.mutex.RLock()
+	defer p2p.mutex.RUnlock()
+
+	known := []KnownPeer{{ID: p2p.NodeID, Address: p2p.Address, Port: p2p.Port, Active: true, LastSeen: time.Now()}}
+	for _, peer := range p2p.Peers {
+		known = append(known, KnownPeer{
+			ID:       peer.ID,
+			Address:  peer.Address,
+			Port:     peer.Port,
+			Active:   peer.Active,
+			LastSeen: peer.LastSeen,
+		})
+	}
+	return known
+}
+
+// DiscoverPeers pregunta a los peers activos por los nodos que conocen y agrega los
+// nuevos que respondan con la identidad anunciada. Retorna cuántos agregó.
+func (p2p *P2PNetwork) DiscoverPeers() int {
+	added := 0
+	for _, peer := range p2p.GetActivePeers() {
+		known, err := p2p.requestKnownPeers(peer)
+		if err != nil {
+			logf("❌ Error obteniendo peers conocidos de %s: %v\n", peer.ID, err)
+			continue
+		}
+		for _, candidate := range known {
+			if !candidate.Active {
+				continue
+			}
+			if err := p2p.DiscoverPeer(candidate.ID, candidate.Address, candidate.Port); err == nil {
+				added++
+			}
+		}
+	}
+	if added > 0 {
+		logf("🧭 %d peers nuevos descubiertos\n", added)
+	}
+	return added
+}
+
+// DiscoverPeer agrega un nodo descubierto si aún no es peer y responde en la dirección
+// anunciada con el mismo ID; retorna error si no se agregó
+func (p2p *P2PNetwork) DiscoverPeer(peerID, address, port string) error {
+	if peerID == "" || address == "" || port == "" || peerID == p2p.NodeID {
+		return fmt.Errorf("peer anunciado incompleto o propio")
+	}
+	if p2p.Isolated() {
+		return ErrNetworkIsolated
+	}
+	if p2p.isRemoved(peerID) {
+		return fmt.Errorf("peer %s retirado por un administrador", peerID)
+	}
+	p2p.mutex.RLock()
+	_, exists := p2p.Peers[peerID]
+	count := len(p2p.Peers)
+	p2p.mutex.RUnlock()
+	if exists {
+		return fmt.Errorf("peer %s ya conocido", peerID)
+	}
+	if count >= MaxKnownPeers {
+		return fmt.Errorf("se alcanzó el máximo de %d peers", MaxKnownPeers)
+	}
+
+	// Un peer anunciado solo se agrega si en su dirección responde el nodo que dice ser
+	nodeID, err := p2p.requestNodeID(address, port)
+	if err != nil {
+		return err
+	}
+	if nodeID != peerID {
+		return fmt.Errorf("en %s:%s responde %s y no %s", address, port, nodeID, peerID)
+	}
+
+	p2p.AddPeer(peerID, address, port)
+	logf("🧭 Peer %s descubierto (%s:%s)\n", peerID, address, port)
+	return nil
+}
+
+// requestKnownPeers solicita a un peer los nodos que conoce, anunciando este nodo
+func (p2p *P2PNetwork) requestKnownPeers(peer *Peer) ([]KnownPeer, error) {
+	query := url.Values{}
+	query.Set("node_id", p2p.NodeID)
+	query.Set("address", p2p.Address)
+	query.Set("port", p2p.Port)
+
+	resp, err := p2p.peerClient(5 * time.Second).Get(p2p.peerURL(peer, "/api/p2p/known-peers?"+query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer respondió con status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Peers []KnownPeer `json:"peers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response.Peers, nil
+}
+
+// requestNodeID consulta el ID del nodo que atiende en la dirección dada
+func (p2p *P2PNetwork) requestNodeID(address, port string) (string, error) {
+	resp, err := p2p.peerClient(5 * time.Second).Get(p2p.peerURL(&Peer{Address: address, Port: port}, "/api/health"))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("nodo respondió con status %d", resp.StatusCode)
+	}
+
+	var health struct {
+		NodeID string `json:"node_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return "", err
+	}
+	return health.NodeID, nil
+}
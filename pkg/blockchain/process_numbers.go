@@ -0,0 +1,403 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// Tipos de las alertas de consecutivos de procesos
+const (
+	// AlertProcessNumberGap se dispara cuando falta un consecutivo en la serie de una entidad
+	AlertProcessNumberGap = "PROCESS_NUMBER_GAP"
+	// AlertProcessNumberReuse se dispara cuando dos contratos usan el mismo número de proceso
+	AlertProcessNumberReuse = "PROCESS_NUMBER_REUSE"
+)
+
+// Máximo de informes de consecutivos que se conservan en memoria
+const maxProcessNumberReports = 30
+
+// processNumberPattern toma el último grupo de dígitos del número de proceso como
+// consecutivo: "SA-MC-2026-014" es el consecutivo 14 de la serie "SA-MC-2026-#"
+var processNumberPattern = regexp.MustCompile(`(\d+)\D*$`)
+
+// ProcessNumberGap es un rango de consecutivos que falta en la serie de una entidad
+type ProcessNumberGap struct {
+	EntityCode string `json:"entity_code"`
+	Series     string `json:"series"`
+	From       int    `json:"from"`
+	To         int    `json:"to"`
+	Missing    int    `json:"missing"`
+	// NextContract es el contrato con el consecutivo siguiente al rango
+	NextContract string    `json:"next_contract"`
+	NextSeenAt   time.Time `json:"next_seen_at"`
+}
+
+// ProcessNumberReuse es un número de proceso usado por más de un contrato
+type ProcessNumberReuse struct {
+	EntityCode    string   `json:"entity_code"`
+	Series        string   `json:"series"`
+	Number        int      `json:"number"`
+	ProcessNumber string   `json:"process_number"`
+	ContractIDs   []string `json:"contract_ids"`
+}
+
+// ProcessNumberReport es el resultado de una revisión de los consecutivos de todas las entidades
+type ProcessNumberReport struct {
+	GeneratedAt time.Time            `json:"generated_at"`
+	Height      int                  `json:"height"`
+	Series      int                  `json:"series"`
+	Gaps        []ProcessNumberGap   `json:"gaps"`
+	Reuses      []ProcessNumberReuse `json:"reuses"`
+	Alerted     int                  `json:"alerted"` // Hallazgos nuevos alertados al DNP
+}
+
+// processNumberUse es un contrato registrado con un consecutivo de la serie
+type processNumberUse struct {
+	contractID    string
+	processNumber string
+	seenAt        time.Time
+}
+
+// numberSeries agrupa los consecutivos de una serie de una entidad
+type numberSeries struct {
+	entityCode string
+	series     string
+	numbers    map[int][]processNumberUse
+}
+
+// ProcessNumberAuditor vigila los consecutivos de los números de proceso de cada entidad.
+// Un consecutivo que falta o que se repite puede indicar contratación por fuera de la
+// cadena; los hallazgos se alertan al DNP a través del gestor de alertas.
+type ProcessNumberAuditor struct {
+	blockchain *Blockchain
+	alerts     *AlertManager
+	grace      time.Duration
+	series     map[string]*numberSeries
+	reported   map[string]bool
+	reports    []ProcessNumberReport
+	mutex      sync.Mutex
+}
+
+// NewProcessNumberAuditor crea el auditor de consecutivos. Un hueco se alerta solo cuando
+// el consecutivo siguiente lleva más de grace en la cadena, para dar tiempo a que lleguen
+// los procesos radicados fuera de orden.
+func NewProcessNumberAuditor(bc *Blockchain, alerts *AlertManager, grace time.Duration) *ProcessNumberAuditor {
+	pa := &ProcessNumberAuditor{
+		blockchain: bc,
+		alerts:     alerts,
+		grace:      grace,
+		series:     make(map[string]*numberSeries),
+		reported:   make(map[string]bool),
+	}
+
+	bc.store.ForEach(storage.BucketProcessNumberAudit, func(key string, value []byte) error {
+		switch key {
+		case "reported":
+			var keys []string
+			if err := json.Unmarshal(value, &keys); err == nil {
+				for _, key := range keys {
+					pa.reported[key] = true
+				}
+			}
+		case "latest":
+			var report ProcessNumberReport
+			if err := json.Unmarshal(value, &report); err == nil {
+				pa.reports = append(pa.reports, report)
+			}
+		}
+		return nil
+	})
+
+	if err := pa.rebuild(); err != nil {
+		logf("❌ Error leyendo números de proceso: %v\n", err)
+	}
+	bc.Projections.Subscribe(ProjectionProcessNumbers, pa.observe)
+	return pa
+}
+
+// parseProcessNumber separa el número de proceso en su serie y su consecutivo
+func parseProcessNumber(processNumber string) (string, int, bool) {
+	normalized := strings.ToUpper(strings.TrimSpace(processNumber))
+	match := processNumberPattern.FindStringSubmatchIndex(normalized)
+	if match == nil {
+		return "", 0, false
+	}
+	number, err := strconv.Atoi(normalized[match[2]:match[3]])
+	if err != nil {
+		return "", 0, false
+	}
+	return normalized[:match[2]] + "#" + normalized[match[3]:], number, true
+}
+
+// record registra el número de proceso de un contrato y retorna los contratos que ya lo
+// usaban en la serie
+func (pa *ProcessNumberAuditor) record(entityCode string, processNumber string, contractID string, seenAt time.Time) []processNumberUse {
+	series, number, ok := parseProcessNumber(processNumber)
+	if !ok || entityCode == "" {
+		return nil
+	}
+
+	key := entityCode + "|" + series
+	numbers, exists := pa.series[key]
+	if !exists {
+		numbers = &numberSeries{entityCode: entityCode, series: series, numbers: make(map[int][]processNumberUse)}
+		pa.series[key] = numbers
+	}
+
+	previous := numbers.numbers[number]
+	for _, use := range previous {
+		if use.contractID == contractID {
+			return nil
+		}
+	}
+	numbers.numbers[number] = append(previous, processNumberUse{contractID: contractID, processNumber: processNumber, seenAt: seenAt})
+	return previous
+}
+
+// processNumberFields extrae entidad, número de proceso y contrato de un evento de creación
+func processNumberFields(event ChainEvent) (string, string, string) {
+	payload := eventPayload(event)
+	entityCode, _ := payload["entity_code"].(string)
+	processNumber, _ := payload["process_number"].(string)
+	contractID := event.ContractID
+	if contractID == "" {
+		contractID, _ = payload["contract_id"].(string)
+	}
+	return entityCode, processNumber, contractID
+}
+
+// rebuild recalcula las series desde la cadena completa
+func (pa *ProcessNumberAuditor) rebuild() error {
+	chain, err := pa.blockchain.FullChain()
+	if err != nil {
+		return err
+	}
+
+	pa.mutex.Lock()
+	defer pa.mutex.Unlock()
+
+	pa.series = make(map[string]*numberSeries)
+	for _, block := range chain {
+		for _, event := range block.Events() {
+			if event.Type != "CONTRACT_CREATION" {
+				continue
+			}
+			entityCode, processNumber, contractID := processNumberFields(event)
+			if processNumber != "" {
+				pa.record(entityCode, processNumber, contractID, event.Timestamp)
+			}
+		}
+	}
+	return nil
+}
+
+// observe registra cada contrato nuevo y alerta de inmediato si repite un número de proceso
+func (pa *ProcessNumberAuditor) observe(event ChainEvent) {
+	if event.Type != "CONTRACT_CREATION" {
+		return
+	}
+	entityCode, processNumber, contractID := processNumberFields(event)
+	if processNumber == "" {
+		return
+	}
+
+	pa.mutex.Lock()
+	previous := pa.record(entityCode, processNumber, contractID, event.Timestamp)
+	if len(previous) == 0 {
+		pa.mutex.Unlock()
+		return
+	}
+	series, number, _ := parseProcessNumber(processNumber)
+	reuse := ProcessNumberReuse{EntityCode: entityCode, Series: series, Number: number, ProcessNumber: previous[0].processNumber}
+	for _, use := range previous {
+		reuse.ContractIDs = append(reuse.ContractIDs, use.contractID)
+	}
+	reuse.ContractIDs = append(reuse.ContractIDs, contractID)
+	alert := pa.markReuse(reuse)
+	pa.mutex.Unlock()
+
+	if alert != nil {
+		alert.BlockHash = event.BlockHash
+		alert.BlockHeight = event.Height
+		pa.alerts.Raise(*alert)
+	}
+}
+
+// Audit revisa los consecutivos de todas las series, alerta al DNP los hallazgos nuevos y
+// guarda el informe
+func (pa *ProcessNumberAuditor) Audit() (*ProcessNumberReport, error) {
+	// La cadena pudo reemplazarse desde la última revisión
+	if err := pa.rebuild(); err != nil {
+		return nil, err
+	}
+
+	pa.mutex.Lock()
+	report := ProcessNumberReport{
+		GeneratedAt: time.Now(),
+		Height:      pa.blockchain.Len() - 1,
+		Series:      len(pa.series),
+		Gaps:        []ProcessNumberGap{},
+		Reuses:      []ProcessNumberReuse{},
+	}
+	for _, series := range pa.series {
+		gaps, reuses := series.findings()
+		report.Gaps = append(report.Gaps, gaps...)
+		report.Reuses = append(report.Reuses, reuses...)
+	}
+	sort.Slice(report.Gaps, func(i, j int) bool {
+		a, b := report.Gaps[i], report.Gaps[j]
+		if a.EntityCode+"|"+a.Series != b.EntityCode+"|"+b.Series {
+			return a.EntityCode+"|"+a.Series < b.EntityCode+"|"+b.Series
+		}
+		return a.From < b.From
+	})
+	sort.Slice(report.Reuses, func(i, j int) bool {
+		a, b := report.Reuses[i], report.Reuses[j]
+		if a.EntityCode+"|"+a.Series != b.EntityCode+"|"+b.Series {
+			return a.EntityCode+"|"+a.Series < b.EntityCode+"|"+b.Series
+		}
+		return a.Number < b.Number
+	})
+
+	var raised []Alert
+	for _, gap := range report.Gaps {
+		if time.Since(gap.NextSeenAt) < pa.grace {
+			continue
+		}
+		if alert := pa.markGap(gap); alert != nil {
+			raised = append(raised, *alert)
+		}
+	}
+	for _, reuse := range report.Reuses {
+		if alert := pa.markReuse(reuse); alert != nil {
+			raised = append(raised, *alert)
+		}
+	}
+	report.Alerted = len(raised)
+
+	pa.reports = append(pa.reports, report)
+	if len(pa.reports) > maxProcessNumberReports {
+		pa.reports = pa.reports[len(pa.reports)-maxProcessNumberReports:]
+	}
+	reported := make([]string, 0, len(pa.reported))
+	for key := range pa.reported {
+		reported = append(reported, key)
+	}
+	sort.Strings(reported)
+	pa.mutex.Unlock()
+
+	pa.blockchain.saveState(storage.BucketProcessNumberAudit, "reported", reported)
+	pa.blockchain.saveState(storage.BucketProcessNumberAudit, "latest", &report)
+	for _, alert := range raised {
+		pa.alerts.Raise(alert)
+	}
+	logf("🔢 Revisión de consecutivos: %d series, %d huecos, %d repetidos, %d alertas nuevas\n",
+		report.Series, len(report.Gaps), len(report.Reuses), report.Alerted)
+	return &report, nil
+}
+
+// findings retorna los huecos y los consecutivos repetidos de la serie
+func (series *numberSeries) findings() ([]ProcessNumberGap, []ProcessNumberReuse) {
+	numbers := make([]int, 0, len(series.numbers))
+	for number := range series.numbers {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	var gaps []ProcessNumberGap
+	var reuses []ProcessNumberReuse
+	for i, number := range numbers {
+		uses := series.numbers[number]
+		if i > 0 && number > numbers[i-1]+1 {
+			gaps = append(gaps, ProcessNumberGap{
+				EntityCode:   series.entityCode,
+				Series:       series.series,
+				From:         numbers[i-1] + 1,
+				To:           number - 1,
+				Missing:      number - numbers[i-1] - 1,
+				NextContract: uses[0].contractID,
+				NextSeenAt:   uses[0].seenAt,
+			})
+		}
+		if len(uses) > 1 {
+			reuse := ProcessNumberReuse{EntityCode: series.entityCode, Series: series.series, Number: number, ProcessNumber: uses[0].processNumber}
+			for _, use := range uses {
+				reuse.ContractIDs = append(reuse.ContractIDs, use.contractID)
+			}
+			reuses = append(reuses, reuse)
+		}
+	}
+	return gaps, reuses
+}
+
+// markGap retorna la alerta de un hueco si aún no se había alertado
+func (pa *ProcessNumberAuditor) markGap(gap ProcessNumberGap) *Alert {
+	key := fmt.Sprintf("gap|%s|%s|%d-%d", gap.EntityCode, gap.Series, gap.From, gap.To)
+	if pa.reported[key] {
+		return nil
+	}
+	pa.reported[key] = true
+
+	message := fmt.Sprintf("La entidad %s no registró el consecutivo %d de la serie %s", gap.EntityCode, gap.From, gap.Series)
+	if gap.Missing > 1 {
+		message = fmt.Sprintf("La entidad %s no registró los consecutivos %d a %d de la serie %s", gap.EntityCode, gap.From, gap.To, gap.Series)
+	}
+	return &Alert{
+		RuleName:   "Consecutivos de procesos",
+		Kind:       AlertProcessNumberGap,
+		Role:       RoleAdminChief,
+		ContractID: gap.NextContract,
+		EntityCode: gap.EntityCode,
+		Message:    message,
+	}
+}
+
+// markReuse retorna la alerta de un número repetido si aún no se había alertado con esos contratos
+func (pa *ProcessNumberAuditor) markReuse(reuse ProcessNumberReuse) *Alert {
+	key := fmt.Sprintf("reuse|%s|%s|%d|%d", reuse.EntityCode, reuse.Series, reuse.Number, len(reuse.ContractIDs))
+	if pa.reported[key] {
+		return nil
+	}
+	pa.reported[key] = true
+
+	return &Alert{
+		RuleName:   "Consecutivos de procesos",
+		Kind:       AlertProcessNumberReuse,
+		Role:       RoleAdminChief,
+		ContractID: reuse.ContractIDs[len(reuse.ContractIDs)-1],
+		EntityCode: reuse.EntityCode,
+		Message:    fmt.Sprintf("La entidad %s usó el número de proceso %s en %d contratos", reuse.EntityCode, reuse.ProcessNumber, len(reuse.ContractIDs)),
+	}
+}
+
+// Reports retorna los informes de consecutivos, el más reciente primero
+func (pa *ProcessNumberAuditor) Reports() []ProcessNumberReport {
+	pa.mutex.Lock()
+	defer pa.mutex.Unlock()
+
+	reports := make([]ProcessNumberReport, 0, len(pa.reports))
+	for i := len(pa.reports) - 1; i >= 0; i-- {
+		reports = append(reports, pa.reports[i])
+	}
+	return reports
+}
+
+// Run revisa los consecutivos periódicamente
+func (pa *ProcessNumberAuditor) Run(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := pa.Audit(); err != nil {
+			logf("❌ Error revisando consecutivos: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PliegoQuestion representa una observación al pliego y su respuesta
+type PliegoQuestion struct {
+	ID          string     `json:"id"`
+	SupplierNIT string     `json:"supplier_nit"`
+	Question    string     `json:"question"`
+	AskedAt     time.Time  `json:"asked_at"`
+	Response    string     `json:"response,omitempty"`
+	RespondedBy string     `json:"responded_by,omitempty"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+// PublishContract publica un contrato autorizado y abre el periodo de observaciones
+func (wm *WorkflowManager) PublishContract(contractID string, publisherID string, questionsDays int, responsesDays int) error {
+	unlock := wm.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := wm.blockchain.editContract(contractID)
+	if err != nil {
+		return err
+	}
+	if contract.Status != StatusAuthorizedForPublication {
+		return fmt.Errorf("el contrato debe estar en %s para publicarse, estado actual: %s", StatusAuthorizedForPublication, contract.Status)
+	}
+
+	calendar, err := NewProcessCalendar(time.Now(), questionsDays, responsesDays)
+	if err != nil {
+		return err
+	}
+
+	contract.Calendar = calendar
+	contract.Status = StatusPublished
+	contract.UpdatedAt = time.Now()
+	wm.addAuditEntry(contract, "CONTRACT_PUBLISHED", publisherID, RoleBudgetAuthority,
+		fmt.Sprintf("Proceso publicado, observaciones hasta %s", calendar.QuestionsDeadline.Format(time.RFC3339)))
+
+	blockData := map[string]interface{}{
+		"type":               "CONTRACT_PUBLICATION",
+		"contract_id":        contractID,
+		"publisher":          publisherID,
+		"questions_deadline": calendar.QuestionsDeadline,
+		"responses_deadline": calendar.ResponsesDeadline,
+		"timestamp":          calendar.PublishedAt,
+	}
+
+	wm.blockchain.stageContract(contract)
+	if err := wm.blockchain.AddBlock(blockData); err != nil {
+		wm.blockchain.unstageContract(contractID)
+		return err
+	}
+	return nil
+}
+
+// SubmitQuestion registra una observación al pliego de un proveedor registrado
+func (wm *WorkflowManager) SubmitQuestion(contractID string, supplierNIT string, question string) (*PliegoQuestion, error) {
+	unlock := wm.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := wm.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := wm.blockchain.GetSupplier(supplierNIT); err != nil {
+		return nil, err
+	}
+	if question == "" {
+		return nil, errors.New("observación requerida")
+	}
+	if contract.Status != StatusPublished || contract.Calendar == nil {
+		return nil, errors.New("el proceso no está publicado")
+	}
+	if !contract.Calendar.QuestionsOpen(time.Now()) {
+		return nil, fmt.Errorf("el plazo de observaciones venció el %s", contract.Calendar.QuestionsDeadline.Format(time.RFC3339))
+	}
+
+	entry := PliegoQuestion{
+		ID:          uuid.New().String(),
+		SupplierNIT: supplierNIT,
+		Question:    question,
+		AskedAt:     time.Now(),
+	}
+	contract.Questions = append(contract.Questions, entry)
+	contract.UpdatedAt = time.Now()
+	wm.addAuditEntry(contract, "PLIEGO_QUESTION", supplierNIT, "", "Observación al pliego registrada")
+
+	blockData := map[string]interface{}{
+		"type":         "PLIEGO_QUESTION",
+		"contract_id":  contractID,
+		"question_id":  entry.ID,
+		"supplier_nit": supplierNIT,
+		"question":     question,
+		"timestamp":    entry.AskedAt,
+	}
+
+	wm.blockchain.stageContract(contract)
+	if err := wm.blockchain.AddBlock(blockData); err != nil {
+		wm.blockchain.unstageContract(contractID)
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// RespondQuestion publica la respuesta de la entidad a una observación
+func (wm *WorkflowManager) RespondQuestion(contractID string, questionID string, responderID string, response string) error {
+	unlock := wm.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := wm.blockchain.editContract(contractID)
+	if err != nil {
+		return err
+	}
+	if response == "" {
+		return errors.New("respuesta requerida")
+	}
+	if contract.Calendar == nil || !contract.Calendar.ResponsesOpen(time.Now()) {
+		return errors.New("el plazo de respuestas no está abierto")
+	}
+
+	var entry *PliegoQuestion
+	for i := range contract.Questions {
+		if contract.Questions[i].ID == questionID {
+			entry = &contract.Questions[i]
+			break
+		}
+	}
+	if entry == nil {
+		return errors.New("observación no encontrada")
+	}
+	if entry.RespondedAt != nil {
+		return errors.New("la observación ya fue respondida")
+	}
+
+	now := time.Now()
+	entry.Response = response
+	entry.RespondedBy = responderID
+	entry.RespondedAt = &now
+	contract.UpdatedAt = now
+	wm.addAuditEntry(contract, "PLIEGO_RESPONSE", responderID, RoleContractsChief, "Respuesta a observación publicada")
+
+	blockData := map[string]interface{}{
+		"type":        "PLIEGO_RESPONSE",
+		"contract_id": contractID,
+		"question_id": questionID,
+		"responder":   responderID,
+		"response":    response,
+		"timestamp":   now,
+	}
+
+	wm.blockchain.stageContract(contract)
+	if err := wm.blockchain.AddBlock(blockData); err != nil {
+		wm.blockchain.unstageContract(contractID)
+		return err
+	}
+	return nil
+}
+
+// AwardContract adjudica un contrato publicado a un proveedor registrado o, si se
+// indica consorcio, a un proponente plural identificado por supplierNIT
+func (wm *WorkflowManager) AwardContract(contractID string, awardedBy string, supplierNIT string, consortium *Consortium) error {
+	unlock := wm.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := wm.blockchain.editContract(contractID)
+	if err != nil {
+		return err
+	}
+	if contract.Status != StatusPublished && contract.Status != StatusEvaluated {
+		return fmt.Errorf("el contrato no puede adjudicarse en estado %s", contract.Status)
+	}
+	if supplierNIT == "" {
+		return errors.New("NIT del adjudicatario requerido")
+	}
+	if consortium != nil {
+		if err := wm.blockchain.validateConsortium(consortium); err != nil {
+			return err
+		}
+	} else if err := wm.blockchain.checkSupplierEligible(supplierNIT); err != nil {
+		return err
+	}
+	if contract.Calendar != nil && contract.Calendar.QuestionsOpen(time.Now()) {
+		return errors.New("el periodo de observaciones sigue abierto")
+	}
+	for _, question := range contract.Questions {
+		if question.RespondedAt == nil {
+			return fmt.Errorf("la observación %s no ha sido respondida", question.ID)
+		}
+	}
+
+	contract.Status = StatusAwarded
+	contract.AwardedTo = supplierNIT
+	contract.Consortium = consortium
+	contract.UpdatedAt = time.Now()
+	wm.addAuditEntry(contract, "CONTRACT_AWARDED", awardedBy, RoleBudgetAuthority, fmt.Sprintf("Contrato adjudicado a %s", supplierNIT))
+
+	blockData := map[string]interface{}{
+		"type":         "CONTRACT_AWARD",
+		"contract_id":  contractID,
+		"awarded_by":   awardedBy,
+		"supplier_nit": supplierNIT,
+		"timestamp":    time.Now(),
+	}
+	if consortium != nil {
+		blockData["consortium"] = consortium
+	}
+
+	wm.blockchain.stageContract(contract)
+	if err := wm.blockchain.AddBlock(blockData); err != nil {
+		wm.blockchain.unstageContract(contractID)
+		return err
+	}
+	return nil
+}
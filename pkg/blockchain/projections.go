@@ -0,0 +1,145 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Nombres de las proyecciones (modelos de lectura) que se alimentan de la cadena
+const (
+	ProjectionContractStore  = "contract_store"
+	ProjectionAlerts         = "alerts"
+	ProjectionProcessNumbers = "process_numbers"
+	ProjectionSubscriptions  = "subscriptions"
+	ProjectionSecopBridge    = "secop_bridge"
+)
+
+// DefaultProjectionMaxLag es el atraso en bloques a partir del cual una proyección se
+// considera desactualizada
+const DefaultProjectionMaxLag = 10
+
+// ProjectionStatus es el estado de una proyección frente a la punta de la cadena
+type ProjectionStatus struct {
+	Name          string     `json:"name"`
+	AppliedHeight int        `json:"applied_height"`
+	TipHeight     int        `json:"tip_height"`
+	Lag           int        `json:"lag"`
+	Errors        int        `json:"errors"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Healthy       bool       `json:"healthy"`
+}
+
+// ProjectionMonitor lleva la última altura aplicada y los errores de cada proyección,
+// para detectar listados desactualizados antes de que los usuarios lo noten
+type ProjectionMonitor struct {
+	blockchain  *Blockchain
+	projections map[string]*ProjectionStatus
+	mutex       sync.Mutex
+}
+
+// NewProjectionMonitor crea el monitor sin proyecciones registradas
+func NewProjectionMonitor(bc *Blockchain) *ProjectionMonitor {
+	return &ProjectionMonitor{
+		blockchain:  bc,
+		projections: make(map[string]*ProjectionStatus),
+	}
+}
+
+// Subscribe registra una proyección alimentada por los eventos de la cadena. Cada evento
+// entregado avanza su altura; si el listener entra en pánico se cuenta como error y el
+// evento se da por aplicado para no detener a los demás suscriptores.
+func (pm *ProjectionMonitor) Subscribe(name string, listener func(ChainEvent)) int {
+	pm.register(name)
+	return pm.blockchain.Events.Subscribe(func(event ChainEvent) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				pm.failed(name, fmt.Errorf("pánico aplicando el bloque %d: %v", event.Height, recovered))
+			}
+			pm.applied(name, event.Height)
+		}()
+		listener(event)
+	})
+}
+
+// register agrega la proyección al día con la cadena actual; las proyecciones cargan su
+// estado al crearse
+func (pm *ProjectionMonitor) register(name string) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if _, exists := pm.projections[name]; !exists {
+		pm.projections[name] = &ProjectionStatus{
+			Name:          name,
+			AppliedHeight: pm.blockchain.Len() - 1,
+			UpdatedAt:     time.Now(),
+		}
+	}
+}
+
+// applied registra que la proyección aplicó la altura dada
+func (pm *ProjectionMonitor) applied(name string, height int) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	projection, exists := pm.projections[name]
+	if !exists {
+		return
+	}
+	if height > projection.AppliedHeight {
+		projection.AppliedHeight = height
+	}
+	projection.UpdatedAt = time.Now()
+}
+
+// failed cuenta un error de la proyección
+func (pm *ProjectionMonitor) failed(name string, err error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	projection, exists := pm.projections[name]
+	if !exists {
+		return
+	}
+	now := time.Now()
+	projection.Errors++
+	projection.LastError = err.Error()
+	projection.LastErrorAt = &now
+	logf("❌ Proyección %s: %v\n", name, err)
+}
+
+// Status retorna el estado de cada proyección; las que superan maxLag no están sanas
+func (pm *ProjectionMonitor) Status(maxLag int) []ProjectionStatus {
+	tip := pm.blockchain.Len() - 1
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	statuses := make([]ProjectionStatus, 0, len(pm.projections))
+	for _, projection := range pm.projections {
+		status := *projection
+		status.TipHeight = tip
+		status.Lag = tip - status.AppliedHeight
+		if status.Lag < 0 {
+			status.Lag = 0
+		}
+		status.Healthy = status.Lag <= maxLag
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Lagging retorna las proyecciones atrasadas más de maxLag bloques
+func (pm *ProjectionMonitor) Lagging(maxLag int) []ProjectionStatus {
+	var lagging []ProjectionStatus
+	for _, status := range pm.Status(maxLag) {
+		if !status.Healthy {
+			lagging = append(lagging, status)
+		}
+	}
+	return lagging
+}
@@ -0,0 +1,198 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Versiones del protocolo implementadas por este nodo
+const (
+	HashingVersion   = 3 // 2: raíz de Merkle en el hash del bloque; 3: codificación CBOR canónica
+	ConsensusVersion = 1
+)
+
+// TransactionKinds lista los tipos de bloque que este nodo sabe procesar
+var TransactionKinds = []string{
+	"CONTRACT_CREATION",
+	"VALIDATION",
+	"AUDIT_OBSERVATION",
+	ObservationResponseBlockType,
+	"SUPPLIER_REGISTRATION",
+	"CONTRACT_PUBLICATION",
+	"PLIEGO_QUESTION",
+	"PLIEGO_RESPONSE",
+	"CONTRACT_AWARD",
+	"SYSTEM_KEY_REGISTRATION",
+	"SUPPLIER_SANCTION",
+	"EXECUTION_EVIDENCE",
+	"VALIDATOR_KEY_REGISTRATION",
+	GovernanceBlockType,
+	"CONTRACT_ATTACHMENT",
+	"CONFLICT_DECLARATION",
+	TemplateMigrationBlockType,
+	PaymentBlockType,
+	CarryoverBlockType,
+	AmendmentBlockType,
+	AmendmentDecisionBlockType,
+	MilestoneBlockType,
+	MilestoneCompletionBlockType,
+	PaymentActBlockType,
+	FiscalClosingBlockType,
+	CheckpointBlockType,
+	BatchBlockType,
+}
+
+// ProtocolFeatures describe las capacidades que un nodo anuncia en el handshake
+type ProtocolFeatures struct {
+	TransactionKinds []string       `json:"transaction_kinds"`
+	HashingVersion   int            `json:"hashing_version"`
+	ConsensusVersion int            `json:"consensus_version"`
+	Activations      map[string]int `json:"activations"`
+}
+
+// FeatureNegotiation representa el resultado de comparar capacidades con un peer
+type FeatureNegotiation struct {
+	Compatible       bool     `json:"compatible"`
+	SharedKinds      []string `json:"shared_kinds"`
+	MissingAtPeer    []string `json:"missing_at_peer"`
+	IncompatibleWith []string `json:"incompatible_with,omitempty"`
+}
+
+// ProtocolManager maneja los feature flags del protocolo y sus alturas de activación
+type ProtocolManager struct {
+	activations map[string]int
+	mutex       sync.RWMutex
+}
+
+// NewProtocolManager crea un gestor sin activaciones programadas
+func NewProtocolManager() *ProtocolManager {
+	return &ProtocolManager{
+		activations: make(map[string]int),
+	}
+}
+
+// ParseActivations interpreta activaciones en formato "FEATURE:altura,FEATURE2:altura"
+func ParseActivations(value string) (map[string]int, error) {
+	activations := make(map[string]int)
+	if strings.TrimSpace(value) == "" {
+		return activations, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("activación inválida: %s", entry)
+		}
+		height, err := strconv.Atoi(parts[1])
+		if err != nil || height < 0 {
+			return nil, fmt.Errorf("altura de activación inválida para %s", parts[0])
+		}
+		activations[parts[0]] = height
+	}
+	return activations, nil
+}
+
+// SetActivation programa la activación de un feature a partir de una altura de bloque
+func (pm *ProtocolManager) SetActivation(feature string, height int) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.activations[feature] = height
+}
+
+// IsActive indica si un feature está activo a la altura dada; los features sin
+// activación programada están siempre activos
+func (pm *ProtocolManager) IsActive(feature string, height int) bool {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	activation, scheduled := pm.activations[feature]
+	return !scheduled || height >= activation
+}
+
+// ActivationHeight retorna la altura programada para un feature, si la tiene
+func (pm *ProtocolManager) ActivationHeight(feature string) (int, bool) {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	height, scheduled := pm.activations[feature]
+	return height, scheduled
+}
+
+// LocalFeatures retorna las capacidades que este nodo anuncia
+func (pm *ProtocolManager) LocalFeatures() ProtocolFeatures {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	activations := make(map[string]int, len(pm.activations))
+	for feature, height := range pm.activations {
+		activations[feature] = height
+	}
+
+	return ProtocolFeatures{
+		TransactionKinds: append([]string(nil), TransactionKinds...),
+		HashingVersion:   HashingVersion,
+		ConsensusVersion: ConsensusVersion,
+		Activations:      activations,
+	}
+}
+
+// Negotiate compara las capacidades locales con las de un peer
+func (pm *ProtocolManager) Negotiate(remote ProtocolFeatures) FeatureNegotiation {
+	local := pm.LocalFeatures()
+	result := FeatureNegotiation{Compatible: true}
+
+	remoteKinds := make(map[string]bool, len(remote.TransactionKinds))
+	for _, kind := range remote.TransactionKinds {
+		remoteKinds[kind] = true
+	}
+	for _, kind := range local.TransactionKinds {
+		if remoteKinds[kind] {
+			result.SharedKinds = append(result.SharedKinds, kind)
+		} else {
+			result.MissingAtPeer = append(result.MissingAtPeer, kind)
+		}
+	}
+
+	if remote.HashingVersion != local.HashingVersion {
+		result.Compatible = false
+		result.IncompatibleWith = append(result.IncompatibleWith, "hashing_version")
+	}
+	if remote.ConsensusVersion != local.ConsensusVersion {
+		result.Compatible = false
+		result.IncompatibleWith = append(result.IncompatibleWith, "consensus_version")
+	}
+
+	// Las alturas de activación deben coincidir para no bifurcar la red
+	for feature, height := range local.Activations {
+		if remoteHeight, ok := remote.Activations[feature]; ok && remoteHeight != height {
+			result.Compatible = false
+			result.IncompatibleWith = append(result.IncompatibleWith, "activation:"+feature)
+		}
+	}
+	sort.Strings(result.IncompatibleWith)
+
+	return result
+}
+
+// IsKnownTransactionKind indica si este nodo sabe procesar un tipo de bloque
+func IsKnownTransactionKind(kind string) bool {
+	for _, k := range TransactionKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsKind indica si las capacidades incluyen un tipo de transacción
+func (pf *ProtocolFeatures) SupportsKind(kind string) bool {
+	for _, k := range pf.TransactionKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
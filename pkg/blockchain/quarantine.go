@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+	"sync"
+	"time"
+)
+
+// MaxQuarantinedBlocks limita la cantidad de bloques guardados como evidencia
+const MaxQuarantinedBlocks = 1000
+
+// QuarantinedBlock representa un bloque rechazado guardado para análisis forense
+type QuarantinedBlock struct {
+	Block      Block     `json:"block"`
+	Sender     string    `json:"sender"`
+	Reason     string    `json:"reason"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Quarantine almacena los bloques inválidos recibidos de otros nodos
+type Quarantine struct {
+	blocks []QuarantinedBlock
+	mutex  sync.RWMutex
+}
+
+// NewQuarantine crea un almacén de cuarentena vacío
+func NewQuarantine() *Quarantine {
+	return &Quarantine{}
+}
+
+// Add guarda un bloque rechazado; si se supera el límite se descarta el más antiguo
+func (q *Quarantine) Add(block Block, sender string, reason string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.blocks = append(q.blocks, QuarantinedBlock{
+		Block:      block,
+		Sender:     sender,
+		Reason:     reason,
+		ReceivedAt: time.Now(),
+	})
+	if len(q.blocks) > MaxQuarantinedBlocks {
+		q.blocks = q.blocks[len(q.blocks)-MaxQuarantinedBlocks:]
+	}
+}
+
+// List retorna los bloques en cuarentena, opcionalmente filtrados por remitente
+func (q *Quarantine) List(sender string) []QuarantinedBlock {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	result := make([]QuarantinedBlock, 0, len(q.blocks))
+	for _, entry := range q.blocks {
+		if sender == "" || entry.Sender == sender {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
@@ -0,0 +1,456 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+
+	"github.com/google/uuid"
+)
+
+// Fuentes de datos de las consultas guardadas
+const (
+	QuerySourceContracts = "contracts"
+	QuerySourceBlocks    = "blocks"
+)
+
+// Agregaciones soportadas por las consultas guardadas
+var queryAggregations = map[string]bool{
+	"count":      true,
+	"sum_amount": true,
+	"avg_amount": true,
+	"min_amount": true,
+	"max_amount": true,
+}
+
+// Agrupaciones soportadas por cada fuente
+var queryGroupings = map[string]map[string]bool{
+	QuerySourceContracts: {"": true, "entity_code": true, "status": true, "contract_type": true, "month": true},
+	QuerySourceBlocks:    {"": true, "type": true, "month": true},
+}
+
+// QueryFilter representa los filtros de una consulta guardada
+type QueryFilter struct {
+	EntityCode   string         `json:"entity_code,omitempty"`
+	Status       ContractStatus `json:"status,omitempty"`
+	ContractType string         `json:"contract_type,omitempty"`
+	BlockType    string         `json:"block_type,omitempty"`
+	From         *time.Time     `json:"from,omitempty"`
+	To           *time.Time     `json:"to,omitempty"`
+	MinAmount    *float64       `json:"min_amount,omitempty"`
+	MaxAmount    *float64       `json:"max_amount,omitempty"`
+}
+
+// SavedQuery representa una consulta con nombre guardada por un analista
+type SavedQuery struct {
+	ID              string      `json:"id"`
+	Name            string      `json:"name"`
+	Description     string      `json:"description,omitempty"`
+	Source          string      `json:"source"`
+	Filter          QueryFilter `json:"filter"`
+	GroupBy         string      `json:"group_by,omitempty"`
+	Aggregations    []string    `json:"aggregations"`
+	ScheduleMinutes int         `json:"schedule_minutes,omitempty"` // 0 = solo bajo demanda
+	// IncludeCompliance adjunta al resultado el último informe de cumplimiento de metas
+	IncludeCompliance bool        `json:"include_compliance,omitempty"`
+	SharedWith        []AdminRole `json:"shared_with,omitempty"`
+	CreatedBy         string      `json:"created_by"`
+	Role              AdminRole   `json:"role"`
+	CreatedAt         time.Time   `json:"created_at"`
+	LastRunAt         *time.Time  `json:"last_run_at,omitempty"`
+}
+
+// QueryRow representa un grupo del resultado con sus agregaciones
+type QueryRow struct {
+	Group  string             `json:"group"`
+	Values map[string]float64 `json:"values"`
+}
+
+// QueryResult representa el resultado de ejecutar una consulta guardada
+type QueryResult struct {
+	QueryID  string     `json:"query_id"`
+	RunAt    time.Time  `json:"run_at"`
+	RunBy    string     `json:"run_by"`
+	Height   int        `json:"height"`
+	Matched  int        `json:"matched"`
+	Rows     []QueryRow `json:"rows"`
+	Duration string     `json:"duration"`
+	// Compliance es el último informe mensual de cumplimiento, si la consulta lo incluye
+	Compliance *ComplianceReport `json:"compliance,omitempty"`
+}
+
+// QueryEngine guarda, ejecuta y programa las consultas de los analistas
+type QueryEngine struct {
+	blockchain *Blockchain
+	queries    map[string]*SavedQuery
+	results    map[string]*QueryResult
+	compliance *ComplianceScorer
+	mutex      sync.Mutex
+}
+
+// NewQueryEngine crea el motor de consultas y carga las consultas guardadas
+func NewQueryEngine(bc *Blockchain) *QueryEngine {
+	qe := &QueryEngine{
+		blockchain: bc,
+		queries:    make(map[string]*SavedQuery),
+		results:    make(map[string]*QueryResult),
+	}
+
+	bc.store.ForEach(storage.BucketSavedQueries, func(key string, value []byte) error {
+		var query SavedQuery
+		if err := json.Unmarshal(value, &query); err == nil {
+			qe.queries[key] = &query
+		}
+		return nil
+	})
+	return qe
+}
+
+// SetComplianceScorer define de dónde toman las consultas el informe de cumplimiento de metas
+func (qe *QueryEngine) SetComplianceScorer(scorer *ComplianceScorer) {
+	qe.compliance = scorer
+}
+
+// Save valida y guarda una consulta nueva
+func (qe *QueryEngine) Save(query SavedQuery) (*SavedQuery, error) {
+	if query.Name == "" {
+		return nil, errors.New("la consulta requiere un nombre")
+	}
+	groupings, exists := queryGroupings[query.Source]
+	if !exists {
+		return nil, fmt.Errorf("fuente de datos desconocida: %s", query.Source)
+	}
+	if !groupings[query.GroupBy] {
+		return nil, fmt.Errorf("agrupación %s no soportada para %s", query.GroupBy, query.Source)
+	}
+	if len(query.Aggregations) == 0 {
+		query.Aggregations = []string{"count"}
+	}
+	for _, aggregation := range query.Aggregations {
+		if !queryAggregations[aggregation] {
+			return nil, fmt.Errorf("agregación desconocida: %s", aggregation)
+		}
+	}
+	if query.ScheduleMinutes < 0 {
+		return nil, errors.New("la programación debe ser positiva")
+	}
+
+	query.ID = uuid.New().String()
+	query.CreatedAt = time.Now()
+	query.LastRunAt = nil
+
+	qe.mutex.Lock()
+	qe.queries[query.ID] = &query
+	qe.mutex.Unlock()
+
+	qe.blockchain.saveState(storage.BucketSavedQueries, query.ID, &query)
+	logf("📊 Consulta %s guardada por %s\n", query.Name, query.CreatedBy)
+	return &query, nil
+}
+
+// Delete elimina una consulta; solo el rol que la creó puede hacerlo
+func (qe *QueryEngine) Delete(queryID string, role AdminRole) error {
+	qe.mutex.Lock()
+	defer qe.mutex.Unlock()
+
+	query, exists := qe.queries[queryID]
+	if !exists || !query.visibleTo(role) {
+		return errors.New("consulta no encontrada")
+	}
+	if query.Role != role {
+		return errors.New("solo el rol que creó la consulta puede eliminarla")
+	}
+	delete(qe.queries, queryID)
+	delete(qe.results, queryID)
+	qe.blockchain.deleteState(storage.BucketSavedQueries, queryID)
+	return nil
+}
+
+// Get retorna una consulta si el rol puede verla
+func (qe *QueryEngine) Get(queryID string, role AdminRole) (*SavedQuery, error) {
+	qe.mutex.Lock()
+	defer qe.mutex.Unlock()
+
+	query, exists := qe.queries[queryID]
+	if !exists || !query.visibleTo(role) {
+		return nil, errors.New("consulta no encontrada")
+	}
+	snapshot := *query
+	return &snapshot, nil
+}
+
+// List retorna las consultas que el rol creó o que le compartieron
+func (qe *QueryEngine) List(role AdminRole) []SavedQuery {
+	qe.mutex.Lock()
+	defer qe.mutex.Unlock()
+
+	queries := make([]SavedQuery, 0, len(qe.queries))
+	for _, query := range qe.queries {
+		if query.visibleTo(role) {
+			queries = append(queries, *query)
+		}
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].CreatedAt.Before(queries[j].CreatedAt) })
+	return queries
+}
+
+// LastResult retorna el último resultado de una consulta si el rol puede verla
+func (qe *QueryEngine) LastResult(queryID string, role AdminRole) (*QueryResult, error) {
+	if _, err := qe.Get(queryID, role); err != nil {
+		return nil, err
+	}
+
+	qe.mutex.Lock()
+	defer qe.mutex.Unlock()
+
+	result, exists := qe.results[queryID]
+	if !exists {
+		return nil, errors.New("la consulta aún no se ha ejecutado")
+	}
+	return result, nil
+}
+
+// Execute ejecuta una consulta bajo demanda
+func (qe *QueryEngine) Execute(queryID string, role AdminRole, runBy string) (*QueryResult, error) {
+	query, err := qe.Get(queryID, role)
+	if err != nil {
+		return nil, err
+	}
+	return qe.execute(query, runBy)
+}
+
+// Run ejecuta periódicamente las consultas programadas
+func (qe *QueryEngine) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		qe.runScheduled()
+	}
+}
+
+// runScheduled ejecuta las consultas cuya programación ya se cumplió
+func (qe *QueryEngine) runScheduled() {
+	now := time.Now()
+
+	qe.mutex.Lock()
+	var due []SavedQuery
+	for _, query := range qe.queries {
+		if query.ScheduleMinutes <= 0 {
+			continue
+		}
+		if query.LastRunAt == nil || now.Sub(*query.LastRunAt) >= time.Duration(query.ScheduleMinutes)*time.Minute {
+			due = append(due, *query)
+		}
+	}
+	qe.mutex.Unlock()
+
+	for i := range due {
+		if _, err := qe.execute(&due[i], "scheduler"); err != nil {
+			logf("❌ Error ejecutando consulta programada %s: %v\n", due[i].Name, err)
+		}
+	}
+}
+
+// execute calcula el resultado de la consulta y lo guarda como el último
+func (qe *QueryEngine) execute(query *SavedQuery, runBy string) (*QueryResult, error) {
+	started := time.Now()
+	groups := make(map[string]*queryAccumulator)
+
+	var matched int
+	var err error
+	switch query.Source {
+	case QuerySourceContracts:
+		matched, err = qe.scanContracts(query, groups)
+	case QuerySourceBlocks:
+		matched, err = qe.scanBlocks(query, groups)
+	default:
+		err = fmt.Errorf("fuente de datos desconocida: %s", query.Source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &QueryResult{
+		QueryID: query.ID,
+		RunAt:   started,
+		RunBy:   runBy,
+		Height:  qe.blockchain.Len(),
+		Matched: matched,
+		Rows:    make([]QueryRow, 0, len(groups)),
+	}
+	for group, accumulator := range groups {
+		result.Rows = append(result.Rows, QueryRow{Group: group, Values: accumulator.values(query.Aggregations)})
+	}
+	sort.Slice(result.Rows, func(i, j int) bool { return result.Rows[i].Group < result.Rows[j].Group })
+	if query.IncludeCompliance && qe.compliance != nil {
+		if report := qe.compliance.Latest(); report != nil {
+			if query.Filter.EntityCode != "" {
+				report = report.ForEntity(query.Filter.EntityCode)
+			}
+			result.Compliance = report
+		}
+	}
+	result.Duration = time.Since(started).String()
+
+	qe.mutex.Lock()
+	qe.results[query.ID] = result
+	var saved *SavedQuery
+	if stored, exists := qe.queries[query.ID]; exists {
+		stored.LastRunAt = &started
+		snapshot := *stored
+		saved = &snapshot
+	}
+	qe.mutex.Unlock()
+
+	if saved != nil {
+		qe.blockchain.saveState(storage.BucketSavedQueries, saved.ID, saved)
+	}
+	return result, nil
+}
+
+// scanContracts agrega los contratos que cumplen los filtros
+func (qe *QueryEngine) scanContracts(query *SavedQuery, groups map[string]*queryAccumulator) (int, error) {
+	filter := query.Filter
+	contracts, err := qe.blockchain.QueryContracts(ContractQuery{
+		EntityCode:   filter.EntityCode,
+		ContractType: filter.ContractType,
+		Status:       filter.Status,
+		From:         filter.From,
+		To:           filter.To,
+		MinAmount:    filter.MinAmount,
+		MaxAmount:    filter.MaxAmount,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	matched := 0
+	for _, contract := range contracts {
+		var group string
+		switch query.GroupBy {
+		case "entity_code":
+			group = contract.EntityCode
+		case "status":
+			group = string(contract.Status)
+		case "contract_type":
+			group = contract.ContractType
+		case "month":
+			group = contract.CreatedAt.Format("2006-01")
+		}
+		accumulatorFor(groups, group).add(contract.Amount, true)
+		matched++
+	}
+	return matched, nil
+}
+
+// scanBlocks agrega las transacciones de la cadena que cumplen los filtros; los lotes del
+// mempool cuentan cada transacción por separado. El monto solo se conoce en los bloques
+// que no fueron archivados.
+func (qe *QueryEngine) scanBlocks(query *SavedQuery, groups map[string]*queryAccumulator) (int, error) {
+	filter := query.Filter
+	matched := 0
+	for _, block := range qe.blockchain.Blocks() {
+		if filter.From != nil && block.Timestamp.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && block.Timestamp.After(*filter.To) {
+			continue
+		}
+
+		for _, event := range block.Events() {
+			if filter.BlockType != "" && event.Type != filter.BlockType {
+				continue
+			}
+
+			var group string
+			switch query.GroupBy {
+			case "type":
+				group = event.Type
+			case "month":
+				group = block.Timestamp.Format("2006-01")
+			}
+			amount, hasAmount := 0.0, false
+			if !block.Pruned {
+				amount, hasAmount = eventPayload(event)["amount"].(float64)
+			}
+			accumulatorFor(groups, group).add(amount, hasAmount)
+			matched++
+		}
+	}
+	return matched, nil
+}
+
+// visibleTo indica si el rol creó la consulta o se la compartieron
+func (query *SavedQuery) visibleTo(role AdminRole) bool {
+	if query.Role == role {
+		return true
+	}
+	for _, shared := range query.SharedWith {
+		if shared == role {
+			return true
+		}
+	}
+	return false
+}
+
+// queryAccumulator acumula las agregaciones de un grupo
+type queryAccumulator struct {
+	count   int
+	amounts int
+	sum     float64
+	min     float64
+	max     float64
+}
+
+func accumulatorFor(groups map[string]*queryAccumulator, group string) *queryAccumulator {
+	accumulator, exists := groups[group]
+	if !exists {
+		accumulator = &queryAccumulator{}
+		groups[group] = accumulator
+	}
+	return accumulator
+}
+
+func (qa *queryAccumulator) add(amount float64, hasAmount bool) {
+	qa.count++
+	if !hasAmount {
+		return
+	}
+	if qa.amounts == 0 || amount < qa.min {
+		qa.min = amount
+	}
+	if qa.amounts == 0 || amount > qa.max {
+		qa.max = amount
+	}
+	qa.amounts++
+	qa.sum += amount
+}
+
+func (qa *queryAccumulator) values(aggregations []string) map[string]float64 {
+	values := make(map[string]float64, len(aggregations))
+	for _, aggregation := range aggregations {
+		switch aggregation {
+		case "count":
+			values[aggregation] = float64(qa.count)
+		case "sum_amount":
+			values[aggregation] = qa.sum
+		case "avg_amount":
+			if qa.amounts > 0 {
+				values[aggregation] = qa.sum / float64(qa.amounts)
+			} else {
+				values[aggregation] = 0
+			}
+		case "min_amount":
+			values[aggregation] = qa.min
+		case "max_amount":
+			values[aggregation] = qa.max
+		}
+	}
+	return values
+}
@@ -0,0 +1,230 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// Registros derivados de la cadena: proveedores con sus sanciones, llaves de los sistemas
+// de las entidades y llaves de los validadores. Igual que los contratos (ver replay.go),
+// ReplayState los reconstruye desde las transacciones, así que un snapshot o una cadena
+// adoptada no pueden traerlos alterados.
+//
+// Los mapas se leen y se escriben con bc.mutex, solo el tiempo de tocar el mapa; los
+// valores no se modifican una vez guardados (una sanción guarda una copia nueva del
+// proveedor). bc.registryMutex serializa cada inscripción desde la verificación hasta que
+// su bloque entra a la cadena, para que dos peticiones concurrentes no inscriban lo mismo.
+
+// registryReducers son las transacciones que alimentan los registros
+var registryReducers = map[string]contractReducer{
+	"SUPPLIER_REGISTRATION":      replaySupplier,
+	"SUPPLIER_SANCTION":          replaySanction,
+	"SYSTEM_KEY_REGISTRATION":    replaySystemKey,
+	"VALIDATOR_KEY_REGISTRATION": replayValidatorKey,
+}
+
+// replaySupplier inscribe el proveedor
+func replaySupplier(sr *stateReplay, tx replayTx) error {
+	supplier := &Supplier{RegisteredAt: tx.At}
+	decodeField(tx.Data, "nit", &supplier.NIT)
+	decodeField(tx.Data, "name", &supplier.Name)
+	decodeField(tx.Data, "email", &supplier.Email)
+	if supplier.NIT == "" {
+		return errors.New("registro de proveedor sin NIT")
+	}
+	if _, exists := sr.suppliers[supplier.NIT]; exists {
+		return fmt.Errorf("proveedor %s registrado dos veces", supplier.NIT)
+	}
+	sr.suppliers[supplier.NIT] = supplier
+	return nil
+}
+
+// replaySanction agrega la sanción al proveedor
+func replaySanction(sr *stateReplay, tx replayTx) error {
+	var nit string
+	decodeField(tx.Data, "nit", &nit)
+	supplier, exists := sr.suppliers[nit]
+	if !exists {
+		return fmt.Errorf("proveedor %s no registrado en la cadena", nit)
+	}
+	sanction := Sanction{IssuedAt: tx.At}
+	decodeField(tx.Data, "reason", &sanction.Reason)
+	decodeField(tx.Data, "issued_by", &sanction.IssuedBy)
+	var validUntil time.Time
+	if decodeField(tx.Data, "valid_until", &validUntil) {
+		sanction.ValidUntil = &validUntil
+	}
+	supplier.Sanctions = append(supplier.Sanctions, sanction)
+	return nil
+}
+
+// replaySystemKey registra (o reemplaza) la llave del sistema de la entidad
+func replaySystemKey(sr *stateReplay, tx replayTx) error {
+	key := &EntitySystemKey{RegisteredAt: tx.At}
+	decodeField(tx.Data, "entity_code", &key.EntityCode)
+	decodeField(tx.Data, "system_id", &key.SystemID)
+	decodeField(tx.Data, "public_key", &key.PublicKey)
+	if key.EntityCode == "" || key.SystemID == "" || key.PublicKey == "" {
+		return errors.New("registro de sistema incompleto")
+	}
+	sr.systemKeys[systemKeyID(key.EntityCode, key.SystemID)] = key
+	return nil
+}
+
+// replayValidatorKey inscribe la llave de firma del validador
+func replayValidatorKey(sr *stateReplay, tx replayTx) error {
+	key := &ValidatorKey{RegisteredAt: tx.At}
+	decodeField(tx.Data, "validator_key_id", &key.KeyID)
+	decodeField(tx.Data, "validator_id", &key.ValidatorID)
+	decodeField(tx.Data, "role", &key.Role)
+	decodeField(tx.Data, "public_key", &key.PublicKey)
+	if key.KeyID == "" || key.ValidatorID == "" || key.PublicKey == "" {
+		return errors.New("registro de llave de validador incompleto")
+	}
+	if _, exists := sr.validatorKeys[key.KeyID]; exists {
+		return fmt.Errorf("llave %s registrada dos veces", key.KeyID)
+	}
+	sr.validatorKeys[key.KeyID] = key
+	return nil
+}
+
+// supplier retorna el proveedor con el NIT dado
+func (bc *Blockchain) supplier(nit string) (*Supplier, bool) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	supplier, exists := bc.suppliers[nit]
+	return supplier, exists
+}
+
+// putSupplier guarda el proveedor en el registro y en el almacenamiento
+func (bc *Blockchain) putSupplier(supplier *Supplier) {
+	bc.mutex.Lock()
+	bc.suppliers[supplier.NIT] = supplier
+	bc.mutex.Unlock()
+	bc.saveState(storage.BucketSuppliers, supplier.NIT, supplier)
+}
+
+// systemKey retorna la llave del sistema de una entidad
+func (bc *Blockchain) systemKey(id string) (*EntitySystemKey, bool) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	key, exists := bc.systemKeys[id]
+	return key, exists
+}
+
+// putSystemKey guarda la llave del sistema en el registro y en el almacenamiento
+func (bc *Blockchain) putSystemKey(key *EntitySystemKey) {
+	id := systemKeyID(key.EntityCode, key.SystemID)
+	bc.mutex.Lock()
+	bc.systemKeys[id] = key
+	bc.mutex.Unlock()
+	bc.saveState(storage.BucketSystemKeys, id, key)
+}
+
+// validatorKey retorna la llave de validador con el ID dado
+func (bc *Blockchain) validatorKey(keyID string) (*ValidatorKey, bool) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	key, exists := bc.validatorKeys[keyID]
+	return key, exists
+}
+
+// putValidatorKey guarda la llave del validador en el registro y en el almacenamiento
+func (bc *Blockchain) putValidatorKey(key *ValidatorKey) {
+	bc.mutex.Lock()
+	bc.validatorKeys[key.KeyID] = key
+	bc.mutex.Unlock()
+	bc.saveState(storage.BucketValidatorKeys, key.KeyID, key)
+}
+
+// AllSystemKeys retorna las llaves de los sistemas de todas las entidades
+func (bc *Blockchain) AllSystemKeys() []*EntitySystemKey {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	keys := make([]*EntitySystemKey, 0, len(bc.systemKeys))
+	for _, key := range bc.systemKeys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// AllValidatorKeys retorna las llaves de todos los validadores
+func (bc *Blockchain) AllValidatorKeys() []*ValidatorKey {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	keys := make([]*ValidatorKey, 0, len(bc.validatorKeys))
+	for _, key := range bc.validatorKeys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// registryMaps retorna copias de los mapas de los registros, p. ej. para un snapshot
+func (bc *Blockchain) registryMaps() (map[string]*Supplier, map[string]*EntitySystemKey, map[string]*ValidatorKey) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	suppliers := make(map[string]*Supplier, len(bc.suppliers))
+	for nit, supplier := range bc.suppliers {
+		suppliers[nit] = supplier
+	}
+	systemKeys := make(map[string]*EntitySystemKey, len(bc.systemKeys))
+	for id, key := range bc.systemKeys {
+		systemKeys[id] = key
+	}
+	validatorKeys := make(map[string]*ValidatorKey, len(bc.validatorKeys))
+	for id, key := range bc.validatorKeys {
+		validatorKeys[id] = key
+	}
+	return suppliers, systemKeys, validatorKeys
+}
+
+// setRegistries guarda los registros derivados, elimina del almacenamiento los que ya no
+// están en la cadena y los intercambia por los vigentes. Los proveedores inscritos antes
+// de que el correo viajara en el bloque conservan el correo de la copia local.
+func (bc *Blockchain) setRegistries(sr *stateReplay) {
+	suppliers, systemKeys, validatorKeys := bc.registryMaps()
+	for nit, supplier := range sr.suppliers {
+		if local, exists := suppliers[nit]; exists && supplier.Email == "" && local.Name == supplier.Name {
+			supplier.Email = local.Email
+		}
+	}
+
+	for nit := range suppliers {
+		if _, exists := sr.suppliers[nit]; !exists {
+			bc.deleteState(storage.BucketSuppliers, nit)
+		}
+	}
+	for nit, supplier := range sr.suppliers {
+		bc.saveState(storage.BucketSuppliers, nit, supplier)
+	}
+	for id := range systemKeys {
+		if _, exists := sr.systemKeys[id]; !exists {
+			bc.deleteState(storage.BucketSystemKeys, id)
+		}
+	}
+	for id, key := range sr.systemKeys {
+		bc.saveState(storage.BucketSystemKeys, id, key)
+	}
+	for id := range validatorKeys {
+		if _, exists := sr.validatorKeys[id]; !exists {
+			bc.deleteState(storage.BucketValidatorKeys, id)
+		}
+	}
+	for id, key := range sr.validatorKeys {
+		bc.saveState(storage.BucketValidatorKeys, id, key)
+	}
+
+	bc.setRegistryMaps(sr.suppliers, sr.systemKeys, sr.validatorKeys)
+}
+
+// setRegistryMaps reemplaza los mapas de los registros
+func (bc *Blockchain) setRegistryMaps(suppliers map[string]*Supplier, systemKeys map[string]*EntitySystemKey, validatorKeys map[string]*ValidatorKey) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	bc.suppliers = suppliers
+	bc.systemKeys = systemKeys
+	bc.validatorKeys = validatorKeys
+}
@@ -0,0 +1,722 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// Máquina de estados de los contratos: su estado (estado, paso actual, línea de auditoría)
+// se deriva aplicando en orden las transacciones tipadas de la cadena, de modo que dos nodos
+// con la misma cadena llegan al mismo estado. ReplayState se usa al iniciar, tras adoptar la
+// cadena de un peer y al resolver una bifurcación.
+//
+// Solo cuenta lo que está bajo el hash de los bloques: lo que un contrato deba mostrar en
+// todos los nodos viaja en los datos de su transacción. Lo que queda fuera (la toma de un
+// paso, las retenciones, las alertas de un nodo) es propio de cada nodo y se conserva con la
+// copia local.
+
+// contractReducer aplica una transacción de un tipo al estado de los contratos
+type contractReducer func(sr *stateReplay, tx replayTx) error
+
+// contractReducers son las transacciones que mueven la máquina de estados de un contrato;
+// las demás solo aportan su secuencia
+var contractReducers = map[string]contractReducer{
+	"CONTRACT_CREATION":          replayCreation,
+	"VALIDATION":                 replayValidation,
+	"CONFLICT_DECLARATION":       replayConflictDeclaration,
+	"AUDIT_OBSERVATION":          replayObservation,
+	ObservationResponseBlockType: replayObservationResponse,
+	"CONTRACT_PUBLICATION":       replayPublication,
+	"PLIEGO_QUESTION":            replayQuestion,
+	"PLIEGO_RESPONSE":            replayQuestionResponse,
+	"CONTRACT_AWARD":             replayAward,
+	TemplateMigrationBlockType:   replayTemplateMigration,
+	PaymentBlockType:             replayPayment,
+	CarryoverBlockType:           replayCarryover,
+	AmendmentBlockType:           replayAmendment,
+	AmendmentDecisionBlockType:   replayAmendmentDecision,
+	MilestoneBlockType:           replayMilestone,
+	MilestoneCompletionBlockType: replayMilestoneCompletion,
+	PaymentActBlockType:          replayPaymentAct,
+	"EXECUTION_EVIDENCE":         replayEvidence,
+	"CONTRACT_ATTACHMENT":        replayAttachment,
+}
+
+// replayTx es una transacción de la cadena con su posición
+type replayTx struct {
+	ID        string // Hash del bloque original y posición en el lote; igual en todos los nodos
+	BlockHash string
+	Data      map[string]interface{}
+	At        time.Time
+}
+
+// stateReplay acumula el estado de los contratos mientras se recorre la cadena
+type stateReplay struct {
+	workflow      *WorkflowManager
+	contracts     map[string]*Contract
+	suppliers     map[string]*Supplier
+	systemKeys    map[string]*EntitySystemKey
+	validatorKeys map[string]*ValidatorKey
+}
+
+// newStateReplay crea un estado vacío para aplicar transacciones
+func newStateReplay(workflow *WorkflowManager) *stateReplay {
+	return &stateReplay{
+		workflow:      workflow,
+		contracts:     make(map[string]*Contract),
+		suppliers:     make(map[string]*Supplier),
+		systemKeys:    make(map[string]*EntitySystemKey),
+		validatorKeys: make(map[string]*ValidatorKey),
+	}
+}
+
+// ReplayState reconstruye el estado de los contratos y los registros (ver registries.go)
+// desde la cadena y lo intercambia por el vigente. Se conserva la copia local de un contrato
+// cuando está al día con la cadena (misma secuencia, estado y paso), porque lleva los campos
+// propios del nodo.
+func (bc *Blockchain) ReplayState() error {
+	chain, err := bc.FullChain()
+	if err != nil {
+		return fmt.Errorf("error leyendo la cadena: %v", err)
+	}
+
+	sr := newStateReplay(bc.WorkflowManager)
+	for _, block := range chain {
+		sr.applyBlock(block)
+	}
+
+	previous := bc.contractMap()
+	kept := 0
+	for id, local := range previous {
+		derived, exists := sr.contracts[id]
+		if !exists {
+			// De los contratos reservados este nodo puede tener solo los encabezados
+			if local.Reserved {
+				sr.contracts[id] = local
+				kept++
+			}
+			continue
+		}
+		if local.Sequence == derived.Sequence && local.Status == derived.Status && local.CurrentStep == derived.CurrentStep {
+			sr.contracts[id] = local
+			kept++
+		}
+	}
+
+	bc.setContracts(sr.contracts)
+	for id := range previous {
+		if _, exists := sr.contracts[id]; !exists {
+			bc.store.Delete(storage.BucketContracts, id)
+		}
+	}
+	for _, contract := range sr.contracts {
+		bc.saveState(storage.BucketContracts, contract.ID, contract)
+	}
+	bc.setRegistries(sr)
+	bc.rebuildSequences()
+	bc.rebuildSignatures()
+	if err := bc.reindexContracts(); err != nil {
+		return err
+	}
+
+	logf("🔄 Estado reconstruido desde %d bloques: %d contratos (%d con cambios locales)\n", len(chain), len(sr.contracts), kept)
+	return nil
+}
+
+// applyBlock aplica las transacciones del bloque
+func (sr *stateReplay) applyBlock(block *Block) {
+	hash := originHash(block)
+	for i, entry := range eventData(block.Data) {
+		kind, _ := entry["type"].(string)
+		tx := replayTx{
+			ID:        fmt.Sprintf("%s:%d", hash, i),
+			BlockHash: hash,
+			Data:      entry,
+			At:        block.Timestamp,
+		}
+		decodeField(entry, "timestamp", &tx.At)
+
+		reducer, exists := contractReducers[kind]
+		if !exists {
+			reducer, exists = registryReducers[kind]
+		}
+		if exists {
+			if err := reducer(sr, tx); err != nil {
+				logf("⚠️ Transacción %s del bloque %d no aplicada: %v\n", kind, block.Index, err)
+				continue
+			}
+		}
+		if contractID, sequence, ok := transactionSequence(entry); ok {
+			if contract, exists := sr.contracts[contractID]; exists {
+				contract.Sequence = sequence
+				if tx.At.After(contract.UpdatedAt) {
+					contract.UpdatedAt = tx.At
+				}
+			}
+		}
+	}
+}
+
+// contract retorna el contrato al que se refiere la transacción
+func (sr *stateReplay) contract(tx replayTx) (*Contract, error) {
+	contractID, _ := tx.Data["contract_id"].(string)
+	contract, exists := sr.contracts[contractID]
+	if !exists {
+		return nil, fmt.Errorf("contrato %s no creado en la cadena", contractID)
+	}
+	return contract, nil
+}
+
+// audit agrega a la línea de auditoría una entrada con ID derivado de la transacción
+func (sr *stateReplay) audit(contract *Contract, tx replayTx, action string, userID string, role AdminRole, description string) {
+	contract.AuditTrail = append(contract.AuditTrail, AuditEntry{
+		ID:          tx.ID + ":" + action,
+		Action:      action,
+		UserID:      userID,
+		UserRole:    role,
+		Timestamp:   tx.At,
+		Description: description,
+		BlockHash:   tx.BlockHash,
+	})
+	contract.UpdatedAt = tx.At
+}
+
+// replayCreation crea el contrato con su flujo inicializado y la plantilla que fijó al radicarse
+func replayCreation(sr *stateReplay, tx replayTx) error {
+	contract := &Contract{AuditTrail: []AuditEntry{}}
+	for key, target := range map[string]interface{}{
+		"contract_id":      &contract.ID,
+		"entity_code":      &contract.EntityCode,
+		"entity_name":      &contract.EntityName,
+		"contract_type":    &contract.ContractType,
+		"description":      &contract.Description,
+		"amount":           &contract.Amount,
+		"created_by":       &contract.CreatedBy,
+		"process_number":   &contract.ProcessNumber,
+		"origin_system":    &contract.OriginSystem,
+		"template_version": &contract.TemplateVersion,
+		"reserved":         &contract.Reserved,
+	} {
+		decodeField(tx.Data, key, target)
+	}
+	if contract.ID == "" {
+		return errors.New("creación sin contract_id")
+	}
+	if _, exists := sr.contracts[contract.ID]; exists {
+		return fmt.Errorf("contrato %s creado dos veces", contract.ID)
+	}
+
+	steps := sr.workflow.GetWorkflowSteps()
+	contract.ValidationSteps = make([]ValidationStep, len(steps))
+	for i, step := range steps {
+		contract.ValidationSteps[i] = ValidationStep{
+			StepNumber: step.StepNumber,
+			Role:       step.Role,
+			Status:     ValidationPending,
+			Required:   step.Required,
+		}
+	}
+	if contract.TemplateVersion != 0 {
+		version := contract.TemplateVersion
+		if template, err := sr.workflow.TemplateVersion(contract.ContractType, version); err == nil {
+			pinTemplate(contract, template)
+		}
+		// Sin la plantilla en este nodo las aprobaciones automáticas igual llegan como validaciones
+		contract.TemplateVersion = version
+	}
+
+	contract.CurrentStep = 1
+	contract.Status = StatusDraft
+	contract.CreatedAt = tx.At
+	sr.audit(contract, tx, "WORKFLOW_INITIALIZED", contract.CreatedBy, RoleProjectDeveloper, "Flujo de trabajo inicializado")
+	sr.contracts[contract.ID] = contract
+	return nil
+}
+
+// replayValidation aplica la decisión sobre un paso del flujo
+func replayValidation(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	var approved bool
+	decodeField(tx.Data, "approved", &approved)
+
+	// Validación de nodo heredada (ValidateContract): solo el rechazo cambia el estado
+	if _, legacy := tx.Data["node_id"]; legacy {
+		if !approved {
+			contract.Status = StatusRejected
+			contract.UpdatedAt = tx.At
+		}
+		return nil
+	}
+
+	var stepNumber int
+	decodeField(tx.Data, "step", &stepNumber)
+	if stepNumber != contract.CurrentStep || stepNumber < 1 || stepNumber > len(contract.ValidationSteps) {
+		return fmt.Errorf("paso %d fuera de orden, el contrato %s está en el paso %d", stepNumber, contract.ID, contract.CurrentStep)
+	}
+
+	step := &contract.ValidationSteps[stepNumber-1]
+	var role string
+	var autoApproved bool
+	decodeField(tx.Data, "validator", &step.ValidatorID)
+	decodeField(tx.Data, "role", &role)
+	decodeField(tx.Data, "comments", &step.Comments)
+	decodeField(tx.Data, "auto_approved", &autoApproved)
+	step.Timestamp = tx.At
+
+	actNote := ""
+	if autoApproved {
+		decodeField(tx.Data, "rule_reference", &step.RuleReference)
+		step.AutoApproved = true
+		step.ValidatorName = "Aprobación automática"
+	} else {
+		// Las validaciones anteriores a este campo solo traen el ID del validador
+		if !decodeField(tx.Data, "validator_name", &step.ValidatorName) {
+			step.ValidatorName = step.ValidatorID
+		}
+		decodeField(tx.Data, "signature", &step.DigitalSign)
+		decodeField(tx.Data, "signature_kid", &step.SignatureKeyID)
+		var signedAt time.Time
+		if decodeField(tx.Data, "signed_at", &signedAt) {
+			step.SignedAt = &signedAt
+		}
+		var act StepAct
+		if decodeField(tx.Data, "act_hash", &act.Hash) {
+			decodeField(tx.Data, "act_reference", &act.Reference)
+			step.Act = &act
+			if act.Reference != "" {
+				actNote = fmt.Sprintf(" (%s, hash %s)", act.Reference, act.Hash)
+			} else {
+				actNote = fmt.Sprintf(" (acta %s)", act.Hash)
+			}
+		}
+	}
+	contract.Claim = nil
+
+	switch {
+	case approved && autoApproved:
+		step.Status = ValidationApproved
+		sr.audit(contract, tx, "STEP_AUTO_APPROVED", step.ValidatorID, step.Role, fmt.Sprintf("Paso %d: %s", stepNumber, step.Comments))
+	case approved:
+		step.Status = ValidationApproved
+		sr.audit(contract, tx, "STEP_APPROVED", step.ValidatorID, AdminRole(role), fmt.Sprintf("Paso %d aprobado: %s%s", stepNumber, step.Comments, actNote))
+	default:
+		step.Status = ValidationRejected
+		contract.Status = StatusRejected
+		sr.audit(contract, tx, "STEP_REJECTED", step.ValidatorID, AdminRole(role), fmt.Sprintf("Paso %d rechazado: %s%s", stepNumber, step.Comments, actNote))
+		return nil
+	}
+	if advanceStep(contract, stepNumber) {
+		sr.audit(contract, tx, "WORKFLOW_COMPLETED", step.ValidatorID, AdminRole(role), "Flujo de validación completado")
+	}
+	return nil
+}
+
+// replayConflictDeclaration registra la declaración de conflicto de interés de un validador;
+// si declaró conflicto se libera la toma que tuviera sobre el paso
+func replayConflictDeclaration(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	var step int
+	var validatorID, validatorName, role string
+	var hasConflict bool
+	decodeField(tx.Data, "step", &step)
+	decodeField(tx.Data, "validator", &validatorID)
+	decodeField(tx.Data, "role", &role)
+	decodeField(tx.Data, "has_conflict", &hasConflict)
+	// Las declaraciones anteriores a este campo solo traen el ID del validador
+	if !decodeField(tx.Data, "validator_name", &validatorName) {
+		validatorName = validatorID
+	}
+
+	if hasConflict {
+		sr.audit(contract, tx, "CONFLICT_DECLARED", validatorID, AdminRole(role),
+			fmt.Sprintf("Paso %d: %s declaró conflicto de interés y queda impedido", step, validatorName))
+		if contract.Claim != nil && contract.Claim.ReviewerID == validatorID {
+			contract.Claim = nil
+		}
+	} else {
+		sr.audit(contract, tx, "NO_CONFLICT_DECLARED", validatorID, AdminRole(role),
+			fmt.Sprintf("Paso %d: %s declaró no tener conflicto de interés", step, validatorName))
+	}
+	return nil
+}
+
+// replayObservation registra la observación de un ente de control con su plazo
+func replayObservation(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	observation := AuditObservation{FiledAt: tx.At}
+	var role string
+	decodeField(tx.Data, "observation_id", &observation.ID)
+	decodeField(tx.Data, "auditor", &observation.AuditorID)
+	decodeField(tx.Data, "role", &role)
+	decodeField(tx.Data, "observation", &observation.Observation)
+	observation.Role = AdminRole(role)
+	if !decodeField(tx.Data, "due_at", &observation.DueAt) {
+		observation.DueAt = tx.At.AddDate(0, 0, DefaultObservationResponseDays)
+	}
+
+	// Las observaciones anteriores a los plazos de respuesta no tienen ID propio
+	if observation.ID != "" {
+		contract.Observations = append(contract.Observations, observation)
+	}
+	sr.audit(contract, tx, "AUDIT_OBSERVATION", observation.AuditorID, observation.Role, observation.Observation)
+	return nil
+}
+
+// replayObservationResponse registra la respuesta de la entidad a una observación
+func replayObservationResponse(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	var observationID, role string
+	decodeField(tx.Data, "observation_id", &observationID)
+	decodeField(tx.Data, "role", &role)
+
+	for i := range contract.Observations {
+		observation := &contract.Observations[i]
+		if observation.ID != observationID {
+			continue
+		}
+		respondedAt := tx.At
+		decodeField(tx.Data, "response", &observation.Response)
+		decodeField(tx.Data, "responder", &observation.RespondedBy)
+		observation.RespondedAt = &respondedAt
+		sr.audit(contract, tx, ObservationResponseBlockType, observation.RespondedBy, AdminRole(role), observation.responseDescription())
+		return nil
+	}
+	return fmt.Errorf("observación %s no encontrada", observationID)
+}
+
+// replayPublication publica el proceso con su cronograma
+func replayPublication(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	var publisher string
+	calendar := &ProcessCalendar{PublishedAt: tx.At}
+	decodeField(tx.Data, "publisher", &publisher)
+	decodeField(tx.Data, "questions_deadline", &calendar.QuestionsDeadline)
+	decodeField(tx.Data, "responses_deadline", &calendar.ResponsesDeadline)
+
+	contract.Calendar = calendar
+	contract.Status = StatusPublished
+	sr.audit(contract, tx, "CONTRACT_PUBLISHED", publisher, RoleBudgetAuthority,
+		fmt.Sprintf("Proceso publicado, observaciones hasta %s", calendar.QuestionsDeadline.Format(time.RFC3339)))
+	return nil
+}
+
+// replayQuestion registra una observación al pliego
+func replayQuestion(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	question := PliegoQuestion{AskedAt: tx.At}
+	decodeField(tx.Data, "question_id", &question.ID)
+	decodeField(tx.Data, "supplier_nit", &question.SupplierNIT)
+	decodeField(tx.Data, "question", &question.Question)
+
+	contract.Questions = append(contract.Questions, question)
+	sr.audit(contract, tx, "PLIEGO_QUESTION", question.SupplierNIT, "", "Observación al pliego registrada")
+	return nil
+}
+
+// replayQuestionResponse registra la respuesta a una observación al pliego
+func replayQuestionResponse(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	var questionID string
+	decodeField(tx.Data, "question_id", &questionID)
+
+	for i := range contract.Questions {
+		question := &contract.Questions[i]
+		if question.ID != questionID {
+			continue
+		}
+		respondedAt := tx.At
+		decodeField(tx.Data, "response", &question.Response)
+		decodeField(tx.Data, "responder", &question.RespondedBy)
+		question.RespondedAt = &respondedAt
+		sr.audit(contract, tx, "PLIEGO_RESPONSE", question.RespondedBy, RoleContractsChief, "Respuesta a observación publicada")
+		return nil
+	}
+	return fmt.Errorf("observación al pliego %s no encontrada", questionID)
+}
+
+// replayAward adjudica el contrato
+func replayAward(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	var awardedBy string
+	decodeField(tx.Data, "awarded_by", &awardedBy)
+	decodeField(tx.Data, "supplier_nit", &contract.AwardedTo)
+	contract.Consortium = nil
+	var consortium Consortium
+	if decodeField(tx.Data, "consortium", &consortium) {
+		contract.Consortium = &consortium
+	}
+
+	contract.Status = StatusAwarded
+	sr.audit(contract, tx, "CONTRACT_AWARDED", awardedBy, RoleBudgetAuthority, fmt.Sprintf("Contrato adjudicado a %s", contract.AwardedTo))
+	return nil
+}
+
+// replayTemplateMigration cambia la versión de plantilla de los pasos aún sin decidir
+func replayTemplateMigration(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	var previous, version int
+	var migratedBy, reason string
+	decodeField(tx.Data, "from_version", &previous)
+	decodeField(tx.Data, "to_version", &version)
+	decodeField(tx.Data, "migrated_by", &migratedBy)
+	decodeField(tx.Data, "reason", &reason)
+
+	var template *WorkflowTemplate
+	if version != 0 {
+		template, _ = sr.workflow.TemplateVersion(contract.ContractType, version)
+	}
+	pinTemplate(contract, template)
+	contract.TemplateVersion = version
+	sr.audit(contract, tx, "TEMPLATE_MIGRATED", migratedBy, "",
+		fmt.Sprintf("Plantilla migrada de la versión %d a la %d: %s", previous, version, reason))
+	return nil
+}
+
+// replayPayment registra un pago del contrato
+func replayPayment(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	payment := ContractPayment{BlockHash: tx.BlockHash}
+	var role string
+	decodeField(tx.Data, "payment_id", &payment.ID)
+	decodeField(tx.Data, "amount", &payment.Amount)
+	decodeField(tx.Data, "reference", &payment.Reference)
+	decodeField(tx.Data, "paid_at", &payment.PaidAt)
+	decodeField(tx.Data, "registered_by", &payment.RegisteredBy)
+	decodeField(tx.Data, "role", &role)
+
+	contract.Payments = append(contract.Payments, payment)
+	sr.audit(contract, tx, PaymentBlockType, payment.RegisteredBy, AdminRole(role), payment.description())
+	return nil
+}
+
+// replayCarryover constituye el saldo del contrato al cierre de una vigencia
+func replayCarryover(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	carryover := Carryover{MarkedAt: tx.At, BlockHash: tx.BlockHash}
+	var kind, role string
+	decodeField(tx.Data, "fiscal_year", &carryover.FiscalYear)
+	decodeField(tx.Data, "kind", &kind)
+	decodeField(tx.Data, "amount", &carryover.Amount)
+	decodeField(tx.Data, "justification", &carryover.Justification)
+	decodeField(tx.Data, "marked_by", &carryover.MarkedBy)
+	decodeField(tx.Data, "role", &role)
+	carryover.Kind = CarryoverKind(kind)
+
+	contract.Carryovers = append(contract.Carryovers, carryover)
+	sr.audit(contract, tx, CarryoverBlockType, carryover.MarkedBy, AdminRole(role), carryover.description())
+	return nil
+}
+
+// replayAmendment radica una adición o prórroga pendiente de aprobación
+func replayAmendment(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	var id, justification, requestedBy, role string
+	var amount float64
+	decodeField(tx.Data, "amendment_id", &id)
+	decodeField(tx.Data, "additional_amount", &amount)
+	decodeField(tx.Data, "justification", &justification)
+	decodeField(tx.Data, "requested_by", &requestedBy)
+	decodeField(tx.Data, "role", &role)
+	var newEndDate *time.Time
+	var endDate time.Time
+	if decodeField(tx.Data, "new_end_date", &endDate) {
+		newEndDate = &endDate
+	}
+
+	amendment := newAmendment(id, amount, newEndDate, justification, requestedBy, tx.At)
+	amendment.BlockHash = tx.BlockHash
+	contract.Amendments = append(contract.Amendments, amendment)
+	sr.audit(contract, tx, AmendmentBlockType, requestedBy, AdminRole(role), amendment.description())
+	return nil
+}
+
+// replayAmendmentDecision aplica la decisión de un paso del flujo de la modificación
+func replayAmendmentDecision(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	var id, comments, decidedBy, role string
+	var approved bool
+	decodeField(tx.Data, "amendment_id", &id)
+	decodeField(tx.Data, "approved", &approved)
+	decodeField(tx.Data, "comments", &comments)
+	decodeField(tx.Data, "decided_by", &decidedBy)
+	decodeField(tx.Data, "role", &role)
+
+	amendment := contract.Amendment(id)
+	if amendment == nil {
+		return fmt.Errorf("modificación %s no radicada en la cadena", id)
+	}
+	if err := amendment.checkDecision(AdminRole(role)); err != nil {
+		return err
+	}
+	contract.applyAmendmentDecision(amendment, approved, decidedBy, comments, tx.At, tx.BlockHash)
+	sr.audit(contract, tx, AmendmentDecisionBlockType, decidedBy, AdminRole(role), amendmentDecisionDescription(contract, amendment, AdminRole(role), approved))
+	return nil
+}
+
+// replayMilestone agrega un hito al plan de ejecución del contrato
+func replayMilestone(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	milestone := Milestone{Status: MilestonePending, BlockHash: tx.BlockHash}
+	var role string
+	decodeField(tx.Data, "milestone_id", &milestone.ID)
+	decodeField(tx.Data, "description", &milestone.Description)
+	decodeField(tx.Data, "due_date", &milestone.DueDate)
+	decodeField(tx.Data, "amount", &milestone.Amount)
+	decodeField(tx.Data, "registered_by", &milestone.RegisteredBy)
+	decodeField(tx.Data, "role", &role)
+
+	contract.Milestones = append(contract.Milestones, milestone)
+	sr.audit(contract, tx, MilestoneBlockType, milestone.RegisteredBy, AdminRole(role), milestone.description())
+	return nil
+}
+
+// replayMilestoneCompletion marca cumplido un hito del contrato
+func replayMilestoneCompletion(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	var id, evidenceHash, completedBy, role string
+	decodeField(tx.Data, "milestone_id", &id)
+	decodeField(tx.Data, "evidence_hash", &evidenceHash)
+	decodeField(tx.Data, "completed_by", &completedBy)
+	decodeField(tx.Data, "role", &role)
+
+	milestone := contract.Milestone(id)
+	if milestone == nil {
+		return fmt.Errorf("hito %s no registrado en la cadena", id)
+	}
+	contract.completeMilestone(milestone, evidenceHash, completedBy, tx.At)
+	sr.audit(contract, tx, MilestoneCompletionBlockType, completedBy, AdminRole(role), "Hito cumplido: "+milestone.Description)
+	return nil
+}
+
+// replayPaymentAct registra el pago de un hito cumplido
+func replayPaymentAct(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	payment := ContractPayment{BlockHash: tx.BlockHash}
+	var role string
+	decodeField(tx.Data, "payment_id", &payment.ID)
+	decodeField(tx.Data, "milestone_id", &payment.MilestoneID)
+	decodeField(tx.Data, "amount", &payment.Amount)
+	decodeField(tx.Data, "reference", &payment.Reference)
+	decodeField(tx.Data, "paid_at", &payment.PaidAt)
+	decodeField(tx.Data, "registered_by", &payment.RegisteredBy)
+	decodeField(tx.Data, "role", &role)
+
+	milestone := contract.Milestone(payment.MilestoneID)
+	if milestone == nil {
+		return fmt.Errorf("hito %s no registrado en la cadena", payment.MilestoneID)
+	}
+	contract.Payments = append(contract.Payments, payment)
+	milestone.Status = MilestonePaid
+	milestone.PaymentID = payment.ID
+	sr.audit(contract, tx, PaymentActBlockType, payment.RegisteredBy, AdminRole(role), paymentActDescription(&payment))
+	return nil
+}
+
+// replayEvidence agrega la evidencia de ejecución que cargó el supervisor
+func replayEvidence(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	evidence := Evidence{ContractID: contract.ID, UploadedAt: tx.At, BlockHash: tx.BlockHash}
+	var role string
+	decodeField(tx.Data, "evidence_id", &evidence.ID)
+	decodeField(tx.Data, "uploaded_by", &evidence.UploadedBy)
+	decodeField(tx.Data, "media_type", &evidence.MediaType)
+	decodeField(tx.Data, "sha256", &evidence.SHA256)
+	decodeField(tx.Data, "perceptual_hash", &evidence.PerceptualHash)
+	decodeField(tx.Data, "size", &evidence.Size)
+	decodeField(tx.Data, "description", &evidence.Description)
+	decodeField(tx.Data, "role", &role)
+	// Las evidencias anteriores a estos campos solo traen el ID y el hash del archivo
+	if !decodeField(tx.Data, "file_name", &evidence.FileName) {
+		evidence.FileName = evidence.ID
+	}
+
+	contract.Evidence = append(contract.Evidence, evidence)
+	sr.audit(contract, tx, "EXECUTION_EVIDENCE", evidence.UploadedBy, AdminRole(role), "Evidencia de ejecución cargada: "+evidence.FileName)
+	return nil
+}
+
+// replayAttachment registra en la auditoría del contrato el documento adjunto
+func replayAttachment(sr *stateReplay, tx replayTx) error {
+	contract, err := sr.contract(tx)
+	if err != nil {
+		return err
+	}
+	var uploadedBy, role, category string
+	decodeField(tx.Data, "uploaded_by", &uploadedBy)
+	decodeField(tx.Data, "role", &role)
+	decodeField(tx.Data, "category", &category)
+
+	sr.audit(contract, tx, "CONTRACT_ATTACHMENT", uploadedBy, AdminRole(role), "Adjunto cargado: "+category)
+	return nil
+}
+
+// decodeField lee un campo de los datos de una transacción en target. Los datos pueden
+// traer los valores originales (bloques locales) o ya decodificados de JSON (bloques
+// recibidos o restaurados), así que se convierten pasando por JSON. Retorna false si el
+// campo no está o no tiene el tipo esperado.
+func decodeField(data map[string]interface{}, key string, target interface{}) bool {
+	value, exists := data[key]
+	if !exists || value == nil {
+		return false
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(encoded, target) == nil
+}
@@ -0,0 +1,191 @@
+package blockchain
+
+import (
+	"sync"
+	"time"
+)
+
+// Reputación de los peers: cada nodo lleva la cuenta de lo que recibe de sus peers y veta
+// temporalmente a los que envían datos inválidos de forma repetida. Las caídas y fallas de
+// sincronización se registran para diagnóstico, pero no vetan: un peer caído no es malicioso.
+
+// Política de veto por defecto
+const (
+	DefaultBanThreshold = 3         // Bloques o cadenas inválidos que provocan el veto
+	DefaultBanDuration  = time.Hour // Duración del primer veto; se duplica con cada reincidencia
+	maxBanDuration      = 7 * 24 * time.Hour
+)
+
+// Peso de cada evento en el puntaje de reputación (de 0 a 100)
+const (
+	reputationMaxScore   = 100
+	invalidDataPenalty   = 25
+	syncFailurePenalty   = 5
+	healthFailurePenalty = 2
+	validBlockReward     = 1
+	healthCheckReward    = 1
+)
+
+// PeerStats son las estadísticas de comportamiento de un peer
+type PeerStats struct {
+	PeerID             string     `json:"peer_id"`
+	Score              int        `json:"score"`
+	ValidBlocks        int        `json:"valid_blocks"`
+	InvalidBlocks      int        `json:"invalid_blocks"`
+	FailedHealthChecks int        `json:"failed_health_checks"`
+	SyncFailures       int        `json:"sync_failures"`
+	LastInvalidReason  string     `json:"last_invalid_reason,omitempty"`
+	LastInvalidAt      *time.Time `json:"last_invalid_at,omitempty"`
+	Bans               int        `json:"bans"`
+	BannedUntil        *time.Time `json:"banned_until,omitempty"`
+	Banned             bool       `json:"banned"`
+	strikes            int        // Datos inválidos desde el último veto
+}
+
+// PeerReputation lleva las estadísticas de los peers y decide los vetos
+type PeerReputation struct {
+	// BanThreshold es la cantidad de datos inválidos que provocan el veto (0 desactiva los vetos)
+	BanThreshold int
+	// BanDuration es la duración del primer veto
+	BanDuration time.Duration
+	stats       map[string]*PeerStats
+	mutex       sync.Mutex
+}
+
+// NewPeerReputation crea el registro con la política por defecto
+func NewPeerReputation() *PeerReputation {
+	return &PeerReputation{
+		BanThreshold: DefaultBanThreshold,
+		BanDuration:  DefaultBanDuration,
+		stats:        make(map[string]*PeerStats),
+	}
+}
+
+// entry retorna las estadísticas del peer creándolas si no existen; requiere el mutex
+func (pr *PeerReputation) entry(peerID string) *PeerStats {
+	stats, exists := pr.stats[peerID]
+	if !exists {
+		stats = &PeerStats{PeerID: peerID, Score: reputationMaxScore}
+		pr.stats[peerID] = stats
+	}
+	return stats
+}
+
+// adjust suma delta al puntaje manteniéndolo entre 0 y el máximo
+func (stats *PeerStats) adjust(delta int) {
+	stats.Score += delta
+	if stats.Score < 0 {
+		stats.Score = 0
+	}
+	if stats.Score > reputationMaxScore {
+		stats.Score = reputationMaxScore
+	}
+}
+
+// RecordInvalid registra un bloque o cadena inválidos del peer y lo veta si alcanza el
+// umbral; retorna true si el peer quedó vetado
+func (pr *PeerReputation) RecordInvalid(peerID string, reason string) bool {
+	if peerID == "" {
+		return false
+	}
+	pr.mutex.Lock()
+	defer pr.mutex.Unlock()
+
+	now := time.Now()
+	stats := pr.entry(peerID)
+	stats.InvalidBlocks++
+	stats.strikes++
+	stats.LastInvalidReason = reason
+	stats.LastInvalidAt = &now
+	stats.adjust(-invalidDataPenalty)
+
+	if pr.BanThreshold <= 0 || stats.strikes < pr.BanThreshold {
+		return false
+	}
+
+	duration := pr.BanDuration
+	for i := 0; i < stats.Bans && duration < maxBanDuration; i++ {
+		duration *= 2
+	}
+	if duration > maxBanDuration {
+		duration = maxBanDuration
+	}
+	until := now.Add(duration)
+	stats.Bans++
+	stats.strikes = 0
+	stats.BannedUntil = &until
+	logf("⛔ Peer %s vetado hasta %s por enviar datos inválidos: %s\n", peerID, until.Format(time.RFC3339), reason)
+	return true
+}
+
+// RecordValidBlock registra un bloque válido recibido del peer
+func (pr *PeerReputation) RecordValidBlock(peerID string) {
+	pr.mutex.Lock()
+	defer pr.mutex.Unlock()
+	stats := pr.entry(peerID)
+	stats.ValidBlocks++
+	stats.adjust(validBlockReward)
+}
+
+// RecordHealthCheck registra el resultado de la verificación de salud del peer
+func (pr *PeerReputation) RecordHealthCheck(peerID string, healthy bool) {
+	pr.mutex.Lock()
+	defer pr.mutex.Unlock()
+	stats := pr.entry(peerID)
+	if healthy {
+		stats.adjust(healthCheckReward)
+		return
+	}
+	stats.FailedHealthChecks++
+	stats.adjust(-healthFailurePenalty)
+}
+
+// RecordSyncFailure registra que no se pudo sincronizar con el peer
+func (pr *PeerReputation) RecordSyncFailure(peerID string) {
+	pr.mutex.Lock()
+	defer pr.mutex.Unlock()
+	stats := pr.entry(peerID)
+	stats.SyncFailures++
+	stats.adjust(-syncFailurePenalty)
+}
+
+// IsBanned indica si el peer está vetado en este momento
+func (pr *PeerReputation) IsBanned(peerID string) bool {
+	pr.mutex.Lock()
+	defer pr.mutex.Unlock()
+	stats, exists := pr.stats[peerID]
+	return exists && stats.BannedUntil != nil && time.Now().Before(*stats.BannedUntil)
+}
+
+// Unban levanta el veto del peer; retorna false si no estaba vetado
+func (pr *PeerReputation) Unban(peerID string) bool {
+	pr.mutex.Lock()
+	defer pr.mutex.Unlock()
+	stats, exists := pr.stats[peerID]
+	if !exists || stats.BannedUntil == nil || !time.Now().Before(*stats.BannedUntil) {
+		return false
+	}
+	stats.BannedUntil = nil
+	stats.strikes = 0
+	logf("✅ Veto del peer %s levantado\n", peerID)
+	return true
+}
+
+// Stats retorna una copia de las estadísticas del peer
+func (pr *PeerReputation) Stats(peerID string) (PeerStats, bool) {
+	pr.mutex.Lock()
+	defer pr.mutex.Unlock()
+	stats, exists := pr.stats[peerID]
+	if !exists {
+		return PeerStats{PeerID: peerID, Score: reputationMaxScore}, false
+	}
+	copied := *stats
+	copied.Banned = copied.BannedUntil != nil && time.Now().Before(*copied.BannedUntil)
+	return copied, true
+}
+
+// rejectInvalidBlock guarda el bloque en cuarentena y descuenta la reputación del remitente
+func (p2p *P2PNetwork) rejectInvalidBlock(block Block, sender string, reason string) {
+	p2p.Quarantine.Add(block, sender, reason)
+	p2p.Reputation.RecordInvalid(sender, reason)
+}
@@ -0,0 +1,280 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+
+	"github.com/google/uuid"
+)
+
+// Replicación selectiva de contratos reservados (p. ej. de defensa): sus bloques completos
+// solo viajan a los nodos autorizados (DNP, entes de control). Los demás reciben un bloque
+// "retenido" con el encabezado firmado y el hash, sin datos, transacciones ni estado, que
+// basta para mantener la cadena enlazada. Un nodo autorizado que solo tiene el encabezado
+// puede pedir el bloque completo a otro nodo autorizado; cada entrega queda registrada.
+
+// Errores de la entrega de bloques reservados
+var (
+	ErrReservedNotAuthorized = errors.New("nodo no autorizado para contratos reservados")
+	ErrReservedNotAvailable  = errors.New("ningún nodo autorizado entregó el bloque completo")
+)
+
+// ReservedAccess registra una entrega (o un intento) del contenido de un bloque reservado
+type ReservedAccess struct {
+	ID        string    `json:"id"`
+	BlockHash string    `json:"block_hash"`
+	Requester string    `json:"requester"`
+	Kind      string    `json:"kind"` // "node" para peers, "user" para consultas de la API
+	Granted   bool      `json:"granted"`
+	Reason    string    `json:"reason,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// ReservedPolicy define qué nodos reciben los bloques reservados completos y lleva el
+// registro de accesos
+type ReservedPolicy struct {
+	blockchain *Blockchain
+	authorized map[string]bool
+	accesses   []ReservedAccess
+	mutex      sync.RWMutex
+}
+
+// NewReservedPolicy crea la política sin nodos autorizados y carga el registro de accesos
+func NewReservedPolicy(bc *Blockchain) *ReservedPolicy {
+	rp := &ReservedPolicy{
+		blockchain: bc,
+		authorized: make(map[string]bool),
+	}
+	bc.store.ForEach(storage.BucketReservedAccess, func(key string, value []byte) error {
+		var access ReservedAccess
+		if err := json.Unmarshal(value, &access); err == nil {
+			rp.accesses = append(rp.accesses, access)
+		}
+		return nil
+	})
+	sort.Slice(rp.accesses, func(i, j int) bool { return rp.accesses[i].At.Before(rp.accesses[j].At) })
+	return rp
+}
+
+// SetAuthorizedNodes reemplaza la lista de nodos que reciben los bloques reservados completos
+func (rp *ReservedPolicy) SetAuthorizedNodes(nodeIDs []string) {
+	authorized := make(map[string]bool, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		if nodeID != "" {
+			authorized[nodeID] = true
+		}
+	}
+	rp.mutex.Lock()
+	rp.authorized = authorized
+	rp.mutex.Unlock()
+}
+
+// AuthorizedNodes retorna los nodos autorizados, ordenados
+func (rp *ReservedPolicy) AuthorizedNodes() []string {
+	rp.mutex.RLock()
+	defer rp.mutex.RUnlock()
+	nodes := make([]string, 0, len(rp.authorized))
+	for nodeID := range rp.authorized {
+		nodes = append(nodes, nodeID)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// IsAuthorized indica si el nodo recibe los bloques reservados completos
+func (rp *ReservedPolicy) IsAuthorized(nodeID string) bool {
+	rp.mutex.RLock()
+	defer rp.mutex.RUnlock()
+	return rp.authorized[nodeID]
+}
+
+// LogAccess registra la entrega o el rechazo del contenido de un bloque reservado
+func (rp *ReservedPolicy) LogAccess(blockHash string, requester string, kind string, granted bool, reason string) {
+	access := ReservedAccess{
+		ID:        uuid.New().String(),
+		BlockHash: blockHash,
+		Requester: requester,
+		Kind:      kind,
+		Granted:   granted,
+		Reason:    reason,
+		At:        time.Now(),
+	}
+	rp.mutex.Lock()
+	rp.accesses = append(rp.accesses, access)
+	rp.mutex.Unlock()
+	rp.blockchain.saveState(storage.BucketReservedAccess, access.ID, access)
+
+	if granted {
+		logf("🔐 Bloque reservado %s entregado a %s\n", blockHash, requester)
+	} else {
+		logf("🔐 Bloque reservado %s negado a %s: %s\n", blockHash, requester, reason)
+	}
+}
+
+// AccessLog retorna los accesos registrados, opcionalmente de un bloque
+func (rp *ReservedPolicy) AccessLog(blockHash string) []ReservedAccess {
+	rp.mutex.RLock()
+	defer rp.mutex.RUnlock()
+	accesses := []ReservedAccess{}
+	for _, access := range rp.accesses {
+		if blockHash == "" || access.BlockHash == blockHash {
+			accesses = append(accesses, access)
+		}
+	}
+	return accesses
+}
+
+// IsReservedBlock indica si el bloque toca algún contrato reservado o ya es un encabezado retenido
+func (bc *Blockchain) IsReservedBlock(block *Block) bool {
+	return block.Withheld || bc.isReservedData(block.Data)
+}
+
+// isReservedData indica si los datos de un bloque o de una transacción del mempool tocan
+// algún contrato reservado
+func (bc *Blockchain) isReservedData(data map[string]interface{}) bool {
+	if withheld, _ := data["withheld"].(bool); withheld {
+		return true
+	}
+	for _, entry := range eventData(data) {
+		if reserved, _ := entry["reserved"].(bool); reserved {
+			return true
+		}
+		if contractID, ok := entry["contract_id"].(string); ok {
+			if contract, exists := bc.Contract(contractID); exists && contract.Reserved {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withhold retorna el encabezado firmado del bloque, sin datos, transacciones ni estado
+func withhold(block Block) Block {
+	return Block{
+		Index:        block.Index,
+		Timestamp:    block.Timestamp,
+		PreviousHash: block.PreviousHash,
+		Hash:         block.Hash,
+		Nonce:        block.Nonce,
+		Type:         block.Type,
+		Withheld:     true,
+		Signature:    block.Signature,
+		SignerNodeID: block.SignerNodeID,
+		SignerKeyID:  block.SignerKeyID,
+		MerkleRoot:   block.MerkleRoot,
+		HashVersion:  block.HashVersion,
+	}
+}
+
+// BlockFor retorna el bloque tal como se le entrega al nodo: completo si no es reservado o
+// si el nodo está autorizado, retenido en otro caso
+func (p2p *P2PNetwork) BlockFor(nodeID string, block Block) Block {
+	if block.Withheld || !p2p.Blockchain.IsReservedBlock(&block) || p2p.Reserved.IsAuthorized(nodeID) {
+		return block
+	}
+	return withhold(block)
+}
+
+// BlocksFor aplica BlockFor a cada bloque
+func (p2p *P2PNetwork) BlocksFor(nodeID string, blocks []Block) []Block {
+	delivered := make([]Block, len(blocks))
+	for i, block := range blocks {
+		delivered[i] = p2p.BlockFor(nodeID, block)
+	}
+	return delivered
+}
+
+// ReservedBlock entrega a un nodo el bloque completo con el hash dado (el propio o el del
+// autor si llegó retransmitido) y registra el acceso
+func (p2p *P2PNetwork) ReservedBlock(hash string, nodeID string) (*Block, error) {
+	if !p2p.Reserved.IsAuthorized(nodeID) {
+		p2p.Reserved.LogAccess(hash, nodeID, "node", false, ErrReservedNotAuthorized.Error())
+		return nil, ErrReservedNotAuthorized
+	}
+	block, err := p2p.Blockchain.BlockByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if block.Withheld || isWithheldCopy(block) {
+		return nil, fmt.Errorf("este nodo solo tiene el encabezado del bloque %s", hash)
+	}
+	p2p.Reserved.LogAccess(hash, nodeID, "node", true, "")
+	return block, nil
+}
+
+// RetrieveReservedBlock obtiene el bloque completo con el hash dado: el local si lo tiene o,
+// si solo tiene el encabezado, el de un peer autorizado. Los contratos que sus transacciones
+// dejan más adelante que la copia local se ponen en vigencia.
+func (p2p *P2PNetwork) RetrieveReservedBlock(hash string) (*Block, error) {
+	if local, err := p2p.Blockchain.BlockByHash(hash); err == nil && !local.Withheld && !isWithheldCopy(local) {
+		return local, nil
+	}
+	if !p2p.Reserved.IsAuthorized(p2p.NodeID) {
+		return nil, ErrReservedNotAuthorized
+	}
+
+	for _, peer := range p2p.GetActivePeers() {
+		if !p2p.Reserved.IsAuthorized(peer.ID) {
+			continue
+		}
+		block, err := p2p.requestReservedBlock(peer, hash)
+		if err != nil {
+			logf("⚠️ %s no entregó el bloque reservado %s: %v\n", peer.ID, hash, err)
+			continue
+		}
+		if !block.IsValid() || (block.Hash != hash && originHash(block) != hash) {
+			p2p.Reputation.RecordInvalid(peer.ID, "bloque reservado que no corresponde al hash pedido")
+			continue
+		}
+		for id, contract := range p2p.Blockchain.deriveBlockState(block).contracts {
+			if local, exists := p2p.Blockchain.Contract(id); !exists || contract.Sequence > local.Sequence {
+				p2p.Blockchain.putContract(contract)
+				p2p.Blockchain.storeContract(contract)
+			}
+		}
+		return block, nil
+	}
+	return nil, ErrReservedNotAvailable
+}
+
+// requestReservedBlock pide a un peer el bloque reservado completo
+func (p2p *P2PNetwork) requestReservedBlock(peer *Peer, hash string) (*Block, error) {
+	resp, err := p2p.peerGet(peer, "/api/p2p/reserved/"+hash, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer respondió con status %d", resp.StatusCode)
+	}
+	var block Block
+	if err := json.NewDecoder(resp.Body).Decode(&block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// peerGet hace un GET a una ruta del peer identificándose con X-Node-ID, para que el peer
+// sepa a quién entrega los bloques reservados
+func (p2p *P2PNetwork) peerGet(peer *Peer, path string, timeout time.Duration) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, p2p.peerURL(peer, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Node-ID", p2p.NodeID)
+	return p2p.peerClient(timeout).Do(req)
+}
+
+// isWithheldCopy indica si el bloque local es la retransmisión de un encabezado retenido
+func isWithheldCopy(block *Block) bool {
+	withheld, _ := block.Data["withheld"].(bool)
+	return withheld
+}
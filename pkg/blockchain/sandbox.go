@@ -0,0 +1,177 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Modo sandbox para los integradores: el nodo corre aislado de la red sobre una cadena
+// propia con contratos sintéticos, que se reinicia periódicamente. Las escrituras
+// funcionan igual que en producción pero nunca salen del nodo.
+
+// ErrNetworkIsolated indica que el nodo está aislado y no acepta peers
+var ErrNetworkIsolated = errors.New("el nodo está aislado de la red (sandbox)")
+
+// Valores por defecto del sandbox
+const (
+	DefaultSandboxContracts   = 25
+	DefaultSandboxResetPeriod = 24 * time.Hour
+)
+
+// sandboxEntities son las entidades a las que se atribuyen los contratos sintéticos
+var sandboxEntities = []struct {
+	Code string
+	Name string
+	Mail string
+}{
+	{"05001", "Alcaldía de Medellín (sandbox)", "contratacion@sandbox.medellin.gov.co"},
+	{"11001", "Secretaría de Educación de Bogotá (sandbox)", "compras@sandbox.educacionbogota.edu.co"},
+	{"76001", "Gobernación del Valle del Cauca (sandbox)", "contratos@sandbox.valledelcauca.gov.co"},
+	{"08001", "Alcaldía de Barranquilla (sandbox)", "obras@sandbox.barranquilla.gov.co"},
+	{"68001", "Alcaldía de Bucaramanga (sandbox)", "juridica@sandbox.bucaramanga.gov.co"},
+}
+
+// sandboxObjects son objetos contractuales de ejemplo por tipo de contrato, con el rango de montos
+var sandboxObjects = []struct {
+	ContractType string
+	Description  string
+	MinAmount    float64
+	MaxAmount    float64
+}{
+	{"OBRA_PUBLICA", "Construcción de puente peatonal", 800000000, 4000000000},
+	{"OBRA_PUBLICA", "Pavimentación de vías terciarias", 1500000000, 9000000000},
+	{"SUMINISTRO", "Adquisición de computadores para colegios públicos", 200000000, 1200000000},
+	{"SUMINISTRO", "Suministro de medicamentos para la red hospitalaria", 300000000, 2000000000},
+	{"PRESTACION_SERVICIOS", "Prestación de servicios profesionales de apoyo jurídico", 30000000, 120000000},
+	{"PRESTACION_SERVICIOS", "Interventoría técnica de obras de infraestructura", 150000000, 700000000},
+	{"CONTRATACION_DIRECTA", "Convenio interadministrativo de transporte escolar", 100000000, 900000000},
+	{"MINIMA_CUANTIA", "Compra de elementos de aseo y cafetería", 5000000, 40000000},
+}
+
+// SandboxStatus es el estado del sandbox que se publica en /api/health
+type SandboxStatus struct {
+	Enabled    bool       `json:"enabled"`
+	Contracts  int        `json:"synthetic_contracts"`
+	ResetEvery string     `json:"reset_every"` // "0s" si no se reinicia
+	LastReset  time.Time  `json:"last_reset"`
+	NextReset  *time.Time `json:"next_reset,omitempty"`
+	Resets     int        `json:"resets"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// Sandbox mantiene la cadena sintética de un nodo aislado
+type Sandbox struct {
+	blockchain *Blockchain
+	// Contracts es la cantidad de contratos sintéticos que se crean en cada reinicio
+	Contracts int
+	// ResetEvery es cada cuánto se reinicia la cadena (0 nunca)
+	ResetEvery time.Duration
+	lastReset  time.Time
+	resets     int
+	lastError  string
+	mutex      sync.Mutex
+}
+
+// NewSandbox aísla la red del nodo y prepara el sandbox sobre su cadena. La cadena debe
+// vivir solo en memoria: Reset la reemplaza por el bloque génesis.
+func NewSandbox(bc *Blockchain, network *P2PNetwork) *Sandbox {
+	network.Isolate()
+	return &Sandbox{
+		blockchain: bc,
+		Contracts:  DefaultSandboxContracts,
+		ResetEvery: DefaultSandboxResetPeriod,
+	}
+}
+
+// Reset deja la cadena solo con el bloque génesis y vuelve a crear los contratos sintéticos
+func (s *Sandbox) Reset() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.reset()
+	s.lastReset = time.Now()
+	s.resets++
+	s.lastError = ""
+	if err != nil {
+		s.lastError = err.Error()
+		logf("❌ Error reiniciando el sandbox: %v\n", err)
+		return err
+	}
+	logf("🧪 Sandbox reiniciado con %d contratos sintéticos\n", s.Contracts)
+	return nil
+}
+
+func (s *Sandbox) reset() error {
+	genesis, err := s.blockchain.BlockAt(0)
+	if err != nil {
+		return err
+	}
+	if err := s.blockchain.ReplaceChain([]*Block{genesis}); err != nil {
+		return err
+	}
+	if err := s.blockchain.ReplayState(); err != nil {
+		return err
+	}
+	s.blockchain.Operations.clear()
+	return s.seed()
+}
+
+// seed crea los contratos sintéticos; la semilla fija hace que cada reinicio produzca los
+// mismos contratos, así los integradores pueden escribir pruebas contra ellos
+func (s *Sandbox) seed() error {
+	random := rand.New(rand.NewSource(int64(s.Contracts)))
+	for i := 0; i < s.Contracts; i++ {
+		entity := sandboxEntities[random.Intn(len(sandboxEntities))]
+		object := sandboxObjects[random.Intn(len(sandboxObjects))]
+		amount := object.MinAmount + random.Float64()*(object.MaxAmount-object.MinAmount)
+
+		contract := &Contract{
+			EntityCode:   entity.Code,
+			EntityName:   entity.Name,
+			ContractType: object.ContractType,
+			Description:  fmt.Sprintf("%s - proceso sintético %03d", object.Description, i+1),
+			Amount:       float64(int64(amount/1000000)) * 1000000,
+			CreatedBy:    entity.Mail,
+		}
+		if err := s.blockchain.AddContract(contract); err != nil {
+			return fmt.Errorf("error creando contrato sintético %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Status retorna el estado del sandbox
+func (s *Sandbox) Status() SandboxStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	status := SandboxStatus{
+		Enabled:    true,
+		Contracts:  s.Contracts,
+		ResetEvery: s.ResetEvery.String(),
+		LastReset:  s.lastReset,
+		Resets:     s.resets,
+		LastError:  s.lastError,
+	}
+	if s.ResetEvery > 0 && !s.lastReset.IsZero() {
+		next := s.lastReset.Add(s.ResetEvery)
+		status.NextReset = &next
+	}
+	return status
+}
+
+// Run reinicia la cadena cada ResetEvery; con 0 no hace nada
+func (s *Sandbox) Run() {
+	if s.ResetEvery <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.ResetEvery)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.Reset()
+	}
+}
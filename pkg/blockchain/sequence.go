@@ -0,0 +1,114 @@
+package blockchain
+
+import "fmt"
+
+// transactionSequence extrae el contrato y el número de secuencia de los datos de un
+// bloque. Los bloques recibidos de peers traen los datos originales anidados en "data".
+func transactionSequence(data map[string]interface{}) (string, int, bool) {
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+
+	contractID, _ := data["contract_id"].(string)
+	if contractID == "" {
+		return "", 0, false
+	}
+	switch sequence := data["sequence"].(type) {
+	case int:
+		return contractID, sequence, true
+	case float64:
+		return contractID, int(sequence), true
+	}
+	return contractID, 0, false
+}
+
+// stampSequence asigna a las transacciones de contrato el siguiente número de secuencia
+// si aún no lo tienen
+func (bc *Blockchain) stampSequence(data map[string]interface{}) {
+	if _, nested := data["data"].(map[string]interface{}); nested {
+		return
+	}
+	for _, entry := range eventData(data) {
+		contractID, _, stamped := transactionSequence(entry)
+		if contractID == "" || stamped {
+			continue
+		}
+		entry["sequence"] = bc.lastSequence(contractID) + 1
+	}
+}
+
+// checkSequence verifica que cada transacción sea la siguiente de su contrato, para que
+// bloques duplicados o fuera de orden no alteren la progresión de sus pasos
+func (bc *Blockchain) checkSequence(data map[string]interface{}) error {
+	for _, entry := range eventData(data) {
+		contractID, sequence, ok := transactionSequence(entry)
+		if !ok {
+			continue
+		}
+
+		expected := bc.lastSequence(contractID) + 1
+		if sequence < expected {
+			return fmt.Errorf("transacción duplicada para el contrato %s: secuencia %d ya aplicada", contractID, sequence)
+		}
+		if sequence > expected {
+			return fmt.Errorf("transacción fuera de orden para el contrato %s: se esperaba la secuencia %d y llegó la %d", contractID, expected, sequence)
+		}
+	}
+	return nil
+}
+
+// applySequence registra la secuencia de las transacciones ya agregadas a la cadena
+func (bc *Blockchain) applySequence(data map[string]interface{}) {
+	for _, entry := range eventData(data) {
+		contractID, sequence, ok := transactionSequence(entry)
+		if !ok {
+			continue
+		}
+		bc.mutex.Lock()
+		bc.sequences[contractID] = sequence
+		bc.mutex.Unlock()
+		bc.setContractSequence(contractID, sequence)
+	}
+}
+
+// rebuildSequences recalcula la última secuencia de cada contrato a partir de la cadena
+func (bc *Blockchain) rebuildSequences() {
+	sequences := make(map[string]int)
+	for _, block := range bc.Blocks() {
+		for _, entry := range eventData(block.Data) {
+			if contractID, sequence, ok := transactionSequence(entry); ok {
+				sequences[contractID] = sequence
+			}
+		}
+	}
+
+	bc.mutex.Lock()
+	bc.sequences = sequences
+	bc.mutex.Unlock()
+
+	for contractID, sequence := range sequences {
+		bc.setContractSequence(contractID, sequence)
+	}
+}
+
+// setContractSequence pone en vigencia una copia del contrato con la secuencia dada; el
+// contrato vigente no se modifica porque otros lo pueden estar leyendo
+func (bc *Blockchain) setContractSequence(contractID string, sequence int) {
+	contract, exists := bc.Contract(contractID)
+	if !exists || contract.Sequence == sequence {
+		return
+	}
+	updated, err := cloneContract(contract)
+	if err != nil {
+		return
+	}
+	updated.Sequence = sequence
+	bc.putContract(updated)
+}
+
+// lastSequence retorna la última secuencia aplicada al contrato
+func (bc *Blockchain) lastSequence(contractID string) int {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	return bc.sequences[contractID]
+}
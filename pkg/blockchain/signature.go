@@ -0,0 +1,38 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SetSigner configura la identidad con la que el nodo firma los bloques que agrega a la cadena
+func (bc *Blockchain) SetSigner(nodeID string, keys *NodeKeyring) {
+	bc.signerID = nodeID
+	bc.signerKeys = keys
+}
+
+// signBlock firma el hash del bloque con la llave activa del nodo; la firma no forma
+// parte del hash, que ya cubre todo el contenido del bloque
+func (bc *Blockchain) signBlock(block *Block) {
+	if bc.signerKeys == nil {
+		return
+	}
+	block.SignerKeyID, block.Signature = bc.signerKeys.Sign([]byte(block.Hash))
+	block.SignerNodeID = bc.signerID
+}
+
+// VerifyBlockSignature verifica que el bloque esté firmado por un nodo conocido,
+// usando las llaves públicas de cada validador (incluidas las retiradas)
+func VerifyBlockSignature(block Block, validatorKeys map[string][]PublicKeyInfo) error {
+	if block.Signature == "" || block.SignerNodeID == "" {
+		return errors.New("bloque sin firma")
+	}
+	keys, known := validatorKeys[block.SignerNodeID]
+	if !known {
+		return fmt.Errorf("nodo firmante desconocido: %s", block.SignerNodeID)
+	}
+	if err := VerifyWithKeys(keys, block.SignerKeyID, []byte(block.Hash), block.Signature); err != nil {
+		return fmt.Errorf("firma del bloque inválida: %v", err)
+	}
+	return nil
+}
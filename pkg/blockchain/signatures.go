@@ -0,0 +1,79 @@
+package blockchain
+
+// Firmas ya usadas: una decisión de validación o un payload firmado por el sistema de una
+// entidad se procesa una sola vez. El registro se deriva de la cadena (las firmas viajan en
+// los bloques VALIDATION y CONTRACT_CREATION), así que sobrevive a un reinicio y es el mismo
+// en todos los nodos.
+
+// blockSignatures retorna las firmas que consumen las transacciones de un bloque
+func blockSignatures(data map[string]interface{}) []string {
+	var signatures []string
+	for _, entry := range eventData(data) {
+		var signature string
+		switch entry["type"] {
+		case "VALIDATION":
+			signature, _ = entry["signature"].(string)
+		case "CONTRACT_CREATION":
+			signature, _ = entry["origin_signature"].(string)
+		}
+		if signature != "" {
+			signatures = append(signatures, signature)
+		}
+	}
+	return signatures
+}
+
+// claimSignature reserva la firma para la transacción en curso y retorna false si ya fue
+// usada o si otra petición la tiene reservada. Si la transacción no llega a la cadena la
+// reserva se libera con releaseSignature.
+func (bc *Blockchain) claimSignature(signature string) bool {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	if bc.usedSignatures[signature] {
+		return false
+	}
+	bc.usedSignatures[signature] = true
+	return true
+}
+
+// releaseSignature libera la reserva de una firma cuya transacción falló
+func (bc *Blockchain) releaseSignature(signature string) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	delete(bc.usedSignatures, signature)
+}
+
+// applySignatures marca como usadas las firmas de un bloque ya agregado a la cadena,
+// incluidos los recibidos de peers
+func (bc *Blockchain) applySignatures(data map[string]interface{}) {
+	signatures := blockSignatures(data)
+	if len(signatures) == 0 {
+		return
+	}
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	for _, signature := range signatures {
+		bc.usedSignatures[signature] = true
+	}
+}
+
+// rebuildSignatures recalcula las firmas usadas a partir de la cadena completa, incluidos
+// los bloques archivados
+func (bc *Blockchain) rebuildSignatures() {
+	chain, err := bc.FullChain()
+	if err != nil {
+		logf("⚠️ No se pudieron leer los bloques archivados; las firmas usadas se toman de la cadena en memoria: %v\n", err)
+		chain = bc.Blocks()
+	}
+
+	used := make(map[string]bool)
+	for _, block := range chain {
+		for _, signature := range blockSignatures(block.Data) {
+			used[signature] = true
+		}
+	}
+
+	bc.mutex.Lock()
+	bc.usedSignatures = used
+	bc.mutex.Unlock()
+}
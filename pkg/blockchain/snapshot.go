@@ -0,0 +1,135 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SnapshotVersion es la versión del formato de snapshot
+const SnapshotVersion = 1
+
+// Snapshot representa una copia de la cadena y su estado para respaldar un nodo o
+// arrancar uno nuevo sin sincronizar desde el génesis. Si From es mayor que cero el
+// snapshot es incremental y solo trae los bloques desde esa altura. El estado viaja como
+// referencia para quien lo consulte; al restaurar se deriva de los bloques.
+type Snapshot struct {
+	Version       int                         `json:"version"`
+	CreatedAt     time.Time                   `json:"created_at"`
+	From          int                         `json:"from"`
+	Height        int                         `json:"height"`
+	TipHash       string                      `json:"tip_hash"`
+	Blocks        []*Block                    `json:"blocks"`
+	Contracts     map[string]*Contract        `json:"contracts"`
+	Suppliers     map[string]*Supplier        `json:"suppliers"`
+	SystemKeys    map[string]*EntitySystemKey `json:"system_keys"`
+	ValidatorKeys map[string]*ValidatorKey    `json:"validator_keys"`
+}
+
+// Snapshot exporta la cadena desde la altura from (0 para la cadena completa) junto con el estado actual
+func (bc *Blockchain) Snapshot(from int) (*Snapshot, error) {
+	if from < 0 || from > bc.Len() {
+		return nil, fmt.Errorf("altura inicial %d fuera de rango (0-%d)", from, bc.Len())
+	}
+
+	chain, err := bc.FullChain()
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]*Block, len(chain)-from)
+	copy(blocks, chain[from:])
+
+	suppliers, systemKeys, validatorKeys := bc.registryMaps()
+	return &Snapshot{
+		Version:       SnapshotVersion,
+		CreatedAt:     time.Now(),
+		From:          from,
+		Height:        bc.Len(),
+		TipHash:       bc.TipHash(),
+		Blocks:        blocks,
+		Contracts:     bc.contractMap(),
+		Suppliers:     suppliers,
+		SystemKeys:    systemKeys,
+		ValidatorKeys: validatorKeys,
+	}, nil
+}
+
+// Restore importa un snapshot. Uno completo reemplaza la cadena; uno incremental debe
+// enlazar con la cadena local y solo agrega los bloques que faltan. Los contratos y
+// registros que trae el snapshot no se copian: se derivan de la cadena verificada. Los
+// bloques importados deben estar firmados con alguna de las llaves conocidas (keys).
+func (bc *Blockchain) Restore(snapshot *Snapshot, keys map[string][]PublicKeyInfo) error {
+	chain, err := bc.snapshotChain(snapshot, keys)
+	if err != nil {
+		return err
+	}
+
+	if err := bc.ReplaceChain(chain); err != nil {
+		return err
+	}
+	if err := bc.ReplayState(); err != nil {
+		return err
+	}
+
+	logf("📦 Snapshot restaurado: %d bloques, %d contratos\n", bc.Len(), bc.ContractCount())
+	return nil
+}
+
+// VerifySnapshot comprueba que un snapshot se podría restaurar sobre esta cadena, sin aplicarlo
+func (bc *Blockchain) VerifySnapshot(snapshot *Snapshot, keys map[string][]PublicKeyInfo) error {
+	_, err := bc.snapshotChain(snapshot, keys)
+	return err
+}
+
+// snapshotChain valida un snapshot y arma la cadena completa que resultaría de restaurarlo
+func (bc *Blockchain) snapshotChain(snapshot *Snapshot, keys map[string][]PublicKeyInfo) ([]*Block, error) {
+	if snapshot.Version != SnapshotVersion {
+		return nil, fmt.Errorf("versión de snapshot no soportada: %d", snapshot.Version)
+	}
+	if snapshot.From < 0 || snapshot.From+len(snapshot.Blocks) != snapshot.Height {
+		return nil, errors.New("snapshot inconsistente: la cantidad de bloques no corresponde a la altura")
+	}
+	if len(snapshot.Blocks) > 0 && snapshot.Blocks[len(snapshot.Blocks)-1].Hash != snapshot.TipHash {
+		return nil, errors.New("snapshot inconsistente: el último bloque no corresponde al hash de la punta")
+	}
+
+	var chain []*Block
+	if snapshot.From == 0 {
+		chain = snapshot.Blocks
+	} else {
+		if snapshot.From > bc.Len() {
+			return nil, fmt.Errorf("snapshot incremental desde la altura %d, pero la cadena local solo tiene %d bloques", snapshot.From, bc.Len())
+		}
+		if snapshot.Height <= bc.Len() {
+			return nil, errors.New("el snapshot incremental no trae bloques nuevos")
+		}
+		local, err := bc.FullChain()
+		if err != nil {
+			return nil, err
+		}
+		chain = make([]*Block, 0, snapshot.Height)
+		chain = append(chain, local[:snapshot.From]...)
+		chain = append(chain, snapshot.Blocks...)
+	}
+
+	if err := newIntegrityVerifier().Verify(chain); err != nil {
+		return nil, fmt.Errorf("el snapshot no es válido: %v", err)
+	}
+	// Los bloques locales ya se verificaron al agregarse; los importados, en cualquier modo
+	// de consenso, deben venir firmados
+	checkSigner := checkBlockSigner(keys)
+	for i := snapshot.From; i < len(chain); i++ {
+		if err := checkSigner(chain, i); err != nil {
+			return nil, fmt.Errorf("el snapshot no es válido: bloque %d: %v", i, err)
+		}
+	}
+	if err := bc.verifyAuthorityChain(chain); err != nil {
+		return nil, fmt.Errorf("el snapshot no cumple el consenso por autoridad: %v", err)
+	}
+	for i := range chain {
+		if chain[i].Index != i {
+			return nil, fmt.Errorf("el snapshot no es válido: bloque en la posición %d tiene índice %d", i, chain[i].Index)
+		}
+	}
+	return chain, nil
+}
@@ -0,0 +1,92 @@
+package blockchain
+
+import "sync"
+
+// State guarda los contratos derivados de la cadena. La blockchain siempre accede a los
+// contratos a través de él, de modo que se puede reemplazar por otra implementación (otro
+// backend de almacenamiento o una falsa en pruebas) sin tocar a quienes lo usan.
+//
+// Las reconstrucciones completas (recuperar el WAL, restaurar un snapshot, adoptar la
+// cadena de un peer) no modifican el estado vigente: arman uno nuevo aparte y lo
+// intercambian de una sola vez, así los lectores nunca ven un estado a medio reconstruir.
+type State interface {
+	// Get retorna el contrato con el ID dado
+	Get(contractID string) (*Contract, bool)
+	// Put guarda o reemplaza el contrato
+	Put(contract *Contract)
+	// Delete elimina el contrato
+	Delete(contractID string)
+	// Iterate recorre los contratos hasta que fn retorne false; el orden no está definido
+	Iterate(fn func(contract *Contract) bool)
+	// Len retorna la cantidad de contratos
+	Len() int
+	// Snapshot retorna una copia del mapa de contratos que no cambia con escrituras posteriores
+	Snapshot() map[string]*Contract
+	// Restore reemplaza todo el contenido por los contratos dados
+	Restore(contracts map[string]*Contract)
+}
+
+// memoryState es el State en memoria que usa la blockchain por defecto
+type memoryState struct {
+	contracts map[string]*Contract
+	mutex     sync.RWMutex
+}
+
+// NewMemoryState crea un estado vacío en memoria
+func NewMemoryState() State {
+	return &memoryState{contracts: make(map[string]*Contract)}
+}
+
+func (ms *memoryState) Get(contractID string) (*Contract, bool) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	contract, exists := ms.contracts[contractID]
+	return contract, exists
+}
+
+func (ms *memoryState) Put(contract *Contract) {
+	ms.mutex.Lock()
+	ms.contracts[contract.ID] = contract
+	ms.mutex.Unlock()
+}
+
+func (ms *memoryState) Delete(contractID string) {
+	ms.mutex.Lock()
+	delete(ms.contracts, contractID)
+	ms.mutex.Unlock()
+}
+
+// Iterate recorre una copia para que fn pueda escribir en el estado sin bloquearse
+func (ms *memoryState) Iterate(fn func(contract *Contract) bool) {
+	for _, contract := range ms.Snapshot() {
+		if !fn(contract) {
+			return
+		}
+	}
+}
+
+func (ms *memoryState) Len() int {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	return len(ms.contracts)
+}
+
+func (ms *memoryState) Snapshot() map[string]*Contract {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	contracts := make(map[string]*Contract, len(ms.contracts))
+	for id, contract := range ms.contracts {
+		contracts[id] = contract
+	}
+	return contracts
+}
+
+func (ms *memoryState) Restore(contracts map[string]*Contract) {
+	restored := make(map[string]*Contract, len(contracts))
+	for id, contract := range contracts {
+		restored[id] = contract
+	}
+	ms.mutex.Lock()
+	ms.contracts = restored
+	ms.mutex.Unlock()
+}
@@ -0,0 +1,88 @@
+package blockchain
+
+import "encoding/json"
+
+// Estado de los bloques recibidos: un peer no envía contratos ni registros, solo bloques.
+// El nodo aplica las transacciones del bloque (ver replay.go) a copias de lo que tocan y las
+// pone en vigencia cuando el bloque entra a la cadena; así solo cuenta lo que está bajo el
+// hash y la firma del bloque original, y el resultado es el mismo que el de ReplayState.
+
+// cloneContract copia el contrato en profundidad, para que la copia que se modifica y el
+// contrato vigente no compartan slices
+func cloneContract(contract *Contract) (*Contract, error) {
+	encoded, err := json.Marshal(contract)
+	if err != nil {
+		return nil, err
+	}
+	var clone Contract
+	if err := json.Unmarshal(encoded, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// blockContractIDs retorna los contratos que tocan las transacciones del bloque, sin repetir
+func blockContractIDs(data map[string]interface{}) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, entry := range eventData(data) {
+		contractID, ok := entry["contract_id"].(string)
+		if !ok || contractID == "" || seen[contractID] {
+			continue
+		}
+		seen[contractID] = true
+		ids = append(ids, contractID)
+	}
+	return ids
+}
+
+// deriveBlockState aplica las transacciones de un bloque recibido a copias de los contratos
+// y registros que tocan; el estado vigente no cambia hasta commitDerived
+func (bc *Blockchain) deriveBlockState(block *Block) *stateReplay {
+	sr := newStateReplay(bc.WorkflowManager)
+	for _, entry := range eventData(block.Data) {
+		if contractID, ok := entry["contract_id"].(string); ok && sr.contracts[contractID] == nil {
+			if contract, exists := bc.Contract(contractID); exists {
+				if clone, err := cloneContract(contract); err == nil {
+					sr.contracts[contractID] = clone
+				}
+			}
+		}
+		if nit, ok := entry["nit"].(string); ok && sr.suppliers[nit] == nil {
+			if supplier, exists := bc.supplier(nit); exists {
+				copied := *supplier
+				copied.Sanctions = append([]Sanction(nil), supplier.Sanctions...)
+				sr.suppliers[nit] = &copied
+			}
+		}
+		if keyID, ok := entry["validator_key_id"].(string); ok {
+			if key, exists := bc.validatorKey(keyID); exists {
+				sr.validatorKeys[keyID] = key
+			}
+		}
+	}
+	sr.applyBlock(block)
+	return sr
+}
+
+// commitDerived pone en vigencia el estado derivado de un bloque ya agregado a la cadena;
+// appendNewBlock lo guarda con los cambios de estado del bloque
+func (bc *Blockchain) commitDerived(sr *stateReplay) {
+	if sr == nil {
+		return
+	}
+	for _, contract := range sr.contracts {
+		bc.putContract(contract)
+	}
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	for nit, supplier := range sr.suppliers {
+		bc.suppliers[nit] = supplier
+	}
+	for id, key := range sr.systemKeys {
+		bc.systemKeys[id] = key
+	}
+	for id, key := range sr.validatorKeys {
+		bc.validatorKeys[id] = key
+	}
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucketBlocks guarda los bloques indexados por altura (big-endian, para recorrerlos en orden)
+var bucketBlocks = []byte("blocks")
+
+// BoltStore implementa Store sobre BoltDB
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBolt abre (o crea) la base de datos BoltDB en la ruta indicada
+func OpenBolt(path string) (*BoltStore, error) {
+	return openBolt(path, false)
+}
+
+// OpenBoltReadOnly abre la base de datos en modo solo lectura, para herramientas de inspección
+func OpenBoltReadOnly(path string) (*BoltStore, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return openBolt(path, true)
+}
+
+func openBolt(path string, readOnly bool) (*BoltStore, error) {
+	if !readOnly {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 2 * time.Second, ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir %s (¿otro proceso la tiene abierta?): %v", path, err)
+	}
+
+	if !readOnly {
+		err = db.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(bucketBlocks)
+			return err
+		})
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func heightKey(height int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(height))
+	return key
+}
+
+// AppendBlock guarda el bloque en la altura indicada
+func (bs *BoltStore) AppendBlock(height int, block []byte) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketBlocks)
+		next := 0
+		if last, _ := bucket.Cursor().Last(); last != nil {
+			next = int(binary.BigEndian.Uint64(last)) + 1
+		}
+		if height != next {
+			return fmt.Errorf("altura %d no corresponde al final de la cadena (%d)", height, next)
+		}
+		return bucket.Put(heightKey(height), block)
+	})
+}
+
+// ReplaceChain reemplaza todos los bloques en una sola transacción
+func (bs *BoltStore) ReplaceChain(blocks [][]byte) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucketBlocks); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		bucket, err := tx.CreateBucket(bucketBlocks)
+		if err != nil {
+			return err
+		}
+		for height, block := range blocks {
+			if err := bucket.Put(heightKey(height), block); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Blocks recorre los bloques en orden de altura
+func (bs *BoltStore) Blocks(fn func(height int, block []byte) error) error {
+	return bs.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketBlocks)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			return fn(int(binary.BigEndian.Uint64(k)), append([]byte(nil), v...))
+		})
+	})
+}
+
+// Put guarda un valor de estado
+func (bs *BoltStore) Put(bucket string, key string, value []byte) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), value)
+	})
+}
+
+// Delete elimina un valor de estado
+func (bs *BoltStore) Delete(bucket string, key string) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.Delete([]byte(key))
+	})
+}
+
+// ForEach recorre los valores de un bucket en orden de llave
+func (bs *BoltStore) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	return bs.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			return fn(string(k), append([]byte(nil), v...))
+		})
+	})
+}
+
+// Close cierra la base de datos
+func (bs *BoltStore) Close() error {
+	return bs.db.Close()
+}
@@ -0,0 +1,169 @@
+// Package storage define la capa de persistencia de la blockchain SECOP.
+// Los valores se guardan serializados (JSON) para no depender de los tipos del paquete blockchain.
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Buckets usados por la blockchain para su estado
+const (
+	BucketContracts                = "contracts"
+	BucketSuppliers                = "suppliers"
+	BucketSystemKeys               = "system_keys"
+	BucketValidatorKeys            = "validator_keys"
+	BucketArchived                 = "archived_drafts"
+	BucketAlertRules               = "alert_rules"
+	BucketBridge                   = "secop_bridge"
+	BucketOutbox                   = "outbox"
+	BucketOutboxDone               = "outbox_done"
+	BucketSavedQueries             = "saved_queries"
+	BucketAPIKeys                  = "api_keys"
+	BucketWorkflowTemplates        = "workflow_templates"
+	BucketJoinTokens               = "join_tokens"
+	BucketFinality                 = "finality"
+	BucketAttachments              = "attachments"
+	BucketConflictDeclarations     = "conflict_declarations"
+	BucketTrafficMetrics           = "traffic_metrics"
+	BucketContentIndex             = "content_index"
+	BucketWorkflowTemplateVersions = "workflow_template_versions"
+	BucketProcessNumberAudit       = "process_number_audit"
+	BucketKPICompliance            = "kpi_compliance"
+	BucketReservedAccess           = "reserved_access"
+	BucketFiscalClosing            = "fiscal_closing"
+	BucketPeerRemovals             = "peer_removals"
+	BucketWebhooks                 = "webhooks"
+	BucketIdempotencyKeys          = "idempotency_keys"
+)
+
+// Store es la interfaz de almacenamiento de bloques y estado
+type Store interface {
+	// AppendBlock guarda el bloque en la altura indicada
+	AppendBlock(height int, block []byte) error
+	// ReplaceChain reemplaza atómicamente todos los bloques guardados
+	ReplaceChain(blocks [][]byte) error
+	// Blocks recorre los bloques en orden de altura
+	Blocks(fn func(height int, block []byte) error) error
+	// Put guarda un valor de estado
+	Put(bucket string, key string, value []byte) error
+	// Delete elimina un valor de estado
+	Delete(bucket string, key string) error
+	// ForEach recorre los valores de un bucket
+	ForEach(bucket string, fn func(key string, value []byte) error) error
+	// Close libera los recursos del almacenamiento
+	Close() error
+}
+
+// Open abre el almacenamiento según el backend indicado ("bolt" o "memory")
+func Open(backend string, path string) (Store, error) {
+	switch backend {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "bolt":
+		return OpenBolt(path)
+	default:
+		return nil, fmt.Errorf("backend de almacenamiento desconocido: %s", backend)
+	}
+}
+
+// MemoryStore guarda todo en memoria; no sobrevive reinicios
+type MemoryStore struct {
+	blocks  [][]byte
+	buckets map[string]map[string][]byte
+	mutex   sync.RWMutex
+}
+
+// NewMemoryStore crea un almacenamiento en memoria vacío
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]map[string][]byte),
+	}
+}
+
+// AppendBlock guarda el bloque en la altura indicada
+func (ms *MemoryStore) AppendBlock(height int, block []byte) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if height != len(ms.blocks) {
+		return fmt.Errorf("altura %d no corresponde al final de la cadena (%d)", height, len(ms.blocks))
+	}
+	ms.blocks = append(ms.blocks, block)
+	return nil
+}
+
+// ReplaceChain reemplaza todos los bloques guardados
+func (ms *MemoryStore) ReplaceChain(blocks [][]byte) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.blocks = append([][]byte(nil), blocks...)
+	return nil
+}
+
+// Blocks recorre los bloques en orden de altura
+func (ms *MemoryStore) Blocks(fn func(height int, block []byte) error) error {
+	ms.mutex.RLock()
+	blocks := ms.blocks
+	ms.mutex.RUnlock()
+
+	for height, block := range blocks {
+		if err := fn(height, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Put guarda un valor de estado
+func (ms *MemoryStore) Put(bucket string, key string, value []byte) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if ms.buckets[bucket] == nil {
+		ms.buckets[bucket] = make(map[string][]byte)
+	}
+	ms.buckets[bucket][key] = value
+	return nil
+}
+
+// Delete elimina un valor de estado
+func (ms *MemoryStore) Delete(bucket string, key string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	delete(ms.buckets[bucket], key)
+	return nil
+}
+
+// ForEach recorre los valores de un bucket en orden de llave
+func (ms *MemoryStore) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	ms.mutex.RLock()
+	keys := make([]string, 0, len(ms.buckets[bucket]))
+	for key := range ms.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	values := ms.buckets[bucket]
+	ms.mutex.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		ms.mutex.RLock()
+		value, ok := values[key]
+		ms.mutex.RUnlock()
+		if !ok {
+			continue
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close no hace nada en memoria
+func (ms *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,254 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Parámetros de entrega de eventos a sistemas externos
+const (
+	subscriptionMaxAttempts = 10
+	subscriptionBaseBackoff = 2 * time.Second
+	subscriptionMaxBackoff  = 10 * time.Minute
+)
+
+// ContractSubscription representa la suscripción de un sistema externo a los eventos de un contrato
+type ContractSubscription struct {
+	ID          string     `json:"id"`
+	ContractID  string     `json:"contract_id"`
+	CallbackURL string     `json:"callback_url"`
+	SystemName  string     `json:"system_name"`
+	CreatedBy   string     `json:"created_by"` // Sesión o llave de API que registró la suscripción
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiredAt   *time.Time `json:"expired_at,omitempty"`
+	Delivered   int        `json:"delivered"`
+	Failed      int        `json:"failed"`
+	Pending     int        `json:"pending"`
+	sequence    int
+	queue       []*subscriptionDelivery
+	expiring    bool
+}
+
+// subscriptionDelivery representa un evento pendiente de entrega
+type subscriptionDelivery struct {
+	Sequence    int        `json:"sequence"`
+	Event       ChainEvent `json:"event"`
+	attempts    int
+	nextAttempt time.Time
+}
+
+// SubscriptionManager entrega los eventos de cada contrato a sus suscriptores en
+// orden y con reintentos (entrega al menos una vez, con número de secuencia para deduplicar)
+type SubscriptionManager struct {
+	blockchain    *Blockchain
+	subscriptions map[string]*ContractSubscription
+	client        *http.Client
+	mutex         sync.Mutex
+}
+
+// NewSubscriptionManager crea el gestor y lo conecta al bus de eventos de la cadena
+func NewSubscriptionManager(bc *Blockchain) *SubscriptionManager {
+	sm := &SubscriptionManager{
+		blockchain:    bc,
+		subscriptions: make(map[string]*ContractSubscription),
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+	bc.Projections.Subscribe(ProjectionSubscriptions, sm.enqueue)
+	return sm
+}
+
+// ErrNotSubscriber indica que la suscripción la registró otra identidad
+var ErrNotSubscriber = errors.New("solo quien registró la suscripción puede eliminarla")
+
+// Subscribe registra un sistema externo para los eventos de un contrato
+func (sm *SubscriptionManager) Subscribe(contractID string, callbackURL string, systemName string, createdBy string) (*ContractSubscription, error) {
+	contract, exists := sm.blockchain.Contract(contractID)
+	if !exists {
+		return nil, errors.New("contrato no encontrado")
+	}
+	if isTerminalStatus(contract.Status) {
+		return nil, fmt.Errorf("el contrato ya finalizó (%s)", contract.Status)
+	}
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, errors.New("URL de callback inválida")
+	}
+
+	subscription := &ContractSubscription{
+		ID:          uuid.New().String(),
+		ContractID:  contractID,
+		CallbackURL: callbackURL,
+		SystemName:  systemName,
+		CreatedBy:   createdBy,
+		CreatedAt:   time.Now(),
+	}
+
+	sm.mutex.Lock()
+	sm.subscriptions[subscription.ID] = subscription
+	sm.mutex.Unlock()
+
+	logf("🔔 Suscripción %s registrada para contrato %s\n", subscription.ID, contractID)
+	return subscription, nil
+}
+
+// Unsubscribe elimina una suscripción; solo la puede eliminar quien la registró
+func (sm *SubscriptionManager) Unsubscribe(contractID string, subscriptionID string, requestedBy string) error {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	subscription, exists := sm.subscriptions[subscriptionID]
+	if !exists || subscription.ContractID != contractID {
+		return errors.New("suscripción no encontrada")
+	}
+	if subscription.CreatedBy != requestedBy {
+		return ErrNotSubscriber
+	}
+	delete(sm.subscriptions, subscriptionID)
+	return nil
+}
+
+// GetSubscriptions retorna las suscripciones de un contrato
+func (sm *SubscriptionManager) GetSubscriptions(contractID string) []ContractSubscription {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	var result []ContractSubscription
+	for _, subscription := range sm.subscriptions {
+		if subscription.ContractID == contractID {
+			snapshot := *subscription
+			snapshot.Pending = len(subscription.queue)
+			snapshot.queue = nil
+			result = append(result, snapshot)
+		}
+	}
+	return result
+}
+
+// enqueue encola el evento para las suscripciones del contrato afectado
+func (sm *SubscriptionManager) enqueue(event ChainEvent) {
+	if event.ContractID == "" {
+		return
+	}
+
+	terminal := false
+	if contract, exists := sm.blockchain.Contract(event.ContractID); exists {
+		terminal = isTerminalStatus(contract.Status)
+	}
+
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	for _, subscription := range sm.subscriptions {
+		if subscription.ContractID != event.ContractID || subscription.ExpiredAt != nil || subscription.expiring {
+			continue
+		}
+		subscription.sequence++
+		subscription.queue = append(subscription.queue, &subscriptionDelivery{
+			Sequence:    subscription.sequence,
+			Event:       event,
+			nextAttempt: time.Now(),
+		})
+		// Tras la liquidación se entrega el último evento y la suscripción expira
+		if terminal {
+			subscription.expiring = true
+		}
+	}
+}
+
+// Run entrega periódicamente los eventos pendientes
+func (sm *SubscriptionManager) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sm.deliverPending()
+	}
+}
+
+// deliverPending intenta entregar el primer evento pendiente de cada suscripción
+func (sm *SubscriptionManager) deliverPending() {
+	sm.mutex.Lock()
+	var ready []*ContractSubscription
+	now := time.Now()
+	for _, subscription := range sm.subscriptions {
+		if len(subscription.queue) > 0 && !subscription.queue[0].nextAttempt.After(now) {
+			ready = append(ready, subscription)
+		}
+	}
+	sm.mutex.Unlock()
+
+	for _, subscription := range ready {
+		sm.mutex.Lock()
+		if len(subscription.queue) == 0 {
+			sm.mutex.Unlock()
+			continue
+		}
+		delivery := subscription.queue[0]
+		callbackURL := subscription.CallbackURL
+		subscriptionID := subscription.ID
+		sm.mutex.Unlock()
+
+		err := sm.post(callbackURL, subscriptionID, delivery)
+
+		sm.mutex.Lock()
+		if err == nil {
+			subscription.queue = subscription.queue[1:]
+			subscription.Delivered++
+		} else {
+			delivery.attempts++
+			if delivery.attempts >= subscriptionMaxAttempts {
+				logf("❌ Evento %d descartado para suscripción %s: %v\n", delivery.Sequence, subscriptionID, err)
+				subscription.queue = subscription.queue[1:]
+				subscription.Failed++
+			} else {
+				backoff := subscriptionBaseBackoff << uint(delivery.attempts-1)
+				if backoff > subscriptionMaxBackoff {
+					backoff = subscriptionMaxBackoff
+				}
+				delivery.nextAttempt = time.Now().Add(backoff)
+			}
+		}
+		if subscription.expiring && len(subscription.queue) == 0 && subscription.ExpiredAt == nil {
+			expiredAt := time.Now()
+			subscription.ExpiredAt = &expiredAt
+			logf("⌛ Suscripción %s expirada tras la liquidación del contrato\n", subscriptionID)
+		}
+		sm.mutex.Unlock()
+	}
+}
+
+// post envía un evento al callback del sistema suscrito
+func (sm *SubscriptionManager) post(callbackURL string, subscriptionID string, delivery *subscriptionDelivery) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"subscription_id": subscriptionID,
+		"sequence":        delivery.Sequence,
+		"event":           delivery.Event,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := sm.client.Post(callbackURL, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback respondió con status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// isTerminalStatus indica si el contrato terminó su ciclo de vida (liquidado o rechazado)
+func isTerminalStatus(status ContractStatus) bool {
+	return status == StatusCompleted || status == StatusRejected
+}
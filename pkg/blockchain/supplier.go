@@ -0,0 +1,68 @@
+package blockchain
+
+import (
+	"errors"
+	"time"
+)
+
+// Supplier representa un proveedor registrado que puede participar en procesos
+type Supplier struct {
+	NIT          string     `json:"nit"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	RegisteredAt time.Time  `json:"registered_at"`
+	Sanctions    []Sanction `json:"sanctions"`
+}
+
+// RegisterSupplier registra un proveedor en la blockchain; queda inscrito cuando su bloque
+// entra a la cadena
+func (bc *Blockchain) RegisterSupplier(supplier *Supplier) error {
+	if supplier.NIT == "" {
+		return errors.New("NIT del proveedor requerido")
+	}
+	if supplier.Name == "" {
+		return errors.New("nombre del proveedor requerido")
+	}
+
+	bc.registryMutex.Lock()
+	defer bc.registryMutex.Unlock()
+	if _, exists := bc.supplier(supplier.NIT); exists {
+		return errors.New("proveedor ya registrado")
+	}
+
+	supplier.RegisteredAt = time.Now()
+
+	blockData := map[string]interface{}{
+		"type":      "SUPPLIER_REGISTRATION",
+		"nit":       supplier.NIT,
+		"name":      supplier.Name,
+		"email":     supplier.Email,
+		"timestamp": supplier.RegisteredAt,
+	}
+
+	if err := bc.AddBlock(blockData); err != nil {
+		return err
+	}
+	bc.putSupplier(supplier)
+	return nil
+}
+
+// GetSupplier obtiene un proveedor por NIT
+func (bc *Blockchain) GetSupplier(nit string) (*Supplier, error) {
+	supplier, exists := bc.supplier(nit)
+	if !exists {
+		return nil, errors.New("proveedor no registrado")
+	}
+	return supplier, nil
+}
+
+// GetAllSuppliers obtiene todos los proveedores registrados
+func (bc *Blockchain) GetAllSuppliers() []*Supplier {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	suppliers := make([]*Supplier, 0, len(bc.suppliers))
+	for _, supplier := range bc.suppliers {
+		suppliers = append(suppliers, supplier)
+	}
+	return suppliers
+}
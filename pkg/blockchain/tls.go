@@ -0,0 +1,111 @@
+package blockchain
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// NodeTLS contiene la configuración TLS mutua del nodo: el mismo certificado sirve para
+// atender peticiones y para identificarse ante los peers, y la CA valida a ambos lados
+type NodeTLS struct {
+	Server *tls.Config
+	Client *tls.Config
+}
+
+// LoadNodeTLS carga el certificado del nodo y el bundle de CAs de la red. Los peers
+// deben presentar un certificado emitido por esas CAs y su dirección debe estar en el SAN.
+func LoadNodeTLS(certFile, keyFile, caFile string) (*NodeTLS, error) {
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return nil, errors.New("TLS mutuo requiere certificado, llave y bundle de CAs")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("certificado del nodo inválido: %v", err)
+	}
+
+	caData, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return nil, errors.New("el bundle de CAs no contiene certificados PEM válidos")
+	}
+
+	return &NodeTLS{
+		// La API pública no exige certificado de cliente; las rutas entre nodos sí (ver PeerCertificate)
+		Server: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientCAs:    pool,
+			ClientAuth:   tls.VerifyClientCertIfGiven,
+			MinVersion:   tls.VersionTLS12,
+		},
+		Client: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      pool,
+			MinVersion:   tls.VersionTLS12,
+		},
+	}, nil
+}
+
+// PeerCertificate retorna el certificado verificado con el que se presentó un peer, o error
+// si la petición no llegó por TLS mutuo
+func PeerCertificate(req *http.Request) (*x509.Certificate, error) {
+	if req.TLS == nil {
+		return nil, errors.New("se requiere TLS")
+	}
+	if len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil, errors.New("se requiere un certificado de nodo emitido por la CA de la red")
+	}
+	return req.TLS.VerifiedChains[0][0], nil
+}
+
+// PeerNodeID retorna el ID del nodo que se presentó con certificado de la red. Los
+// certificados de nodo llevan el ID en el Common Name; a diferencia de X-Node-ID, no se
+// puede suplantar.
+func PeerNodeID(req *http.Request) (string, error) {
+	cert, err := PeerCertificate(req)
+	if err != nil {
+		return "", err
+	}
+	if cert.Subject.CommonName == "" {
+		return "", errors.New("el certificado del nodo no trae su ID en el Common Name")
+	}
+	return cert.Subject.CommonName, nil
+}
+
+// SetTLS hace que el tráfico hacia los peers use HTTPS con el certificado del nodo
+func (p2p *P2PNetwork) SetTLS(config *tls.Config) {
+	p2p.tlsConfig = config
+	p2p.transport = &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     config,
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// peerURL arma la URL de una ruta de un peer con el esquema que corresponde
+func (p2p *P2PNetwork) peerURL(peer *Peer, path string) string {
+	scheme := "http"
+	if p2p.tlsConfig != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%s%s", scheme, peer.Address, peer.Port, path)
+}
+
+// peerClient retorna un cliente HTTP hacia los peers con el timeout indicado
+// (0 para ninguno), usando TLS mutuo si está configurado
+func (p2p *P2PNetwork) peerClient(timeout time.Duration) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if p2p.transport != nil {
+		client.Transport = p2p.transport
+	}
+	return client
+}
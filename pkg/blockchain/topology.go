@@ -0,0 +1,225 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TopologyNode representa un nodo en el grafo de la red
+type TopologyNode struct {
+	ID        string `json:"id"`
+	Address   string `json:"address"`
+	Port      string `json:"port"`
+	Reachable bool   `json:"reachable"`
+}
+
+// TopologyEdge representa una conexión declarada por un nodo hacia otro
+type TopologyEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Active bool   `json:"active"`
+}
+
+// Topology representa el grafo de conectividad de la red
+type Topology struct {
+	Nodes              []TopologyNode `json:"nodes"`
+	Edges              []TopologyEdge `json:"edges"`
+	Components         [][]string     `json:"components"`
+	Partitioned        bool           `json:"partitioned"`
+	ArticulationPoints []string       `json:"articulation_points"`
+	GeneratedAt        time.Time      `json:"generated_at"`
+}
+
+// Topology consulta la lista de peers de cada peer conocido y arma el grafo de la red
+func (p2p *P2PNetwork) Topology() *Topology {
+	p2p.mutex.RLock()
+	peers := make([]Peer, 0, len(p2p.Peers))
+	for _, peer := range p2p.Peers {
+		peers = append(peers, *peer)
+	}
+	p2p.mutex.RUnlock()
+
+	nodes := map[string]*TopologyNode{
+		p2p.NodeID: {ID: p2p.NodeID, Address: p2p.Address, Port: p2p.Port, Reachable: true},
+	}
+	var edges []TopologyEdge
+	for _, peer := range peers {
+		nodes[peer.ID] = &TopologyNode{ID: peer.ID, Address: peer.Address, Port: peer.Port}
+		edges = append(edges, TopologyEdge{From: p2p.NodeID, To: peer.ID, Active: peer.Active})
+	}
+
+	// Consultar en paralelo la lista de peers de cada vecino
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer Peer) {
+			defer wg.Done()
+			remotePeers, err := p2p.requestPeersFromPeer(&peer)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				return
+			}
+			nodes[peer.ID].Reachable = true
+			for _, remote := range remotePeers {
+				if _, known := nodes[remote.ID]; !known {
+					nodes[remote.ID] = &TopologyNode{ID: remote.ID, Address: remote.Address, Port: remote.Port}
+				}
+				edges = append(edges, TopologyEdge{From: peer.ID, To: remote.ID, Active: remote.Active})
+			}
+		}(peer)
+	}
+	wg.Wait()
+
+	topology := &Topology{GeneratedAt: time.Now()}
+	for _, node := range nodes {
+		topology.Nodes = append(topology.Nodes, *node)
+	}
+	sort.Slice(topology.Nodes, func(i, j int) bool { return topology.Nodes[i].ID < topology.Nodes[j].ID })
+	topology.Edges = edges
+
+	adjacency := buildAdjacency(topology)
+	topology.Components = connectedComponents(adjacency)
+	topology.Partitioned = len(topology.Components) > 1
+	topology.ArticulationPoints = articulationPoints(adjacency)
+
+	return topology
+}
+
+// requestPeersFromPeer solicita la lista de peers activos de un peer
+func (p2p *P2PNetwork) requestPeersFromPeer(peer *Peer) ([]Peer, error) {
+	url := p2p.peerURL(peer, "/api/p2p/peers")
+
+	client := p2p.peerClient(5 * time.Second)
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer respondió con status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Peers []Peer `json:"peers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response.Peers, nil
+}
+
+// buildAdjacency construye la lista de adyacencia no dirigida usando solo enlaces activos
+func buildAdjacency(topology *Topology) map[string][]string {
+	adjacency := make(map[string][]string, len(topology.Nodes))
+	for _, node := range topology.Nodes {
+		adjacency[node.ID] = nil
+	}
+
+	seen := make(map[[2]string]bool)
+	for _, edge := range topology.Edges {
+		if !edge.Active || edge.From == edge.To {
+			continue
+		}
+		key := [2]string{edge.From, edge.To}
+		if edge.To < edge.From {
+			key = [2]string{edge.To, edge.From}
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		adjacency[edge.From] = append(adjacency[edge.From], edge.To)
+		adjacency[edge.To] = append(adjacency[edge.To], edge.From)
+	}
+	return adjacency
+}
+
+// connectedComponents retorna los grupos de nodos conectados entre sí
+func connectedComponents(adjacency map[string][]string) [][]string {
+	ids := make([]string, 0, len(adjacency))
+	for id := range adjacency {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	visited := make(map[string]bool)
+	var components [][]string
+	for _, id := range ids {
+		if visited[id] {
+			continue
+		}
+		var component []string
+		stack := []string{id}
+		visited[id] = true
+		for len(stack) > 0 {
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			component = append(component, current)
+			for _, next := range adjacency[current] {
+				if !visited[next] {
+					visited[next] = true
+					stack = append(stack, next)
+				}
+			}
+		}
+		sort.Strings(component)
+		components = append(components, component)
+	}
+	return components
+}
+
+// articulationPoints retorna los nodos cuya caída partiría la red (algoritmo de Tarjan)
+func articulationPoints(adjacency map[string][]string) []string {
+	discovery := make(map[string]int)
+	low := make(map[string]int)
+	points := make(map[string]bool)
+	timer := 0
+
+	var visit func(node string, parent string)
+	visit = func(node string, parent string) {
+		timer++
+		discovery[node] = timer
+		low[node] = timer
+		children := 0
+
+		for _, next := range adjacency[node] {
+			if _, seen := discovery[next]; !seen {
+				children++
+				visit(next, node)
+				if low[next] < low[node] {
+					low[node] = low[next]
+				}
+				if parent != "" && low[next] >= discovery[node] {
+					points[node] = true
+				}
+			} else if next != parent && discovery[next] < low[node] {
+				low[node] = discovery[next]
+			}
+		}
+
+		if parent == "" && children > 1 {
+			points[node] = true
+		}
+	}
+
+	for node := range adjacency {
+		if _, seen := discovery[node]; !seen {
+			visit(node, "")
+		}
+	}
+
+	result := make([]string, 0, len(points))
+	for node := range points {
+		result = append(result, node)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,120 @@
+package blockchain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Backends de transporte entre nodos: HTTP, con una petición por mensaje, y libp2p, con
+// conexiones cifradas que atraviesan NAT y descubrimiento por DHT (ver libp2p_transport.go)
+const (
+	P2PBackendHTTP   = "http"
+	P2PBackendLibp2p = "libp2p"
+)
+
+// P2PTransport es el medio por el que el nodo entrega bloques, transacciones y páginas de
+// sincronización a sus peers. La lógica de gossip, consenso y sincronización no depende de
+// cómo viajan los mensajes.
+type P2PTransport interface {
+	// Name identifica el transporte en la administración del nodo
+	Name() string
+	// SendBlock entrega un bloque al peer
+	SendBlock(peer *Peer, block Block) error
+	// SendTransaction entrega una transacción pendiente al peer
+	SendTransaction(peer *Peer, tx MempoolTransaction) error
+	// RequestBlocks pide al peer una página de bloques desde la altura dada
+	RequestBlocks(peer *Peer, from int) (*BlocksPage, error)
+}
+
+// SetBackend selecciona el transporte entre nodos por nombre. libp2p abre su host con la
+// configuración de p2p.Libp2p y recurre a HTTP con los peers que no tienen conexión libp2p.
+func (p2p *P2PNetwork) SetBackend(name string) error {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", P2PBackendHTTP:
+		p2p.backend = &httpTransport{p2p: p2p}
+	case P2PBackendLibp2p:
+		transport, err := newLibp2pTransport(p2p, p2p.Libp2p, &httpTransport{p2p: p2p})
+		if err != nil {
+			return fmt.Errorf("backend libp2p: %v", err)
+		}
+		p2p.backend = transport
+		if p2p.libp2p != nil {
+			p2p.libp2p.host.Close()
+		}
+		p2p.libp2p = transport
+		return nil
+	default:
+		return fmt.Errorf("backend P2P desconocido: %s (opciones: %s, %s)", name, P2PBackendHTTP, P2PBackendLibp2p)
+	}
+	if p2p.libp2p != nil {
+		p2p.libp2p.host.Close()
+		p2p.libp2p = nil
+	}
+	return nil
+}
+
+// Backend retorna el nombre del transporte en uso
+func (p2p *P2PNetwork) Backend() string {
+	return p2p.backend.Name()
+}
+
+// httpTransport entrega los mensajes con una petición HTTP por mensaje
+type httpTransport struct {
+	p2p *P2PNetwork
+}
+
+func (t *httpTransport) Name() string {
+	return P2PBackendHTTP
+}
+
+func (t *httpTransport) SendBlock(peer *Peer, block Block) error {
+	return t.p2p.sendBlockToPeer(peer, block)
+}
+
+func (t *httpTransport) SendTransaction(peer *Peer, tx MempoolTransaction) error {
+	return t.p2p.postTransaction(peer, tx)
+}
+
+func (t *httpTransport) RequestBlocks(peer *Peer, from int) (*BlocksPage, error) {
+	return t.p2p.fetchBlocksPage(peer, from)
+}
+
+// webSocketTransport usa el canal WebSocket del peer cuando está abierto y con espacio en
+// su cola, y si no recurre al transporte que envuelve
+type webSocketTransport struct {
+	p2p      *P2PNetwork
+	fallback P2PTransport
+}
+
+func (t *webSocketTransport) Name() string {
+	return t.fallback.Name() + "+websocket"
+}
+
+func (t *webSocketTransport) SendBlock(peer *Peer, block Block) error {
+	err := t.p2p.sendMessage(peer.ID, PeerMessageNewBlock, "", block)
+	if err == nil {
+		return nil
+	}
+	if err != errNoPeerConn {
+		logf("⚠️ %v, enviando bloque %s por %s\n", err, block.Hash, t.fallback.Name())
+	}
+	return t.fallback.SendBlock(peer, block)
+}
+
+func (t *webSocketTransport) SendTransaction(peer *Peer, tx MempoolTransaction) error {
+	if err := t.p2p.sendMessage(peer.ID, PeerMessageTransaction, "", tx); err == nil {
+		return nil
+	}
+	return t.fallback.SendTransaction(peer, tx)
+}
+
+func (t *webSocketTransport) RequestBlocks(peer *Peer, from int) (*BlocksPage, error) {
+	page, err := t.p2p.requestPageOverWS(peer.ID, from)
+	if err == nil {
+		return page, nil
+	}
+	if err != errNoPeerConn {
+		logf("⚠️ %v, pidiendo bloques por %s\n", err, t.fallback.Name())
+	}
+	return t.fallback.RequestBlocks(peer, from)
+}
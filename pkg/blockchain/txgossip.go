@@ -0,0 +1,90 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Difusión de las transacciones del mempool: igual que los bloques, cada transacción
+// enviada en un nodo viaja por gossip a un subconjunto de peers que la reenvían. El
+// mempool descarta por ID las copias repetidas, de modo que los ciclos se cortan solos.
+
+// PeerMessageTransaction es el mensaje del canal WebSocket que lleva una transacción pendiente
+const PeerMessageTransaction = "TRANSACTION"
+
+// enableTransactionGossip hace que el mempool difunda por la red las transacciones locales
+func (p2p *P2PNetwork) enableTransactionGossip() {
+	if p2p.Blockchain.Mempool == nil {
+		return
+	}
+	p2p.Blockchain.Mempool.SetBroadcast(func(tx MempoolTransaction) {
+		tx.Origin = p2p.NodeID
+		p2p.gossipTransaction(tx, "")
+	})
+}
+
+// ReceiveTransaction deja en el mempool una transacción difundida por un peer y la reenvía
+// si era nueva
+func (p2p *P2PNetwork) ReceiveTransaction(tx MempoolTransaction, sender string) error {
+	if p2p.Blockchain.Mempool == nil {
+		return fmt.Errorf("el mempool no está activo en este nodo")
+	}
+	if p2p.Reputation.IsBanned(sender) {
+		return fmt.Errorf("peer %s vetado por enviar datos inválidos", sender)
+	}
+	added, err := p2p.Blockchain.Mempool.AddRemote(tx)
+	if err != nil {
+		return err
+	}
+	if added {
+		logf("📨 Transacción %s de %s recibida de %s\n", tx.ID, tx.Origin, sender)
+		go p2p.gossipTransaction(tx, sender)
+	}
+	return nil
+}
+
+// gossipTransaction envía la transacción a un subconjunto aleatorio de peers, sin
+// devolverla a quien la envió ni a su origen
+func (p2p *P2PNetwork) gossipTransaction(tx MempoolTransaction, sender string) {
+	kind, _ := tx.Data["type"].(string)
+	for peerID, peer := range p2p.gossipPeers(sender, tx.Origin) {
+		if !peer.acceptsKind(kind) {
+			continue
+		}
+		// Las transacciones de contratos reservados no salen hacia nodos no autorizados
+		if !p2p.Reserved.IsAuthorized(peerID) && p2p.Blockchain.isReservedData(tx.Data) {
+			continue
+		}
+		if err := p2p.backend.SendTransaction(peer, tx); err != nil {
+			logf("❌ Error enviando transacción %s a %s: %v\n", tx.ID, peerID, err)
+		}
+	}
+}
+
+// postTransaction envía la transacción al peer por HTTP
+func (p2p *P2PNetwork) postTransaction(peer *Peer, tx MempoolTransaction) error {
+	body, err := json.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, p2p.peerURL(peer, "/api/p2p/mempool"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Node-ID", p2p.NodeID)
+
+	resp, err := p2p.peerClient(5 * time.Second).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer respondió con status %d", resp.StatusCode)
+	}
+	return nil
+}
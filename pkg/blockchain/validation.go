@@ -0,0 +1,198 @@
+package blockchain
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Tamaño de los lotes que procesa cada worker; por debajo de este tamaño
+// la verificación se hace de forma secuencial
+const validationChunkSize = 512
+
+// blockCheck verifica el bloque en la posición i de la cadena
+type blockCheck func(blocks []*Block, i int) error
+
+// ChainVerifier valida cadenas completas en etapas (estructura, hash, enlaces, firmas) usando
+// un pool de workers por etapa y deteniéndose en la primera falla
+type ChainVerifier struct {
+	Workers int
+	stages  []verificationStage
+}
+
+// verificationStage representa una etapa del pipeline de validación
+type verificationStage struct {
+	name  string
+	check blockCheck
+}
+
+// NewChainVerifier crea un verificador para una cadena recibida de un peer: además de las
+// etapas de integridad, cada bloque debe estar firmado por una de las llaves conocidas de
+// su nodo firmante (ver P2PNetwork.ValidatorKeys)
+func NewChainVerifier(keys map[string][]PublicKeyInfo) *ChainVerifier {
+	cv := newIntegrityVerifier()
+	cv.AddStage("firma", checkBlockSigner(keys))
+	return cv
+}
+
+// newIntegrityVerifier crea un verificador de estructura, hash y enlaces, sin firmas. Lo usan
+// la cadena guardada por el propio nodo y sus respaldos, cuyos bloques se verificaron al
+// agregarse; las cadenas de peers usan NewChainVerifier.
+func newIntegrityVerifier() *ChainVerifier {
+	return &ChainVerifier{
+		Workers: runtime.NumCPU(),
+		stages: []verificationStage{
+			{name: "estructura", check: checkBlockStructure},
+			{name: "hash", check: checkBlockHash},
+			{name: "enlace", check: checkBlockLink},
+		},
+	}
+}
+
+// newLocalChainVerifier crea un verificador para la cadena propia del nodo, que puede
+// tener bloques archivados
+func newLocalChainVerifier() *ChainVerifier {
+	cv := newIntegrityVerifier()
+	for i := range cv.stages {
+		if cv.stages[i].name == "hash" {
+			cv.stages[i].check = checkLocalBlockHash
+		}
+	}
+	return cv
+}
+
+// AddStage agrega una etapa al final del pipeline
+func (cv *ChainVerifier) AddStage(name string, check blockCheck) {
+	cv.stages = append(cv.stages, verificationStage{name: name, check: check})
+}
+
+// Verify ejecuta todas las etapas en orden y retorna el primer error encontrado
+func (cv *ChainVerifier) Verify(blocks []*Block) error {
+	if len(blocks) == 0 {
+		return fmt.Errorf("cadena vacía")
+	}
+
+	for _, stage := range cv.stages {
+		if err := cv.runStage(blocks, stage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runStage ejecuta una etapa repartiendo los bloques en lotes entre los workers
+func (cv *ChainVerifier) runStage(blocks []*Block, stage verificationStage) error {
+	if len(blocks) <= validationChunkSize || cv.Workers <= 1 {
+		for i := range blocks {
+			if err := stage.check(blocks, i); err != nil {
+				return fmt.Errorf("etapa %s, bloque %d: %v", stage.name, i, err)
+			}
+		}
+		return nil
+	}
+
+	chunks := make(chan int)
+	var failed int32
+	var firstErr error
+	var errIndex int
+	var errMutex sync.Mutex
+	var wg sync.WaitGroup
+
+	for w := 0; w < cv.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range chunks {
+				end := start + validationChunkSize
+				if end > len(blocks) {
+					end = len(blocks)
+				}
+				for i := start; i < end; i++ {
+					if atomic.LoadInt32(&failed) == 1 {
+						break
+					}
+					if err := stage.check(blocks, i); err != nil {
+						errMutex.Lock()
+						if firstErr == nil || i < errIndex {
+							firstErr = err
+							errIndex = i
+						}
+						errMutex.Unlock()
+						atomic.StoreInt32(&failed, 1)
+						break
+					}
+				}
+			}
+		}()
+	}
+
+	for start := 0; start < len(blocks); start += validationChunkSize {
+		if atomic.LoadInt32(&failed) == 1 {
+			break
+		}
+		chunks <- start
+	}
+	close(chunks)
+	wg.Wait()
+
+	if firstErr != nil {
+		return fmt.Errorf("etapa %s, bloque %d: %v", stage.name, errIndex, firstErr)
+	}
+	return nil
+}
+
+// checkBlockStructure verifica que el bloque tenga los campos obligatorios
+func checkBlockStructure(blocks []*Block, i int) error {
+	block := blocks[i]
+	if block == nil || block.Hash == "" {
+		return fmt.Errorf("hash vacío")
+	}
+	if block.Timestamp.IsZero() {
+		return fmt.Errorf("timestamp vacío")
+	}
+	return nil
+}
+
+// checkBlockHash recalcula el hash del bloque. Los bloques retenidos de contratos reservados
+// no traen el contenido con que se calculó; su firma sigue identificando al autor.
+func checkBlockHash(blocks []*Block, i int) error {
+	if blocks[i].Withheld {
+		return nil
+	}
+	if !blocks[i].IsValid() {
+		return fmt.Errorf("hash no corresponde al contenido")
+	}
+	return nil
+}
+
+// checkLocalBlockHash recalcula el hash de los bloques en memoria; los archivados solo
+// tienen encabezado y se verifican al escribir y leer su segmento
+func checkLocalBlockHash(blocks []*Block, i int) error {
+	if blocks[i].Pruned {
+		return nil
+	}
+	return checkBlockHash(blocks, i)
+}
+
+// checkBlockSigner verifica que la firma del bloque corresponda a una llave del nodo que lo
+// firmó. El génesis lo crea cada nodo al iniciar y no lleva firma.
+func checkBlockSigner(keys map[string][]PublicKeyInfo) blockCheck {
+	return func(blocks []*Block, i int) error {
+		if i == 0 {
+			return nil
+		}
+		return VerifyBlockSignature(*blocks[i], keys)
+	}
+}
+
+// checkBlockLink verifica el enlace con el bloque anterior
+func checkBlockLink(blocks []*Block, i int) error {
+	if i == 0 {
+		return nil
+	}
+	if blocks[i].PreviousHash != blocks[i-1].Hash {
+		return fmt.Errorf("hash previo no coincide con el bloque %d", i-1)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package blockchain
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Desfase máximo aceptado entre la hora de firma de una aprobación y la del nodo
+const stepSignatureMaxSkew = 10 * time.Minute
+
+// ValidatorKey representa la llave pública Ed25519 con la que un funcionario firma
+// sus decisiones en el flujo de validación
+type ValidatorKey struct {
+	KeyID        string    `json:"kid"`
+	ValidatorID  string    `json:"validator_id"`
+	Role         AdminRole `json:"role"`
+	PublicKey    string    `json:"public_key"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// StepSignature representa la firma de un validador sobre su decisión en un paso
+type StepSignature struct {
+	KeyID     string    `json:"key_id"`
+	Signature string    `json:"signature"`
+	SignedAt  time.Time `json:"signed_at"`
+}
+
+// RegisterValidatorKey inscribe la llave pública de un validador; las llaves anteriores
+// se conservan para poder verificar las aprobaciones ya firmadas
+func (bc *Blockchain) RegisterValidatorKey(validatorID string, role AdminRole, publicKey string) (*ValidatorKey, error) {
+	if validatorID == "" || role == "" {
+		return nil, errors.New("validador y rol requeridos")
+	}
+	raw, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("llave pública Ed25519 inválida")
+	}
+
+	bc.registryMutex.Lock()
+	defer bc.registryMutex.Unlock()
+
+	keyID := KeyIDFor(ed25519.PublicKey(raw))
+	if _, exists := bc.validatorKey(keyID); exists {
+		return nil, errors.New("la llave ya está registrada")
+	}
+
+	key := &ValidatorKey{
+		KeyID:        keyID,
+		ValidatorID:  validatorID,
+		Role:         role,
+		PublicKey:    publicKey,
+		RegisteredAt: time.Now(),
+	}
+
+	blockData := map[string]interface{}{
+		"type":             "VALIDATOR_KEY_REGISTRATION",
+		"validator_key_id": keyID,
+		"validator_id":     validatorID,
+		"role":             string(role),
+		"public_key":       publicKey,
+		"timestamp":        key.RegisteredAt,
+	}
+
+	if err := bc.AddBlock(blockData); err != nil {
+		return nil, err
+	}
+	bc.putValidatorKey(key)
+	return key, nil
+}
+
+// GetValidatorKeys obtiene las llaves registradas de un validador
+func (bc *Blockchain) GetValidatorKeys(validatorID string) []*ValidatorKey {
+	var keys []*ValidatorKey
+	for _, key := range bc.AllValidatorKeys() {
+		if key.ValidatorID == validatorID {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// StepApprovalPayload construye el mensaje que firma el validador: JSON con contract_id,
+// step, decision (APPROVED o REJECTED), timestamp en RFC 3339 UTC con precisión de segundos
+// y act_hash solo si la decisión adjunta el acta del comité
+func StepApprovalPayload(contractID string, step int, approved bool, signedAt time.Time, actHash string) []byte {
+	decision := ValidationRejected
+	if approved {
+		decision = ValidationApproved
+	}
+	payload, _ := json.Marshal(struct {
+		ContractID string           `json:"contract_id"`
+		Step       int              `json:"step"`
+		Decision   ValidationStatus `json:"decision"`
+		Timestamp  string           `json:"timestamp"`
+		ActHash    string           `json:"act_hash,omitempty"`
+	}{contractID, step, decision, signedAt.UTC().Format(time.RFC3339), actHash})
+	return payload
+}
+
+// verifyStepSignature verifica que la decisión esté firmada por una llave del validador
+// con el rol del paso, que la firma sea reciente y que no se haya usado antes. La firma queda
+// reservada; quien llama la libera si la decisión no llega a la cadena.
+func (bc *Blockchain) verifyStepSignature(contractID string, step int, validatorID string, role AdminRole, approved bool, signature StepSignature, actHash string) error {
+	if signature.Signature == "" || signature.KeyID == "" {
+		return errors.New("la decisión debe estar firmada por el validador")
+	}
+	key, exists := bc.validatorKey(signature.KeyID)
+	if !exists || key.ValidatorID != validatorID {
+		return errors.New("llave de firma no registrada para el validador")
+	}
+	if key.Role != role {
+		return fmt.Errorf("la llave fue registrada para el rol %s", key.Role)
+	}
+
+	skew := time.Since(signature.SignedAt)
+	if skew > stepSignatureMaxSkew || skew < -stepSignatureMaxSkew {
+		return errors.New("la firma está vencida o tiene una hora inválida")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return errors.New("firma mal codificada")
+	}
+	publicKey, _ := base64.StdEncoding.DecodeString(key.PublicKey)
+	if !ed25519.Verify(ed25519.PublicKey(publicKey), StepApprovalPayload(contractID, step, approved, signature.SignedAt, actHash), sig) {
+		return errors.New("firma de la decisión inválida")
+	}
+	if !bc.claimSignature(signature.Signature) {
+		return errors.New("la firma ya fue usada")
+	}
+	return nil
+}
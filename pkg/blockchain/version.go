@@ -0,0 +1,190 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Información de compilación; se fija con
+// -ldflags "-X secop-blockchain/pkg/blockchain.Version=1.4.0 -X ...Commit=abc123 -X ...BuildDate=2024-01-01"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// SchemaVersion es la versión del formato de bloques y estado guardados; se incrementa
+// cuando un cambio exige migrar el almacenamiento o impide leer datos de otro nodo
+const SchemaVersion = 1
+
+// NodeVersion describe el software y las versiones de protocolo de un nodo
+type NodeVersion struct {
+	NodeID           string   `json:"node_id"`
+	Version          string   `json:"version"`
+	Commit           string   `json:"commit,omitempty"`
+	BuildDate        string   `json:"build_date,omitempty"`
+	GoVersion        string   `json:"go_version"`
+	SchemaVersion    int      `json:"schema_version"`
+	HashingVersion   int      `json:"hashing_version"`
+	ConsensusVersion int      `json:"consensus_version"`
+	TransactionKinds []string `json:"transaction_kinds"`
+	Byzantine        []string `json:"byzantine,omitempty"`
+}
+
+// PeerVersion representa la versión reportada por un peer y su compatibilidad con este nodo
+type PeerVersion struct {
+	NodeID     string       `json:"node_id"`
+	Reachable  bool         `json:"reachable"`
+	Version    *NodeVersion `json:"version,omitempty"`
+	Compatible bool         `json:"compatible"`
+	Warnings   []string     `json:"warnings,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// NetworkVersions resume las versiones de la red para coordinar actualizaciones escalonadas
+type NetworkVersions struct {
+	Local          NodeVersion    `json:"local"`
+	Peers          []PeerVersion  `json:"peers"`
+	Versions       map[string]int `json:"versions"`        // nodos por versión de software
+	SchemaVersions map[string]int `json:"schema_versions"` // nodos por versión de esquema
+	Warnings       []string       `json:"warnings,omitempty"`
+}
+
+// LocalVersion retorna la información de versión de este nodo
+func (p2p *P2PNetwork) LocalVersion() NodeVersion {
+	version := NodeVersion{
+		NodeID:           p2p.NodeID,
+		Version:          Version,
+		Commit:           Commit,
+		BuildDate:        BuildDate,
+		GoVersion:        runtime.Version(),
+		SchemaVersion:    SchemaVersion,
+		HashingVersion:   HashingVersion,
+		ConsensusVersion: ConsensusVersion,
+		TransactionKinds: append([]string{}, TransactionKinds...),
+		Byzantine:        ByzantineBehaviors(),
+	}
+
+	// Sin ldflags se usa la información de VCS que Go incluye en el binario
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if version.Commit == "" {
+					version.Commit = setting.Value
+				}
+			case "vcs.time":
+				if version.BuildDate == "" {
+					version.BuildDate = setting.Value
+				}
+			}
+		}
+	}
+	return version
+}
+
+// NetworkVersions consulta en paralelo la versión de los peers y advierte sobre
+// los que no podrían operar con este nodo
+func (p2p *P2PNetwork) NetworkVersions() NetworkVersions {
+	local := p2p.LocalVersion()
+
+	p2p.mutex.RLock()
+	peers := make([]*Peer, 0, len(p2p.Peers))
+	for _, peer := range p2p.Peers {
+		peers = append(peers, peer)
+	}
+	p2p.mutex.RUnlock()
+
+	result := NetworkVersions{
+		Local:          local,
+		Versions:       map[string]int{local.Version: 1},
+		SchemaVersions: map[string]int{fmt.Sprintf("%d", local.SchemaVersion): 1},
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer *Peer) {
+			defer wg.Done()
+			peerVersion := PeerVersion{NodeID: peer.ID}
+
+			remote, err := p2p.requestVersion(peer)
+			if err != nil {
+				peerVersion.Error = err.Error()
+			} else {
+				peerVersion.Reachable = true
+				peerVersion.Version = remote
+				peerVersion.Warnings = versionWarnings(local, *remote)
+				peerVersion.Compatible = remote.SchemaVersion == local.SchemaVersion &&
+					remote.HashingVersion == local.HashingVersion &&
+					remote.ConsensusVersion == local.ConsensusVersion
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			result.Peers = append(result.Peers, peerVersion)
+			if remote != nil {
+				result.Versions[remote.Version]++
+				result.SchemaVersions[fmt.Sprintf("%d", remote.SchemaVersion)]++
+			}
+			for _, warning := range peerVersion.Warnings {
+				result.Warnings = append(result.Warnings, peer.ID+": "+warning)
+			}
+		}(peer)
+	}
+	wg.Wait()
+
+	sort.Slice(result.Peers, func(i, j int) bool { return result.Peers[i].NodeID < result.Peers[j].NodeID })
+	sort.Strings(result.Warnings)
+	return result
+}
+
+// versionWarnings compara la versión de un peer con la local
+func versionWarnings(local NodeVersion, remote NodeVersion) []string {
+	var warnings []string
+	if remote.SchemaVersion != local.SchemaVersion {
+		warnings = append(warnings, fmt.Sprintf("esquema incompatible: local %d, peer %d", local.SchemaVersion, remote.SchemaVersion))
+	}
+	if remote.HashingVersion != local.HashingVersion {
+		warnings = append(warnings, fmt.Sprintf("versión de hashing incompatible: local %d, peer %d", local.HashingVersion, remote.HashingVersion))
+	}
+	if remote.ConsensusVersion != local.ConsensusVersion {
+		warnings = append(warnings, fmt.Sprintf("versión de consenso incompatible: local %d, peer %d", local.ConsensusVersion, remote.ConsensusVersion))
+	}
+	if remote.Version != local.Version {
+		warnings = append(warnings, fmt.Sprintf("versión de software distinta: local %s, peer %s (actualización en curso)", local.Version, remote.Version))
+	}
+	if len(remote.Byzantine) > 0 {
+		warnings = append(warnings, fmt.Sprintf("compilación bizantina de pruebas: %v", remote.Byzantine))
+	}
+	return warnings
+}
+
+// requestVersion solicita la información de versión a un peer
+func (p2p *P2PNetwork) requestVersion(peer *Peer) (*NodeVersion, error) {
+	url := p2p.peerURL(peer, "/api/p2p/version")
+
+	client := p2p.peerClient(3 * time.Second)
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer respondió con status %d", resp.StatusCode)
+	}
+
+	var version NodeVersion
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
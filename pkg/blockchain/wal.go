@@ -0,0 +1,219 @@
+package blockchain
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Tamaño a partir del cual el WAL se trunca cuando no quedan entradas pendientes
+const walCheckpointSize = 1 << 20
+
+// Operaciones registradas en el WAL
+const (
+	walBegin  = "begin"
+	walCommit = "commit"
+	walAbort  = "abort"
+)
+
+// walRecord representa una línea del WAL
+type walRecord struct {
+	Seq    uint64        `json:"seq"`
+	Op     string        `json:"op"`
+	Block  *Block        `json:"block,omitempty"`
+	States []stateChange `json:"states,omitempty"`
+}
+
+// WAL es el registro de escritura anticipada de los bloques: cada bloque y el estado
+// que modifica se escriben y sincronizan a disco antes de aplicarse, de modo que una
+// caída a mitad de un append se pueda completar o descartar al reiniciar.
+type WAL struct {
+	path string
+	file *os.File
+	seq  uint64
+}
+
+// OpenWAL abre el WAL de la blockchain, recupera las entradas que quedaron sin
+// confirmar y revalida la cadena antes de aceptar nuevos bloques
+func (bc *Blockchain) OpenWAL(path string) error {
+	records, err := readWAL(path)
+	if err != nil {
+		return err
+	}
+
+	if err := bc.recoverWAL(records); err != nil {
+		return err
+	}
+
+	// Con todo recuperado el WAL se puede empezar de cero
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	bc.wal = &WAL{path: path, file: file}
+	return nil
+}
+
+// readWAL lee los registros del WAL; una última línea incompleta (escritura
+// interrumpida) se ignora porque su bloque nunca llegó a aplicarse
+func readWAL(path string) ([]walRecord, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []walRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	for scanner.Scan() {
+		var record walRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			logf("⚠️ Registro incompleto al final del WAL descartado\n")
+			break
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// recoverWAL completa o descarta los bloques que quedaron sin confirmar
+func (bc *Blockchain) recoverWAL(records []walRecord) error {
+	pending := make(map[uint64]walRecord)
+	for _, record := range records {
+		switch record.Op {
+		case walBegin:
+			if record.Block != nil {
+				pending[record.Seq] = record
+			}
+		case walCommit, walAbort:
+			delete(pending, record.Seq)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	seqs := make([]uint64, 0, len(pending))
+	for seq := range pending {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	for _, seq := range seqs {
+		record := pending[seq]
+		block := record.Block
+		chain := bc.Blocks()
+		height := len(chain)
+
+		switch {
+		case block.Index < height && chain[block.Index].Hash == block.Hash:
+			// El bloque alcanzó a guardarse; falta asegurar su estado
+			logf("🩹 WAL: completando estado del bloque %d\n", block.Index)
+		case block.Index == height && block.PreviousHash == bc.TipHash() && block.IsValid():
+			if err := bc.persistBlock(block); err != nil {
+				return fmt.Errorf("error reaplicando bloque %d del WAL: %v", block.Index, err)
+			}
+			bc.appendBlock(block)
+			logf("🩹 WAL: bloque %d reaplicado\n", block.Index)
+		default:
+			logf("🗑️ WAL: bloque %d descartado (no enlaza con la cadena)\n", block.Index)
+			continue
+		}
+
+		for _, change := range record.States {
+			if err := bc.store.Put(change.Bucket, change.Key, change.Value); err != nil {
+				return fmt.Errorf("error reaplicando estado %s/%s del WAL: %v", change.Bucket, change.Key, err)
+			}
+		}
+	}
+
+	if err := bc.VerifyChain(); err != nil {
+		return fmt.Errorf("la cadena no es válida tras recuperar el WAL: %v", err)
+	}
+
+	// Recargar el estado en memoria con lo reaplicado; los contratos se intercambian al
+	// terminar de leerlos
+	bc.setChain(nil)
+	return bc.loadFromStorage()
+}
+
+// Begin registra un bloque y su estado antes de aplicarlos y retorna su número de secuencia.
+// Sin WAL configurado no hace nada.
+func (w *WAL) Begin(block *Block, states []stateChange) (uint64, error) {
+	if w == nil {
+		return 0, nil
+	}
+	w.seq++
+	return w.seq, w.write(walRecord{Seq: w.seq, Op: walBegin, Block: block, States: states})
+}
+
+// Commit marca el bloque como aplicado y trunca el WAL si creció demasiado
+func (w *WAL) Commit(seq uint64) error {
+	if w == nil {
+		return nil
+	}
+	if err := w.write(walRecord{Seq: seq, Op: walCommit}); err != nil {
+		return err
+	}
+	return w.checkpoint()
+}
+
+// Abort marca el bloque como descartado para que no se reaplique al reiniciar
+func (w *WAL) Abort(seq uint64) {
+	if w == nil {
+		return
+	}
+	if err := w.write(walRecord{Seq: seq, Op: walAbort}); err != nil {
+		logf("❌ Error descartando entrada %d del WAL: %v\n", seq, err)
+	}
+}
+
+// Close cierra el archivo del WAL
+func (w *WAL) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.file.Close()
+}
+
+// write agrega un registro al WAL y lo sincroniza a disco
+func (w *WAL) write(record walRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := w.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// checkpoint trunca el WAL cuando supera el tamaño límite; los appends son
+// secuenciales, así que tras un commit no quedan entradas pendientes
+func (w *WAL) checkpoint() error {
+	info, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < walCheckpointSize {
+		return nil
+	}
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.file.Seek(0, 0); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
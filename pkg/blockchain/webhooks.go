@@ -0,0 +1,520 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+
+	"github.com/google/uuid"
+)
+
+// Webhooks salientes: los sistemas externos registran una URL para los eventos de todos
+// los contratos que les interesan (no uno por contrato, como las suscripciones). Cada
+// entrega se encola, se reintenta con espera exponencial y se firma con HMAC-SHA256 con
+// el secreto del webhook, para que el receptor verifique que viene de este nodo.
+
+// Eventos que se entregan a los webhooks
+const (
+	WebhookContractCreated  = "contract.created"
+	WebhookStepApproved     = "step.approved"
+	WebhookContractRejected = "contract.rejected"
+)
+
+// WebhookEvents son los eventos a los que se puede registrar un webhook
+var WebhookEvents = []string{WebhookContractCreated, WebhookStepApproved, WebhookContractRejected}
+
+// Encabezados de las entregas; la firma cubre "<timestamp>.<cuerpo>"
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Parámetros de entrega a los webhooks
+const (
+	webhookMaxAttempts = 10
+	webhookBaseBackoff = 2 * time.Second
+	webhookMaxBackoff  = 30 * time.Minute
+	webhookMaxFailures = 50 // Entregas fallidas que se conservan por webhook
+)
+
+// ProjectionWebhooks es la proyección que encola las entregas a los webhooks
+const ProjectionWebhooks = "webhooks"
+
+// Errores de WebhookManager
+var (
+	ErrWebhookNotFound = errors.New("webhook no encontrado")
+	ErrWebhookURL      = errors.New("URL del webhook inválida")
+	ErrWebhookEvents   = errors.New("el webhook debe registrar al menos un evento válido")
+)
+
+// Webhook es la URL registrada por un sistema externo con los eventos que recibe
+type Webhook struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Secret      string    `json:"secret,omitempty"` // La API solo lo entrega al crear el webhook o rotarlo
+}
+
+// WebhookStatus es un webhook con el estado de sus entregas, sin el secreto
+type WebhookStatus struct {
+	Webhook
+	Delivered   int              `json:"delivered"`
+	Pending     int              `json:"pending"`
+	NextAttempt *time.Time       `json:"next_attempt,omitempty"`
+	Failures    []WebhookFailure `json:"failures"`
+}
+
+// WebhookFailure es una entrega descartada tras agotar los reintentos
+type WebhookFailure struct {
+	DeliveryID string    `json:"delivery_id"`
+	Event      string    `json:"event"`
+	Attempts   int       `json:"attempts"`
+	Error      string    `json:"error"`
+	FailedAt   time.Time `json:"failed_at"`
+}
+
+// WebhookPayload es el cuerpo de cada entrega
+type WebhookPayload struct {
+	DeliveryID string                 `json:"delivery_id"` // Llave de idempotencia para el receptor
+	Event      string                 `json:"event"`
+	ContractID string                 `json:"contract_id"`
+	BlockHash  string                 `json:"block_hash"`
+	Height     int                    `json:"height"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// webhookDelivery es una entrega pendiente
+type webhookDelivery struct {
+	payload     WebhookPayload
+	attempts    int
+	nextAttempt time.Time
+}
+
+// webhookState son las entregas de un webhook; se entregan en orden
+type webhookState struct {
+	queue     []*webhookDelivery
+	delivered int
+	failures  []WebhookFailure
+}
+
+// WebhookManager guarda los webhooks registrados y les entrega los eventos de la cadena
+type WebhookManager struct {
+	blockchain *Blockchain
+	webhooks   map[string]*Webhook
+	states     map[string]*webhookState
+	client     *http.Client
+	mutex      sync.Mutex
+}
+
+// NewWebhookManager carga los webhooks guardados y los conecta al bus de eventos
+func NewWebhookManager(bc *Blockchain) *WebhookManager {
+	wm := &WebhookManager{
+		blockchain: bc,
+		webhooks:   make(map[string]*Webhook),
+		states:     make(map[string]*webhookState),
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+	bc.store.ForEach(storage.BucketWebhooks, func(key string, value []byte) error {
+		var webhook Webhook
+		if err := json.Unmarshal(value, &webhook); err == nil {
+			wm.webhooks[key] = &webhook
+			wm.states[key] = &webhookState{}
+		}
+		return nil
+	})
+	bc.Projections.Subscribe(ProjectionWebhooks, wm.enqueue)
+	return wm
+}
+
+// Create registra un webhook con un secreto nuevo, que solo se entrega en la respuesta
+func (wm *WebhookManager) Create(callbackURL string, events []string, description string, createdBy string) (*Webhook, error) {
+	if err := validateWebhook(callbackURL, events); err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	webhook := &Webhook{
+		ID:          uuid.New().String(),
+		URL:         callbackURL,
+		Events:      normalizeWebhookEvents(events),
+		Description: description,
+		Active:      true,
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Secret:      secret,
+	}
+
+	wm.mutex.Lock()
+	wm.webhooks[webhook.ID] = webhook
+	wm.states[webhook.ID] = &webhookState{}
+	wm.mutex.Unlock()
+	wm.blockchain.saveState(storage.BucketWebhooks, webhook.ID, webhook)
+
+	logf("🪝 Webhook %s registrado hacia %s\n", webhook.ID, callbackURL)
+	created := *webhook
+	return &created, nil
+}
+
+// WebhookUpdate son los cambios a un webhook; los campos nil no cambian
+type WebhookUpdate struct {
+	URL          *string  `json:"url"`
+	Events       []string `json:"events"`
+	Description  *string  `json:"description"`
+	Active       *bool    `json:"active"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+// Update modifica un webhook; si se rota el secreto, el nuevo solo se entrega en la respuesta
+func (wm *WebhookManager) Update(id string, update WebhookUpdate) (*Webhook, error) {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	webhook, exists := wm.webhooks[id]
+	if !exists {
+		return nil, ErrWebhookNotFound
+	}
+	callbackURL := webhook.URL
+	if update.URL != nil {
+		callbackURL = *update.URL
+	}
+	events := webhook.Events
+	if update.Events != nil {
+		events = update.Events
+	}
+	if err := validateWebhook(callbackURL, events); err != nil {
+		return nil, err
+	}
+	secret := webhook.Secret
+	if update.RotateSecret {
+		var err error
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	webhook.URL = callbackURL
+	webhook.Events = normalizeWebhookEvents(events)
+	if update.Description != nil {
+		webhook.Description = *update.Description
+	}
+	if update.Active != nil {
+		webhook.Active = *update.Active
+	}
+	webhook.Secret = secret
+	webhook.UpdatedAt = time.Now()
+	wm.blockchain.saveState(storage.BucketWebhooks, webhook.ID, webhook)
+
+	updated := *webhook
+	if !update.RotateSecret {
+		updated.Secret = ""
+	}
+	return &updated, nil
+}
+
+// Delete elimina el webhook y descarta sus entregas pendientes
+func (wm *WebhookManager) Delete(id string) error {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	if _, exists := wm.webhooks[id]; !exists {
+		return ErrWebhookNotFound
+	}
+	delete(wm.webhooks, id)
+	delete(wm.states, id)
+	wm.blockchain.deleteState(storage.BucketWebhooks, id)
+	logf("🪝 Webhook %s eliminado\n", id)
+	return nil
+}
+
+// Get retorna un webhook con el estado de sus entregas
+func (wm *WebhookManager) Get(id string) (*WebhookStatus, error) {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	webhook, exists := wm.webhooks[id]
+	if !exists {
+		return nil, ErrWebhookNotFound
+	}
+	status := wm.status(webhook)
+	return &status, nil
+}
+
+// List retorna los webhooks registrados, del más antiguo al más reciente
+func (wm *WebhookManager) List() []WebhookStatus {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	list := make([]WebhookStatus, 0, len(wm.webhooks))
+	for _, webhook := range wm.webhooks {
+		list = append(list, wm.status(webhook))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// status arma el estado del webhook sin su secreto; requiere el lock
+func (wm *WebhookManager) status(webhook *Webhook) WebhookStatus {
+	state := wm.states[webhook.ID]
+	status := WebhookStatus{
+		Webhook:   *webhook,
+		Delivered: state.delivered,
+		Pending:   len(state.queue),
+		Failures:  append([]WebhookFailure{}, state.failures...),
+	}
+	status.Secret = ""
+	if len(state.queue) > 0 {
+		next := state.queue[0].nextAttempt
+		status.NextAttempt = &next
+	}
+	return status
+}
+
+// enqueue encola el evento para los webhooks activos registrados a él. Los contratos
+// reservados no salen del nodo por esta vía.
+func (wm *WebhookManager) enqueue(event ChainEvent) {
+	payload := eventPayload(event)
+	if wm.blockchain.isReservedData(payload) {
+		return
+	}
+	name := webhookEvent(event.Type, payload)
+	if name == "" {
+		return
+	}
+	contractID := event.ContractID
+	if contractID == "" {
+		contractID, _ = payload["contract_id"].(string)
+	}
+
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+	for id, webhook := range wm.webhooks {
+		if !webhook.Active || !webhook.subscribed(name) {
+			continue
+		}
+		state := wm.states[id]
+		state.queue = append(state.queue, &webhookDelivery{
+			payload: WebhookPayload{
+				DeliveryID: uuid.New().String(),
+				Event:      name,
+				ContractID: contractID,
+				BlockHash:  event.BlockHash,
+				Height:     event.Height,
+				OccurredAt: event.Timestamp,
+				Data:       payload,
+			},
+			nextAttempt: time.Now(),
+		})
+	}
+}
+
+// webhookEvent traduce el tipo de transacción al evento de webhook; "" si no tiene uno
+func webhookEvent(eventType string, payload map[string]interface{}) string {
+	switch eventType {
+	case "CONTRACT_CREATION":
+		return WebhookContractCreated
+	case "VALIDATION":
+		// Un paso rechazado rechaza el contrato completo
+		if approved, _ := payload["approved"].(bool); approved {
+			return WebhookStepApproved
+		}
+		return WebhookContractRejected
+	}
+	return ""
+}
+
+// subscribed indica si el webhook recibe el evento
+func (webhook *Webhook) subscribed(event string) bool {
+	for _, registered := range webhook.Events {
+		if registered == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Run entrega periódicamente las entregas pendientes
+func (wm *WebhookManager) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		wm.deliverPending()
+	}
+}
+
+// deliverPending intenta la primera entrega pendiente de cada webhook; las siguientes
+// esperan a que salga para que el receptor las vea en orden
+func (wm *WebhookManager) deliverPending() {
+	type attempt struct {
+		webhookID string
+		url       string
+		secret    string
+		delivery  *webhookDelivery
+	}
+
+	now := time.Now()
+	var ready []attempt
+	wm.mutex.Lock()
+	for id, state := range wm.states {
+		if len(state.queue) > 0 && !state.queue[0].nextAttempt.After(now) {
+			webhook := wm.webhooks[id]
+			ready = append(ready, attempt{webhookID: id, url: webhook.URL, secret: webhook.Secret, delivery: state.queue[0]})
+		}
+	}
+	wm.mutex.Unlock()
+
+	for _, next := range ready {
+		err := wm.post(next.url, next.secret, next.delivery.payload)
+
+		wm.mutex.Lock()
+		state, exists := wm.states[next.webhookID]
+		if !exists || len(state.queue) == 0 || state.queue[0] != next.delivery {
+			// Eliminado o modificado mientras se entregaba
+			wm.mutex.Unlock()
+			continue
+		}
+		if err == nil {
+			state.queue = state.queue[1:]
+			state.delivered++
+			wm.mutex.Unlock()
+			continue
+		}
+
+		delivery := next.delivery
+		delivery.attempts++
+		if delivery.attempts >= webhookMaxAttempts {
+			logf("❌ Entrega %s al webhook %s descartada: %v\n", delivery.payload.DeliveryID, next.webhookID, err)
+			state.queue = state.queue[1:]
+			state.failures = append(state.failures, WebhookFailure{
+				DeliveryID: delivery.payload.DeliveryID,
+				Event:      delivery.payload.Event,
+				Attempts:   delivery.attempts,
+				Error:      err.Error(),
+				FailedAt:   time.Now(),
+			})
+			if len(state.failures) > webhookMaxFailures {
+				state.failures = state.failures[len(state.failures)-webhookMaxFailures:]
+			}
+		} else {
+			backoff := webhookBaseBackoff << uint(delivery.attempts-1)
+			if backoff > webhookMaxBackoff {
+				backoff = webhookMaxBackoff
+			}
+			delivery.nextAttempt = time.Now().Add(backoff)
+		}
+		wm.mutex.Unlock()
+	}
+}
+
+// post envía la entrega firmada al webhook
+func (wm *WebhookManager) post(callbackURL string, secret string, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, callbackURL, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, payload.Event)
+	req.Header.Set(WebhookDeliveryHeader, payload.DeliveryID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(secret, timestamp, body))
+
+	resp, err := wm.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook respondió con status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhook calcula la firma HMAC-SHA256 (hex) de una entrega; el receptor la recalcula
+// con su secreto, el encabezado de timestamp y el cuerpo recibido
+func SignWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateWebhook verifica la URL y que los eventos existan
+func validateWebhook(callbackURL string, events []string) error {
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrWebhookURL
+	}
+	if len(events) == 0 {
+		return ErrWebhookEvents
+	}
+	for _, event := range events {
+		known := false
+		for _, name := range WebhookEvents {
+			if event == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("%w: %q", ErrWebhookEvents, event)
+		}
+	}
+	return nil
+}
+
+// normalizeWebhookEvents quita eventos repetidos y los ordena
+func normalizeWebhookEvents(events []string) []string {
+	seen := make(map[string]bool, len(events))
+	normalized := make([]string, 0, len(events))
+	for _, event := range events {
+		if !seen[event] {
+			seen[event] = true
+			normalized = append(normalized, event)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// newWebhookSecret genera un secreto aleatorio de 256 bits
+func newWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(raw), nil
+}
@@ -3,6 +3,7 @@ package blockchain
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,13 +12,24 @@ import (
 // WorkflowManager maneja el flujo de validación de contratos
 type WorkflowManager struct {
 	blockchain *Blockchain
+	templates  map[string]*WorkflowTemplate
+	versions   map[string][]WorkflowTemplate
+	mutex      sync.RWMutex
+	// ObservationResponseDays es el plazo para responder las observaciones de control
+	ObservationResponseDays int
 }
 
-// NewWorkflowManager crea un nuevo gestor de flujo de trabajo
+// NewWorkflowManager crea un nuevo gestor de flujo de trabajo con las plantillas guardadas
 func NewWorkflowManager(bc *Blockchain) *WorkflowManager {
-	return &WorkflowManager{
+	wm := &WorkflowManager{
 		blockchain: bc,
+		templates:  make(map[string]*WorkflowTemplate),
+		versions:   make(map[string][]WorkflowTemplate),
+
+		ObservationResponseDays: DefaultObservationResponseDays,
 	}
+	wm.loadTemplates()
+	return wm
 }
 
 // GetWorkflowSteps define los pasos del flujo de trabajo SECOP
@@ -54,6 +66,10 @@ func (wm *WorkflowManager) InitializeContractWorkflow(contract *Contract) error
 			Timestamp:  time.Time{}, // Se establecerá cuando se valide
 		}
 	}
+
+	// Marcar los pasos que la plantilla de la modalidad aprueba automáticamente; el
+	// contrato queda fijado a la versión vigente aunque la plantilla cambie después
+	pinTemplate(contract, wm.templateFor(contract))
 	
 	contract.CurrentStep = 1
 	contract.Status = StatusDraft
@@ -65,11 +81,17 @@ func (wm *WorkflowManager) InitializeContractWorkflow(contract *Contract) error
 	return nil
 }
 
-// ValidateStep valida un paso específico del flujo de trabajo
-func (wm *WorkflowManager) ValidateStep(contractID string, stepNumber int, validatorID string, validatorName string, role AdminRole, approved bool, comments string) error {
-	contract, exists := wm.blockchain.Contracts[contractID]
-	if !exists {
-		return errors.New("contrato no encontrado")
+// ValidateStep valida un paso específico del flujo de trabajo. La decisión debe venir
+// firmada con una llave registrada del validador (ver StepApprovalPayload); si adjunta el
+// acta del comité, la firma cubre también su hash. La decisión se toma con el candado del
+// contrato y sobre una copia que entra en vigencia con el bloque de la validación.
+func (wm *WorkflowManager) ValidateStep(contractID string, stepNumber int, validatorID string, validatorName string, role AdminRole, approved bool, comments string, signature StepSignature, act *StepAct) error {
+	unlock := wm.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := wm.blockchain.editContract(contractID)
+	if err != nil {
+		return err
 	}
 	
 	// Verificar que es el paso correcto
@@ -86,51 +108,121 @@ func (wm *WorkflowManager) ValidateStep(contractID string, stepNumber int, valid
 	if step.Role != role {
 		return fmt.Errorf("rol incorrecto para este paso. Esperado: %s, recibido: %s", step.Role, role)
 	}
+
+	// Verificar que otro revisor no haya tomado el paso
+	if err := checkClaim(contract, validatorID); err != nil {
+		return err
+	}
+
+	// Verificar que el validador declaró no tener conflicto de interés en el paso
+	if err := wm.blockchain.Conflicts.checkDeclaration(contractID, stepNumber, validatorID); err != nil {
+		return err
+	}
+
+	// Verificar el acta del comité, obligatoria para aprobar si la plantilla la exige
+	actHash := ""
+	if act != nil {
+		if err := act.validate(); err != nil {
+			return err
+		}
+		actHash = act.Hash
+	} else if approved && step.ActRequired {
+		return fmt.Errorf("el paso %d requiere el hash del acta firmada del comité", stepNumber)
+	}
+
+	// Verificar la firma del validador sobre su decisión
+	if err := wm.blockchain.verifyStepSignature(contractID, stepNumber, validatorID, role, approved, signature, actHash); err != nil {
+		return err
+	}
 	
 	// Actualizar el paso
 	step.ValidatorID = validatorID
 	step.ValidatorName = validatorName
 	step.Timestamp = time.Now()
 	step.Comments = comments
+	step.DigitalSign = signature.Signature
+	step.SignatureKeyID = signature.KeyID
+	signedAt := signature.SignedAt
+	step.SignedAt = &signedAt
+	step.Act = act
 	
+	actNote := ""
+	if act != nil && act.Reference != "" {
+		actNote = fmt.Sprintf(" (%s, hash %s)", act.Reference, act.Hash)
+	} else if act != nil {
+		actNote = fmt.Sprintf(" (acta %s)", act.Hash)
+	}
 	if approved {
 		step.Status = ValidationApproved
-		wm.addAuditEntry(contract, "STEP_APPROVED", validatorID, role, fmt.Sprintf("Paso %d aprobado: %s", stepNumber, comments))
-		
-		// Avanzar al siguiente paso o completar el flujo
-		if stepNumber < len(contract.ValidationSteps) {
-			contract.CurrentStep++
-			contract.Status = wm.getStatusForStep(contract.CurrentStep)
-		} else {
-			// Todos los pasos completados
-			contract.Status = StatusAuthorizedForPublication
-			wm.addAuditEntry(contract, "WORKFLOW_COMPLETED", validatorID, role, "Flujo de validación completado")
-		}
+		wm.addAuditEntry(contract, "STEP_APPROVED", validatorID, role, fmt.Sprintf("Paso %d aprobado: %s%s", stepNumber, comments, actNote))
+		wm.advance(contract, stepNumber, validatorID, role)
 	} else {
 		step.Status = ValidationRejected
 		contract.Status = StatusRejected
-		wm.addAuditEntry(contract, "STEP_REJECTED", validatorID, role, fmt.Sprintf("Paso %d rechazado: %s", stepNumber, comments))
+		wm.addAuditEntry(contract, "STEP_REJECTED", validatorID, role, fmt.Sprintf("Paso %d rechazado: %s%s", stepNumber, comments, actNote))
 	}
 	
 	contract.UpdatedAt = time.Now()
+	contract.Claim = nil
 	
 	// Crear bloque para registrar la validación
 	blockData := map[string]interface{}{
-		"type":        "VALIDATION",
-		"contract_id": contractID,
-		"step":        stepNumber,
-		"validator":   validatorID,
-		"role":        string(role),
-		"approved":    approved,
-		"comments":    comments,
-		"timestamp":   time.Now(),
+		"type":           "VALIDATION",
+		"contract_id":    contractID,
+		"step":           stepNumber,
+		"validator":      validatorID,
+		"validator_name": validatorName,
+		"role":           string(role),
+		"approved":       approved,
+		"comments":       comments,
+		"signature":      signature.Signature,
+		"signature_kid":  signature.KeyID,
+		"signed_at":      signature.SignedAt,
+		"timestamp":      time.Now(),
+	}
+	if act != nil {
+		blockData["act_hash"] = act.Hash
+		blockData["act_reference"] = act.Reference
 	}
 	
-	return wm.blockchain.AddBlock(blockData)
+	wm.blockchain.stageContract(contract)
+	if err := wm.blockchain.AddBlock(blockData); err != nil {
+		wm.blockchain.unstageContract(contractID)
+		wm.blockchain.releaseSignature(signature.Signature)
+		return err
+	}
+
+	// Si los pasos siguientes son automáticos, aprobarlos de una vez
+	_, err = wm.applyAutoApprovals(contract)
+	return err
+}
+
+// advance avanza el contrato al siguiente paso o completa el flujo tras aprobar stepNumber
+func (wm *WorkflowManager) advance(contract *Contract, stepNumber int, validatorID string, role AdminRole) {
+	if advanceStep(contract, stepNumber) {
+		wm.addAuditEntry(contract, "WORKFLOW_COMPLETED", validatorID, role, "Flujo de validación completado")
+	}
+}
+
+// advanceStep mueve el contrato al paso siguiente a stepNumber y retorna true si con él se
+// completaron todos los pasos
+func advanceStep(contract *Contract, stepNumber int) bool {
+	if stepNumber < len(contract.ValidationSteps) {
+		contract.CurrentStep++
+		contract.Status = statusForStep(contract.CurrentStep)
+		return false
+	}
+	contract.Status = StatusAuthorizedForPublication
+	return true
 }
 
 // getStatusForStep retorna el estado correspondiente al paso actual
 func (wm *WorkflowManager) getStatusForStep(stepNumber int) ContractStatus {
+	return statusForStep(stepNumber)
+}
+
+// statusForStep retorna el estado del contrato mientras espera el paso indicado
+func statusForStep(stepNumber int) ContractStatus {
 	switch stepNumber {
 	case 1:
 		return StatusDraft
@@ -149,32 +241,45 @@ func (wm *WorkflowManager) getStatusForStep(stepNumber int) ContractStatus {
 	}
 }
 
-// AddAuditObservation agrega una observación de auditoría (control externo)
-func (wm *WorkflowManager) AddAuditObservation(contractID string, auditorID string, role AdminRole, observation string) error {
-	contract, exists := wm.blockchain.Contracts[contractID]
-	if !exists {
-		return errors.New("contrato no encontrado")
-	}
-	
+// AddAuditObservation agrega una observación de auditoría (control externo); la entidad
+// responsable debe responderla antes del plazo
+func (wm *WorkflowManager) AddAuditObservation(contractID string, auditorID string, role AdminRole, observation string) (*AuditObservation, error) {
 	// Verificar que es un rol de control externo
 	if role != RoleComptroller && role != RoleProsecutor && role != RoleCitizen {
-		return errors.New("rol no autorizado para auditoría")
+		return nil, errors.New("rol no autorizado para auditoría")
+	}
+
+	unlock := wm.blockchain.lockContract(contractID)
+	defer unlock()
+	contract, err := wm.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
 	}
 	
+	filed := newObservation(auditorID, role, observation, wm.ObservationResponseDays)
+	contract.Observations = append(contract.Observations, filed)
+	contract.UpdatedAt = filed.FiledAt
 	wm.addAuditEntry(contract, "AUDIT_OBSERVATION", auditorID, role, observation)
 	
 	// Las observaciones de auditoría no bloquean el proceso
 	// Solo se registran para transparencia
 	blockData := map[string]interface{}{
-		"type":        "AUDIT_OBSERVATION",
-		"contract_id": contractID,
-		"auditor":     auditorID,
-		"role":        string(role),
-		"observation": observation,
-		"timestamp":   time.Now(),
+		"type":           "AUDIT_OBSERVATION",
+		"contract_id":    contractID,
+		"observation_id": filed.ID,
+		"auditor":        auditorID,
+		"role":           string(role),
+		"observation":    observation,
+		"due_at":         filed.DueAt,
+		"timestamp":      time.Now(),
 	}
 	
-	return wm.blockchain.AddBlock(blockData)
+	wm.blockchain.stageContract(contract)
+	if err := wm.blockchain.AddBlock(blockData); err != nil {
+		wm.blockchain.unstageContract(contractID)
+		return nil, err
+	}
+	return &filed, nil
 }
 
 // addAuditEntry agrega una entrada al registro de auditoría
@@ -194,7 +299,7 @@ func (wm *WorkflowManager) addAuditEntry(contract *Contract, action string, user
 
 // GetContractWorkflowStatus retorna el estado actual del flujo de trabajo
 func (wm *WorkflowManager) GetContractWorkflowStatus(contractID string) (*WorkflowStatus, error) {
-	contract, exists := wm.blockchain.Contracts[contractID]
+	contract, exists := wm.blockchain.Contract(contractID)
 	if !exists {
 		return nil, errors.New("contrato no encontrado")
 	}
@@ -238,7 +343,7 @@ func (wm *WorkflowManager) getNextRole(contract *Contract) AdminRole {
 
 // GetWorkflowStatus obtiene el estado actual del flujo de trabajo de un contrato
 func (wm *WorkflowManager) GetWorkflowStatus(contractID string) (map[string]interface{}, error) {
-	contract, exists := wm.blockchain.Contracts[contractID]
+	contract, exists := wm.blockchain.Contract(contractID)
 	if !exists {
 		return nil, errors.New("contrato no encontrado")
 	}
@@ -264,6 +369,7 @@ func (wm *WorkflowManager) GetWorkflowStatus(contractID string) (map[string]inte
 		"status":           string(contract.Status),
 		"validation_steps": contract.ValidationSteps,
 		"audit_trail":      contract.AuditTrail,
+		"acts":             contract.StepActs(),
 		"created_at":       contract.CreatedAt,
 		"updated_at":       contract.UpdatedAt,
 	}
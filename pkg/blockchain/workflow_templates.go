@@ -0,0 +1,346 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"secop-blockchain/pkg/blockchain/storage"
+)
+
+// Identificador con el que quedan registradas las validaciones automáticas
+const SystemValidatorID = "SYSTEM"
+
+// TemplateMigrationBlockType es el tipo de bloque que registra el cambio de la versión de
+// plantilla de un contrato en curso
+const TemplateMigrationBlockType = "WORKFLOW_TEMPLATE_MIGRATION"
+
+// WorkflowTemplate ajusta el flujo de validación para una modalidad de contratación.
+// Los contratos de la modalidad con monto hasta MaxAmount tienen los pasos indicados
+// aprobados automáticamente por el sistema, citando la regla que lo permite. Cada cambio
+// Los pasos de ActRequiredSteps (los de comité) solo se aprueban adjuntando el acta firmada.
+// Cada cambio crea una versión nueva; los contratos quedan fijados a la versión con la que
+// se crearon.
+type WorkflowTemplate struct {
+	ContractType      string    `json:"contract_type"`
+	Version           int       `json:"version"`
+	MaxAmount         float64   `json:"max_amount"`
+	AutoApprovedSteps []int     `json:"auto_approved_steps"`
+	ActRequiredSteps  []int     `json:"act_required_steps,omitempty"`
+	RuleReference     string    `json:"rule_reference"`
+	UpdatedBy         string    `json:"updated_by"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// SetTemplate publica una nueva versión de la plantilla de una modalidad. Solo afecta a
+// los contratos creados después; los que están en curso conservan la versión con la que se
+// crearon hasta que un administrador los migre.
+func (wm *WorkflowManager) SetTemplate(template WorkflowTemplate) (*WorkflowTemplate, error) {
+	if template.ContractType == "" {
+		return nil, errors.New("la plantilla requiere la modalidad de contratación")
+	}
+	if template.MaxAmount <= 0 {
+		return nil, errors.New("el monto máximo debe ser mayor que cero")
+	}
+	if len(template.AutoApprovedSteps) == 0 && len(template.ActRequiredSteps) == 0 {
+		return nil, errors.New("la plantilla debe indicar al menos un paso")
+	}
+	if len(template.AutoApprovedSteps) > 0 && template.RuleReference == "" {
+		return nil, errors.New("la plantilla debe citar la regla que permite la aprobación automática")
+	}
+
+	totalSteps := len(wm.GetWorkflowSteps())
+	seen := make(map[int]bool)
+	for _, step := range template.AutoApprovedSteps {
+		// El paso 1 es la radicación del proyecto y siempre la hace un funcionario
+		if step < 2 || step > totalSteps {
+			return nil, fmt.Errorf("paso %d no se puede aprobar automáticamente (pasos 2 a %d)", step, totalSteps)
+		}
+		if seen[step] {
+			return nil, fmt.Errorf("paso %d repetido", step)
+		}
+		seen[step] = true
+	}
+	actSteps := make(map[int]bool)
+	for _, step := range template.ActRequiredSteps {
+		if step < 2 || step > totalSteps {
+			return nil, fmt.Errorf("paso %d no puede exigir acta de comité (pasos 2 a %d)", step, totalSteps)
+		}
+		// Un paso aprobado por el sistema no tiene comité que levante el acta
+		if seen[step] {
+			return nil, fmt.Errorf("paso %d no puede ser automático y exigir acta a la vez", step)
+		}
+		if actSteps[step] {
+			return nil, fmt.Errorf("paso %d repetido", step)
+		}
+		actSteps[step] = true
+	}
+	if template.AutoApprovedSteps == nil {
+		template.AutoApprovedSteps = []int{}
+	}
+	sort.Ints(template.AutoApprovedSteps)
+	sort.Ints(template.ActRequiredSteps)
+	template.UpdatedAt = time.Now()
+
+	wm.mutex.Lock()
+	template.Version = len(wm.versions[template.ContractType]) + 1
+	wm.templates[template.ContractType] = &template
+	wm.versions[template.ContractType] = append(wm.versions[template.ContractType], template)
+	wm.mutex.Unlock()
+
+	wm.blockchain.saveState(storage.BucketWorkflowTemplateVersions, templateVersionKey(template.ContractType, template.Version), &template)
+	wm.blockchain.saveState(storage.BucketWorkflowTemplates, template.ContractType, &template)
+	logf("🧾 Plantilla de flujo para %s v%d: pasos %v automáticos hasta %.2f (%s)\n",
+		template.ContractType, template.Version, template.AutoApprovedSteps, template.MaxAmount, template.RuleReference)
+	return &template, nil
+}
+
+// RemoveTemplate retira la plantilla vigente de una modalidad. Sus versiones se conservan
+// para los contratos que quedaron fijados a ellas.
+func (wm *WorkflowManager) RemoveTemplate(contractType string) error {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	if _, exists := wm.templates[contractType]; !exists {
+		return errors.New("plantilla no encontrada")
+	}
+	delete(wm.templates, contractType)
+	wm.blockchain.deleteState(storage.BucketWorkflowTemplates, contractType)
+	return nil
+}
+
+// Templates lista las plantillas configuradas
+func (wm *WorkflowManager) Templates() []WorkflowTemplate {
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+
+	templates := make([]WorkflowTemplate, 0, len(wm.templates))
+	for _, template := range wm.templates {
+		templates = append(templates, *template)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ContractType < templates[j].ContractType })
+	return templates
+}
+
+// TemplateVersions lista las versiones publicadas de la plantilla de una modalidad
+func (wm *WorkflowManager) TemplateVersions(contractType string) []WorkflowTemplate {
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+
+	return append([]WorkflowTemplate{}, wm.versions[contractType]...)
+}
+
+// TemplateVersion retorna una versión publicada de la plantilla de una modalidad
+func (wm *WorkflowManager) TemplateVersion(contractType string, version int) (*WorkflowTemplate, error) {
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+
+	versions := wm.versions[contractType]
+	if version < 1 || version > len(versions) {
+		return nil, fmt.Errorf("la plantilla de %s no tiene versión %d", contractType, version)
+	}
+	template := versions[version-1]
+	return &template, nil
+}
+
+// loadTemplates carga las plantillas guardadas y su historial de versiones
+func (wm *WorkflowManager) loadTemplates() {
+	wm.blockchain.store.ForEach(storage.BucketWorkflowTemplateVersions, func(key string, value []byte) error {
+		var template WorkflowTemplate
+		if err := json.Unmarshal(value, &template); err == nil {
+			wm.versions[template.ContractType] = append(wm.versions[template.ContractType], template)
+		}
+		return nil
+	})
+	for _, versions := range wm.versions {
+		sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	}
+
+	wm.blockchain.store.ForEach(storage.BucketWorkflowTemplates, func(key string, value []byte) error {
+		var template WorkflowTemplate
+		if err := json.Unmarshal(value, &template); err != nil {
+			return nil
+		}
+		// Las plantillas guardadas antes del versionado pasan a ser su versión 1
+		if template.Version == 0 && len(wm.versions[key]) == 0 {
+			template.Version = 1
+			wm.versions[key] = []WorkflowTemplate{template}
+			wm.blockchain.saveState(storage.BucketWorkflowTemplateVersions, templateVersionKey(key, 1), &template)
+			wm.blockchain.saveState(storage.BucketWorkflowTemplates, key, &template)
+		}
+		wm.templates[key] = &template
+		return nil
+	})
+}
+
+// templateVersionKey es la llave de una versión de plantilla; el número va con ceros a la
+// izquierda para que las versiones queden en orden
+func templateVersionKey(contractType string, version int) string {
+	return fmt.Sprintf("%s/%06d", contractType, version)
+}
+
+// templateFor retorna la plantilla vigente que aplica al contrato, o nil si sigue el flujo completo
+func (wm *WorkflowManager) templateFor(contract *Contract) *WorkflowTemplate {
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+
+	template, exists := wm.templates[contract.ContractType]
+	if !exists || !template.appliesTo(contract) {
+		return nil
+	}
+	return template
+}
+
+// appliesTo indica si el monto del contrato está dentro del alcance de la plantilla
+func (template *WorkflowTemplate) appliesTo(contract *Contract) bool {
+	return contract.Amount > 0 && contract.Amount <= template.MaxAmount
+}
+
+// pinTemplate marca en los pasos pendientes del contrato los que la versión de plantilla
+// aprueba automáticamente o que exigen acta de comité, y fija esa versión en el contrato. Con template nil el
+// contrato queda con el flujo completo. Los pasos ya decididos no cambian.
+func pinTemplate(contract *Contract, template *WorkflowTemplate) {
+	contract.TemplateVersion = 0
+	for i := range contract.ValidationSteps {
+		step := &contract.ValidationSteps[i]
+		if step.Status == ValidationPending {
+			step.AutoApproved = false
+			step.RuleReference = ""
+			step.ActRequired = false
+		}
+	}
+	if template == nil {
+		return
+	}
+
+	contract.TemplateVersion = template.Version
+	for _, stepNumber := range template.AutoApprovedSteps {
+		if stepNumber > len(contract.ValidationSteps) {
+			continue
+		}
+		step := &contract.ValidationSteps[stepNumber-1]
+		if step.Status == ValidationPending {
+			step.AutoApproved = true
+			step.RuleReference = template.RuleReference
+		}
+	}
+	for _, stepNumber := range template.ActRequiredSteps {
+		if stepNumber > len(contract.ValidationSteps) {
+			continue
+		}
+		step := &contract.ValidationSteps[stepNumber-1]
+		if step.Status == ValidationPending {
+			step.ActRequired = true
+		}
+	}
+}
+
+// MigrateContractTemplate cambia la versión de plantilla de un contrato en curso. Es una
+// acción administrativa explícita: la migración queda registrada en la cadena y solo
+// afecta a los pasos que aún no se han decidido. La versión 0 deja el flujo completo.
+func (wm *WorkflowManager) MigrateContractTemplate(contractID string, version int, adminID string, role AdminRole, reason string) (*Contract, error) {
+	unlock := wm.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := wm.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Status == StatusRejected || contract.Status == StatusAuthorizedForPublication {
+		return nil, errors.New("el flujo del contrato ya terminó")
+	}
+	if reason == "" {
+		return nil, errors.New("la migración requiere una justificación")
+	}
+	if version == contract.TemplateVersion {
+		return nil, fmt.Errorf("el contrato ya está en la versión %d de la plantilla", version)
+	}
+
+	var template *WorkflowTemplate
+	if version != 0 {
+		if template, err = wm.TemplateVersion(contract.ContractType, version); err != nil {
+			return nil, err
+		}
+		if !template.appliesTo(contract) {
+			return nil, fmt.Errorf("el monto del contrato supera el máximo de la versión %d (%.2f)", version, template.MaxAmount)
+		}
+	}
+
+	previous := contract.TemplateVersion
+	pinTemplate(contract, template)
+	contract.UpdatedAt = time.Now()
+	wm.addAuditEntry(contract, "TEMPLATE_MIGRATED", adminID, role,
+		fmt.Sprintf("Plantilla migrada de la versión %d a la %d: %s", previous, version, reason))
+
+	blockData := map[string]interface{}{
+		"type":          TemplateMigrationBlockType,
+		"contract_id":   contract.ID,
+		"contract_type": contract.ContractType,
+		"from_version":  previous,
+		"to_version":    version,
+		"migrated_by":   adminID,
+		"reason":        reason,
+		"timestamp":     contract.UpdatedAt,
+	}
+	wm.blockchain.stageContract(contract)
+	if err := wm.blockchain.AddBlock(blockData); err != nil {
+		wm.blockchain.unstageContract(contractID)
+		return nil, err
+	}
+	logf("🧾 Contrato %s migrado de la plantilla v%d a la v%d por %s\n", contract.ID, previous, version, adminID)
+
+	// El paso actual puede haber quedado como automático con la nueva versión
+	return wm.applyAutoApprovals(contract)
+}
+
+// applyAutoApprovals aprueba a nombre del sistema los pasos marcados por la plantilla a
+// medida que el flujo llega a ellos, registrando cada uno como una validación en la cadena.
+// Requiere el candado del contrato; cada aprobación se arma sobre una copia que entra en
+// vigencia con su bloque. Retorna el contrato vigente al terminar.
+func (wm *WorkflowManager) applyAutoApprovals(current *Contract) (*Contract, error) {
+	for current.CurrentStep <= len(current.ValidationSteps) &&
+		current.Status != StatusRejected && current.Status != StatusAuthorizedForPublication {
+		next := current.ValidationSteps[current.CurrentStep-1]
+		if !next.AutoApproved || next.Status != ValidationPending {
+			break
+		}
+
+		contract, err := cloneContract(current)
+		if err != nil {
+			return current, err
+		}
+		step := &contract.ValidationSteps[contract.CurrentStep-1]
+
+		comments := "Aprobado automáticamente según " + step.RuleReference
+		step.ValidatorID = SystemValidatorID
+		step.ValidatorName = "Aprobación automática"
+		step.Timestamp = time.Now()
+		step.Comments = comments
+		step.Status = ValidationApproved
+		wm.addAuditEntry(contract, "STEP_AUTO_APPROVED", SystemValidatorID, step.Role, fmt.Sprintf("Paso %d: %s", step.StepNumber, comments))
+		wm.advance(contract, step.StepNumber, SystemValidatorID, step.Role)
+		contract.UpdatedAt = time.Now()
+
+		blockData := map[string]interface{}{
+			"type":           "VALIDATION",
+			"contract_id":    contract.ID,
+			"step":           step.StepNumber,
+			"validator":      SystemValidatorID,
+			"role":           string(step.Role),
+			"approved":       true,
+			"auto_approved":  true,
+			"rule_reference": step.RuleReference,
+			"comments":       comments,
+			"timestamp":      step.Timestamp,
+		}
+		wm.blockchain.stageContract(contract)
+		if err := wm.blockchain.AddBlock(blockData); err != nil {
+			wm.blockchain.unstageContract(contract.ID)
+			return current, err
+		}
+		current = contract
+	}
+	return current, nil
+}
@@ -0,0 +1,262 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReviewClaim representa la toma de un contrato por un revisor para el paso actual
+type ReviewClaim struct {
+	ReviewerID   string    `json:"reviewer_id"`
+	ReviewerName string    `json:"reviewer_name"`
+	Role         AdminRole `json:"role"`
+	Step         int       `json:"step"`
+	ClaimedAt    time.Time `json:"claimed_at"`
+	AssignedBy   string    `json:"assigned_by,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// WorkItem representa un contrato pendiente en la cola de un rol
+type WorkItem struct {
+	ContractID  string         `json:"contract_id"`
+	EntityName  string         `json:"entity_name"`
+	Description string         `json:"description"`
+	Amount      float64        `json:"amount"`
+	Status      ContractStatus `json:"status"`
+	Step        int            `json:"step"`
+	WaitingFor  string         `json:"waiting_for"`
+	Claim       *ReviewClaim   `json:"claim,omitempty"`
+}
+
+// WorkQueue coordina qué revisor de un comité trabaja cada contrato para evitar
+// revisiones duplicadas en paralelo. Las tomas se liberan solas tras la inactividad.
+type WorkQueue struct {
+	blockchain *Blockchain
+	timeout    time.Duration
+	mutex      sync.Mutex
+}
+
+// NewWorkQueue crea la cola de trabajo con el tiempo máximo de inactividad por toma
+func NewWorkQueue(bc *Blockchain, timeout time.Duration) *WorkQueue {
+	return &WorkQueue{blockchain: bc, timeout: timeout}
+}
+
+// Items retorna los contratos pendientes del rol, los más antiguos primero, con su toma vigente
+func (wq *WorkQueue) Items(role AdminRole) []WorkItem {
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	contracts := wq.blockchain.GetContractsByRole(role)
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].UpdatedAt.Before(contracts[j].UpdatedAt)
+	})
+
+	now := time.Now()
+	items := make([]WorkItem, 0, len(contracts))
+	for _, contract := range contracts {
+		item := WorkItem{
+			ContractID:  contract.ID,
+			EntityName:  contract.EntityName,
+			Description: contract.Description,
+			Amount:      contract.Amount,
+			Status:      contract.Status,
+			Step:        contract.CurrentStep,
+			WaitingFor:  now.Sub(contract.UpdatedAt).Round(time.Minute).String(),
+		}
+		if activeClaim(contract, now) {
+			claim := *contract.Claim
+			item.Claim = &claim
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// Claim toma un contrato para el revisor; si ya es suyo, renueva la toma
+func (wq *WorkQueue) Claim(contractID string, reviewerID string, reviewerName string, role AdminRole) (*ReviewClaim, error) {
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	return wq.claim(contractID, reviewerID, reviewerName, role, "")
+}
+
+// ClaimNext toma hasta count contratos libres de la cola del rol, los más antiguos primero
+func (wq *WorkQueue) ClaimNext(role AdminRole, reviewerID string, reviewerName string, count int) ([]ReviewClaim, error) {
+	if count <= 0 {
+		return nil, errors.New("cantidad inválida")
+	}
+
+	items := wq.Items(role)
+
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	var claimed []ReviewClaim
+	for _, item := range items {
+		if len(claimed) == count {
+			break
+		}
+		if item.Claim != nil {
+			continue
+		}
+		claim, err := wq.claim(item.ContractID, reviewerID, reviewerName, role, "")
+		if err != nil {
+			continue
+		}
+		claimed = append(claimed, *claim)
+	}
+	return claimed, nil
+}
+
+// Assign asigna un contrato a un revisor en nombre de quien coordina el comité
+func (wq *WorkQueue) Assign(contractID string, assigneeID string, assigneeName string, role AdminRole, assignedBy string) (*ReviewClaim, error) {
+	if assignedBy == "" {
+		return nil, errors.New("se requiere quien asigna")
+	}
+
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	return wq.claim(contractID, assigneeID, assigneeName, role, assignedBy)
+}
+
+// claim registra la toma; requiere el lock de la cola
+func (wq *WorkQueue) claim(contractID string, reviewerID string, reviewerName string, role AdminRole, assignedBy string) (*ReviewClaim, error) {
+	unlock := wq.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := wq.blockchain.editContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if reviewerID == "" {
+		return nil, errors.New("se requiere el revisor")
+	}
+	if contract.CurrentStep < 1 || contract.CurrentStep > len(contract.ValidationSteps) {
+		return nil, errors.New("el contrato no tiene pasos pendientes")
+	}
+	step := contract.ValidationSteps[contract.CurrentStep-1]
+	if step.Role != role || step.Status != ValidationPending || contract.Status == StatusRejected {
+		return nil, fmt.Errorf("el contrato no está pendiente para el rol %s", role)
+	}
+	if wq.blockchain.Conflicts.IsRecused(contractID, contract.CurrentStep, reviewerID) {
+		return nil, fmt.Errorf("%s declaró conflicto de interés en el paso %d", reviewerName, contract.CurrentStep)
+	}
+
+	now := time.Now()
+	renewal := activeClaim(contract, now) && contract.Claim.ReviewerID == reviewerID
+	if activeClaim(contract, now) && !renewal && assignedBy == "" {
+		return nil, fmt.Errorf("el contrato ya fue tomado por %s", contract.Claim.ReviewerName)
+	}
+
+	if renewal && assignedBy == "" {
+		contract.Claim.ExpiresAt = now.Add(wq.timeout)
+	} else {
+		contract.Claim = &ReviewClaim{
+			ReviewerID:   reviewerID,
+			ReviewerName: reviewerName,
+			Role:         role,
+			Step:         contract.CurrentStep,
+			ClaimedAt:    now,
+			AssignedBy:   assignedBy,
+			ExpiresAt:    now.Add(wq.timeout),
+		}
+		if assignedBy != "" {
+			wq.blockchain.WorkflowManager.addAuditEntry(contract, "REVIEW_ASSIGNED", assignedBy, role,
+				fmt.Sprintf("Paso %d asignado a %s", contract.CurrentStep, reviewerName))
+		} else {
+			wq.blockchain.WorkflowManager.addAuditEntry(contract, "REVIEW_CLAIMED", reviewerID, role,
+				fmt.Sprintf("Paso %d tomado por %s", contract.CurrentStep, reviewerName))
+		}
+	}
+
+	wq.blockchain.saveContract(contract)
+	claim := *contract.Claim
+	return &claim, nil
+}
+
+// Release libera la toma de un contrato; solo puede hacerlo el revisor que lo tomó
+func (wq *WorkQueue) Release(contractID string, reviewerID string) error {
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	unlock := wq.blockchain.lockContract(contractID)
+	defer unlock()
+
+	contract, err := wq.blockchain.editContract(contractID)
+	if err != nil {
+		return err
+	}
+	if !activeClaim(contract, time.Now()) {
+		return errors.New("el contrato no está tomado")
+	}
+	if contract.Claim.ReviewerID != reviewerID {
+		return errors.New("solo el revisor que tomó el contrato puede liberarlo")
+	}
+
+	wq.blockchain.WorkflowManager.addAuditEntry(contract, "REVIEW_RELEASED", reviewerID, contract.Claim.Role,
+		fmt.Sprintf("Paso %d liberado por %s", contract.Claim.Step, contract.Claim.ReviewerName))
+	contract.Claim = nil
+	wq.blockchain.saveContract(contract)
+	return nil
+}
+
+// ReleaseExpired libera las tomas sin actividad y las que quedaron de pasos ya resueltos
+func (wq *WorkQueue) ReleaseExpired() []string {
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	now := time.Now()
+	var released []string
+	for id, current := range wq.blockchain.contractMap() {
+		if current.Claim == nil || activeClaim(current, now) {
+			continue
+		}
+
+		unlock := wq.blockchain.lockContract(id)
+		contract, err := wq.blockchain.editContract(id)
+		if err != nil || contract.Claim == nil || activeClaim(contract, now) {
+			unlock()
+			continue
+		}
+		if contract.Claim.Step == contract.CurrentStep && contract.Status != StatusRejected {
+			wq.blockchain.WorkflowManager.addAuditEntry(contract, "REVIEW_AUTO_RELEASED", contract.Claim.ReviewerID, contract.Claim.Role,
+				fmt.Sprintf("Paso %d liberado por inactividad de %s", contract.Claim.Step, contract.Claim.ReviewerName))
+			logf("⌛ Contrato %s liberado por inactividad de %s\n", contract.ID, contract.Claim.ReviewerName)
+		}
+		contract.Claim = nil
+		wq.blockchain.saveContract(contract)
+		unlock()
+		released = append(released, contract.ID)
+	}
+	return released
+}
+
+// Run libera periódicamente las tomas vencidas
+func (wq *WorkQueue) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		wq.ReleaseExpired()
+	}
+}
+
+// activeClaim indica si el contrato tiene una toma vigente para su paso actual
+func activeClaim(contract *Contract, now time.Time) bool {
+	return contract.Claim != nil &&
+		contract.Claim.Step == contract.CurrentStep &&
+		contract.Status != StatusRejected &&
+		now.Before(contract.Claim.ExpiresAt)
+}
+
+// checkClaim verifica que el paso no esté tomado por otro revisor
+func checkClaim(contract *Contract, validatorID string) error {
+	if activeClaim(contract, time.Now()) && contract.Claim.ReviewerID != validatorID {
+		return fmt.Errorf("el paso está tomado por %s", contract.Claim.ReviewerName)
+	}
+	return nil
+}
@@ -0,0 +1,560 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/websocket"
+)
+
+// Transporte WebSocket entre peers: en vez de un POST por bloque, cada par de nodos
+// mantiene un canal persistente por el que viajan mensajes tipados en ambos sentidos. Si
+// el canal no está abierto o su cola está llena, los envíos vuelven al transporte HTTP.
+// Para no abrir dos canales por par marca primero el nodo con el ID menor; el otro solo
+// marca si pasado un tiempo no lo han llamado (p. ej. porque el peer aún no lo conoce), y
+// si llegan a quedar dos canales se conserva el que abrió el nodo con el ID menor.
+
+// Tipos de los mensajes del canal entre peers
+const (
+	PeerMessageNewBlock      = "NEW_BLOCK"
+	PeerMessageVote          = "VOTE"
+	PeerMessagePeerAnnounce  = "PEER_ANNOUNCE"
+	PeerMessageChainRequest  = "CHAIN_REQUEST"
+	PeerMessageChainResponse = "CHAIN_RESPONSE"
+)
+
+// Parámetros del canal entre peers
+const (
+	peerSendQueue      = 256              // Mensajes en espera por canal antes de rechazar envíos
+	peerReceiveQueue   = 64               // Mensajes recibidos en espera de procesarse
+	peerWriteTimeout   = 10 * time.Second // Tiempo máximo para escribir un mensaje
+	peerReadTimeout    = 90 * time.Second // Sin mensajes en este tiempo el canal se da por caído
+	peerHeartbeat      = 30 * time.Second // Cada cuánto se reanuncia el nodo para mantener vivo el canal
+	peerRequestTimeout = 15 * time.Second // Espera de la respuesta a un CHAIN_REQUEST
+	peerDialGrace      = 20 * time.Second // Espera del nodo con el ID mayor antes de marcar él
+	peerMinBackoff     = time.Second
+	peerMaxBackoff     = time.Minute
+)
+
+// errNoPeerConn indica que no hay canal abierto con el peer
+var errNoPeerConn = errors.New("sin canal WebSocket con el peer")
+
+// PeerMessage es el sobre de los mensajes del canal entre peers
+type PeerMessage struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"` // Correlaciona CHAIN_REQUEST con su respuesta
+	From    string          `json:"from"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// chainRequest pide los bloques desde una altura, como GET /api/p2p/blocks
+type chainRequest struct {
+	FromHeight int `json:"from_height"`
+	Limit      int `json:"limit"`
+}
+
+// PeerConnStatus es el estado del canal con un peer
+type PeerConnStatus struct {
+	PeerID      string    `json:"peer_id"`
+	Transport   string    `json:"transport"`             // websocket o libp2p
+	Libp2pPeer  string    `json:"libp2p_peer,omitempty"` // Peer ID libp2p de la conexión
+	Outbound    bool      `json:"outbound"`              // Este nodo abrió el canal
+	ConnectedAt time.Time `json:"connected_at"`
+	Queued      int       `json:"queued"`
+	Sent        int       `json:"sent"`
+	Received    int       `json:"received"`
+	Rejected    int       `json:"rejected"` // Envíos rechazados con la cola llena
+}
+
+// peerConn es un canal abierto con un peer
+type peerConn struct {
+	peerID      string
+	ws          *websocket.Conn
+	outbound    bool
+	verified    bool // La identidad del peer está respaldada por TLS mutuo
+	connectedAt time.Time
+	outgoing    chan PeerMessage
+	incoming    chan PeerMessage
+	done        chan struct{}
+	closeOnce   sync.Once
+	sent        int
+	received    int
+	rejected    int
+	mutex       sync.Mutex
+}
+
+// enqueue pone el mensaje en la cola del canal sin bloquear; con la cola llena el
+// mensaje se rechaza para que el llamador use otro transporte
+func (pc *peerConn) enqueue(message PeerMessage) error {
+	select {
+	case <-pc.done:
+		return errNoPeerConn
+	default:
+	}
+	select {
+	case pc.outgoing <- message:
+		return nil
+	default:
+		pc.mutex.Lock()
+		pc.rejected++
+		pc.mutex.Unlock()
+		return fmt.Errorf("cola del canal con %s llena", pc.peerID)
+	}
+}
+
+// close cierra el canal una sola vez
+func (pc *peerConn) close() {
+	pc.closeOnce.Do(func() {
+		close(pc.done)
+		pc.ws.Close()
+	})
+}
+
+// peerBackoff controla los reintentos de conexión con un peer
+type peerBackoff struct {
+	since   time.Time // Desde cuándo se intenta tener canal con el peer
+	dialing bool
+	delay   time.Duration
+	next    time.Time
+}
+
+// wsTransport guarda los canales abiertos y las solicitudes en espera de respuesta
+type wsTransport struct {
+	conns   map[string]*peerConn
+	pending map[string]chan PeerMessage
+	backoff map[string]*peerBackoff
+	mutex   sync.Mutex
+}
+
+// EnableWebSocket activa el canal WebSocket con los peers; sin activarlo todo el tráfico
+// sigue por HTTP
+func (p2p *P2PNetwork) EnableWebSocket() {
+	p2p.ws = &wsTransport{
+		conns:   make(map[string]*peerConn),
+		pending: make(map[string]chan PeerMessage),
+		backoff: make(map[string]*peerBackoff),
+	}
+	p2p.backend = &webSocketTransport{p2p: p2p, fallback: p2p.backend}
+}
+
+// RunWebSocket revisa cada every los peers sin canal y los reconecta respetando su espera
+func (p2p *P2PNetwork) RunWebSocket(every time.Duration) {
+	if p2p.ws == nil {
+		return
+	}
+	p2p.connectPeers()
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p2p.connectPeers()
+	}
+}
+
+// connectPeers abre canal con los peers activos que aún no tienen, salvo los vetados
+func (p2p *P2PNetwork) connectPeers() {
+	now := time.Now()
+	for _, peer := range p2p.GetActivePeers() {
+		if p2p.Reputation.IsBanned(peer.ID) {
+			continue
+		}
+		p2p.ws.mutex.Lock()
+		_, connected := p2p.ws.conns[peer.ID]
+		backoff, exists := p2p.ws.backoff[peer.ID]
+		if !exists || connected {
+			backoff = &peerBackoff{since: now}
+			p2p.ws.backoff[peer.ID] = backoff
+		}
+		turn := p2p.NodeID < peer.ID || now.Sub(backoff.since) >= peerDialGrace
+		due := !connected && turn && !backoff.dialing && !now.Before(backoff.next)
+		if due {
+			backoff.dialing = true
+		}
+		p2p.ws.mutex.Unlock()
+
+		if due {
+			go p2p.dialPeer(peer)
+		}
+	}
+}
+
+// dialPeer abre el canal con un peer; si falla, duplica la espera antes del siguiente intento
+func (p2p *P2PNetwork) dialPeer(peer *Peer) {
+	ws, err := p2p.dialWebSocket(peer)
+
+	p2p.ws.mutex.Lock()
+	backoff := p2p.ws.backoff[peer.ID]
+	backoff.dialing = false
+	if err != nil {
+		if backoff.delay < peerMinBackoff {
+			backoff.delay = peerMinBackoff
+		} else if backoff.delay *= 2; backoff.delay > peerMaxBackoff {
+			backoff.delay = peerMaxBackoff
+		}
+		backoff.next = time.Now().Add(backoff.delay)
+	} else {
+		backoff.delay = 0
+	}
+	retry := backoff.delay
+	p2p.ws.mutex.Unlock()
+
+	if err != nil {
+		logf("❌ Error abriendo canal WebSocket con %s (reintento en %s): %v\n", peer.ID, retry, err)
+		return
+	}
+	p2p.serveConn(peer.ID, ws, true, p2p.tlsConfig != nil)
+}
+
+// dialWebSocket conecta con la ruta WebSocket del peer, con TLS mutuo si está configurado
+func (p2p *P2PNetwork) dialWebSocket(peer *Peer) (*websocket.Conn, error) {
+	scheme := "ws"
+	if p2p.tlsConfig != nil {
+		scheme = "wss"
+	}
+	// Como en known-peers, el nodo se anuncia para que un peer que no lo conoce lo agregue
+	query := url.Values{}
+	query.Set("address", p2p.Address)
+	query.Set("port", p2p.Port)
+	target := fmt.Sprintf("%s://%s:%s/api/p2p/ws?%s", scheme, peer.Address, peer.Port, query.Encode())
+	origin := fmt.Sprintf("http://%s:%s/", p2p.Address, p2p.Port)
+
+	config, err := websocket.NewConfig(target, origin)
+	if err != nil {
+		return nil, err
+	}
+	config.TlsConfig = p2p.tlsConfig
+	config.Dialer = &net.Dialer{Timeout: 5 * time.Second}
+	config.Header.Set("X-Node-ID", p2p.NodeID)
+	return websocket.DialConfig(config)
+}
+
+// ServePeerConn atiende el canal que abrió un peer hasta que se cierre. Solo se aceptan
+// peers conocidos; a uno desconocido que se anuncia se le intenta descubrir para que su
+// siguiente intento sí se acepte. verified indica que peerID viene del certificado del
+// peer; los bloques de un canal sin verificar se procesan como anónimos (ver ReceiveBlock).
+func (p2p *P2PNetwork) ServePeerConn(ws *websocket.Conn, peerID string, verified bool, address, port string) {
+	if p2p.ws == nil {
+		ws.Close()
+		return
+	}
+	p2p.mutex.RLock()
+	_, known := p2p.Peers[peerID]
+	p2p.mutex.RUnlock()
+	if !known {
+		logf("⚠️ Canal WebSocket rechazado: %s no es un peer conocido\n", peerID)
+		ws.Close()
+		go p2p.DiscoverPeer(peerID, address, port)
+		return
+	}
+	if p2p.Reputation.IsBanned(peerID) {
+		logf("⛔ Canal WebSocket rechazado: %s está vetado\n", peerID)
+		ws.Close()
+		return
+	}
+	p2p.serveConn(peerID, ws, false, verified)
+}
+
+// serveConn registra el canal, anuncia este nodo y procesa los mensajes entrantes hasta
+// que el canal se cae
+func (p2p *P2PNetwork) serveConn(peerID string, ws *websocket.Conn, outbound bool, verified bool) {
+	pc := &peerConn{
+		peerID:      peerID,
+		ws:          ws,
+		outbound:    outbound,
+		verified:    verified,
+		connectedAt: time.Now(),
+		outgoing:    make(chan PeerMessage, peerSendQueue),
+		incoming:    make(chan PeerMessage, peerReceiveQueue),
+		done:        make(chan struct{}),
+	}
+
+	p2p.ws.mutex.Lock()
+	previous := p2p.ws.conns[peerID]
+	if previous != nil && p2p.preferredConn(previous) && !p2p.preferredConn(pc) {
+		p2p.ws.mutex.Unlock()
+		ws.Close()
+		return
+	}
+	p2p.ws.conns[peerID] = pc
+	p2p.ws.mutex.Unlock()
+	if previous != nil {
+		previous.close()
+	}
+	logf("🔌 Canal WebSocket con %s abierto\n", peerID)
+
+	go p2p.writeLoop(pc)
+	go p2p.processLoop(pc)
+	p2p.readLoop(pc)
+
+	pc.close()
+	p2p.ws.mutex.Lock()
+	if p2p.ws.conns[peerID] == pc {
+		delete(p2p.ws.conns, peerID)
+	}
+	p2p.ws.mutex.Unlock()
+	logf("🔌 Canal WebSocket con %s cerrado\n", peerID)
+}
+
+// preferredConn indica si el canal lo abrió el nodo con el ID menor; ambos extremos
+// llegan a la misma conclusión y conservan el mismo canal
+func (p2p *P2PNetwork) preferredConn(pc *peerConn) bool {
+	return pc.outbound == (p2p.NodeID < pc.peerID)
+}
+
+// writeLoop escribe los mensajes de la cola y reanuncia el nodo periódicamente
+func (p2p *P2PNetwork) writeLoop(pc *peerConn) {
+	heartbeat := time.NewTicker(peerHeartbeat)
+	defer heartbeat.Stop()
+
+	pc.enqueue(p2p.announcement())
+	for {
+		var message PeerMessage
+		select {
+		case <-pc.done:
+			return
+		case message = <-pc.outgoing:
+		case <-heartbeat.C:
+			message = p2p.announcement()
+		}
+
+		pc.ws.SetWriteDeadline(time.Now().Add(peerWriteTimeout))
+		if err := websocket.JSON.Send(pc.ws, message); err != nil {
+			logf("❌ Error escribiendo en el canal con %s: %v\n", pc.peerID, err)
+			pc.close()
+			return
+		}
+		pc.mutex.Lock()
+		pc.sent++
+		pc.mutex.Unlock()
+	}
+}
+
+// readLoop lee los mensajes entrantes. Las respuestas se entregan de inmediato a quien
+// las espera; el resto pasa en orden a processLoop, y con su cola llena se deja de leer,
+// de modo que un nodo lento frena al emisor a través de TCP en vez de acumular.
+func (p2p *P2PNetwork) readLoop(pc *peerConn) {
+	for {
+		pc.ws.SetReadDeadline(time.Now().Add(peerReadTimeout))
+		var message PeerMessage
+		if err := websocket.JSON.Receive(pc.ws, &message); err != nil {
+			return
+		}
+		pc.mutex.Lock()
+		pc.received++
+		pc.mutex.Unlock()
+		p2p.touchPeer(pc.peerID)
+
+		if message.Type == PeerMessageChainResponse {
+			p2p.deliverResponse(message)
+			continue
+		}
+		select {
+		case pc.incoming <- message:
+		case <-pc.done:
+			return
+		}
+	}
+}
+
+// processLoop procesa en orden los mensajes recibidos hasta que se cierra el canal
+func (p2p *P2PNetwork) processLoop(pc *peerConn) {
+	for {
+		select {
+		case message := <-pc.incoming:
+			p2p.handleMessage(pc, message)
+		case <-pc.done:
+			return
+		}
+	}
+}
+
+// deliverResponse entrega la respuesta a la solicitud que la espera
+func (p2p *P2PNetwork) deliverResponse(message PeerMessage) {
+	p2p.ws.mutex.Lock()
+	waiting, exists := p2p.ws.pending[message.ID]
+	delete(p2p.ws.pending, message.ID)
+	p2p.ws.mutex.Unlock()
+	if exists {
+		waiting <- message
+	}
+}
+
+// handleMessage despacha un mensaje según su tipo
+func (p2p *P2PNetwork) handleMessage(pc *peerConn, message PeerMessage) {
+	switch message.Type {
+	case PeerMessageNewBlock:
+		var block Block
+		if err := json.Unmarshal(message.Payload, &block); err != nil {
+			return
+		}
+		if p2p.Maintenance != nil && p2p.Maintenance.IsEnabled() {
+			logf("⏸️ Bloque %s de %s ignorado: nodo en mantenimiento\n", block.Hash, pc.peerID)
+			return
+		}
+		sender := ""
+		if pc.verified {
+			sender = pc.peerID
+		}
+		if err := p2p.ReceiveBlock(block, sender); err != nil {
+			logf("❌ Bloque %s de %s rechazado: %v\n", block.Hash, pc.peerID, err)
+			return
+		}
+		// Igual que por HTTP, el validador responde con su voto de finalización
+		if p2p.Blockchain.HasBlock(block.Hash) {
+			if vote := p2p.Vote(block.Hash); vote != nil {
+				p2p.sendMessage(pc.peerID, PeerMessageVote, "", vote)
+			}
+		}
+
+	case PeerMessageTransaction:
+		var tx MempoolTransaction
+		if err := json.Unmarshal(message.Payload, &tx); err != nil {
+			return
+		}
+		if p2p.Maintenance != nil && p2p.Maintenance.IsEnabled() {
+			return
+		}
+		if err := p2p.ReceiveTransaction(tx, pc.peerID); err != nil {
+			logf("❌ Transacción %s de %s rechazada: %v\n", tx.ID, pc.peerID, err)
+		}
+
+	case PeerMessageVote:
+		var vote FinalityVote
+		if err := json.Unmarshal(message.Payload, &vote); err == nil {
+			p2p.recordVote(vote)
+		}
+
+	case PeerMessagePeerAnnounce:
+		var announced KnownPeer
+		if err := json.Unmarshal(message.Payload, &announced); err == nil && announced.ID != pc.peerID {
+			go p2p.DiscoverPeer(announced.ID, announced.Address, announced.Port)
+		}
+
+	case PeerMessageChainRequest:
+		var request chainRequest
+		response := PeerMessage{Type: PeerMessageChainResponse, ID: message.ID, From: p2p.NodeID}
+		if err := json.Unmarshal(message.Payload, &request); err != nil {
+			response.Error = err.Error()
+		} else if page, err := p2p.Blockchain.BlocksFrom(request.FromHeight, request.Limit); err != nil {
+			response.Error = err.Error()
+		} else {
+			page.Blocks = p2p.BlocksFor(pc.peerID, page.Blocks)
+			response.Payload, _ = json.Marshal(page)
+		}
+		pc.enqueue(response)
+	}
+}
+
+// announcement es el mensaje con el que el nodo se anuncia por el canal
+func (p2p *P2PNetwork) announcement() PeerMessage {
+	payload, _ := json.Marshal(KnownPeer{ID: p2p.NodeID, Address: p2p.Address, Port: p2p.Port, Active: true, LastSeen: time.Now()})
+	return PeerMessage{Type: PeerMessagePeerAnnounce, From: p2p.NodeID, Payload: payload}
+}
+
+// touchPeer registra que el peer sigue vivo
+func (p2p *P2PNetwork) touchPeer(peerID string) {
+	p2p.mutex.Lock()
+	defer p2p.mutex.Unlock()
+	if peer, exists := p2p.Peers[peerID]; exists {
+		peer.LastSeen = time.Now()
+	}
+}
+
+// sendMessage encola un mensaje para el peer; falla si no hay canal o su cola está llena
+func (p2p *P2PNetwork) sendMessage(peerID, messageType, id string, payload interface{}) error {
+	if p2p.ws == nil {
+		return errNoPeerConn
+	}
+	p2p.ws.mutex.Lock()
+	pc, exists := p2p.ws.conns[peerID]
+	p2p.ws.mutex.Unlock()
+	if !exists {
+		return errNoPeerConn
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return pc.enqueue(PeerMessage{Type: messageType, ID: id, From: p2p.NodeID, Payload: data})
+}
+
+// requestPageOverWS pide una página de bloques por el canal del peer y espera la respuesta
+func (p2p *P2PNetwork) requestPageOverWS(peerID string, from int) (*BlocksPage, error) {
+	if p2p.ws == nil {
+		return nil, errNoPeerConn
+	}
+	id := uuid.New().String()
+	waiting := make(chan PeerMessage, 1)
+	p2p.ws.mutex.Lock()
+	p2p.ws.pending[id] = waiting
+	p2p.ws.mutex.Unlock()
+	defer func() {
+		p2p.ws.mutex.Lock()
+		delete(p2p.ws.pending, id)
+		p2p.ws.mutex.Unlock()
+	}()
+
+	if err := p2p.sendMessage(peerID, PeerMessageChainRequest, id, chainRequest{FromHeight: from, Limit: SyncPageSize}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-waiting:
+		if response.Error != "" {
+			return nil, errors.New(response.Error)
+		}
+		var page BlocksPage
+		if err := json.Unmarshal(response.Payload, &page); err != nil {
+			return nil, err
+		}
+		return &page, nil
+	case <-time.After(peerRequestTimeout):
+		return nil, fmt.Errorf("%s no respondió la solicitud de bloques", peerID)
+	}
+}
+
+// PeerConnections retorna el estado de los canales WebSocket y las conexiones libp2p abiertos
+func (p2p *P2PNetwork) PeerConnections() []PeerConnStatus {
+	statuses := []PeerConnStatus{}
+	if p2p.libp2p != nil {
+		statuses = append(statuses, p2p.libp2pConnections()...)
+	}
+	if p2p.ws == nil {
+		return statuses
+	}
+
+	p2p.ws.mutex.Lock()
+	for _, pc := range p2p.ws.conns {
+		pc.mutex.Lock()
+		statuses = append(statuses, PeerConnStatus{
+			PeerID:      pc.peerID,
+			Transport:   "websocket",
+			Outbound:    pc.outbound,
+			ConnectedAt: pc.connectedAt,
+			Queued:      len(pc.outgoing),
+			Sent:        pc.sent,
+			Received:    pc.received,
+			Rejected:    pc.rejected,
+		})
+		pc.mutex.Unlock()
+	}
+	p2p.ws.mutex.Unlock()
+
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].PeerID < statuses[j].PeerID })
+	return statuses
+}
+
+// WebSocketEnabled indica si el canal WebSocket con los peers está activo
+func (p2p *P2PNetwork) WebSocketEnabled() bool {
+	return p2p.ws != nil
+}